@@ -83,9 +83,36 @@ type ConfigSyncOptions struct {
 	Tuning
 }
 
+// VaultOptions has the pki secrets engine of HashiCorp Vault issue the
+// certificates of the site CA instead of a self generated CA
+type VaultOptions struct {
+	Address  string
+	PkiPath  string
+	Role     string
+	AuthRole string
+}
+
+// CertManagerOptions has cert-manager issue the site certificates, the CAs
+// being Certificates of the issuer and the CA issuers of the other certificates
+type CertManagerOptions struct {
+	Issuer     string
+	IssuerKind string
+}
+
+const (
+	CertManagerIssuerKind        = "Issuer"
+	CertManagerClusterIssuerKind = "ClusterIssuer"
+)
+
 type FlowCollectorOptions struct {
 	Tuning
-	FlowRecordTtl time.Duration
+	FlowRecordTtl       time.Duration
+	TlsMinVersion       string
+	TlsCipherSuites     string
+	TlsCurvePreferences string
+	AcmeDomains         string
+	AcmeEmail           string
+	AcmeDirectory       string
 }
 
 type PrometheusServerOptions struct {
@@ -95,6 +122,8 @@ type PrometheusServerOptions struct {
 	User           string
 	Password       string
 	PodAnnotations map[string]string
+	RetentionTime  string
+	RetentionSize  string
 }
 
 type SiteConfigSpec struct {
@@ -111,13 +140,19 @@ type SiteConfigSpec struct {
 	AuthMode                 string
 	User                     string
 	Password                 string
+	OpenIDIssuer             string
+	OpenIDClientId           string
+	SAMLIdPMetadata          string
+	SAMLConsoleURL           string
 	Ingress                  string
 	IngressAnnotations       map[string]string
 	ConsoleIngress           string
 	IngressHost              string
+	CertificateHosts         []string
 	Replicas                 int32
 	SiteControlled           bool
 	CreateNetworkPolicy      bool
+	ComponentNetworkPolicies bool
 	Annotations              map[string]string
 	Labels                   map[string]string
 	Router                   RouterOptions
@@ -125,11 +160,14 @@ type SiteConfigSpec struct {
 	ConfigSync               ConfigSyncOptions
 	FlowCollector            FlowCollectorOptions
 	PrometheusServer         PrometheusServerOptions
+	Vault                    VaultOptions
+	CertManager              CertManagerOptions
 	Platform                 Platform
 	RunAsUser                int64
 	RunAsGroup               int64
 	EnableClusterPermissions bool
 	EnableSkupperEvents      bool
+	SyslogAddress            string
 }
 
 const (
@@ -201,7 +239,7 @@ func (s *SiteConfigSpec) getConsoleIngress() string {
 
 func ValidIngressOptions(platform Platform) []string {
 	switch platform {
-	case PlatformPodman:
+	case PlatformPodman, PlatformDocker:
 		return []string{IngressPodmanExternal, IngressNoneString}
 	default:
 		return []string{IngressRouteString, IngressLoadBalancerString, IngressNodePortString, IngressNginxIngressString, IngressContourHttpProxyString, IngressKubernetes, IngressNoneString}
@@ -210,10 +248,10 @@ func ValidIngressOptions(platform Platform) []string {
 
 func ValidAuthOptions(platform Platform) []string {
 	switch platform {
-	case PlatformPodman:
+	case PlatformPodman, PlatformDocker:
 		return []string{"internal", "unsecured"}
 	default:
-		return []string{"internal", "unsecured", "openshift"}
+		return []string{"internal", "unsecured", "openshift", "openid", "saml", "kubernetes"}
 	}
 }
 
@@ -298,6 +336,35 @@ type GatewayInspectResponse struct {
 	Listeners  map[string]GatewayEndpoint
 }
 
+// CertificateRotateOptions selects the certificates regenerated by
+// CertificatesRotate, the CAs and the certificates they sign
+type CertificateRotateOptions struct {
+	CA     bool
+	Leaf   bool
+	DryRun bool
+}
+
+// CertificateInfo describes a certificate of the site, CA being the secret
+// of the CA signing it, empty for the CAs themselves
+type CertificateInfo struct {
+	Name        string
+	CA          string
+	Subject     string
+	Hosts       []string
+	NotAfter    time.Time
+	Rotated     bool
+	Deployments []string
+}
+
+// RevokedCertificate is a link credential issued by the site CA and revoked
+// through the revocation list of the site
+type RevokedCertificate struct {
+	Subject     string
+	Serial      string
+	Fingerprint string
+	RevokedAt   time.Time
+}
+
 type VanClientInterface interface {
 	RouterCreate(ctx context.Context, options SiteConfig) error
 	RouterInspect(ctx context.Context) (*RouterInspectResponse, error)
@@ -316,6 +383,8 @@ type VanClientInterface interface {
 	ConnectorTokenCreateFile(ctx context.Context, subject string, secretFile string) error
 	TokenClaimCreate(ctx context.Context, name string, password []byte, expiry time.Duration, uses int) (*corev1.Secret, bool, error)
 	TokenClaimCreateFile(ctx context.Context, name string, password []byte, expiry time.Duration, uses int, secretFile string) error
+	MutualTokenClaimCreateFile(ctx context.Context, name string, password []byte, expiry time.Duration, uses int, secretFile string) error
+	TokenClaimCreateFileWithPolicy(ctx context.Context, name string, password []byte, expiry time.Duration, uses int, mutual bool, policy ClaimPolicy, secretFile string) error
 	ServiceInterfaceCreate(ctx context.Context, service *ServiceInterface) error
 	ServiceInterfaceInspect(ctx context.Context, address string) (*ServiceInterface, error)
 	ServiceInterfaceList(ctx context.Context) ([]*ServiceInterface, error)
@@ -345,10 +414,14 @@ type VanClientInterface interface {
 	SkupperEvents(verbose bool) (*bytes.Buffer, error)
 	SkupperCheckService(service string, verbose bool) (*bytes.Buffer, error)
 	SkupperPolicies(verbose bool) (*bytes.Buffer, error)
+	SkupperLogLevel(component string, level string) (*bytes.Buffer, error)
 	GetNamespace() string
 	GetVersion(component string, name string) string
 	GetIngressDefault() string
 	RevokeAccess(ctx context.Context) error
+	RevokeLinkCredentials(ctx context.Context, match string) ([]RevokedCertificate, error)
+	CertificatesRotate(ctx context.Context, options CertificateRotateOptions) ([]CertificateInfo, error)
 	NetworkStatus(ctx context.Context) (*network.NetworkStatusInfo, error)
+	NetworkStatusHistory(ctx context.Context) ([]network.NetworkSnapshot, error)
 	GetConsoleUrl(namespace string) (string, error)
 }
@@ -28,13 +28,17 @@ import (
 
 const (
 	// NamespaceDefault means the VAN is in the  skupper namespace which is applied when not specified by clients
-	NamespaceDefault           string = "skupper"
-	DefaultVanName             string = "skupper"
-	DefaultSiteName            string = "skupper-site"
-	ClusterLocalPostfix        string = ".svc.cluster.local"
-	SiteConfigMapName          string = "skupper-site"
-	NetworkStatusConfigMapName string = "skupper-network-status"
-	SiteLeaderLockName         string = "skupper-site-leader"
+	NamespaceDefault            string = "skupper"
+	DefaultVanName              string = "skupper"
+	DefaultSiteName             string = "skupper-site"
+	ClusterLocalPostfix         string = ".svc.cluster.local"
+	SiteConfigMapName           string = "skupper-site"
+	NetworkStatusConfigMapName  string = "skupper-network-status"
+	SiteLeaderLockName          string = "skupper-site-leader"
+	FlowCollectorLeaderLockName string = "skupper-flow-collector-leader"
+	ConsolePreferencesConfigMap string = "skupper-console-preferences"
+	SiteEventsConfigMap         string = "skupper-site-events"
+	SlosConfigMap               string = "skupper-slos"
 )
 
 const DefaultTimeoutDuration = time.Second * 120
@@ -129,10 +133,12 @@ const (
 	ControllerConfigPath                 string = "/etc/messaging/"
 	ControllerServiceName                string = "skupper"
 	ControllerPodmanContainerName        string = "skupper-controller-podman"
+	ControllerLivenessPort               int32  = 8182
 	FlowCollectorContainerName           string = "flow-collector"
 	PrometheusDeploymentName             string = "skupper-prometheus"
 	PrometheusComponentName              string = "prometheus"
 	PrometheusContainerName              string = "prometheus-server"
+	MetricsExporterContainerName         string = "skupper-metrics-exporter"
 	PrometheusServiceAccountName         string = "skupper-prometheus"
 	PrometheusServiceName                string = "skupper-prometheus"
 	PrometheusRoleBindingName            string = "skupper-prometheus"
@@ -170,6 +176,18 @@ var ControllerPolicyRule = []rbacv1.PolicyRule{
 		APIGroups: []string{"networking.k8s.io"},
 		Resources: []string{"ingresses"},
 	},
+	//needed for flow collector leader election
+	{
+		Verbs:     []string{"get", "create", "update"},
+		APIGroups: []string{"coordination.k8s.io"},
+		Resources: []string{"leases"},
+	},
+	//needed for flow collector roles from kubernetes rbac
+	{
+		Verbs:     []string{"create"},
+		APIGroups: []string{"authorization.k8s.io"},
+		Resources: []string{"localsubjectaccessreviews"},
+	},
 }
 
 var ControllerRoutesCustomHostPolicyRule = []rbacv1.PolicyRule{
@@ -196,6 +214,16 @@ var ClusterControllerPolicyRules = []rbacv1.PolicyRule{
 		Resources: []string{"nodes"},
 		Verbs:     []string{"get", "list", "watch"},
 	},
+	{
+		APIGroups: []string{"authentication.k8s.io"},
+		Resources: []string{"tokenreviews"},
+		Verbs:     []string{"create"},
+	},
+	{
+		APIGroups: []string{"authorization.k8s.io"},
+		Resources: []string{"subjectaccessreviews"},
+		Verbs:     []string{"create"},
+	},
 }
 
 var ClusterControllerExtendedPolicyRules = []rbacv1.PolicyRule{
@@ -213,8 +241,15 @@ const (
 	LocalCaSecret            string = "skupper-local-ca"
 	SiteServerSecret         string = "skupper-site-server"
 	SiteCaSecret             string = "skupper-site-ca"
+	SiteCrlSecret            string = "skupper-site-crl"
 	ConsoleServerSecret      string = "skupper-console-certs"
 	ConsoleUsersSecret       string = "skupper-console-users"
+	ConsoleLdapSecret        string = "skupper-console-ldap"
+	ConsoleClientCASecret    string = "skupper-console-client-ca"
+	ConsoleRolesSecret       string = "skupper-console-roles"
+	SyslogCASecret           string = "skupper-syslog-ca"
+	ConsoleTokensSecret      string = "skupper-console-tokens"
+	ConsoleSessionSecret     string = "skupper-console-session"
 	PrometheusServerSecret   string = "skupper-prometheus-certs"
 	OauthRouterConsoleSecret string = "skupper-router-console-certs"
 	ServiceCaSecret          string = "skupper-service-ca"
@@ -223,63 +258,80 @@ const (
 
 // Skupper qualifiers
 const (
-	BaseQualifier               string = "skupper.io"
-	InternalQualifier           string = "internal." + BaseQualifier
-	AddressQualifier            string = BaseQualifier + "/address"
-	PortQualifier               string = BaseQualifier + "/port"
-	ProxyQualifier              string = BaseQualifier + "/proxy"
-	TargetServiceQualifier      string = BaseQualifier + "/target"
-	HeadlessQualifier           string = BaseQualifier + "/headless"
-	IngressModeQualifier        string = BaseQualifier + "/ingress"
-	CpuRequestAnnotation        string = BaseQualifier + "/cpu-request"
-	MemoryRequestAnnotation     string = BaseQualifier + "/memory-request"
-	CpuLimitAnnotation          string = BaseQualifier + "/cpu-limit"
-	MemoryLimitAnnotation       string = BaseQualifier + "/memory-limit"
-	AffinityAnnotation          string = BaseQualifier + "/affinity"
-	AntiAffinityAnnotation      string = BaseQualifier + "/anti-affinity"
-	NodeSelectorAnnotation      string = BaseQualifier + "/node-selector"
-	ControlledQualifier         string = InternalQualifier + "/controlled"
-	ServiceQualifier            string = InternalQualifier + "/service"
-	OriginQualifier             string = InternalQualifier + "/origin"
-	OriginalSelectorQualifier   string = InternalQualifier + "/originalSelector"
-	OriginalTargetPortQualifier string = InternalQualifier + "/originalTargetPort"
-	OriginalAssignedQualifier   string = InternalQualifier + "/originalAssignedPort"
-	InternalTypeQualifier       string = InternalQualifier + "/type"
-	InternalMetadataQualifier   string = InternalQualifier + "/metadata"
-	SkupperTypeQualifier        string = BaseQualifier + "/type"
-	TypeProxyQualifier          string = InternalTypeQualifier + "=proxy"
-	SkupperDisabledQualifier    string = InternalQualifier + "/disabled"
-	TypeToken                   string = "connection-token"
-	TypeClaimRecord             string = "token-claim-record"
-	TypeClaimRequest            string = "token-claim"
-	TypeGatewayToken            string = "gateway-connection-token"
-	TypeTokenQualifier          string = BaseQualifier + "/type=connection-token"
-	TypeTokenRequestQualifier   string = BaseQualifier + "/type=connection-token-request"
-	TokenGeneratedBy            string = BaseQualifier + "/generated-by"
-	SiteVersion                 string = BaseQualifier + "/site-version"
-	TokenCost                   string = BaseQualifier + "/cost"
-	TokenTemplate               string = BaseQualifier + "/token-template"
-	UpdatedAnnotation           string = InternalQualifier + "/updated"
-	AnnotationExcludes          string = BaseQualifier + "/exclude-annotations"
-	LabelExcludes               string = BaseQualifier + "/exclude-labels"
-	ServiceLabels               string = BaseQualifier + "/service-labels"
-	ServiceAnnotations          string = BaseQualifier + "/service-annotations"
-	ComponentAnnotation         string = BaseQualifier + "/component"
-	SiteControllerIgnore        string = InternalQualifier + "/site-controller-ignore"
-	RouterComponent             string = "router"
-	ClaimExpiration             string = BaseQualifier + "/claim-expiration"
-	ClaimsRemaining             string = BaseQualifier + "/claims-remaining"
-	ClaimsMade                  string = BaseQualifier + "/claims-made"
-	ClaimUrlAnnotationKey       string = BaseQualifier + "/url"
-	ClaimPasswordDataKey        string = "password"
-	ClaimCaCertDataKey          string = "ca.crt"
-	ClaimRequestSelector        string = SkupperTypeQualifier + "=" + TypeClaimRequest
-	LastFailedAnnotationKey     string = InternalQualifier + "/last-failed"
-	StatusAnnotationKey         string = InternalQualifier + "/status"
-	GatewayQualifier            string = InternalQualifier + "/gateway"
-	IngressOnlyQualifier        string = BaseQualifier + "/ingress-only"
-	TlsCertQualifier            string = BaseQualifier + "/tls-cert"
-	TlsTrustQualifier           string = BaseQualifier + "/tls-trust"
+	BaseQualifier                 string = "skupper.io"
+	InternalQualifier             string = "internal." + BaseQualifier
+	AddressQualifier              string = BaseQualifier + "/address"
+	PortQualifier                 string = BaseQualifier + "/port"
+	ProxyQualifier                string = BaseQualifier + "/proxy"
+	TargetServiceQualifier        string = BaseQualifier + "/target"
+	HeadlessQualifier             string = BaseQualifier + "/headless"
+	IngressModeQualifier          string = BaseQualifier + "/ingress"
+	CpuRequestAnnotation          string = BaseQualifier + "/cpu-request"
+	MemoryRequestAnnotation       string = BaseQualifier + "/memory-request"
+	CpuLimitAnnotation            string = BaseQualifier + "/cpu-limit"
+	MemoryLimitAnnotation         string = BaseQualifier + "/memory-limit"
+	AffinityAnnotation            string = BaseQualifier + "/affinity"
+	AntiAffinityAnnotation        string = BaseQualifier + "/anti-affinity"
+	NodeSelectorAnnotation        string = BaseQualifier + "/node-selector"
+	SloLatencyAnnotation          string = BaseQualifier + "/slo-latency"
+	SloLatencyObjectiveAnnotation string = BaseQualifier + "/slo-latency-objective"
+	SloErrorObjectiveAnnotation   string = BaseQualifier + "/slo-error-objective"
+	ControlledQualifier           string = InternalQualifier + "/controlled"
+	ServiceQualifier              string = InternalQualifier + "/service"
+	OriginQualifier               string = InternalQualifier + "/origin"
+	OriginalSelectorQualifier     string = InternalQualifier + "/originalSelector"
+	OriginalTargetPortQualifier   string = InternalQualifier + "/originalTargetPort"
+	OriginalAssignedQualifier     string = InternalQualifier + "/originalAssignedPort"
+	InternalTypeQualifier         string = InternalQualifier + "/type"
+	InternalMetadataQualifier     string = InternalQualifier + "/metadata"
+	SkupperTypeQualifier          string = BaseQualifier + "/type"
+	TypeProxyQualifier            string = InternalTypeQualifier + "=proxy"
+	SkupperDisabledQualifier      string = InternalQualifier + "/disabled"
+	TypeToken                     string = "connection-token"
+	TypeClaimRecord               string = "token-claim-record"
+	TypeClaimRequest              string = "token-claim"
+	TypeSiteCrl                   string = "site-crl"
+	TypeComponentNetworkPolicy    string = "component-network-policy"
+	TypeGatewayToken              string = "gateway-connection-token"
+	TypeTokenQualifier            string = BaseQualifier + "/type=connection-token"
+	TypeTokenRequestQualifier     string = BaseQualifier + "/type=connection-token-request"
+	TokenGeneratedBy              string = BaseQualifier + "/generated-by"
+	SiteVersion                   string = BaseQualifier + "/site-version"
+	TokenCost                     string = BaseQualifier + "/cost"
+	TokenTemplate                 string = BaseQualifier + "/token-template"
+	UpdatedAnnotation             string = InternalQualifier + "/updated"
+	AnnotationExcludes            string = BaseQualifier + "/exclude-annotations"
+	LabelExcludes                 string = BaseQualifier + "/exclude-labels"
+	ServiceLabels                 string = BaseQualifier + "/service-labels"
+	ServiceAnnotations            string = BaseQualifier + "/service-annotations"
+	ComponentAnnotation           string = BaseQualifier + "/component"
+	SiteControllerIgnore          string = InternalQualifier + "/site-controller-ignore"
+	RouterComponent               string = "router"
+	ClaimExpiration               string = BaseQualifier + "/claim-expiration"
+	ClaimsRemaining               string = BaseQualifier + "/claims-remaining"
+	ClaimsMade                    string = BaseQualifier + "/claims-made"
+	ClaimUrlAnnotationKey         string = BaseQualifier + "/url"
+	ClaimPasswordDataKey          string = "password"
+	ClaimCaCertDataKey            string = "ca.crt"
+	ClaimMutual                   string = BaseQualifier + "/claim-mutual"
+	ClaimReverseDataKey           string = "reverse-claim"
+	ClaimReverseHeader            string = "skupper-reverse-claim"
+	ClaimAllowedSites             string = BaseQualifier + "/claim-allowed-sites"
+	ClaimEdgeOnly                 string = BaseQualifier + "/claim-edge-only"
+	ClaimMaxCost                  string = BaseQualifier + "/claim-max-cost"
+	ClaimNotBefore                string = BaseQualifier + "/claim-not-before"
+	ClaimSiteNameHeader           string = "skupper-site-display-name"
+	ClaimSiteModeHeader           string = "skupper-site-mode"
+	ClaimLinkCostHeader           string = "skupper-link-cost"
+	ClaimRequestSelector          string = SkupperTypeQualifier + "=" + TypeClaimRequest
+	SiteCrlSelector               string = SkupperTypeQualifier + "=" + TypeSiteCrl
+	ComponentPolicySelector       string = SkupperTypeQualifier + "=" + TypeComponentNetworkPolicy
+	LastFailedAnnotationKey       string = InternalQualifier + "/last-failed"
+	StatusAnnotationKey           string = InternalQualifier + "/status"
+	GatewayQualifier              string = InternalQualifier + "/gateway"
+	IngressOnlyQualifier          string = BaseQualifier + "/ingress-only"
+	TlsCertQualifier              string = BaseQualifier + "/tls-cert"
+	TlsTrustQualifier             string = BaseQualifier + "/tls-trust"
 )
 
 // standard labels
@@ -320,6 +372,7 @@ type Platform string
 const (
 	PlatformKubernetes Platform = "kubernetes"
 	PlatformPodman              = "podman"
+	PlatformDocker              = "docker"
 )
 
 func (p Platform) IsKubernetes() bool {
@@ -329,9 +382,12 @@ func (p Platform) IsKubernetes() bool {
 type ConsoleAuthMode string
 
 const (
-	ConsoleAuthModeOpenshift ConsoleAuthMode = "openshift"
-	ConsoleAuthModeInternal                  = "internal"
-	ConsoleAuthModeUnsecured                 = "unsecured"
+	ConsoleAuthModeOpenshift  ConsoleAuthMode = "openshift"
+	ConsoleAuthModeInternal                   = "internal"
+	ConsoleAuthModeUnsecured                  = "unsecured"
+	ConsoleAuthModeOpenID                     = "openid"
+	ConsoleAuthModeSAML                       = "saml"
+	ConsoleAuthModeKubernetes                 = "kubernetes"
 )
 
 const (
@@ -356,6 +412,11 @@ const (
 	PrometheusRouteName                      string = "skupper-prometheus"
 )
 
+// Router metrics exporter constants (podman sites only)
+const (
+	MetricsExporterDefaultPort int32 = 9191
+)
+
 // Assembly constants
 const (
 	AmqpDefaultPort         int32  = 5672
@@ -567,9 +628,27 @@ type Credential struct {
 	Labels      map[string]string
 }
 
+// ClaimPolicy constrains the sites allowed to redeem a claim, on top of its
+// uses and expiration
+type ClaimPolicy struct {
+	// AllowedSites are the patterns, as matched by path.Match, of the names
+	// of the sites allowed to redeem the claim. The names are the ones the
+	// sites report, so the restriction is advisory.
+	AllowedSites []string
+	EdgeOnly     bool
+	// MaxCost is the highest cost of the link created with the claim
+	MaxCost int
+	// NotBefore is the start of the validity window ended by the expiration
+	NotBefore time.Time
+}
+
 type CertAuthority struct {
 	Name   string
 	Labels map[string]string
+	// Vault issues the certificates of the CA when set
+	Vault *VaultOptions
+	// CertManager issues the CA certificate when set
+	CertManager *CertManagerOptions
 }
 
 type CredentialHandler interface {
@@ -617,6 +696,7 @@ type ServiceInterface struct {
 	ExposeIngress            ServiceIngressMode       `json:"exposeIngress" yaml:"exposeIngress"`
 	EventChannel             bool                     `json:"eventchannel,omitempty" yaml:"eventchannel,omitempty"`
 	Aggregate                string                   `json:"aggregate,omitempty" yaml:"aggregate,omitempty"`
+	AggregateOptions         *AggregateOptions        `json:"aggregateOptions,omitempty" yaml:"aggregateOptions,omitempty"`
 	Headless                 *Headless                `json:"headless,omitempty" yaml:"headless,omitempty"`
 	Labels                   map[string]string        `json:"labels,omitempty" yaml:"labels,omitempty"`
 	Annotations              map[string]string        `json:"annotations,omitempty" yaml:"annotations,omitempty"`
@@ -628,6 +708,55 @@ type ServiceInterface struct {
 	BridgeImage              string                   `json:"bridgeImage,omitempty"`
 }
 
+const (
+	AggregatePartialFail  string = "fail"
+	AggregatePartialAllow string = "allow"
+)
+
+// AggregateOptions tune how each request to an aggregated service is fanned
+// out to its implementations
+type AggregateOptions struct {
+	// Timeout is how long to wait for the responses, e.g. "5s"
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// Partial is "fail" to fail the request when any response is missing or
+	// "allow" to aggregate whatever responses arrived in time
+	Partial string `json:"partial,omitempty" yaml:"partial,omitempty"`
+	// MaxParallel limits the implementations called at once for a request
+	MaxParallel int `json:"maxParallel,omitempty" yaml:"maxParallel,omitempty"`
+}
+
+func (o *AggregateOptions) IsEmpty() bool {
+	return o == nil || *o == AggregateOptions{}
+}
+
+func (o *AggregateOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+	if _, err := o.TimeoutMillis(); err != nil {
+		return err
+	}
+	if o.Partial != "" && o.Partial != AggregatePartialFail && o.Partial != AggregatePartialAllow {
+		return fmt.Errorf("%s is not a valid partial result policy. Choose '%s' or '%s'.", o.Partial, AggregatePartialFail, AggregatePartialAllow)
+	}
+	if o.MaxParallel < 0 {
+		return fmt.Errorf("The aggregate max parallel must not be negative.")
+	}
+	return nil
+}
+
+// TimeoutMillis returns the timeout in milliseconds, zero when not set
+func (o *AggregateOptions) TimeoutMillis() (int, error) {
+	if o == nil || o.Timeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(o.Timeout)
+	if err != nil || timeout < time.Millisecond {
+		return 0, fmt.Errorf("%s is not a valid aggregate timeout.", o.Timeout)
+	}
+	return int(timeout / time.Millisecond), nil
+}
+
 func (s *ServiceInterface) IsOfLocalOrigin() bool {
 	return IsOfLocalOrigin(s.Origin)
 }
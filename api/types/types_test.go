@@ -173,3 +173,34 @@ func TestServiceInterfaceList_ConvertFrom(t *testing.T) {
 		})
 	}
 }
+
+func TestAggregateOptions(t *testing.T) {
+	testTable := []struct {
+		doc     string
+		options *AggregateOptions
+		timeout int
+		err     string
+	}{
+		{doc: "nil", options: nil},
+		{doc: "all", options: &AggregateOptions{Timeout: "2.5s", Partial: AggregatePartialAllow, MaxParallel: 4}, timeout: 2500},
+		{doc: "bad-timeout", options: &AggregateOptions{Timeout: "soon"}, err: "soon is not a valid aggregate timeout."},
+		{doc: "sub-millisecond", options: &AggregateOptions{Timeout: "10us"}, err: "10us is not a valid aggregate timeout."},
+		{doc: "bad-partial", options: &AggregateOptions{Partial: "maybe"}, err: "maybe is not a valid partial result policy. Choose 'fail' or 'allow'."},
+		{doc: "negative-parallel", options: &AggregateOptions{MaxParallel: -1}, err: "The aggregate max parallel must not be negative."},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			err := test.options.Validate()
+			if test.err != "" {
+				assert.Error(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			timeout, _ := test.options.TimeoutMillis()
+			assert.Equal(t, timeout, test.timeout)
+		})
+	}
+	assert.Assert(t, (*AggregateOptions)(nil).IsEmpty())
+	assert.Assert(t, (&AggregateOptions{}).IsEmpty())
+	assert.Assert(t, !(&AggregateOptions{MaxParallel: 1}).IsEmpty())
+}
@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+const certificateRestartTimeout = 5 * time.Minute
+
+// the CAs of the site along with the certificates they sign
+var siteCertificates = []struct {
+	ca    string
+	certs []string
+}{
+	{types.LocalCaSecret, []string{types.LocalServerSecret, types.LocalClientSecret, types.ConsoleServerSecret}},
+	{types.SiteCaSecret, []string{types.SiteServerSecret}},
+}
+
+// the deployments mounting the certificates, restarted in this order once
+// they are rotated so the router serves the new certificates before its
+// clients present theirs
+var certificateDeployments = []struct {
+	name  string
+	certs []string
+}{
+	{types.TransportDeploymentName, []string{types.LocalServerSecret, types.LocalClientSecret, types.SiteServerSecret}},
+	{types.ControllerDeploymentName, []string{types.LocalClientSecret, types.ConsoleServerSecret}},
+}
+
+func certificateInfo(secret *corev1.Secret, ca string) (types.CertificateInfo, *x509.Certificate, error) {
+	info := types.CertificateInfo{
+		Name: secret.Name,
+		CA:   ca,
+	}
+	cert, err := certs.DecodeCertificate(secret.Data["tls.crt"])
+	if err != nil {
+		return info, nil, fmt.Errorf("Invalid certificate in secret %s: %w", secret.Name, err)
+	}
+	info.Subject = cert.Subject.CommonName
+	info.Hosts = append(info.Hosts, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		info.Hosts = append(info.Hosts, ip.String())
+	}
+	info.NotAfter = cert.NotAfter
+	for _, deployment := range certificateDeployments {
+		for _, name := range deployment.certs {
+			if name == secret.Name {
+				info.Deployments = append(info.Deployments, deployment.name)
+			}
+		}
+	}
+	return info, cert, nil
+}
+
+// CertificatesRotate regenerates the CAs and/or the certificates of the site
+// they sign, keeping the subject and hosts of the current certificates, then
+// restarts the deployments mounting them. Rotating a CA rotates all the
+// certificates it signs. The certificates not signed by the CA of the site,
+// such as those of the openshift service CA, are left as they are. With
+// DryRun the certificates are only listed.
+func (cli *VanClient) CertificatesRotate(ctx context.Context, options types.CertificateRotateOptions) ([]types.CertificateInfo, error) {
+	secrets := cli.KubeClient.CoreV1().Secrets(cli.Namespace)
+	result := []types.CertificateInfo{}
+	restart := map[string]bool{}
+	for _, group := range siteCertificates {
+		caSecret, err := secrets.Get(ctx, group.ca, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			// edge sites have no site CA
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		caInfo, caCert, err := certificateInfo(caSecret, "")
+		if err != nil {
+			return nil, err
+		}
+		caInfo.Deployments = nil
+		if options.CA && !options.DryRun {
+			caSecret, err = kube.RegenerateCertAuthority(group.ca, cli.Namespace, cli.KubeClient)
+			if err != nil {
+				return nil, err
+			}
+			caInfo, _, err = certificateInfo(caSecret, "")
+			if err != nil {
+				return nil, err
+			}
+			caInfo.Rotated = true
+		}
+		result = append(result, caInfo)
+		for _, name := range group.certs {
+			secret, err := secrets.Get(ctx, name, metav1.GetOptions{})
+			if errors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			info, cert, err := certificateInfo(secret, group.ca)
+			if err != nil {
+				return nil, err
+			}
+			if cert.CheckSignatureFrom(caCert) != nil {
+				// issued by another CA
+				info.CA = ""
+				result = append(result, info)
+				continue
+			}
+			if (options.CA || options.Leaf) && !options.DryRun {
+				if kube.IsCertManagerSecret(secret) {
+					return nil, fmt.Errorf("The secret %s is issued by cert-manager, renew its certificate %s with cmctl renew instead", name, secret.Annotations[kube.CertManagerCertificateNameAnnotation])
+				}
+				regenerated := certs.GenerateSecret(name, info.Subject, strings.Join(info.Hosts, ","), caSecret)
+				for key, value := range regenerated.Data {
+					secret.Data[key] = value
+				}
+				secret, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+				if err != nil {
+					return nil, err
+				}
+				info, _, err = certificateInfo(secret, group.ca)
+				if err != nil {
+					return nil, err
+				}
+				info.Rotated = true
+				for _, deployment := range info.Deployments {
+					restart[deployment] = true
+				}
+			}
+			result = append(result, info)
+		}
+	}
+	for _, deployment := range certificateDeployments {
+		if !restart[deployment.name] {
+			continue
+		}
+		if err := cli.restartDeployment(deployment.name); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// restartDeployment rolls the pods of the deployment and waits for them to
+// be ready
+func (cli *VanClient) restartDeployment(name string) error {
+	deployment, err := cli.KubeClient.AppsV1().Deployments(cli.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	touch(deployment)
+	_, err = cli.KubeClient.AppsV1().Deployments(cli.Namespace).Update(context.TODO(), deployment, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("Failed to restart %s: %w", name, err)
+	}
+	_, err = kube.WaitDeploymentRolledOut(name, cli.Namespace, cli.KubeClient, certificateRestartTimeout, time.Second)
+	if err != nil {
+		return fmt.Errorf("%s not ready after the restart: %w", name, err)
+	}
+	return nil
+}
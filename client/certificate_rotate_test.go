@@ -0,0 +1,101 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/skupperproject/skupper/api/types"
+	"gotest.tools/assert"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCertificatesRotate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cli, err := newMockClient("skupper", "", "")
+	assert.Assert(t, err)
+
+	config, err := cli.SiteConfigCreate(ctx, types.SiteConfigSpec{
+		SkupperName:       "skupper",
+		RouterMode:        string(types.TransportModeInterior),
+		EnableController:  true,
+		EnableServiceSync: true,
+		Ingress:           types.IngressNoneString,
+	})
+	assert.Assert(t, err)
+	err = cli.RouterCreate(ctx, *config)
+	assert.Assert(t, err, "Unable to create router")
+
+	// the fake client has no deployment controller rolling the pods
+	for _, name := range []string{types.TransportDeploymentName, types.ControllerDeploymentName} {
+		dep, err := cli.KubeClient.AppsV1().Deployments(cli.Namespace).Get(ctx, name, metav1.GetOptions{})
+		assert.Assert(t, err)
+		replicas := int32(1)
+		if dep.Spec.Replicas != nil {
+			replicas = *dep.Spec.Replicas
+		}
+		dep.Status = appsv1.DeploymentStatus{Replicas: replicas, UpdatedReplicas: replicas, ReadyReplicas: replicas}
+		_, err = cli.KubeClient.AppsV1().Deployments(cli.Namespace).UpdateStatus(ctx, dep, metav1.UpdateOptions{})
+		assert.Assert(t, err)
+	}
+
+	secretData := func() map[string]map[string][]byte {
+		data := map[string]map[string][]byte{}
+		for _, name := range []string{types.LocalCaSecret, types.SiteCaSecret, types.LocalServerSecret, types.LocalClientSecret, types.SiteServerSecret} {
+			secret, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(ctx, name, metav1.GetOptions{})
+			assert.Assert(t, err)
+			data[name] = secret.Data
+		}
+		return data
+	}
+	restarted := func(name string) bool {
+		dep, err := cli.KubeClient.AppsV1().Deployments(cli.Namespace).Get(ctx, name, metav1.GetOptions{})
+		assert.Assert(t, err)
+		_, ok := dep.Spec.Template.Annotations[types.UpdatedAnnotation]
+		return ok
+	}
+
+	before := secretData()
+	infos, err := cli.CertificatesRotate(ctx, types.CertificateRotateOptions{CA: true, Leaf: true, DryRun: true})
+	assert.Assert(t, err)
+	assert.Equal(t, len(infos), 5)
+	for _, info := range infos {
+		assert.Assert(t, !info.Rotated, info.Name)
+		assert.Assert(t, !info.NotAfter.IsZero(), info.Name)
+	}
+	assert.DeepEqual(t, before, secretData())
+	assert.Assert(t, !restarted(types.TransportDeploymentName))
+
+	infos, err = cli.CertificatesRotate(ctx, types.CertificateRotateOptions{Leaf: true})
+	assert.Assert(t, err)
+	after := secretData()
+	for _, info := range infos {
+		if info.CA == "" {
+			assert.Assert(t, !info.Rotated, info.Name)
+			assert.DeepEqual(t, before[info.Name], after[info.Name])
+			continue
+		}
+		assert.Assert(t, info.Rotated, info.Name)
+		assert.Assert(t, !bytes.Equal(before[info.Name]["tls.crt"], after[info.Name]["tls.crt"]), info.Name)
+		if info.Name == types.SiteServerSecret {
+			assert.Equal(t, info.Subject, types.TransportServiceName)
+			assert.Assert(t, len(info.Hosts) > 0)
+		}
+	}
+	assert.DeepEqual(t, before[types.LocalClientSecret]["connect.json"], after[types.LocalClientSecret]["connect.json"])
+	assert.Assert(t, restarted(types.TransportDeploymentName))
+	assert.Assert(t, restarted(types.ControllerDeploymentName))
+
+	before = after
+	_, err = cli.CertificatesRotate(ctx, types.CertificateRotateOptions{CA: true})
+	assert.Assert(t, err)
+	after = secretData()
+	for name := range before {
+		assert.Assert(t, !bytes.Equal(before[name]["tls.crt"], after[name]["tls.crt"]), name)
+	}
+	assert.DeepEqual(t, after[types.SiteServerSecret]["ca.crt"], after[types.SiteCaSecret]["tls.crt"])
+}
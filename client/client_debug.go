@@ -267,3 +267,13 @@ func (cli *VanClient) SkupperCheckService(service string, verbose bool) (*bytes.
 func (cli *VanClient) SkupperPolicies(verbose bool) (*bytes.Buffer, error) {
 	return cli.execInServiceControllerPod(addOutputFlag([]string{"get", "policies", "list"}, verbose))
 }
+
+// SkupperLogLevel shows the log level of the service controller or of the
+// flow collector, changing it first when a level is given
+func (cli *VanClient) SkupperLogLevel(component string, level string) (*bytes.Buffer, error) {
+	command := []string{"get", "loglevel", "--component", component}
+	if level != "" {
+		command = append(command, level)
+	}
+	return cli.execInServiceControllerPod(command)
+}
@@ -3,10 +3,12 @@ package client
 import (
 	"context"
 	"fmt"
+	"log"
 	"strconv"
 
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/kube"
 	"github.com/skupperproject/skupper/pkg/kube/resolver"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -77,6 +79,12 @@ func (cli *VanClient) ConnectorTokenCreate(ctx context.Context, subject string,
 	if err != nil {
 		return nil, false, err
 	}
+	// recorded so that the link credential can be revoked on its own, the
+	// token is still valid when it cannot be
+	err = kube.RecordIssuedCertificate(&secret, namespace, cli.KubeClient)
+	if err != nil {
+		log.Printf("Link credential %s cannot be revoked on its own: %s", secret.Name, err)
+	}
 	return &secret, localOnly, nil
 }
 
@@ -44,6 +44,20 @@ type Client interface {
 	VolumeList() ([]*Volume, error)
 }
 
+// EngineClient is the client of the container engine a site runs on,
+// podman or docker
+type EngineClient interface {
+	Client
+	Platform() types.Platform
+	Validate() error
+	GetEndpoint() string
+	IsSockEndpoint() bool
+	GetSockFile() string
+	IsRunningInContainer() bool
+	ContainerUpdateImage(ctx context.Context, name string, newImage string) (*Container, error)
+	SetPullOptions(options PullOptions)
+}
+
 type VersionInfo struct {
 	Version    string
 	APIVersion string
@@ -56,6 +70,7 @@ type Version struct {
 	Arch     string
 	Kernel   string
 	OS       string
+	Rootless bool
 }
 
 type Container struct {
@@ -73,16 +88,35 @@ type Container struct {
 	EntryPoint     []string
 	Command        []string
 	RestartPolicy  string
-	MaxCpus        int
+	MaxCpus        float64
 	MaxMemoryBytes int64
+	Healthcheck    *Healthcheck
 	RestartCount   int
 	Running        bool
+	Health         string
 	CreatedAt      time.Time
 	StartedAt      time.Time
 	ExitedAt       time.Time
 	ExitCode       int
 }
 
+const (
+	HealthStarting  = "starting"
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+)
+
+// Healthcheck is a command the container engine runs periodically in the
+// container to verify it is healthy, the health state being reported in
+// the Health field of the inspected container
+type Healthcheck struct {
+	Command     []string
+	Interval    time.Duration
+	Timeout     time.Duration
+	StartPeriod time.Duration
+	Retries     int
+}
+
 func (c *Container) FromEnv(env []string) {
 	for _, e := range env {
 		if !strings.Contains(e, "=") {
@@ -130,6 +164,28 @@ type Volume struct {
 	Mode        string
 	RW          bool
 	Labels      map[string]string
+	// Options are the options of the volume driver
+	Options map[string]string
+}
+
+// BindVolumeOptions returns the options of a local volume backed by a
+// directory of the host
+func BindVolumeOptions(hostPath string) map[string]string {
+	return map[string]string{
+		"type":   "none",
+		"o":      "bind",
+		"device": hostPath,
+	}
+}
+
+// VolumeSource returns the directory of the host holding the data of the
+// volume, which is the bound directory for volumes backed by one, as
+// the mount point is only bound while the volume is used
+func VolumeSource(mountpoint string, options map[string]string) string {
+	if device := options["device"]; device != "" && options["o"] == "bind" {
+		return device
+	}
+	return mountpoint
 }
 
 func (v *Volume) GetLabels() map[string]string {
@@ -287,6 +343,49 @@ type Image struct {
 	Created    string
 }
 
+const (
+	PullAlways  = "always"
+	PullMissing = "missing"
+	PullNever   = "never"
+)
+
+var ValidPullPolicies = []string{PullAlways, PullMissing, PullNever}
+
+// PullOptions define when the images are pulled and the credentials the
+// registries are authenticated with, the auth file (as written by podman
+// or docker login) being ignored when a username is given
+type PullOptions struct {
+	Policy   string
+	AuthFile string
+	Username string
+	Password string
+}
+
+// GetPolicy returns the pull policy, the images are always pulled by default
+func (o PullOptions) GetPolicy() string {
+	if o.Policy == "" {
+		return PullAlways
+	}
+	return o.Policy
+}
+
+// ImageName returns the image reference without its tag or digest
+func ImageName(image string) string {
+	if name, _, ok := strings.Cut(image, "@"); ok {
+		return name
+	}
+	lastSlash := strings.LastIndex(image, "/")
+	if lastColon := strings.LastIndex(image, ":"); lastColon > lastSlash {
+		return image[:lastColon]
+	}
+	return image
+}
+
+// PinnedImage returns the reference of the image pinned to the given digest
+func PinnedImage(image, digest string) string {
+	return ImageName(image) + "@" + digest
+}
+
 type Network struct {
 	ID        string
 	Name      string
@@ -0,0 +1,135 @@
+package container
+
+import (
+	"fmt"
+	"time"
+)
+
+// UpdateContainer replaces the container (by name) with an identical copy
+// with an applied Customization (required).
+// To achieve that, it follows this procedure:
+// - Create a new container
+// - Stop current container
+// - Rename current container
+// - Rename the new container (replacing the original one)
+// - Starts the new container
+// - Removes the original container
+//
+// In case of failures during this process, the original container is restored.
+func UpdateContainer(cli Client, name string, fn func(newContainer *Container)) (*Container, error) {
+	if fn == nil {
+		return nil, fmt.Errorf("at least one customization is needed")
+	}
+
+	datetime := time.Now().Format("20060102150405")
+	c, err := cli.ContainerInspect(name)
+	if err != nil {
+		return nil, err
+	}
+
+	newContainerName := fmt.Sprintf("%s-new-%s", c.Name, datetime)
+	cc := containerSpec(c, newContainerName)
+
+	// apply new container customization
+	fn(cc)
+	if cc.Name != newContainerName {
+		return nil, fmt.Errorf("container name cannot be changed")
+	}
+
+	// creating a new container
+	err = cli.ContainerCreate(cc)
+	if err != nil {
+		return cc, err
+	}
+
+	// rollback this one in case of failures below
+	defer func() {
+		if err != nil {
+			_ = cli.ContainerStop(cc.Name)
+			_ = cli.ContainerRemove(cc.Name)
+		}
+	}()
+
+	// stopping current container
+	err = cli.ContainerStop(name)
+	if err != nil {
+		return cc, err
+	}
+
+	// restarting current container
+	defer func() {
+		if err != nil {
+			_ = cli.ContainerStart(name)
+		}
+	}()
+
+	// renaming current container to a backup name
+	backupName := fmt.Sprintf("%s-%s", name, datetime)
+	err = cli.ContainerRename(name, backupName)
+	if err != nil {
+		return cc, err
+	}
+
+	// restoring original container
+	defer func() {
+		if err != nil {
+			_ = cli.ContainerRename(backupName, name)
+		}
+	}()
+
+	// renaming new container to current name
+	err = cli.ContainerRename(cc.Name, name)
+	if err != nil {
+		return cc, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = cli.ContainerRename(name, newContainerName)
+		}
+	}()
+
+	// starting new container
+	err = cli.ContainerStart(name)
+	if err != nil {
+		return cc, err
+	} else {
+		if removeErr := cli.ContainerRemove(backupName); removeErr != nil {
+			fmt.Printf("Unable to remove backup container: %s - %s", backupName, err)
+			fmt.Println()
+		}
+	}
+
+	return cc, nil
+}
+
+// RecreateContainer creates and starts a container identical to the given
+// inspected one, which no longer exists
+func RecreateContainer(cli Client, c *Container) error {
+	if err := cli.ContainerCreate(containerSpec(c, c.Name)); err != nil {
+		return err
+	}
+	return cli.ContainerStart(c.Name)
+}
+
+// containerSpec returns the definition of a new container, with the given
+// name, identical to the given inspected container
+func containerSpec(c *Container, name string) *Container {
+	return &Container{
+		Name:           name,
+		Image:          c.Image,
+		Env:            c.Env,
+		Labels:         c.Labels,
+		Annotations:    c.Annotations,
+		Networks:       c.Networks,
+		Mounts:         c.Mounts,
+		FileMounts:     c.FileMounts,
+		Ports:          c.Ports,
+		EntryPoint:     c.EntryPoint,
+		Command:        c.Command,
+		RestartPolicy:  c.RestartPolicy,
+		MaxCpus:        c.MaxCpus,
+		MaxMemoryBytes: c.MaxMemoryBytes,
+		Healthcheck:    c.Healthcheck,
+	}
+}
@@ -0,0 +1,506 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/pkg/utils"
+)
+
+const (
+	// selinuxLabelAnnotation is the annotation podman containers are
+	// created with to disable the selinux separation, docker containers
+	// get the label=disable security option instead
+	selinuxLabelAnnotation = "io.podman.annotations.label"
+	selinuxLabelDisable    = "label=disable"
+)
+
+type mountPoint struct {
+	Type        string `json:"Type"`
+	Name        string `json:"Name"`
+	Source      string `json:"Source"`
+	Destination string `json:"Destination"`
+	Mode        string `json:"Mode"`
+	RW          bool   `json:"RW"`
+}
+
+type endpointSettings struct {
+	NetworkID   string   `json:"NetworkID,omitempty"`
+	IPAddress   string   `json:"IPAddress,omitempty"`
+	IPPrefixLen int      `json:"IPPrefixLen,omitempty"`
+	MacAddress  string   `json:"MacAddress,omitempty"`
+	Gateway     string   `json:"Gateway,omitempty"`
+	Aliases     []string `json:"Aliases,omitempty"`
+}
+
+type portBinding struct {
+	HostIp   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+type listContainer struct {
+	Id      string            `json:"Id"`
+	Names   []string          `json:"Names"`
+	Image   string            `json:"Image"`
+	Created int64             `json:"Created"`
+	State   string            `json:"State"`
+	Labels  map[string]string `json:"Labels"`
+	Ports   []struct {
+		IP          string `json:"IP"`
+		PrivatePort int    `json:"PrivatePort"`
+		PublicPort  int    `json:"PublicPort"`
+		Type        string `json:"Type"`
+	} `json:"Ports"`
+	NetworkSettings struct {
+		Networks map[string]endpointSettings `json:"Networks"`
+	} `json:"NetworkSettings"`
+	Mounts []mountPoint `json:"Mounts"`
+}
+
+type inspectContainer struct {
+	Id           string    `json:"Id"`
+	Name         string    `json:"Name"`
+	Created      time.Time `json:"Created"`
+	RestartCount int       `json:"RestartCount"`
+	State        struct {
+		Running    bool      `json:"Running"`
+		ExitCode   int       `json:"ExitCode"`
+		StartedAt  time.Time `json:"StartedAt"`
+		FinishedAt time.Time `json:"FinishedAt"`
+		Health     *struct {
+			Status string `json:"Status"`
+		} `json:"Health,omitempty"`
+	} `json:"State"`
+	Config struct {
+		Image       string            `json:"Image"`
+		Env         []string          `json:"Env"`
+		Labels      map[string]string `json:"Labels"`
+		Cmd         []string          `json:"Cmd"`
+		Entrypoint  []string          `json:"Entrypoint"`
+		Healthcheck *healthConfig     `json:"Healthcheck,omitempty"`
+	} `json:"Config"`
+	HostConfig hostConfig   `json:"HostConfig"`
+	Mounts     []mountPoint `json:"Mounts"`
+	Settings   struct {
+		Ports    map[string][]portBinding    `json:"Ports"`
+		Networks map[string]endpointSettings `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+type restartPolicy struct {
+	Name string `json:"Name"`
+}
+
+type hostConfig struct {
+	Binds         []string                 `json:"Binds,omitempty"`
+	PortBindings  map[string][]portBinding `json:"PortBindings,omitempty"`
+	RestartPolicy restartPolicy            `json:"RestartPolicy"`
+	NanoCpus      int64                    `json:"NanoCpus,omitempty"`
+	Memory        int64                    `json:"Memory,omitempty"`
+	SecurityOpt   []string                 `json:"SecurityOpt,omitempty"`
+	NetworkMode   string                   `json:"NetworkMode,omitempty"`
+}
+
+type healthConfig struct {
+	Test        []string `json:"Test,omitempty"`
+	Interval    int64    `json:"Interval,omitempty"`
+	Timeout     int64    `json:"Timeout,omitempty"`
+	StartPeriod int64    `json:"StartPeriod,omitempty"`
+	Retries     int      `json:"Retries,omitempty"`
+}
+
+type createContainer struct {
+	Image            string              `json:"Image"`
+	Env              []string            `json:"Env,omitempty"`
+	Labels           map[string]string   `json:"Labels,omitempty"`
+	Cmd              []string            `json:"Cmd,omitempty"`
+	Entrypoint       []string            `json:"Entrypoint,omitempty"`
+	ExposedPorts     map[string]struct{} `json:"ExposedPorts,omitempty"`
+	Healthcheck      *healthConfig       `json:"Healthcheck,omitempty"`
+	HostConfig       hostConfig          `json:"HostConfig"`
+	NetworkingConfig struct {
+		EndpointsConfig map[string]endpointSettings `json:"EndpointsConfig,omitempty"`
+	} `json:"NetworkingConfig"`
+}
+
+type idResponse struct {
+	Id string `json:"Id"`
+}
+
+func containerPath(id string, action string) string {
+	p := "/containers/" + url.PathEscape(id)
+	if action != "" {
+		p += "/" + action
+	}
+	return p
+}
+
+func (d *DockerRestClient) ContainerList() ([]*container.Container, error) {
+	var list []listContainer
+	if err := d.call("GET", "/containers/json", url.Values{"all": {"true"}}, nil, &list); err != nil {
+		return nil, fmt.Errorf("error listing containers: %v", err)
+	}
+	cts := []*container.Container{}
+	for _, c := range list {
+		cts = append(cts, fromListContainer(c))
+	}
+	return cts, nil
+}
+
+func fromListContainer(c listContainer) *container.Container {
+	ct := &container.Container{
+		ID:        c.Id,
+		Image:     c.Image,
+		Labels:    c.Labels,
+		Running:   c.State == "running",
+		CreatedAt: time.Unix(c.Created, 0),
+		Env:       map[string]string{},
+		Networks:  map[string]container.ContainerNetworkInfo{},
+	}
+	if len(c.Names) > 0 {
+		ct.Name = strings.TrimPrefix(c.Names[0], "/")
+	}
+	for name, n := range c.NetworkSettings.Networks {
+		ct.Networks[name] = container.ContainerNetworkInfo{
+			ID:        n.NetworkID,
+			IPAddress: n.IPAddress,
+		}
+	}
+	for _, m := range c.Mounts {
+		ct.Mounts = append(ct.Mounts, container.Volume{Name: m.Name, Destination: m.Destination})
+	}
+	for _, port := range c.Ports {
+		if port.PublicPort == 0 {
+			continue
+		}
+		ct.Ports = append(ct.Ports, container.Port{
+			Host:     strconv.Itoa(port.PublicPort),
+			HostIP:   port.IP,
+			Target:   strconv.Itoa(port.PrivatePort),
+			Protocol: port.Type,
+		})
+	}
+	return ct
+}
+
+func (d *DockerRestClient) ContainerInspect(id string) (*container.Container, error) {
+	var c inspectContainer
+	if err := d.call("GET", containerPath(id, "json"), nil, nil, &c); err != nil {
+		return nil, fmt.Errorf("error inspecting container '%s': %w", id, err)
+	}
+	return fromInspectContainer(c), nil
+}
+
+func fromInspectContainer(c inspectContainer) *container.Container {
+	ct := &container.Container{
+		ID:           c.Id,
+		Name:         strings.TrimPrefix(c.Name, "/"),
+		Image:        c.Config.Image,
+		Labels:       c.Config.Labels,
+		Annotations:  map[string]string{},
+		Env:          map[string]string{},
+		Networks:     map[string]container.ContainerNetworkInfo{},
+		EntryPoint:   c.Config.Entrypoint,
+		Command:      c.Config.Cmd,
+		RestartCount: c.RestartCount,
+		CreatedAt:    c.Created,
+		Running:      c.State.Running,
+		StartedAt:    c.State.StartedAt,
+		ExitedAt:     c.State.FinishedAt,
+		ExitCode:     c.State.ExitCode,
+	}
+	if ct.Labels == nil {
+		ct.Labels = map[string]string{}
+	}
+	ct.FromEnv(c.Config.Env)
+	if hc := c.Config.Healthcheck; hc != nil && len(hc.Test) > 1 && hc.Test[0] == "CMD" {
+		ct.Healthcheck = &container.Healthcheck{
+			Command:     hc.Test[1:],
+			Interval:    time.Duration(hc.Interval),
+			Timeout:     time.Duration(hc.Timeout),
+			StartPeriod: time.Duration(hc.StartPeriod),
+			Retries:     hc.Retries,
+		}
+	}
+	if c.State.Health != nil {
+		ct.Health = c.State.Health.Status
+	}
+
+	// Volume mounts
+	for _, m := range c.Mounts {
+		switch m.Type {
+		case "volume":
+			ct.Mounts = append(ct.Mounts, container.Volume{
+				Name:        m.Name,
+				Source:      m.Source,
+				Destination: m.Destination,
+				Mode:        m.Mode,
+				RW:          m.RW,
+			})
+		case "bind":
+			fileMount := container.FileMount{
+				Source:      m.Source,
+				Destination: m.Destination,
+			}
+			if m.Mode != "" {
+				fileMount.Options = strings.Split(m.Mode, ",")
+			}
+			ct.FileMounts = append(ct.FileMounts, fileMount)
+		}
+	}
+
+	// HostConfig
+	ct.RestartPolicy = c.HostConfig.RestartPolicy.Name
+	ct.MaxCpus = float64(c.HostConfig.NanoCpus) / 1e9
+	ct.MaxMemoryBytes = c.HostConfig.Memory
+	for _, opt := range c.HostConfig.SecurityOpt {
+		if opt == selinuxLabelDisable {
+			ct.Annotations[selinuxLabelAnnotation] = "disable"
+		}
+	}
+
+	// Network info, the short id of the container docker adds to the
+	// aliases is left out as it does not survive an update
+	for name, n := range c.Settings.Networks {
+		var aliases []string
+		for _, alias := range n.Aliases {
+			if !strings.HasPrefix(c.Id, alias) {
+				aliases = append(aliases, alias)
+			}
+		}
+		ct.Networks[name] = container.ContainerNetworkInfo{
+			ID:          n.NetworkID,
+			IPAddress:   n.IPAddress,
+			IPPrefixLen: n.IPPrefixLen,
+			MacAddress:  n.MacAddress,
+			Gateway:     n.Gateway,
+			Aliases:     aliases,
+		}
+	}
+
+	// Port mapping
+	for portProto, bindings := range c.Settings.Ports {
+		portProtoS := strings.Split(portProto, "/")
+		protocol := "tcp"
+		if len(portProtoS) > 1 {
+			protocol = portProtoS[1]
+		}
+		for _, binding := range bindings {
+			ct.Ports = append(ct.Ports, container.Port{
+				Host:     binding.HostPort,
+				HostIP:   binding.HostIp,
+				Target:   portProtoS[0],
+				Protocol: protocol,
+			})
+		}
+	}
+	return ct
+}
+
+// toCreateContainer returns the body creating the container, attached to
+// its first network only as older docker versions reject more networks on
+// creation
+func toCreateContainer(c *container.Container) (*createContainer, []string) {
+	cc := &createContainer{
+		Image:      c.Image,
+		Env:        c.EnvSlice(),
+		Labels:     c.Labels,
+		Cmd:        c.Command,
+		Entrypoint: c.EntryPoint,
+		HostConfig: hostConfig{
+			RestartPolicy: restartPolicy{Name: c.RestartPolicy},
+			NanoCpus:      int64(c.MaxCpus * 1e9),
+			Memory:        c.MaxMemoryBytes,
+		},
+	}
+	if hc := c.Healthcheck; hc != nil {
+		cc.Healthcheck = &healthConfig{
+			Test:        append([]string{"CMD"}, hc.Command...),
+			Interval:    int64(hc.Interval),
+			Timeout:     int64(hc.Timeout),
+			StartPeriod: int64(hc.StartPeriod),
+			Retries:     hc.Retries,
+		}
+	}
+	if c.Annotations != nil && c.Annotations[selinuxLabelAnnotation] == "disable" {
+		cc.HostConfig.SecurityOpt = append(cc.HostConfig.SecurityOpt, selinuxLabelDisable)
+	}
+
+	// Volumes and files, shared between containers
+	for _, v := range c.Mounts {
+		cc.HostConfig.Binds = append(cc.HostConfig.Binds, fmt.Sprintf("%s:%s:%s", v.Name, v.Destination, utils.DefaultStr(v.Mode, "z")))
+	}
+	for _, fm := range c.FileMounts {
+		bind := fm.Source + ":" + fm.Destination
+		if len(fm.Options) > 0 {
+			bind += ":" + strings.Join(fm.Options, ",")
+		}
+		cc.HostConfig.Binds = append(cc.HostConfig.Binds, bind)
+	}
+
+	// Port mapping
+	for _, port := range c.Ports {
+		if cc.ExposedPorts == nil {
+			cc.ExposedPorts = map[string]struct{}{}
+			cc.HostConfig.PortBindings = map[string][]portBinding{}
+		}
+		portProto := port.Target + "/" + utils.DefaultStr(port.Protocol, "tcp")
+		cc.ExposedPorts[portProto] = struct{}{}
+		cc.HostConfig.PortBindings[portProto] = append(cc.HostConfig.PortBindings[portProto], portBinding{
+			HostIp:   port.HostIP,
+			HostPort: port.Host,
+		})
+	}
+
+	// Network info, aliases must be populated for the name to be resolved
+	var otherNetworks []string
+	networkNames := c.NetworkNames()
+	sort.Strings(networkNames)
+	for _, networkName := range networkNames {
+		network := c.Networks[networkName]
+		if len(network.Aliases) == 0 {
+			network.Aliases = append(network.Aliases, c.Name)
+		}
+		if cc.NetworkingConfig.EndpointsConfig == nil {
+			cc.HostConfig.NetworkMode = networkName
+			cc.NetworkingConfig.EndpointsConfig = map[string]endpointSettings{
+				networkName: {Aliases: network.Aliases},
+			}
+			continue
+		}
+		otherNetworks = append(otherNetworks, networkName)
+	}
+	return cc, otherNetworks
+}
+
+func (d *DockerRestClient) ContainerCreate(c *container.Container) error {
+	if c.Labels == nil {
+		c.Labels = map[string]string{}
+	}
+	c.Labels["application"] = types.AppName
+	create, otherNetworks := toCreateContainer(c)
+	var created idResponse
+	if err := d.call("POST", "/containers/create", url.Values{"name": {c.Name}}, create, &created); err != nil {
+		return fmt.Errorf("error creating container %s: %v", c.Name, err)
+	}
+	for _, networkName := range otherNetworks {
+		network := c.Networks[networkName]
+		if len(network.Aliases) == 0 {
+			network.Aliases = []string{c.Name}
+		}
+		if err := d.NetworkConnect(networkName, c.Name, network.Aliases...); err != nil {
+			_ = d.ContainerRemove(c.Name)
+			return fmt.Errorf("error creating container %s: %v", c.Name, err)
+		}
+	}
+	return nil
+}
+
+// ContainerUpdate replaces the container (by name) with an identical copy
+// with an applied Customization (required), as done by
+// container.UpdateContainer.
+func (d *DockerRestClient) ContainerUpdate(name string, fn func(newContainer *container.Container)) (*container.Container, error) {
+	return container.UpdateContainer(d, name, fn)
+}
+
+// ContainerUpdateImage updates the image used by the given container (name).
+func (d *DockerRestClient) ContainerUpdateImage(ctx context.Context, name string, newImage string) (*container.Container, error) {
+	err := d.ImagePull(ctx, newImage)
+	if err != nil {
+		return nil, fmt.Errorf("error pulling image %q: %s", newImage, err)
+	}
+	return d.ContainerUpdate(name, func(newContainer *container.Container) {
+		newContainer.Image = newImage
+	})
+}
+
+func (d *DockerRestClient) ContainerRename(currentName, newName string) error {
+	if err := d.call("POST", containerPath(currentName, "rename"), url.Values{"name": {newName}}, nil, nil); err != nil {
+		return fmt.Errorf("error renaming container %s to %s: %v", currentName, newName, err)
+	}
+	return nil
+}
+
+func (d *DockerRestClient) ContainerRemove(name string) error {
+	err := d.call("DELETE", containerPath(name, ""), url.Values{"force": {"true"}}, nil, nil)
+	if err != nil && !IsNotFound(err) {
+		return fmt.Errorf("error deleting container %s: %v", name, err)
+	}
+	return nil
+}
+
+func (d *DockerRestClient) ContainerStart(name string) error {
+	if err := d.call("POST", containerPath(name, "start"), nil, nil, nil); err != nil {
+		return fmt.Errorf("error starting container %s: %v", name, err)
+	}
+	return nil
+}
+
+func (d *DockerRestClient) ContainerStop(name string) error {
+	err := d.call("POST", containerPath(name, "stop"), nil, nil, nil)
+	if err != nil && !IsNotFound(err) {
+		return fmt.Errorf("error stopping container %s: %v", name, err)
+	}
+	return nil
+}
+
+func (d *DockerRestClient) ContainerRestart(name string) error {
+	if err := d.call("POST", containerPath(name, "restart"), nil, nil, nil); err != nil {
+		return fmt.Errorf("error restarting container %s: %v", name, err)
+	}
+	return nil
+}
+
+func (d *DockerRestClient) ContainerExec(id string, command []string) (string, error) {
+	// Creating the exec
+	var exec idResponse
+	err := d.call("POST", containerPath(id, "exec"), nil, map[string]interface{}{
+		"AttachStdout": true,
+		"AttachStderr": true,
+		"Cmd":          command,
+	}, &exec)
+	if err != nil {
+		return "", fmt.Errorf("error executing command on %s: %v", id, err)
+	}
+
+	// Starting the exec, its output is sent until the command ends
+	res, err := d.request(context.Background(), "POST", "/exec/"+url.PathEscape(exec.Id)+"/start", nil, map[string]interface{}{
+		"Detach": false,
+		"Tty":    false,
+	}, nil)
+	if err != nil {
+		return "", fmt.Errorf("error starting execution: %v", err)
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading execution output: %v", err)
+	}
+	stdout, stderr := demultiplex(data)
+	return stdout + stderr, nil
+}
+
+func (d *DockerRestClient) ContainerLogs(id string) (string, error) {
+	res, err := d.request(context.Background(), "GET", containerPath(id, "logs"), url.Values{
+		"stdout": {"true"},
+		"stderr": {"true"},
+	}, nil, nil)
+	if err != nil {
+		return "", fmt.Errorf("error retrieving logs from container %s: %v", id, err)
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("error retrieving logs from container %s: %v", id, err)
+	}
+	stdout, stderr := demultiplex(data)
+	return stdout + stderr, nil
+}
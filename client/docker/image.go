@@ -0,0 +1,232 @@
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/skupperproject/skupper/client/container"
+)
+
+const (
+	imagePullRecommendation = `
+If the image is being pulled from an authenticated registry,
+make sure to log in first, using:
+
+    docker login <registry-url>
+
+In case you are using a custom configuration directory, you should
+set the DOCKER_CONFIG environment variable.`
+)
+
+type listImage struct {
+	Id       string   `json:"Id"`
+	RepoTags []string `json:"RepoTags"`
+	Created  int64    `json:"Created"`
+}
+
+type inspectImage struct {
+	Id          string   `json:"Id"`
+	RepoTags    []string `json:"RepoTags"`
+	RepoDigests []string `json:"RepoDigests"`
+	Created     string   `json:"Created"`
+}
+
+func (d *DockerRestClient) ImageList() ([]*container.Image, error) {
+	var list []listImage
+	if err := d.call("GET", "/images/json", url.Values{"all": {"true"}}, nil, &list); err != nil {
+		return nil, fmt.Errorf("error listing images: %v", err)
+	}
+	var imgs []*container.Image
+	for _, img := range list {
+		for _, imgName := range img.RepoTags {
+			imgs = append(imgs, &container.Image{
+				Id:         img.Id,
+				Repository: imgName,
+				Created:    fmt.Sprint(img.Created),
+			})
+		}
+	}
+	return imgs, nil
+}
+
+func (d *DockerRestClient) ImageInspect(id string) (*container.Image, error) {
+	var res inspectImage
+	if err := d.call("GET", "/images/"+id+"/json", nil, nil, &res); err != nil {
+		return nil, fmt.Errorf("error inspecting image %s: %v", id, err)
+	}
+	img := &container.Image{
+		Id:      res.Id,
+		Created: res.Created,
+	}
+	if len(res.RepoTags) > 0 {
+		img.Repository = res.RepoTags[0]
+	}
+	if len(res.RepoDigests) > 0 {
+		if _, digest, ok := strings.Cut(res.RepoDigests[0], "@"); ok {
+			img.Digest = digest
+		}
+	}
+	if !strings.HasPrefix(img.Id, id) {
+		for _, name := range res.RepoTags {
+			if strings.Contains(name, id) {
+				img.Repository = name
+				break
+			}
+		}
+	}
+	return img, nil
+}
+
+// SetPullOptions defines the pull policy and the registry credentials of
+// the images pulled afterwards
+func (d *DockerRestClient) SetPullOptions(options container.PullOptions) {
+	d.pullOptions = options
+}
+
+func (d *DockerRestClient) ImagePull(ctx context.Context, id string) error {
+	// the docker daemon always pulls, the policy is applied here
+	if policy := d.pullOptions.GetPolicy(); policy != container.PullAlways {
+		if _, err := d.ImageInspect(id); err == nil {
+			return nil
+		} else if policy == container.PullNever {
+			return &Error{
+				Message:        fmt.Sprintf("image %s is not present and the pull policy is %s", id, policy),
+				Recommendation: "Pull or load the image before, or use another pull policy.",
+			}
+		}
+	}
+	fromImage, tag := splitImageReference(id)
+	header := http.Header{}
+	if auth := d.registryAuth(fromImage); auth != "" {
+		header.Set("X-Registry-Auth", auth)
+	}
+	res, err := d.request(ctx, "POST", "/images/create", url.Values{
+		"fromImage": {fromImage},
+		"tag":       {tag},
+	}, nil, header)
+	if err != nil {
+		return &Error{
+			Message:        fmt.Sprintf("error pulling image %s: %v", id, err),
+			Recommendation: imagePullRecommendation,
+		}
+	}
+	defer res.Body.Close()
+
+	// the progress is streamed as json messages, failures included
+	decoder := json.NewDecoder(res.Body)
+	for {
+		var msg struct {
+			Error string `json:"error"`
+		}
+		if err := decoder.Decode(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("error pulling image %s: %v", id, err)
+		}
+		if msg.Error != "" {
+			return &Error{
+				Message:        fmt.Sprintf("unable to pull image %s: %s", id, msg.Error),
+				Recommendation: imagePullRecommendation,
+			}
+		}
+	}
+}
+
+// splitImageReference returns the name and the tag (or digest) of the
+// given image, the tag defaults to latest
+func splitImageReference(image string) (string, string) {
+	if name, digest, ok := strings.Cut(image, "@"); ok {
+		return name, digest
+	}
+	lastSlash := strings.LastIndex(image, "/")
+	if lastColon := strings.LastIndex(image, ":"); lastColon > lastSlash {
+		return image[:lastColon], image[lastColon+1:]
+	}
+	return image, "latest"
+}
+
+// registryAuth returns the credentials of the registry of the image, the
+// ones given through the pull options or read from the auth file
+func (d *DockerRestClient) registryAuth(image string) string {
+	if d.pullOptions.Username != "" {
+		return encodeRegistryAuth(registryServer(image), d.pullOptions.Username, d.pullOptions.Password)
+	}
+	return getXRegistryAuth(image, d.pullOptions.AuthFile)
+}
+
+// registryServer returns the registry of the image, as named in the auth
+// files of docker
+func registryServer(image string) string {
+	imageServer := strings.Split(image, "/")[0]
+	if !strings.ContainsAny(imageServer, ".:") && imageServer != "localhost" {
+		imageServer = "https://index.docker.io/v1/"
+	}
+	return imageServer
+}
+
+func encodeRegistryAuth(server, username, password string) string {
+	registryAuthJson, _ := json.Marshal(map[string]string{
+		"username":      username,
+		"password":      password,
+		"serveraddress": server,
+	})
+	return base64.URLEncoding.EncodeToString(registryAuthJson)
+}
+
+// getXRegistryAuth returns the credentials stored by docker login, in the
+// given auth file or in the docker config file, for the registry of the
+// given image, encoded as expected by the docker daemon
+func getXRegistryAuth(image string, authFile string) string {
+	if authFile == "" {
+		configDir := os.Getenv("DOCKER_CONFIG")
+		if configDir == "" {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return ""
+			}
+			configDir = path.Join(homeDir, ".docker")
+		}
+		authFile = path.Join(configDir, "config.json")
+	}
+	data, err := os.ReadFile(authFile)
+	if err != nil {
+		return ""
+	}
+	var dockerConfig struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err = json.Unmarshal(data, &dockerConfig); err != nil {
+		fmt.Printf("Unable to parse docker config file - %s", err)
+		fmt.Println()
+		return ""
+	}
+	imageServer := registryServer(image)
+	for server, serverData := range dockerConfig.Auths {
+		if server != imageServer && strings.TrimPrefix(server, "https://") != imageServer {
+			continue
+		}
+		credentialsBytes, err := base64.StdEncoding.DecodeString(serverData.Auth)
+		if err != nil {
+			fmt.Printf("Unable to decode base64 auth info for %s - %s", imageServer, err)
+			fmt.Println()
+			return ""
+		}
+		username, password, ok := strings.Cut(string(credentialsBytes), ":")
+		if !ok {
+			return ""
+		}
+		return encodeRegistryAuth(server, username, password)
+	}
+	return ""
+}
@@ -0,0 +1,148 @@
+package docker
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/pkg/utils"
+)
+
+type ipamConfig struct {
+	Subnet  string `json:"Subnet,omitempty"`
+	Gateway string `json:"Gateway,omitempty"`
+}
+
+type network struct {
+	Id         string            `json:"Id,omitempty"`
+	Name       string            `json:"Name"`
+	Created    string            `json:"Created,omitempty"`
+	Driver     string            `json:"Driver"`
+	EnableIPv6 bool              `json:"EnableIPv6"`
+	Internal   bool              `json:"Internal"`
+	Labels     map[string]string `json:"Labels"`
+	Options    map[string]string `json:"Options"`
+	IPAM       struct {
+		Config []ipamConfig `json:"Config"`
+	} `json:"IPAM"`
+}
+
+func (d *DockerRestClient) NetworkList() ([]*container.Network, error) {
+	var list []network
+	if err := d.call("GET", "/networks", nil, nil, &list); err != nil {
+		return nil, fmt.Errorf("error listing networks: %v", err)
+	}
+	var nets []*container.Network
+	for _, net := range list {
+		nets = append(nets, toNetworkInfo(net))
+	}
+	return nets, nil
+}
+
+func toNetworkInfo(net network) *container.Network {
+	var ss []*container.Subnet
+	for _, s := range net.IPAM.Config {
+		ss = append(ss, &container.Subnet{
+			Subnet:  s.Subnet,
+			Gateway: s.Gateway,
+		})
+	}
+	return &container.Network{
+		ID:      net.Id,
+		Name:    net.Name,
+		Subnets: ss,
+		Driver:  net.Driver,
+		IPV6:    net.EnableIPv6,
+		// the embedded dns server is available on user defined networks only
+		DNS:       net.Name != "bridge" && net.Name != "host" && net.Name != "none",
+		Internal:  net.Internal,
+		Labels:    net.Labels,
+		Options:   net.Options,
+		CreatedAt: net.Created,
+	}
+}
+
+func (d *DockerRestClient) NetworkInspect(id string) (*container.Network, error) {
+	var net network
+	if err := d.call("GET", "/networks/"+url.PathEscape(id), nil, nil, &net); err != nil {
+		return nil, fmt.Errorf("error inspecting network %s: %w", id, err)
+	}
+	return toNetworkInfo(net), nil
+}
+
+func (d *DockerRestClient) NetworkCreate(net *container.Network) (*container.Network, error) {
+	if net.Labels == nil {
+		net.Labels = map[string]string{}
+	}
+	net.Labels["application"] = types.AppName
+	labels := map[string]string{
+		types.PartOfLabel: types.AppName,
+	}
+	for k, v := range net.Labels {
+		labels[k] = v
+	}
+	create := struct {
+		network
+		CheckDuplicate bool `json:"CheckDuplicate"`
+	}{
+		network: network{
+			Name:       net.Name,
+			Driver:     utils.DefaultStr(net.Driver, DefaultNetworkDriver),
+			EnableIPv6: net.IPV6,
+			Internal:   net.Internal,
+			Labels:     labels,
+			Options:    net.Options,
+		},
+		CheckDuplicate: true,
+	}
+	for _, subnet := range net.Subnets {
+		create.IPAM.Config = append(create.IPAM.Config, ipamConfig{
+			Subnet:  subnet.Subnet,
+			Gateway: subnet.Gateway,
+		})
+	}
+	var created idResponse
+	if err := d.call("POST", "/networks/create", nil, create, &created); err != nil {
+		return nil, fmt.Errorf("error creating network %s: %v", net.Name, err)
+	}
+	return d.NetworkInspect(created.Id)
+}
+
+func (d *DockerRestClient) NetworkRemove(id string) error {
+	existing, err := d.NetworkInspect(id)
+	if err != nil {
+		return fmt.Errorf("network does not exist %s - %w", id, err)
+	}
+	if !container.IsOwnedBySkupper(existing.Labels) {
+		return fmt.Errorf("network %s is not owned by Skupper", id)
+	}
+	if err = d.call("DELETE", "/networks/"+url.PathEscape(id), nil, nil, nil); err != nil {
+		return fmt.Errorf("error removing network %s: %v", id, err)
+	}
+	return nil
+}
+
+func (d *DockerRestClient) NetworkConnect(id, container string, aliases ...string) error {
+	connect := map[string]interface{}{
+		"Container": container,
+		"EndpointConfig": endpointSettings{
+			Aliases: aliases,
+		},
+	}
+	if err := d.call("POST", "/networks/"+url.PathEscape(id)+"/connect", nil, connect, nil); err != nil {
+		return fmt.Errorf("error connecting %s to network %s: %v", container, id, err)
+	}
+	return nil
+}
+
+func (d *DockerRestClient) NetworkDisconnect(id, container string) error {
+	disconnect := map[string]interface{}{
+		"Container": container,
+		"Force":     true,
+	}
+	if err := d.call("POST", "/networks/"+url.PathEscape(id)+"/disconnect", nil, disconnect, nil); err != nil {
+		return fmt.Errorf("error disconnecting %s from network %s: %v", container, id, err)
+	}
+	return nil
+}
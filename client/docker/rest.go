@@ -0,0 +1,258 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/pkg/config"
+	"github.com/skupperproject/skupper/pkg/utils"
+)
+
+const (
+	ENV_DOCKER_HOST      = "DOCKER_HOST"
+	DEFAULT_API_VERSION  = "1.41"
+	DefaultNetworkDriver = "bridge"
+	defaultDockerSocket  = "/var/run/docker.sock"
+)
+
+// DockerRestClient is a client of the Docker Engine API, reached through
+// the docker socket or a tcp endpoint
+type DockerRestClient struct {
+	httpClient  *http.Client
+	host        string
+	basePath    string
+	endpoint    string
+	pullOptions container.PullOptions
+}
+
+type RestClientFactory func(endpoint string) (*DockerRestClient, error)
+
+func NewDockerClient(endpoint string) (*DockerRestClient, error) {
+	if endpoint == "" {
+		endpoint = utils.DefaultStr(os.Getenv(ENV_DOCKER_HOST), GetDefaultDockerEndpoint())
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	host := "docker"
+	isSockFile := strings.HasPrefix(endpoint, "/")
+	switch {
+	case isSockFile || strings.HasPrefix(endpoint, "unix://"):
+		if isSockFile {
+			endpoint = "unix://" + endpoint
+		}
+		sockFile := strings.TrimPrefix(endpoint, "unix://")
+		if _, err := os.Stat(sockFile); err != nil {
+			return nil, fmt.Errorf("Docker service is not available on provided endpoint - %w", err)
+		}
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", sockFile)
+		}
+	case strings.HasPrefix(endpoint, "tcp://") || strings.HasPrefix(endpoint, "http://"):
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		if u.Port() == "" {
+			return nil, fmt.Errorf("invalid endpoint: %s, the port is required", endpoint)
+		}
+		host = u.Host
+		transport.DialContext = dialer.DialContext
+	case strings.HasPrefix(endpoint, "ssh://"):
+		return nil, fmt.Errorf("invalid endpoint: %s, ssh endpoints are not supported by docker sites", endpoint)
+	default:
+		return nil, fmt.Errorf("invalid endpoint: %s", endpoint)
+	}
+
+	cli := &DockerRestClient{
+		httpClient: &http.Client{Transport: transport},
+		host:       host,
+		basePath:   "/v" + DEFAULT_API_VERSION,
+		endpoint:   endpoint,
+	}
+	if err := cli.Validate(); err != nil {
+		return nil, err
+	}
+	return cli, nil
+}
+
+// GetDefaultDockerEndpoint returns the socket of the rootless docker daemon
+// of the user when running, or the one of the system wide daemon
+func GetDefaultDockerEndpoint() string {
+	rootlessSocket := path.Join(config.GetRuntimeDir(), "docker.sock")
+	if _, err := os.Stat(rootlessSocket); err == nil {
+		return "unix://" + rootlessSocket
+	}
+	return "unix://" + defaultDockerSocket
+}
+
+// IsSockEndpoint tells if the docker daemon is reached through its socket
+func (d *DockerRestClient) IsSockEndpoint() bool {
+	return strings.HasPrefix(d.endpoint, "unix://")
+}
+
+// GetSockFile returns the path of the docker socket
+func (d *DockerRestClient) GetSockFile() string {
+	return strings.TrimPrefix(d.endpoint, "unix://")
+}
+
+func (d *DockerRestClient) GetEndpoint() string {
+	return d.endpoint
+}
+
+func (d *DockerRestClient) Platform() types.Platform {
+	return types.PlatformDocker
+}
+
+func (d *DockerRestClient) IsRunningInContainer() bool {
+	// See: https://docs.docker.com/engine/faq/
+	_, err := os.Stat("/.dockerenv")
+	return err == nil
+}
+
+func (d *DockerRestClient) Validate() error {
+	version, err := d.Version()
+	if err != nil {
+		return fmt.Errorf("Docker service is not available on provided endpoint (unable to verify version) - %w", err)
+	}
+	apiVersion := utils.ParseVersion(version.Server.APIVersion)
+	minimum := utils.ParseVersion(DEFAULT_API_VERSION)
+	if apiVersion.LessRecentThan(minimum) {
+		return fmt.Errorf("docker API version must be %s or greater (docker 20.10), found: %s", DEFAULT_API_VERSION, version.Server.APIVersion)
+	}
+	return nil
+}
+
+// Error is an error reported by the docker daemon
+type Error struct {
+	StatusCode     int
+	Message        string
+	Recommendation string
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.Recommendation != "" {
+		return fmt.Sprintf("%s\n\nRecommendation: %s\n", msg, e.Recommendation)
+	}
+	return msg
+}
+
+// IsNotFound tells if the daemon reported the object of the request does
+// not exist
+func IsNotFound(err error) bool {
+	dockerErr, ok := err.(*Error)
+	return ok && dockerErr.StatusCode == http.StatusNotFound
+}
+
+// request sends a request to the versioned api, the body being encoded as
+// json, the response body must be closed by the caller
+func (d *DockerRestClient) request(ctx context.Context, method string, apiPath string, query url.Values, body interface{}, header http.Header) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	u := url.URL{
+		Scheme:   "http",
+		Host:     d.host,
+		Path:     d.basePath + apiPath,
+		RawQuery: query.Encode(),
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 400 {
+		defer res.Body.Close()
+		dockerErr := &Error{StatusCode: res.StatusCode}
+		errBody := struct {
+			Message string `json:"message"`
+		}{}
+		if data, readErr := io.ReadAll(res.Body); readErr == nil {
+			if json.Unmarshal(data, &errBody) == nil {
+				dockerErr.Message = errBody.Message
+			} else {
+				dockerErr.Message = strings.TrimSpace(string(data))
+			}
+		}
+		return nil, dockerErr
+	}
+	return res, nil
+}
+
+// call sends a request decoding the json response into result, when given
+func (d *DockerRestClient) call(method string, apiPath string, query url.Values, body interface{}, result interface{}) error {
+	res, err := d.request(context.Background(), method, apiPath, query, body, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if result == nil || res.StatusCode == http.StatusNoContent || res.StatusCode == http.StatusNotModified {
+		_, _ = io.Copy(io.Discard, res.Body)
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(result)
+}
+
+// demultiplex splits the stream of a container without a tty into its
+// stdout and stderr. Each frame has a header of 8 bytes, the first one
+// telling the stream (0 = stdin, 1 = stdout and 2 = stderr) and the last
+// four the size of the payload (uint32 big endian).
+//
+// See: https://docs.docker.com/engine/api/v1.41/#tag/Container/operation/ContainerAttach
+func demultiplex(data []byte) (string, string) {
+	var stdout, stderr bytes.Buffer
+	for len(data) >= 8 {
+		size := int(binary.BigEndian.Uint32(data[4:8]))
+		frame := data[8:]
+		if size < len(frame) {
+			frame = frame[:size]
+		}
+		if data[0] == 2 {
+			stderr.Write(frame)
+		} else {
+			stdout.Write(frame)
+		}
+		data = data[8+len(frame):]
+	}
+	return stdout.String(), stderr.String()
+}
@@ -0,0 +1,320 @@
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skupperproject/skupper/client/container"
+	"gotest.tools/assert"
+)
+
+type fakeDaemon struct {
+	apiVersion string
+	created    createContainer
+	inspect    string
+	exec       []byte
+	images     []string
+	pulls      []string
+	auth       string
+}
+
+func (f *fakeDaemon) start(t *testing.T) (*DockerRestClient, error) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(versionResponse{Version: "24.0.5", ApiVersion: f.apiVersion, Os: "linux", Arch: "amd64"})
+	})
+	mux.HandleFunc("/info", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(infoResponse{Name: "docker-host", SecurityOptions: []string{"name=seccomp,profile=builtin", "name=rootless"}})
+	})
+	mux.HandleFunc("/v1.41/containers/create", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Query().Get("name"), "skupper-router")
+		assert.Assert(t, json.NewDecoder(r.Body).Decode(&f.created))
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(idResponse{Id: "4a2f3c9b8d7e"})
+	})
+	mux.HandleFunc("/v1.41/containers/skupper-router/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, f.inspect)
+	})
+	mux.HandleFunc("/v1.41/containers/missing/json", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"message": "No such container: missing"}`)
+	})
+	mux.HandleFunc("/v1.41/containers/skupper-router/exec", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(idResponse{Id: "e1"})
+	})
+	mux.HandleFunc("/v1.41/exec/e1/start", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(f.exec)
+	})
+	mux.HandleFunc("/v1.41/images/create", func(w http.ResponseWriter, r *http.Request) {
+		image := r.URL.Query().Get("fromImage") + ":" + r.URL.Query().Get("tag")
+		f.pulls = append(f.pulls, image)
+		f.images = append(f.images, image)
+		f.auth = r.Header.Get("X-Registry-Auth")
+		_, _ = io.WriteString(w, `{"status": "Downloaded newer image"}`)
+	})
+	mux.HandleFunc("/v1.41/images/", func(w http.ResponseWriter, r *http.Request) {
+		image := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1.41/images/"), "/json")
+		for _, img := range f.images {
+			if img == image {
+				_ = json.NewEncoder(w).Encode(inspectImage{Id: "sha256:8f3b", RepoTags: []string{image}})
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"message": "No such image: `+image+`"}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return NewDockerClient("tcp://" + server.Listener.Addr().String())
+}
+
+func frame(stream byte, payload string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+func TestNewDockerClient(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiVersion string
+		expectErr  bool
+	}{{
+		name:       "supported",
+		apiVersion: "1.43",
+	}, {
+		name:       "minimum",
+		apiVersion: "1.41",
+	}, {
+		name:       "too-old",
+		apiVersion: "1.40",
+		expectErr:  true,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			daemon := &fakeDaemon{apiVersion: test.apiVersion}
+			cli, err := daemon.start(t)
+			if test.expectErr {
+				assert.ErrorContains(t, err, "docker API version must be 1.41 or greater")
+				return
+			}
+			assert.Assert(t, err)
+			assert.Assert(t, !cli.IsSockEndpoint())
+			version, err := cli.Version()
+			assert.Assert(t, err)
+			assert.Equal(t, version.Server.APIVersion, test.apiVersion)
+			assert.Equal(t, version.Hostname, "docker-host")
+			assert.Assert(t, version.Rootless)
+		})
+	}
+}
+
+func TestNewDockerClientEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"tcp://127.0.0.1", "ssh://user@host", "docker.sock"} {
+		_, err := NewDockerClient(endpoint)
+		assert.ErrorContains(t, err, "invalid endpoint")
+	}
+}
+
+func TestContainerCreate(t *testing.T) {
+	daemon := &fakeDaemon{apiVersion: "1.41"}
+	cli, err := daemon.start(t)
+	assert.Assert(t, err)
+
+	err = cli.ContainerCreate(&container.Container{
+		Name:        "skupper-router",
+		Image:       "quay.io/skupper/skupper-router:main",
+		Env:         map[string]string{"APPLICATION_NAME": "skupper-router"},
+		Annotations: map[string]string{selinuxLabelAnnotation: "disable"},
+		Networks: map[string]container.ContainerNetworkInfo{
+			"skupper": {},
+		},
+		Mounts: []container.Volume{
+			{Name: "skupper-internal", Destination: "/etc/skupper-router-certs/skupper-internal/"},
+		},
+		FileMounts: []container.FileMount{
+			{Source: "/run/user/1000/docker.sock", Destination: "/tmp/docker.sock", Options: []string{"z"}},
+		},
+		Ports: []container.Port{
+			{Host: "55671", Target: "55671"},
+		},
+		RestartPolicy: "always",
+		MaxCpus:       2,
+		Healthcheck: &container.Healthcheck{
+			Command:  []string{"curl", "-sf", "http://localhost:9090/healthz"},
+			Interval: 30 * time.Second,
+			Retries:  3,
+		},
+	})
+	assert.Assert(t, err)
+
+	created := daemon.created
+	assert.Equal(t, created.Labels["application"], "skupper")
+	assert.DeepEqual(t, created.Env, []string{"APPLICATION_NAME=skupper-router"})
+	assert.DeepEqual(t, created.HostConfig.Binds, []string{
+		"skupper-internal:/etc/skupper-router-certs/skupper-internal/:z",
+		"/run/user/1000/docker.sock:/tmp/docker.sock:z",
+	})
+	assert.DeepEqual(t, created.HostConfig.SecurityOpt, []string{selinuxLabelDisable})
+	assert.DeepEqual(t, created.HostConfig.PortBindings, map[string][]portBinding{
+		"55671/tcp": {{HostPort: "55671"}},
+	})
+	assert.Equal(t, created.HostConfig.RestartPolicy.Name, "always")
+	assert.Equal(t, created.HostConfig.NanoCpus, int64(2e9))
+	assert.DeepEqual(t, created.Healthcheck, &healthConfig{
+		Test:     []string{"CMD", "curl", "-sf", "http://localhost:9090/healthz"},
+		Interval: int64(30 * time.Second),
+		Retries:  3,
+	})
+	assert.Equal(t, created.HostConfig.NetworkMode, "skupper")
+	assert.DeepEqual(t, created.NetworkingConfig.EndpointsConfig["skupper"].Aliases, []string{"skupper-router"})
+}
+
+func TestContainerInspect(t *testing.T) {
+	daemon := &fakeDaemon{
+		apiVersion: "1.41",
+		inspect: `{
+  "Id": "4a2f3c9b8d7e6f5a",
+  "Name": "/skupper-router",
+  "Created": "2023-09-01T10:00:00.000000000Z",
+  "State": {"Running": true, "StartedAt": "2023-09-01T10:00:01.000000000Z", "Health": {"Status": "healthy"}},
+  "Config": {
+    "Image": "quay.io/skupper/skupper-router:main",
+    "Env": ["APPLICATION_NAME=skupper-router"],
+    "Labels": {"application": "skupper"},
+    "Healthcheck": {"Test": ["CMD", "curl", "-sf", "http://localhost:9090/healthz"], "Interval": 30000000000, "Retries": 3}
+  },
+  "HostConfig": {
+    "RestartPolicy": {"Name": "always"},
+    "NanoCpus": 2000000000,
+    "Memory": 1073741824,
+    "SecurityOpt": ["label=disable"]
+  },
+  "Mounts": [
+    {"Type": "volume", "Name": "skupper-internal", "Source": "/var/lib/docker/volumes/skupper-internal/_data",
+     "Destination": "/etc/skupper-router-certs/skupper-internal", "Mode": "z", "RW": true},
+    {"Type": "bind", "Source": "/run/user/1000/docker.sock", "Destination": "/tmp/docker.sock", "Mode": "z", "RW": true}
+  ],
+  "NetworkSettings": {
+    "Ports": {"55671/tcp": [{"HostIp": "0.0.0.0", "HostPort": "55671"}]},
+    "Networks": {
+      "skupper": {"NetworkID": "n1", "IPAddress": "172.18.0.2", "IPPrefixLen": 16,
+                  "Aliases": ["skupper-router", "4a2f3c9b8d7e"]}
+    }
+  }
+}`,
+	}
+	cli, err := daemon.start(t)
+	assert.Assert(t, err)
+
+	c, err := cli.ContainerInspect("skupper-router")
+	assert.Assert(t, err)
+	assert.Equal(t, c.Name, "skupper-router")
+	assert.Equal(t, c.Env["APPLICATION_NAME"], "skupper-router")
+	assert.Assert(t, c.Running)
+	assert.Equal(t, c.RestartPolicy, "always")
+	assert.Equal(t, c.MaxCpus, 2.0)
+	assert.Equal(t, c.Health, container.HealthHealthy)
+	assert.DeepEqual(t, c.Healthcheck.Command, []string{"curl", "-sf", "http://localhost:9090/healthz"})
+	assert.Equal(t, c.Healthcheck.Interval, 30*time.Second)
+	assert.Equal(t, c.MaxMemoryBytes, int64(1073741824))
+	assert.Equal(t, c.Annotations[selinuxLabelAnnotation], "disable")
+	assert.Equal(t, len(c.Mounts), 1)
+	assert.Equal(t, c.Mounts[0].Name, "skupper-internal")
+	assert.DeepEqual(t, c.FileMounts, []container.FileMount{
+		{Source: "/run/user/1000/docker.sock", Destination: "/tmp/docker.sock", Options: []string{"z"}},
+	})
+	assert.DeepEqual(t, c.Ports, []container.Port{
+		{Host: "55671", HostIP: "0.0.0.0", Target: "55671", Protocol: "tcp"},
+	})
+	assert.DeepEqual(t, c.NetworkAliases(), map[string][]string{"skupper": {"skupper-router"}})
+
+	_, err = cli.ContainerInspect("missing")
+	assert.ErrorContains(t, err, "No such container: missing")
+	assert.Assert(t, IsNotFound(errors.Unwrap(err)))
+}
+
+func TestContainerExec(t *testing.T) {
+	daemon := &fakeDaemon{
+		apiVersion: "1.41",
+		exec:       append(frame(1, "router is "), append(frame(2, "warning\n"), frame(1, "running\n")...)...),
+	}
+	cli, err := daemon.start(t)
+	assert.Assert(t, err)
+
+	out, err := cli.ContainerExec("skupper-router", []string{"skstat", "-g"})
+	assert.Assert(t, err)
+	assert.Equal(t, out, "router is running\nwarning\n")
+}
+
+func TestSplitImageReference(t *testing.T) {
+	tests := []struct {
+		image string
+		name  string
+		tag   string
+	}{
+		{"quay.io/skupper/skupper-router:main", "quay.io/skupper/skupper-router", "main"},
+		{"quay.io/skupper/skupper-router", "quay.io/skupper/skupper-router", "latest"},
+		{"localhost:5000/skupper-router", "localhost:5000/skupper-router", "latest"},
+		{"quay.io/skupper/skupper-router@sha256:abc", "quay.io/skupper/skupper-router", "sha256:abc"},
+	}
+	for _, test := range tests {
+		name, tag := splitImageReference(test.image)
+		assert.Equal(t, name, test.name)
+		assert.Equal(t, tag, test.tag)
+	}
+}
+
+func TestImagePull(t *testing.T) {
+	const image = "quay.io/skupper/skupper-router:main"
+	decodeAuth := func(auth string) map[string]string {
+		data, err := base64.URLEncoding.DecodeString(auth)
+		assert.Assert(t, err)
+		var credentials map[string]string
+		assert.Assert(t, json.Unmarshal(data, &credentials))
+		return credentials
+	}
+
+	daemon := &fakeDaemon{apiVersion: "1.41"}
+	cli, err := daemon.start(t)
+	assert.Assert(t, err)
+
+	// never pulling a missing image
+	cli.SetPullOptions(container.PullOptions{Policy: container.PullNever})
+	assert.ErrorContains(t, cli.ImagePull(context.Background(), image), "image "+image+" is not present")
+	assert.Equal(t, len(daemon.pulls), 0)
+
+	// pulling only the missing images
+	cli.SetPullOptions(container.PullOptions{Policy: container.PullMissing, Username: "skupper", Password: "secret"})
+	assert.Assert(t, cli.ImagePull(context.Background(), image))
+	assert.Assert(t, cli.ImagePull(context.Background(), image))
+	assert.DeepEqual(t, daemon.pulls, []string{image})
+	assert.DeepEqual(t, decodeAuth(daemon.auth), map[string]string{
+		"username":      "skupper",
+		"password":      "secret",
+		"serveraddress": "quay.io",
+	})
+
+	// always pulling, with the credentials of an auth file
+	authFile := path.Join(t.TempDir(), "auth.json")
+	assert.Assert(t, os.WriteFile(authFile, []byte(`{"auths": {"quay.io": {"auth": "`+
+		base64.StdEncoding.EncodeToString([]byte("robot:token"))+`"}}}`), 0600))
+	cli.SetPullOptions(container.PullOptions{AuthFile: authFile})
+	assert.Assert(t, cli.ImagePull(context.Background(), image))
+	assert.DeepEqual(t, daemon.pulls, []string{image, image})
+	assert.Equal(t, decodeAuth(daemon.auth)["username"], "robot")
+	assert.Equal(t, decodeAuth(daemon.auth)["password"], "token")
+}
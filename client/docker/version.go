@@ -0,0 +1,47 @@
+package docker
+
+import (
+	"github.com/skupperproject/skupper/client/container"
+)
+
+type versionResponse struct {
+	Version       string `json:"Version"`
+	ApiVersion    string `json:"ApiVersion"`
+	Os            string `json:"Os"`
+	Arch          string `json:"Arch"`
+	KernelVersion string `json:"KernelVersion"`
+}
+
+type infoResponse struct {
+	Name            string   `json:"Name"`
+	SecurityOptions []string `json:"SecurityOptions"`
+}
+
+func (d *DockerRestClient) Version() (*container.Version, error) {
+	// the version is not bound to the api version, so it can be verified
+	unversioned := *d
+	unversioned.basePath = ""
+	var version versionResponse
+	if err := unversioned.call("GET", "/version", nil, nil, &version); err != nil {
+		return nil, err
+	}
+	v := &container.Version{
+		Server: container.VersionInfo{
+			Version:    version.Version,
+			APIVersion: version.ApiVersion,
+		},
+		Arch:   version.Arch,
+		Kernel: version.KernelVersion,
+		OS:     version.Os,
+	}
+	var info infoResponse
+	if err := unversioned.call("GET", "/info", nil, nil, &info); err == nil {
+		v.Hostname = info.Name
+		for _, opt := range info.SecurityOptions {
+			if opt == "name=rootless" {
+				v.Rootless = true
+			}
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,71 @@
+package docker
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+)
+
+type volume struct {
+	Name       string            `json:"Name"`
+	Mountpoint string            `json:"Mountpoint,omitempty"`
+	Labels     map[string]string `json:"Labels"`
+	Options    map[string]string `json:"Options,omitempty"`
+	DriverOpts map[string]string `json:"DriverOpts,omitempty"`
+}
+
+func toVolume(v volume) *container.Volume {
+	return &container.Volume{
+		Name:    v.Name,
+		Source:  container.VolumeSource(v.Mountpoint, v.Options),
+		Labels:  v.Labels,
+		Options: v.Options,
+	}
+}
+
+func (d *DockerRestClient) VolumeCreate(v *container.Volume) (*container.Volume, error) {
+	if v.Labels == nil {
+		v.Labels = map[string]string{}
+	}
+	v.Labels["application"] = types.AppName
+	var created volume
+	if err := d.call("POST", "/volumes/create", nil, volume{Name: v.Name, Labels: v.Labels, DriverOpts: v.Options}, &created); err != nil {
+		return nil, err
+	}
+	return toVolume(created), nil
+}
+
+func (d *DockerRestClient) VolumeRemove(id string) error {
+	v, err := d.VolumeInspect(id)
+	if err != nil {
+		return err
+	}
+	if !container.IsOwnedBySkupper(v.GetLabels()) {
+		return fmt.Errorf("volume %s is not owned by Skupper", id)
+	}
+	return d.call("DELETE", "/volumes/"+url.PathEscape(id), url.Values{"force": {"true"}}, nil, nil)
+}
+
+func (d *DockerRestClient) VolumeInspect(id string) (*container.Volume, error) {
+	var v volume
+	if err := d.call("GET", "/volumes/"+url.PathEscape(id), nil, nil, &v); err != nil {
+		return nil, err
+	}
+	return toVolume(v), nil
+}
+
+func (d *DockerRestClient) VolumeList() ([]*container.Volume, error) {
+	var res struct {
+		Volumes []volume `json:"Volumes"`
+	}
+	if err := d.call("GET", "/volumes", nil, nil, &res); err != nil {
+		return nil, err
+	}
+	list := []*container.Volume{}
+	for _, v := range res.Volumes {
+		list = append(list, toVolume(v))
+	}
+	return list, nil
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
+)
+
+// componentIngresses lets the pods of the namespace reach the router on any
+// port, which covers the exposed services and the controller and prometheus
+// clients, while only the ports of the router, controller and prometheus
+// services reachable from outside the site are open to other peers. The
+// components are read from the deployments and services of the site, so
+// the components not deployed are left out.
+func (cli *VanClient) componentIngresses(ctx context.Context, namespace string) ([]kube.ComponentIngress, error) {
+	components := []struct {
+		deployment string
+		service    string
+		peers      []networkingv1.NetworkPolicyPeer
+	}{
+		{
+			deployment: types.TransportDeploymentName,
+			service:    types.TransportServiceName,
+			peers: []networkingv1.NetworkPolicyPeer{
+				{
+					PodSelector: &metav1.LabelSelector{},
+				},
+			},
+		},
+		{
+			deployment: types.ControllerDeploymentName,
+			service:    types.ControllerServiceName,
+		},
+		{
+			deployment: types.PrometheusDeploymentName,
+			peers: []networkingv1.NetworkPolicyPeer{
+				{
+					PodSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							types.ComponentAnnotation: types.ControllerComponentName,
+						},
+					},
+				},
+			},
+		},
+	}
+	ingresses := []kube.ComponentIngress{}
+	for _, component := range components {
+		deployment, err := cli.KubeClient.AppsV1().Deployments(namespace).Get(ctx, component.deployment, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		ingress := kube.ComponentIngress{
+			Name:  component.deployment,
+			Peers: component.peers,
+		}
+		if deployment.Spec.Selector != nil {
+			ingress.Selector = deployment.Spec.Selector.MatchLabels
+		}
+		if component.service != "" {
+			svc, err := cli.KubeClient.CoreV1().Services(namespace).Get(ctx, component.service, metav1.GetOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				return nil, err
+			}
+			if err == nil {
+				for _, port := range svc.Spec.Ports {
+					ingress.Ports = append(ingress.Ports, resolveTargetPort(port, deployment))
+				}
+			}
+		}
+		ingresses = append(ingresses, ingress)
+	}
+	return ingresses, nil
+}
+
+// resolveTargetPort returns the container port a service port targets, the
+// name of a target port being looked up in the containers of the deployment
+func resolveTargetPort(port corev1.ServicePort, deployment *appsv1.Deployment) intstr.IntOrString {
+	if port.TargetPort.Type == intstr.String {
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			for _, cp := range container.Ports {
+				if cp.Name == port.TargetPort.StrVal {
+					return intstr.FromInt(int(cp.ContainerPort))
+				}
+			}
+		}
+		// network policies match named ports against the pod containers too
+		return port.TargetPort
+	}
+	if port.TargetPort.IntVal == 0 {
+		return intstr.FromInt(int(port.Port))
+	}
+	return port.TargetPort
+}
+
+func (cli *VanClient) reconcileComponentNetworkPolicies(ctx context.Context, ownerRefs []metav1.OwnerReference, namespace string) error {
+	components, err := cli.componentIngresses(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	return kube.ReconcileComponentNetworkPolicies(components, ownerRefs, namespace, cli.KubeClient)
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestResolveTargetPort(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "router", Ports: []corev1.ContainerPort{{Name: "amqps", ContainerPort: 5671}}},
+						{Name: "config-sync", Ports: []corev1.ContainerPort{{Name: "claims", ContainerPort: 8081}}},
+					},
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name     string
+		port     corev1.ServicePort
+		expected intstr.IntOrString
+	}{
+		{
+			name:     "number",
+			port:     corev1.ServicePort{Port: 55671, TargetPort: intstr.FromInt(55671)},
+			expected: intstr.FromInt(55671),
+		},
+		{
+			name:     "unset",
+			port:     corev1.ServicePort{Port: 45671},
+			expected: intstr.FromInt(45671),
+		},
+		{
+			name:     "name of a sidecar port",
+			port:     corev1.ServicePort{Port: 8081, TargetPort: intstr.FromString("claims")},
+			expected: intstr.FromInt(8081),
+		},
+		{
+			name:     "unknown name",
+			port:     corev1.ServicePort{Port: 8080, TargetPort: intstr.FromString("metrics")},
+			expected: intstr.FromString("metrics"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.DeepEqual(t, resolveTargetPort(test.port, deployment), test.expected)
+		})
+	}
+}
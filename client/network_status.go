@@ -33,3 +33,13 @@ func (cli *VanClient) NetworkStatus(ctx context.Context) (*network.NetworkStatus
 
 	return vanInfo, nil
 }
+
+// NetworkStatusHistory returns the snapshots of the network kept by the
+// flow collector along with the network status
+func (cli *VanClient) NetworkStatusHistory(ctx context.Context) ([]network.NetworkSnapshot, error) {
+	configmap, err := k8s.GetConfigMap(types.NetworkStatusConfigMapName, cli.Namespace, cli.KubeClient)
+	if err != nil {
+		return nil, err
+	}
+	return network.UnmarshalNetworkStatusHistory(configmap.Data[network.NetworkStatusHistoryKey])
+}
@@ -98,6 +98,10 @@ func ToSpecGenerator(c *container.Container) *models.SpecGenerator {
 		}
 	}
 
+	if c.Healthcheck != nil {
+		spec.Healthconfig = ToHealthConfig(c.Healthcheck)
+	}
+
 	if c.Annotations != nil && c.Annotations["io.podman.annotations.label"] == "disable" {
 		spec.SelinuxOpts = append(spec.SelinuxOpts, "disable")
 	}
@@ -119,6 +123,33 @@ func ToSpecGenerator(c *container.Container) *models.SpecGenerator {
 	return spec
 }
 
+// ToHealthConfig returns the healthcheck of a container as set through the
+// --health-* flags of the CLI, the command being run without a shell
+func ToHealthConfig(hc *container.Healthcheck) *models.Schema2HealthConfig {
+	return &models.Schema2HealthConfig{
+		Test:        append([]string{"CMD"}, hc.Command...),
+		Interval:    models.Duration(hc.Interval),
+		Timeout:     models.Duration(hc.Timeout),
+		StartPeriod: models.Duration(hc.StartPeriod),
+		Retries:     int64(hc.Retries),
+	}
+}
+
+// FromHealthConfig returns the healthcheck of an inspected container, nil
+// when the container has none or when it is disabled
+func FromHealthConfig(hc *models.Schema2HealthConfig) *container.Healthcheck {
+	if hc == nil || len(hc.Test) < 2 || hc.Test[0] != "CMD" {
+		return nil
+	}
+	return &container.Healthcheck{
+		Command:     hc.Test[1:],
+		Interval:    time.Duration(hc.Interval),
+		Timeout:     time.Duration(hc.Timeout),
+		StartPeriod: time.Duration(hc.StartPeriod),
+		Retries:     int(hc.Retries),
+	}
+}
+
 func ToPortmappings(c *container.Container) []*models.PortMapping {
 	var mapping []*models.PortMapping
 	for _, port := range c.Ports {
@@ -151,116 +182,10 @@ func (p *PodmanRestClient) ContainerCreate(container *container.Container) error
 }
 
 // ContainerUpdate replaces the container (by name) with an identical copy
-// with an applied Customization (required).
-// To achieve that, it follows this procedure:
-// - Create a new container
-// - Stop current container
-// - Rename current container
-// - Rename the new container (replacing the original one)
-// - Starts the new container
-// - Removes the original container
-//
-// In case of failures during this process, the original container is restored.
+// with an applied Customization (required), as done by
+// container.UpdateContainer.
 func (p *PodmanRestClient) ContainerUpdate(name string, fn func(newContainer *container.Container)) (*container.Container, error) {
-	if fn == nil {
-		return nil, fmt.Errorf("at least one customization is needed")
-	}
-
-	datetime := time.Now().Format("20060102150405")
-	c, err := p.ContainerInspect(name)
-	if err != nil {
-		return nil, err
-	}
-
-	newContainerName := fmt.Sprintf("%s-new-%s", c.Name, datetime)
-	cc := &container.Container{
-		Name:           newContainerName,
-		Image:          c.Image,
-		Env:            c.Env,
-		Labels:         c.Labels,
-		Annotations:    c.Annotations,
-		Networks:       c.Networks,
-		Mounts:         c.Mounts,
-		FileMounts:     c.FileMounts,
-		Ports:          c.Ports,
-		EntryPoint:     c.EntryPoint,
-		Command:        c.Command,
-		RestartPolicy:  c.RestartPolicy,
-		MaxCpus:        c.MaxCpus,
-		MaxMemoryBytes: c.MaxMemoryBytes,
-	}
-
-	// apply new container customization
-	fn(cc)
-	if cc.Name != newContainerName {
-		return nil, fmt.Errorf("container name cannot be changed")
-	}
-
-	// creating a new container
-	err = p.ContainerCreate(cc)
-	if err != nil {
-		return cc, err
-	}
-
-	// rollback this one in case of failures below
-	defer func() {
-		if err != nil {
-			_ = p.ContainerStop(cc.Name)
-			_ = p.ContainerRemove(cc.Name)
-		}
-	}()
-
-	// stopping current container
-	err = p.ContainerStop(name)
-	if err != nil {
-		return cc, err
-	}
-
-	// restarting current container
-	defer func() {
-		if err != nil {
-			_ = p.ContainerStart(name)
-		}
-	}()
-
-	// renaming current container to a backup name
-	backupName := fmt.Sprintf("%s-%s", name, datetime)
-	err = p.ContainerRename(name, backupName)
-	if err != nil {
-		return cc, err
-	}
-
-	// restoring original container
-	defer func() {
-		if err != nil {
-			_ = p.ContainerRename(backupName, name)
-		}
-	}()
-
-	// renaming new container to current name
-	err = p.ContainerRename(cc.Name, name)
-	if err != nil {
-		return cc, err
-	}
-
-	defer func() {
-		if err != nil {
-			_ = p.ContainerRename(name, newContainerName)
-		}
-	}()
-
-	// starting new container
-	err = p.ContainerStart(name)
-	if err != nil {
-		return cc, err
-	} else {
-		if removeErr := p.ContainerRemove(backupName); removeErr != nil {
-			fmt.Printf("Unable to remove backup container: %s - %s", backupName, err)
-			fmt.Println()
-		}
-	}
-
-	return cc, nil
+	return container.UpdateContainer(p, name, fn)
 }
 
 // ContainerUpdateImage updates the image used by the given container (name).
@@ -486,6 +411,7 @@ func FromInspectContainer(c containers.ContainerInspectLibpodOKBody) *container.
 			ct.EntryPoint = []string{config.Entrypoint}
 		}
 		ct.Command = config.Cmd
+		ct.Healthcheck = FromHealthConfig(config.Healthcheck)
 	}
 
 	// HostConfig
@@ -495,7 +421,11 @@ func FromInspectContainer(c containers.ContainerInspectLibpodOKBody) *container.
 			ct.RestartPolicy = hostConfig.RestartPolicy.Name
 		}
 		if hostConfig.CPUQuota > 0 {
-			ct.MaxCpus = int(hostConfig.CPUQuota / 100000)
+			period := int64(100000)
+			if hostConfig.CPUPeriod > 0 {
+				period = int64(hostConfig.CPUPeriod)
+			}
+			ct.MaxCpus = float64(hostConfig.CPUQuota) / float64(period)
 		}
 		if hostConfig.Memory > 0 {
 			ct.MaxMemoryBytes = hostConfig.Memory
@@ -543,6 +473,9 @@ func FromInspectContainer(c containers.ContainerInspectLibpodOKBody) *container.
 		ct.StartedAt = time.Time(c.State.StartedAt)
 		ct.ExitedAt = time.Time(c.State.FinishedAt)
 		ct.ExitCode = int(c.State.ExitCode)
+		if c.State.Health != nil {
+			ct.Health = c.State.Health.Status
+		}
 	}
 
 	return ct
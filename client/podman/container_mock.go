@@ -284,6 +284,12 @@ func (r *RestClientMock) HandleContainerInspect(operation *runtime.ClientOperati
 			StartedAt:  strfmt.DateTime(c.StartedAt),
 		},
 	}
+	if c.Healthcheck != nil {
+		res.Payload.Config.Healthcheck = ToHealthConfig(c.Healthcheck)
+	}
+	if c.Health != "" {
+		res.Payload.State.Health = &models.HealthCheckResults{Status: c.Health}
+	}
 	if c.MaxCpus > 0 || c.MaxMemoryBytes > 0 {
 		res.Payload.HostConfig = &models.InspectContainerHostConfig{
 			CPUQuota:  int64(c.MaxCpus * 100000),
@@ -364,11 +370,12 @@ func (r *RestClientMock) HandleContainerCreate(operation *runtime.ClientOperatio
 		Command:       spec.Command,
 		RestartPolicy: spec.RestartPolicy,
 		RestartCount:  int(spec.RestartRetries),
+		Healthcheck:   FromHealthConfig(spec.Healthconfig),
 		CreatedAt:     time.Now(),
 	}
 	if spec.ResourceLimits != nil {
 		if spec.ResourceLimits.CPU != nil {
-			c.MaxCpus = int(spec.ResourceLimits.CPU.Quota / 100000)
+			c.MaxCpus = float64(spec.ResourceLimits.CPU.Quota) / 100000
 		}
 		if spec.ResourceLimits.Memory != nil {
 			c.MaxMemoryBytes = spec.ResourceLimits.Memory.Limit
@@ -294,7 +294,7 @@ func TestContainerCreateMock(t *testing.T) {
 	err := cli.ContainerCreate(&container.Container{
 		Name:           "sample-container",
 		Image:          "sample-image",
-		MaxCpus:        2,
+		MaxCpus:        1.5,
 		MaxMemoryBytes: 1024 * 1024 * 1024,
 	})
 	assert.Assert(t, err)
@@ -302,7 +302,7 @@ func TestContainerCreateMock(t *testing.T) {
 	ci, err := cli.ContainerInspect("sample-container")
 	assert.Assert(t, err)
 
-	assert.Equal(t, 2, ci.MaxCpus)
+	assert.Equal(t, 1.5, ci.MaxCpus)
 	assert.Equal(t, int64(1024*1024*1024), ci.MaxMemoryBytes)
 }
 
@@ -11,6 +11,7 @@ import (
 	"github.com/go-openapi/runtime"
 	"github.com/skupperproject/skupper/client/container"
 	"github.com/skupperproject/skupper/client/generated/libpod/client/images"
+	"github.com/skupperproject/skupper/pkg/utils"
 )
 
 const (
@@ -81,8 +82,8 @@ func (p *PodmanRestClient) ImagePull(ctx context.Context, id string) error {
 	params.Arch = stringP("")
 	params.OS = stringP("")
 	params.Variant = stringP("")
-	params.Policy = stringP("always")
-	params.XRegistryAuth = getXRegistryAuth(id)
+	params.Policy = stringP(p.pullOptions.GetPolicy())
+	params.XRegistryAuth = p.registryAuth(id)
 
 	// Need to do that as the default response reader is being closed too soon
 	reader := &responseReaderJSONErrorBody{}
@@ -126,21 +127,46 @@ func (p *PodmanRestClient) ImagePull(ctx context.Context, id string) error {
 	return nil
 }
 
-func getXRegistryAuth(image string) *string {
-	authFile := os.Getenv("REGISTRY_AUTH_FILE")
+// SetPullOptions defines the pull policy and the registry credentials of
+// the images pulled afterwards
+func (p *PodmanRestClient) SetPullOptions(options container.PullOptions) {
+	p.pullOptions = options
+}
+
+// registryAuth returns the credentials of the registry of the image, the
+// ones given through the pull options or read from the auth file
+func (p *PodmanRestClient) registryAuth(image string) *string {
+	if p.pullOptions.Username != "" {
+		return encodeRegistryAuth(strings.Split(image, "/")[0], p.pullOptions.Username, p.pullOptions.Password)
+	}
+	return getXRegistryAuth(image, utils.DefaultStr(p.pullOptions.AuthFile, os.Getenv("REGISTRY_AUTH_FILE")))
+}
+
+func encodeRegistryAuth(imageServer, username, password string) *string {
+	registryAuthMap := map[string]map[string]string{}
+	registryAuthMap[imageServer] = map[string]string{
+		"username": username,
+		"password": password,
+	}
+	registryAuthMapJson, _ := json.Marshal(registryAuthMap)
+	registryAuthMapB64 := base64.StdEncoding.EncodeToString(registryAuthMapJson)
+	return &registryAuthMapB64
+}
+
+func getXRegistryAuth(image string, authFile string) *string {
 	// use the default
 	if authFile == "" {
 		return nil
 	}
 	data, err := os.ReadFile(authFile)
 	if err != nil {
-		fmt.Printf("Unable to read REGISTRY_AUTH_FILE - %s", err)
+		fmt.Printf("Unable to read registry auth file %s - %s", authFile, err)
 		fmt.Println()
 		return nil
 	}
 	var jsonData map[string]interface{}
 	if err = json.Unmarshal(data, &jsonData); err != nil {
-		fmt.Printf("Unable to parse REGISTRY_AUTH_FILE - %s", err)
+		fmt.Printf("Unable to parse registry auth file %s - %s", authFile, err)
 		fmt.Println()
 		return nil
 	}
@@ -167,14 +193,7 @@ func getXRegistryAuth(image string) *string {
 				if len(credentials) != 2 {
 					return nil
 				}
-				registryAuthMap := map[string]map[string]string{}
-				registryAuthMap[imageServer] = map[string]string{
-					"username": credentials[0],
-					"password": credentials[1],
-				}
-				registryAuthMapJson, _ := json.Marshal(registryAuthMap)
-				registryAuthMapB64 := base64.StdEncoding.EncodeToString(registryAuthMapJson)
-				return &registryAuthMapB64
+				return encodeRegistryAuth(imageServer, credentials[0], credentials[1])
 			}
 		}
 	}
@@ -18,7 +18,7 @@ const (
 //
 
 type informerCommon struct {
-	cli          *PodmanRestClient
+	cli          container.Client
 	resyncPeriod time.Duration
 }
 
@@ -35,7 +35,7 @@ type updateNotification[T any] struct {
 // Container informer
 //
 
-func NewContainerInformer(cli *PodmanRestClient) *ContainerInformer {
+func NewContainerInformer(cli container.Client) *ContainerInformer {
 	return &ContainerInformer{
 		informerCommon: informerCommon{
 			cli:          cli,
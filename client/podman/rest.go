@@ -17,6 +17,8 @@ import (
 	"github.com/go-openapi/runtime"
 	runtimeclient "github.com/go-openapi/runtime/client"
 	"github.com/go-openapi/strfmt"
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
 	"github.com/skupperproject/skupper/client/generated/libpod/models"
 	"github.com/skupperproject/skupper/pkg/config"
 	"github.com/skupperproject/skupper/pkg/utils"
@@ -34,8 +36,9 @@ var (
 )
 
 type PodmanRestClient struct {
-	RestClient runtime.ClientTransport
-	endpoint   string
+	RestClient  runtime.ClientTransport
+	endpoint    string
+	pullOptions container.PullOptions
 }
 
 type RestClientFactory func(endpoint, basePath string) (*PodmanRestClient, error)
@@ -49,8 +52,16 @@ func NewPodmanClient(endpoint, basePath string) (*PodmanRestClient, error) {
 	}
 
 	var u *url.URL
+	var sshEndpoint *url.URL
+	var tlsConfig *tls.Config
 	isSockFile := strings.HasPrefix(endpoint, "/")
-	if isSockFile || strings.HasPrefix(endpoint, "unix://") {
+	if IsSSHEndpoint(endpoint) {
+		sshEndpoint, err = ParseSSHEndpoint(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		u = &url.URL{Scheme: "http", Host: "podman"}
+	} else if isSockFile || strings.HasPrefix(endpoint, "unix://") {
 		if isSockFile {
 			endpoint = "unix://" + endpoint
 		}
@@ -63,6 +74,14 @@ func NewPodmanClient(endpoint, basePath string) (*PodmanRestClient, error) {
 		u.Host = "unix"
 	} else {
 		host := endpoint
+		if IsTLSEndpoint(host) {
+			var tlsEndpoint *url.URL
+			tlsEndpoint, tlsConfig, err = ParseTLSEndpoint(host)
+			if err != nil {
+				return nil, err
+			}
+			host = tlsEndpoint.String()
+		}
 		match, _ := regexp.Match(`(http[s]*|tcp)://`, []byte(host))
 		if !match {
 			if !strings.Contains(host, "://") {
@@ -112,18 +131,22 @@ func NewPodmanClient(endpoint, basePath string) (*PodmanRestClient, error) {
 		TLSHandshakeTimeout:   10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
 	}
-	if u.Scheme == "https" {
+	if tlsConfig != nil {
+		// https endpoints, verified when a CA certificate is given
 		ct := c.Transport.(*http.Transport)
-		if ct.TLSClientConfig == nil {
-			ct.TLSClientConfig = &tls.Config{}
-		}
-		ct.TLSClientConfig.InsecureSkipVerify = true
+		ct.TLSClientConfig = tlsConfig
 	} else {
 		ct := c.Transport.(*http.Transport)
 		ct.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
 			return net.Dial("tcp", hostPort)
 		}
 	}
+	if sshEndpoint != nil {
+		ct := c.Transport.(*http.Transport)
+		ct.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialSSH(sshEndpoint)
+		}
+	}
 	if isSockFile {
 		_, err := os.Stat(u.RequestURI())
 		if err != nil {
@@ -149,14 +172,33 @@ func GetDefaultPodmanEndpoint() string {
 	return fmt.Sprintf("unix://%s/podman/podman.sock", config.GetRuntimeDir())
 }
 
+// IsSockEndpoint tells if the podman service is reached through a socket
+// of the host it runs on, locally or over ssh
 func (p *PodmanRestClient) IsSockEndpoint() bool {
-	return strings.HasPrefix(p.endpoint, "/") || strings.HasPrefix(p.endpoint, "unix://")
+	return strings.HasPrefix(p.endpoint, "/") || strings.HasPrefix(p.endpoint, "unix://") || IsSSHEndpoint(p.endpoint)
+}
+
+// IsSSHEndpoint tells if the podman service runs on a remote host
+func (p *PodmanRestClient) IsSSHEndpoint() bool {
+	return IsSSHEndpoint(p.endpoint)
+}
+
+// GetSockFile returns the path of the podman socket on the host it runs on
+func (p *PodmanRestClient) GetSockFile() string {
+	if u, err := ParseSSHEndpoint(p.endpoint); err == nil {
+		return u.Path
+	}
+	return strings.TrimPrefix(p.endpoint, "unix://")
 }
 
 func (p *PodmanRestClient) GetEndpoint() string {
 	return p.endpoint
 }
 
+func (p *PodmanRestClient) Platform() types.Platform {
+	return types.PlatformPodman
+}
+
 func (p *PodmanRestClient) IsRunningInContainer() bool {
 	// See: https://docs.podman.io/en/latest/markdown/podman-run.1.html
 	_, err := os.Stat("/run/.containerenv")
@@ -0,0 +1,141 @@
+package podman
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sshCommand is the ssh client used to reach the podman service of remote
+// hosts, so the user's ssh configuration, keys and agent apply
+var sshCommand = "ssh"
+
+// IsSSHEndpoint tells if the endpoint is a remote host reached over ssh, as
+// in ssh://user@host[:port]/run/user/1000/podman/podman.sock
+func IsSSHEndpoint(endpoint string) bool {
+	return strings.HasPrefix(endpoint, "ssh://")
+}
+
+// ParseSSHEndpoint validates an ssh endpoint, which must name the podman
+// socket on the remote host, the optional identity query parameter names
+// the private key to use
+func ParseSSHEndpoint(endpoint string) (*url.URL, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "ssh" || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid ssh endpoint %q, expected ssh://[user@]host[:port]/path/to/podman.sock", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		return nil, fmt.Errorf("invalid ssh endpoint %q, the path of the remote podman socket is required", endpoint)
+	}
+	return u, nil
+}
+
+func sshArgs(u *url.URL) []string {
+	args := []string{"-o", "BatchMode=yes"}
+	if u.Port() != "" {
+		args = append(args, "-p", u.Port())
+	}
+	if identity := u.Query().Get("identity"); identity != "" {
+		args = append(args, "-i", identity)
+	}
+	destination := u.Hostname()
+	if u.User != nil && u.User.Username() != "" {
+		destination = u.User.Username() + "@" + destination
+	}
+	return append(args, "--", destination, "podman", "--url", "unix://"+u.Path, "system", "dial-stdio")
+}
+
+// sshConn is a connection to the remote podman service, proxied through
+// the standard input and output of an ssh session
+type sshConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	stderr *sshStderr
+	once   sync.Once
+}
+
+// sshStderr keeps what ssh reports, written while the connection is read
+type sshStderr struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (s *sshStderr) Write(b []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.buf.Len() > 4096 {
+		return len(b), nil
+	}
+	return s.buf.Write(b)
+}
+
+func (s *sshStderr) String() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.buf.String()
+}
+
+func dialSSH(u *url.URL) (net.Conn, error) {
+	cmd := exec.Command(sshCommand, sshArgs(u)...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	conn := &sshConn{cmd: cmd, stdin: stdin, stdout: stdout, stderr: &sshStderr{}}
+	cmd.Stderr = conn.stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("unable to run %s: %w", sshCommand, err)
+	}
+	return conn, nil
+}
+
+func (c *sshConn) Read(b []byte) (int, error) {
+	n, err := c.stdout.Read(b)
+	if err == io.EOF && n == 0 {
+		// the session ended, report why if ssh said anything
+		if detail := strings.TrimSpace(c.stderr.String()); detail != "" {
+			return 0, fmt.Errorf("ssh session to podman ended: %s", detail)
+		}
+	}
+	return n, err
+}
+
+func (c *sshConn) Write(b []byte) (int, error) {
+	return c.stdin.Write(b)
+}
+
+func (c *sshConn) Close() error {
+	c.once.Do(func() {
+		c.stdin.Close()
+		if c.cmd.Process != nil {
+			c.cmd.Process.Kill()
+		}
+		c.cmd.Wait()
+	})
+	return nil
+}
+
+type sshAddr string
+
+func (a sshAddr) Network() string { return "ssh" }
+func (a sshAddr) String() string  { return string(a) }
+
+func (c *sshConn) LocalAddr() net.Addr                { return sshAddr("local") }
+func (c *sshConn) RemoteAddr() net.Addr               { return sshAddr(c.cmd.Path) }
+func (c *sshConn) SetDeadline(t time.Time) error      { return nil }
+func (c *sshConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *sshConn) SetWriteDeadline(t time.Time) error { return nil }
@@ -0,0 +1,42 @@
+package podman
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSSHEndpoint(t *testing.T) {
+	testTable := []struct {
+		doc      string
+		endpoint string
+		args     []string
+		err      string
+	}{
+		{
+			doc:      "host",
+			endpoint: "ssh://edge.example.com/run/podman/podman.sock",
+			args:     []string{"-o", "BatchMode=yes", "--", "edge.example.com", "podman", "--url", "unix:///run/podman/podman.sock", "system", "dial-stdio"},
+		},
+		{
+			doc:      "user port identity",
+			endpoint: "ssh://skupper@edge.example.com:2222/run/user/1000/podman/podman.sock?identity=/home/skupper/.ssh/id_ed25519",
+			args: []string{"-o", "BatchMode=yes", "-p", "2222", "-i", "/home/skupper/.ssh/id_ed25519", "--",
+				"skupper@edge.example.com", "podman", "--url", "unix:///run/user/1000/podman/podman.sock", "system", "dial-stdio"},
+		},
+		{doc: "no socket", endpoint: "ssh://edge.example.com", err: "path of the remote podman socket is required"},
+		{doc: "no host", endpoint: "ssh:///run/podman/podman.sock", err: "invalid ssh endpoint"},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			assert.Assert(t, IsSSHEndpoint(test.endpoint))
+			u, err := ParseSSHEndpoint(test.endpoint)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			assert.DeepEqual(t, sshArgs(u), test.args)
+		})
+	}
+}
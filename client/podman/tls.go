@@ -0,0 +1,129 @@
+package podman
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	// TLSCAParam names the CA certificate the podman service certificate
+	// is verified with
+	TLSCAParam = "tls-ca"
+	// TLSCertParam and TLSKeyParam name the client certificate and key
+	// presented to the podman service
+	TLSCertParam = "tls-cert"
+	TLSKeyParam  = "tls-key"
+)
+
+// TLSParams are the query parameters of a tls endpoint naming files
+var TLSParams = []string{TLSCAParam, TLSCertParam, TLSKeyParam}
+
+// IsTLSEndpoint tells if the endpoint is a podman service reached over tls,
+// as in https://host:port or tcp://host:port?tls-ca=/path/to/ca.pem
+func IsTLSEndpoint(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "tcp":
+		q := u.Query()
+		for _, param := range TLSParams {
+			if q.Get(param) != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseTLSEndpoint validates a tls endpoint, returning its url without the
+// tls parameters and the tls configuration they define. The certificate of
+// the podman service is only verified when a CA certificate is given.
+func ParseTLSEndpoint(endpoint string) (*url.URL, *tls.Config, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	if (u.Scheme != "https" && u.Scheme != "tcp") || u.Hostname() == "" {
+		return nil, nil, fmt.Errorf("invalid tls endpoint %q, expected tcp://host:port or https://host:port", endpoint)
+	}
+	q := u.Query()
+	ca, cert, key := q.Get(TLSCAParam), q.Get(TLSCertParam), q.Get(TLSKeyParam)
+	for _, param := range TLSParams {
+		q.Del(param)
+	}
+	u.RawQuery = q.Encode()
+	u.Scheme = "https"
+
+	tlsConfig := &tls.Config{
+		ServerName: u.Hostname(),
+	}
+	if ca == "" {
+		tlsConfig.InsecureSkipVerify = true
+	} else {
+		caData, err := os.ReadFile(ca)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read the tls ca certificate - %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, nil, fmt.Errorf("no certificate found in the tls ca certificate %s", ca)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if (cert == "") != (key == "") {
+		return nil, nil, fmt.Errorf("the tls client certificate and key must be given together")
+	}
+	if cert != "" {
+		clientCert, err := tls.LoadX509KeyPair(cert, key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to load the tls client certificate - %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+	return u, tlsConfig, nil
+}
+
+// TLSFiles returns the files named by the tls parameters of the endpoint
+func TLSFiles(endpoint string) map[string]string {
+	files := map[string]string{}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return files
+	}
+	for _, param := range TLSParams {
+		if file := u.Query().Get(param); file != "" {
+			files[param] = file
+		}
+	}
+	return files
+}
+
+// WithTLSFiles returns the endpoint with its tls parameters naming the
+// given files
+func WithTLSFiles(endpoint string, files map[string]string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	q := u.Query()
+	changed := false
+	for _, param := range TLSParams {
+		if file := strings.TrimSpace(files[param]); file != "" {
+			q.Set(param, file)
+			changed = true
+		}
+	}
+	if !changed {
+		return endpoint
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,76 @@
+package podman
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/skupperproject/skupper/pkg/certs"
+	"gotest.tools/assert"
+)
+
+func TestTLSEndpoint(t *testing.T) {
+	dir := t.TempDir()
+	ca := certs.GenerateCASecret("podman-ca", "podman-ca")
+	client := certs.GenerateSecret("podman-client", "skupper", "", &ca)
+	files := map[string][]byte{
+		"ca.pem":   ca.Data["tls.crt"],
+		"cert.pem": client.Data["tls.crt"],
+		"key.pem":  client.Data["tls.key"],
+		"bad.pem":  []byte("not a certificate"),
+	}
+	for name, data := range files {
+		assert.Assert(t, os.WriteFile(path.Join(dir, name), data, 0600))
+	}
+	caFile, certFile, keyFile := path.Join(dir, "ca.pem"), path.Join(dir, "cert.pem"), path.Join(dir, "key.pem")
+
+	testTable := []struct {
+		doc      string
+		endpoint string
+		tls      bool
+		url      string
+		verify   bool
+		client   bool
+		err      string
+	}{
+		{doc: "tcp", endpoint: "tcp://lab.example.com:8888"},
+		{doc: "unix", endpoint: "unix:///run/podman/podman.sock"},
+		{doc: "https", endpoint: "https://lab.example.com:8888", tls: true, url: "https://lab.example.com:8888"},
+		{doc: "ca", endpoint: "tcp://lab.example.com:8888?tls-ca=" + caFile, tls: true, url: "https://lab.example.com:8888", verify: true},
+		{doc: "client certificate", endpoint: WithTLSFiles("tcp://lab.example.com:8888", map[string]string{
+			TLSCAParam: caFile, TLSCertParam: certFile, TLSKeyParam: keyFile,
+		}), tls: true, url: "https://lab.example.com:8888", verify: true, client: true},
+		{doc: "certificate without key", endpoint: "tcp://lab.example.com:8888?tls-cert=" + certFile, tls: true,
+			err: "the tls client certificate and key must be given together"},
+		{doc: "invalid ca", endpoint: "tcp://lab.example.com:8888?tls-ca=" + path.Join(dir, "bad.pem"), tls: true,
+			err: "no certificate found in the tls ca certificate"},
+		{doc: "missing ca", endpoint: "tcp://lab.example.com:8888?tls-ca=" + path.Join(dir, "missing.pem"), tls: true,
+			err: "unable to read the tls ca certificate"},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			assert.Equal(t, IsTLSEndpoint(test.endpoint), test.tls)
+			if !test.tls {
+				return
+			}
+			u, tlsConfig, err := ParseTLSEndpoint(test.endpoint)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			assert.Equal(t, u.String(), test.url)
+			assert.Equal(t, tlsConfig.ServerName, "lab.example.com")
+			assert.Equal(t, tlsConfig.InsecureSkipVerify, !test.verify)
+			assert.Equal(t, tlsConfig.RootCAs != nil, test.verify)
+			assert.Equal(t, len(tlsConfig.Certificates) > 0, test.client)
+		})
+	}
+}
+
+func TestTLSFiles(t *testing.T) {
+	endpoint := WithTLSFiles("tcp://lab.example.com:8888", map[string]string{TLSCAParam: "/keys/ca.pem", TLSKeyParam: ""})
+	assert.Equal(t, endpoint, "tcp://lab.example.com:8888?tls-ca=%2Fkeys%2Fca.pem")
+	assert.DeepEqual(t, TLSFiles(endpoint), map[string]string{TLSCAParam: "/keys/ca.pem"})
+	assert.Equal(t, WithTLSFiles("tcp://lab.example.com:8888", nil), "tcp://lab.example.com:8888")
+}
@@ -21,6 +21,9 @@ func (p *PodmanRestClient) Version() (*container.Version, error) {
 		v.Arch = info.Payload.Host.Arch
 		v.Kernel = info.Payload.Host.Kernel
 		v.OS = info.Payload.Host.OS
+		if security := info.Payload.Host.Security; security != nil {
+			v.Rootless = security.Rootless
+		}
 	}
 
 	return v, nil
@@ -2,11 +2,13 @@ package podman
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/client/container"
 	"github.com/skupperproject/skupper/client/generated/libpod/client/volumes"
 	"github.com/skupperproject/skupper/client/generated/libpod/models"
+	"github.com/skupperproject/skupper/pkg/utils"
 )
 
 func (p *PodmanRestClient) VolumeCreate(volume *container.Volume) (*container.Volume, error) {
@@ -26,17 +28,19 @@ func (p *PodmanRestClient) VolumeCreate(volume *container.Volume) (*container.Vo
 
 func ToVolumeCreateOptions(v *container.Volume) *models.VolumeCreateOptions {
 	nv := &models.VolumeCreateOptions{
-		Name:   v.Name,
-		Labels: v.Labels,
+		Name:    v.Name,
+		Labels:  v.Labels,
+		Options: v.Options,
 	}
 	return nv
 }
 
 func FromCreatedToVolume(created *volumes.VolumeCreateLibpodCreated) *container.Volume {
 	v := &container.Volume{
-		Name:   created.Payload.Name,
-		Source: created.Payload.Mountpoint,
-		Labels: created.Payload.Labels,
+		Name:    created.Payload.Name,
+		Source:  container.VolumeSource(created.Payload.Mountpoint, created.Payload.Options),
+		Labels:  created.Payload.Labels,
+		Options: created.Payload.Options,
 	}
 	return v
 }
@@ -77,10 +81,15 @@ func FilesToMounts(c *container.Container) []*models.Mount {
 func VolumesToNamedVolumes(c *container.Container) []*models.NamedVolume {
 	var namedVolumes []*models.NamedVolume
 	for _, v := range c.Mounts {
+		// shared between containers unless other options are given
+		options := strings.Split(utils.DefaultStr(v.Mode, "z"), ",")
+		if !utils.StringSliceContains(options, "U") {
+			options = append(options, "U")
+		}
 		m := &models.NamedVolume{
 			Dest:    v.Destination,
 			Name:    v.Name,
-			Options: []string{"z", "U"}, // shared between containers
+			Options: options,
 		}
 		namedVolumes = append(namedVolumes, m)
 	}
@@ -101,9 +110,10 @@ func (p *PodmanRestClient) VolumeInspect(id string) (*container.Volume, error) {
 
 func FromInspectToVolume(vi *volumes.VolumeInspectLibpodOK) *container.Volume {
 	return &container.Volume{
-		Name:   vi.Payload.Name,
-		Source: vi.Payload.Mountpoint,
-		Labels: vi.Payload.Labels,
+		Name:    vi.Payload.Name,
+		Source:  container.VolumeSource(vi.Payload.Mountpoint, vi.Payload.Options),
+		Labels:  vi.Payload.Labels,
+		Options: vi.Payload.Options,
 	}
 }
 
@@ -121,9 +131,10 @@ func FromListToVolume(vi *volumes.VolumeListLibpodOK) []*container.Volume {
 	list := []*container.Volume{}
 	for _, pv := range vi.Payload {
 		list = append(list, &container.Volume{
-			Name:   pv.Name,
-			Source: pv.Mountpoint,
-			Labels: pv.Labels,
+			Name:    pv.Name,
+			Source:  container.VolumeSource(pv.Mountpoint, pv.Options),
+			Labels:  pv.Labels,
+			Options: pv.Options,
 		})
 	}
 	return list
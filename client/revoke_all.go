@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/skupperproject/skupper/api/types"
@@ -59,6 +60,7 @@ func (cli *VanClient) regenerateSiteSecret(ctx context.Context, ca *corev1.Secre
 		return err
 	}
 	siteServerSecret.Hosts = append(siteServerSecret.Hosts, hosts...)
+	siteServerSecret.Hosts = append(siteServerSecret.Hosts, siteconfig.Spec.CertificateHosts...)
 	_, err = kube.RegenerateCredentials(siteServerSecret, namespace, ca, cli.KubeClient)
 	if err != nil {
 		return err
@@ -82,5 +84,34 @@ func (cli *VanClient) RevokeAccess(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	// the certificates issued by the previous CA are no longer valid
+	err = cli.KubeClient.CoreV1().Secrets(cli.Namespace).Delete(ctx, types.SiteCrlSecret, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
 	return cli.regenerateSiteSecret(ctx, ca, cli.Namespace)
 }
+
+// RevokeLinkCredentials revokes the link credentials issued by the site CA
+// whose subject, serial number or fingerprint matches, keeping the CA and
+// the other links. The service controller closes the links established
+// with the revoked credentials, whenever they are established again.
+func (cli *VanClient) RevokeLinkCredentials(ctx context.Context, match string) ([]types.RevokedCertificate, error) {
+	if match == "" {
+		return nil, fmt.Errorf("The link credentials to revoke must be specified")
+	}
+	revoked, err := kube.RevokeIssuedCertificates(match, cli.Namespace, cli.KubeClient)
+	if err != nil {
+		return nil, err
+	}
+	result := []types.RevokedCertificate{}
+	for _, cert := range revoked {
+		result = append(result, types.RevokedCertificate{
+			Subject:     cert.Subject,
+			Serial:      cert.Serial,
+			Fingerprint: cert.Fingerprint,
+			RevokedAt:   *cert.RevokedAt,
+		})
+	}
+	return result, nil
+}
@@ -13,7 +13,9 @@ import (
 
 	routev1 "github.com/openshift/api/route/v1"
 	"github.com/skupperproject/skupper/pkg/config"
+	"github.com/skupperproject/skupper/pkg/fips"
 	"github.com/skupperproject/skupper/pkg/images"
+	"github.com/skupperproject/skupper/pkg/logging"
 	"github.com/skupperproject/skupper/pkg/version"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -318,9 +320,13 @@ func (cli *VanClient) GetVanControllerSpec(options types.SiteConfigSpec, van *ty
 	envVars = append(envVars, corev1.EnvVar{Name: "OWNER_NAME", Value: transport.ObjectMeta.Name})
 	envVars = append(envVars, corev1.EnvVar{Name: "OWNER_UID", Value: string(transport.ObjectMeta.UID)})
 	envVars = images.AddRouterImageOverrideToEnv(envVars)
+	if fips.Enabled() {
+		envVars = append(envVars, corev1.EnvVar{Name: fips.EnvVar, Value: "true"})
+	}
 	if !options.EnableServiceSync {
 		envVars = append(envVars, corev1.EnvVar{Name: "SKUPPER_DISABLE_SERVICE_SYNC", Value: "true"})
 	}
+	envVars = append(envVars, syslogEnvVars(options.SyslogAddress)...)
 
 	sidecars := []*corev1.Container{}
 	volumes := []corev1.Volume{}
@@ -346,6 +352,17 @@ func (cli *VanClient) GetVanControllerSpec(options types.SiteConfigSpec, van *ty
 		}
 		van.Collector.Image = images.GetFlowCollectorImageDetails()
 		van.Collector.EnvVar = envVars
+		if options.AuthMode == string(types.ConsoleAuthModeInternal) {
+			van.Collector.EnvVar = append(append([]corev1.EnvVar{}, envVars...), consoleLdapEnvVars()...)
+		}
+		if options.AuthMode != string(types.ConsoleAuthModeOpenshift) {
+			van.Collector.EnvVar = append(append([]corev1.EnvVar{}, van.Collector.EnvVar...), consoleClientCertEnvVars()...)
+			// the console sessions outlive a restart of the collector
+			van.Collector.EnvVar = append(van.Collector.EnvVar, corev1.EnvVar{Name: "FLOW_SESSION_KEY_FILE", Value: "/etc/console-session/key"})
+			kube.AppendSecretVolume(&volumes, &mounts[flowCollector], types.ConsoleSessionSecret, "/etc/console-session/")
+		}
+		van.Collector.EnvVar = append(append([]corev1.EnvVar{}, van.Collector.EnvVar...), consoleRolesEnvVars()...)
+		van.Collector.EnvVar = append(van.Collector.EnvVar, consoleTlsEnvVars(options.FlowCollector)...)
 		sidecars = append(sidecars, kube.ContainerForFlowCollector(van.Collector))
 		if options.AuthMode == string(types.ConsoleAuthModeOpenshift) {
 			csp := strconv.Itoa(int(types.ConsoleOpenShiftServicePort))
@@ -469,6 +486,13 @@ func (cli *VanClient) GetVanControllerSpec(options types.SiteConfigSpec, van *ty
 			if host != "" {
 				host = types.ConsoleRouteName + "-" + van.Namespace + "." + host
 			}
+			// the console answers the ACME challenges and serves the
+			// certificate obtained itself
+			termination := routev1.TLSTerminationReencrypt
+			if options.FlowCollector.AcmeDomains != "" {
+				host = strings.TrimSpace(strings.Split(options.FlowCollector.AcmeDomains, ",")[0])
+				termination = routev1.TLSTerminationPassthrough
+			}
 			routes = append(routes, &routev1.Route{
 				TypeMeta: metav1.TypeMeta{
 					APIVersion: "v1",
@@ -489,7 +513,7 @@ func (cli *VanClient) GetVanControllerSpec(options types.SiteConfigSpec, van *ty
 						Name: types.ControllerServiceName,
 					},
 					TLS: &routev1.TLSConfig{
-						Termination:                   routev1.TLSTerminationReencrypt,
+						Termination:                   termination,
 						InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect,
 					},
 				},
@@ -660,6 +684,91 @@ func (cli *VanClient) ClusterRoles(enablePermissions bool) []*rbacv1.ClusterRole
 	return clusterRoles
 }
 
+// consoleLdapEnvVars configures the collector to validate the console users
+// against the ldap server described by the optional skupper-console-ldap secret
+func consoleLdapEnvVars() []corev1.EnvVar {
+	return optionalSecretEnvVars(types.ConsoleLdapSecret, [][2]string{
+		{"FLOW_LDAP_URL", "url"},
+		{"FLOW_LDAP_BIND_DN", "bind-dn"},
+		{"FLOW_LDAP_BIND_PASSWORD", "bind-password"},
+		{"FLOW_LDAP_SEARCH_BASE", "search-base"},
+		{"FLOW_LDAP_USER_FILTER", "user-filter"},
+		{"FLOW_LDAP_START_TLS", "start-tls"},
+		{"FLOW_LDAP_CA", "ca.crt"},
+	})
+}
+
+// consoleClientCertEnvVars configures the collector to authenticate the api
+// clients presenting a certificate issued by the ca in the optional
+// skupper-console-client-ca secret
+func consoleClientCertEnvVars() []corev1.EnvVar {
+	return optionalSecretEnvVars(types.ConsoleClientCASecret, [][2]string{
+		{"FLOW_CLIENT_CA", "ca.crt"},
+		{"FLOW_CLIENT_CERT_REQUIRED", "required"},
+	})
+}
+
+// consoleRolesEnvVars configures how the collector assigns the viewer and
+// admin roles from the optional skupper-console-roles secret
+func consoleRolesEnvVars() []corev1.EnvVar {
+	return optionalSecretEnvVars(types.ConsoleRolesSecret, [][2]string{
+		{"FLOW_DEFAULT_ROLE", "default-role"},
+		{"FLOW_OIDC_ROLES_CLAIM", "oidc-claim"},
+		{"FLOW_RBAC_ROLES", "kubernetes-rbac"},
+	})
+}
+
+// consoleTlsEnvVars restricts the tls versions, cipher suites and curves
+// accepted by the console listener and has its certificate obtained through
+// ACME
+func consoleTlsEnvVars(options types.FlowCollectorOptions) []corev1.EnvVar {
+	envVars := []corev1.EnvVar{}
+	for _, item := range [][2]string{
+		{"FLOW_TLS_MIN_VERSION", options.TlsMinVersion},
+		{"FLOW_TLS_CIPHER_SUITES", options.TlsCipherSuites},
+		{"FLOW_TLS_CURVE_PREFERENCES", options.TlsCurvePreferences},
+		{"FLOW_ACME_DOMAINS", options.AcmeDomains},
+		{"FLOW_ACME_EMAIL", options.AcmeEmail},
+		{"FLOW_ACME_DIRECTORY", options.AcmeDirectory},
+	} {
+		if item[1] != "" {
+			envVars = append(envVars, corev1.EnvVar{Name: item[0], Value: item[1]})
+		}
+	}
+	return envVars
+}
+
+// syslogEnvVars has the controller and the collector send their logs to the
+// syslog endpoint, verifying a tls endpoint with the CA of the optional
+// skupper-syslog-ca secret
+func syslogEnvVars(address string) []corev1.EnvVar {
+	if address == "" {
+		return nil
+	}
+	envVars := []corev1.EnvVar{{Name: logging.SyslogEnvVar, Value: address}}
+	return append(envVars, optionalSecretEnvVars(types.SyslogCASecret, [][2]string{
+		{logging.SyslogCAEnvVar, "ca.crt"},
+	})...)
+}
+
+func optionalSecretEnvVars(secret string, items [][2]string) []corev1.EnvVar {
+	optional := true
+	envVars := []corev1.EnvVar{}
+	for _, item := range items {
+		envVars = append(envVars, corev1.EnvVar{
+			Name: item[0],
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secret},
+					Key:                  item[1],
+					Optional:             &optional,
+				},
+			},
+		})
+	}
+	return envVars
+}
+
 func configureDeployment(spec *types.DeploymentSpec, options *types.Tuning) error {
 	errs := []string{}
 	if options.Affinity != "" {
@@ -923,16 +1032,27 @@ func (cli *VanClient) GetRouterSpecFromOpts(options types.SiteConfigSpec, siteId
 		Labels: options.Labels,
 	})
 	if !isEdge {
-		cas = append(cas, types.CertAuthority{
+		siteCa := types.CertAuthority{
 			Name:   types.SiteCaSecret,
 			Labels: options.Labels,
-		})
+		}
+		if options.Vault.Address != "" {
+			vault := options.Vault
+			siteCa.Vault = &vault
+		}
+		cas = append(cas, siteCa)
 	}
 
 	cas = append(cas, types.CertAuthority{
 		Name:   types.ServiceCaSecret,
 		Labels: options.Labels,
 	})
+	if options.CertManager.Issuer != "" {
+		certManager := options.CertManager
+		for i := range cas {
+			cas[i].CertManager = &certManager
+		}
+	}
 
 	van.CertAuthoritys = cas
 
@@ -971,7 +1091,7 @@ func (cli *VanClient) GetRouterSpecFromOpts(options types.SiteConfigSpec, siteId
 			CA:          types.SiteCaSecret,
 			Name:        types.SiteServerSecret,
 			Subject:     types.TransportServiceName,
-			Hosts:       []string{types.TransportServiceName + "." + van.Namespace, types.TransportServiceName + "." + van.Namespace + ".svc.cluster.local"},
+			Hosts:       append([]string{types.TransportServiceName + "." + van.Namespace, types.TransportServiceName + "." + van.Namespace + ".svc.cluster.local"}, options.CertificateHosts...),
 			ConnectJson: false,
 			Post:        true,
 			Labels:      options.Labels,
@@ -992,6 +1112,14 @@ func (cli *VanClient) GetRouterSpecFromOpts(options types.SiteConfigSpec, siteId
 			Labels:      options.Labels,
 		})
 	}
+	if options.EnableFlowCollector && options.AuthMode != string(types.ConsoleAuthModeOpenshift) {
+		credentials = append(credentials, types.Credential{
+			Name:   types.ConsoleSessionSecret,
+			Data:   map[string][]byte{"key": []byte(utils.RandomId(64))},
+			Post:   false,
+			Labels: options.Labels,
+		})
+	}
 	van.TransportCredentials = credentials
 
 	// TODO: this is a hack for ports, fix this
@@ -1297,14 +1425,18 @@ sasldb_path: /tmp/skrouterd.sasldb
 		}
 	}
 	for _, ca := range van.CertAuthoritys {
-		_, err = kube.NewCertAuthority(ca, siteOwnerRef, van.Namespace, cli.KubeClient)
+		if ca.CertManager != nil {
+			_, err = kube.NewCertManagerCertAuthority(ca, siteOwnerRef, van.Namespace, cli.KubeClient, cli.DynamicClient)
+		} else {
+			_, err = kube.NewCertAuthority(ca, siteOwnerRef, van.Namespace, cli.KubeClient)
+		}
 		if err != nil && !errors.IsAlreadyExists(err) {
 			return err
 		}
 	}
 	for _, cred := range van.TransportCredentials {
 		if !cred.Post {
-			_, err = kube.NewSecret(cred, siteOwnerRef, van.Namespace, cli.KubeClient)
+			_, err = cli.newSecret(&options.Spec, cred, siteOwnerRef, van.Namespace)
 			if err != nil && !errors.IsAlreadyExists(err) {
 				return err
 			}
@@ -1412,7 +1544,7 @@ sasldb_path: /tmp/skrouterd.sasldb
 						}
 					}
 				}
-				kube.NewSecret(cred, siteOwnerRef, van.Namespace, cli.KubeClient)
+				cli.newSecret(&options.Spec, cred, siteOwnerRef, van.Namespace)
 			}
 		}
 	}
@@ -1494,7 +1626,7 @@ sasldb_path: /tmp/skrouterd.sasldb
 					return err
 				}
 			}
-			_, err = kube.NewSecret(cred, siteOwnerRef, van.Namespace, cli.KubeClient)
+			_, err = cli.newSecret(&options.Spec, cred, siteOwnerRef, van.Namespace)
 			if err != nil && !errors.IsAlreadyExists(err) {
 				return err
 			}
@@ -1525,6 +1657,13 @@ sasldb_path: /tmp/skrouterd.sasldb
 		}
 	}
 
+	if options.Spec.ComponentNetworkPolicies {
+		err = cli.reconcileComponentNetworkPolicies(ctx, ownerRefs, van.Namespace)
+		if err != nil {
+			return err
+		}
+	}
+
 	if options.Spec.EnableSkupperEvents {
 		err = kube.AddEventRecorderPermissions(van.Namespace, ownerRefs, cli.KubeClient, types.ControllerServiceAccountName)
 		if err != nil {
@@ -1535,6 +1674,15 @@ sasldb_path: /tmp/skrouterd.sasldb
 	return nil
 }
 
+// newSecret has cert-manager issue the credential when it manages the
+// certificates of the site
+func (cli *VanClient) newSecret(options *types.SiteConfigSpec, cred types.Credential, owner *metav1.OwnerReference, namespace string) (*corev1.Secret, error) {
+	if options.CertManager.Issuer != "" {
+		return kube.NewCertManagerSecret(cred, owner, namespace, cli.KubeClient, cli.DynamicClient)
+	}
+	return kube.NewSecret(cred, owner, namespace, cli.KubeClient)
+}
+
 func (cli *VanClient) appendIngressHost(prefixes []string, namespace string, cred *types.Credential) error {
 	routes, err := kube.GetIngressRoutes(types.IngressName, namespace, cli)
 	if err != nil {
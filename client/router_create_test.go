@@ -117,6 +117,7 @@ func TestRouterCreateDefaults(t *testing.T) {
 				types.LocalServerSecret,
 				types.LocalClientSecret,
 				types.ConsoleServerSecret,
+				types.ConsoleSessionSecret,
 				types.SiteServerSecret,
 				types.ServiceCaSecret,
 				types.ServiceClientSecret},
@@ -157,6 +158,7 @@ func TestRouterCreateDefaults(t *testing.T) {
 				types.SiteServerSecret,
 				"skupper-console-users",
 				types.ConsoleServerSecret,
+				types.ConsoleSessionSecret,
 				types.ServiceCaSecret,
 				types.ServiceClientSecret},
 			svcsExpected:        []string{types.LocalTransportServiceName, types.TransportServiceName, types.ControllerServiceName, types.PrometheusServiceName},
@@ -229,6 +231,7 @@ func TestRouterCreateDefaults(t *testing.T) {
 			roleBindingsExpected: []string{types.TransportRoleBindingName, types.ControllerRoleBindingName, types.PrometheusRoleBindingName},
 			secretsExpected: []string{types.LocalCaSecret,
 				types.ConsoleServerSecret,
+				types.ConsoleSessionSecret,
 				types.LocalServerSecret,
 				types.LocalClientSecret,
 				types.ServiceCaSecret,
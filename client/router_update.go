@@ -161,6 +161,12 @@ func (cli *VanClient) RouterUpdateVersionInNamespace(ctx context.Context, hup bo
 
 		site.Version = version.Version
 		config.SetSiteMetadata(&site)
+		if profile, ok := config.SslProfiles[types.InterRouterProfile]; ok && !config.IsEdge() && profile.UidFormat == "" {
+			// the links are identified by the fingerprint of the certificate
+			// of the linked site, so that the revoked ones can be closed
+			profile.UidFormat = qdr.UidFormatFingerprint
+			config.SslProfiles[types.InterRouterProfile] = profile
+		}
 
 		_, err = config.UpdateConfigMap(configmap)
 		if err != nil {
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	jsonencoding "encoding/json"
+	"fmt"
+
+	"github.com/skupperproject/skupper/api/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ServiceInterfaceManifest returns a partial skupper-services config map
+// holding only the given service definitions, as applied by the create and
+// bind commands. It is a fragment to merge into the config map of the site,
+// which holds the other services, either as a merge patch:
+//
+//	kubectl patch configmap skupper-services --type merge --patch-file <manifest>
+//
+// or through a server side apply with the field manager returned by
+// ServiceInterfaceFieldManager, each manager owning the keys of its service.
+// A plain kubectl apply of successive manifests removes the services of the
+// previous ones, as they are missing from the last applied configuration.
+func ServiceInterfaceManifest(namespace string, services ...*types.ServiceInterface) (*corev1.ConfigMap, error) {
+	configMap := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      types.ServiceInterfaceConfigMap,
+			Namespace: namespace,
+		},
+		Data: map[string]string{},
+	}
+	for _, service := range services {
+		encoded, err := jsonencoding.Marshal(service)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to encode service interface as json: %s", err)
+		}
+		configMap.Data[service.Address] = string(encoded)
+	}
+	return configMap, nil
+}
+
+// ServiceInterfaceFieldManager returns the field manager for the server side
+// apply of the manifest of a service, so that the manifests of different
+// services do not remove the keys of each other
+func ServiceInterfaceFieldManager(address string) string {
+	return "skupper-service-" + address
+}
+
+// ServiceInterfaceCreateManifest validates the service like ServiceInterfaceCreate
+// but returns the manifest that would be applied instead of applying it
+func (cli *VanClient) ServiceInterfaceCreateManifest(ctx context.Context, service *types.ServiceInterface) (*corev1.ConfigMap, error) {
+	policy := NewPolicyValidatorAPI(cli)
+	res, err := policy.Service(service.Address)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Allowed {
+		return nil, res.Err()
+	}
+	err = validateServiceInterface(service, cli)
+	if err != nil {
+		return nil, err
+	}
+	return ServiceInterfaceManifest(cli.Namespace, service)
+}
+
+// ServiceInterfaceBindManifest resolves the target like ServiceInterfaceBind
+// but returns the manifest that would be applied instead of applying it
+func (cli *VanClient) ServiceInterfaceBindManifest(ctx context.Context, service *types.ServiceInterface, targetType string, targetName string, targetPorts map[int]int, namespace string) (*corev1.ConfigMap, error) {
+	policy := NewPolicyValidatorAPI(cli)
+	res, err := policy.Expose(targetType, targetName)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Allowed {
+		return nil, res.Err()
+	}
+	err = validateServiceInterface(service, cli)
+	if err != nil {
+		return nil, err
+	}
+	err = bindServiceInterfaceTarget(cli, service, targetType, targetName, targetPorts, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return ServiceInterfaceManifest(cli.Namespace, service)
+}
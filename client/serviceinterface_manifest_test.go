@@ -0,0 +1,65 @@
+package client
+
+import (
+	jsonencoding "encoding/json"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/skupperproject/skupper/api/types"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServiceInterfaceManifest(t *testing.T) {
+	services := []*types.ServiceInterface{
+		{Address: "frontend", Protocol: "http", Ports: []int{8080}},
+		{Address: "backend", Protocol: "tcp", Ports: []int{5432}},
+	}
+	manifest, err := ServiceInterfaceManifest("west", services...)
+	assert.Assert(t, err)
+	assert.Equal(t, manifest.Kind, "ConfigMap")
+	assert.Equal(t, manifest.Name, types.ServiceInterfaceConfigMap)
+	assert.Equal(t, manifest.Namespace, "west")
+	assert.Equal(t, len(manifest.Data), 2)
+
+	decoded := types.ServiceInterface{}
+	assert.Assert(t, jsonencoding.Unmarshal([]byte(manifest.Data["frontend"]), &decoded))
+	assert.Equal(t, decoded.Protocol, "http")
+	assert.DeepEqual(t, decoded.Ports, []int{8080})
+}
+
+func TestServiceInterfaceManifestsMerged(t *testing.T) {
+	existing := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: types.ServiceInterfaceConfigMap, Namespace: "west"},
+		Data:       map[string]string{"database": `{"address":"database","protocol":"tcp","ports":[5432]}`},
+	}
+	current, err := jsonencoding.Marshal(existing)
+	assert.Assert(t, err)
+
+	// the manifests of two services applied in sequence, as with kubectl patch --type merge
+	for _, service := range []*types.ServiceInterface{
+		{Address: "frontend", Protocol: "http", Ports: []int{8080}},
+		{Address: "backend", Protocol: "tcp", Ports: []int{9090}},
+	} {
+		manifest, err := ServiceInterfaceManifest("west", service)
+		assert.Assert(t, err)
+		patch, err := jsonencoding.Marshal(manifest)
+		assert.Assert(t, err)
+		current, err = jsonpatch.MergePatch(current, patch)
+		assert.Assert(t, err)
+	}
+	merged := corev1.ConfigMap{}
+	assert.Assert(t, jsonencoding.Unmarshal(current, &merged))
+	assert.Equal(t, merged.Name, types.ServiceInterfaceConfigMap)
+	assert.Equal(t, len(merged.Data), 3)
+	assert.Equal(t, merged.Data["database"], existing.Data["database"])
+	for _, address := range []string{"frontend", "backend"} {
+		decoded := types.ServiceInterface{}
+		assert.Assert(t, jsonencoding.Unmarshal([]byte(merged.Data[address]), &decoded))
+		assert.Equal(t, decoded.Address, address)
+	}
+	assert.Equal(t, ServiceInterfaceFieldManager("frontend"), "skupper-service-frontend")
+}
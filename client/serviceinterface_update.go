@@ -154,6 +154,10 @@ func validateServiceInterface(service *types.ServiceInterface, cli *VanClient) e
 		return fmt.Errorf("%s is not a valid mapping. Choose 'tcp', 'http' or 'http2'.", service.Protocol)
 	} else if service.Aggregate != "" && service.Protocol != "http" {
 		return fmt.Errorf("The aggregate option is currently only valid for http")
+	} else if !service.AggregateOptions.IsEmpty() && service.Aggregate == "" {
+		return fmt.Errorf("The aggregate timeout, partial and max parallel options require an aggregation strategy")
+	} else if err := service.AggregateOptions.Validate(); err != nil {
+		return err
 	} else if service.EventChannel && service.Protocol != "http" && service.Protocol != "udp" {
 		return fmt.Errorf("The event-channel option is currently only valid for http")
 	} else {
@@ -197,38 +201,10 @@ func (cli *VanClient) ServiceInterfaceBind(ctx context.Context, service *types.S
 		if err != nil {
 			return err
 		}
-		err = validateCrossNamespacePermissions(cli, namespace)
-		if err != nil {
-			return err
-		}
-		deducePorts := len(service.Ports) == 0 && len(targetPorts) == 0
-		svcNamespace := utils.GetOrDefault(namespace, cli.GetNamespace())
-		target, err := kube.GetServiceInterfaceTarget(targetType, targetName, deducePorts, svcNamespace, cli.KubeClient, cli.OCAppsClient)
+		err = bindServiceInterfaceTarget(cli, service, targetType, targetName, targetPorts, namespace)
 		if err != nil {
 			return err
 		}
-		if len(service.Ports) == 0 && len(target.TargetPorts) > 0 {
-			for _, ePort := range target.TargetPorts {
-				service.Ports = append(service.Ports, ePort)
-			}
-			target.TargetPorts = map[int]int{}
-		} else if len(targetPorts) > 0 {
-			if len(service.Ports) == 0 {
-				for iPort, _ := range targetPorts {
-					service.Ports = append(service.Ports, iPort)
-				}
-			} else {
-				target.TargetPorts = targetPorts
-			}
-		}
-		if len(service.Ports) == 0 {
-			if service.Protocol == "http" {
-				service.Ports = append(service.Ports, 80)
-			} else {
-				return fmt.Errorf("Service port required and cannot be deduced.")
-			}
-		}
-		service.AddTarget(target)
 
 		tlsSupport := qdr.TlsServiceSupport{Address: service.Address, Credentials: service.TlsCredentials, CertAuthority: service.TlsCertAuthority}
 		tlsManager := &qdr.TlsManager{KubeClient: cli.KubeClient, Namespace: cli.Namespace}
@@ -246,6 +222,44 @@ func (cli *VanClient) ServiceInterfaceBind(ctx context.Context, service *types.S
 	}
 }
 
+// bindServiceInterfaceTarget resolves the target of a bind and adds it to the
+// service, deducing the service ports from the target when not specified
+func bindServiceInterfaceTarget(cli *VanClient, service *types.ServiceInterface, targetType string, targetName string, targetPorts map[int]int, namespace string) error {
+	err := validateCrossNamespacePermissions(cli, namespace)
+	if err != nil {
+		return err
+	}
+	deducePorts := len(service.Ports) == 0 && len(targetPorts) == 0
+	svcNamespace := utils.GetOrDefault(namespace, cli.GetNamespace())
+	target, err := kube.GetServiceInterfaceTarget(targetType, targetName, deducePorts, svcNamespace, cli.KubeClient, cli.OCAppsClient)
+	if err != nil {
+		return err
+	}
+	if len(service.Ports) == 0 && len(target.TargetPorts) > 0 {
+		for _, ePort := range target.TargetPorts {
+			service.Ports = append(service.Ports, ePort)
+		}
+		target.TargetPorts = map[int]int{}
+	} else if len(targetPorts) > 0 {
+		if len(service.Ports) == 0 {
+			for iPort, _ := range targetPorts {
+				service.Ports = append(service.Ports, iPort)
+			}
+		} else {
+			target.TargetPorts = targetPorts
+		}
+	}
+	if len(service.Ports) == 0 {
+		if service.Protocol == "http" {
+			service.Ports = append(service.Ports, 80)
+		} else {
+			return fmt.Errorf("Service port required and cannot be deduced.")
+		}
+	}
+	service.AddTarget(target)
+	return nil
+}
+
 func (cli *VanClient) GetHeadlessServiceConfiguration(targetName string, protocol string, address string, ports []int, publishNotReadyAddresses bool, namespace string) (*types.ServiceInterface, error) {
 	svcNamespace := utils.GetOrDefault(namespace, cli.GetNamespace())
 
@@ -209,8 +209,13 @@ func TestSiteConfigRoundtrip(t *testing.T) {
 						Cpu:    "1",
 						Memory: "2G",
 					},
-					FlowRecordTtl: time.Minute * 30,
+					FlowRecordTtl:       time.Minute * 30,
+					TlsMinVersion:       "1.3",
+					TlsCurvePreferences: "X25519,P256",
+					AcmeDomains:         "console.example.com",
+					AcmeEmail:           "admin@example.com",
 				},
+				SyslogAddress: "tls://syslog.example.com:6514",
 				PrometheusServer: types.PrometheusServerOptions{
 					Tuning: types.Tuning{
 						Cpu:    "1",
@@ -231,9 +236,14 @@ func TestSiteConfigRoundtrip(t *testing.T) {
 						Cpu:    "1",
 						Memory: "2G",
 					},
-					FlowRecordTtl: time.Minute * 30,
+					FlowRecordTtl:       time.Minute * 30,
+					TlsMinVersion:       "1.3",
+					TlsCurvePreferences: "X25519,P256",
+					AcmeDomains:         "console.example.com",
+					AcmeEmail:           "admin@example.com",
 				},
-				Router: types.RouterOptions{Logging: []types.RouterLogConfig{{Module: "ROUTER_CORE", Level: "error+"}}},
+				SyslogAddress: "tls://syslog.example.com:6514",
+				Router:        types.RouterOptions{Logging: []types.RouterLogConfig{{Module: "ROUTER_CORE", Level: "error+"}}},
 				PrometheusServer: types.PrometheusServerOptions{
 					Tuning: types.Tuning{
 						Cpu:    "1",
@@ -243,6 +253,46 @@ func TestSiteConfigRoundtrip(t *testing.T) {
 				},
 			},
 		},
+		{
+			input: types.SiteConfigSpec{
+				Ingress:          "none",
+				CertificateHosts: []string{"skupper.example.com", "10.0.0.10"},
+				Vault:            types.VaultOptions{Address: "https://vault.example.com:8200", PkiPath: "pki_int", Role: "skupper", AuthRole: "skupper-site"},
+			},
+			expected: types.SiteConfigSpec{
+				SkupperName:      "site-config-roundtrip-9",
+				SkupperNamespace: "site-config-roundtrip-9",
+				Ingress:          "none",
+				CertificateHosts: []string{"skupper.example.com", "10.0.0.10"},
+				Vault:            types.VaultOptions{Address: "https://vault.example.com:8200", PkiPath: "pki_int", Role: "skupper", AuthRole: "skupper-site"},
+				RouterMode:       "interior",
+				AuthMode:         "internal",
+				Annotations:      map[string]string{},
+				Labels:           map[string]string{},
+				Router:           types.RouterOptions{Logging: []types.RouterLogConfig{{Module: "ROUTER_CORE", Level: "error+"}}},
+				FlowCollector:    types.FlowCollectorOptions{FlowRecordTtl: types.DefaultFlowTimeoutDuration},
+				PrometheusServer: types.PrometheusServerOptions{AuthMode: "tls"},
+			},
+		},
+		{
+			input: types.SiteConfigSpec{
+				Ingress:     "none",
+				CertManager: types.CertManagerOptions{Issuer: "letsencrypt", IssuerKind: "ClusterIssuer"},
+			},
+			expected: types.SiteConfigSpec{
+				SkupperName:      "site-config-roundtrip-10",
+				SkupperNamespace: "site-config-roundtrip-10",
+				Ingress:          "none",
+				CertManager:      types.CertManagerOptions{Issuer: "letsencrypt", IssuerKind: "ClusterIssuer"},
+				RouterMode:       "interior",
+				AuthMode:         "internal",
+				Annotations:      map[string]string{},
+				Labels:           map[string]string{},
+				Router:           types.RouterOptions{Logging: []types.RouterLogConfig{{Module: "ROUTER_CORE", Level: "error+"}}},
+				FlowCollector:    types.FlowCollectorOptions{FlowRecordTtl: types.DefaultFlowTimeoutDuration},
+				PrometheusServer: types.PrometheusServerOptions{AuthMode: "tls"},
+			},
+		},
 	}
 
 	isCluster := *clusterRun
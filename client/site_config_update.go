@@ -7,6 +7,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/kube"
 	"github.com/skupperproject/skupper/pkg/site"
 )
 
@@ -15,9 +16,11 @@ func (cli *VanClient) SiteConfigUpdate(ctx context.Context, config types.SiteCon
 	if err != nil {
 		return nil, err
 	}
-	// For now, only update router-logging (TODO: update of other options)
+	// For now, only update router-logging, certificate hosts and component network policies (TODO: update of other options)
 	updateLogging := site.UpdateLogging(config, configmap)
-	if updateLogging {
+	updateCertificateHosts := site.UpdateCertificateHosts(config, configmap)
+	updateNetworkPolicies := site.UpdateComponentNetworkPolicies(config, configmap)
+	if updateLogging || updateCertificateHosts || updateNetworkPolicies {
 		configmap, err = cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Update(ctx, configmap, metav1.UpdateOptions{})
 		if err != nil {
 			return nil, err
@@ -26,16 +29,41 @@ func (cli *VanClient) SiteConfigUpdate(ctx context.Context, config types.SiteCon
 	updates := []string{}
 	if updateLogging {
 		updated, err := cli.RouterUpdateLogging(ctx, configmap, true)
-		if errors.IsNotFound(err) {
-			return nil, nil
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+		if updated {
+			updates = append(updates, "router logging")
+		}
+	}
+	if updateCertificateHosts {
+		ca, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(ctx, types.SiteCaSecret, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
 		}
+		err = cli.regenerateSiteSecret(ctx, ca, cli.Namespace)
 		if err != nil {
 			return nil, err
 		}
-		if !updated {
-			return nil, nil
+		updates = append(updates, "certificate hosts")
+	}
+	if updateNetworkPolicies {
+		if config.ComponentNetworkPolicies {
+			siteConfig, err := site.ReadSiteConfig(configmap, cli.Namespace, "")
+			if err != nil {
+				return nil, err
+			}
+			err = cli.reconcileComponentNetworkPolicies(ctx, asOwnerReferences(siteConfig.Reference), cli.Namespace)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			err = kube.DeleteComponentNetworkPolicies(cli.Namespace, cli.KubeClient)
+			if err != nil {
+				return nil, err
+			}
 		}
-		updates = append(updates, "router logging")
+		updates = append(updates, "component network policies")
 	}
 	return updates, nil
 
@@ -10,6 +10,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/serializer/json"
 	"k8s.io/client-go/kubernetes/scheme"
 
+	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/pkg/kube/claims"
 	"github.com/skupperproject/skupper/pkg/kube/site"
 )
@@ -24,6 +25,22 @@ func getSiteId(service *corev1.Service) string {
 }
 
 func (cli *VanClient) TokenClaimCreateFile(ctx context.Context, name string, password []byte, expiry time.Duration, uses int, secretFile string) error {
+	return cli.tokenClaimCreateFile(ctx, name, password, expiry, uses, false, types.ClaimPolicy{}, secretFile)
+}
+
+// MutualTokenClaimCreateFile writes a claim that, once redeemed, also links
+// this site back to the redeeming site
+func (cli *VanClient) MutualTokenClaimCreateFile(ctx context.Context, name string, password []byte, expiry time.Duration, uses int, secretFile string) error {
+	return cli.tokenClaimCreateFile(ctx, name, password, expiry, uses, true, types.ClaimPolicy{}, secretFile)
+}
+
+// TokenClaimCreateFileWithPolicy writes a claim that can only be redeemed by
+// the sites satisfying the policy
+func (cli *VanClient) TokenClaimCreateFileWithPolicy(ctx context.Context, name string, password []byte, expiry time.Duration, uses int, mutual bool, policy types.ClaimPolicy, secretFile string) error {
+	return cli.tokenClaimCreateFile(ctx, name, password, expiry, uses, mutual, policy, secretFile)
+}
+
+func (cli *VanClient) tokenClaimCreateFile(ctx context.Context, name string, password []byte, expiry time.Duration, uses int, mutual bool, claimPolicy types.ClaimPolicy, secretFile string) error {
 	policy := NewPolicyValidatorAPI(cli)
 	res, err := policy.IncomingLink()
 	if err != nil {
@@ -32,7 +49,7 @@ func (cli *VanClient) TokenClaimCreateFile(ctx context.Context, name string, pas
 	if !res.Allowed {
 		return res.Err()
 	}
-	claim, localOnly, err := cli.TokenClaimCreate(ctx, name, password, expiry, uses)
+	claim, localOnly, err := cli.tokenClaimCreate(ctx, name, password, expiry, uses, mutual, claimPolicy)
 	if err != nil {
 		return err
 	}
@@ -56,6 +73,10 @@ func (cli *VanClient) TokenClaimCreateFile(ctx context.Context, name string, pas
 }
 
 func (cli *VanClient) TokenClaimCreate(ctx context.Context, name string, password []byte, expiry time.Duration, uses int) (*corev1.Secret, bool, error) {
+	return cli.tokenClaimCreate(ctx, name, password, expiry, uses, false, types.ClaimPolicy{})
+}
+
+func (cli *VanClient) tokenClaimCreate(ctx context.Context, name string, password []byte, expiry time.Duration, uses int, mutual bool, claimPolicy types.ClaimPolicy) (*corev1.Secret, bool, error) {
 	policy := NewClusterPolicyValidator(cli)
 	res := policy.ValidateIncomingLink()
 	if !res.Allowed() {
@@ -67,7 +88,8 @@ func (cli *VanClient) TokenClaimCreate(ctx context.Context, name string, passwor
 		return nil, false, err
 	}
 
-	token, err := claims.NewClaimFactory(cli, cli.Namespace, siteContext, ctx).CreateTokenClaim(name, password, expiry, uses)
+	factory := claims.NewClaimFactory(cli, cli.Namespace, siteContext, ctx)
+	token, err := factory.CreateTokenClaimWithPolicy(name, password, expiry, uses, mutual, claimPolicy)
 	if err != nil {
 		return nil, false, err
 	}
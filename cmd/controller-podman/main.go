@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -20,6 +21,7 @@ var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
 func main() {
 	// if -version used, report and exit
 	isVersion := flag.Bool("version", false, "Report the version of the Skupper Controller")
+	metricsExporter := flag.String("metrics-exporter", "", "Only serve the local router metrics on the given address")
 	flag.Parse()
 	if *isVersion {
 		fmt.Println(version.Version)
@@ -37,15 +39,38 @@ func main() {
 
 	tlsConfig := certs.GetTlsConfigRetriever(true, types.ControllerConfigPath+"tls.crt", types.ControllerConfigPath+"tls.key", types.ControllerConfigPath+"ca.crt")
 
+	if *metricsExporter != "" {
+		log.Printf("Skupper router metrics exporter")
+		if err := podmancontroller.NewMetricsExporter(*metricsExporter, tlsConfig).Run(stopCh); err != nil {
+			log.Fatal("Error running metrics exporter:", err.Error())
+		}
+		return
+	}
+
 	controller, err := podmancontroller.NewControllerPodman(origin, tlsConfig)
 	if err != nil {
 		log.Fatal("Error getting new controller", err.Error())
 	}
+	go listenHealthz()
 	if err = controller.Run(stopCh); err != nil {
 		log.Fatal("Error running controller:", err.Error())
 	}
 }
 
+// listenHealthz serves the endpoint the healthcheck of the controller
+// container probes
+func listenHealthz() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	})
+	addr := fmt.Sprintf(":%d", types.ControllerLivenessPort)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Printf("Error serving healthz on %s: %s", addr, err)
+	}
+}
+
 func SetupSignalHandler() (stopCh <-chan struct{}) {
 	close(onlyOneSignalHandler) // panics when called twice
 
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	acmeCacheSecret     = "skupper-console-acme"
+	acmeDefaultCacheDir = "/tmp/skupper-acme"
+)
+
+// acmeProvider obtains and renews the console certificate of the public
+// domains through ACME, the tls-alpn-01 challenge being answered by the
+// console listener and the http-01 one by a plain http listener, when
+// enabled. The other server names are served the mounted certificate.
+type acmeProvider struct {
+	manager  *autocert.Manager
+	domains  map[string]bool
+	httpAddr string
+}
+
+// newAcmeProviderFromEnv reads FLOW_ACME_DOMAINS, a comma separated list
+// of the public domains of the console, FLOW_ACME_EMAIL, FLOW_ACME_DIRECTORY
+// (Let's Encrypt by default), FLOW_ACME_HTTP_ADDR to answer the http-01
+// challenges on and FLOW_ACME_CACHE_DIR. On kubernetes the account and
+// certificates are kept in a secret unless a cache directory is given. It
+// returns nil when no domain is set.
+func newAcmeProviderFromEnv(kubeClient kubernetes.Interface, namespace string) (*acmeProvider, error) {
+	value := os.Getenv("FLOW_ACME_DOMAINS")
+	if value == "" {
+		return nil, nil
+	}
+	p := &acmeProvider{
+		domains:  map[string]bool{},
+		httpAddr: os.Getenv("FLOW_ACME_HTTP_ADDR"),
+	}
+	domains := []string{}
+	for _, domain := range strings.Split(value, ",") {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain == "" {
+			continue
+		}
+		if strings.Contains(domain, "*") {
+			return nil, fmt.Errorf("invalid FLOW_ACME_DOMAINS, the wildcard domain %s requires the dns-01 challenge, which is not supported", domain)
+		}
+		p.domains[domain] = true
+		domains = append(domains, domain)
+	}
+	client := &acme.Client{DirectoryURL: autocert.DefaultACMEDirectory}
+	if directory := os.Getenv("FLOW_ACME_DIRECTORY"); directory != "" {
+		u, err := url.Parse(directory)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("invalid FLOW_ACME_DIRECTORY %q", directory)
+		}
+		client.DirectoryURL = directory
+	}
+	var cache autocert.Cache
+	if dir := os.Getenv("FLOW_ACME_CACHE_DIR"); dir != "" || kubeClient == nil {
+		if dir == "" {
+			dir = acmeDefaultCacheDir
+		}
+		cache = autocert.DirCache(dir)
+	} else {
+		cache = &secretCache{client: kubeClient, namespace: namespace, name: acmeCacheSecret}
+	}
+	p.manager = &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      cache,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Client:     client,
+		Email:      os.Getenv("FLOW_ACME_EMAIL"),
+	}
+	return p, nil
+}
+
+// configure has the listener answer the tls-alpn-01 challenges and serve
+// the certificates obtained to the public domains
+func (p *acmeProvider) configure(config *tls.Config, fallback func(*tls.ClientHelloInfo) (*tls.Certificate, error)) {
+	config.NextProtos = append(config.NextProtos, acme.ALPNProto)
+	config.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if p.domains[strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))] {
+			return p.manager.GetCertificate(hello)
+		}
+		return fallback(hello)
+	}
+}
+
+// serveHTTP answers the http-01 challenges, redirecting the other requests
+// to https
+func (p *acmeProvider) serveHTTP() error {
+	if p.httpAddr == "" {
+		return nil
+	}
+	return http.ListenAndServe(p.httpAddr, p.manager.HTTPHandler(nil))
+}
+
+// secretCache keeps the ACME account and certificates in a secret, so they
+// survive restarts instead of being requested again
+type secretCache struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+}
+
+// secretKey escapes the characters not allowed in the keys of a secret
+func secretKey(key string) string {
+	var b strings.Builder
+	for _, c := range []byte(key) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '.':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "_%02x", c)
+		}
+	}
+	return b.String()
+}
+
+func (c *secretCache) Get(ctx context.Context, key string) ([]byte, error) {
+	secret, err := c.client.CoreV1().Secrets(c.namespace).Get(ctx, c.name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, autocert.ErrCacheMiss
+	} else if err != nil {
+		return nil, err
+	}
+	data, ok := secret.Data[secretKey(key)]
+	if !ok {
+		return nil, autocert.ErrCacheMiss
+	}
+	return data, nil
+}
+
+func (c *secretCache) Put(ctx context.Context, key string, data []byte) error {
+	secrets := c.client.CoreV1().Secrets(c.namespace)
+	secret, err := secrets.Get(ctx, c.name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: c.name},
+			Data:       map[string][]byte{secretKey(key): data},
+		}
+		_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+		return err
+	} else if err != nil {
+		return err
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[secretKey(key)] = data
+	_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *secretCache) Delete(ctx context.Context, key string) error {
+	secrets := c.client.CoreV1().Secrets(c.namespace)
+	secret, err := secrets.Get(ctx, c.name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if _, ok := secret.Data[secretKey(key)]; !ok {
+		return nil
+	}
+	delete(secret.Data, secretKey(key))
+	_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"testing"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAcmeSecretCache(t *testing.T) {
+	ctx := context.Background()
+	cache := &secretCache{client: fake.NewSimpleClientset(), namespace: "test", name: acmeCacheSecret}
+	_, err := cache.Get(ctx, "acme_account+key")
+	assert.Equal(t, err, autocert.ErrCacheMiss)
+	assert.Assert(t, cache.Delete(ctx, "acme_account+key"))
+
+	assert.Assert(t, cache.Put(ctx, "acme_account+key", []byte("account")))
+	assert.Assert(t, cache.Put(ctx, "console.example.com", []byte("cert")))
+	data, err := cache.Get(ctx, "acme_account+key")
+	assert.Assert(t, err)
+	assert.Equal(t, string(data), "account")
+
+	secret, err := cache.client.CoreV1().Secrets("test").Get(ctx, acmeCacheSecret, metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.DeepEqual(t, secret.Data, map[string][]byte{
+		"acme_5faccount_2bkey": []byte("account"),
+		"console.example.com":  []byte("cert"),
+	})
+
+	assert.Assert(t, cache.Delete(ctx, "console.example.com"))
+	_, err = cache.Get(ctx, "console.example.com")
+	assert.Equal(t, err, autocert.ErrCacheMiss)
+}
+
+func TestAcmeProviderFromEnv(t *testing.T) {
+	testTable := []struct {
+		doc       string
+		domains   string
+		directory string
+		err       string
+		disabled  bool
+	}{
+		{doc: "disabled", disabled: true},
+		{doc: "domains", domains: "console.example.com, Console.Example.org"},
+		{doc: "directory", domains: "console.example.com", directory: "https://acme-staging-v02.api.letsencrypt.org/directory"},
+		{doc: "bad directory", domains: "console.example.com", directory: "http://acme.example.com", err: "invalid FLOW_ACME_DIRECTORY"},
+		{doc: "wildcard", domains: "*.example.com", err: "requires the dns-01 challenge"},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			t.Setenv("FLOW_ACME_DOMAINS", test.domains)
+			t.Setenv("FLOW_ACME_DIRECTORY", test.directory)
+			t.Setenv("FLOW_ACME_CACHE_DIR", t.TempDir())
+			p, err := newAcmeProviderFromEnv(nil, "")
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			assert.Equal(t, p == nil, test.disabled)
+		})
+	}
+}
+
+func TestAcmeProviderConfigure(t *testing.T) {
+	t.Setenv("FLOW_ACME_DOMAINS", "console.example.com")
+	t.Setenv("FLOW_ACME_CACHE_DIR", t.TempDir())
+	p, err := newAcmeProviderFromEnv(nil, "")
+	assert.Assert(t, err)
+	mounted := &tls.Certificate{}
+	config := &tls.Config{}
+	p.configure(config, func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return mounted, nil
+	})
+	assert.DeepEqual(t, config.NextProtos, []string{acme.ALPNProto})
+
+	// the internal names are served the mounted certificate
+	cert, err := config.GetCertificate(&tls.ClientHelloInfo{ServerName: "skupper.test.svc"})
+	assert.Assert(t, err)
+	assert.Assert(t, cert == mounted)
+}
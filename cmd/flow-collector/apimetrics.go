@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// apiMetrics measures the latency of the api requests by endpoint, so the
+// time spent waiting on the collector shows up along with the handling
+type apiMetrics struct {
+	latency *prometheus.HistogramVec
+}
+
+func newApiMetrics(reg prometheus.Registerer) *apiMetrics {
+	m := &apiMetrics{
+		latency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: "api_request_latency_microseconds",
+				Help: "The latency of the api requests, partitioned by endpoint, method and status code",
+				//                 100us, 1ms, 5ms,  10ms,  50ms,  100ms,  1s,      10s
+				Buckets: []float64{100, 1000, 5000, 10000, 50000, 100000, 1000000, 10000000},
+			},
+			[]string{"endpoint", "method", "code"}),
+	}
+	reg.MustRegister(m.latency)
+	return m
+}
+
+func (m *apiMetrics) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &auditResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, r)
+		if rw.status == 0 {
+			rw.status = http.StatusOK
+		}
+		m.latency.WithLabelValues(endpointName(r.URL.Path), r.Method, strconv.Itoa(rw.status)).Observe(float64(time.Since(start).Microseconds()))
+	})
+}
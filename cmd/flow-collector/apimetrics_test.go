@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestApiMetrics(t *testing.T) {
+	m := newApiMetrics(prometheus.NewRegistry())
+	handler := m.handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1alpha1/sites/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	for _, path := range []string{"/api/v1alpha1/sites/", "/api/v1alpha1/sites/missing", "/api/v1alpha1/flows/"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	assert.Equal(t, testutil.CollectAndCount(m.latency), 3)
+	// the series are the ones of the requests
+	for _, labels := range [][]string{{"sites", "GET", "200"}, {"sites", "GET", "404"}, {"flows", "GET", "200"}} {
+		m.latency.WithLabelValues(labels...)
+	}
+	assert.Equal(t, testutil.CollectAndCount(m.latency), 3)
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/skupperproject/skupper/api/types"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	apiTokenAuthType = "token"
+	apiTokenPrefix   = "sk_"
+	apiTokenFile     = ".tokens"
+	apiTokenCacheTtl = 30 * time.Second
+)
+
+// apiTokenAuth is set when the collector accepts api tokens, a request
+// with a valid token is authenticated as the token name whatever the
+// console authentication mode
+var apiTokenAuth *apiTokenAuthenticator
+
+var apiTokenName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
+var errApiTokenNotFound = errors.New("no such token")
+
+type apiTokenUserKey struct{}
+
+// apiTokenStore holds the sha256 hashes of the tokens by name
+type apiTokenStore interface {
+	load(ctx context.Context) (map[string]string, error)
+	save(ctx context.Context, hashes map[string]string) error
+}
+
+// secretTokenStore keeps the hashes in the skupper-console-tokens secret
+type secretTokenStore struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+func (s *secretTokenStore) load(ctx context.Context) (map[string]string, error) {
+	hashes := map[string]string{}
+	secret, err := s.client.CoreV1().Secrets(s.namespace).Get(ctx, types.ConsoleTokensSecret, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return hashes, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for name, hash := range secret.Data {
+		hashes[name] = string(hash)
+	}
+	return hashes, nil
+}
+
+func (s *secretTokenStore) save(ctx context.Context, hashes map[string]string) error {
+	data := map[string][]byte{}
+	for name, hash := range hashes {
+		data[name] = []byte(hash)
+	}
+	secrets := s.client.CoreV1().Secrets(s.namespace)
+	secret, err := secrets.Get(ctx, types.ConsoleTokensSecret, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: types.ConsoleTokensSecret},
+			Data:       data,
+		}
+		_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+		return err
+	} else if err != nil {
+		return err
+	}
+	secret.Data = data
+	_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	return err
+}
+
+// fileTokenStore keeps the hashes as name=hash lines in the tokens file of
+// the users directory
+type fileTokenStore struct {
+	path string
+}
+
+func (s *fileTokenStore) load(ctx context.Context) (map[string]string, error) {
+	hashes := map[string]string{}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return hashes, nil
+	} else if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if name, hash, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "="); ok {
+			hashes[name] = hash
+		}
+	}
+	return hashes, scanner.Err()
+}
+
+func (s *fileTokenStore) save(ctx context.Context, hashes map[string]string) error {
+	var buf bytes.Buffer
+	for _, name := range sortedNames(hashes) {
+		fmt.Fprintf(&buf, "%s=%s\n", name, hashes[name])
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+type apiTokenAuthenticator struct {
+	store apiTokenStore
+
+	lock   sync.Mutex
+	hashes map[string]string
+	loaded time.Time
+}
+
+// newApiTokenAuthenticator keeps the tokens in a secret on kubernetes and
+// in the users directory otherwise, it returns nil when neither is available
+func newApiTokenAuthenticator(kubeClient kubernetes.Interface, namespace string, usersDir string) *apiTokenAuthenticator {
+	if kubeClient != nil {
+		return &apiTokenAuthenticator{store: &secretTokenStore{client: kubeClient, namespace: namespace}}
+	}
+	if usersDir != "" {
+		return &apiTokenAuthenticator{store: &fileTokenStore{path: path.Join(usersDir, apiTokenFile)}}
+	}
+	return nil
+}
+
+func hashApiToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func sortedNames(hashes map[string]string) []string {
+	names := make([]string, 0, len(hashes))
+	for name := range hashes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// apiTokenOwner returns the name part of a token of the form
+// sk_<name>_<secret>
+func apiTokenOwner(token string) (string, bool) {
+	if !strings.HasPrefix(token, apiTokenPrefix) {
+		return "", false
+	}
+	name, secret, ok := strings.Cut(strings.TrimPrefix(token, apiTokenPrefix), "_")
+	return name, ok && secret != "" && apiTokenName.MatchString(name)
+}
+
+func (a *apiTokenAuthenticator) cachedHashes(ctx context.Context) (map[string]string, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.hashes != nil && time.Since(a.loaded) < apiTokenCacheTtl {
+		return a.hashes, nil
+	}
+	hashes, err := a.store.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a.hashes, a.loaded = hashes, time.Now()
+	return hashes, nil
+}
+
+// update applies the change to the stored hashes, reloading them first so
+// changes made by other replicas are kept
+func (a *apiTokenAuthenticator) update(ctx context.Context, change func(map[string]string) error) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	hashes, err := a.store.load(ctx)
+	if err != nil {
+		return err
+	}
+	if err := change(hashes); err != nil {
+		return err
+	}
+	if err := a.store.save(ctx, hashes); err != nil {
+		return err
+	}
+	a.hashes, a.loaded = hashes, time.Now()
+	return nil
+}
+
+// mint returns a new token for the name, replacing any previous token of
+// that name; only its hash is stored
+func (a *apiTokenAuthenticator) mint(ctx context.Context, name string) (string, error) {
+	if !apiTokenName.MatchString(name) {
+		return "", fmt.Errorf("invalid token name %q", name)
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	token := apiTokenPrefix + name + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	err := a.update(ctx, func(hashes map[string]string) error {
+		hashes[name] = hashApiToken(token)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (a *apiTokenAuthenticator) revoke(ctx context.Context, name string) error {
+	return a.update(ctx, func(hashes map[string]string) error {
+		if _, ok := hashes[name]; !ok {
+			return errApiTokenNotFound
+		}
+		delete(hashes, name)
+		return nil
+	})
+}
+
+// validate returns the name of the token when it matches a stored hash
+func (a *apiTokenAuthenticator) validate(ctx context.Context, token string) (string, bool) {
+	name, ok := apiTokenOwner(token)
+	if !ok {
+		return "", false
+	}
+	hashes, err := a.cachedHashes(ctx)
+	if err != nil {
+		logger.Errorf("Failed to load api tokens: %s", err)
+		return "", false
+	}
+	stored, ok := hashes[name]
+	if !ok || subtle.ConstantTimeCompare([]byte(stored), []byte(hashApiToken(token))) != 1 {
+		return "", false
+	}
+	return name, true
+}
+
+func (a *apiTokenAuthenticator) authenticated(h http.HandlerFunc, fallback http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if !strings.HasPrefix(token, apiTokenPrefix) {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+		if name, ok := a.validate(r.Context(), token); ok {
+			h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiTokenUserKey{}, name)))
+			return
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="skupper", error="invalid_token"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	})
+}
+
+func getApiTokenUser(r *http.Request) (UserResponse, bool) {
+	user, ok := r.Context().Value(apiTokenUserKey{}).(string)
+	return UserResponse{
+		Username: user,
+		AuthMode: apiTokenAuthType,
+	}, ok
+}
+
+type apiTokenResponse struct {
+	Name  string `json:"name"`
+	Token string `json:"token,omitempty"`
+}
+
+// tokensHandler lists the token names, mints a token or revokes one
+func (a *apiTokenAuthenticator) tokensHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch r.Method {
+	case http.MethodGet:
+		hashes, err := a.cachedHashes(r.Context())
+		if err != nil {
+			logger.Errorf("Failed to load api tokens: %s", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		tokens := []apiTokenResponse{}
+		for _, name := range sortedNames(hashes) {
+			tokens = append(tokens, apiTokenResponse{Name: name})
+		}
+		json.NewEncoder(w).Encode(tokens)
+	case http.MethodPost:
+		request := apiTokenResponse{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || !apiTokenName.MatchString(request.Name) {
+			http.Error(w, "Invalid token name", http.StatusBadRequest)
+			return
+		}
+		token, err := a.mint(r.Context(), request.Name)
+		if err != nil {
+			logger.Errorf("Failed to mint api token %s: %s", request.Name, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		logger.Infof("Minted api token %s", request.Name)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(apiTokenResponse{Name: request.Name, Token: token})
+	case http.MethodDelete:
+		name := mux.Vars(r)["name"]
+		err := a.revoke(r.Context(), name)
+		if errors.Is(err, errApiTokenNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			logger.Errorf("Failed to revoke api token %s: %s", name, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		logger.Infof("Revoked api token %s", name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
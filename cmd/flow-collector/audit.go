@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	auditQueueSize      = 1000
+	auditBatchSize      = 100
+	auditFlushInterval  = 5 * time.Second
+	auditWebhookTimeout = 10 * time.Second
+)
+
+// auditLog is set when the api access is audited
+var auditLog *auditLogger
+
+type auditEntryKey struct{}
+
+// AuditEvent is a single access to the api, written as a line of json
+type AuditEvent struct {
+	Time         time.Time `json:"time"`
+	User         string    `json:"user"`
+	Method       string    `json:"method"`
+	Route        string    `json:"route"`
+	Path         string    `json:"path"`
+	Status       int       `json:"status"`
+	SourceIP     string    `json:"sourceIP"`
+	ForwardedFor string    `json:"forwardedFor,omitempty"`
+	Duration     int64     `json:"durationMicroseconds"`
+}
+
+// auditEntry travels in the request context so the authentication can
+// attribute the request to its user
+type auditEntry struct {
+	user string
+}
+
+// auditLogger writes the audit events to the collector log, appends them to
+// a file and posts them in batches to a webhook, as configured
+type auditLogger struct {
+	user    func(*http.Request) UserResponse
+	stdout  bool
+	lock    sync.Mutex
+	file    *os.File
+	webhook string
+	client  *http.Client
+	queue   chan AuditEvent
+	dropped uint64
+}
+
+// newAuditLoggerFromEnv reads the FLOW_AUDIT_LOG flag, to write the events
+// to the collector log, the FLOW_AUDIT_FILE to append them to and the
+// FLOW_AUDIT_WEBHOOK to post them to, it returns nil when none is set
+func newAuditLoggerFromEnv() (*auditLogger, error) {
+	a := &auditLogger{}
+	if value := os.Getenv("FLOW_AUDIT_LOG"); value != "" {
+		stdout, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FLOW_AUDIT_LOG %q", value)
+		}
+		a.stdout = stdout
+	}
+	if filename := os.Getenv("FLOW_AUDIT_FILE"); filename != "" {
+		file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+		a.file = file
+	}
+	if webhook := os.Getenv("FLOW_AUDIT_WEBHOOK"); webhook != "" {
+		u, err := url.Parse(webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid FLOW_AUDIT_WEBHOOK %q", webhook)
+		}
+		a.webhook = webhook
+		a.client = &http.Client{Timeout: auditWebhookTimeout}
+		a.queue = make(chan AuditEvent, auditQueueSize)
+	}
+	if !a.stdout && a.file == nil && a.webhook == "" {
+		return nil, nil
+	}
+	return a, nil
+}
+
+// attribute records the user of the authenticated requests, it wraps the
+// handler that is only called once authentication succeeded
+func (a *auditLogger) attribute(h http.HandlerFunc) http.HandlerFunc {
+	if a == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if entry, ok := r.Context().Value(auditEntryKey{}).(*auditEntry); ok && a.user != nil {
+			entry.user = a.user(r).Username
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (aw *auditResponseWriter) WriteHeader(status int) {
+	if aw.status == 0 {
+		aw.status = status
+	}
+	aw.ResponseWriter.WriteHeader(status)
+}
+
+func (aw *auditResponseWriter) Write(data []byte) (int, error) {
+	if aw.status == 0 {
+		aw.status = http.StatusOK
+	}
+	return aw.ResponseWriter.Write(data)
+}
+
+func (aw *auditResponseWriter) Flush() {
+	if f, ok := aw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// handler audits every request routed to the api, the user is empty for
+// the requests that failed authentication
+func (a *auditLogger) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		entry := &auditEntry{}
+		aw := &auditResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(aw, r.WithContext(context.WithValue(r.Context(), auditEntryKey{}, entry)))
+		route := ""
+		if current := mux.CurrentRoute(r); current != nil {
+			route, _ = current.GetPathTemplate()
+		}
+		status := aw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		a.record(AuditEvent{
+			Time:         start.UTC(),
+			User:         entry.user,
+			Method:       r.Method,
+			Route:        route,
+			Path:         r.URL.Path,
+			Status:       status,
+			SourceIP:     sourceIP(r),
+			ForwardedFor: r.Header.Get("X-Forwarded-For"),
+			Duration:     time.Since(start).Microseconds(),
+		})
+	})
+}
+
+func (a *auditLogger) record(event AuditEvent) {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	if a.stdout {
+		logger.Infof("AUDIT %s", line)
+	}
+	if a.file != nil {
+		a.lock.Lock()
+		_, err = a.file.Write(append(line, '\n'))
+		a.lock.Unlock()
+		if err != nil {
+			logger.Errorf("Failed to write the audit log: %s", err)
+		}
+	}
+	if a.queue != nil {
+		select {
+		case a.queue <- event:
+		default:
+			atomic.AddUint64(&a.dropped, 1)
+		}
+	}
+}
+
+// run posts the queued events to the webhook in batches, until stopped
+func (a *auditLogger) run(stopCh <-chan struct{}) {
+	if a.queue == nil {
+		return
+	}
+	ticker := time.NewTicker(auditFlushInterval)
+	defer ticker.Stop()
+	batch := []AuditEvent{}
+	for {
+		select {
+		case event := <-a.queue:
+			batch = append(batch, event)
+			if len(batch) < auditBatchSize {
+				continue
+			}
+		case <-ticker.C:
+		case <-stopCh:
+			for len(a.queue) > 0 {
+				batch = append(batch, <-a.queue)
+			}
+			a.post(batch)
+			return
+		}
+		a.post(batch)
+		batch = batch[:0]
+	}
+}
+
+func (a *auditLogger) post(batch []AuditEvent) {
+	if dropped := atomic.SwapUint64(&a.dropped, 0); dropped > 0 {
+		logger.Warnf("Dropped %d audit events, the webhook is not keeping up", dropped)
+	}
+	if len(batch) == 0 {
+		return
+	}
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return
+	}
+	resp, err := a.client.Post(a.webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		logger.Errorf("Failed to post %d audit events: %s", len(batch), err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		logger.Errorf("Failed to post %d audit events: %s", len(batch), resp.Status)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"gotest.tools/assert"
+)
+
+func TestAuditLoggerFromEnv(t *testing.T) {
+	t.Setenv("FLOW_AUDIT_LOG", "")
+	t.Setenv("FLOW_AUDIT_FILE", "")
+	t.Setenv("FLOW_AUDIT_WEBHOOK", "")
+	a, err := newAuditLoggerFromEnv()
+	assert.Assert(t, err)
+	assert.Assert(t, a == nil)
+
+	t.Setenv("FLOW_AUDIT_LOG", "sometimes")
+	_, err = newAuditLoggerFromEnv()
+	assert.ErrorContains(t, err, "invalid FLOW_AUDIT_LOG")
+
+	t.Setenv("FLOW_AUDIT_LOG", "true")
+	t.Setenv("FLOW_AUDIT_WEBHOOK", "ftp://audit.example.com")
+	_, err = newAuditLoggerFromEnv()
+	assert.ErrorContains(t, err, "invalid FLOW_AUDIT_WEBHOOK")
+
+	t.Setenv("FLOW_AUDIT_WEBHOOK", "https://audit.example.com/events")
+	a, err = newAuditLoggerFromEnv()
+	assert.Assert(t, err)
+	assert.Assert(t, a.stdout)
+	assert.Equal(t, a.webhook, "https://audit.example.com/events")
+}
+
+func TestAuditHandler(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	t.Setenv("FLOW_AUDIT_LOG", "")
+	t.Setenv("FLOW_AUDIT_WEBHOOK", "")
+	t.Setenv("FLOW_AUDIT_FILE", filename)
+	a, err := newAuditLoggerFromEnv()
+	assert.Assert(t, err)
+	a.user = func(r *http.Request) UserResponse {
+		user, _, _ := r.BasicAuth()
+		return UserResponse{Username: user}
+	}
+	// stands in for the authentication of the api handlers
+	authenticated := func(h http.HandlerFunc) http.HandlerFunc {
+		h = a.attribute(h)
+		return func(w http.ResponseWriter, r *http.Request) {
+			if user, _, ok := r.BasicAuth(); !ok || user != "alice" {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			h(w, r)
+		}
+	}
+	router := mux.NewRouter()
+	router.Use(a.handler)
+	router.HandleFunc("/api/v1alpha1/sites/{id}", authenticated(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+
+	testTable := []struct {
+		user   string
+		status int
+	}{
+		{user: "alice", status: http.StatusOK},
+		{user: "mallory", status: http.StatusUnauthorized},
+	}
+	for _, test := range testTable {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/sites/site-1", nil)
+		r.RemoteAddr = "10.0.0.7:43210"
+		r.SetBasicAuth(test.user, "secret")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, r)
+		assert.Equal(t, rec.Code, test.status)
+	}
+
+	file, err := os.Open(filename)
+	assert.Assert(t, err)
+	defer file.Close()
+	events := []AuditEvent{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		event := AuditEvent{}
+		assert.Assert(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	assert.Equal(t, len(events), 2)
+	assert.Equal(t, events[0].User, "alice")
+	assert.Equal(t, events[0].Status, http.StatusOK)
+	assert.Equal(t, events[0].Route, "/api/v1alpha1/sites/{id}")
+	assert.Equal(t, events[0].Path, "/api/v1alpha1/sites/site-1")
+	assert.Equal(t, events[0].SourceIP, "10.0.0.7")
+	// failed authentication is audited without a user
+	assert.Equal(t, events[1].User, "")
+	assert.Equal(t, events[1].Status, http.StatusUnauthorized)
+}
+
+func TestAuditWebhook(t *testing.T) {
+	received := make(chan []AuditEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		batch := []AuditEvent{}
+		json.NewDecoder(r.Body).Decode(&batch)
+		received <- batch
+	}))
+	defer server.Close()
+	t.Setenv("FLOW_AUDIT_LOG", "")
+	t.Setenv("FLOW_AUDIT_FILE", "")
+	t.Setenv("FLOW_AUDIT_WEBHOOK", server.URL)
+	a, err := newAuditLoggerFromEnv()
+	assert.Assert(t, err)
+
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		a.run(stopCh)
+		close(done)
+	}()
+	a.record(AuditEvent{User: "alice", Method: http.MethodGet, Status: http.StatusOK})
+	a.record(AuditEvent{User: "bob", Method: http.MethodDelete, Status: http.StatusForbidden})
+	close(stopCh)
+	<-done
+
+	batch := <-received
+	assert.Equal(t, len(batch), 2)
+	assert.Equal(t, batch[1].User, "bob")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/tls"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/skupperproject/skupper/pkg/encryption"
+	"github.com/skupperproject/skupper/pkg/fs"
+)
+
+// certReloader serves the certificate of the console, loading it again
+// when the files change so a rotated certificate is used without restarting
+// the server
+type certReloader struct {
+	certFile string
+	keyFile  string
+	lock     sync.RWMutex
+	cert     *tls.Certificate
+}
+
+func newCertReloader(certFile string, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) reload() error {
+	cert, err := encryption.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	r.cert = &cert
+	r.lock.Unlock()
+	return nil
+}
+
+// GetCertificate is set on the tls configuration of the server
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.cert, nil
+}
+
+// changed keeps the current certificate when the files can't be loaded,
+// which happens while only one of them has been written
+func (r *certReloader) changed(name string) {
+	if err := r.reload(); err != nil {
+		logger.Warnf("Keeping the current console certificate, unable to load %s: %s", r.certFile, err)
+		return
+	}
+	logger.Infof("Reloaded the console certificate from %s", r.certFile)
+}
+
+func (r *certReloader) OnCreate(name string) { r.changed(name) }
+func (r *certReloader) OnUpdate(name string) { r.changed(name) }
+func (r *certReloader) OnRemove(name string) {}
+
+// watch reloads the certificate when the files change, the directory is
+// watched as the files of a mounted secret are replaced through a symlink
+func (r *certReloader) watch(stopCh <-chan struct{}) error {
+	w, err := fs.NewWatcher()
+	if err != nil {
+		return err
+	}
+	w.Add(filepath.Dir(r.certFile), r, regexp.MustCompile(`\.crt$|\.key$|\.\.data$`))
+	w.Start(stopCh)
+	return nil
+}
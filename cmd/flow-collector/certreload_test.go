@@ -0,0 +1,48 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func writeTestKeyPair(t *testing.T, dir string, cn string) *x509.Certificate {
+	cert, key := newTestCertificate(t, cn, nil, nil)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Assert(t, err)
+	assert.Assert(t, os.WriteFile(filepath.Join(dir, "tls.crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0600))
+	assert.Assert(t, os.WriteFile(filepath.Join(dir, "tls.key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return cert
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	_, err := newCertReloader(certFile, keyFile)
+	assert.Assert(t, os.IsNotExist(err))
+
+	writeTestKeyPair(t, dir, "console-1")
+	r, err := newCertReloader(certFile, keyFile)
+	assert.Assert(t, err)
+	served, err := r.GetCertificate(nil)
+	assert.Assert(t, err)
+	leaf, err := x509.ParseCertificate(served.Certificate[0])
+	assert.Assert(t, err)
+	assert.Equal(t, leaf.Subject.CommonName, "console-1")
+
+	rotated := writeTestKeyPair(t, dir, "console-2")
+	r.OnUpdate(certFile)
+	served, _ = r.GetCertificate(nil)
+	assert.DeepEqual(t, served.Certificate[0], rotated.Raw)
+
+	// a certificate written without its key yet keeps the current one
+	other, _ := newTestCertificate(t, "console-3", nil, nil)
+	assert.Assert(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: other.Raw}), 0600))
+	r.OnUpdate(certFile)
+	served, _ = r.GetCertificate(nil)
+	assert.DeepEqual(t, served.Certificate[0], rotated.Raw)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+const clientCertAuthType = "certificate"
+
+// clientCertAuth is set when the collector verifies client certificates, a
+// request presenting a verified certificate is authenticated as its common
+// name whatever the console authentication mode
+var clientCertAuth *clientCertAuthenticator
+
+type clientCertUserKey struct{}
+
+type clientCertAuthenticator struct {
+	pool     *x509.CertPool
+	required bool
+}
+
+// newClientCertAuthenticatorFromEnv returns nil when FLOW_CLIENT_CA is not set
+func newClientCertAuthenticatorFromEnv() (*clientCertAuthenticator, error) {
+	ca, err := envOrFile("FLOW_CLIENT_CA")
+	if err != nil || ca == "" {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(ca)) {
+		return nil, fmt.Errorf("no certificates found in client ca")
+	}
+	a := &clientCertAuthenticator{pool: pool}
+	if value := os.Getenv("FLOW_CLIENT_CERT_REQUIRED"); value != "" {
+		if a.required, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("invalid FLOW_CLIENT_CERT_REQUIRED %q", value)
+		}
+	}
+	return a, nil
+}
+
+// configure has the server ask for client certificates, when they are
+// optional other clients fall back to the console authentication
+func (a *clientCertAuthenticator) configure(config *tls.Config) {
+	config.ClientCAs = a.pool
+	if a.required {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	} else {
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+}
+
+func (a *clientCertAuthenticator) authenticated(h http.HandlerFunc, fallback http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+			if user := r.TLS.VerifiedChains[0][0].Subject.CommonName; user != "" {
+				h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientCertUserKey{}, user)))
+				return
+			}
+		}
+		if a.required {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fallback.ServeHTTP(w, r)
+	})
+}
+
+func getClientCertUser(r *http.Request) (UserResponse, bool) {
+	user, ok := r.Context().Value(clientCertUserKey{}).(string)
+	return UserResponse{
+		Username: user,
+		AuthMode: clientCertAuthType,
+	}, ok
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func newTestCertificate(t *testing.T, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Assert(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.Assert(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Assert(t, err)
+	return cert, key
+}
+
+func TestClientCertAuthenticated(t *testing.T) {
+	ca, caKey := newTestCertificate(t, "test-ca", nil, nil)
+	other, otherKey := newTestCertificate(t, "other-ca", nil, nil)
+	alice, aliceKey := newTestCertificate(t, "alice", ca, caKey)
+	mallory, malloryKey := newTestCertificate(t, "mallory", other, otherKey)
+	t.Setenv("FLOW_CLIENT_CA", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})))
+
+	testTable := []struct {
+		name     string
+		required bool
+		cert     *x509.Certificate
+		key      *ecdsa.PrivateKey
+		status   int
+		body     string
+		err      bool
+	}{
+		{name: "verified", cert: alice, key: aliceKey, status: http.StatusOK, body: "alice"},
+		{name: "verified-required", required: true, cert: alice, key: aliceKey, status: http.StatusOK, body: "alice"},
+		{name: "no-certificate", status: http.StatusTeapot, body: "fallback"},
+		{name: "no-certificate-required", required: true, err: true},
+		{name: "untrusted", cert: mallory, key: malloryKey, err: true},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			if test.required {
+				t.Setenv("FLOW_CLIENT_CERT_REQUIRED", "true")
+			} else {
+				t.Setenv("FLOW_CLIENT_CERT_REQUIRED", "")
+			}
+			auth, err := newClientCertAuthenticatorFromEnv()
+			assert.Assert(t, err)
+			server := httptest.NewUnstartedServer(auth.authenticated(func(w http.ResponseWriter, r *http.Request) {
+				user, ok := getClientCertUser(r)
+				assert.Assert(t, ok)
+				io.WriteString(w, user.Username)
+			}, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+				io.WriteString(w, "fallback")
+			}))
+			server.TLS = &tls.Config{}
+			auth.configure(server.TLS)
+			server.StartTLS()
+			defer server.Close()
+
+			client := server.Client()
+			if test.cert != nil {
+				// always sent, even when not issued by the accepted ca
+				certificate := &tls.Certificate{Certificate: [][]byte{test.cert.Raw}, PrivateKey: test.key}
+				client.Transport.(*http.Transport).TLSClientConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+					return certificate, nil
+				}
+			}
+			resp, err := client.Get(server.URL)
+			if test.err {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.Assert(t, err)
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, resp.StatusCode, test.status)
+			assert.Equal(t, string(body), test.body)
+		})
+	}
+}
+
+func TestClientCertConfig(t *testing.T) {
+	t.Setenv("FLOW_CLIENT_CA", "")
+	auth, err := newClientCertAuthenticatorFromEnv()
+	assert.Assert(t, err)
+	assert.Assert(t, auth == nil)
+
+	t.Setenv("FLOW_CLIENT_CA", "not a certificate")
+	_, err = newClientCertAuthenticatorFromEnv()
+	assert.ErrorContains(t, err, "no certificates")
+
+	ca, _ := newTestCertificate(t, "test-ca", nil, nil)
+	t.Setenv("FLOW_CLIENT_CA", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})))
+	t.Setenv("FLOW_CLIENT_CERT_REQUIRED", "sometimes")
+	_, err = newClientCertAuthenticatorFromEnv()
+	assert.ErrorContains(t, err, "invalid FLOW_CLIENT_CERT_REQUIRED")
+}
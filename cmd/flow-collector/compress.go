@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	encodingZstd    = "zstd"
+	encodingBrotli  = "br"
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+// supportedEncodings in order of server preference, used to break ties
+// between encodings the client accepts with the same quality
+var supportedEncodings = []string{encodingZstd, encodingBrotli, encodingGzip, encodingDeflate}
+
+// incompressibleTypes are content types that are already compressed, so
+// spending CPU on them only makes the response bigger
+var incompressibleTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"font/woff",
+	"font/woff2",
+	"application/zip",
+	"application/gzip",
+	"application/zstd",
+}
+
+// compressionLevel is a generic level from 1 (fastest) to 9 (best), that
+// gets mapped to the native range of each encoder
+type compressionLevel int
+
+const defaultCompressionLevel compressionLevel = 5
+
+func parseCompressionLevel(value string) (compressionLevel, error) {
+	switch strings.ToLower(value) {
+	case "":
+		return defaultCompressionLevel, nil
+	case "fastest":
+		return 1, nil
+	case "default":
+		return defaultCompressionLevel, nil
+	case "best":
+		return 9, nil
+	}
+	level, err := strconv.Atoi(value)
+	if err != nil || level < 1 || level > 9 {
+		return 0, fmt.Errorf("invalid compression level %q, must be fastest, default, best or a number between 1 and 9", value)
+	}
+	return compressionLevel(level), nil
+}
+
+func (l compressionLevel) newWriter(encoding string, w io.Writer) (io.WriteCloser, error) {
+	switch encoding {
+	case encodingZstd:
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(int(l)*2)))
+	case encodingBrotli:
+		return brotli.NewWriterLevel(w, int(l)), nil
+	case encodingGzip:
+		return gzip.NewWriterLevel(w, int(l))
+	case encodingDeflate:
+		return flate.NewWriter(w, int(l))
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", encoding)
+}
+
+// negotiateEncoding selects the content coding to use from the value of an
+// Accept-Encoding header, or returns an empty string for identity
+func negotiateEncoding(acceptEncoding string) string {
+	qualities := map[string]float64{}
+	wildcard := -1.0
+	for _, entry := range strings.Split(acceptEncoding, ",") {
+		parts := strings.Split(entry, ";")
+		coding := strings.ToLower(strings.TrimSpace(parts[0]))
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if coding == "*" {
+			wildcard = q
+		} else {
+			qualities[coding] = q
+		}
+	}
+	candidates := []string{}
+	for _, encoding := range supportedEncodings {
+		if q, ok := qualities[encoding]; ok {
+			if q > 0 {
+				candidates = append(candidates, encoding)
+			}
+		} else if wildcard > 0 {
+			qualities[encoding] = wildcard
+			candidates = append(candidates, encoding)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return qualities[candidates[i]] > qualities[candidates[j]]
+	})
+	return candidates[0]
+}
+
+// compressionOptOut is implemented by the response writer handed out by
+// compressHandler, allowing inner handlers to disable compression
+type compressionOptOut interface {
+	disableCompression()
+}
+
+// uncompressed disables response compression for the wrapped handler, for
+// routes that serve already compressed content or compress on their own
+func uncompressed(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, ok := w.(compressionOptOut); ok {
+			c.disableCompression()
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoding    string
+	level       compressionLevel
+	writer      io.WriteCloser
+	disabled    bool
+	wroteHeader bool
+}
+
+func (cw *compressResponseWriter) disableCompression() {
+	cw.disabled = true
+}
+
+func (cw *compressResponseWriter) shouldCompress(status int) bool {
+	if cw.disabled || status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	header := cw.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	for _, t := range incompressibleTypes {
+		if strings.HasPrefix(contentType, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func (cw *compressResponseWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	if cw.shouldCompress(status) {
+		writer, err := cw.level.newWriter(cw.encoding, cw.ResponseWriter)
+		if err == nil {
+			cw.writer = writer
+			cw.Header().Set("Content-Encoding", cw.encoding)
+			cw.Header().Del("Content-Length")
+		}
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *compressResponseWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		if cw.Header().Get("Content-Type") == "" {
+			cw.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.writer != nil {
+		return cw.writer.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+func (cw *compressResponseWriter) Flush() {
+	if f, ok := cw.writer.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := cw.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, fmt.Errorf("response writer does not support hijacking")
+}
+
+func (cw *compressResponseWriter) close() error {
+	if cw.writer != nil {
+		return cw.writer.Close()
+	}
+	return nil
+}
+
+// compressHandler compresses responses with the best content coding
+// accepted by the client, at the given compression level
+func compressHandler(level compressionLevel, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			h.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressResponseWriter{
+			ResponseWriter: w,
+			encoding:       encoding,
+			level:          level,
+		}
+		defer cw.close()
+		h.ServeHTTP(cw, r)
+	})
+}
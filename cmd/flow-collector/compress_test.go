@@ -0,0 +1,103 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	testTable := []struct {
+		acceptEncoding string
+		expected       string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", encodingGzip},
+		{"gzip, deflate", encodingGzip},
+		{"deflate, gzip;q=0.5", encodingDeflate},
+		{"gzip, deflate, br, zstd", encodingZstd},
+		{"gzip, br", encodingBrotli},
+		{"br;q=0.2, gzip;q=0.8", encodingGzip},
+		{"zstd;q=0, gzip", encodingGzip},
+		{"*", encodingZstd},
+		{"*;q=0.5, gzip", encodingGzip},
+		{"*;q=0", ""},
+		{"GZIP", encodingGzip},
+	}
+	for _, test := range testTable {
+		t.Run(test.acceptEncoding, func(t *testing.T) {
+			assert.Equal(t, negotiateEncoding(test.acceptEncoding), test.expected)
+		})
+	}
+}
+
+func TestParseCompressionLevel(t *testing.T) {
+	level, err := parseCompressionLevel("")
+	assert.Assert(t, err)
+	assert.Equal(t, level, defaultCompressionLevel)
+	level, err = parseCompressionLevel("best")
+	assert.Assert(t, err)
+	assert.Equal(t, level, compressionLevel(9))
+	level, err = parseCompressionLevel("3")
+	assert.Assert(t, err)
+	assert.Equal(t, level, compressionLevel(3))
+	_, err = parseCompressionLevel("12")
+	assert.ErrorContains(t, err, "invalid compression level")
+}
+
+func TestCompressHandler(t *testing.T) {
+	body := `{"results":[{"identity":"a"},{"identity":"b"}],"status":"","count":2}`
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	})
+	image := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		io.WriteString(w, body)
+	})
+
+	testTable := []struct {
+		name             string
+		handler          http.Handler
+		acceptEncoding   string
+		expectedEncoding string
+	}{
+		{"identity", api, "", ""},
+		{"gzip", api, "gzip", encodingGzip},
+		{"zstd", api, "zstd", encodingZstd},
+		{"brotli", api, "br", encodingBrotli},
+		{"deflate", api, "deflate", encodingDeflate},
+		{"opt-out", uncompressed(api), "gzip", ""},
+		{"incompressible", image, "gzip", ""},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/sites/", nil)
+			if test.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", test.acceptEncoding)
+			}
+			compressHandler(defaultCompressionLevel, test.handler).ServeHTTP(rec, req)
+			assert.Equal(t, rec.Code, http.StatusOK)
+			assert.Equal(t, rec.Header().Get("Content-Encoding"), test.expectedEncoding)
+			assert.Equal(t, rec.Header().Get("Vary"), "Accept-Encoding")
+			switch test.expectedEncoding {
+			case "":
+				assert.Equal(t, rec.Body.String(), body)
+			case encodingGzip:
+				reader, err := gzip.NewReader(rec.Body)
+				assert.Assert(t, err)
+				data, err := io.ReadAll(reader)
+				assert.Assert(t, err)
+				assert.Equal(t, string(data), body)
+			default:
+				assert.Assert(t, rec.Body.String() != body)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	consoleIndex               = "index.html"
+	consoleContentSecurity     = "default-src 'self'; img-src 'self' data:; style-src 'self' 'unsafe-inline'; font-src 'self' data:; connect-src 'self'; frame-ancestors 'none'; base-uri 'self'; object-src 'none'"
+	consoleImmutableCacheValue = "public, max-age=31536000, immutable"
+)
+
+// hashedAsset matches the file names emitted by the console bundler that
+// carry a content hash (e.g. main.3f2a9c1e.js), which can be cached forever
+var hashedAsset = regexp.MustCompile(`[.-][0-9a-f]{8,}\.[a-z0-9]+$`)
+
+// consoleHandler serves the console single page application from a
+// directory, falling back to index.html for client side routes
+type consoleHandler struct {
+	root       string
+	basePath   string
+	fileServer http.Handler
+}
+
+func newConsoleHandler(root string, basePath string) *consoleHandler {
+	return &consoleHandler{
+		root:       root,
+		basePath:   normalizeBasePath(basePath),
+		fileServer: http.FileServer(http.Dir(root)),
+	}
+}
+
+// normalizeBasePath returns the prefix in the form "/prefix" or an empty
+// string when the console is served from the root
+func normalizeBasePath(basePath string) string {
+	basePath = strings.Trim(basePath, "/")
+	if basePath == "" {
+		return ""
+	}
+	return "/" + basePath
+}
+
+func setConsoleSecurityHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Security-Policy", consoleContentSecurity)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Referrer-Policy", "same-origin")
+}
+
+func (h *consoleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	urlPath := r.URL.Path
+	if h.basePath != "" {
+		if urlPath == h.basePath {
+			http.Redirect(w, r, h.basePath+"/", http.StatusMovedPermanently)
+			return
+		}
+		if !strings.HasPrefix(urlPath, h.basePath+"/") {
+			http.NotFound(w, r)
+			return
+		}
+		urlPath = strings.TrimPrefix(urlPath, h.basePath)
+	}
+	urlPath = path.Clean("/" + urlPath)
+
+	setConsoleSecurityHeaders(w)
+
+	info, err := os.Stat(filepath.Join(h.root, filepath.FromSlash(urlPath)))
+	if err == nil && !info.IsDir() && path.Base(urlPath) != consoleIndex {
+		if hashedAsset.MatchString(urlPath) {
+			w.Header().Set("Cache-Control", consoleImmutableCacheValue)
+		}
+		h.serveFile(w, r, urlPath)
+		return
+	}
+	if (err == nil && info.IsDir()) || path.Ext(urlPath) == "" || path.Base(urlPath) == consoleIndex {
+		// client side route, let the application resolve it
+		w.Header().Set("Cache-Control", "no-cache")
+		h.serveFile(w, r, "/")
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (h *consoleHandler) serveFile(w http.ResponseWriter, r *http.Request, urlPath string) {
+	req := r.Clone(r.Context())
+	req.URL.Path = urlPath
+	req.URL.RawPath = ""
+	h.fileServer.ServeHTTP(w, req)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestConsoleHandler(t *testing.T) {
+	root := t.TempDir()
+	assert.Assert(t, os.WriteFile(filepath.Join(root, "index.html"), []byte("index"), 0644))
+	assert.Assert(t, os.WriteFile(filepath.Join(root, "main.3f2a9c1e.js"), []byte("hashed"), 0644))
+	assert.Assert(t, os.WriteFile(filepath.Join(root, "favicon.ico"), []byte("icon"), 0644))
+
+	testTable := []struct {
+		name         string
+		basePath     string
+		method       string
+		path         string
+		expectedCode int
+		expectedBody string
+		cacheControl string
+	}{
+		{
+			name:         "root",
+			path:         "/",
+			expectedCode: http.StatusOK,
+			expectedBody: "index",
+			cacheControl: "no-cache",
+		},
+		{
+			name:         "spa-route",
+			path:         "/topology/sites",
+			expectedCode: http.StatusOK,
+			expectedBody: "index",
+			cacheControl: "no-cache",
+		},
+		{
+			name:         "index-explicit",
+			path:         "/index.html",
+			expectedCode: http.StatusOK,
+			expectedBody: "index",
+			cacheControl: "no-cache",
+		},
+		{
+			name:         "hashed-asset",
+			path:         "/main.3f2a9c1e.js",
+			expectedCode: http.StatusOK,
+			expectedBody: "hashed",
+			cacheControl: consoleImmutableCacheValue,
+		},
+		{
+			name:         "plain-asset",
+			path:         "/favicon.ico",
+			expectedCode: http.StatusOK,
+			expectedBody: "icon",
+		},
+		{
+			name:         "missing-asset",
+			path:         "/missing.js",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "traversal",
+			path:         "/../../etc/passwd",
+			expectedCode: http.StatusOK,
+			expectedBody: "index",
+		},
+		{
+			name:         "post",
+			method:       http.MethodPost,
+			path:         "/",
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:         "base-path",
+			basePath:     "/console/",
+			path:         "/console/main.3f2a9c1e.js",
+			expectedCode: http.StatusOK,
+			expectedBody: "hashed",
+			cacheControl: consoleImmutableCacheValue,
+		},
+		{
+			name:         "base-path-route",
+			basePath:     "console",
+			path:         "/console/sites",
+			expectedCode: http.StatusOK,
+			expectedBody: "index",
+			cacheControl: "no-cache",
+		},
+		{
+			name:         "base-path-redirect",
+			basePath:     "console",
+			path:         "/console",
+			expectedCode: http.StatusMovedPermanently,
+		},
+		{
+			name:         "outside-base-path",
+			basePath:     "console",
+			path:         "/other/",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			h := newConsoleHandler(root, test.basePath)
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(method, "http://localhost"+test.path, nil)
+			req.URL.Path = test.path
+			h.ServeHTTP(rec, req)
+			assert.Equal(t, rec.Code, test.expectedCode)
+			if test.expectedBody != "" {
+				assert.Equal(t, rec.Body.String(), test.expectedBody)
+			}
+			if test.cacheControl != "" {
+				assert.Equal(t, rec.Header().Get("Cache-Control"), test.cacheControl)
+			}
+			if rec.Code == http.StatusOK {
+				assert.Equal(t, rec.Header().Get("X-Content-Type-Options"), "nosniff")
+				assert.Assert(t, rec.Header().Get("Content-Security-Policy") != "")
+			}
+		})
+	}
+}
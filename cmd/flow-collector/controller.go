@@ -1,7 +1,6 @@
 package main
 
 import (
-	"log"
 	"time"
 
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -9,37 +8,78 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/skupperproject/skupper/pkg/certs"
 	"github.com/skupperproject/skupper/pkg/flow"
+	"github.com/skupperproject/skupper/pkg/messaging"
 	"github.com/skupperproject/skupper/pkg/qdr"
+	"github.com/skupperproject/skupper/pkg/utils/configs"
 )
 
 type Controller struct {
 	FlowCollector *flow.FlowCollector
+	federation    *federation
 }
 
-func NewController(origin string, reg prometheus.Registerer, scheme string, host string, port string, tlsConfig *certs.TlsConfigRetriever, recordTtl time.Duration) (*Controller, error) {
+func NewController(origin string, reg prometheus.Registerer, conn *configs.ConnectInfo, recordTtl time.Duration, staleSourceAfter time.Duration, staleSourceGrace time.Duration, clockSkewThreshold time.Duration, memoryBudget uint64, tagRules []flow.TagRule, latencyBuckets []float64, flowEvents flow.FlowEventSink, leading func() bool, siteEvents flow.SiteEventStore, slos flow.SloStore) (*Controller, error) {
 
 	controller := &Controller{
 		FlowCollector: flow.NewFlowCollector(flow.FlowCollectorSpec{
-			Mode:              flow.RecordMetrics,
-			Origin:            origin,
-			PromReg:           reg,
-			ConnectionFactory: qdr.NewConnectionFactory(scheme+"://"+host+":"+port, tlsConfig),
-			FlowRecordTtl:     recordTtl,
+			Mode:               flow.RecordMetrics,
+			Origin:             origin,
+			PromReg:            reg,
+			ConnectionFactory:  newConnectionFactory(conn),
+			FlowRecordTtl:      recordTtl,
+			MemoryBudget:       memoryBudget,
+			TagRules:           tagRules,
+			LatencyBuckets:     latencyBuckets,
+			FlowEvents:         flowEvents,
+			Leading:            leading,
+			SiteEvents:         siteEvents,
+			Slos:               slos,
+			StaleSourceAfter:   staleSourceAfter,
+			StaleSourceGrace:   staleSourceGrace,
+			ClockSkewThreshold: clockSkewThreshold,
 		}),
 	}
 
 	return controller, nil
 }
 
+// newConnectionFactory connects to the router endpoints in order until one
+// of them is reachable
+func newConnectionFactory(conn *configs.ConnectInfo) messaging.ConnectionFactory {
+	factories := []*qdr.ConnectionFactory{}
+	for _, endpoint := range conn.Endpoints {
+		factories = append(factories, endpointConnectionFactory(endpoint))
+	}
+	if len(factories) == 1 {
+		return factories[0]
+	}
+	return qdr.NewFailoverConnectionFactory(factories...)
+}
+
+func endpointConnectionFactory(endpoint configs.ConnectEndpoint) *qdr.ConnectionFactory {
+	var tlsConfig qdr.TlsConfigRetriever
+	if endpoint.Tls != nil {
+		tlsConfig = certs.GetTlsConfigRetriever(endpoint.Tls.VerifyHost(), endpoint.Tls.Cert, endpoint.Tls.Key, endpoint.Tls.Ca)
+	}
+	if endpoint.Sasl != nil {
+		return qdr.NewConnectionFactoryWithSasl(endpoint.Url(), tlsConfig, qdr.SaslConfig{
+			Mechanism: endpoint.Sasl.Mechanism,
+			Username:  endpoint.Sasl.Username,
+			Password:  endpoint.Sasl.Password,
+		})
+	}
+	return qdr.NewConnectionFactory(endpoint.Url(), tlsConfig)
+}
+
 func (c *Controller) Run(stopCh <-chan struct{}) error {
 	defer utilruntime.HandleCrash()
 
-	log.Println("COLLECTOR: Starting the Skupper flow collector")
+	logger.Infof("Starting the Skupper flow collector")
 
 	c.FlowCollector.Start(stopCh)
 
 	<-stopCh
-	log.Println("COLLECTOR: Shutting down the Skupper flow collector")
+	logger.Infof("Shutting down the Skupper flow collector")
 
 	return nil
 }
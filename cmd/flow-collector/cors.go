@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	corsAllowedMethods = "GET, POST, PUT, DELETE"
+	corsDefaultHeaders = "Authorization, Content-Type"
+	corsMaxAge         = "600"
+)
+
+// corsOrigin is an allowed origin: an optional scheme, a host that may
+// start with a "*." wildcard matching any subdomain, and an optional port
+type corsOrigin struct {
+	scheme string
+	host   string
+	port   string
+}
+
+func parseCorsOrigin(value string) (corsOrigin, error) {
+	origin := corsOrigin{}
+	hostport := value
+	if scheme, rest, ok := strings.Cut(value, "://"); ok {
+		if scheme != "http" && scheme != "https" {
+			return origin, fmt.Errorf("invalid origin %q, the scheme must be http or https", value)
+		}
+		origin.scheme, hostport = scheme, rest
+	}
+	if hostport == "" || strings.ContainsAny(hostport, "/?#") {
+		return origin, fmt.Errorf("invalid origin %q", value)
+	}
+	origin.host = hostport
+	if host, port, ok := strings.Cut(hostport, ":"); ok {
+		if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+			return origin, fmt.Errorf("invalid origin %q, bad port", value)
+		}
+		origin.host, origin.port = host, port
+	}
+	if strings.Contains(strings.TrimPrefix(origin.host, "*."), "*") {
+		return origin, fmt.Errorf("invalid origin %q, only a leading *. wildcard is allowed", value)
+	}
+	origin.host = strings.ToLower(origin.host)
+	return origin, nil
+}
+
+func (o corsOrigin) matches(origin *url.URL) bool {
+	if o.scheme != "" && o.scheme != origin.Scheme {
+		return false
+	}
+	if o.port != "" && o.port != origin.Port() {
+		return false
+	}
+	host := strings.ToLower(origin.Hostname())
+	if suffix, ok := strings.CutPrefix(o.host, "*"); ok {
+		return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+	return host == o.host
+}
+
+// corsPolicy answers cross origin requests from the allowed origins only,
+// the origin of any other request is never echoed back
+type corsPolicy struct {
+	anyOrigin   bool
+	origins     []corsOrigin
+	headers     string
+	credentials bool
+}
+
+// newCorsPolicyFromEnv reads the comma separated CORS_ALLOWED_ORIGINS and
+// CORS_ALLOWED_HEADERS and the CORS_ALLOW_CREDENTIALS flag, it returns nil
+// when cross origin requests are not enabled; USE_CORS on its own allows
+// any origin without credentials
+func newCorsPolicyFromEnv() (*corsPolicy, error) {
+	allowed := os.Getenv("CORS_ALLOWED_ORIGINS")
+	if allowed == "" && os.Getenv("USE_CORS") == "" {
+		return nil, nil
+	}
+	p := &corsPolicy{
+		headers: corsDefaultHeaders,
+	}
+	if allowed == "" {
+		allowed = "*"
+	}
+	for _, value := range strings.Split(allowed, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if value == "*" {
+			p.anyOrigin = true
+			continue
+		}
+		origin, err := parseCorsOrigin(value)
+		if err != nil {
+			return nil, err
+		}
+		p.origins = append(p.origins, origin)
+	}
+	if headers := os.Getenv("CORS_ALLOWED_HEADERS"); headers != "" {
+		names := []string{}
+		for _, name := range strings.Split(headers, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+		p.headers = strings.Join(names, ", ")
+	}
+	if value := os.Getenv("CORS_ALLOW_CREDENTIALS"); value != "" {
+		credentials, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CORS_ALLOW_CREDENTIALS %q", value)
+		}
+		p.credentials = credentials
+	}
+	if p.credentials && p.anyOrigin {
+		return nil, fmt.Errorf("CORS_ALLOW_CREDENTIALS requires an explicit list of CORS_ALLOWED_ORIGINS")
+	}
+	return p, nil
+}
+
+func (p *corsPolicy) allowed(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if p.anyOrigin {
+		return true
+	}
+	for _, o := range p.origins {
+		if o.matches(u) {
+			return true
+		}
+	}
+	return false
+}
+
+func corsAllowsMethod(method string) bool {
+	for _, allowed := range strings.Split(corsAllowedMethods, ", ") {
+		if method == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// handler answers the preflight requests itself, before authentication
+// and routing, and adds the cors headers to the other responses
+func (p *corsPolicy) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		requestMethod := r.Header.Get("Access-Control-Request-Method")
+		preflight := r.Method == http.MethodOptions && requestMethod != ""
+		if !p.allowed(origin) {
+			if preflight {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		if p.anyOrigin && !p.credentials {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if p.credentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !corsAllowsMethod(requestMethod) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+		w.Header().Set("Access-Control-Allow-Headers", p.headers)
+		w.Header().Set("Access-Control-Max-Age", corsMaxAge)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestParseCorsOrigin(t *testing.T) {
+	testTable := []struct {
+		value    string
+		expected corsOrigin
+		err      string
+	}{
+		{value: "https://console.example.com", expected: corsOrigin{scheme: "https", host: "console.example.com"}},
+		{value: "Console.Example.com:8443", expected: corsOrigin{host: "console.example.com", port: "8443"}},
+		{value: "https://*.example.com", expected: corsOrigin{scheme: "https", host: "*.example.com"}},
+		{value: "ftp://example.com", err: "the scheme must be http or https"},
+		{value: "https://example.com/console", err: "invalid origin"},
+		{value: "example.com:http", err: "bad port"},
+		{value: "console.*.com", err: "only a leading *. wildcard"},
+	}
+	for _, test := range testTable {
+		t.Run(test.value, func(t *testing.T) {
+			origin, err := parseCorsOrigin(test.value)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			assert.Equal(t, origin, test.expected)
+		})
+	}
+}
+
+func TestCorsPolicyAllowed(t *testing.T) {
+	t.Setenv("USE_CORS", "")
+	t.Setenv("CORS_ALLOWED_HEADERS", "")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://console.example.com, *.apps.example.com, localhost:3000")
+	policy, err := newCorsPolicyFromEnv()
+	assert.Assert(t, err)
+	testTable := []struct {
+		origin   string
+		expected bool
+	}{
+		{"https://console.example.com", true},
+		{"http://console.example.com", false},
+		{"https://console.example.com:8443", true},
+		{"https://skupper.apps.example.com", true},
+		{"http://a.b.apps.example.com", true},
+		{"https://apps.example.com", false},
+		{"https://evilapps.example.com", false},
+		{"http://localhost:3000", true},
+		{"http://localhost:3001", false},
+		{"https://console.example.com.evil.io", false},
+		{"null", false},
+	}
+	for _, test := range testTable {
+		t.Run(test.origin, func(t *testing.T) {
+			assert.Equal(t, policy.allowed(test.origin), test.expected)
+		})
+	}
+}
+
+func TestCorsPolicyFromEnv(t *testing.T) {
+	t.Setenv("USE_CORS", "")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "")
+	t.Setenv("CORS_ALLOWED_HEADERS", "")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "")
+	policy, err := newCorsPolicyFromEnv()
+	assert.Assert(t, err)
+	assert.Assert(t, policy == nil)
+
+	t.Setenv("USE_CORS", "true")
+	policy, err = newCorsPolicyFromEnv()
+	assert.Assert(t, err)
+	assert.Assert(t, policy.anyOrigin)
+
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	_, err = newCorsPolicyFromEnv()
+	assert.ErrorContains(t, err, "requires an explicit list")
+
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "maybe")
+	_, err = newCorsPolicyFromEnv()
+	assert.ErrorContains(t, err, "invalid CORS_ALLOW_CREDENTIALS")
+
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "")
+	t.Setenv("CORS_ALLOWED_HEADERS", "authorization, x-requested-with")
+	policy, err = newCorsPolicyFromEnv()
+	assert.Assert(t, err)
+	assert.Equal(t, policy.headers, "Authorization, X-Requested-With")
+}
+
+func TestCorsHandler(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	testTable := []struct {
+		name          string
+		policy        *corsPolicy
+		method        string
+		origin        string
+		requestMethod string
+		status        int
+		allowOrigin   string
+		credentials   string
+		allowMethods  string
+	}{
+		{
+			name:   "same-origin",
+			policy: &corsPolicy{origins: []corsOrigin{{host: "console.example.com"}}},
+			method: http.MethodGet,
+			status: http.StatusOK,
+		},
+		{
+			name:        "allowed",
+			policy:      &corsPolicy{origins: []corsOrigin{{host: "console.example.com"}}, credentials: true},
+			method:      http.MethodGet,
+			origin:      "https://console.example.com",
+			status:      http.StatusOK,
+			allowOrigin: "https://console.example.com",
+			credentials: "true",
+		},
+		{
+			name:   "not-allowed",
+			policy: &corsPolicy{origins: []corsOrigin{{host: "console.example.com"}}},
+			method: http.MethodGet,
+			origin: "https://evil.example.com",
+			status: http.StatusOK,
+		},
+		{
+			name:        "any-origin",
+			policy:      &corsPolicy{anyOrigin: true},
+			method:      http.MethodGet,
+			origin:      "https://evil.example.com",
+			status:      http.StatusOK,
+			allowOrigin: "*",
+		},
+		{
+			name:          "preflight",
+			policy:        &corsPolicy{origins: []corsOrigin{{host: "console.example.com"}}, headers: corsDefaultHeaders},
+			method:        http.MethodOptions,
+			origin:        "https://console.example.com",
+			requestMethod: http.MethodDelete,
+			status:        http.StatusNoContent,
+			allowOrigin:   "https://console.example.com",
+			allowMethods:  corsAllowedMethods,
+		},
+		{
+			name:          "preflight-not-allowed",
+			policy:        &corsPolicy{origins: []corsOrigin{{host: "console.example.com"}}},
+			method:        http.MethodOptions,
+			origin:        "https://evil.example.com",
+			requestMethod: http.MethodGet,
+			status:        http.StatusForbidden,
+		},
+		{
+			name:          "preflight-method",
+			policy:        &corsPolicy{origins: []corsOrigin{{host: "console.example.com"}}},
+			method:        http.MethodOptions,
+			origin:        "https://console.example.com",
+			requestMethod: http.MethodPatch,
+			status:        http.StatusForbidden,
+			allowOrigin:   "https://console.example.com",
+		},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(test.method, "/api/v1alpha1/sites/", nil)
+			if test.origin != "" {
+				r.Header.Set("Origin", test.origin)
+			}
+			if test.requestMethod != "" {
+				r.Header.Set("Access-Control-Request-Method", test.requestMethod)
+			}
+			rec := httptest.NewRecorder()
+			test.policy.handler(next).ServeHTTP(rec, r)
+			assert.Equal(t, rec.Code, test.status)
+			assert.Equal(t, rec.Header().Get("Access-Control-Allow-Origin"), test.allowOrigin)
+			assert.Equal(t, rec.Header().Get("Access-Control-Allow-Credentials"), test.credentials)
+			assert.Equal(t, rec.Header().Get("Access-Control-Allow-Methods"), test.allowMethods)
+		})
+	}
+}
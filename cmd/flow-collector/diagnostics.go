@@ -0,0 +1,145 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/skupperproject/skupper/pkg/flow"
+	"github.com/skupperproject/skupper/pkg/version"
+)
+
+// diagnosticsPrefixes are the prefixes of the environment variables that
+// configure the collector
+var diagnosticsPrefixes = []string{
+	"FLOW_", "SKUPPER_", "API_", "CORS_", "CONSOLE_", "PROMETHEUS_", "LOG_", "ENABLE_", "USE_", "COMPRESSION_",
+}
+
+// diagnosticsSecrets are the words of the names of the environment
+// variables whose values are left out of the dumps
+var diagnosticsSecrets = []string{"TOKEN", "PASSWORD", "SECRET", "KEY", "HEADERS"}
+
+type diagnosticsConfig struct {
+	Version     string            `json:"version"`
+	GoVersion   string            `json:"goVersion"`
+	Args        []string          `json:"args"`
+	GoMaxProcs  int               `json:"goMaxProcs"`
+	Goroutines  int               `json:"goroutines"`
+	Environment map[string]string `json:"environment"`
+}
+
+// redactedEnvironment returns the configuration of the collector from the
+// environment, without the secrets and the credentials of the urls
+func redactedEnvironment(environ []string) map[string]string {
+	env := map[string]string{}
+	for _, entry := range environ {
+		name, value, _ := strings.Cut(entry, "=")
+		configures := false
+		for _, prefix := range diagnosticsPrefixes {
+			configures = configures || strings.HasPrefix(name, prefix)
+		}
+		if !configures {
+			continue
+		}
+		// the files holding the secrets are named, not read
+		if !strings.HasSuffix(name, "_FILE") && !strings.HasSuffix(name, "_DIR") {
+			for _, secret := range diagnosticsSecrets {
+				if strings.Contains(name, secret) {
+					value = "<redacted>"
+					break
+				}
+			}
+		}
+		if u, err := url.Parse(value); err == nil && u.User != nil {
+			value = u.Redacted()
+		}
+		env[name] = value
+	}
+	return env
+}
+
+// writeDiagnostics writes a gzipped tarball of the state of the collector,
+// its configuration, the stacks of its goroutines and its heap profile
+func writeDiagnostics(w io.Writer, dir string, now time.Time, collector []byte) error {
+	files := []struct {
+		name  string
+		write func(io.Writer) error
+	}{
+		{"collector.json", func(w io.Writer) error {
+			_, err := w.Write(collector)
+			return err
+		}},
+		{"config.json", func(w io.Writer) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(diagnosticsConfig{
+				Version:     version.Version,
+				GoVersion:   runtime.Version(),
+				Args:        os.Args,
+				GoMaxProcs:  runtime.GOMAXPROCS(0),
+				Goroutines:  runtime.NumGoroutine(),
+				Environment: redactedEnvironment(os.Environ()),
+			})
+		}},
+		{"goroutines.txt", func(w io.Writer) error {
+			return pprof.Lookup("goroutine").WriteTo(w, 2)
+		}},
+		{"heap.pprof", func(w io.Writer) error {
+			return pprof.Lookup("heap").WriteTo(w, 0)
+		}},
+	}
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+	for _, file := range files {
+		var content bytes.Buffer
+		if err := file.write(&content); err != nil {
+			return fmt.Errorf("failed to dump %s: %s", file.name, err)
+		}
+		header := &tar.Header{
+			Name:    dir + "/" + file.name,
+			Mode:    0644,
+			Size:    int64(content.Len()),
+			ModTime: now,
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := archive.Write(content.Bytes()); err != nil {
+			return err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// diagnosticsHandler serves the diagnostic dump to attach to bug reports
+func (c *Controller) diagnosticsHandler(w http.ResponseWriter, r *http.Request) {
+	response, err := c.apiRequest(r.Context(), flow.ApiRequest{Request: r})
+	if err != nil {
+		writeTimeout(w, err, "")
+		return
+	}
+	if response.Status != http.StatusOK || response.Body == nil {
+		w.WriteHeader(response.Status)
+		return
+	}
+	now := time.Now().UTC()
+	name := "collector-diagnostics-" + now.Format("20060102T150405Z")
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".tar.gz"))
+	if err := writeDiagnostics(w, name, now, []byte(*response.Body)); err != nil {
+		logger.Warnf("Failed to write the diagnostic dump: %s", err)
+	}
+}
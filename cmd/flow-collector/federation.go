@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/skupperproject/skupper/pkg/flow"
+)
+
+const peerRequestTimeout = 10 * time.Second
+
+// federatedRecordTypes maps the record collections exposed through the
+// federated view to their record type
+var federatedRecordTypes = map[string]int{
+	"sites":     flow.Site,
+	"processes": flow.Process,
+	"flows":     flow.Flow,
+	"flowpairs": flow.FlowPair,
+}
+
+// peerCollector is a flow collector of another VAN (or another segment of
+// the same network) whose records are merged into the federated view
+type peerCollector struct {
+	Name     string `json:"name"`
+	Url      string `json:"url"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+type peerStatus struct {
+	Name     string `json:"name"`
+	Url      string `json:"url"`
+	LastSeen int64  `json:"lastSeen,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type federatedPayload struct {
+	Results    []map[string]interface{} `json:"results"`
+	Status     string                   `json:"status"`
+	Count      int                      `json:"count"`
+	TotalCount int                      `json:"totalCount"`
+	Errors     map[string]string        `json:"errors,omitempty"`
+}
+
+type federation struct {
+	lock   sync.RWMutex
+	peers  map[string]*peerCollector
+	status map[string]*peerStatus
+	client *http.Client
+}
+
+func (p *peerCollector) validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("peer name is required")
+	}
+	u, err := url.Parse(p.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid peer url %q", p.Url)
+	}
+	return nil
+}
+
+// newFederation creates the federation registry, with an optional initial
+// set of peers in the form name=url[,name=url]
+func newFederation(peers string) (*federation, error) {
+	f := &federation{
+		peers:  map[string]*peerCollector{},
+		status: map[string]*peerStatus{},
+		client: &http.Client{Timeout: peerRequestTimeout},
+	}
+	for _, entry := range strings.Split(peers, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid peer %q, expected name=url", entry)
+		}
+		if err := f.register(peerCollector{Name: parts[0], Url: parts[1]}); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (f *federation) register(peer peerCollector) error {
+	if err := peer.validate(); err != nil {
+		return err
+	}
+	peer.Url = strings.TrimSuffix(peer.Url, "/")
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.peers[peer.Name] = &peer
+	f.status[peer.Name] = &peerStatus{Name: peer.Name, Url: peer.Url}
+	logger.Infof("Registered peer collector %s at %s", peer.Name, peer.Url)
+	return nil
+}
+
+func (f *federation) unregister(name string) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if _, ok := f.peers[name]; !ok {
+		return false
+	}
+	delete(f.peers, name)
+	delete(f.status, name)
+	logger.Infof("Unregistered peer collector %s", name)
+	return true
+}
+
+func (f *federation) list() []peerStatus {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	peers := []peerStatus{}
+	for _, status := range f.status {
+		peers = append(peers, *status)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Name < peers[j].Name
+	})
+	return peers
+}
+
+func (f *federation) snapshot() []peerCollector {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	peers := []peerCollector{}
+	for _, peer := range f.peers {
+		peers = append(peers, *peer)
+	}
+	return peers
+}
+
+func (f *federation) updateStatus(name string, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if status, ok := f.status[name]; ok {
+		if err != nil {
+			status.Error = err.Error()
+		} else {
+			status.Error = ""
+			status.LastSeen = time.Now().Unix()
+		}
+	}
+}
+
+// fetch retrieves a record collection from a peer collector, passing on the
+// query parameters of the original request
+func (f *federation) fetch(ctx context.Context, peer peerCollector, collection string, rawQuery string) (*flow.Payload, []map[string]interface{}, error) {
+	u := peer.Url + "/api/v1alpha1/" + collection + "/"
+	if rawQuery != "" {
+		u += "?" + rawQuery
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if peer.Username != "" {
+		req.SetBasicAuth(peer.Username, peer.Password)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("peer returned %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return decodePayload(body)
+}
+
+func decodePayload(body []byte) (*flow.Payload, []map[string]interface{}, error) {
+	payload := &flow.Payload{}
+	if err := json.Unmarshal(body, payload); err != nil {
+		return nil, nil, err
+	}
+	results := []map[string]interface{}{}
+	if payload.Results != nil {
+		data, _ := json.Marshal(payload.Results)
+		if err := json.Unmarshal(data, &results); err != nil {
+			return nil, nil, fmt.Errorf("unexpected results: %s", err)
+		}
+	}
+	return payload, results, nil
+}
+
+// merge adds the records of a collector to the federated payload, tagging
+// each with the collector it came from. Records already present (e.g. sites
+// visible from more than one VAN) are kept from the first collector.
+func (p *federatedPayload) merge(collector string, payload *flow.Payload, results []map[string]interface{}, seen map[string]bool) {
+	for _, record := range results {
+		if identity, ok := record["identity"].(string); ok {
+			if seen[identity] {
+				continue
+			}
+			seen[identity] = true
+		}
+		record["collector"] = collector
+		p.Results = append(p.Results, record)
+		p.Count++
+	}
+	p.TotalCount += payload.TotalCount
+}
+
+func (c *Controller) federatedHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	collection := mux.Vars(r)["collection"]
+	recordType, ok := federatedRecordTypes[collection]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	// the federated responses have no revision, the local records are always read
+	local := r.Clone(r.Context())
+	local.Header.Del("If-None-Match")
+	response, err := c.apiRequest(r.Context(), flow.ApiRequest{RecordType: recordType, Request: local})
+	if err != nil {
+		writeTimeout(w, err, listTimeoutHint)
+		return
+	}
+	response.ETag = ""
+	if response.Status != http.StatusOK || response.Body == nil {
+		writeApiResponse(w, r, response)
+		return
+	}
+	local, results, err := decodePayload([]byte(*response.Body))
+	if err != nil {
+		logger.Errorf("Error decoding local %s: %s", collection, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	federated := federatedPayload{
+		Results: []map[string]interface{}{},
+		Status:  local.Status,
+	}
+	seen := map[string]bool{}
+	federated.merge(c.FlowCollector.Collector.Identity, local, results, seen)
+
+	type peerResult struct {
+		name    string
+		payload *flow.Payload
+		results []map[string]interface{}
+		err     error
+	}
+	peers := c.federation.snapshot()
+	peerResults := make([]peerResult, len(peers))
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(i int, peer peerCollector) {
+			defer wg.Done()
+			payload, results, err := c.federation.fetch(r.Context(), peer, collection, r.URL.RawQuery)
+			c.federation.updateStatus(peer.Name, err)
+			peerResults[i] = peerResult{name: peer.Name, payload: payload, results: results, err: err}
+		}(i, peer)
+	}
+	wg.Wait()
+	sort.Slice(peerResults, func(i, j int) bool {
+		return peerResults[i].name < peerResults[j].name
+	})
+	for _, result := range peerResults {
+		if result.err != nil {
+			if federated.Errors == nil {
+				federated.Errors = map[string]string{}
+			}
+			federated.Errors[result.name] = result.err.Error()
+			continue
+		}
+		federated.merge(result.name, result.payload, result.results, seen)
+	}
+
+	body, err := json.Marshal(federated)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	s := string(body)
+	writeApiResponse(w, r, flow.ApiResponse{Body: &s, Status: http.StatusOK})
+}
+
+func (c *Controller) peersHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(c.federation.list())
+	case http.MethodPost:
+		peer := peerCollector{}
+		if err := json.NewDecoder(io.LimitReader(r.Body, 64*1024)).Decode(&peer); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := c.federation.register(peer); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodDelete:
+		if !c.federation.unregister(mux.Vars(r)["name"]) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestNewFederation(t *testing.T) {
+	f, err := newFederation("")
+	assert.Assert(t, err)
+	assert.Equal(t, len(f.list()), 0)
+
+	f, err = newFederation("east=https://collector.east:8010/, west=http://collector.west:8010")
+	assert.Assert(t, err)
+	peers := f.list()
+	assert.Equal(t, len(peers), 2)
+	assert.Equal(t, peers[0].Name, "east")
+	assert.Equal(t, peers[0].Url, "https://collector.east:8010")
+	assert.Equal(t, peers[1].Name, "west")
+
+	assert.Assert(t, f.unregister("east"))
+	assert.Assert(t, !f.unregister("east"))
+	assert.Equal(t, len(f.list()), 1)
+
+	_, err = newFederation("east")
+	assert.ErrorContains(t, err, "expected name=url")
+	_, err = newFederation("east=ftp://collector.east")
+	assert.ErrorContains(t, err, "invalid peer url")
+}
+
+func TestFederationFetchAndMerge(t *testing.T) {
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "admin" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, r.URL.Path, "/api/v1alpha1/sites/")
+		assert.Equal(t, r.URL.RawQuery, "name=west")
+		io.WriteString(w, `{"results":[{"identity":"site:shared"},{"identity":"site:west"}],"status":"","count":2,"timeRangeCount":2,"totalCount":2}`)
+	}))
+	defer peer.Close()
+
+	f, err := newFederation("")
+	assert.Assert(t, err)
+	assert.Assert(t, f.register(peerCollector{Name: "west", Url: peer.URL, Username: "admin", Password: "secret"}))
+
+	payload, results, err := f.fetch(context.Background(), f.snapshot()[0], "sites", "name=west")
+	assert.Assert(t, err)
+	assert.Equal(t, len(results), 2)
+
+	local, localResults, err := decodePayload([]byte(`{"results":[{"identity":"site:shared"}],"status":"","count":1,"totalCount":1}`))
+	assert.Assert(t, err)
+
+	federated := federatedPayload{Results: []map[string]interface{}{}}
+	seen := map[string]bool{}
+	federated.merge("local", local, localResults, seen)
+	federated.merge("west", payload, results, seen)
+	assert.Equal(t, federated.Count, 2)
+	assert.Equal(t, federated.TotalCount, 3)
+	assert.Equal(t, federated.Results[0]["collector"], "local")
+	assert.Equal(t, federated.Results[1]["identity"], "site:west")
+	assert.Equal(t, federated.Results[1]["collector"], "west")
+
+	_, _, err = f.fetch(context.Background(), peerCollector{Name: "west", Url: peer.URL}, "sites", "")
+	assert.ErrorContains(t, err, "401")
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/skupperproject/skupper/pkg/flow"
+	"github.com/skupperproject/skupper/pkg/version"
+)
+
+const (
+	flowEventQueueSize      = 10000
+	flowEventBatchSize      = 500
+	flowEventFlushInterval  = 5 * time.Second
+	flowEventWebhookTimeout = 10 * time.Second
+	flowEventSyslogTag      = "skupper-flow-collector"
+)
+
+// flowEventExporter sends the flow open and close events to a syslog
+// server, as json or CEF, and posts them in batches to a webhook, so the
+// connections of the network can be ingested by a SIEM
+type flowEventExporter struct {
+	syslog  *syslog.Writer
+	format  string
+	webhook string
+	client  *http.Client
+	queue   chan flow.FlowEvent
+	dropped uint64
+}
+
+// newFlowEventExporterFromEnv reads FLOW_EVENTS_SYSLOG, as in
+// udp://siem.example.com:514, with FLOW_EVENTS_FORMAT being json or cef,
+// and FLOW_EVENTS_WEBHOOK, it returns nil when neither is set
+func newFlowEventExporterFromEnv() (*flowEventExporter, error) {
+	e := &flowEventExporter{format: "json"}
+	if format := os.Getenv("FLOW_EVENTS_FORMAT"); format != "" {
+		if format != "json" && format != "cef" {
+			return nil, fmt.Errorf("invalid FLOW_EVENTS_FORMAT %q, it must be json or cef", format)
+		}
+		e.format = format
+	}
+	if address := os.Getenv("FLOW_EVENTS_SYSLOG"); address != "" {
+		u, err := url.Parse(address)
+		if err != nil || (u.Scheme != "udp" && u.Scheme != "tcp") || u.Host == "" {
+			return nil, fmt.Errorf("invalid FLOW_EVENTS_SYSLOG %q, expected udp://host:port or tcp://host:port", address)
+		}
+		writer, err := syslog.Dial(u.Scheme, u.Host, syslog.LOG_INFO|syslog.LOG_LOCAL0, flowEventSyslogTag)
+		if err != nil {
+			return nil, fmt.Errorf("unable to reach the syslog server %s: %w", address, err)
+		}
+		e.syslog = writer
+	}
+	if webhook := os.Getenv("FLOW_EVENTS_WEBHOOK"); webhook != "" {
+		u, err := url.Parse(webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid FLOW_EVENTS_WEBHOOK %q", webhook)
+		}
+		e.webhook = webhook
+		e.client = &http.Client{Timeout: flowEventWebhookTimeout}
+	}
+	if e.syslog == nil && e.webhook == "" {
+		return nil, nil
+	}
+	e.queue = make(chan flow.FlowEvent, flowEventQueueSize)
+	return e, nil
+}
+
+// flowEventSinks sends the flow events to each of the exporters
+type flowEventSinks []flow.FlowEventSink
+
+func (s flowEventSinks) FlowEvent(event flow.FlowEvent) {
+	for _, sink := range s {
+		sink.FlowEvent(event)
+	}
+}
+
+// FlowEvent queues the event, dropping it when the exporter is not keeping
+// up rather than holding the update loop of the collector
+func (e *flowEventExporter) FlowEvent(event flow.FlowEvent) {
+	select {
+	case e.queue <- event:
+	default:
+		atomic.AddUint64(&e.dropped, 1)
+	}
+}
+
+// run sends the queued events until stopped
+func (e *flowEventExporter) run(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(flowEventFlushInterval)
+	defer ticker.Stop()
+	batch := []flow.FlowEvent{}
+	for {
+		select {
+		case event := <-e.queue:
+			e.send(event)
+			if e.webhook == "" {
+				continue
+			}
+			batch = append(batch, event)
+			if len(batch) < flowEventBatchSize {
+				continue
+			}
+		case <-ticker.C:
+		case <-stopCh:
+			for len(e.queue) > 0 {
+				event := <-e.queue
+				e.send(event)
+				batch = append(batch, event)
+			}
+			e.post(batch)
+			return
+		}
+		if dropped := atomic.SwapUint64(&e.dropped, 0); dropped > 0 {
+			logger.Warnf("Dropped %d flow events, the exporter is not keeping up", dropped)
+		}
+		e.post(batch)
+		batch = batch[:0]
+	}
+}
+
+func (e *flowEventExporter) send(event flow.FlowEvent) {
+	if e.syslog == nil {
+		return
+	}
+	var message string
+	if e.format == "cef" {
+		message = cefFlowEvent(event)
+	} else {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return
+		}
+		message = string(line)
+	}
+	if err := e.syslog.Info(message); err != nil {
+		logger.Errorf("Failed to send a flow event to syslog: %s", err)
+	}
+}
+
+func (e *flowEventExporter) post(batch []flow.FlowEvent) {
+	if e.webhook == "" || len(batch) == 0 {
+		return
+	}
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return
+	}
+	resp, err := e.client.Post(e.webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		logger.Errorf("Failed to post %d flow events: %s", len(batch), err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		logger.Errorf("Failed to post %d flow events: %s", len(batch), resp.Status)
+	}
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`)
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+)
+
+// cefFlowEvent formats the event in the ArcSight Common Event Format
+func cefFlowEvent(event flow.FlowEvent) string {
+	name := "Flow opened"
+	if event.Type == flow.FlowEventClose {
+		name = "Flow closed"
+	}
+	extension := []string{}
+	add := func(key string, value string) {
+		if value != "" {
+			extension = append(extension, key+"="+cefExtensionEscaper.Replace(value))
+		}
+	}
+	// src and dst only take addresses, the names go to shost and dhost
+	addHost := func(addressKey string, nameKey string, host string) {
+		if net.ParseIP(host) != nil {
+			add(addressKey, host)
+		} else {
+			add(nameKey, host)
+		}
+	}
+	addCount := func(key string, value *uint64) {
+		if value != nil {
+			add(key, strconv.FormatUint(*value, 10))
+		}
+	}
+	add("rt", strconv.FormatUint(event.Time/1000, 10))
+	add("externalId", event.Identity)
+	add("app", event.Protocol)
+	addHost("src", "shost", event.SourceHost)
+	add("spt", event.SourcePort)
+	add("sproc", event.SourceProcess)
+	addHost("dst", "dhost", event.DestinationHost)
+	add("dpt", event.DestinationPort)
+	add("dproc", event.DestinationProcess)
+	addCount("out", event.Octets)
+	addCount("in", event.OctetsReverse)
+	add("requestMethod", event.Method)
+	add("outcome", event.Result)
+	if event.Address != "" {
+		add("cs1Label", "address")
+		add("cs1", event.Address)
+	}
+	if event.SourceSite != "" {
+		add("cs2Label", "sourceSite")
+		add("cs2", event.SourceSite)
+	}
+	if event.DestinationSite != "" {
+		add("cs3Label", "destinationSite")
+		add("cs3", event.DestinationSite)
+	}
+	if event.EndReason != "" {
+		add("cs4Label", "endReason")
+		add("cs4", event.EndReason)
+	}
+	if event.Duration != nil {
+		add("cn1Label", "durationMicroseconds")
+		addCount("cn1", event.Duration)
+	}
+	return fmt.Sprintf("CEF:0|Skupper|flow-collector|%s|flow-%s|%s|3|%s",
+		cefHeaderEscaper.Replace(version.Version), event.Type, name, strings.Join(extension, " "))
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/skupperproject/skupper/pkg/flow"
+	"github.com/skupperproject/skupper/pkg/version"
+	"gotest.tools/assert"
+)
+
+func testFlowEvent() flow.FlowEvent {
+	octets, octetsReverse, duration := uint64(100), uint64(2000), uint64(5000)
+	return flow.FlowEvent{
+		Type:               flow.FlowEventClose,
+		Time:               1700000000000000,
+		Identity:           "fp-flow:0",
+		Protocol:           "tcp",
+		Address:            "backend",
+		SourceSite:         "west",
+		SourceProcess:      "frontend",
+		SourceHost:         "10.0.0.1",
+		SourcePort:         "40000",
+		DestinationSite:    "east=1",
+		DestinationProcess: "backend",
+		DestinationHost:    "backend.east.svc",
+		DestinationPort:    "8080",
+		Octets:             &octets,
+		OctetsReverse:      &octetsReverse,
+		Duration:           &duration,
+		EndReason:          "connection reset",
+	}
+}
+
+func TestCefFlowEvent(t *testing.T) {
+	assert.Equal(t, cefFlowEvent(testFlowEvent()),
+		"CEF:0|Skupper|flow-collector|"+version.Version+"|flow-close|Flow closed|3|rt=1700000000000 externalId=fp-flow:0 app=tcp "+
+			"src=10.0.0.1 spt=40000 sproc=frontend dhost=backend.east.svc dpt=8080 dproc=backend out=100 in=2000 "+
+			`cs1Label=address cs1=backend cs2Label=sourceSite cs2=west cs3Label=destinationSite cs3=east\=1 `+
+			"cs4Label=endReason cs4=connection reset cn1Label=durationMicroseconds cn1=5000")
+
+	open := flow.FlowEvent{Type: flow.FlowEventOpen, Time: 1000, Identity: "fp-flow:1"}
+	assert.Equal(t, cefFlowEvent(open), "CEF:0|Skupper|flow-collector|"+version.Version+"|flow-open|Flow opened|3|rt=1 externalId=fp-flow:1")
+}
+
+func TestFlowEventExporter(t *testing.T) {
+	syslogServer, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Assert(t, err)
+	defer syslogServer.Close()
+	posted := make(chan []flow.FlowEvent, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		batch := []flow.FlowEvent{}
+		json.NewDecoder(r.Body).Decode(&batch)
+		posted <- batch
+	}))
+	defer webhook.Close()
+
+	t.Setenv("FLOW_EVENTS_SYSLOG", "udp://"+syslogServer.LocalAddr().String())
+	t.Setenv("FLOW_EVENTS_FORMAT", "cef")
+	t.Setenv("FLOW_EVENTS_WEBHOOK", webhook.URL)
+	e, err := newFlowEventExporterFromEnv()
+	assert.Assert(t, err)
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		e.run(stopCh)
+		close(done)
+	}()
+	e.FlowEvent(testFlowEvent())
+
+	buf := make([]byte, 4096)
+	n, _, err := syslogServer.ReadFrom(buf)
+	assert.Assert(t, err)
+	assert.Assert(t, strings.Contains(string(buf[:n]), flowEventSyslogTag))
+	assert.Assert(t, strings.HasSuffix(strings.TrimSpace(string(buf[:n])), cefFlowEvent(testFlowEvent())))
+
+	// the pending batch is posted when stopped
+	close(stopCh)
+	<-done
+	batch := <-posted
+	assert.Equal(t, len(batch), 1)
+	assert.Equal(t, batch[0].Identity, "fp-flow:0")
+	assert.Equal(t, *batch[0].Duration, uint64(5000))
+}
+
+func TestFlowEventExporterFromEnv(t *testing.T) {
+	testTable := []struct {
+		doc      string
+		syslog   string
+		format   string
+		webhook  string
+		err      string
+		disabled bool
+	}{
+		{doc: "disabled", disabled: true},
+		{doc: "webhook", webhook: "https://siem.example.com/events"},
+		{doc: "bad webhook", webhook: "siem.example.com", err: "invalid FLOW_EVENTS_WEBHOOK"},
+		{doc: "bad syslog", syslog: "siem.example.com:514", err: "invalid FLOW_EVENTS_SYSLOG"},
+		{doc: "bad format", format: "leef", webhook: "https://siem.example.com/events", err: "invalid FLOW_EVENTS_FORMAT"},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			t.Setenv("FLOW_EVENTS_SYSLOG", test.syslog)
+			t.Setenv("FLOW_EVENTS_FORMAT", test.format)
+			t.Setenv("FLOW_EVENTS_WEBHOOK", test.webhook)
+			e, err := newFlowEventExporterFromEnv()
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			assert.Equal(t, e == nil, test.disabled)
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/skupperproject/skupper/pkg/flow"
+)
+
+const (
+	flowTraceQueueSize     = 10000
+	flowTraceBatchSize     = 500
+	flowTraceFlushInterval = 5 * time.Second
+)
+
+const (
+	otlpSpanKindServer = 2
+	otlpSpanKindClient = 3
+	otlpStatusError    = 2
+)
+
+type otlpStatus struct {
+	Code int `json:"code"`
+}
+
+type otlpSpan struct {
+	TraceId           string         `json:"traceId"`
+	SpanId            string         `json:"spanId"`
+	TraceState        string         `json:"traceState,omitempty"`
+	ParentSpanId      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            *otlpStatus    `json:"status,omitempty"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpTracesRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+// flowTraceExporter exports the completed flow pairs as OpenTelemetry
+// spans, a client span for the listener site and a server span for the
+// connector site, so that the traffic of the network shows in the tracing
+// backends along with the application traces
+type flowTraceExporter struct {
+	otlp    *otlpExporter
+	queue   chan flow.FlowEvent
+	dropped uint64
+}
+
+// newFlowTraceExporterFromEnv reads FLOW_TRACES_OTLP_ENDPOINT and
+// FLOW_TRACES_OTLP_HEADERS, it returns nil when no endpoint is set
+func newFlowTraceExporterFromEnv() (*flowTraceExporter, error) {
+	otlp, err := newOtlpExporterFromEnv("FLOW_TRACES", "traces")
+	if err != nil || otlp == nil {
+		return nil, err
+	}
+	return &flowTraceExporter{
+		otlp:  otlp,
+		queue: make(chan flow.FlowEvent, flowTraceQueueSize),
+	}, nil
+}
+
+// FlowEvent queues the completed flow pairs, dropping them when the
+// exporter is not keeping up rather than holding the update loop of the
+// collector
+func (e *flowTraceExporter) FlowEvent(event flow.FlowEvent) {
+	if event.Type != flow.FlowEventClose {
+		return
+	}
+	select {
+	case e.queue <- event:
+	default:
+		atomic.AddUint64(&e.dropped, 1)
+	}
+}
+
+// run exports the queued flow pairs until stopped
+func (e *flowTraceExporter) run(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(flowTraceFlushInterval)
+	defer ticker.Stop()
+	batch := []flow.FlowEvent{}
+	for {
+		select {
+		case event := <-e.queue:
+			batch = append(batch, event)
+			if len(batch) < flowTraceBatchSize {
+				continue
+			}
+		case <-ticker.C:
+		case <-stopCh:
+			for len(e.queue) > 0 {
+				batch = append(batch, <-e.queue)
+			}
+			e.export(batch)
+			return
+		}
+		if dropped := atomic.SwapUint64(&e.dropped, 0); dropped > 0 {
+			logger.Warnf("Dropped %d flow traces, the exporter is not keeping up", dropped)
+		}
+		e.export(batch)
+		batch = batch[:0]
+	}
+}
+
+func (e *flowTraceExporter) export(batch []flow.FlowEvent) {
+	if len(batch) == 0 {
+		return
+	}
+	if err := e.otlp.post(flowTracesRequest(batch)); err != nil {
+		logger.Errorf("Failed to export %d flow traces: %s", len(batch), err)
+	}
+}
+
+// flowTraceIds derives the ids of the trace and of its spans from the
+// identity of the flow pair, so that the spans of a flow are linked
+// whichever collector exports them
+func flowTraceIds(identity string) (traceId string, clientSpanId string, serverSpanId string) {
+	sum := sha256.Sum256([]byte(identity))
+	return hex.EncodeToString(sum[:16]), hex.EncodeToString(sum[16:24]), hex.EncodeToString(sum[24:32])
+}
+
+// flowSpans returns the client and server spans of the completed flow pair,
+// the client span joining the trace of the application when the request
+// carried a traceparent header
+func flowSpans(event flow.FlowEvent) (otlpSpan, otlpSpan) {
+	traceId, clientSpanId, serverSpanId := flowTraceIds(event.Identity)
+	parentSpanId := ""
+	if appTraceId, appSpanId, ok := flow.ParseTraceParent(event.TraceParent); ok {
+		traceId, parentSpanId = appTraceId, appSpanId
+	}
+	end := event.Time
+	start := end
+	if event.Duration != nil && *event.Duration <= end {
+		start = end - *event.Duration
+	}
+	name := event.Address
+	if event.Method != "" {
+		name = event.Method + " " + event.Address
+	}
+	if name == "" {
+		name = event.Protocol
+	}
+	attributes := []otlpKeyValue{otlpString("skupper.flow.id", event.Identity)}
+	add := func(key string, value string) {
+		if value != "" {
+			attributes = append(attributes, otlpString(key, value))
+		}
+	}
+	addPort := func(key string, value string) {
+		if port, err := strconv.ParseInt(value, 10, 64); err == nil {
+			attributes = append(attributes, otlpInt(key, port))
+		}
+	}
+	add("skupper.address", event.Address)
+	add("network.protocol.name", event.Protocol)
+	add("client.address", event.SourceHost)
+	addPort("client.port", event.SourcePort)
+	add("server.address", event.DestinationHost)
+	addPort("server.port", event.DestinationPort)
+	add("http.request.method", event.Method)
+	add("skupper.flow.end_reason", event.EndReason)
+	var status *otlpStatus
+	if code, err := strconv.ParseInt(event.Result, 10, 64); err == nil {
+		attributes = append(attributes, otlpInt("http.response.status_code", code))
+		if code >= 500 {
+			status = &otlpStatus{Code: otlpStatusError}
+		}
+	}
+	octets := func(key string, value *uint64) []otlpKeyValue {
+		if value == nil {
+			return nil
+		}
+		return []otlpKeyValue{otlpInt(key, int64(*value))}
+	}
+
+	client := otlpSpan{
+		TraceId:           traceId,
+		SpanId:            clientSpanId,
+		ParentSpanId:      parentSpanId,
+		TraceState:        event.TraceState,
+		Name:              name,
+		Kind:              otlpSpanKindClient,
+		StartTimeUnixNano: otlpTime(start),
+		EndTimeUnixNano:   otlpTime(end),
+		Status:            status,
+	}
+	client.Attributes = append(append(append([]otlpKeyValue{}, attributes...),
+		octets("skupper.octets.sent", event.Octets)...), octets("skupper.octets.received", event.OctetsReverse)...)
+	server := otlpSpan{
+		TraceId:           traceId,
+		SpanId:            serverSpanId,
+		TraceState:        event.TraceState,
+		ParentSpanId:      clientSpanId,
+		Name:              name,
+		Kind:              otlpSpanKindServer,
+		StartTimeUnixNano: otlpTime(start),
+		EndTimeUnixNano:   otlpTime(end),
+		Status:            status,
+	}
+	server.Attributes = append(append(append([]otlpKeyValue{}, attributes...),
+		octets("skupper.octets.sent", event.OctetsReverse)...), octets("skupper.octets.received", event.Octets)...)
+	return client, server
+}
+
+// flowTraceResource is the resource of the spans of a process of a site,
+// the process being the service of the spans
+func flowTraceResource(site string, process string) otlpResource {
+	service := process
+	if service == "" {
+		service = "skupper"
+	}
+	attributes := []otlpKeyValue{otlpString("service.name", service)}
+	if site != "" {
+		attributes = append(attributes, otlpString("skupper.site.name", site))
+	}
+	return otlpResource{Attributes: attributes}
+}
+
+// flowTracesRequest groups the spans of the flow pairs by the site and the
+// process they were observed at
+func flowTracesRequest(batch []flow.FlowEvent) otlpTracesRequest {
+	type resourceKey struct {
+		site    string
+		process string
+	}
+	request := otlpTracesRequest{ResourceSpans: []otlpResourceSpans{}}
+	index := map[resourceKey]int{}
+	add := func(site string, process string, span otlpSpan) {
+		key := resourceKey{site: site, process: process}
+		i, ok := index[key]
+		if !ok {
+			i = len(request.ResourceSpans)
+			index[key] = i
+			request.ResourceSpans = append(request.ResourceSpans, otlpResourceSpans{
+				Resource:   flowTraceResource(site, process),
+				ScopeSpans: []otlpScopeSpans{{Scope: otlpCollectorScope()}},
+			})
+		}
+		scopeSpans := &request.ResourceSpans[i].ScopeSpans[0]
+		scopeSpans.Spans = append(scopeSpans.Spans, span)
+	}
+	for _, event := range batch {
+		client, server := flowSpans(event)
+		add(event.SourceSite, event.SourceProcess, client)
+		add(event.DestinationSite, event.DestinationProcess, server)
+	}
+	return request
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skupperproject/skupper/pkg/flow"
+	"gotest.tools/assert"
+)
+
+func spanAttribute(span otlpSpan, key string) *otlpAnyValue {
+	for _, attribute := range span.Attributes {
+		if attribute.Key == key {
+			return &attribute.Value
+		}
+	}
+	return nil
+}
+
+func TestFlowSpans(t *testing.T) {
+	event := testFlowEvent()
+	event.Result = "503"
+	client, server := flowSpans(event)
+
+	assert.Equal(t, client.TraceId, server.TraceId)
+	assert.Equal(t, len(client.TraceId), 32)
+	assert.Equal(t, len(client.SpanId), 16)
+	assert.Equal(t, server.ParentSpanId, client.SpanId)
+	assert.Assert(t, client.SpanId != server.SpanId)
+	assert.Equal(t, client.Kind, otlpSpanKindClient)
+	assert.Equal(t, server.Kind, otlpSpanKindServer)
+	assert.Equal(t, client.Name, "backend")
+	assert.Equal(t, client.StartTimeUnixNano, "1699999999995000000")
+	assert.Equal(t, client.EndTimeUnixNano, "1700000000000000000")
+	assert.Equal(t, server.StartTimeUnixNano, client.StartTimeUnixNano)
+	assert.Equal(t, client.Status.Code, otlpStatusError)
+
+	assert.Equal(t, *spanAttribute(client, "skupper.flow.id").StringValue, "fp-flow:0")
+	assert.Equal(t, *spanAttribute(client, "server.port").IntValue, "8080")
+	assert.Equal(t, *spanAttribute(client, "http.response.status_code").IntValue, "503")
+	assert.Equal(t, *spanAttribute(client, "skupper.octets.sent").IntValue, "100")
+	assert.Equal(t, *spanAttribute(client, "skupper.octets.received").IntValue, "2000")
+	assert.Equal(t, *spanAttribute(server, "skupper.octets.sent").IntValue, "2000")
+	assert.Equal(t, *spanAttribute(server, "skupper.octets.received").IntValue, "100")
+
+	// the ids only depend on the flow identity
+	again, _ := flowSpans(event)
+	assert.Equal(t, again.TraceId, client.TraceId)
+	event.Identity = "fp-flow:1"
+	other, _ := flowSpans(event)
+	assert.Assert(t, other.TraceId != client.TraceId)
+
+	// the spans join the trace of the request
+	event.TraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	event.TraceState = "vendor=value"
+	client, server = flowSpans(event)
+	assert.Equal(t, client.TraceId, "4bf92f3577b34da6a3ce929d0e0e4736")
+	assert.Equal(t, client.ParentSpanId, "00f067aa0ba902b7")
+	assert.Equal(t, client.TraceState, "vendor=value")
+	assert.Equal(t, server.TraceId, client.TraceId)
+	assert.Equal(t, server.ParentSpanId, client.SpanId)
+}
+
+func TestFlowTracesRequest(t *testing.T) {
+	first := testFlowEvent()
+	second := testFlowEvent()
+	second.Identity = "fp-flow:1"
+	request := flowTracesRequest([]flow.FlowEvent{first, second})
+
+	// the spans are grouped by the site and process they were observed at
+	assert.Equal(t, len(request.ResourceSpans), 2)
+	client := request.ResourceSpans[0]
+	assert.Equal(t, *client.Resource.Attributes[0].Value.StringValue, "frontend")
+	assert.Equal(t, *client.Resource.Attributes[1].Value.StringValue, "west")
+	assert.Equal(t, len(client.ScopeSpans[0].Spans), 2)
+	assert.Equal(t, client.ScopeSpans[0].Scope.Name, otlpScopeName)
+	server := request.ResourceSpans[1]
+	assert.Equal(t, *server.Resource.Attributes[0].Value.StringValue, "backend")
+	assert.Equal(t, len(server.ScopeSpans[0].Spans), 2)
+}
+
+func TestFlowTraceExporter(t *testing.T) {
+	posted := make(chan otlpTracesRequest, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, "/v1/traces")
+		assert.Equal(t, r.Header.Get("Content-Type"), "application/json")
+		assert.Equal(t, r.Header.Get("Authorization"), "Bearer secret")
+		request := otlpTracesRequest{}
+		json.NewDecoder(r.Body).Decode(&request)
+		posted <- request
+	}))
+	defer collector.Close()
+
+	t.Setenv("FLOW_TRACES_OTLP_ENDPOINT", collector.URL)
+	t.Setenv("FLOW_TRACES_OTLP_HEADERS", "Authorization=Bearer%20secret")
+	e, err := newFlowTraceExporterFromEnv()
+	assert.Assert(t, err)
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		e.run(stopCh)
+		close(done)
+	}()
+	// only the completed flows are exported
+	e.FlowEvent(flow.FlowEvent{Type: flow.FlowEventOpen, Identity: "fp-flow:1"})
+	e.FlowEvent(testFlowEvent())
+	close(stopCh)
+	<-done
+
+	request := <-posted
+	assert.Equal(t, len(request.ResourceSpans), 2)
+	assert.Equal(t, request.ResourceSpans[0].ScopeSpans[0].Spans[0].Name, "backend")
+}
+
+func TestOtlpExporterFromEnv(t *testing.T) {
+	var tests = []struct {
+		doc      string
+		endpoint string
+		headers  string
+		url      string
+		err      string
+	}{
+		{doc: "not configured"},
+		{doc: "base endpoint", endpoint: "http://otel-collector:4318", url: "http://otel-collector:4318/v1/traces"},
+		{doc: "signal endpoint", endpoint: "https://otel.example.com/custom/traces", url: "https://otel.example.com/custom/traces"},
+		{doc: "bad endpoint", endpoint: "otel-collector:4318", err: "invalid FLOW_TRACES_OTLP_ENDPOINT"},
+		{doc: "bad headers", endpoint: "http://otel-collector:4318", headers: "Authorization", err: "invalid FLOW_TRACES_OTLP_HEADERS"},
+	}
+	for _, test := range tests {
+		t.Run(test.doc, func(t *testing.T) {
+			t.Setenv("FLOW_TRACES_OTLP_ENDPOINT", test.endpoint)
+			t.Setenv("FLOW_TRACES_OTLP_HEADERS", test.headers)
+			e, err := newOtlpExporterFromEnv("FLOW_TRACES", "traces")
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			if test.url == "" {
+				assert.Assert(t, e == nil)
+				return
+			}
+			assert.Equal(t, e.url, test.url)
+		})
+	}
+}
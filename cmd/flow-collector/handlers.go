@@ -1,228 +1,207 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
+	"time"
 
 	"github.com/skupperproject/skupper/pkg/flow"
 )
 
-func (c *Controller) eventsourceHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	c.FlowCollector.Request <- flow.ApiRequest{RecordType: flow.EventSource, Request: r}
-	response := <-c.FlowCollector.Response
+// writeApiResponse writes a collector response along with its entity tag.
+// The collector answers the conditional GET requests with 304 Not Modified
+// on its own, from the revision of its records, before encoding them.
+func writeApiResponse(w http.ResponseWriter, r *http.Request, response flow.ApiResponse) {
+	if response.ETag != "" && (response.Status == http.StatusOK || response.Status == http.StatusNotModified) {
+		w.Header().Set("ETag", response.ETag)
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	if response.Status == http.StatusNotModified {
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 	w.WriteHeader(response.Status)
 	if response.Body != nil {
 		fmt.Fprintf(w, "%s", *response.Body)
 	}
 }
 
-func (c *Controller) siteHandler(w http.ResponseWriter, r *http.Request) {
+func (c *Controller) eventsourceHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	c.FlowCollector.Request <- flow.ApiRequest{RecordType: flow.Site, Request: r}
-	response := <-c.FlowCollector.Response
-	w.WriteHeader(response.Status)
-	if response.Body != nil {
-		fmt.Fprintf(w, "%s", *response.Body)
+	c.serveApiRequest(w, r, flow.ApiRequest{RecordType: flow.EventSource, Request: r})
+}
+
+func (c *Controller) ingestHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	// read the batch here so a slow client does not stall the collector
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, flow.MaxIngestBodySize))
+	if err != nil {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
 	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	c.serveApiRequest(w, r, flow.ApiRequest{Request: r})
 }
 
-func (c *Controller) hostHandler(w http.ResponseWriter, r *http.Request) {
+func (c *Controller) dropsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	c.FlowCollector.Request <- flow.ApiRequest{RecordType: flow.Host, Request: r}
-	response := <-c.FlowCollector.Response
-	w.WriteHeader(response.Status)
-	if response.Body != nil {
-		fmt.Fprintf(w, "%s", *response.Body)
+	c.serveApiRequest(w, r, flow.ApiRequest{Request: r})
+}
+
+func (c *Controller) sloHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodPut {
+		// read the objectives here so a slow client does not stall the collector
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, flow.MaxIngestBodySize))
+		if err != nil {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
 	}
+	c.serveApiRequest(w, r, flow.ApiRequest{Request: r})
+}
+
+func (c *Controller) siteEventsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	c.serveApiRequest(w, r, flow.ApiRequest{Request: r})
+}
+
+func (c *Controller) siteHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	c.serveApiRequest(w, r, flow.ApiRequest{RecordType: flow.Site, Request: r})
+}
+
+func (c *Controller) hostHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	c.serveApiRequest(w, r, flow.ApiRequest{RecordType: flow.Host, Request: r})
 }
 
 func (c *Controller) routerHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	c.FlowCollector.Request <- flow.ApiRequest{RecordType: flow.Router, Request: r}
-	response := <-c.FlowCollector.Response
-	w.WriteHeader(response.Status)
-	if response.Body != nil {
-		fmt.Fprintf(w, "%s", *response.Body)
-	}
+	c.serveApiRequest(w, r, flow.ApiRequest{RecordType: flow.Router, Request: r})
 }
 
 func (c *Controller) linkHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	c.FlowCollector.Request <- flow.ApiRequest{RecordType: flow.Link, Request: r}
-	response := <-c.FlowCollector.Response
-	w.WriteHeader(response.Status)
-	if response.Body != nil {
-		fmt.Fprintf(w, "%s", *response.Body)
-	}
+	c.serveApiRequest(w, r, flow.ApiRequest{RecordType: flow.Link, Request: r})
 }
 
 func (c *Controller) listenerHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	c.FlowCollector.Request <- flow.ApiRequest{RecordType: flow.Listener, Request: r}
-	response := <-c.FlowCollector.Response
-	w.WriteHeader(response.Status)
-	if response.Body != nil {
-		fmt.Fprintf(w, "%s", *response.Body)
-	}
+	c.serveApiRequest(w, r, flow.ApiRequest{RecordType: flow.Listener, Request: r})
 }
 
 func (c *Controller) connectorHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	c.FlowCollector.Request <- flow.ApiRequest{RecordType: flow.Connector, Request: r}
-	response := <-c.FlowCollector.Response
-	w.WriteHeader(response.Status)
-	if response.Body != nil {
-		fmt.Fprintf(w, "%s", *response.Body)
-	}
+	c.serveApiRequest(w, r, flow.ApiRequest{RecordType: flow.Connector, Request: r})
 }
 
 func (c *Controller) addressHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	c.FlowCollector.Request <- flow.ApiRequest{RecordType: flow.Address, Request: r}
-	response := <-c.FlowCollector.Response
-	w.WriteHeader(response.Status)
-	if response.Body != nil {
-		fmt.Fprintf(w, "%s", *response.Body)
-	}
+	c.serveApiRequest(w, r, flow.ApiRequest{RecordType: flow.Address, Request: r})
 }
 
 func (c *Controller) processHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	c.FlowCollector.Request <- flow.ApiRequest{RecordType: flow.Process, Request: r}
-	response := <-c.FlowCollector.Response
-	w.WriteHeader(response.Status)
-	if response.Body != nil {
-		fmt.Fprintf(w, "%s", *response.Body)
-	}
+	c.serveApiRequest(w, r, flow.ApiRequest{RecordType: flow.Process, Request: r})
 }
 
 func (c *Controller) processGroupHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	c.FlowCollector.Request <- flow.ApiRequest{RecordType: flow.ProcessGroup, Request: r}
-	response := <-c.FlowCollector.Response
-	w.WriteHeader(response.Status)
-	if response.Body != nil {
-		fmt.Fprintf(w, "%s", *response.Body)
-	}
+	c.serveApiRequest(w, r, flow.ApiRequest{RecordType: flow.ProcessGroup, Request: r})
 }
 
 func (c *Controller) flowHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	c.FlowCollector.Request <- flow.ApiRequest{RecordType: flow.Flow, Request: r}
-	response := <-c.FlowCollector.Response
-	w.WriteHeader(response.Status)
-	if response.Body != nil {
-		fmt.Fprintf(w, "%s", *response.Body)
-	}
+	c.serveApiRequest(w, r, flow.ApiRequest{RecordType: flow.Flow, Request: r})
 }
 
 func (c *Controller) flowPairHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	c.FlowCollector.Request <- flow.ApiRequest{RecordType: flow.FlowPair, Request: r}
-	response := <-c.FlowCollector.Response
-	w.WriteHeader(response.Status)
-	if response.Body != nil {
-		fmt.Fprintf(w, "%s", *response.Body)
-	}
+	c.serveApiRequest(w, r, flow.ApiRequest{RecordType: flow.FlowPair, Request: r})
 }
 
 func (c *Controller) sitePairHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	c.FlowCollector.Request <- flow.ApiRequest{RecordType: flow.SitePair, Request: r}
-	response := <-c.FlowCollector.Response
-	w.WriteHeader(response.Status)
-	if response.Body != nil {
-		fmt.Fprintf(w, "%s", *response.Body)
-	}
+	c.serveApiRequest(w, r, flow.ApiRequest{RecordType: flow.SitePair, Request: r})
+}
+
+func (c *Controller) siteLatencyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	c.serveApiRequest(w, r, flow.ApiRequest{RecordType: flow.SiteLatency, Request: r})
 }
 
 func (c *Controller) processGroupPairHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	c.FlowCollector.Request <- flow.ApiRequest{RecordType: flow.ProcessGroupPair, Request: r}
-	response := <-c.FlowCollector.Response
-	w.WriteHeader(response.Status)
-	if response.Body != nil {
-		fmt.Fprintf(w, "%s", *response.Body)
-	}
+	c.serveApiRequest(w, r, flow.ApiRequest{RecordType: flow.ProcessGroupPair, Request: r})
 }
 
 func (c *Controller) processPairHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	c.FlowCollector.Request <- flow.ApiRequest{RecordType: flow.ProcessPair, Request: r}
-	response := <-c.FlowCollector.Response
-	w.WriteHeader(response.Status)
-	if response.Body != nil {
-		fmt.Fprintf(w, "%s", *response.Body)
-	}
+	c.serveApiRequest(w, r, flow.ApiRequest{RecordType: flow.ProcessPair, Request: r})
 }
 
 func (c *Controller) collectorHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	c.FlowCollector.Request <- flow.ApiRequest{RecordType: flow.Collector, Request: r}
-	response := <-c.FlowCollector.Response
-	w.WriteHeader(response.Status)
-	if response.Body != nil {
-		fmt.Fprintf(w, "%s", *response.Body)
-	}
+	c.serveApiRequest(w, r, flow.ApiRequest{RecordType: flow.Collector, Request: r})
 }
 
 func (c *Controller) promqueryHandler(w http.ResponseWriter, r *http.Request) {
-	client := http.Client{}
-
-	urlOut := c.FlowCollector.Collector.PrometheusUrl + "query?" + r.URL.RawQuery
-	proxyReq, err := http.NewRequest(r.Method, urlOut, nil)
-	if err != nil {
-		log.Printf("COLLECTOR: prom proxy request error: %s\n", err.Error())
-
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Internal Server Error: %s\n", err.Error())
-		return
-	}
+	c.proxyPrometheus(w, r, "query")
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	proxyResp, err := client.Do(proxyReq)
-	if err != nil {
-		log.Printf("COLLECTOR: Prometheus query error: %s\n", err.Error())
+func (c *Controller) promqueryrangeHandler(w http.ResponseWriter, r *http.Request) {
+	c.proxyPrometheus(w, r, "query_range")
+}
 
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "Internal Server Error: %s\n", err.Error())
-		return
-	} else {
-		w.WriteHeader(proxyResp.StatusCode)
-		data, _ := io.ReadAll(proxyResp.Body)
-		proxyResp.Body.Close()
-		fmt.Fprintf(w, "%s\n", data)
-	}
+// prometheusTimeout formats the timeout of a query in whole milliseconds, as
+// prometheus durations take no fractional values
+func prometheusTimeout(remaining time.Duration) string {
+	return fmt.Sprintf("%dms", remaining.Milliseconds())
 }
 
-func (c *Controller) promqueryrangeHandler(w http.ResponseWriter, r *http.Request) {
+// proxyPrometheus forwards a query to the prometheus api, bounded by the
+// deadline of the incoming request which is also passed on to prometheus so
+// it can stop evaluating the query
+func (c *Controller) proxyPrometheus(w http.ResponseWriter, r *http.Request, endpoint string) {
 	client := http.Client{}
+	ctx := r.Context()
 
-	urlOut := c.FlowCollector.Collector.PrometheusUrl + "query_range?" + r.URL.RawQuery
-	proxyReq, err := http.NewRequest(r.Method, urlOut, nil)
+	query := r.URL.Query()
+	if deadline, ok := ctx.Deadline(); ok && query.Get("timeout") == "" {
+		if remaining := time.Until(deadline); remaining >= time.Millisecond {
+			query.Set("timeout", prometheusTimeout(remaining))
+		}
+	}
+	urlOut := c.FlowCollector.Collector.PrometheusUrl + endpoint + "?" + query.Encode()
+	proxyReq, err := http.NewRequestWithContext(ctx, r.Method, urlOut, nil)
 	if err != nil {
-		log.Printf("COLLECTOR: prom proxy request error: %s \n", err.Error())
+		logger.Errorf("prom proxy request error: %s", err.Error())
 
 		w.WriteHeader(http.StatusInternalServerError)
 		fmt.Fprintf(w, "Internal Server Error: %s\n", err.Error())
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
 	proxyResp, err := client.Do(proxyReq)
-	w.WriteHeader(proxyResp.StatusCode)
 	if err != nil {
-		log.Printf("COLLECTOR: Prometheus query_range error: %s\n", err.Error())
+		if ctx.Err() != nil {
+			logger.Warnf("Prometheus %s timed out: %s", endpoint, err.Error())
+			writeTimeout(w, ctx.Err(), promTimeoutHint)
+			return
+		}
+		logger.Errorf("Prometheus %s error: %s", endpoint, err.Error())
 
 		w.WriteHeader(http.StatusInternalServerError)
 		fmt.Fprintf(w, "Internal Server Error: %s\n", err.Error())
 		return
-	} else {
-		data, _ := io.ReadAll(proxyResp.Body)
-		proxyResp.Body.Close()
-		fmt.Fprintf(w, "%s\n", data)
 	}
+	defer proxyResp.Body.Close()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(proxyResp.StatusCode)
+	io.Copy(w, proxyResp.Body)
 }
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skupperproject/skupper/pkg/flow"
+	"gotest.tools/assert"
+)
+
+func TestWriteApiResponseConditional(t *testing.T) {
+	body := `{"results":[],"status":"","count":0,"timeRangeCount":0,"totalCount":0}`
+	etag := `W/"1-2"`
+
+	testTable := []struct {
+		name         string
+		response     flow.ApiResponse
+		expectedCode int
+		expectedETag bool
+		expectedBody bool
+	}{
+		{
+			name:         "modified",
+			response:     flow.ApiResponse{Body: &body, Status: http.StatusOK, ETag: etag},
+			expectedCode: http.StatusOK,
+			expectedETag: true,
+			expectedBody: true,
+		},
+		{
+			name:         "not-modified",
+			response:     flow.ApiResponse{Status: http.StatusNotModified, ETag: etag},
+			expectedCode: http.StatusNotModified,
+			expectedETag: true,
+		},
+		{
+			name:         "no-revision",
+			response:     flow.ApiResponse{Body: &body, Status: http.StatusOK},
+			expectedCode: http.StatusOK,
+			expectedBody: true,
+		},
+		{
+			name:         "error-response",
+			response:     flow.ApiResponse{Body: &body, Status: http.StatusInternalServerError, ETag: etag},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: true,
+		},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rec.Header().Set("Content-Type", "application/json")
+			req := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/sites/", nil)
+			writeApiResponse(rec, req, test.response)
+			assert.Equal(t, rec.Code, test.expectedCode)
+			if test.expectedETag {
+				assert.Equal(t, rec.Header().Get("ETag"), etag)
+			} else {
+				assert.Equal(t, rec.Header().Get("ETag"), "")
+			}
+			if test.expectedBody {
+				assert.Equal(t, rec.Body.String(), body)
+			} else {
+				assert.Equal(t, rec.Body.Len(), 0)
+				assert.Equal(t, rec.Header().Get("Content-Type"), "")
+			}
+		})
+	}
+}
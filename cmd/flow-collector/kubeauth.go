@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	kubeTokenAuthType     = "kubernetes"
+	kubeTokenCacheTtl     = 30 * time.Second
+	kubeTokenCacheEntries = 1000
+)
+
+// kubeTokenAuth is set when the collector accepts kubernetes tokens, such as
+// those of service accounts, validated with a TokenReview and authorized
+// with a SubjectAccessReview
+var kubeTokenAuth *kubeTokenAuthenticator
+
+type kubeTokenUserKey struct{}
+
+type kubeTokenReview struct {
+	user    string
+	allowed bool
+	expires time.Time
+}
+
+type kubeTokenAuthenticator struct {
+	client    kubernetes.Interface
+	namespace string
+	audiences []string
+	// required rejects the requests without a valid token, rather than
+	// falling back to the console authentication
+	required bool
+
+	lock    sync.Mutex
+	reviews map[string]kubeTokenReview
+}
+
+// newKubeTokenAuthenticatorFromEnv returns nil unless the console auth mode
+// is kubernetes or FLOW_KUBE_TOKEN_AUTH is set, FLOW_KUBE_TOKEN_AUDIENCES
+// restricts the audiences of the accepted tokens
+func newKubeTokenAuthenticatorFromEnv(kubeClient kubernetes.Interface, namespace string, authMode string) (*kubeTokenAuthenticator, error) {
+	enabled := authMode == types.ConsoleAuthModeKubernetes
+	if value := os.Getenv("FLOW_KUBE_TOKEN_AUTH"); value != "" && !enabled {
+		var err error
+		if enabled, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("invalid FLOW_KUBE_TOKEN_AUTH %q", value)
+		}
+	}
+	if !enabled {
+		return nil, nil
+	}
+	if kubeClient == nil {
+		return nil, fmt.Errorf("kubernetes token authentication is only supported on kubernetes")
+	}
+	a := &kubeTokenAuthenticator{
+		client:    kubeClient,
+		namespace: namespace,
+		required:  authMode == types.ConsoleAuthModeKubernetes,
+		reviews:   map[string]kubeTokenReview{},
+	}
+	for _, audience := range strings.Split(os.Getenv("FLOW_KUBE_TOKEN_AUDIENCES"), ",") {
+		if audience = strings.TrimSpace(audience); audience != "" {
+			a.audiences = append(a.audiences, audience)
+		}
+	}
+	return a, nil
+}
+
+// review authenticates the token and checks that its user may get the
+// proxy subresource of the skupper service, the results are cached briefly
+func (a *kubeTokenAuthenticator) review(ctx context.Context, token string) (string, bool, error) {
+	sum := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(sum[:])
+	now := time.Now()
+	a.lock.Lock()
+	cached, ok := a.reviews[key]
+	a.lock.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.user, cached.allowed, nil
+	}
+
+	tokenReview, err := a.client.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token:     token,
+			Audiences: a.audiences,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", false, err
+	}
+	result := kubeTokenReview{expires: now.Add(kubeTokenCacheTtl)}
+	if tokenReview.Status.Authenticated {
+		userInfo := tokenReview.Status.User
+		result.user = userInfo.Username
+		extra := map[string]authorizationv1.ExtraValue{}
+		for name, values := range userInfo.Extra {
+			extra[name] = authorizationv1.ExtraValue(values)
+		}
+		accessReview, err := a.client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:   userInfo.Username,
+				Groups: userInfo.Groups,
+				UID:    userInfo.UID,
+				Extra:  extra,
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace:   a.namespace,
+					Verb:        "get",
+					Resource:    "services",
+					Subresource: "proxy",
+					Name:        types.ControllerServiceName,
+				},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return "", false, err
+		}
+		result.allowed = accessReview.Status.Allowed
+	}
+
+	a.lock.Lock()
+	if len(a.reviews) >= kubeTokenCacheEntries {
+		for k, review := range a.reviews {
+			if !now.Before(review.expires) {
+				delete(a.reviews, k)
+			}
+		}
+		if len(a.reviews) >= kubeTokenCacheEntries {
+			a.reviews = map[string]kubeTokenReview{}
+		}
+	}
+	a.reviews[key] = result
+	a.lock.Unlock()
+	return result.user, result.allowed, nil
+}
+
+func (a *kubeTokenAuthenticator) authenticated(h http.HandlerFunc, fallback http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" || strings.HasPrefix(token, apiTokenPrefix) {
+			if a.required {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="skupper"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			fallback.ServeHTTP(w, r)
+			return
+		}
+		user, allowed, err := a.review(r.Context(), token)
+		if err != nil {
+			logger.Errorf("Failed to review kubernetes token: %s", err)
+		}
+		if user == "" && !a.required {
+			// not a kubernetes token, it may be one of the console
+			fallback.ServeHTTP(w, r)
+			return
+		}
+		if user == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="skupper", error="invalid_token"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !allowed {
+			logger.Warnf("Kubernetes user %s is not allowed to get services/proxy %s", user, types.ControllerServiceName)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), kubeTokenUserKey{}, user)))
+	})
+}
+
+func getKubeTokenUser(r *http.Request) (UserResponse, bool) {
+	user, ok := r.Context().Value(kubeTokenUserKey{}).(string)
+	return UserResponse{
+		Username: user,
+		AuthMode: kubeTokenAuthType,
+	}, ok
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skupperproject/skupper/api/types"
+	"gotest.tools/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newFakeReviewClient authenticates the tokens by user and allows the
+// users given
+func newFakeReviewClient(tokens map[string]string, allowed map[string]bool, reviews *int) *fake.Clientset {
+	kubeClient := fake.NewSimpleClientset()
+	kubeClient.Fake.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		*reviews++
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if user, ok := tokens[review.Spec.Token]; ok {
+			review.Status.Authenticated = true
+			review.Status.User.Username = user
+		}
+		return true, review, nil
+	})
+	kubeClient.Fake.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		review.Status.Allowed = allowed[review.Spec.User] && attributes.Namespace == "skupper" &&
+			attributes.Resource == "services" && attributes.Subresource == "proxy" && attributes.Name == types.ControllerServiceName
+		return true, review, nil
+	})
+	return kubeClient
+}
+
+func TestKubeTokenAuthenticator(t *testing.T) {
+	reviews := 0
+	kubeClient := newFakeReviewClient(map[string]string{
+		"reader-token":   "system:serviceaccount:monitoring:reader",
+		"stranger-token": "system:serviceaccount:default:stranger",
+	}, map[string]bool{
+		"system:serviceaccount:monitoring:reader": true,
+	}, &reviews)
+	h := func(w http.ResponseWriter, r *http.Request) {
+		user, _ := getKubeTokenUser(r)
+		w.Write([]byte(user.Username))
+	}
+	fallback := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}
+
+	testTable := []struct {
+		doc      string
+		authMode string
+		token    string
+		status   int
+		user     string
+	}{
+		{doc: "allowed", authMode: types.ConsoleAuthModeKubernetes, token: "reader-token", status: http.StatusOK, user: "system:serviceaccount:monitoring:reader"},
+		{doc: "not-allowed", authMode: types.ConsoleAuthModeKubernetes, token: "stranger-token", status: http.StatusForbidden},
+		{doc: "invalid", authMode: types.ConsoleAuthModeKubernetes, token: "forged-token", status: http.StatusUnauthorized},
+		{doc: "missing", authMode: types.ConsoleAuthModeKubernetes, status: http.StatusUnauthorized},
+		{doc: "layered-allowed", authMode: types.ConsoleAuthModeInternal, token: "reader-token", status: http.StatusOK, user: "system:serviceaccount:monitoring:reader"},
+		{doc: "layered-invalid", authMode: types.ConsoleAuthModeInternal, token: "forged-token", status: http.StatusTeapot},
+		{doc: "layered-missing", authMode: types.ConsoleAuthModeInternal, status: http.StatusTeapot},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			t.Setenv("FLOW_KUBE_TOKEN_AUTH", "true")
+			t.Setenv("FLOW_KUBE_TOKEN_AUDIENCES", "")
+			a, err := newKubeTokenAuthenticatorFromEnv(kubeClient, "skupper", test.authMode)
+			assert.Assert(t, err)
+			r := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/sites/", nil)
+			if test.token != "" {
+				r.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			rec := httptest.NewRecorder()
+			a.authenticated(h, fallback)(rec, r)
+			assert.Equal(t, rec.Code, test.status)
+			if test.user != "" {
+				assert.Equal(t, rec.Body.String(), test.user)
+			}
+		})
+	}
+
+	// the reviews of a token are cached
+	t.Setenv("FLOW_KUBE_TOKEN_AUTH", "")
+	a, err := newKubeTokenAuthenticatorFromEnv(kubeClient, "skupper", types.ConsoleAuthModeKubernetes)
+	assert.Assert(t, err)
+	reviews = 0
+	for i := 0; i < 3; i++ {
+		user, allowed, err := a.review(httptest.NewRequest(http.MethodGet, "/", nil).Context(), "reader-token")
+		assert.Assert(t, err)
+		assert.Assert(t, allowed)
+		assert.Equal(t, user, "system:serviceaccount:monitoring:reader")
+	}
+	assert.Equal(t, reviews, 1)
+}
+
+func TestKubeTokenAuthenticatorFromEnv(t *testing.T) {
+	t.Setenv("FLOW_KUBE_TOKEN_AUTH", "")
+	t.Setenv("FLOW_KUBE_TOKEN_AUDIENCES", "skupper, https://kubernetes.default.svc")
+	a, err := newKubeTokenAuthenticatorFromEnv(fake.NewSimpleClientset(), "skupper", types.ConsoleAuthModeInternal)
+	assert.Assert(t, err)
+	assert.Assert(t, a == nil)
+
+	a, err = newKubeTokenAuthenticatorFromEnv(fake.NewSimpleClientset(), "skupper", types.ConsoleAuthModeKubernetes)
+	assert.Assert(t, err)
+	assert.Assert(t, a.required)
+	assert.DeepEqual(t, a.audiences, []string{"skupper", "https://kubernetes.default.svc"})
+
+	_, err = newKubeTokenAuthenticatorFromEnv(nil, "", types.ConsoleAuthModeKubernetes)
+	assert.ErrorContains(t, err, "only supported on kubernetes")
+
+	t.Setenv("FLOW_KUBE_TOKEN_AUTH", "maybe")
+	_, err = newKubeTokenAuthenticatorFromEnv(fake.NewSimpleClientset(), "skupper", types.ConsoleAuthModeInternal)
+	assert.ErrorContains(t, err, "invalid FLOW_KUBE_TOKEN_AUTH")
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ldapAuth is set when internal authentication validates the console
+// credentials against an LDAP or Active Directory server
+var ldapAuth *ldapAuthenticator
+
+const (
+	ldapTimeout      = 10 * time.Second
+	ldapCacheTtl     = time.Minute
+	ldapDefaultQuery = "(uid=%s)"
+	// the responses are a bind result or a couple of search entries
+	// without attributes, anything larger is refused
+	ldapMaxMessageSize = 1024 * 1024
+)
+
+// ldapFilter validates a search filter in the string representation of
+// RFC 4515
+func ldapFilter(filter string) error {
+	_, err := ldap.CompileFilter(filter)
+	return err
+}
+
+type ldapCacheEntry struct {
+	hash    [32]byte
+	expires time.Time
+}
+
+// ldapAuthenticator validates credentials by looking up the user entry with
+// the service account and then binding as that entry with the password
+type ldapAuthenticator struct {
+	address      string
+	useTLS       bool
+	startTLS     bool
+	tlsConfig    *tls.Config
+	bindDN       string
+	bindPassword string
+	searchBase   string
+	userFilter   string
+
+	lock  sync.Mutex
+	cache map[string]ldapCacheEntry
+}
+
+func envOrFile(name string) (string, error) {
+	if file := os.Getenv(name + "_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return os.Getenv(name), nil
+}
+
+// newLdapAuthenticatorFromEnv returns nil when FLOW_LDAP_URL is not set
+func newLdapAuthenticatorFromEnv() (*ldapAuthenticator, error) {
+	rawUrl := os.Getenv("FLOW_LDAP_URL")
+	if rawUrl == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(rawUrl)
+	if err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid ldap url %q", rawUrl)
+	}
+	ber.MaxPacketLengthBytes = ldapMaxMessageSize
+	a := &ldapAuthenticator{
+		useTLS:     u.Scheme == "ldaps",
+		bindDN:     os.Getenv("FLOW_LDAP_BIND_DN"),
+		searchBase: os.Getenv("FLOW_LDAP_SEARCH_BASE"),
+		userFilter: os.Getenv("FLOW_LDAP_USER_FILTER"),
+		cache:      map[string]ldapCacheEntry{},
+		tlsConfig:  &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12},
+	}
+	a.startTLS, _ = strconv.ParseBool(os.Getenv("FLOW_LDAP_START_TLS"))
+	port := u.Port()
+	if port == "" {
+		port = "389"
+		if a.useTLS {
+			port = "636"
+		}
+	}
+	a.address = net.JoinHostPort(u.Hostname(), port)
+	if a.userFilter == "" {
+		a.userFilter = ldapDefaultQuery
+	}
+	if strings.Count(a.userFilter, "%s") < 1 {
+		return nil, fmt.Errorf("ldap user filter %q must contain %%s", a.userFilter)
+	}
+	if err := ldapFilter(strings.ReplaceAll(a.userFilter, "%s", "user")); err != nil {
+		return nil, err
+	}
+	if a.searchBase == "" {
+		return nil, fmt.Errorf("FLOW_LDAP_SEARCH_BASE is required")
+	}
+	if a.bindPassword, err = envOrFile("FLOW_LDAP_BIND_PASSWORD"); err != nil {
+		return nil, err
+	}
+	ca, err := envOrFile("FLOW_LDAP_CA")
+	if err != nil {
+		return nil, err
+	}
+	if ca != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(ca)) {
+			return nil, fmt.Errorf("no certificates found in ldap ca")
+		}
+		a.tlsConfig.RootCAs = pool
+	}
+	return a, nil
+}
+
+func (a *ldapAuthenticator) dial() (*ldap.Conn, error) {
+	scheme := "ldap"
+	if a.useTLS {
+		scheme = "ldaps"
+	}
+	conn, err := ldap.DialURL(scheme+"://"+a.address, ldap.DialWithDialer(&net.Dialer{Timeout: ldapTimeout}), ldap.DialWithTLSConfig(a.tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(ldapTimeout)
+	if a.startTLS && !a.useTLS {
+		if err := conn.StartTLS(a.tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ldap starttls failed: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+func (a *ldapAuthenticator) cached(user string, hash [32]byte) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	entry, ok := a.cache[user]
+	if ok && time.Now().Before(entry.expires) && entry.hash == hash {
+		return true
+	}
+	delete(a.cache, user)
+	return false
+}
+
+func (a *ldapAuthenticator) authenticate(user string, password string) bool {
+	if user == "" || password == "" {
+		// an empty password would be an unauthenticated bind
+		return false
+	}
+	hash := sha256.Sum256([]byte(user + "\x00" + password))
+	if a.cached(user, hash) {
+		return true
+	}
+	if err := a.verify(user, password); err != nil {
+		logger.Warnf("Failed to authenticate %s against ldap: %s", user, err)
+		return false
+	}
+	a.lock.Lock()
+	a.cache[user] = ldapCacheEntry{hash: hash, expires: time.Now().Add(ldapCacheTtl)}
+	a.lock.Unlock()
+	return true
+}
+
+func (a *ldapAuthenticator) verify(user string, password string) error {
+	conn, err := a.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if a.bindDN != "" {
+		if err := conn.Bind(a.bindDN, a.bindPassword); err != nil {
+			return fmt.Errorf("service bind failed: %w", err)
+		}
+	}
+	request := ldap.NewSearchRequest(a.searchBase, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(ldapTimeout.Seconds()), true,
+		strings.ReplaceAll(a.userFilter, "%s", ldap.EscapeFilter(user)), []string{"1.1"}, nil)
+	result, err := conn.Search(request)
+	if err != nil {
+		return fmt.Errorf("user search failed: %w", err)
+	}
+	if len(result.Entries) != 1 {
+		return fmt.Errorf("user search returned %d entries", len(result.Entries))
+	}
+	if err := conn.Bind(result.Entries[0].DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return fmt.Errorf("invalid credentials")
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"net"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"gotest.tools/assert"
+)
+
+// fakeLdapServer accepts simple binds for the given dn/password pairs and
+// answers any search with the configured entries
+func fakeLdapServer(t *testing.T, passwords map[string]string, entries []string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Assert(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				for {
+					msg, err := ber.ReadPacket(conn)
+					if err != nil || len(msg.Children) < 2 {
+						return
+					}
+					id := msg.Children[0].Value
+					reply := func(op *ber.Packet) {
+						envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+						envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+						envelope.AppendChild(op)
+						conn.Write(envelope.Bytes())
+					}
+					result := func(tag ber.Tag, code int) *ber.Packet {
+						op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+						op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, ""))
+						op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+						op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+						return op
+					}
+					op := msg.Children[1]
+					switch op.Tag {
+					case ldap.ApplicationBindRequest:
+						code := ldap.LDAPResultInvalidCredentials
+						if password, ok := passwords[op.Children[1].Data.String()]; ok && password == op.Children[2].Data.String() {
+							code = ldap.LDAPResultSuccess
+						}
+						reply(result(ldap.ApplicationBindResponse, code))
+					case ldap.ApplicationSearchRequest:
+						for _, dn := range entries {
+							entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+							entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, dn, ""))
+							entry.AppendChild(ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, ""))
+							reply(entry)
+						}
+						reply(result(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess))
+					case ldap.ApplicationUnbindRequest:
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestLdapFilter(t *testing.T) {
+	testTable := []struct {
+		filter string
+		err    bool
+	}{
+		{filter: "(uid=alice)"},
+		{filter: "(&(objectClass=person)(sAMAccountName=alice))"},
+		{filter: "(|(uid=a*)(!(cn=*)))"},
+		{filter: "(uid=" + ldap.EscapeFilter("a*b)(uid=*") + ")"},
+		{filter: "(uidReferenceNumber>=10)"},
+		{filter: "uid=alice", err: true},
+		{filter: "(&(uid=alice)", err: true},
+		{filter: "(uid=alice)(cn=bob)", err: true},
+		{filter: "(uid=\\zz)", err: true},
+	}
+	for _, test := range testTable {
+		t.Run(test.filter, func(t *testing.T) {
+			err := ldapFilter(test.filter)
+			assert.Equal(t, err != nil, test.err)
+		})
+	}
+}
+
+func TestLdapAuthenticate(t *testing.T) {
+	passwords := map[string]string{
+		"cn=service,dc=example,dc=com": "secret",
+		"uid=alice,dc=example,dc=com":  "wonderland",
+	}
+	testTable := []struct {
+		name     string
+		entries  []string
+		user     string
+		password string
+		expected bool
+	}{
+		{name: "valid", entries: []string{"uid=alice,dc=example,dc=com"}, user: "alice", password: "wonderland", expected: true},
+		{name: "wrong-password", entries: []string{"uid=alice,dc=example,dc=com"}, user: "alice", password: "looking-glass"},
+		{name: "empty-password", entries: []string{"uid=alice,dc=example,dc=com"}, user: "alice"},
+		{name: "no-such-user", user: "bob", password: "wonderland"},
+		{name: "ambiguous", entries: []string{"uid=alice,dc=example,dc=com", "uid=alice,ou=other,dc=example,dc=com"}, user: "alice", password: "wonderland"},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			address := fakeLdapServer(t, passwords, test.entries)
+			t.Setenv("FLOW_LDAP_URL", "ldap://"+address)
+			t.Setenv("FLOW_LDAP_BIND_DN", "cn=service,dc=example,dc=com")
+			t.Setenv("FLOW_LDAP_BIND_PASSWORD", "secret")
+			t.Setenv("FLOW_LDAP_SEARCH_BASE", "dc=example,dc=com")
+			t.Setenv("FLOW_LDAP_USER_FILTER", "(&(objectClass=person)(uid=%s))")
+			auth, err := newLdapAuthenticatorFromEnv()
+			assert.Assert(t, err)
+			assert.Equal(t, auth.authenticate(test.user, test.password), test.expected)
+			// only successful logins are cached
+			_, cached := auth.cache[test.user]
+			assert.Equal(t, cached, test.expected)
+		})
+	}
+}
+
+func TestLdapConfig(t *testing.T) {
+	t.Setenv("FLOW_LDAP_URL", "")
+	auth, err := newLdapAuthenticatorFromEnv()
+	assert.Assert(t, err)
+	assert.Assert(t, auth == nil)
+
+	t.Setenv("FLOW_LDAP_URL", "ldaps://ldap.example.com")
+	t.Setenv("FLOW_LDAP_SEARCH_BASE", "dc=example,dc=com")
+	t.Setenv("FLOW_LDAP_USER_FILTER", "")
+	auth, err = newLdapAuthenticatorFromEnv()
+	assert.Assert(t, err)
+	assert.Equal(t, auth.address, "ldap.example.com:636")
+	assert.Equal(t, auth.userFilter, ldapDefaultQuery)
+
+	t.Setenv("FLOW_LDAP_USER_FILTER", "(uid=alice)")
+	_, err = newLdapAuthenticatorFromEnv()
+	assert.ErrorContains(t, err, "must contain")
+
+	t.Setenv("FLOW_LDAP_URL", "http://ldap.example.com")
+	_, err = newLdapAuthenticatorFromEnv()
+	assert.ErrorContains(t, err, "invalid ldap url")
+}
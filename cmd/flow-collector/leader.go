@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/flow"
+)
+
+// leaderState tracks whether this collector replica is the active one.
+// Every replica collects, so that a standby taking over already holds the
+// records, but only the leader is ready to serve and only the leader has
+// side effects such as writing the network status or exporting events.
+type leaderState struct {
+	leading int32
+}
+
+func (l *leaderState) set(leading bool) {
+	if leading {
+		atomic.StoreInt32(&l.leading, 1)
+	} else {
+		atomic.StoreInt32(&l.leading, 0)
+	}
+}
+
+func (l *leaderState) isLeader() bool {
+	return atomic.LoadInt32(&l.leading) == 1
+}
+
+// ready is the readiness probe, so that the service only routes to the
+// leader
+func (l *leaderState) ready(w http.ResponseWriter, r *http.Request) {
+	if !l.isLeader() {
+		http.Error(w, "flow collector is on standby", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+type leaderEvents struct {
+	leader *leaderState
+	sink   flow.FlowEventSink
+}
+
+func (e *leaderEvents) FlowEvent(event flow.FlowEvent) {
+	if e.leader.isLeader() {
+		e.sink.FlowEvent(event)
+	}
+}
+
+// events forwards the flow events to the sink only while leading
+func (l *leaderState) events(sink flow.FlowEventSink) flow.FlowEventSink {
+	if sink == nil {
+		return nil
+	}
+	return &leaderEvents{leader: l, sink: sink}
+}
+
+// runLeaderElection blocks until the stop channel is closed, campaigning
+// for the lease again whenever it is lost.
+func runLeaderElection(leader *leaderState, kubeClient kubernetes.Interface, namespace string, stopCh <-chan struct{}) {
+	podname, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      types.FlowCollectorLeaderLockName,
+			Namespace: namespace,
+		},
+		Client: kubeClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: podname,
+		},
+	}
+
+	begin := time.Now()
+	for ctx.Err() == nil {
+		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			ReleaseOnCancel: true,
+			LeaseDuration:   15 * time.Second,
+			RenewDeadline:   10 * time.Second,
+			RetryPeriod:     2 * time.Second,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(leaderCtx context.Context) {
+					logger.Infof("Leader %s serving flow collection after %s", podname, time.Since(begin))
+					leader.set(true)
+				},
+				OnStoppedLeading: func() {
+					leader.set(false)
+					if ctx.Err() == nil {
+						logger.Warnf("%s lost flow collector leadership, stepping down to standby", podname)
+					} else {
+						logger.Infof("%s released flow collector leadership", podname)
+					}
+				},
+				OnNewLeader: func(identity string) {
+					if identity == podname {
+						return
+					}
+					logger.Infof("New leader for flow collection is %s", identity)
+				},
+			},
+		})
+	}
+}
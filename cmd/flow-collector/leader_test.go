@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/pkg/flow"
+)
+
+func TestLeaderReady(t *testing.T) {
+	leader := &leaderState{}
+
+	rec := httptest.NewRecorder()
+	leader.ready(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, rec.Code, http.StatusServiceUnavailable)
+
+	leader.set(true)
+	rec = httptest.NewRecorder()
+	leader.ready(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+}
+
+type countingSink struct {
+	count int
+}
+
+func (s *countingSink) FlowEvent(event flow.FlowEvent) {
+	s.count++
+}
+
+func TestLeaderEvents(t *testing.T) {
+	leader := &leaderState{}
+	assert.Assert(t, leader.events(nil) == nil)
+
+	sink := &countingSink{}
+	events := leader.events(sink)
+	events.FlowEvent(flow.FlowEvent{})
+	assert.Equal(t, sink.count, 0)
+
+	leader.set(true)
+	events.FlowEvent(flow.FlowEvent{})
+	assert.Equal(t, sink.count, 1)
+
+	leader.set(false)
+	events.FlowEvent(flow.FlowEvent{})
+	assert.Equal(t, sink.count, 1)
+}
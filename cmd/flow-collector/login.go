@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/skupperproject/skupper/pkg/utils"
+)
+
+const (
+	defaultLoginMaxFailures = 5
+	defaultLoginLockout     = 30 * time.Second
+	loginMaxLockout         = 15 * time.Minute
+	// a source trying many users is allowed more failures than a single
+	// user, so that a shared address is not locked out by one typo too many
+	loginSourceFailureFactor = 4
+)
+
+// loginThrottle is set when the console users are authenticated by
+// password, it locks out the users and sources that fail too often
+var loginThrottle *loginLimiter
+
+type loginLimiter struct {
+	users    *utils.AttemptLimiter
+	sources  *utils.AttemptLimiter
+	failures prometheus.Counter
+	lockouts *prometheus.CounterVec
+}
+
+// newLoginLimiterFromEnv reads FLOW_LOGIN_MAX_FAILURES, the consecutive
+// failed logins of a user from an address that lock it out, zero disables
+// the lockouts, and FLOW_LOGIN_LOCKOUT, the first lockout which doubles
+// with every further failure
+func newLoginLimiterFromEnv(reg prometheus.Registerer) (*loginLimiter, error) {
+	maxFailures := defaultLoginMaxFailures
+	lockout := defaultLoginLockout
+	if value := os.Getenv("FLOW_LOGIN_MAX_FAILURES"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid FLOW_LOGIN_MAX_FAILURES %q", value)
+		}
+		maxFailures = parsed
+	}
+	if value := os.Getenv("FLOW_LOGIN_LOCKOUT"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid FLOW_LOGIN_LOCKOUT %q", value)
+		}
+		lockout = parsed
+	}
+	l := &loginLimiter{
+		users:   utils.NewAttemptLimiter(0, 0, maxFailures, lockout, loginMaxLockout),
+		sources: utils.NewAttemptLimiter(0, 0, maxFailures*loginSourceFailureFactor, lockout, loginMaxLockout),
+		failures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "skupper_console_login_failures_total",
+			Help: "Failed console logins",
+		}),
+		lockouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "skupper_console_login_lockouts_total",
+			Help: "Console logins locked out after repeated failures",
+		}, []string{"scope"}),
+	}
+	if reg != nil {
+		if err := reg.Register(l.failures); err != nil {
+			return nil, err
+		}
+		if err := reg.Register(l.lockouts); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+func loginUserKey(r *http.Request, user string) string {
+	return user + "@" + sourceIP(r)
+}
+
+// locked returns how long the logins of the user from the address of the
+// request remain locked out
+func (l *loginLimiter) locked(r *http.Request, user string) time.Duration {
+	if l == nil {
+		return 0
+	}
+	wait := l.users.Locked(loginUserKey(r, user))
+	if source := l.sources.Locked(sourceIP(r)); source > wait {
+		wait = source
+	}
+	return wait
+}
+
+// record counts the failed logins and forgets them once the user succeeds
+func (l *loginLimiter) record(r *http.Request, user string, ok bool) {
+	if l == nil {
+		return
+	}
+	key := loginUserKey(r, user)
+	if ok {
+		l.users.Succeeded(key)
+		l.sources.Succeeded(sourceIP(r))
+		return
+	}
+	l.failures.Inc()
+	if lockout := l.users.Failed(key); lockout > 0 {
+		l.lockouts.WithLabelValues("user").Inc()
+		logger.Warnf("EVENT ConsoleLoginLockout: user %s locked out from %s for %s after repeated failed logins", user, sourceIP(r), lockout)
+	}
+	if lockout := l.sources.Failed(sourceIP(r)); lockout > 0 {
+		l.lockouts.WithLabelValues("source").Inc()
+		logger.Warnf("EVENT ConsoleLoginLockout: logins from %s locked out for %s after repeated failed logins", sourceIP(r), lockout)
+	}
+}
+
+func tooManyLogins(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestLoginLimiter(t *testing.T) {
+	t.Setenv("FLOW_LOGIN_MAX_FAILURES", "2")
+	t.Setenv("FLOW_LOGIN_LOCKOUT", "1m")
+	l, err := newLoginLimiterFromEnv(prometheus.NewRegistry())
+	assert.Assert(t, err)
+
+	request := func(source string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/user/", nil)
+		r.RemoteAddr = source + ":40000"
+		return r
+	}
+	testTable := []struct {
+		doc    string
+		source string
+		user   string
+		ok     bool
+		locked bool
+	}{
+		{doc: "first failure", source: "10.0.0.1", user: "admin"},
+		{doc: "success resets", source: "10.0.0.1", user: "admin", ok: true},
+		{doc: "failure", source: "10.0.0.1", user: "admin"},
+		{doc: "lockout", source: "10.0.0.1", user: "admin", locked: true},
+		{doc: "other source", source: "10.0.0.2", user: "admin", ok: true},
+		{doc: "spray one", source: "10.0.0.3", user: "a"},
+		{doc: "spray two", source: "10.0.0.3", user: "b"},
+		{doc: "spray three", source: "10.0.0.3", user: "c"},
+		{doc: "spray four", source: "10.0.0.3", user: "d"},
+		{doc: "spray five", source: "10.0.0.3", user: "e"},
+		{doc: "spray six", source: "10.0.0.3", user: "f"},
+		{doc: "spray seven", source: "10.0.0.3", user: "g"},
+		{doc: "spray eight", source: "10.0.0.3", user: "h", locked: true},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			r := request(test.source)
+			assert.Equal(t, l.locked(r, test.user), time.Duration(0))
+			l.record(r, test.user, test.ok)
+			assert.Equal(t, l.locked(r, test.user) > 0, test.locked)
+		})
+	}
+	assert.Equal(t, testutil.ToFloat64(l.failures), float64(11))
+	assert.Equal(t, testutil.ToFloat64(l.lockouts.WithLabelValues("user")), float64(1))
+	assert.Equal(t, testutil.ToFloat64(l.lockouts.WithLabelValues("source")), float64(1))
+
+	// a nil limiter never locks anyone out
+	var disabled *loginLimiter
+	disabled.record(request("10.0.0.1"), "admin", false)
+	assert.Equal(t, disabled.locked(request("10.0.0.1"), "admin"), time.Duration(0))
+
+	t.Setenv("FLOW_LOGIN_LOCKOUT", "soon")
+	_, err = newLoginLimiterFromEnv(nil)
+	assert.ErrorContains(t, err, "invalid FLOW_LOGIN_LOCKOUT")
+}
+
+func TestLoginLockoutResponse(t *testing.T) {
+	t.Setenv("FLOW_LOGIN_MAX_FAILURES", "1")
+	t.Setenv("FLOW_LOGIN_LOCKOUT", "")
+	l, err := newLoginLimiterFromEnv(nil)
+	assert.Assert(t, err)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	l.record(r, "admin", false)
+	wait := l.locked(r, "admin")
+	assert.Assert(t, wait > 0)
+	rec := httptest.NewRecorder()
+	tooManyLogins(rec, wait)
+	assert.Equal(t, rec.Code, http.StatusTooManyRequests)
+	assert.Equal(t, rec.Header().Get("Retry-After"), "30")
+}
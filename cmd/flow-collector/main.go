@@ -8,70 +8,47 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
-	_ "net/http/pprof"
 	"os"
 	"os/signal"
 	"path"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
-	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	clientpodman "github.com/skupperproject/skupper/client/podman"
 	"github.com/skupperproject/skupper/pkg/config"
 	"github.com/skupperproject/skupper/pkg/domain/podman"
 	"github.com/skupperproject/skupper/pkg/utils"
 
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/client"
-	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/flow"
 	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/logging"
+	"github.com/skupperproject/skupper/pkg/utils/configs"
 	"github.com/skupperproject/skupper/pkg/version"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes"
 )
 
-// should this be in utils?
-type tlsConfig struct {
-	Ca     string `json:"ca,omitempty"`
-	Cert   string `json:"cert,omitempty"`
-	Key    string `json:"key,omitempty"`
-	Verify bool   `json:"recType,omitempty"`
-}
-
-type connectJson struct {
-	Scheme string    `json:"scheme,omitempty"`
-	Host   string    `json:"host,omitempty"`
-	Port   string    `json:"port,omitempty"`
-	Tls    tlsConfig `json:"tls,omitempty"`
-}
-
 type UserResponse struct {
 	Username string `json:"username"`
 	AuthMode string `json:"authType"`
+	Role     string `json:"role,omitempty"`
 }
 
-var onlyOneSignalHandler = make(chan struct{})
-var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+var logger = logging.New("collector")
 
-func getConnectInfo(file string) (connectJson, error) {
-	cj := connectJson{}
-
-	jsonFile, err := os.ReadFile(file)
-	if err != nil {
-		return cj, err
-	}
-
-	err = json.Unmarshal(jsonFile, &cj)
-	if err != nil {
-		return cj, err
-	}
+var onlyOneSignalHandler = make(chan struct{})
 
-	return cj, nil
-}
+// htpasswdAuth is set when internal authentication validates the console
+// credentials against the FLOW_HTPASSWD file
+var htpasswdAuth *utils.Htpasswd
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
 
 func SetupSignalHandler() (stopCh <-chan struct{}) {
 	close(onlyOneSignalHandler) // panics when called twice
@@ -89,26 +66,18 @@ func SetupSignalHandler() (stopCh <-chan struct{}) {
 	return stop
 }
 
-func cors(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		origin := r.Header.Get("Origin")
-		if origin == "" {
-			origin = "*"
-		}
-		w.Header().Set("Access-Control-Allow-Origin", origin)
-		w.Header().Set("Access-Control-Allow-Methods", "GET,POST,DELETE")
-		next.ServeHTTP(w, r)
-	})
-}
-
 func authenticate(dir string, user string, password string) bool {
+	if strings.HasPrefix(user, ".") {
+		logger.Warnf("Failed to authenticate %s, no such user exists", user)
+		return false
+	}
 	filename := path.Join(dir, user)
 	file, err := os.Open(filename)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			log.Printf("COLLECTOR: Failed to authenticate %s, no such user exists", user)
+			logger.Warnf("Failed to authenticate %s, no such user exists", user)
 		} else {
-			log.Printf("COLLECTOR: Failed to authenticate %s: %s", user, err)
+			logger.Warnf("Failed to authenticate %s: %s", user, err)
 		}
 		return false
 	}
@@ -116,23 +85,99 @@ func authenticate(dir string, user string, password string) bool {
 
 	bytes, err := io.ReadAll(file)
 	if err != nil {
-		log.Printf("COLLECTOR: Failed to authenticate %s: %s", user, err)
+		logger.Warnf("Failed to authenticate %s: %s", user, err)
 		return false
 	}
-	return string(bytes) == password
+	return utils.VerifyPassword(string(bytes), password)
 }
 
 func authenticated(h http.HandlerFunc) http.HandlerFunc {
+	h = auditLog.attribute(h)
+	next := consoleAuthenticated(h)
+	if kubeTokenAuth != nil {
+		next = kubeTokenAuth.authenticated(h, next)
+	}
+	if apiTokenAuth != nil {
+		next = apiTokenAuth.authenticated(h, next)
+	}
+	if clientCertAuth != nil {
+		return clientCertAuth.authenticated(h, next)
+	}
+	return next
+}
+
+// passwordCheck verifies the console users against the ldap server, the
+// htpasswd file or the users directory, it returns nil when none is configured
+func passwordCheck(dir string) func(string, string) bool {
+	if ldapAuth != nil {
+		return ldapAuth.authenticate
+	} else if htpasswdAuth != nil {
+		return func(user string, password string) bool {
+			ok, err := htpasswdAuth.Authenticate(user, password)
+			if err != nil {
+				logger.Warnf("Failed to read the htpasswd file: %s", err)
+			}
+			if !ok {
+				logger.Warnf("Failed to authenticate %s", user)
+			}
+			return ok
+		}
+	} else if dir != "" {
+		return func(user string, password string) bool {
+			return authenticate(dir, user, password)
+		}
+	}
+	return nil
+}
+
+func consoleAuthenticated(h http.HandlerFunc) http.HandlerFunc {
 	dir := os.Getenv("FLOW_USERS")
 
-	if dir != "" {
+	if openIDAuth != nil {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorized, err := openIDAuth.authenticate(r)
+			if err == nil {
+				h.ServeHTTP(w, authorized)
+			} else {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="skupper", error="invalid_token"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			}
+		})
+	} else if samlAuth != nil {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorized, err := samlAuth.authenticate(r)
+			if err == nil {
+				h.ServeHTTP(w, authorized)
+			} else {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			}
+		})
+	} else if check := passwordCheck(dir); check != nil {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if sessionAuth != nil {
+				if authorized, err := sessionAuth.authenticate(r); err == nil {
+					h.ServeHTTP(w, authorized)
+					return
+				}
+			}
 			user, password, ok := r.BasicAuth()
+			if ok {
+				if wait := loginThrottle.locked(r, user); wait > 0 {
+					tooManyLogins(w, wait)
+					return
+				}
+				ok = check(user, password)
+				loginThrottle.record(r, user, ok)
+			}
 
-			if ok && authenticate(dir, user, password) {
+			if ok {
 				h.ServeHTTP(w, r)
 			} else {
-				w.Header().Set("WWW-Authenticate", "Basic realm=skupper")
+				// a console with a session logs in again rather than
+				// have the browser prompt for credentials
+				if !hasSessionCookie(r) {
+					w.Header().Set("WWW-Authenticate", "Basic realm=skupper")
+				}
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			}
 		})
@@ -147,7 +192,10 @@ func getOpenshiftUser(r *http.Request) UserResponse {
 		AuthMode: string(types.ConsoleAuthModeOpenshift),
 	}
 
-	if cookie, err := r.Cookie("_oauth_proxy"); err == nil && cookie != nil {
+	// the oauth proxy passes the user it authenticated
+	if user := r.Header.Get("X-Forwarded-User"); user != "" {
+		userResponse.Username = user
+	} else if cookie, err := r.Cookie("_oauth_proxy"); err == nil && cookie != nil {
 		if cookieDecoded, _ := base64.StdEncoding.DecodeString(cookie.Value); cookieDecoded != nil {
 			userResponse.Username = string(cookieDecoded)
 		}
@@ -162,9 +210,9 @@ func getInternalUser(r *http.Request) UserResponse {
 		AuthMode: string(types.ConsoleAuthModeInternal),
 	}
 
-	user, _, ok := r.BasicAuth()
-
-	if ok {
+	if user, ok := getSessionUser(r); ok {
+		userResponse.Username = user
+	} else if user, _, ok := r.BasicAuth(); ok {
 		userResponse.Username = user
 	}
 
@@ -190,6 +238,10 @@ func openshiftLogout(w http.ResponseWriter, r *http.Request) {
 }
 
 func internalLogout(w http.ResponseWriter, r *http.Request, validNonces map[string]bool) {
+	if sessionAuth != nil && hasSessionCookie(r) {
+		sessionAuth.logout(w, r)
+		return
+	}
 	queryParams := r.URL.Query()
 	nonce := queryParams.Get("nonce")
 
@@ -211,7 +263,7 @@ func main() {
 	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	// if -version used, report and exit
 	isVersion := flags.Bool("version", false, "Report the version of the Skupper Flow Collector")
-	isProf := flags.Bool("profile", false, "Exposes the runtime profiling facilities from net/http/pprof on http://localhost:9970")
+	isProf := flags.Bool("profile", false, "Exposes the runtime profiling facilities from net/http/pprof on FLOW_PPROF_ADDR, http://localhost:9970 by default")
 
 	flags.Parse(os.Args[1:])
 	if *isVersion {
@@ -219,8 +271,12 @@ func main() {
 		os.Exit(0)
 	}
 
+	if err := logging.Configure("collector"); err != nil {
+		logger.Fatalf("Invalid logging configuration: %s", err)
+	}
+
 	// Startup message
-	log.Printf("COLLECTOR: Starting Skupper Flow collector controller version %s \n", version.Version)
+	logger.Infof("Starting Skupper Flow collector controller version %s", version.Version)
 
 	origin := os.Getenv("SKUPPER_SITE_ID")
 	namespace := os.Getenv("SKUPPER_NAMESPACE")
@@ -233,6 +289,13 @@ func main() {
 	var enableConsole bool
 	var prometheusUrl string
 	var authMode string
+	var openIDIssuer string
+	var openIDClientId string
+	var samlIdPMetadata string
+	var samlConsoleURL string
+	var kubeClient kubernetes.Interface
+	leaderElection, _ := strconv.ParseBool(os.Getenv("FLOW_LEADER_ELECTION"))
+	leader := &leaderState{}
 	//collecting valid nonces for internal auth mode
 	var validNonces = make(map[string]bool)
 
@@ -240,36 +303,41 @@ func main() {
 	if platform == "" || platform == types.PlatformKubernetes {
 		cli, err := client.NewClient(namespace, "", "")
 		if err != nil {
-			log.Fatal("COLLECTOR: Error getting van client", err.Error())
+			logger.Fatalf("Error getting van client: %s", err)
 		}
+		kubeClient = cli.KubeClient
 
-		log.Println("COLLECTOR: Waiting for Skupper router component to start")
+		logger.Infof("Waiting for Skupper router component to start")
 		_, err = kube.WaitDeploymentReady(types.TransportDeploymentName, namespace, cli.KubeClient, time.Second*180, time.Second*5)
 		if err != nil {
-			log.Fatal("COLLECTOR: Error waiting for transport deployment to be ready ", err.Error())
+			logger.Fatalf("Error waiting for transport deployment to be ready: %s", err)
 		}
 
 		siteConfig, err := cli.SiteConfigInspect(context.Background(), nil)
 		if err != nil {
-			log.Fatal("COLLECTOR: Error getting site config", err.Error())
+			logger.Fatalf("Error getting site config: %s", err)
 		}
 
 		flowRecordTtl = siteConfig.Spec.FlowCollector.FlowRecordTtl
 		enableConsole = siteConfig.Spec.EnableConsole
 		authMode = siteConfig.Spec.AuthMode
+		openIDIssuer = siteConfig.Spec.OpenIDIssuer
+		openIDClientId = siteConfig.Spec.OpenIDClientId
+		samlIdPMetadata = siteConfig.Spec.SAMLIdPMetadata
+		samlConsoleURL = siteConfig.Spec.SAMLConsoleURL
 
 		svc, err := kube.GetService(types.PrometheusServiceName, cli.Namespace, cli.KubeClient)
 		if err == nil {
 			prometheusUrl = "http://" + svc.Spec.ClusterIP + ":" + fmt.Sprint(svc.Spec.Ports[0].Port) + "/api/v1/"
 		}
 	} else {
-		cfg, err := podman.NewPodmanConfigFileHandler().GetConfig()
+		cfg, err := podman.NewConfigFileHandler(platform).GetConfig()
 		if err != nil {
-			log.Fatal("Error reading podman site config", err)
+			logger.Fatalf("Error reading %s site config: %s", platform, err)
 		}
-		podmanCli, err := clientpodman.NewPodmanClient(cfg.Endpoint, "")
+		podmanCli, err := podman.NewEngineClient(platform, cfg.Endpoint)
 		if err != nil {
-			log.Fatal("Error creating podman client", err)
+			logger.Fatalf("Error creating %s client: %s", platform, err)
 		}
 		err = utils.Retry(time.Second, 120, func() (bool, error) {
 			router, err := podmanCli.ContainerInspect(types.TransportDeploymentName)
@@ -282,33 +350,231 @@ func main() {
 			return true, nil
 		})
 		if err != nil {
-			log.Fatalf("unable to determine if %s container is running - %s", types.TransportDeploymentName, err)
+			logger.Fatalf("unable to determine if %s container is running - %s", types.TransportDeploymentName, err)
 		}
 		flowRecordTtl, _ = time.ParseDuration(os.Getenv("FLOW_RECORD_TTL"))
 		enableConsole, _ = strconv.ParseBool(os.Getenv("ENABLE_CONSOLE"))
-		prometheusUrl = "http://skupper-prometheus:9090/api/v1/"
+		prometheusUrl = utils.DefaultStr(os.Getenv("PROMETHEUS_URL"), "http://skupper-prometheus:9090/api/v1/")
 
 		flowUsers := os.Getenv("FLOW_USERS")
-		// Podman support only unsecured auth mode and internal auth mode
+		openIDIssuer = os.Getenv("FLOW_OIDC_ISSUER")
+		openIDClientId = os.Getenv("FLOW_OIDC_CLIENT_ID")
+		samlIdPMetadata = os.Getenv("FLOW_SAML_IDP_METADATA")
+		samlConsoleURL = os.Getenv("FLOW_SAML_URL")
+		// Podman support only unsecured, internal, openid and saml auth modes
 		authMode = types.ConsoleAuthModeUnsecured
-		if flowUsers != "" {
+		if openIDIssuer != "" {
+			authMode = types.ConsoleAuthModeOpenID
+		} else if samlIdPMetadata != "" {
+			authMode = types.ConsoleAuthModeSAML
+		} else if flowUsers != "" || os.Getenv("FLOW_LDAP_URL") != "" || os.Getenv("FLOW_HTPASSWD") != "" {
 			authMode = types.ConsoleAuthModeInternal
 		}
+		if leaderElection {
+			logger.Infof("Leader election is only supported on kubernetes, ignoring")
+			leaderElection = false
+		}
 	}
 
-	tlsConfig := certs.GetTlsConfigRetriever(true, types.ControllerConfigPath+"tls.crt", types.ControllerConfigPath+"tls.key", types.ControllerConfigPath+"ca.crt")
+	if err := validateCollectorConfig("/etc/service-controller/console/", types.ControllerConfigPath+"connect.json", prometheusUrl); err != nil {
+		logger.Fatalf("Invalid configuration, %s", err)
+	}
 
-	conn, err := getConnectInfo(types.ControllerConfigPath + "connect.json")
+	conn, err := configs.LoadConnectInfo(types.ControllerConfigPath+"connect.json", "FLOW_CONNECT_")
 	if err != nil {
-		log.Fatal("Error getting connect.json", err.Error())
+		logger.Fatalf("Invalid router connection configuration: %s", err)
 	}
 
 	reg := prometheus.NewRegistry()
-	c, err := NewController(origin, reg, conn.Scheme, conn.Host, conn.Port, tlsConfig, flowRecordTtl)
+	var memoryBudget uint64
+	if budget := os.Getenv("FLOW_MEMORY_BUDGET"); budget != "" {
+		quantity, err := resource.ParseQuantity(budget)
+		if err != nil || quantity.Sign() <= 0 {
+			logger.Fatalf("Invalid memory budget %q", budget)
+		}
+		memoryBudget = uint64(quantity.Value())
+		logger.Infof("Memory budget set to %s", quantity.String())
+	}
+
+	var tagRules []flow.TagRule
+	if rulesFile := os.Getenv("FLOW_TAG_RULES"); rulesFile != "" {
+		tagRules, err = flow.LoadTagRules(rulesFile)
+		if err != nil {
+			logger.Fatalf("Unable to load tag rules: %s", err)
+		}
+		logger.Infof("Loaded %d tag rules from %s", len(tagRules), rulesFile)
+	}
+
+	var latencyBuckets []float64
+	if buckets := os.Getenv("FLOW_LATENCY_BUCKETS"); buckets != "" {
+		latencyBuckets, err = flow.ParseLatencyBuckets(buckets)
+		if err != nil {
+			logger.Fatalf("Invalid latency buckets: %s", err)
+		}
+		logger.Infof("Latency histogram buckets set to %s", buckets)
+	}
+
+	// the routers and sites not heard from are shown as stale for the grace
+	// period before they are purged
+	var staleSourceAfter, staleSourceGrace time.Duration
+	if value := os.Getenv("FLOW_STALE_SOURCE_AFTER"); value != "" {
+		staleSourceAfter, err = time.ParseDuration(value)
+		if err != nil || staleSourceAfter <= 0 {
+			logger.Fatalf("Invalid FLOW_STALE_SOURCE_AFTER %q", value)
+		}
+	}
+	if value := os.Getenv("FLOW_STALE_SOURCE_GRACE"); value != "" {
+		staleSourceGrace, err = time.ParseDuration(value)
+		if err != nil || staleSourceGrace <= 0 {
+			logger.Fatalf("Invalid FLOW_STALE_SOURCE_GRACE %q", value)
+		}
+	}
+	var clockSkewThreshold time.Duration
+	if value := os.Getenv("FLOW_CLOCK_SKEW_THRESHOLD"); value != "" {
+		clockSkewThreshold, err = time.ParseDuration(value)
+		if err != nil || clockSkewThreshold <= 0 {
+			logger.Fatalf("Invalid FLOW_CLOCK_SKEW_THRESHOLD %q", value)
+		}
+	}
+
+	var flowEventSink flow.FlowEventSink
+	var sinks flowEventSinks
+	flowEvents, err := newFlowEventExporterFromEnv()
+	if err != nil {
+		logger.Fatalf("Error configuring the flow event export: %s", err)
+	}
+	if flowEvents != nil {
+		// a nil exporter must not be set as the sink
+		sinks = append(sinks, flowEvents)
+		logger.Infof("Exporting the flow open and close events")
+		go flowEvents.run(stopCh)
+	}
+	flowTraces, err := newFlowTraceExporterFromEnv()
+	if err != nil {
+		logger.Fatalf("Error configuring the flow trace export: %s", err)
+	}
+	if flowTraces != nil {
+		sinks = append(sinks, flowTraces)
+		logger.Infof("Exporting the completed flows as OpenTelemetry spans to %s", flowTraces.otlp.url)
+		go flowTraces.run(stopCh)
+	}
+	if len(sinks) > 0 {
+		flowEventSink = sinks
+		if leaderElection {
+			flowEventSink = leader.events(sinks)
+		}
+	}
+
+	if !leaderElection {
+		leader.set(true)
+	}
+	c, err := NewController(origin, reg, conn, flowRecordTtl, staleSourceAfter, staleSourceGrace, clockSkewThreshold, memoryBudget, tagRules, latencyBuckets, flowEventSink, leader.isLeader, newSiteEventStore(kubeClient, namespace, leader.isLeader), newSloStore(kubeClient, namespace, leader.isLeader))
+	if err != nil {
+		logger.Fatalf("Error getting new flow collector: %s", err)
+	}
+	// the metrics are relabeled wherever they are scraped or pushed to
+	gatherer, err := newMetricRelabelingFromEnv(reg)
 	if err != nil {
-		log.Fatal("Error getting new flow collector ", err.Error())
+		logger.Fatalf("Error configuring the metric labels: %s", err)
+	}
+	otlpMetrics, err := newOtlpMetricsExporterFromEnv(gatherer, origin, leader.isLeader)
+	if err != nil {
+		logger.Fatalf("Error configuring the metrics export: %s", err)
+	}
+	if otlpMetrics != nil {
+		logger.Infof("Pushing the metrics to %s every %s", otlpMetrics.otlp.url, otlpMetrics.interval)
+		go otlpMetrics.run(stopCh)
+	}
+	remoteWriter, err := newRemoteWriterFromEnv(gatherer, leader.isLeader)
+	if err != nil {
+		logger.Fatalf("Error configuring the metrics remote write: %s", err)
+	}
+	if remoteWriter != nil {
+		logger.Infof("Remote writing the metrics to %s every %s", remoteWriter.url, remoteWriter.interval)
+		go remoteWriter.run(stopCh)
+	}
+	if kubeClient != nil {
+		go watchSloAnnotations(kubeClient, namespace, c.FlowCollector, stopCh)
 	}
 	c.FlowCollector.Collector.PrometheusUrl = prometheusUrl
+	c.federation, err = newFederation(os.Getenv("FLOW_PEERS"))
+	if err != nil {
+		logger.Fatalf("Error parsing peer collectors: %s", err)
+	}
+	netbox, err := newNetboxExporterFromEnv()
+	if err != nil {
+		logger.Fatalf("Error configuring the NetBox export: %s", err)
+	}
+	if netbox != nil {
+		netbox.fetch = c.listRecords
+		logger.Infof("Exporting the topology to NetBox every %s", netbox.interval)
+		go netbox.run(stopCh)
+	}
+
+	if authMode == types.ConsoleAuthModeOpenID {
+		openIDAuth, err = newOpenIDVerifier(openIDIssuer, openIDClientId, os.Getenv("FLOW_OIDC_USERNAME_CLAIM"))
+		if err != nil {
+			logger.Fatalf("Error configuring openid authentication: %s", err)
+		}
+		logger.Infof("Console authenticated with openid issuer %s", openIDIssuer)
+	}
+
+	if authMode == types.ConsoleAuthModeSAML {
+		samlAuth, err = newSamlServiceProvider(samlIdPMetadata, samlConsoleURL, os.Getenv("FLOW_SAML_USERNAME_ATTRIBUTE"))
+		if err != nil {
+			logger.Fatalf("Error configuring saml authentication: %s", err)
+		}
+		logger.Infof("Console authenticated with saml identity provider %s", samlAuth.idp.entityID)
+	}
+
+	if authMode == types.ConsoleAuthModeInternal {
+		ldapAuth, err = newLdapAuthenticatorFromEnv()
+		if err != nil {
+			logger.Fatalf("Error configuring ldap authentication: %s", err)
+		}
+		if ldapAuth != nil {
+			logger.Infof("Console users authenticated against ldap server %s", ldapAuth.address)
+		}
+		if filename := os.Getenv("FLOW_HTPASSWD"); filename != "" && ldapAuth == nil {
+			htpasswdAuth, err = utils.NewHtpasswd(filename)
+			if err != nil {
+				logger.Fatalf("Error reading the htpasswd file: %s", err)
+			}
+			logger.Infof("Console users authenticated against htpasswd file %s", filename)
+		}
+		if check := passwordCheck(os.Getenv("FLOW_USERS")); check != nil {
+			loginThrottle, err = newLoginLimiterFromEnv(reg)
+			if err != nil {
+				logger.Fatalf("Error configuring console login lockouts: %s", err)
+			}
+			sessionAuth, err = newSessionManagerFromEnv(check)
+			if err != nil {
+				logger.Fatalf("Error configuring console sessions: %s", err)
+			}
+		}
+	}
+
+	clientCertAuth, err = newClientCertAuthenticatorFromEnv()
+	if err != nil {
+		logger.Fatalf("Error configuring client certificate authentication: %s", err)
+	}
+
+	roles, err = newRoleAuthorizerFromEnv(kubeClient, namespace)
+	if err != nil {
+		logger.Fatalf("Error configuring roles: %s", err)
+	}
+	if roles != nil {
+		logger.Infof("Role based access enabled, default role %s", roles.defaultRole)
+	}
+
+	// api tokens are validated by the collector, not by the oauth proxy
+	if authMode != types.ConsoleAuthModeUnsecured && authMode != string(types.ConsoleAuthModeOpenshift) {
+		apiTokenAuth = newApiTokenAuthenticator(kubeClient, namespace, os.Getenv("FLOW_USERS"))
+	}
+	kubeTokenAuth, err = newKubeTokenAuthenticatorFromEnv(kubeClient, namespace, authMode)
+	if err != nil {
+		logger.Fatalf("Error configuring kubernetes token authentication: %s", err)
+	}
 
 	// map the authentication mode with the function to get the user
 	userMap := make(map[string]func(*http.Request) UserResponse)
@@ -316,31 +582,92 @@ func main() {
 	userMap[string(types.ConsoleAuthModeInternal)] = getInternalUser
 	userMap[string(types.ConsoleAuthModeUnsecured)] = getUnsecuredUser
 
+	if openIDAuth != nil {
+		userMap[types.ConsoleAuthModeOpenID] = openIDAuth.getUser
+	}
+	if samlAuth != nil {
+		userMap[types.ConsoleAuthModeSAML] = samlAuth.getUser
+	}
+	userMap[types.ConsoleAuthModeKubernetes] = func(r *http.Request) UserResponse {
+		userResponse, _ := getKubeTokenUser(r)
+		return userResponse
+	}
+
+	getUser := func(r *http.Request) UserResponse {
+		if userResponse, ok := getClientCertUser(r); ok {
+			return userResponse
+		}
+		if userResponse, ok := getApiTokenUser(r); ok {
+			return userResponse
+		}
+		if userResponse, ok := getKubeTokenUser(r); ok {
+			return userResponse
+		}
+		if handler, exists := userMap[authMode]; exists {
+			return handler(r)
+		}
+		return UserResponse{}
+	}
+	auditLog, err = newAuditLoggerFromEnv()
+	if err != nil {
+		logger.Fatalf("Error configuring the audit log: %s", err)
+	}
+	if auditLog != nil {
+		auditLog.user = getUser
+		go auditLog.run(stopCh)
+	}
+	preferences, err = newPreferenceManagerFromEnv(kubeClient, namespace, os.Getenv("FLOW_USERS"))
+	if err != nil {
+		logger.Fatalf("Error configuring user preferences: %s", err)
+	}
+	if preferences != nil {
+		preferences.user = getUser
+	}
+	adminOnly := func(h http.HandlerFunc) http.HandlerFunc {
+		return requireRole(roleAdmin, getUser, h)
+	}
+
 	logoutMap := make(map[string]func(http.ResponseWriter, *http.Request))
 	logoutMap[string(types.ConsoleAuthModeOpenshift)] = openshiftLogout
 	logoutMap[string(types.ConsoleAuthModeInternal)] = func(w http.ResponseWriter, r *http.Request) {
 		internalLogout(w, r, validNonces)
 	}
+	if openIDAuth != nil {
+		logoutMap[types.ConsoleAuthModeOpenID] = openIDAuth.logout
+	}
+	if samlAuth != nil {
+		logoutMap[types.ConsoleAuthModeSAML] = samlAuth.logout
+	}
 
 	var mux = mux.NewRouter().StrictSlash(true)
+	mux.HandleFunc("/readyz", leader.ready)
 
 	var api = mux.PathPrefix("/api").Subrouter()
 	api.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 	})
-	if os.Getenv("USE_CORS") != "" {
-		api.Use(cors)
-	}
-
 	var api1 = api.PathPrefix("/v1alpha1").Subrouter()
 	api1.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 	})
+	api1.Use(newApiMetrics(reg).handler)
+	if auditLog != nil {
+		api1.Use(auditLog.handler)
+	}
+	timeouts, err := parseEndpointTimeouts(os.Getenv("API_TIMEOUTS"))
+	if err != nil {
+		logger.Fatalf("Error parsing api timeouts: %s", err)
+	}
+	api1.Use(timeouts.handler)
+	profiling, err := pprofConfigFromEnv(*isProf)
+	if err != nil {
+		logger.Fatalf("Error configuring profiling: %s", err)
+	}
 	var logUri = os.Getenv("LOG_REQ_URI")
 	if logUri == "true" {
 		api1.Use(func(next http.Handler) http.Handler {
 			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				log.Printf("COLLECTOR: request uri %s \n", r.RequestURI)
+				logger.Infof("request uri %s", r.RequestURI)
 				next.ServeHTTP(w, r)
 			})
 		})
@@ -351,6 +678,17 @@ func main() {
 		w.WriteHeader(http.StatusNotFound)
 	})
 
+	var dropsApi = api1Internal.PathPrefix("/drops").Subrouter()
+	dropsApi.StrictSlash(true)
+	dropsApi.HandleFunc("/", authenticated(adminOnly(c.dropsHandler))).Methods(http.MethodGet).Name("drops")
+	dropsApi.NotFoundHandler = authenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	var diagnosticsApi = api1Internal.PathPrefix("/diagnostics").Subrouter()
+	diagnosticsApi.StrictSlash(true)
+	diagnosticsApi.HandleFunc("/", authenticated(adminOnly(c.diagnosticsHandler))).Methods(http.MethodGet).Name("diagnostics")
+
 	var promApi = api1Internal.PathPrefix("/prom").Subrouter()
 	promApi.StrictSlash(true)
 	promApi.NotFoundHandler = authenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -359,44 +697,99 @@ func main() {
 
 	var promqueryApi = promApi.PathPrefix("/query").Subrouter()
 	promqueryApi.StrictSlash(true)
-	promqueryApi.HandleFunc("/", authenticated(http.HandlerFunc(c.promqueryHandler)))
+	promqueryApi.HandleFunc("/", authenticated(adminOnly(c.promqueryHandler)))
 	promqueryApi.NotFoundHandler = authenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 	}))
 
 	var promqueryrangeApi = promApi.PathPrefix("/rangequery").Subrouter()
 	promqueryrangeApi.StrictSlash(true)
-	promqueryrangeApi.HandleFunc("/", authenticated(http.HandlerFunc(c.promqueryrangeHandler)))
+	promqueryrangeApi.HandleFunc("/", authenticated(adminOnly(c.promqueryrangeHandler)))
 	promqueryrangeApi.NotFoundHandler = authenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 	}))
 
+	var logLevelApi = api1Internal.PathPrefix(logging.LevelPath).Subrouter()
+	logLevelApi.StrictSlash(true)
+	logLevelApi.HandleFunc("/", authenticated(adminOnly(logging.LevelHandler))).Methods(http.MethodGet, http.MethodPut).Name("loglevel")
+
+	if profiling.api {
+		profiles := http.StripPrefix(pprofApiPrefix, pprofHandler(func(h http.HandlerFunc) http.HandlerFunc {
+			return authenticated(adminOnly(h))
+		}))
+		api1Internal.PathPrefix(pprofPath).Handler(profiles).Name("pprof")
+	}
+
+	var sloApi = api1.PathPrefix("/slos").Subrouter()
+	sloApi.StrictSlash(true)
+	sloApi.HandleFunc("/", authenticated(http.HandlerFunc(c.sloHandler))).Methods(http.MethodGet).Name("slos")
+	sloApi.HandleFunc("/{address}", authenticated(http.HandlerFunc(c.sloHandler))).Methods(http.MethodGet).Name("slo")
+	sloApi.HandleFunc("/{address}", authenticated(adminOnly(c.sloHandler))).Methods(http.MethodPut, http.MethodDelete).Name("slo")
+	sloApi.NotFoundHandler = authenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	var eventsApi = api1.PathPrefix("/events").Subrouter()
+	eventsApi.StrictSlash(true)
+	eventsApi.HandleFunc("/", authenticated(http.HandlerFunc(c.siteEventsHandler))).Methods(http.MethodGet).Name("siteevents")
+	eventsApi.NotFoundHandler = authenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
 	var metricsApi = api1.PathPrefix("/metrics").Subrouter()
 	metricsApi.StrictSlash(true)
-	metricsApi.Handle("/", promhttp.HandlerFor(reg, promhttp.HandlerOpts{Registry: reg}))
+	metricsApi.Handle("/", uncompressed(promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{Registry: reg, EnableOpenMetrics: true})))
 
 	var eventsourceApi = api1.PathPrefix("/eventsources").Subrouter()
 	eventsourceApi.StrictSlash(true)
-	eventsourceApi.HandleFunc("/", http.HandlerFunc(c.eventsourceHandler)).Name("list")
-	eventsourceApi.HandleFunc("/{id}", http.HandlerFunc(c.eventsourceHandler)).Name("item")
+	eventsourceApi.HandleFunc("/", authenticated(http.HandlerFunc(c.eventsourceHandler))).Name("list")
+	eventsourceApi.HandleFunc("/{id}", authenticated(http.HandlerFunc(c.eventsourceHandler))).Name("item")
 	eventsourceApi.NotFoundHandler = authenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 	}))
 
+	if samlAuth != nil {
+		var samlApi = api1.PathPrefix("/saml").Subrouter()
+		samlApi.StrictSlash(true)
+		samlApi.HandleFunc("/login", http.HandlerFunc(samlAuth.login)).Methods(http.MethodGet).Name("login")
+		samlApi.HandleFunc("/acs", http.HandlerFunc(samlAuth.acs)).Methods(http.MethodPost).Name("acs")
+		samlApi.HandleFunc("/metadata", http.HandlerFunc(samlAuth.metadata)).Methods(http.MethodGet).Name("metadata")
+	}
+
+	if sessionAuth != nil {
+		api1.HandleFunc("/login", http.HandlerFunc(sessionAuth.login)).Methods(http.MethodPost).Name("console-login")
+		api1.HandleFunc("/session", http.HandlerFunc(sessionAuth.sessionHandler)).Methods(http.MethodGet, http.MethodDelete).Name("session")
+	}
+
+	if apiTokenAuth != nil {
+		var tokenApi = api1.PathPrefix("/tokens").Subrouter()
+		tokenApi.StrictSlash(true)
+		tokenApi.HandleFunc("/", authenticated(adminOnly(apiTokenAuth.tokensHandler))).Methods(http.MethodGet, http.MethodPost).Name("tokens")
+		tokenApi.HandleFunc("/{name}", authenticated(adminOnly(apiTokenAuth.tokensHandler))).Methods(http.MethodDelete).Name("token")
+	}
+
 	var userApi = api1.PathPrefix("/user").Subrouter()
 	userApi.StrictSlash(true)
 	userApi.HandleFunc("/", authenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		handler, exists := userMap[authMode]
+		userResponse, ok := getClientCertUser(r)
+		if !ok {
+			userResponse, ok = getApiTokenUser(r)
+		}
+		if !ok {
+			handler, exists := userMap[authMode]
 
-		if !exists {
-			w.WriteHeader(http.StatusNoContent)
-			return
+			if !exists {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			userResponse = handler(r)
 		}
+		userResponse.Role = roles.role(r, userResponse)
 
-		response, err := json.Marshal(handler(r))
+		response, err := json.Marshal(userResponse)
 
 		if err != nil {
-			log.Printf("Error /user response: %s", err.Error())
+			logger.Errorf("Error /user response: %s", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
@@ -405,6 +798,11 @@ func main() {
 		w.Write(response)
 	})))
 
+	if preferences != nil {
+		userApi.HandleFunc("/preferences/", authenticated(preferences.preferencesHandler)).Methods(http.MethodGet, http.MethodDelete).Name("preferences")
+		userApi.HandleFunc("/preferences/{name}", authenticated(preferences.preferencesHandler)).Methods(http.MethodGet, http.MethodPut, http.MethodDelete).Name("preference")
+	}
+
 	var userLogout = api1.PathPrefix("/logout").Subrouter()
 	userLogout.StrictSlash(true)
 	userLogout.HandleFunc("/", authenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -483,6 +881,7 @@ func main() {
 	addressApi.HandleFunc("/{id}/flowpairs", authenticated(http.HandlerFunc(c.addressHandler))).Name("flowpairs")
 	addressApi.HandleFunc("/{id}/listeners", authenticated(http.HandlerFunc(c.addressHandler))).Name("listeners")
 	addressApi.HandleFunc("/{id}/connectors", authenticated(http.HandlerFunc(c.addressHandler))).Name("connectors")
+	addressApi.HandleFunc("/{id}/forecast", authenticated(http.HandlerFunc(c.addressHandler))).Name("forecast")
 	addressApi.NotFoundHandler = authenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 	}))
@@ -532,6 +931,14 @@ func main() {
 		w.WriteHeader(http.StatusNotFound)
 	}))
 
+	var sitelatencyApi = api1.PathPrefix("/sitelatencies").Subrouter()
+	sitelatencyApi.StrictSlash(true)
+	sitelatencyApi.HandleFunc("/", authenticated(http.HandlerFunc(c.siteLatencyHandler))).Name("list")
+	sitelatencyApi.HandleFunc("/{id}", authenticated(http.HandlerFunc(c.siteLatencyHandler))).Name("item")
+	sitelatencyApi.NotFoundHandler = authenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
 	var processgrouppairApi = api1.PathPrefix("/processgrouppairs").Subrouter()
 	processgrouppairApi.StrictSlash(true)
 	processgrouppairApi.HandleFunc("/", authenticated(http.HandlerFunc(c.processGroupPairHandler))).Name("list")
@@ -548,10 +955,45 @@ func main() {
 		w.WriteHeader(http.StatusNotFound)
 	}))
 
+	var ingestApi = api1.PathPrefix("/ingest").Subrouter()
+	ingestApi.StrictSlash(true)
+	ingestApi.HandleFunc("/", authenticated(adminOnly(c.ingestHandler))).Methods(http.MethodPost).Name("ingest")
+	ingestApi.NotFoundHandler = authenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	var peerApi = api1.PathPrefix("/peers").Subrouter()
+	peerApi.StrictSlash(true)
+	peerApi.HandleFunc("/", authenticated(http.HandlerFunc(c.peersHandler))).Methods(http.MethodGet)
+	peerApi.HandleFunc("/", authenticated(adminOnly(c.peersHandler))).Methods(http.MethodPost)
+	peerApi.HandleFunc("/{name}", authenticated(adminOnly(c.peersHandler))).Methods(http.MethodDelete)
+	peerApi.NotFoundHandler = authenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	posture := newPostureReporter(c.FlowCollector.Collector.Identity, authMode, "/etc/service-controller/console/", conn, kubeClient, namespace, c.federation)
+	var postureApi = api1.PathPrefix("/posture").Subrouter()
+	postureApi.StrictSlash(true)
+	postureApi.HandleFunc("/", authenticated(adminOnly(posture.postureHandler))).Methods(http.MethodGet).Name("posture")
+	postureApi.NotFoundHandler = authenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	var federatedApi = api1.PathPrefix("/federated").Subrouter()
+	federatedApi.StrictSlash(true)
+	federatedApi.HandleFunc("/{collection}", authenticated(http.HandlerFunc(c.federatedHandler))).Methods(http.MethodGet).Name("list")
+	federatedApi.NotFoundHandler = authenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
 	if enableConsole {
-		mux.PathPrefix("/").Handler(http.FileServer(http.Dir("/app/console/")))
+		console := newConsoleHandler("/app/console/", os.Getenv("CONSOLE_BASE_PATH"))
+		if console.basePath != "" {
+			mux.Handle(console.basePath, console)
+		}
+		mux.PathPrefix(console.basePath + "/").Handler(console)
 	} else {
-		log.Println("COLLECTOR: Skupper console is disabled")
+		logger.Infof("Skupper console is disabled")
 	}
 
 	var collectorApi = api1.PathPrefix("/collectors").Subrouter()
@@ -573,16 +1015,67 @@ func main() {
 	if os.Getenv("FLOW_HOST") != "" {
 		addr = os.Getenv("FLOW_HOST") + addr
 	}
-	log.Printf("COLLECTOR: server listening on %s", addr)
+	compression, err := parseCompressionLevel(os.Getenv("COMPRESSION_LEVEL"))
+	if err != nil {
+		logger.Fatalf("Error parsing compression level: %s", err)
+	}
+	handler := compressHandler(compression, mux)
+	corsPolicy, err := newCorsPolicyFromEnv()
+	if err != nil {
+		logger.Fatalf("Error configuring cors: %s", err)
+	}
+	if corsPolicy != nil {
+		handler = corsPolicy.handler(handler)
+	}
+	logger.Infof("server listening on %s", addr)
 	s := &http.Server{
 		Addr:    addr,
-		Handler: handlers.CompressHandler(mux),
+		Handler: handler,
+	}
+	_, tlsErr := os.Stat("/etc/service-controller/console/tls.crt")
+	if tlsErr == nil {
+		s.TLSConfig, err = utils.TlsServerConfig(os.Getenv("FLOW_TLS_MIN_VERSION"), os.Getenv("FLOW_TLS_CIPHER_SUITES"), os.Getenv("FLOW_TLS_CURVE_PREFERENCES"))
+		if err != nil {
+			logger.Fatalf("Error configuring tls: %s", err)
+		}
+		certs, err := newCertReloader("/etc/service-controller/console/tls.crt", "/etc/service-controller/console/tls.key")
+		if err != nil {
+			logger.Fatalf("Error loading the console certificate: %s", err)
+		}
+		if err := certs.watch(stopCh); err != nil {
+			logger.Warnf("Unable to watch the console certificate, it will not be reloaded: %s", err)
+		}
+		s.TLSConfig.GetCertificate = certs.GetCertificate
+		posture.consoleTls = s.TLSConfig
+	}
+	acmeCerts, err := newAcmeProviderFromEnv(kubeClient, namespace)
+	if err != nil {
+		logger.Fatalf("Error configuring ACME: %s", err)
+	}
+	if acmeCerts != nil {
+		if tlsErr != nil {
+			logger.Fatalf("ACME certificates require the console to be served over tls")
+		}
+		acmeCerts.configure(s.TLSConfig, s.TLSConfig.GetCertificate)
+		logger.Infof("Obtaining the console certificate of %s through ACME", os.Getenv("FLOW_ACME_DOMAINS"))
+		go func() {
+			if err := acmeCerts.serveHTTP(); err != nil {
+				logger.Warnf("Unable to answer the ACME http-01 challenges: %s", err)
+			}
+		}()
+	}
+	if clientCertAuth != nil {
+		if tlsErr != nil {
+			logger.Fatalf("Client certificate authentication requires the console to be served over tls")
+		}
+		clientCertAuth.configure(s.TLSConfig)
+		logger.Infof("Client certificates verified, required: %t", clientCertAuth.required)
 	}
 
 	go func() {
-		_, err := os.Stat("/etc/service-controller/console/tls.crt")
-		if err == nil {
-			err := s.ListenAndServeTLS("/etc/service-controller/console/tls.crt", "/etc/service-controller/console/tls.key")
+		if tlsErr == nil {
+			// the certificates are served by the reloader and ACME
+			err := s.ListenAndServeTLS("", "")
 			if err != nil {
 				fmt.Println(err)
 			}
@@ -593,17 +1086,47 @@ func main() {
 			}
 		}
 	}()
-	if *isProf {
-		// serve only over localhost loopback
+	// the log level is also served over the loopback, for the skupper cli to
+	// change it from within the pod
+	go func() {
+		adminAddr := "localhost:8011"
+		if os.Getenv("FLOW_ADMIN_PORT") != "" {
+			adminAddr = "localhost:" + os.Getenv("FLOW_ADMIN_PORT")
+		}
+		adminMux := http.NewServeMux()
+		adminMux.HandleFunc(logging.LevelPath, logging.LevelHandler)
+		if err := http.ListenAndServe(adminAddr, adminMux); err != nil {
+			logger.Errorf("Failed to serve the log level on %s: %s", adminAddr, err)
+		}
+	}()
+	if profiling.addr != "" {
+		// the profiles are behind the same authentication and tls as the api
+		profileServer := &http.Server{
+			Addr: profiling.addr,
+			Handler: pprofHandler(func(h http.HandlerFunc) http.HandlerFunc {
+				return authenticated(adminOnly(h))
+			}),
+			TLSConfig: s.TLSConfig,
+		}
 		go func() {
-			if err := http.ListenAndServe("localhost:9970", nil); err != nil {
-				log.Fatalf("failure running default http server for net/http/pprof: %s", err)
+			var err error
+			if tlsErr == nil {
+				err = profileServer.ListenAndServeTLS("", "")
+			} else {
+				err = profileServer.ListenAndServe()
+			}
+			if err != nil {
+				logger.Fatalf("failure running the http server for net/http/pprof on %s: %s", profiling.addr, err)
 			}
 		}()
+		logger.Infof("Serving the runtime profiles on %s", profiling.addr)
 	}
 
+	if leaderElection {
+		go runLeaderElection(leader, kubeClient, namespace, stopCh)
+	}
 	if err = c.Run(stopCh); err != nil {
-		log.Fatal("Error running Flow collector: ", err.Error())
+		logger.Fatalf("Error running Flow collector: %s", err)
 	}
 
 }
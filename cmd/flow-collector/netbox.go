@@ -0,0 +1,449 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/skupperproject/skupper/pkg/flow"
+)
+
+const (
+	defaultNetboxInterval = 5 * time.Minute
+	netboxRequestTimeout  = 30 * time.Second
+)
+
+// netboxCollections are the collector records synced, in the order they are
+// synced so that the objects they reference already exist
+var netboxCollections = []struct {
+	name       string
+	path       string
+	recordType int
+}{
+	{"sites", "sites", flow.Site},
+	{"routers", "routers", flow.Router},
+	{"links", "links", flow.Link},
+	{"services", "listeners", flow.Listener},
+}
+
+// netboxMapping maps the records of a collection to objects of a NetBox
+// api endpoint. Lookup holds the query used to find the existing object and
+// Fields its fields, both as templates where ${field} is replaced by the
+// field of the record and ${slug:field} by its slug; dotted field names
+// reference related objects, such as site.slug
+type netboxMapping struct {
+	Endpoint string            `json:"endpoint"`
+	Lookup   map[string]string `json:"lookup,omitempty"`
+	Fields   map[string]string `json:"fields,omitempty"`
+}
+
+func defaultNetboxMappings() map[string]netboxMapping {
+	return map[string]netboxMapping{
+		"sites": {
+			Endpoint: "dcim/sites/",
+			Lookup:   map[string]string{"slug": "${slug:name}"},
+			Fields: map[string]string{
+				"name":        "${name}",
+				"slug":        "${slug:name}",
+				"status":      "active",
+				"description": "Skupper site ${identity}",
+			},
+		},
+		"routers": {
+			Endpoint: "dcim/devices/",
+			Lookup:   map[string]string{"name": "${name}"},
+			Fields: map[string]string{
+				"name":             "${name}",
+				"site.slug":        "${slug:siteName}",
+				"role.slug":        "skupper-router",
+				"device_type.slug": "skupper-router",
+				"status":           "active",
+				"description":      "Skupper router ${identity}",
+			},
+		},
+		"links": {
+			Endpoint: "circuits/circuits/",
+			Lookup:   map[string]string{"cid": "${routerName}/${name}"},
+			Fields: map[string]string{
+				"cid":           "${routerName}/${name}",
+				"provider.slug": "skupper",
+				"type.slug":     "skupper-link",
+				"status":        "active",
+				"description":   "Skupper link from ${routerName} to ${name}",
+			},
+		},
+		"services": {
+			Endpoint: "ipam/services/",
+			Lookup:   map[string]string{"name": "${address}", "device": "${routerName}"},
+			Fields: map[string]string{
+				"name":        "${address}",
+				"device.name": "${routerName}",
+				"protocol":    "tcp",
+				"ports":       "[${destPort}]",
+				"description": "Skupper listener ${name}",
+			},
+		},
+	}
+}
+
+// netboxObject is an object to create or update in NetBox
+type netboxObject struct {
+	lookup url.Values
+	fields map[string]interface{}
+}
+
+type netboxExporter struct {
+	url      string
+	token    string
+	file     string
+	interval time.Duration
+	mappings map[string]netboxMapping
+	fetch    func(ctx context.Context, collection string) ([]map[string]interface{}, error)
+	client   *http.Client
+}
+
+// newNetboxExporterFromEnv syncs the topology to the NetBox api at
+// FLOW_NETBOX_URL, with the token in FLOW_NETBOX_TOKEN_FILE, and/or writes
+// it as NetBox objects to FLOW_NETBOX_FILE, every FLOW_NETBOX_INTERVAL;
+// FLOW_NETBOX_MAPPING names a json file overriding the mapping of the
+// collections, it returns nil when neither the url nor the file is set
+func newNetboxExporterFromEnv() (*netboxExporter, error) {
+	e := &netboxExporter{
+		url:      strings.TrimSuffix(os.Getenv("FLOW_NETBOX_URL"), "/"),
+		file:     os.Getenv("FLOW_NETBOX_FILE"),
+		interval: defaultNetboxInterval,
+		mappings: defaultNetboxMappings(),
+		client:   &http.Client{Timeout: netboxRequestTimeout},
+	}
+	if e.url == "" && e.file == "" {
+		return nil, nil
+	}
+	if e.url != "" {
+		u, err := url.Parse(e.url)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid FLOW_NETBOX_URL %q", e.url)
+		}
+		tokenFile := os.Getenv("FLOW_NETBOX_TOKEN_FILE")
+		if tokenFile == "" {
+			return nil, fmt.Errorf("FLOW_NETBOX_TOKEN_FILE is required to sync with NetBox")
+		}
+		token, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return nil, err
+		}
+		e.token = strings.TrimSpace(string(token))
+	}
+	if value := os.Getenv("FLOW_NETBOX_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid FLOW_NETBOX_INTERVAL %q", value)
+		}
+		e.interval = interval
+	}
+	if filename := os.Getenv("FLOW_NETBOX_MAPPING"); filename != "" {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		overrides := map[string]netboxMapping{}
+		if err := json.Unmarshal(data, &overrides); err != nil {
+			return nil, fmt.Errorf("invalid NetBox mapping %s: %w", filename, err)
+		}
+		for name, mapping := range overrides {
+			if _, ok := e.mappings[name]; !ok {
+				return nil, fmt.Errorf("invalid NetBox mapping %s: unknown collection %q", filename, name)
+			}
+			// a mapping without an endpoint is not synced
+			e.mappings[name] = mapping
+		}
+	}
+	return e, nil
+}
+
+var slugInvalid = regexp.MustCompile(`[^a-z0-9_]+`)
+
+func netboxSlug(value string) string {
+	return strings.Trim(slugInvalid.ReplaceAllString(strings.ToLower(value), "-"), "-")
+}
+
+func expandNetboxTemplate(template string, record map[string]string) string {
+	return os.Expand(template, func(key string) string {
+		if field, ok := strings.CutPrefix(key, "slug:"); ok {
+			return netboxSlug(record[field])
+		}
+		return record[key]
+	})
+}
+
+// netboxValue keeps the values rendered as json arrays or objects, such as
+// the ports of a service, as json
+func netboxValue(value string) interface{} {
+	if strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{") {
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(value), &decoded); err == nil {
+			return decoded
+		}
+	}
+	return value
+}
+
+// objects maps the records to NetBox objects, the fields that render empty
+// are left out and the records with an empty lookup skipped
+func (m netboxMapping) objects(records []map[string]string) []netboxObject {
+	objects := []netboxObject{}
+	for _, record := range records {
+		object := netboxObject{lookup: url.Values{}, fields: map[string]interface{}{}}
+		for param, template := range m.Lookup {
+			if value := expandNetboxTemplate(template, record); value != "" {
+				object.lookup.Set(param, value)
+			}
+		}
+		if len(object.lookup) != len(m.Lookup) {
+			continue
+		}
+		for name, template := range m.Fields {
+			value := expandNetboxTemplate(template, record)
+			if value == "" || value == "[]" {
+				continue
+			}
+			fields := object.fields
+			path := strings.Split(name, ".")
+			for _, parent := range path[:len(path)-1] {
+				nested, ok := fields[parent].(map[string]interface{})
+				if !ok {
+					nested = map[string]interface{}{}
+					fields[parent] = nested
+				}
+				fields = nested
+			}
+			fields[path[len(path)-1]] = netboxValue(value)
+		}
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+// topology reads the active records of the collections, flattened to
+// strings, adding the names of the site and router they belong to
+func (e *netboxExporter) topology(ctx context.Context) (map[string][]map[string]string, error) {
+	topology := map[string][]map[string]string{}
+	byIdentity := map[string]map[string]string{}
+	for _, collection := range netboxCollections {
+		records, err := e.fetch(ctx, collection.path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", collection.path, err)
+		}
+		for _, record := range records {
+			flat := map[string]string{}
+			for key, value := range record {
+				if value != nil {
+					flat[key] = fmt.Sprint(value)
+				}
+			}
+			if flat["endTime"] != "" && flat["endTime"] != "0" {
+				continue
+			}
+			if parent, ok := byIdentity[flat["parent"]]; ok {
+				switch parent["recType"] {
+				case "SITE":
+					flat["siteName"] = parent["name"]
+				case "ROUTER":
+					flat["routerName"] = parent["name"]
+					flat["siteName"] = parent["siteName"]
+				}
+			}
+			byIdentity[flat["identity"]] = flat
+			topology[collection.name] = append(topology[collection.name], flat)
+		}
+	}
+	return topology, nil
+}
+
+func (e *netboxExporter) sync(ctx context.Context) error {
+	topology, err := e.topology(ctx)
+	if err != nil {
+		return err
+	}
+	document := map[string][]map[string]interface{}{}
+	failures := 0
+	for _, collection := range netboxCollections {
+		mapping := e.mappings[collection.name]
+		if mapping.Endpoint == "" {
+			continue
+		}
+		for _, object := range mapping.objects(topology[collection.name]) {
+			document[mapping.Endpoint] = append(document[mapping.Endpoint], object.fields)
+			if e.url == "" {
+				continue
+			}
+			if err := e.upsert(ctx, mapping.Endpoint, object); err != nil {
+				logger.Errorf("Failed to sync %s %s with NetBox: %s", collection.name, object.lookup.Encode(), err)
+				failures++
+			}
+		}
+	}
+	if e.file != "" {
+		if err := writeNetboxFile(e.file, document); err != nil {
+			return err
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d objects failed to sync", failures)
+	}
+	return nil
+}
+
+func writeNetboxFile(filename string, document map[string][]map[string]interface{}) error {
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(filename), ".netbox-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+func (e *netboxExporter) request(ctx context.Context, method string, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, e.url+"/api/"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Token "+e.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%s %s: %s %s", method, path, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return resp, nil
+}
+
+// upsert updates the object found by its lookup, or creates it
+func (e *netboxExporter) upsert(ctx context.Context, endpoint string, object netboxObject) error {
+	resp, err := e.request(ctx, http.MethodGet, endpoint+"?"+object.lookup.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	found := struct {
+		Count   int `json:"count"`
+		Results []struct {
+			Id int `json:"id"`
+		} `json:"results"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&found)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	if len(found.Results) == 0 {
+		resp, err = e.request(ctx, http.MethodPost, endpoint, object.fields)
+	} else {
+		resp, err = e.request(ctx, http.MethodPatch, fmt.Sprintf("%s%d/", endpoint, found.Results[0].Id), object.fields)
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// run syncs the topology on start and then at every interval, until stopped
+func (e *netboxExporter) run(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), e.interval)
+		if err := e.sync(ctx); err != nil {
+			logger.Errorf("NetBox export failed: %s", err)
+		}
+		cancel()
+		select {
+		case <-ticker.C:
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (d *discardResponseWriter) Header() http.Header         { return d.header }
+func (d *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (d *discardResponseWriter) WriteHeader(int)             {}
+
+// listRecords reads all the records of a collection from the collector, as
+// the list route of the api would
+func (c *Controller) listRecords(ctx context.Context, collection string) ([]map[string]interface{}, error) {
+	recordType := -1
+	for _, candidate := range netboxCollections {
+		if candidate.path == collection {
+			recordType = candidate.recordType
+		}
+	}
+	if recordType < 0 {
+		return nil, fmt.Errorf("unknown collection %s", collection)
+	}
+	var response flow.ApiResponse
+	var err error
+	router := mux.NewRouter()
+	router.HandleFunc("/"+collection+"/", func(w http.ResponseWriter, r *http.Request) {
+		response, err = c.apiRequest(ctx, flow.ApiRequest{RecordType: recordType, Request: r})
+	}).Name("list")
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, "/"+collection+"/", nil)
+	if err != nil {
+		return nil, err
+	}
+	router.ServeHTTP(&discardResponseWriter{header: http.Header{}}, r)
+	if err != nil {
+		return nil, err
+	}
+	if response.Status != http.StatusOK || response.Body == nil {
+		return nil, fmt.Errorf("collector returned status %d", response.Status)
+	}
+	payload := struct {
+		Results []map[string]interface{} `json:"results"`
+	}{}
+	decoder := json.NewDecoder(strings.NewReader(*response.Body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, err
+	}
+	return payload.Results, nil
+}
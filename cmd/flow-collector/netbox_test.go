@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+var netboxTestRecords = map[string][]map[string]interface{}{
+	"sites": {
+		{"recType": "SITE", "identity": "site-1", "name": "West Site", "endTime": json.Number("0")},
+		{"recType": "SITE", "identity": "site-2", "name": "gone", "endTime": json.Number("1700000000")},
+	},
+	"routers": {
+		{"recType": "ROUTER", "identity": "router-1", "parent": "site-1", "name": "0/west-abc", "endTime": json.Number("0")},
+	},
+	"links": {
+		{"recType": "LINK", "identity": "link-1", "parent": "router-1", "name": "east", "endTime": json.Number("0")},
+	},
+	"listeners": {
+		{"recType": "LISTENER", "identity": "listener-1", "parent": "router-1", "name": "backend:8080", "address": "backend", "destPort": "8080", "endTime": json.Number("0")},
+		{"recType": "LISTENER", "identity": "listener-2", "parent": "router-1", "name": "no-address", "endTime": json.Number("0")},
+	},
+}
+
+func fetchNetboxTestRecords(ctx context.Context, collection string) ([]map[string]interface{}, error) {
+	return netboxTestRecords[collection], nil
+}
+
+// fakeNetbox keeps the objects posted by endpoint, finding them by name
+type fakeNetbox struct {
+	lock    sync.Mutex
+	objects map[string][]map[string]interface{}
+	patches int
+}
+
+func (f *fakeNetbox) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if r.Header.Get("Authorization") != "Token secret" {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/api/")
+	switch r.Method {
+	case http.MethodGet:
+		results := []map[string]interface{}{}
+		for i, object := range f.objects[path] {
+			match := true
+			for param := range r.URL.Query() {
+				value := object[param]
+				if nested, ok := value.(map[string]interface{}); ok {
+					value = nested["name"]
+				}
+				if value != r.URL.Query().Get(param) {
+					match = false
+				}
+			}
+			if match {
+				results = append(results, map[string]interface{}{"id": i + 1})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"count": len(results), "results": results})
+	case http.MethodPost:
+		object := map[string]interface{}{}
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &object)
+		f.objects[path] = append(f.objects[path], object)
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodPatch:
+		f.patches++
+	}
+}
+
+func TestNetboxExporter(t *testing.T) {
+	netbox := &fakeNetbox{objects: map[string][]map[string]interface{}{}}
+	server := httptest.NewServer(netbox)
+	defer server.Close()
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	assert.Assert(t, os.WriteFile(tokenFile, []byte("secret\n"), 0600))
+	mappingFile := filepath.Join(dir, "mapping.json")
+	assert.Assert(t, os.WriteFile(mappingFile, []byte(`{"links": {"endpoint": ""}}`), 0600))
+	t.Setenv("FLOW_NETBOX_URL", server.URL+"/")
+	t.Setenv("FLOW_NETBOX_TOKEN_FILE", tokenFile)
+	t.Setenv("FLOW_NETBOX_FILE", filepath.Join(dir, "netbox.json"))
+	t.Setenv("FLOW_NETBOX_MAPPING", mappingFile)
+	t.Setenv("FLOW_NETBOX_INTERVAL", "")
+
+	e, err := newNetboxExporterFromEnv()
+	assert.Assert(t, err)
+	e.fetch = fetchNetboxTestRecords
+	assert.Assert(t, e.sync(context.Background()))
+
+	assert.DeepEqual(t, netbox.objects, map[string][]map[string]interface{}{
+		"dcim/sites/": {{
+			"name":        "West Site",
+			"slug":        "west-site",
+			"status":      "active",
+			"description": "Skupper site site-1",
+		}},
+		"dcim/devices/": {{
+			"name":        "0/west-abc",
+			"site":        map[string]interface{}{"slug": "west-site"},
+			"role":        map[string]interface{}{"slug": "skupper-router"},
+			"device_type": map[string]interface{}{"slug": "skupper-router"},
+			"status":      "active",
+			"description": "Skupper router router-1",
+		}},
+		"ipam/services/": {{
+			"name":        "backend",
+			"device":      map[string]interface{}{"name": "0/west-abc"},
+			"protocol":    "tcp",
+			"ports":       []interface{}{float64(8080)},
+			"description": "Skupper listener backend:8080",
+		}},
+	})
+
+	// the objects found are updated rather than created again
+	assert.Assert(t, e.sync(context.Background()))
+	assert.Equal(t, netbox.patches, 3)
+	assert.Equal(t, len(netbox.objects["dcim/sites/"]), 1)
+
+	data, err := os.ReadFile(filepath.Join(dir, "netbox.json"))
+	assert.Assert(t, err)
+	document := map[string][]map[string]interface{}{}
+	assert.Assert(t, json.Unmarshal(data, &document))
+	assert.DeepEqual(t, document, netbox.objects)
+}
+
+func TestNetboxExporterFromEnv(t *testing.T) {
+	testTable := []struct {
+		doc      string
+		url      string
+		file     string
+		token    bool
+		interval string
+		mapping  string
+		err      string
+		disabled bool
+	}{
+		{doc: "disabled", disabled: true},
+		{doc: "file only", file: "netbox.json"},
+		{doc: "url", url: "https://netbox.example.com", token: true, interval: "1h"},
+		{doc: "bad url", url: "netbox.example.com", token: true, err: "invalid FLOW_NETBOX_URL"},
+		{doc: "no token", url: "https://netbox.example.com", err: "FLOW_NETBOX_TOKEN_FILE is required"},
+		{doc: "bad interval", file: "netbox.json", interval: "hourly", err: "invalid FLOW_NETBOX_INTERVAL"},
+		{doc: "unknown collection", file: "netbox.json", mapping: `{"flows": {"endpoint": "dcim/cables/"}}`, err: "unknown collection"},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("FLOW_NETBOX_URL", test.url)
+			t.Setenv("FLOW_NETBOX_FILE", test.file)
+			t.Setenv("FLOW_NETBOX_INTERVAL", test.interval)
+			t.Setenv("FLOW_NETBOX_TOKEN_FILE", "")
+			t.Setenv("FLOW_NETBOX_MAPPING", "")
+			if test.token {
+				tokenFile := filepath.Join(dir, "token")
+				assert.Assert(t, os.WriteFile(tokenFile, []byte("secret"), 0600))
+				t.Setenv("FLOW_NETBOX_TOKEN_FILE", tokenFile)
+			}
+			if test.mapping != "" {
+				mappingFile := filepath.Join(dir, "mapping.json")
+				assert.Assert(t, os.WriteFile(mappingFile, []byte(test.mapping), 0600))
+				t.Setenv("FLOW_NETBOX_MAPPING", mappingFile)
+			}
+			e, err := newNetboxExporterFromEnv()
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			assert.Equal(t, e == nil, test.disabled)
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	jose "github.com/go-jose/go-jose/v3"
+	"github.com/skupperproject/skupper/api/types"
+)
+
+const (
+	openIDKeyRefreshDelay = 30 * time.Second
+	// openIDMinRSAKeyBits is the size under which the RSA signing keys of
+	// the provider are ignored
+	openIDMinRSAKeyBits = 2048
+)
+
+// openIDSigningAlgs are the algorithms the tokens may be signed with, the
+// symmetric ones being left out as the signing keys are public
+var openIDSigningAlgs = []string{
+	oidc.RS256, oidc.RS384, oidc.RS512,
+	oidc.PS256, oidc.PS384, oidc.PS512,
+	oidc.ES256, oidc.ES384, oidc.ES512,
+	oidc.EdDSA,
+}
+
+// openIDAuth is set when the console is secured with an OpenID Connect
+// provider, authenticated then requires a bearer token issued by it
+var openIDAuth *openIDVerifier
+
+type openIDClaimsKey struct{}
+
+// openIDVerifier validates the tokens issued by an OpenID Connect provider
+// for the console client. The provider metadata is discovered on first use.
+type openIDVerifier struct {
+	issuer        string
+	clientId      string
+	usernameClaim string
+	client        *http.Client
+
+	lock       sync.Mutex
+	verifier   *oidc.IDTokenVerifier
+	endSession string
+}
+
+func newOpenIDVerifier(issuer string, clientId string, usernameClaim string) (*openIDVerifier, error) {
+	u, err := url.Parse(issuer)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("invalid issuer url %q", issuer)
+	}
+	if clientId == "" {
+		return nil, fmt.Errorf("a client id is required, the tokens issued for other clients of %s would be accepted otherwise", issuer)
+	}
+	if usernameClaim == "" {
+		usernameClaim = "preferred_username"
+	}
+	return &openIDVerifier{
+		issuer:        issuer,
+		clientId:      clientId,
+		usernameClaim: usernameClaim,
+		client:        &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// discover returns the verifier of the tokens, fetching the provider
+// metadata the first time
+func (v *openIDVerifier) discover(ctx context.Context) (*oidc.IDTokenVerifier, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if v.verifier != nil {
+		return v.verifier, nil
+	}
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, v.client), v.issuer)
+	if err != nil {
+		return nil, fmt.Errorf("openid discovery failed: %w", err)
+	}
+	metadata := struct {
+		JwksUri            string `json:"jwks_uri"`
+		EndSessionEndpoint string `json:"end_session_endpoint"`
+	}{}
+	if err = provider.Claims(&metadata); err != nil {
+		return nil, fmt.Errorf("openid discovery failed: %w", err)
+	}
+	if metadata.JwksUri == "" {
+		return nil, fmt.Errorf("openid discovery returned no jwks_uri")
+	}
+	keys := &openIDKeySet{jwksUri: metadata.JwksUri, client: v.client}
+	v.verifier = oidc.NewVerifier(v.issuer, keys, &oidc.Config{
+		ClientID:             v.clientId,
+		SupportedSigningAlgs: openIDSigningAlgs,
+	})
+	v.endSession = metadata.EndSessionEndpoint
+	return v.verifier, nil
+}
+
+// openIDKeySet holds the signing keys of the provider, refreshed whenever a
+// token is signed with an unknown key. The RSA keys too small to be trusted
+// are ignored.
+type openIDKeySet struct {
+	jwksUri string
+	client  *http.Client
+
+	lock      sync.RWMutex
+	keys      []jose.JSONWebKey
+	refreshed time.Time
+}
+
+func (k *openIDKeySet) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.jwksUri, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve signing keys: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to retrieve signing keys: %s returned %s", k.jwksUri, resp.Status)
+	}
+	set := jose.JSONWebKeySet{}
+	if err = json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("unable to decode signing keys: %w", err)
+	}
+	var keys []jose.JSONWebKey
+	for _, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if err := checkSigningKey(key); err != nil {
+			logger.Warnf("Ignoring openid signing key %q: %s", key.KeyID, err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	k.lock.Lock()
+	k.keys = keys
+	k.refreshed = time.Now()
+	k.lock.Unlock()
+	return nil
+}
+
+// checkSigningKey rejects the keys that cannot verify a token signature or
+// are too small to be trusted
+func checkSigningKey(key jose.JSONWebKey) error {
+	if !key.Valid() {
+		return fmt.Errorf("invalid key")
+	}
+	switch pub := key.Key.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < openIDMinRSAKeyBits {
+			return fmt.Errorf("RSA key of %d bits, at least %d are required", pub.N.BitLen(), openIDMinRSAKeyBits)
+		}
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+	default:
+		return fmt.Errorf("unsupported key type %T", key.Key)
+	}
+	return nil
+}
+
+func (k *openIDKeySet) matching(kid string) []jose.JSONWebKey {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	var keys []jose.JSONWebKey
+	for _, key := range k.keys {
+		if kid == "" || key.KeyID == kid {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// VerifySignature verifies the signature of the token with the signing key
+// it names, returning its payload
+func (k *openIDKeySet) VerifySignature(ctx context.Context, token string) ([]byte, error) {
+	jws, err := jose.ParseSigned(token)
+	if err != nil {
+		return nil, fmt.Errorf("malformed token: %w", err)
+	}
+	if len(jws.Signatures) != 1 {
+		return nil, fmt.Errorf("malformed token: %d signatures", len(jws.Signatures))
+	}
+	kid := jws.Signatures[0].Header.KeyID
+	keys := k.matching(kid)
+	k.lock.RLock()
+	recent := time.Since(k.refreshed) < openIDKeyRefreshDelay
+	k.lock.RUnlock()
+	if len(keys) == 0 && !recent {
+		if err := k.refresh(ctx); err != nil {
+			return nil, err
+		}
+		keys = k.matching(kid)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	for _, key := range keys {
+		if payload, err := jws.Verify(key.Key); err == nil {
+			return payload, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid signature")
+}
+
+// verify checks the signature and the standard claims of the token,
+// returning its claims. The token must be issued for the console client,
+// which must also be the authorized party of the tokens issued for several
+// audiences.
+func (v *openIDVerifier) verify(ctx context.Context, token string) (map[string]interface{}, error) {
+	verifier, err := v.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	idToken, err := verifier.Verify(oidc.ClientContext(ctx, v.client), token)
+	if err != nil {
+		return nil, err
+	}
+	claims := map[string]interface{}{}
+	if err = idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("malformed token claims")
+	}
+	azp, hasAzp := claims["azp"].(string)
+	if hasAzp && azp != v.clientId {
+		return nil, fmt.Errorf("token authorized for %q, expected %q", azp, v.clientId)
+	}
+	if !hasAzp && len(idToken.Audience) > 1 {
+		return nil, fmt.Errorf("token issued for several audiences without an authorized party")
+	}
+	return claims, nil
+}
+
+func bearerToken(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+// authenticate verifies the bearer token of the request, returning the
+// request with the token claims in its context
+func (v *openIDVerifier) authenticate(r *http.Request) (*http.Request, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return nil, fmt.Errorf("no bearer token")
+	}
+	claims, err := v.verify(r.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+	return r.WithContext(context.WithValue(r.Context(), openIDClaimsKey{}, claims)), nil
+}
+
+func (v *openIDVerifier) getUser(r *http.Request) UserResponse {
+	userResponse := UserResponse{
+		Username: "",
+		AuthMode: string(types.ConsoleAuthModeOpenID),
+	}
+	if claims, ok := r.Context().Value(openIDClaimsKey{}).(map[string]interface{}); ok {
+		if username, ok := claims[v.usernameClaim].(string); ok {
+			userResponse.Username = username
+		} else if subject, ok := claims["sub"].(string); ok {
+			userResponse.Username = subject
+		}
+	}
+	return userResponse
+}
+
+// logout sends the browser to the end session endpoint of the provider when
+// it has one, the tokens are held by the console so there is nothing to
+// clear here otherwise
+func (v *openIDVerifier) logout(w http.ResponseWriter, r *http.Request) {
+	if _, err := v.discover(r.Context()); err != nil || v.endSession == "" {
+		fmt.Fprintf(w, "%s", "Logged out")
+		return
+	}
+	endSession, err := url.Parse(v.endSession)
+	if err != nil {
+		fmt.Fprintf(w, "%s", "Logged out")
+		return
+	}
+	query := endSession.Query()
+	query.Set("client_id", v.clientId)
+	if redirect := r.URL.Query().Get("redirect_uri"); redirect != "" {
+		query.Set("post_logout_redirect_uri", redirect)
+	}
+	endSession.RawQuery = query.Encode()
+	http.Redirect(w, r, endSession.String(), http.StatusFound)
+}
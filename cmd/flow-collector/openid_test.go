@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jose "github.com/go-jose/go-jose/v3"
+	"gotest.tools/assert"
+)
+
+func signToken(t *testing.T, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	alg := jose.RS256
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		alg = jose.ES256
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: jose.JSONWebKey{Key: key, KeyID: kid}},
+		(&jose.SignerOptions{}).WithType("JWT"))
+	assert.Assert(t, err)
+	payload, err := json.Marshal(claims)
+	assert.Assert(t, err)
+	jws, err := signer.Sign(payload)
+	assert.Assert(t, err)
+	token, err := jws.CompactSerialize()
+	assert.Assert(t, err)
+	return token
+}
+
+func TestNewOpenIDVerifier(t *testing.T) {
+	_, err := newOpenIDVerifier("https://idp.example.com", "", "")
+	assert.ErrorContains(t, err, "a client id is required")
+	_, err = newOpenIDVerifier("idp.example.com", "skupper-console", "")
+	assert.ErrorContains(t, err, "invalid issuer url")
+}
+
+func TestOpenIDVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Assert(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Assert(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Assert(t, err)
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Assert(t, err)
+
+	var issuer string
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{
+				"issuer":               issuer,
+				"jwks_uri":             issuer + "/keys",
+				"end_session_endpoint": issuer + "/logout",
+			})
+		case "/keys":
+			json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+				{KeyID: "rsa", Key: rsaKey.Public(), Use: "sig", Algorithm: string(jose.RS256)},
+				{KeyID: "ec", Key: ecKey.Public()},
+				{KeyID: "weak", Key: weakKey.Public(), Use: "sig"},
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer provider.Close()
+	issuer = provider.URL
+
+	verifier, err := newOpenIDVerifier(issuer, "skupper-console", "")
+	assert.Assert(t, err)
+
+	now := time.Now().Unix()
+	valid := map[string]interface{}{"iss": issuer, "aud": "skupper-console", "exp": now + 60, "sub": "1234", "preferred_username": "alice"}
+	testTable := []struct {
+		name   string
+		kid    string
+		key    crypto.Signer
+		claims map[string]interface{}
+		err    string
+	}{
+		{name: "rsa", kid: "rsa", key: rsaKey, claims: valid},
+		{name: "ec", kid: "ec", key: ecKey, claims: valid},
+		{name: "audience-list", kid: "rsa", key: rsaKey, claims: map[string]interface{}{"iss": issuer, "aud": []string{"other", "skupper-console"}, "azp": "skupper-console", "exp": now + 60}},
+		{name: "audience-list-no-azp", kid: "rsa", key: rsaKey, claims: map[string]interface{}{"iss": issuer, "aud": []string{"other", "skupper-console"}, "exp": now + 60}, err: "without an authorized party"},
+		{name: "azp", kid: "rsa", key: rsaKey, claims: map[string]interface{}{"iss": issuer, "aud": "skupper-console", "azp": "other", "exp": now + 60}, err: "token authorized for \"other\""},
+		{name: "expired", kid: "rsa", key: rsaKey, claims: map[string]interface{}{"iss": issuer, "aud": "skupper-console", "exp": now - 3600}, err: "token is expired"},
+		{name: "audience", kid: "rsa", key: rsaKey, claims: map[string]interface{}{"iss": issuer, "aud": "other", "exp": now + 60}, err: "expected audience"},
+		{name: "no-audience", kid: "rsa", key: rsaKey, claims: map[string]interface{}{"iss": issuer, "exp": now + 60}, err: "expected audience"},
+		{name: "issuer", kid: "rsa", key: rsaKey, claims: map[string]interface{}{"iss": "https://evil.example.com", "aud": "skupper-console", "exp": now + 60}, err: "issued by a different provider"},
+		{name: "signature", kid: "rsa", key: otherKey, claims: valid, err: "invalid signature"},
+		{name: "unknown-key", kid: "other", key: otherKey, claims: valid, err: "unknown signing key"},
+		{name: "weak-key", kid: "weak", key: weakKey, claims: valid, err: "unknown signing key"},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			claims, err := verifier.verify(context.Background(), signToken(t, test.kid, test.key, test.claims))
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+			} else {
+				assert.Assert(t, err)
+				assert.Equal(t, claims["iss"], issuer)
+			}
+		})
+	}
+
+	// the user handler reports the claims of the authenticated token
+	openIDAuth = verifier
+	defer func() { openIDAuth = nil }()
+	handler := authenticated(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(verifier.getUser(r))
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1alpha1/user/", nil))
+	assert.Equal(t, rec.Code, http.StatusUnauthorized)
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/user/", nil)
+	req.Header.Set("Authorization", "Bearer "+signToken(t, "rsa", rsaKey, valid))
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	user := UserResponse{}
+	assert.Assert(t, json.Unmarshal(rec.Body.Bytes(), &user))
+	assert.Equal(t, user.Username, "alice")
+	assert.Equal(t, user.AuthMode, "openid")
+
+	rec = httptest.NewRecorder()
+	verifier.logout(rec, httptest.NewRequest(http.MethodGet, "/api/v1alpha1/logout/?redirect_uri=https://console", nil))
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), issuer+"/logout?client_id=skupper-console&post_logout_redirect_uri=https%3A%2F%2Fconsole")
+}
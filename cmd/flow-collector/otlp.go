@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skupperproject/skupper/pkg/version"
+)
+
+const (
+	otlpTimeout   = 10 * time.Second
+	otlpScopeName = "github.com/skupperproject/skupper/flow-collector"
+)
+
+// otlpExporter posts OTLP/HTTP requests in their json encoding. The
+// OpenTelemetry modules would bring the protobuf encoding, but they require
+// versions of go-logr that the kubernetes client of the collector does not
+// build with.
+type otlpExporter struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// newOtlpExporterFromEnv reads the endpoint of the signal from
+// <prefix>_OTLP_ENDPOINT, as in http://otel-collector:4318, the path of the
+// signal being appended when the endpoint has none, and the headers sent
+// along, as in authorization=Bearer%20token, from <prefix>_OTLP_HEADERS. It
+// returns nil when no endpoint is set.
+func newOtlpExporterFromEnv(prefix string, signal string) (*otlpExporter, error) {
+	endpoint := os.Getenv(prefix + "_OTLP_ENDPOINT")
+	if endpoint == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid %s_OTLP_ENDPOINT %q", prefix, endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/" + signal
+	}
+	headers, err := parseOtlpHeaders(os.Getenv(prefix + "_OTLP_HEADERS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s_OTLP_HEADERS: %w", prefix, err)
+	}
+	return &otlpExporter{
+		url:     u.String(),
+		headers: headers,
+		client:  &http.Client{Timeout: otlpTimeout},
+	}, nil
+}
+
+// parseOtlpHeaders parses the comma separated key=value pairs, the values
+// being url encoded, as the OTEL_EXPORTER_OTLP_HEADERS of the sdks
+func parseOtlpHeaders(value string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, encoded, found := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		decoded, err := url.QueryUnescape(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %s: %w", key, err)
+		}
+		headers[key] = decoded
+	}
+	return headers, nil
+}
+
+func (e *otlpExporter) post(request interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", e.url, resp.Status)
+	}
+	return nil
+}
+
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+func otlpCollectorScope() otlpScope {
+	return otlpScope{Name: otlpScopeName, Version: version.Version}
+}
+
+func otlpString(key string, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: &value}}
+}
+
+// otlpInt encodes the 64 bits integer as a string, as the json mapping of
+// protobuf does
+func otlpInt(key string, value int64) otlpKeyValue {
+	encoded := strconv.FormatInt(value, 10)
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{IntValue: &encoded}}
+}
+
+// otlpTime encodes the time in microseconds of the flow records in
+// nanoseconds
+func otlpTime(micros uint64) string {
+	return strconv.FormatUint(micros*1000, 10)
+}
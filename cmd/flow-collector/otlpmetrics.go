@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	otlpMetricsDefaultInterval = 30 * time.Second
+	otlpMetricsMinInterval     = time.Second
+	// the values are totals since the start of the collector
+	otlpTemporalityCumulative = 2
+)
+
+type otlpNumberDataPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	AsDouble          float64        `json:"asDouble"`
+}
+
+type otlpHistogramDataPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	Count             string         `json:"count"`
+	Sum               float64        `json:"sum"`
+	BucketCounts      []string       `json:"bucketCounts"`
+	ExplicitBounds    []float64      `json:"explicitBounds"`
+}
+
+type otlpQuantileValue struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+type otlpSummaryDataPoint struct {
+	Attributes        []otlpKeyValue      `json:"attributes,omitempty"`
+	StartTimeUnixNano string              `json:"startTimeUnixNano"`
+	TimeUnixNano      string              `json:"timeUnixNano"`
+	Count             string              `json:"count"`
+	Sum               float64             `json:"sum"`
+	QuantileValues    []otlpQuantileValue `json:"quantileValues"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberDataPoint `json:"dataPoints"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpNumberDataPoint `json:"dataPoints"`
+	AggregationTemporality int                   `json:"aggregationTemporality"`
+	IsMonotonic            bool                  `json:"isMonotonic"`
+}
+
+type otlpHistogram struct {
+	DataPoints             []otlpHistogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                      `json:"aggregationTemporality"`
+}
+
+type otlpSummary struct {
+	DataPoints []otlpSummaryDataPoint `json:"dataPoints"`
+}
+
+type otlpMetric struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Gauge       *otlpGauge     `json:"gauge,omitempty"`
+	Sum         *otlpSum       `json:"sum,omitempty"`
+	Histogram   *otlpHistogram `json:"histogram,omitempty"`
+	Summary     *otlpSummary   `json:"summary,omitempty"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+// otlpMetricsExporter pushes the metrics of the prometheus registry of the
+// collector to an OpenTelemetry collector, for the pipelines that do not
+// scrape prometheus endpoints
+type otlpMetricsExporter struct {
+	otlp     *otlpExporter
+	gatherer prometheus.Gatherer
+	resource otlpResource
+	interval time.Duration
+	start    time.Time
+	// only the active replica pushes its metrics
+	leading func() bool
+}
+
+// newOtlpMetricsExporterFromEnv reads FLOW_METRICS_OTLP_ENDPOINT and
+// FLOW_METRICS_OTLP_HEADERS, along with FLOW_METRICS_OTLP_INTERVAL, the
+// period of the pushes, it returns nil when no endpoint is set
+func newOtlpMetricsExporterFromEnv(gatherer prometheus.Gatherer, siteId string, leading func() bool) (*otlpMetricsExporter, error) {
+	otlp, err := newOtlpExporterFromEnv("FLOW_METRICS", "metrics")
+	if err != nil || otlp == nil {
+		return nil, err
+	}
+	interval := otlpMetricsDefaultInterval
+	if value := os.Getenv("FLOW_METRICS_OTLP_INTERVAL"); value != "" {
+		interval, err = time.ParseDuration(value)
+		if err != nil || interval < otlpMetricsMinInterval {
+			return nil, fmt.Errorf("invalid FLOW_METRICS_OTLP_INTERVAL %q, expected a duration of at least %s", value, otlpMetricsMinInterval)
+		}
+	}
+	attributes := []otlpKeyValue{otlpString("service.name", "skupper-flow-collector")}
+	if siteId != "" {
+		attributes = append(attributes, otlpString("skupper.site.id", siteId))
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		attributes = append(attributes, otlpString("service.instance.id", hostname))
+	}
+	return &otlpMetricsExporter{
+		otlp:     otlp,
+		gatherer: gatherer,
+		resource: otlpResource{Attributes: attributes},
+		interval: interval,
+		start:    time.Now(),
+		leading:  leading,
+	}, nil
+}
+
+// run pushes the metrics on every interval until stopped, and a last time
+// then
+func (e *otlpMetricsExporter) run(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			e.export()
+		case <-stopCh:
+			e.export()
+			return
+		}
+	}
+}
+
+func (e *otlpMetricsExporter) export() {
+	if !e.leading() {
+		return
+	}
+	families, err := e.gatherer.Gather()
+	if err != nil {
+		logger.Errorf("Failed to gather the metrics to export: %s", err)
+		if len(families) == 0 {
+			return
+		}
+	}
+	if err := e.otlp.post(otlpMetricsRequestFor(families, e.resource, e.start, time.Now())); err != nil {
+		logger.Errorf("Failed to export the metrics: %s", err)
+	}
+}
+
+func otlpNanos(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func otlpLabels(labels []*dto.LabelPair) []otlpKeyValue {
+	attributes := []otlpKeyValue{}
+	for _, label := range labels {
+		attributes = append(attributes, otlpString(label.GetName(), label.GetValue()))
+	}
+	return attributes
+}
+
+// otlpHistogramPoint converts the cumulative buckets of prometheus, whose
+// +Inf bucket is implicit, into the buckets of OTLP, that count the
+// observations between their bounds
+func otlpHistogramPoint(histogram *dto.Histogram) ([]string, []float64) {
+	counts := []string{}
+	bounds := []float64{}
+	previous := uint64(0)
+	for _, bucket := range histogram.GetBucket() {
+		if math.IsInf(bucket.GetUpperBound(), 1) {
+			continue
+		}
+		bounds = append(bounds, bucket.GetUpperBound())
+		counts = append(counts, strconv.FormatUint(bucket.GetCumulativeCount()-previous, 10))
+		previous = bucket.GetCumulativeCount()
+	}
+	counts = append(counts, strconv.FormatUint(histogram.GetSampleCount()-previous, 10))
+	return counts, bounds
+}
+
+// otlpMetricsRequestFor converts the gathered metrics, the counters being
+// cumulative sums since the start of the collector
+func otlpMetricsRequestFor(families []*dto.MetricFamily, resource otlpResource, start time.Time, now time.Time) otlpMetricsRequest {
+	startNanos, nowNanos := otlpNanos(start), otlpNanos(now)
+	metrics := []otlpMetric{}
+	for _, family := range families {
+		metric := otlpMetric{Name: family.GetName(), Description: family.GetHelp()}
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			metric.Sum = &otlpSum{AggregationTemporality: otlpTemporalityCumulative, IsMonotonic: true}
+			for _, m := range family.GetMetric() {
+				metric.Sum.DataPoints = append(metric.Sum.DataPoints, otlpNumberDataPoint{
+					Attributes:        otlpLabels(m.GetLabel()),
+					StartTimeUnixNano: startNanos,
+					TimeUnixNano:      nowNanos,
+					AsDouble:          m.GetCounter().GetValue(),
+				})
+			}
+		case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+			metric.Gauge = &otlpGauge{}
+			for _, m := range family.GetMetric() {
+				value := m.GetGauge().GetValue()
+				if family.GetType() == dto.MetricType_UNTYPED {
+					value = m.GetUntyped().GetValue()
+				}
+				metric.Gauge.DataPoints = append(metric.Gauge.DataPoints, otlpNumberDataPoint{
+					Attributes:   otlpLabels(m.GetLabel()),
+					TimeUnixNano: nowNanos,
+					AsDouble:     value,
+				})
+			}
+		case dto.MetricType_HISTOGRAM:
+			metric.Histogram = &otlpHistogram{AggregationTemporality: otlpTemporalityCumulative}
+			for _, m := range family.GetMetric() {
+				counts, bounds := otlpHistogramPoint(m.GetHistogram())
+				metric.Histogram.DataPoints = append(metric.Histogram.DataPoints, otlpHistogramDataPoint{
+					Attributes:        otlpLabels(m.GetLabel()),
+					StartTimeUnixNano: startNanos,
+					TimeUnixNano:      nowNanos,
+					Count:             strconv.FormatUint(m.GetHistogram().GetSampleCount(), 10),
+					Sum:               m.GetHistogram().GetSampleSum(),
+					BucketCounts:      counts,
+					ExplicitBounds:    bounds,
+				})
+			}
+		case dto.MetricType_SUMMARY:
+			metric.Summary = &otlpSummary{}
+			for _, m := range family.GetMetric() {
+				quantiles := []otlpQuantileValue{}
+				for _, q := range m.GetSummary().GetQuantile() {
+					quantiles = append(quantiles, otlpQuantileValue{Quantile: q.GetQuantile(), Value: q.GetValue()})
+				}
+				metric.Summary.DataPoints = append(metric.Summary.DataPoints, otlpSummaryDataPoint{
+					Attributes:        otlpLabels(m.GetLabel()),
+					StartTimeUnixNano: startNanos,
+					TimeUnixNano:      nowNanos,
+					Count:             strconv.FormatUint(m.GetSummary().GetSampleCount(), 10),
+					Sum:               m.GetSummary().GetSampleSum(),
+					QuantileValues:    quantiles,
+				})
+			}
+		default:
+			continue
+		}
+		metrics = append(metrics, metric)
+	}
+	return otlpMetricsRequest{
+		ResourceMetrics: []otlpResourceMetrics{{
+			Resource: resource,
+			ScopeMetrics: []otlpScopeMetrics{{
+				Scope:   otlpCollectorScope(),
+				Metrics: metrics,
+			}},
+		}},
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gotest.tools/assert"
+)
+
+func TestOtlpMetricsRequest(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "flows_total", Help: "Flows"}, []string{"address"})
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "active_flows"})
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "flow_latency_seconds", Buckets: []float64{0.1, 1}})
+	reg.MustRegister(counter, gauge, histogram)
+	counter.WithLabelValues("backend").Add(3)
+	gauge.Set(2)
+	histogram.Observe(0.05)
+	histogram.Observe(0.5)
+	histogram.Observe(0.7)
+	histogram.Observe(5)
+
+	families, err := reg.Gather()
+	assert.Assert(t, err)
+	start := time.Unix(100, 0)
+	request := otlpMetricsRequestFor(families, otlpResource{}, start, time.Unix(200, 0))
+	assert.Equal(t, len(request.ResourceMetrics), 1)
+	metrics := map[string]otlpMetric{}
+	for _, metric := range request.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		metrics[metric.Name] = metric
+	}
+	assert.Equal(t, len(metrics), 3)
+
+	sum := metrics["flows_total"].Sum
+	assert.Assert(t, sum != nil)
+	assert.Assert(t, sum.IsMonotonic)
+	assert.Equal(t, sum.AggregationTemporality, otlpTemporalityCumulative)
+	assert.Equal(t, sum.DataPoints[0].AsDouble, 3.0)
+	assert.Equal(t, sum.DataPoints[0].StartTimeUnixNano, "100000000000")
+	assert.Equal(t, sum.DataPoints[0].TimeUnixNano, "200000000000")
+	assert.Equal(t, *sum.DataPoints[0].Attributes[0].Value.StringValue, "backend")
+	assert.Equal(t, metrics["flows_total"].Description, "Flows")
+
+	assert.Equal(t, metrics["active_flows"].Gauge.DataPoints[0].AsDouble, 2.0)
+
+	point := metrics["flow_latency_seconds"].Histogram.DataPoints[0]
+	assert.Equal(t, point.Count, "4")
+	assert.DeepEqual(t, point.ExplicitBounds, []float64{0.1, 1})
+	assert.DeepEqual(t, point.BucketCounts, []string{"1", "2", "1"})
+}
+
+func TestOtlpMetricsExporter(t *testing.T) {
+	posted := make(chan otlpMetricsRequest, 2)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, "/v1/metrics")
+		request := otlpMetricsRequest{}
+		json.NewDecoder(r.Body).Decode(&request)
+		posted <- request
+	}))
+	defer collector.Close()
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "active_flows"}))
+	leading := false
+	t.Setenv("FLOW_METRICS_OTLP_ENDPOINT", collector.URL)
+	t.Setenv("FLOW_METRICS_OTLP_INTERVAL", "1h")
+	e, err := newOtlpMetricsExporterFromEnv(reg, "site-id", func() bool { return leading })
+	assert.Assert(t, err)
+	assert.Equal(t, e.interval, time.Hour)
+
+	// the standby replicas do not push
+	e.export()
+	assert.Equal(t, len(posted), 0)
+
+	leading = true
+	e.export()
+	request := <-posted
+	assert.Equal(t, *request.ResourceMetrics[0].Resource.Attributes[0].Value.StringValue, "skupper-flow-collector")
+	assert.Equal(t, *request.ResourceMetrics[0].Resource.Attributes[1].Value.StringValue, "site-id")
+	assert.Equal(t, request.ResourceMetrics[0].ScopeMetrics[0].Metrics[0].Name, "active_flows")
+
+	t.Setenv("FLOW_METRICS_OTLP_INTERVAL", "10ms")
+	_, err = newOtlpMetricsExporterFromEnv(reg, "site-id", nil)
+	assert.ErrorContains(t, err, "invalid FLOW_METRICS_OTLP_INTERVAL")
+}
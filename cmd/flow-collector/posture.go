@@ -0,0 +1,345 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/encryption"
+	"github.com/skupperproject/skupper/pkg/network"
+	"github.com/skupperproject/skupper/pkg/qdr"
+	"github.com/skupperproject/skupper/pkg/utils/configs"
+)
+
+var tlsVersionNames = map[uint16]string{
+	tls.VersionTLS10: "TLSv1.0",
+	tls.VersionTLS11: "TLSv1.1",
+	tls.VersionTLS12: "TLSv1.2",
+	tls.VersionTLS13: "TLSv1.3",
+}
+
+// postureReporter reports the certificates, the tls settings and the
+// authentication modes of the site, along with those of the peer collectors
+type postureReporter struct {
+	identity   string
+	authMode   string
+	consoleDir string
+	// consoleTls is nil when the console is served over plain http
+	consoleTls *tls.Config
+	conn       *configs.ConnectInfo
+	kubeClient kubernetes.Interface
+	namespace  string
+	federation *federation
+	router     func() (routerPosture, error)
+	now        func() time.Time
+}
+
+type routerPosture struct {
+	listeners   []qdr.Listener
+	profiles    map[string]qdr.SslProfile
+	connections []qdr.Connection
+}
+
+func newPostureReporter(identity string, authMode string, consoleDir string, conn *configs.ConnectInfo, kubeClient kubernetes.Interface, namespace string, federation *federation) *postureReporter {
+	p := &postureReporter{
+		identity:   identity,
+		authMode:   authMode,
+		consoleDir: consoleDir,
+		conn:       conn,
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		federation: federation,
+		now:        time.Now,
+	}
+	p.router = p.queryRouter
+	return p
+}
+
+// queryRouter retrieves the listeners and connections of the local router
+// through the first reachable endpoint
+func (p *postureReporter) queryRouter() (routerPosture, error) {
+	result := routerPosture{profiles: map[string]qdr.SslProfile{}}
+	var agent *qdr.Agent
+	err := fmt.Errorf("no router endpoint configured")
+	for _, endpoint := range p.conn.Endpoints {
+		agent, err = qdr.ConnectWithFactory(endpointConnectionFactory(endpoint))
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return result, err
+	}
+	defer agent.Close()
+	if result.listeners, err = agent.GetLocalListeners(); err != nil {
+		return result, err
+	}
+	for _, listener := range result.listeners {
+		if listener.SslProfile == "" {
+			continue
+		}
+		if _, ok := result.profiles[listener.SslProfile]; ok {
+			continue
+		}
+		profile, err := agent.GetSslProfileByName(listener.SslProfile)
+		if err == nil && profile != nil {
+			result.profiles[listener.SslProfile] = *profile
+		}
+	}
+	if result.connections, err = agent.GetConnections(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func (p *postureReporter) certificates(ctx context.Context, now time.Time) ([]network.CertificatePosture, []string) {
+	postures := []network.CertificatePosture{}
+	errors := []string{}
+	add := func(name string, source string, data []byte) {
+		certs, err := network.CertificatePostures(name, source, data, now)
+		if err != nil {
+			errors = append(errors, err.Error())
+			return
+		}
+		postures = append(postures, certs...)
+	}
+	files := map[string]string{}
+	if p.consoleTls != nil {
+		files[filepath.Join(p.consoleDir, "tls.crt")] = "console"
+	}
+	for _, endpoint := range p.conn.Endpoints {
+		if endpoint.Tls != nil && endpoint.Tls.Cert != "" {
+			files[endpoint.Tls.Cert] = "router client"
+		}
+	}
+	for file, name := range files {
+		data, err := encryption.ReadFile(file)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		add(name, file, data)
+	}
+	if p.kubeClient != nil {
+		secrets, err := p.kubeClient.CoreV1().Secrets(p.namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("Failed to list secrets: %s", err))
+		} else {
+			for _, secret := range secrets.Items {
+				_, skupper := secret.Labels[types.SkupperTypeQualifier]
+				if len(secret.Data["tls.crt"]) == 0 || !(skupper || strings.HasPrefix(secret.Name, "skupper")) {
+					continue
+				}
+				add(secret.Name, "secret/"+secret.Name, secret.Data["tls.crt"])
+			}
+		}
+	}
+	sort.SliceStable(postures, func(i, j int) bool {
+		return postures[i].NotAfter.Before(postures[j].NotAfter)
+	})
+	return postures, errors
+}
+
+func routerTlsPostures(router routerPosture) []network.TlsPosture {
+	postures := []network.TlsPosture{}
+	for _, listener := range router.listeners {
+		posture := network.TlsPosture{
+			Kind:              "listener",
+			Name:              listener.Name,
+			Role:              string(listener.Role),
+			Host:              listener.Host,
+			Encrypted:         listener.SslProfile != "",
+			PeerAuthenticated: listener.SslProfile != "" && listener.AuthenticatePeer,
+		}
+		if profile, ok := router.profiles[listener.SslProfile]; ok {
+			posture.TlsVersion = profile.Protocols
+			posture.Cipher = profile.Ciphers
+		}
+		posture.Evaluate()
+		postures = append(postures, posture)
+	}
+	for _, connection := range router.connections {
+		if connection.Role != string(qdr.RoleInterRouter) && connection.Role != qdr.RoleEdge {
+			continue
+		}
+		posture := network.TlsPosture{
+			Kind:              "link",
+			Name:              connection.Container,
+			Role:              connection.Role,
+			Direction:         connection.Dir,
+			Host:              connection.Host,
+			Encrypted:         connection.Encrypted,
+			TlsVersion:        connection.SslProto,
+			Cipher:            connection.SslCipher,
+			PeerAuthenticated: connection.Encrypted && (connection.Dir == "out" || connection.User != ""),
+		}
+		posture.Evaluate()
+		postures = append(postures, posture)
+	}
+	return postures
+}
+
+func (p *postureReporter) consoleTlsPosture() network.TlsPosture {
+	posture := network.TlsPosture{
+		Kind:      "console",
+		Name:      "flow-collector",
+		Encrypted: p.consoleTls != nil,
+		// the console users authenticate at the http level
+		PeerAuthenticated: true,
+	}
+	if p.consoleTls != nil {
+		posture.TlsVersion = tlsVersionNames[p.consoleTls.MinVersion] + "+"
+		if p.consoleTls.MaxVersion != 0 {
+			posture.TlsVersion = tlsVersionNames[p.consoleTls.MinVersion] + "-" + tlsVersionNames[p.consoleTls.MaxVersion]
+		}
+		suites := []string{}
+		for _, suite := range p.consoleTls.CipherSuites {
+			suites = append(suites, tls.CipherSuiteName(suite))
+		}
+		posture.Cipher = strings.Join(suites, ",")
+	}
+	posture.Evaluate()
+	return posture
+}
+
+func routerAuthPostures(router routerPosture) []network.AuthPosture {
+	postures := []network.AuthPosture{}
+	for _, listener := range router.listeners {
+		if listener.Role != qdr.RoleInterRouter && listener.Role != qdr.RoleEdge {
+			continue
+		}
+		posture := network.AuthPosture{Component: "router listener " + listener.Name, Mode: "none", Status: network.PostureInsecure}
+		if listener.SslProfile != "" && listener.AuthenticatePeer {
+			posture.Mode, posture.Status = "mutual tls", network.PostureOk
+		} else if listener.SaslMechanisms != "" && listener.AuthenticatePeer {
+			posture.Mode, posture.Status = "sasl "+listener.SaslMechanisms, network.PostureOk
+		}
+		postures = append(postures, posture)
+	}
+	return postures
+}
+
+func (p *postureReporter) authPostures() []network.AuthPosture {
+	console := network.AuthPosture{Component: "console", Mode: p.authMode, Status: network.PostureOk}
+	if p.authMode == "" || p.authMode == types.ConsoleAuthModeUnsecured {
+		console.Mode = types.ConsoleAuthModeUnsecured
+		console.Status = network.PostureInsecure
+	}
+	postures := []network.AuthPosture{console}
+	for _, endpoint := range p.conn.Endpoints {
+		posture := network.AuthPosture{Component: "collector to router " + endpoint.Url(), Mode: "none", Status: network.PostureOk}
+		switch {
+		case endpoint.Sasl != nil && endpoint.Sasl.Mechanism != "":
+			posture.Mode = "sasl " + endpoint.Sasl.Mechanism
+		case endpoint.Tls != nil && endpoint.Tls.Cert != "":
+			posture.Mode = "mutual tls"
+		}
+		if posture.Mode == "none" && !isLocalEndpoint(endpoint.Host) {
+			posture.Status = network.PostureInsecure
+		}
+		postures = append(postures, posture)
+	}
+	return postures
+}
+
+func isLocalEndpoint(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1" || host == ""
+}
+
+func (p *postureReporter) report(ctx context.Context) network.PostureReport {
+	now := p.now()
+	report := network.PostureReport{
+		Collector: p.identity,
+		Generated: now,
+		Errors:    []string{},
+	}
+	report.Certificates, report.Errors = p.certificates(ctx, now)
+	report.Tls = []network.TlsPosture{p.consoleTlsPosture()}
+	report.Auth = p.authPostures()
+	router, err := p.router()
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("Failed to query the router: %s", err))
+	} else {
+		report.Tls = append(report.Tls, routerTlsPostures(router)...)
+		report.Auth = append(report.Auth, routerAuthPostures(router)...)
+	}
+	report.Summarize()
+	return report
+}
+
+// fetchPosture retrieves the local posture report of a peer collector
+func (f *federation) fetchPosture(ctx context.Context, peer peerCollector) (network.PostureReport, error) {
+	report := network.PostureReport{}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, peer.Url+"/api/v1alpha1/posture/?local=true", nil)
+	if err != nil {
+		return report, err
+	}
+	if peer.Username != "" {
+		req.SetBasicAuth(peer.Username, peer.Password)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return report, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return report, fmt.Errorf("peer returned %s", resp.Status)
+	}
+	reports := []network.PostureReport{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 8<<20)).Decode(&reports); err != nil {
+		return report, err
+	}
+	if len(reports) == 0 {
+		return report, fmt.Errorf("peer returned no report")
+	}
+	return reports[0], nil
+}
+
+// postureHandler returns the report of the local site followed by those of
+// the peer collectors, unless local is set
+func (p *postureReporter) postureHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	reports := []network.PostureReport{p.report(r.Context())}
+	if r.URL.Query().Get("local") != "true" && p.federation != nil {
+		peers := p.federation.snapshot()
+		peerReports := make([]network.PostureReport, len(peers))
+		var wg sync.WaitGroup
+		for i, peer := range peers {
+			wg.Add(1)
+			go func(i int, peer peerCollector) {
+				defer wg.Done()
+				report, err := p.federation.fetchPosture(r.Context(), peer)
+				p.federation.updateStatus(peer.Name, err)
+				if err != nil {
+					report = network.PostureReport{Errors: []string{err.Error()}}
+				}
+				if report.Collector == "" {
+					report.Collector = peer.Name
+				}
+				peerReports[i] = report
+			}(i, peer)
+		}
+		wg.Wait()
+		sort.Slice(peerReports, func(i, j int) bool {
+			return peerReports[i].Collector < peerReports[j].Collector
+		})
+		reports = append(reports, peerReports...)
+	}
+	json.NewEncoder(w).Encode(reports)
+}
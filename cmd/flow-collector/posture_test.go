@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/network"
+	"github.com/skupperproject/skupper/pkg/qdr"
+	"github.com/skupperproject/skupper/pkg/utils/configs"
+)
+
+func TestPostureReport(t *testing.T) {
+	ca := certs.GenerateCASecret(types.SiteCaSecret, types.SiteCaSecret)
+	ca.Namespace = "test"
+	ca.Labels = map[string]string{types.SkupperTypeQualifier: "ca"}
+	other := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "application-tls", Namespace: "test"},
+		Data:       ca.Data,
+	}
+	kubeClient := fake.NewSimpleClientset(&ca, other)
+
+	posture := newPostureReporter("collector-1", types.ConsoleAuthModeUnsecured, t.TempDir(), &configs.ConnectInfo{
+		Endpoints: []configs.ConnectEndpoint{{Scheme: "amqp", Host: "localhost", Port: "5672"}},
+	}, kubeClient, "test", nil)
+	posture.now = func() time.Time {
+		return time.Now().Add(5*365*24*time.Hour - 24*time.Hour)
+	}
+	posture.router = func() (routerPosture, error) {
+		return routerPosture{
+			listeners: []qdr.Listener{
+				{Name: "interior-listener", Role: qdr.RoleInterRouter, SslProfile: "skupper-internal", AuthenticatePeer: true},
+				{Name: "edge-listener", Role: qdr.RoleEdge},
+			},
+			profiles: map[string]qdr.SslProfile{
+				"skupper-internal": {Name: "skupper-internal", Protocols: "TLSv1.2"},
+			},
+			connections: []qdr.Connection{
+				{Container: "west", Role: "inter-router", Dir: "in", Encrypted: true, SslProto: "TLSv1.3", User: "aa01"},
+				{Container: "client", Role: "normal", Dir: "in"},
+			},
+		}, nil
+	}
+
+	w := httptest.NewRecorder()
+	posture.postureHandler(w, httptest.NewRequest(http.MethodGet, "/api/v1alpha1/posture/", nil))
+	assert.Equal(t, w.Code, http.StatusOK)
+	reports := []network.PostureReport{}
+	assert.Assert(t, json.NewDecoder(w.Body).Decode(&reports))
+	assert.Equal(t, len(reports), 1)
+	report := reports[0]
+	assert.Equal(t, report.Collector, "collector-1")
+
+	// only the skupper secrets are reported, the site CA expiring tomorrow
+	assert.Equal(t, len(report.Certificates), 1)
+	assert.Equal(t, report.Certificates[0].Name, types.SiteCaSecret)
+	assert.Equal(t, report.Certificates[0].Status, network.PostureExpiring)
+
+	status := map[string]string{}
+	for _, tp := range report.Tls {
+		status[tp.Kind+" "+tp.Name] = tp.Status
+	}
+	assert.DeepEqual(t, status, map[string]string{
+		"console flow-collector":     network.PostureInsecure,
+		"listener interior-listener": network.PostureOk,
+		"listener edge-listener":     network.PostureInsecure,
+		"link west":                  network.PostureOk,
+	})
+	auth := map[string]string{}
+	for _, a := range report.Auth {
+		auth[a.Component] = a.Mode
+	}
+	assert.DeepEqual(t, auth, map[string]string{
+		"console": "unsecured",
+		"collector to router amqp://localhost:5672": "none",
+		"router listener interior-listener":         "mutual tls",
+		"router listener edge-listener":             "none",
+	})
+	assert.Equal(t, len(report.Warnings), 5)
+
+	posture.router = func() (routerPosture, error) {
+		return routerPosture{}, fmt.Errorf("connection refused")
+	}
+	w = httptest.NewRecorder()
+	posture.postureHandler(w, httptest.NewRequest(http.MethodGet, "/api/v1alpha1/posture/?local=true", nil))
+	reports = []network.PostureReport{}
+	assert.Assert(t, json.NewDecoder(w.Body).Decode(&reports))
+	assert.DeepEqual(t, reports[0].Errors, []string{"Failed to query the router: connection refused"})
+}
+
+func TestPostureFederation(t *testing.T) {
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, "/api/v1alpha1/posture/")
+		assert.Equal(t, r.URL.Query().Get("local"), "true")
+		json.NewEncoder(w).Encode([]network.PostureReport{{Collector: "east-collector", Warnings: []string{"console authentication is insecure (unsecured)"}}})
+	}))
+	defer peer.Close()
+	f, err := newFederation("east=" + peer.URL + ",west=http://127.0.0.1:1")
+	assert.Assert(t, err)
+
+	posture := newPostureReporter("collector-1", string(types.ConsoleAuthModeInternal), t.TempDir(), &configs.ConnectInfo{}, nil, "", f)
+	posture.router = func() (routerPosture, error) {
+		return routerPosture{}, nil
+	}
+	w := httptest.NewRecorder()
+	posture.postureHandler(w, httptest.NewRequest(http.MethodGet, "/api/v1alpha1/posture/", nil))
+	reports := []network.PostureReport{}
+	assert.Assert(t, json.NewDecoder(w.Body).Decode(&reports))
+	assert.Equal(t, len(reports), 3)
+	assert.Equal(t, reports[0].Collector, "collector-1")
+	assert.Equal(t, reports[1].Collector, "east-collector")
+	assert.Equal(t, len(reports[1].Warnings), 1)
+	assert.Equal(t, reports[2].Collector, "west")
+	assert.Equal(t, len(reports[2].Errors), 1)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"strconv"
+)
+
+const (
+	defaultPprofAddr = "localhost:9970"
+	// pprofPath is where net/http/pprof expects to be served, the name of
+	// the profile following it
+	pprofPath = "/debug/pprof/"
+	// pprofApiPrefix is stripped from the path of the profiles served on the
+	// api, the time they may take being bounded by the timeout of the debug
+	// endpoint (e.g. API_TIMEOUTS=debug=2m)
+	pprofApiPrefix = "/api/v1alpha1/internal"
+)
+
+// pprofConfig tells where the runtime profiles are served, by a listener
+// of their own, on the api or both
+type pprofConfig struct {
+	addr string
+	api  bool
+}
+
+// pprofConfigFromEnv reads FLOW_PPROF_ADDR, the address of the listener of
+// the profiles, and FLOW_PPROF_API, serving them on the api instead. The
+// -profile flag on its own keeps serving them on localhost:9970.
+func pprofConfigFromEnv(profile bool) (pprofConfig, error) {
+	config := pprofConfig{addr: os.Getenv("FLOW_PPROF_ADDR")}
+	if value := os.Getenv("FLOW_PPROF_API"); value != "" {
+		api, err := strconv.ParseBool(value)
+		if err != nil {
+			return config, fmt.Errorf("invalid FLOW_PPROF_API %q", value)
+		}
+		config.api = api
+	}
+	if config.addr != "" {
+		if _, _, err := net.SplitHostPort(config.addr); err != nil {
+			return config, fmt.Errorf("invalid FLOW_PPROF_ADDR %q: %s", config.addr, err)
+		}
+	} else if profile && !config.api {
+		config.addr = defaultPprofAddr
+	}
+	return config, nil
+}
+
+// pprofHandler serves the profiles of net/http/pprof under /debug/pprof/,
+// each of them wrapped by the authentication of the api
+func pprofHandler(wrap func(http.HandlerFunc) http.HandlerFunc) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(pprofPath, wrap(pprof.Index))
+	mux.HandleFunc(pprofPath+"cmdline", wrap(pprof.Cmdline))
+	mux.HandleFunc(pprofPath+"profile", wrap(pprof.Profile))
+	mux.HandleFunc(pprofPath+"symbol", wrap(pprof.Symbol))
+	mux.HandleFunc(pprofPath+"trace", wrap(pprof.Trace))
+	return mux
+}
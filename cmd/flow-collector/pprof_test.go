@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestPprofConfigFromEnv(t *testing.T) {
+	testTable := []struct {
+		doc     string
+		profile bool
+		addr    string
+		api     string
+		config  pprofConfig
+		err     string
+	}{
+		{doc: "disabled"},
+		{doc: "profile flag", profile: true, config: pprofConfig{addr: "localhost:9970"}},
+		{doc: "address", addr: ":9970", config: pprofConfig{addr: ":9970"}},
+		{doc: "api only", profile: true, api: "true", config: pprofConfig{api: true}},
+		{doc: "address and api", addr: "0.0.0.0:9970", api: "true", config: pprofConfig{addr: "0.0.0.0:9970", api: true}},
+		{doc: "invalid address", addr: "9970", err: "invalid FLOW_PPROF_ADDR"},
+		{doc: "invalid api", api: "yes please", err: "invalid FLOW_PPROF_API"},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			t.Setenv("FLOW_PPROF_ADDR", test.addr)
+			t.Setenv("FLOW_PPROF_API", test.api)
+			config, err := pprofConfigFromEnv(test.profile)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			assert.Equal(t, config, test.config)
+		})
+	}
+}
+
+func TestPprofHandler(t *testing.T) {
+	handler := pprofHandler(func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			h(w, r)
+		}
+	})
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/pprof/cmdline", "/debug/pprof/symbol"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, w.Code, http.StatusUnauthorized, path)
+
+		w = httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Authorization", "Basic YWRtaW46YWRtaW4=")
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, w.Code, http.StatusOK, path)
+	}
+
+	// served on the api under the internal endpoints
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, pprofApiPrefix+"/debug/pprof/goroutine?debug=1", nil)
+	r.Header.Set("Authorization", "Basic YWRtaW46YWRtaW4=")
+	http.StripPrefix(pprofApiPrefix, handler).ServeHTTP(w, r)
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Assert(t, len(w.Body.String()) > 0)
+}
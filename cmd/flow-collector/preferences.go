@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/skupperproject/skupper/api/types"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	preferencesFile            = ".preferences"
+	defaultMaxPreferenceBytes  = 64 * 1024
+	defaultMaxPreferenceValues = 100
+)
+
+// preferences is set when the console users can store their preferences
+var preferences *preferenceManager
+
+var preferenceName = regexp.MustCompile(`^[A-Za-z0-9][-_.A-Za-z0-9]{0,63}$`)
+
+var (
+	errPreferenceNotFound = errors.New("no such preference")
+	errPreferencesTooMany = errors.New("too many preferences")
+	errPreferencesTooBig  = errors.New("preferences too big")
+)
+
+// preferenceStore holds the preferences of each user, as raw json values
+// by name
+type preferenceStore interface {
+	load(ctx context.Context, user string) (map[string]json.RawMessage, error)
+	save(ctx context.Context, user string, values map[string]json.RawMessage) error
+}
+
+// preferenceKey hashes the user name, which may hold characters that are
+// not valid in a config map key
+func preferenceKey(user string) string {
+	sum := sha256.Sum256([]byte(user))
+	return hex.EncodeToString(sum[:])
+}
+
+// configMapPreferenceStore keeps the preferences of each user in a key of
+// the skupper-console-preferences config map
+type configMapPreferenceStore struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+func (s *configMapPreferenceStore) load(ctx context.Context, user string) (map[string]json.RawMessage, error) {
+	values := map[string]json.RawMessage{}
+	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, types.ConsolePreferencesConfigMap, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return values, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if data, ok := cm.Data[preferenceKey(user)]; ok {
+		if err := json.Unmarshal([]byte(data), &values); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func (s *configMapPreferenceStore) save(ctx context.Context, user string, values map[string]json.RawMessage) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	configMaps := s.client.CoreV1().ConfigMaps(s.namespace)
+	cm, err := configMaps.Get(ctx, types.ConsolePreferencesConfigMap, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: types.ConsolePreferencesConfigMap},
+			Data:       map[string]string{preferenceKey(user): string(data)},
+		}
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+		return err
+	} else if err != nil {
+		return err
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	if len(values) == 0 {
+		delete(cm.Data, preferenceKey(user))
+	} else {
+		cm.Data[preferenceKey(user)] = string(data)
+	}
+	_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
+
+// filePreferenceStore keeps the preferences of all the users in a json file
+type filePreferenceStore struct {
+	path string
+}
+
+func (s *filePreferenceStore) loadAll() (map[string]map[string]json.RawMessage, error) {
+	all := map[string]map[string]json.RawMessage{}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return all, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+func (s *filePreferenceStore) load(ctx context.Context, user string) (map[string]json.RawMessage, error) {
+	all, err := s.loadAll()
+	if err != nil {
+		return nil, err
+	}
+	if values, ok := all[user]; ok {
+		return values, nil
+	}
+	return map[string]json.RawMessage{}, nil
+}
+
+func (s *filePreferenceStore) save(ctx context.Context, user string, values map[string]json.RawMessage) error {
+	all, err := s.loadAll()
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		delete(all, user)
+	} else {
+		all[user] = values
+	}
+	data, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// preferenceManager limits the number of preferences of each user and
+// their total size
+type preferenceManager struct {
+	store     preferenceStore
+	user      func(*http.Request) UserResponse
+	maxBytes  int
+	maxValues int
+
+	lock sync.Mutex
+}
+
+// newPreferenceManagerFromEnv keeps the preferences in a config map on
+// kubernetes and in the FLOW_PREFERENCES_FILE, or the users directory,
+// otherwise; FLOW_PREFERENCES_MAX_BYTES and FLOW_PREFERENCES_MAX_VALUES
+// set the limits per user. It returns nil when there is nowhere to keep them
+func newPreferenceManagerFromEnv(kubeClient kubernetes.Interface, namespace string, usersDir string) (*preferenceManager, error) {
+	m := &preferenceManager{
+		maxBytes:  defaultMaxPreferenceBytes,
+		maxValues: defaultMaxPreferenceValues,
+	}
+	for _, limit := range []struct {
+		name  string
+		value *int
+	}{
+		{"FLOW_PREFERENCES_MAX_BYTES", &m.maxBytes},
+		{"FLOW_PREFERENCES_MAX_VALUES", &m.maxValues},
+	} {
+		if value := os.Getenv(limit.name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid %s %q", limit.name, value)
+			}
+			*limit.value = n
+		}
+	}
+	if kubeClient != nil {
+		m.store = &configMapPreferenceStore{client: kubeClient, namespace: namespace}
+	} else if file := os.Getenv("FLOW_PREFERENCES_FILE"); file != "" {
+		m.store = &filePreferenceStore{path: file}
+	} else if usersDir != "" {
+		m.store = &filePreferenceStore{path: path.Join(usersDir, preferencesFile)}
+	} else {
+		return nil, nil
+	}
+	return m, nil
+}
+
+func (m *preferenceManager) checkLimits(values map[string]json.RawMessage) error {
+	if len(values) > m.maxValues {
+		return errPreferencesTooMany
+	}
+	size := 0
+	for name, value := range values {
+		size += len(name) + len(value)
+	}
+	if size > m.maxBytes {
+		return errPreferencesTooBig
+	}
+	return nil
+}
+
+// update applies the change to the stored preferences of the user,
+// starting over when another replica changed them in the meantime
+func (m *preferenceManager) update(ctx context.Context, user string, change func(map[string]json.RawMessage) error) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		values, err := m.store.load(ctx, user)
+		if err != nil {
+			return err
+		}
+		if err := change(values); err != nil {
+			return err
+		}
+		if err := m.checkLimits(values); err != nil {
+			return err
+		}
+		return m.store.save(ctx, user, values)
+	})
+}
+
+func preferenceError(w http.ResponseWriter, user string, err error) {
+	switch {
+	case errors.Is(err, errPreferenceNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, errPreferencesTooMany), errors.Is(err, errPreferencesTooBig):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	default:
+		logger.Errorf("Failed to update the preferences of %s: %s", user, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// preferencesHandler returns all the preferences of the user, or reads,
+// sets or deletes the named one
+func (m *preferenceManager) preferencesHandler(w http.ResponseWriter, r *http.Request) {
+	user := m.user(r).Username
+	if user == "" {
+		http.Error(w, "Preferences require an authenticated user", http.StatusForbidden)
+		return
+	}
+	name, named := mux.Vars(r)["name"]
+	if named && !preferenceName.MatchString(name) {
+		http.Error(w, "Invalid preference name", http.StatusBadRequest)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		values, err := m.store.load(r.Context(), user)
+		if err != nil {
+			preferenceError(w, user, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if !named {
+			json.NewEncoder(w).Encode(values)
+		} else if value, ok := values[name]; ok {
+			w.Write(value)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case http.MethodPut:
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(m.maxBytes)))
+		if err != nil {
+			http.Error(w, errPreferencesTooBig.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if !json.Valid(body) {
+			http.Error(w, "The preference must be a json value", http.StatusBadRequest)
+			return
+		}
+		var value bytes.Buffer
+		json.Compact(&value, body)
+		err = m.update(r.Context(), user, func(values map[string]json.RawMessage) error {
+			values[name] = value.Bytes()
+			return nil
+		})
+		if err != nil {
+			preferenceError(w, user, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		err := m.update(r.Context(), user, func(values map[string]json.RawMessage) error {
+			if !named {
+				for name := range values {
+					delete(values, name)
+				}
+				return nil
+			}
+			if _, ok := values[name]; !ok {
+				return errPreferenceNotFound
+			}
+			delete(values, name)
+			return nil
+		})
+		if err != nil {
+			preferenceError(w, user, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
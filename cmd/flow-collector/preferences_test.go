@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/skupperproject/skupper/api/types"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestPreferenceRouter(m *preferenceManager) *mux.Router {
+	m.user = func(r *http.Request) UserResponse {
+		user, _, _ := r.BasicAuth()
+		return UserResponse{Username: user}
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/preferences/", m.preferencesHandler)
+	router.HandleFunc("/preferences/{name}", m.preferencesHandler)
+	return router
+}
+
+func TestPreferencesHandler(t *testing.T) {
+	t.Setenv("FLOW_PREFERENCES_FILE", "")
+	t.Setenv("FLOW_PREFERENCES_MAX_BYTES", "64")
+	t.Setenv("FLOW_PREFERENCES_MAX_VALUES", "2")
+	m, err := newPreferenceManagerFromEnv(nil, "", t.TempDir())
+	assert.Assert(t, err)
+	router := newTestPreferenceRouter(m)
+
+	testTable := []struct {
+		doc    string
+		user   string
+		method string
+		path   string
+		body   string
+		status int
+		result string
+	}{
+		{doc: "anonymous", method: http.MethodGet, path: "/preferences/", status: http.StatusForbidden},
+		{doc: "empty", user: "alice", method: http.MethodGet, path: "/preferences/", status: http.StatusOK, result: "{}"},
+		{doc: "set-theme", user: "alice", method: http.MethodPut, path: "/preferences/theme", body: `"dark"`, status: http.StatusNoContent},
+		{doc: "set-filters", user: "alice", method: http.MethodPut, path: "/preferences/filters", body: `{ "state": "active" }`, status: http.StatusNoContent},
+		{doc: "get-theme", user: "alice", method: http.MethodGet, path: "/preferences/theme", status: http.StatusOK, result: `"dark"`},
+		{doc: "get-all", user: "alice", method: http.MethodGet, path: "/preferences/", status: http.StatusOK, result: `{"filters":{"state":"active"},"theme":"dark"}`},
+		{doc: "other-user", user: "bob", method: http.MethodGet, path: "/preferences/theme", status: http.StatusNotFound},
+		{doc: "too-many", user: "alice", method: http.MethodPut, path: "/preferences/pinned", body: `[]`, status: http.StatusRequestEntityTooLarge},
+		{doc: "too-big", user: "bob", method: http.MethodPut, path: "/preferences/pinned", body: `"` + strings.Repeat("x", 64) + `"`, status: http.StatusRequestEntityTooLarge},
+		{doc: "not-json", user: "alice", method: http.MethodPut, path: "/preferences/theme", body: `dark`, status: http.StatusBadRequest},
+		{doc: "bad-name", user: "alice", method: http.MethodPut, path: "/preferences/.theme", body: `"dark"`, status: http.StatusBadRequest},
+		{doc: "delete-theme", user: "alice", method: http.MethodDelete, path: "/preferences/theme", status: http.StatusNoContent},
+		{doc: "delete-missing", user: "alice", method: http.MethodDelete, path: "/preferences/theme", status: http.StatusNotFound},
+		{doc: "delete-all", user: "alice", method: http.MethodDelete, path: "/preferences/", status: http.StatusNoContent},
+		{doc: "deleted", user: "alice", method: http.MethodGet, path: "/preferences/", status: http.StatusOK, result: "{}"},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			r := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+			if test.user != "" {
+				r.SetBasicAuth(test.user, "secret")
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, r)
+			assert.Equal(t, rec.Code, test.status)
+			if test.result != "" {
+				assert.Equal(t, strings.TrimSpace(rec.Body.String()), test.result)
+			}
+		})
+	}
+}
+
+func TestPreferencesConfigMapStore(t *testing.T) {
+	t.Setenv("FLOW_PREFERENCES_MAX_BYTES", "")
+	t.Setenv("FLOW_PREFERENCES_MAX_VALUES", "")
+	kubeClient := fake.NewSimpleClientset()
+	m, err := newPreferenceManagerFromEnv(kubeClient, "skupper", "")
+	assert.Assert(t, err)
+	ctx := context.Background()
+
+	err = m.update(ctx, "alice@example.com", func(values map[string]json.RawMessage) error {
+		values["theme"] = json.RawMessage(`"dark"`)
+		return nil
+	})
+	assert.Assert(t, err)
+	cm, err := kubeClient.CoreV1().ConfigMaps("skupper").Get(ctx, types.ConsolePreferencesConfigMap, metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, cm.Data[preferenceKey("alice@example.com")], `{"theme":"dark"}`)
+
+	values, err := m.store.load(ctx, "alice@example.com")
+	assert.Assert(t, err)
+	assert.Equal(t, string(values["theme"]), `"dark"`)
+
+	err = m.update(ctx, "alice@example.com", func(values map[string]json.RawMessage) error {
+		delete(values, "theme")
+		return nil
+	})
+	assert.Assert(t, err)
+	cm, err = kubeClient.CoreV1().ConfigMaps("skupper").Get(ctx, types.ConsolePreferencesConfigMap, metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, len(cm.Data), 0)
+}
+
+func TestPreferenceManagerFromEnv(t *testing.T) {
+	t.Setenv("FLOW_PREFERENCES_FILE", "")
+	t.Setenv("FLOW_PREFERENCES_MAX_BYTES", "")
+	t.Setenv("FLOW_PREFERENCES_MAX_VALUES", "")
+	m, err := newPreferenceManagerFromEnv(nil, "", "")
+	assert.Assert(t, err)
+	assert.Assert(t, m == nil)
+
+	t.Setenv("FLOW_PREFERENCES_FILE", "/var/lib/skupper/preferences.json")
+	m, err = newPreferenceManagerFromEnv(nil, "", "")
+	assert.Assert(t, err)
+	assert.Equal(t, m.maxBytes, defaultMaxPreferenceBytes)
+	assert.Equal(t, m.maxValues, defaultMaxPreferenceValues)
+
+	t.Setenv("FLOW_PREFERENCES_MAX_BYTES", "lots")
+	_, err = newPreferenceManagerFromEnv(nil, "", "")
+	assert.ErrorContains(t, err, "invalid FLOW_PREFERENCES_MAX_BYTES")
+}
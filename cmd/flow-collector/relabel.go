@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var metricLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// metricRelabeling drops, renames and adds labels to the metrics gathered
+// from the registry of the collector. The series left with the same labels
+// once some are dropped are summed up.
+type metricRelabeling struct {
+	gatherer prometheus.Gatherer
+	drop     map[string]bool
+	rename   map[string]string
+	static   map[string]string
+}
+
+func validMetricLabel(name string) error {
+	if !metricLabelName.MatchString(name) || strings.HasPrefix(name, "__") {
+		return fmt.Errorf("invalid label name %q", name)
+	}
+	return nil
+}
+
+// newMetricRelabelingFromEnv reads the labels removed from the metrics from
+// FLOW_METRIC_LABELS_DROP, as in sourceProcess,destProcess, the labels
+// renamed from FLOW_METRIC_LABELS_RENAME, as in sourceSite=source_site, and
+// the labels added to all of them from FLOW_METRIC_LABELS_STATIC, as in
+// cluster=east,environment=prod. The static labels do not replace those of
+// the metrics that are set. The gatherer is returned as is when none is set.
+func newMetricRelabelingFromEnv(gatherer prometheus.Gatherer) (prometheus.Gatherer, error) {
+	m := &metricRelabeling{
+		gatherer: gatherer,
+		drop:     map[string]bool{},
+	}
+	for _, name := range strings.Split(os.Getenv("FLOW_METRIC_LABELS_DROP"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if err := validMetricLabel(name); err != nil {
+			return nil, fmt.Errorf("invalid FLOW_METRIC_LABELS_DROP: %w", err)
+		}
+		m.drop[name] = true
+	}
+	var err error
+	m.rename, err = parseOtlpHeaders(os.Getenv("FLOW_METRIC_LABELS_RENAME"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid FLOW_METRIC_LABELS_RENAME: %w", err)
+	}
+	for name, renamed := range m.rename {
+		if err := validMetricLabel(name); err != nil {
+			return nil, fmt.Errorf("invalid FLOW_METRIC_LABELS_RENAME: %w", err)
+		}
+		if err := validMetricLabel(renamed); err != nil {
+			return nil, fmt.Errorf("invalid FLOW_METRIC_LABELS_RENAME: %w", err)
+		}
+		if m.drop[name] {
+			return nil, fmt.Errorf("invalid FLOW_METRIC_LABELS_RENAME: %s is dropped", name)
+		}
+	}
+	m.static, err = parseOtlpHeaders(os.Getenv("FLOW_METRIC_LABELS_STATIC"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid FLOW_METRIC_LABELS_STATIC: %w", err)
+	}
+	for name := range m.static {
+		if err := validMetricLabel(name); err != nil {
+			return nil, fmt.Errorf("invalid FLOW_METRIC_LABELS_STATIC: %w", err)
+		}
+	}
+	if len(m.drop) == 0 && len(m.rename) == 0 && len(m.static) == 0 {
+		return gatherer, nil
+	}
+	return m, nil
+}
+
+func (m *metricRelabeling) Gather() ([]*dto.MetricFamily, error) {
+	families, err := m.gatherer.Gather()
+	for _, family := range families {
+		m.relabel(family)
+	}
+	return families, err
+}
+
+// labels returns the labels of the metric once relabeled, sorted by name
+func (m *metricRelabeling) labels(metric *dto.Metric) []*dto.LabelPair {
+	values := map[string]string{}
+	for _, pair := range metric.Label {
+		if _, renamed := m.rename[pair.GetName()]; !renamed && !m.drop[pair.GetName()] {
+			values[pair.GetName()] = pair.GetValue()
+		}
+	}
+	// a renamed label replaces the label of the metric with its new name
+	for _, pair := range metric.Label {
+		if renamed, ok := m.rename[pair.GetName()]; ok {
+			values[renamed] = pair.GetValue()
+		}
+	}
+	for name, value := range m.static {
+		if values[name] == "" {
+			values[name] = value
+		}
+	}
+	labels := make([]*dto.LabelPair, 0, len(values))
+	for name, value := range values {
+		name, value := name, value
+		labels = append(labels, &dto.LabelPair{Name: &name, Value: &value})
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].GetName() < labels[j].GetName() })
+	return labels
+}
+
+func (m *metricRelabeling) relabel(family *dto.MetricFamily) {
+	metrics := make([]*dto.Metric, 0, len(family.Metric))
+	seen := map[string]*dto.Metric{}
+	for _, metric := range family.Metric {
+		metric.Label = m.labels(metric)
+		var key strings.Builder
+		for _, pair := range metric.Label {
+			key.WriteString(pair.GetName() + "\xff" + pair.GetValue() + "\xff")
+		}
+		if existing, ok := seen[key.String()]; ok {
+			mergeMetric(existing, metric)
+			continue
+		}
+		seen[key.String()] = metric
+		metrics = append(metrics, metric)
+	}
+	family.Metric = metrics
+}
+
+func addValue(to *float64, value float64) *float64 {
+	sum := value
+	if to != nil {
+		sum += *to
+	}
+	return &sum
+}
+
+// mergeMetric adds the values of a series to those of another, the
+// quantiles of the summaries not adding up are left out
+func mergeMetric(to *dto.Metric, from *dto.Metric) {
+	switch {
+	case to.Counter != nil && from.Counter != nil:
+		to.Counter.Value = addValue(to.Counter.Value, from.Counter.GetValue())
+	case to.Gauge != nil && from.Gauge != nil:
+		to.Gauge.Value = addValue(to.Gauge.Value, from.Gauge.GetValue())
+	case to.Untyped != nil && from.Untyped != nil:
+		to.Untyped.Value = addValue(to.Untyped.Value, from.Untyped.GetValue())
+	case to.Histogram != nil && from.Histogram != nil:
+		count := to.Histogram.GetSampleCount() + from.Histogram.GetSampleCount()
+		to.Histogram.SampleCount = &count
+		to.Histogram.SampleSum = addValue(to.Histogram.SampleSum, from.Histogram.GetSampleSum())
+		if len(to.Histogram.Bucket) != len(from.Histogram.Bucket) {
+			return
+		}
+		for i, bucket := range to.Histogram.Bucket {
+			count := bucket.GetCumulativeCount() + from.Histogram.Bucket[i].GetCumulativeCount()
+			bucket.CumulativeCount = &count
+		}
+	case to.Summary != nil && from.Summary != nil:
+		count := to.Summary.GetSampleCount() + from.Summary.GetSampleCount()
+		to.Summary.SampleCount = &count
+		to.Summary.SampleSum = addValue(to.Summary.SampleSum, from.Summary.GetSampleSum())
+		to.Summary.Quantile = nil
+	}
+}
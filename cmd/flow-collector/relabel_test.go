@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestMetricRelabelingFromEnv(t *testing.T) {
+	testTable := []struct {
+		doc      string
+		drop     string
+		rename   string
+		static   string
+		err      string
+		disabled bool
+	}{
+		{doc: "disabled", disabled: true},
+		{doc: "all", drop: "sourceProcess, destProcess", rename: "sourceSite=source_site", static: "cluster=east"},
+		{doc: "invalid drop", drop: "source-process", err: "FLOW_METRIC_LABELS_DROP"},
+		{doc: "invalid rename", rename: "sourceSite=source site", err: "FLOW_METRIC_LABELS_RENAME"},
+		{doc: "renamed and dropped", drop: "sourceSite", rename: "sourceSite=site", err: "sourceSite is dropped"},
+		{doc: "reserved static", static: "__name__=up", err: "FLOW_METRIC_LABELS_STATIC"},
+		{doc: "not key value", static: "cluster", err: "FLOW_METRIC_LABELS_STATIC"},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			t.Setenv("FLOW_METRIC_LABELS_DROP", test.drop)
+			t.Setenv("FLOW_METRIC_LABELS_RENAME", test.rename)
+			t.Setenv("FLOW_METRIC_LABELS_STATIC", test.static)
+			reg := prometheus.NewRegistry()
+			gatherer, err := newMetricRelabelingFromEnv(reg)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			_, relabeled := gatherer.(*metricRelabeling)
+			assert.Equal(t, relabeled, !test.disabled)
+		})
+	}
+}
+
+func TestMetricRelabeling(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	flows := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "flows_total", Help: "Flows"}, []string{"sourceSite", "sourceProcess", "cluster"})
+	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "latency", Help: "Latency", Buckets: []float64{10, 100}}, []string{"sourceProcess"})
+	reg.MustRegister(flows, latency)
+	flows.WithLabelValues("west", "frontend", "").Add(2)
+	flows.WithLabelValues("west", "backend", "").Add(3)
+	flows.WithLabelValues("east", "backend", "other").Add(1)
+	latency.WithLabelValues("frontend").Observe(5)
+	latency.WithLabelValues("backend").Observe(50)
+
+	m := &metricRelabeling{
+		gatherer: reg,
+		drop:     map[string]bool{"sourceProcess": true},
+		rename:   map[string]string{"sourceSite": "source_site"},
+		static:   map[string]string{"cluster": "east", "environment": "prod"},
+	}
+	expected := `
+# HELP flows_total Flows
+# TYPE flows_total counter
+flows_total{cluster="east",environment="prod",source_site="west"} 5
+flows_total{cluster="other",environment="prod",source_site="east"} 1
+# HELP latency Latency
+# TYPE latency histogram
+latency_bucket{cluster="east",environment="prod",le="10"} 1
+latency_bucket{cluster="east",environment="prod",le="100"} 2
+latency_bucket{cluster="east",environment="prod",le="+Inf"} 2
+latency_sum{cluster="east",environment="prod"} 55
+latency_count{cluster="east",environment="prod"} 2
+`
+	assert.Assert(t, testutil.GatherAndCompare(m, strings.NewReader(expected)))
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	remoteWriteDefaultInterval = 30 * time.Second
+	remoteWriteMinInterval     = time.Second
+	remoteWriteTimeout         = 30 * time.Second
+	remoteWriteJob             = "skupper-flow-collector"
+)
+
+type remoteWriteLabel struct {
+	name  string
+	value string
+}
+
+type remoteWriteSeries struct {
+	labels []remoteWriteLabel
+	value  float64
+}
+
+// remoteWriter pushes the metrics of the prometheus registry of the
+// collector with the prometheus remote write protocol, to Cortex, Mimir or
+// Thanos receivers, so that no prometheus server has to scrape the
+// collector
+type remoteWriter struct {
+	url       string
+	headers   map[string]string
+	tokenFile string
+	client    *http.Client
+	gatherer  prometheus.Gatherer
+	instance  string
+	interval  time.Duration
+	// only the active replica pushes its metrics
+	leading func() bool
+}
+
+// newRemoteWriterFromEnv reads FLOW_REMOTE_WRITE_URL, the headers sent
+// along, as in X-Scope-OrgID=tenant, from FLOW_REMOTE_WRITE_HEADERS, the
+// file of a bearer token from FLOW_REMOTE_WRITE_BEARER_TOKEN_FILE, and the
+// period of the pushes from FLOW_REMOTE_WRITE_INTERVAL. It returns nil when
+// no url is set.
+func newRemoteWriterFromEnv(gatherer prometheus.Gatherer, leading func() bool) (*remoteWriter, error) {
+	endpoint := os.Getenv("FLOW_REMOTE_WRITE_URL")
+	if endpoint == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid FLOW_REMOTE_WRITE_URL %q", endpoint)
+	}
+	headers, err := parseOtlpHeaders(os.Getenv("FLOW_REMOTE_WRITE_HEADERS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid FLOW_REMOTE_WRITE_HEADERS: %w", err)
+	}
+	tokenFile := os.Getenv("FLOW_REMOTE_WRITE_BEARER_TOKEN_FILE")
+	if tokenFile != "" {
+		if _, err := os.Stat(tokenFile); err != nil {
+			return nil, fmt.Errorf("invalid FLOW_REMOTE_WRITE_BEARER_TOKEN_FILE: %w", err)
+		}
+	}
+	interval := remoteWriteDefaultInterval
+	if value := os.Getenv("FLOW_REMOTE_WRITE_INTERVAL"); value != "" {
+		interval, err = time.ParseDuration(value)
+		if err != nil || interval < remoteWriteMinInterval {
+			return nil, fmt.Errorf("invalid FLOW_REMOTE_WRITE_INTERVAL %q, expected a duration of at least %s", value, remoteWriteMinInterval)
+		}
+	}
+	instance, _ := os.Hostname()
+	return &remoteWriter{
+		url:       endpoint,
+		headers:   headers,
+		tokenFile: tokenFile,
+		client:    &http.Client{Timeout: remoteWriteTimeout},
+		gatherer:  gatherer,
+		instance:  instance,
+		interval:  interval,
+		leading:   leading,
+	}, nil
+}
+
+// run pushes the metrics on every interval until stopped
+func (w *remoteWriter) run(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.write(time.Now()); err != nil {
+				logger.Errorf("Failed to remote write the metrics: %s", err)
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+func (w *remoteWriter) write(now time.Time) error {
+	if !w.leading() {
+		return nil
+	}
+	families, err := w.gatherer.Gather()
+	if err != nil && len(families) == 0 {
+		return err
+	}
+	series := remoteWriteSeriesFor(families, []remoteWriteLabel{{"instance", w.instance}, {"job", remoteWriteJob}})
+	body := snappy.Encode(nil, encodeWriteRequest(series, now.UnixMilli()))
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for key, value := range w.headers {
+		req.Header.Set(key, value)
+	}
+	if w.tokenFile != "" {
+		// read on every push, as the token may be rotated
+		token, err := os.ReadFile(w.tokenFile)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", w.url, resp.Status)
+	}
+	return nil
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// remoteWriteSeriesFor flattens the gathered metrics into the series a
+// prometheus server would have scraped, with the target labels added
+func remoteWriteSeriesFor(families []*dto.MetricFamily, target []remoteWriteLabel) []remoteWriteSeries {
+	series := []remoteWriteSeries{}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			add := func(name string, value float64, extra ...remoteWriteLabel) {
+				labels := []remoteWriteLabel{{"__name__", name}}
+				labels = append(labels, target...)
+				for _, label := range m.GetLabel() {
+					labels = append(labels, remoteWriteLabel{label.GetName(), label.GetValue()})
+				}
+				labels = append(labels, extra...)
+				sort.Slice(labels, func(i, j int) bool {
+					return labels[i].name < labels[j].name
+				})
+				series = append(series, remoteWriteSeries{labels: labels, value: value})
+			}
+			name := family.GetName()
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				add(name, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add(name, m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				add(name, m.GetUntyped().GetValue())
+			case dto.MetricType_HISTOGRAM:
+				histogram := m.GetHistogram()
+				for _, bucket := range histogram.GetBucket() {
+					if math.IsInf(bucket.GetUpperBound(), 1) {
+						continue
+					}
+					add(name+"_bucket", float64(bucket.GetCumulativeCount()), remoteWriteLabel{"le", formatFloat(bucket.GetUpperBound())})
+				}
+				add(name+"_bucket", float64(histogram.GetSampleCount()), remoteWriteLabel{"le", "+Inf"})
+				add(name+"_sum", histogram.GetSampleSum())
+				add(name+"_count", float64(histogram.GetSampleCount()))
+			case dto.MetricType_SUMMARY:
+				summary := m.GetSummary()
+				for _, quantile := range summary.GetQuantile() {
+					add(name, quantile.GetValue(), remoteWriteLabel{"quantile", formatFloat(quantile.GetQuantile())})
+				}
+				add(name+"_sum", summary.GetSampleSum())
+				add(name+"_count", float64(summary.GetSampleCount()))
+			}
+		}
+	}
+	return series
+}
+
+// encodeWriteRequest encodes the prometheus.WriteRequest protobuf message,
+// whose messages are few enough to be written field by field rather than
+// through the modules of the prometheus server:
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(series []remoteWriteSeries, timestamp int64) []byte {
+	var request []byte
+	for _, s := range series {
+		var timeSeries []byte
+		for _, label := range s.labels {
+			var l []byte
+			l = protowire.AppendTag(l, 1, protowire.BytesType)
+			l = protowire.AppendString(l, label.name)
+			l = protowire.AppendTag(l, 2, protowire.BytesType)
+			l = protowire.AppendString(l, label.value)
+			timeSeries = protowire.AppendTag(timeSeries, 1, protowire.BytesType)
+			timeSeries = protowire.AppendBytes(timeSeries, l)
+		}
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(s.value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(timestamp))
+		timeSeries = protowire.AppendTag(timeSeries, 2, protowire.BytesType)
+		timeSeries = protowire.AppendBytes(timeSeries, sample)
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, timeSeries)
+	}
+	return request
+}
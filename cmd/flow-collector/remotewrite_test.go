@@ -0,0 +1,148 @@
+package main
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protowire"
+	"gotest.tools/assert"
+)
+
+// decodeFields returns the fields of a protobuf message by number, the
+// fixed and varint values being returned as uint64
+func decodeFields(t *testing.T, data []byte) map[protowire.Number][]interface{} {
+	fields := map[protowire.Number][]interface{}{}
+	for len(data) > 0 {
+		number, kind, n := protowire.ConsumeTag(data)
+		assert.Assert(t, n > 0)
+		data = data[n:]
+		switch kind {
+		case protowire.BytesType:
+			value, n := protowire.ConsumeBytes(data)
+			assert.Assert(t, n > 0)
+			fields[number] = append(fields[number], value)
+			data = data[n:]
+		case protowire.Fixed64Type:
+			value, n := protowire.ConsumeFixed64(data)
+			assert.Assert(t, n > 0)
+			fields[number] = append(fields[number], value)
+			data = data[n:]
+		case protowire.VarintType:
+			value, n := protowire.ConsumeVarint(data)
+			assert.Assert(t, n > 0)
+			fields[number] = append(fields[number], value)
+			data = data[n:]
+		default:
+			t.Fatalf("unexpected wire type %d", kind)
+		}
+	}
+	return fields
+}
+
+// decodeWriteRequest returns the samples of the request by the labels of
+// their series, along with their timestamps
+func decodeWriteRequest(t *testing.T, data []byte) (map[string]float64, []int64) {
+	samples := map[string]float64{}
+	timestamps := []int64{}
+	for _, timeSeries := range decodeFields(t, data)[1] {
+		fields := decodeFields(t, timeSeries.([]byte))
+		key := ""
+		for _, label := range fields[1] {
+			l := decodeFields(t, label.([]byte))
+			key += string(l[1][0].([]byte)) + "=" + string(l[2][0].([]byte)) + ";"
+		}
+		sample := decodeFields(t, fields[2][0].([]byte))
+		samples[key] = math.Float64frombits(sample[1][0].(uint64))
+		timestamps = append(timestamps, int64(sample[2][0].(uint64)))
+	}
+	return samples, timestamps
+}
+
+func TestRemoteWrite(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "flows_total"}, []string{"address"})
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "flow_latency_seconds", Buckets: []float64{0.5}})
+	reg.MustRegister(counter, histogram)
+	counter.WithLabelValues("backend").Add(3)
+	histogram.Observe(0.1)
+	histogram.Observe(2)
+
+	posted := make(chan []byte, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Header.Get("Content-Encoding"), "snappy")
+		assert.Equal(t, r.Header.Get("Content-Type"), "application/x-protobuf")
+		assert.Equal(t, r.Header.Get("X-Prometheus-Remote-Write-Version"), "0.1.0")
+		assert.Equal(t, r.Header.Get("X-Scope-OrgID"), "tenant")
+		assert.Equal(t, r.Header.Get("Authorization"), "Bearer token")
+		body, err := io.ReadAll(r.Body)
+		assert.Assert(t, err)
+		data, err := snappy.Decode(nil, body)
+		assert.Assert(t, err)
+		posted <- data
+	}))
+	defer receiver.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.Assert(t, os.WriteFile(tokenFile, []byte("token\n"), 0600))
+	t.Setenv("FLOW_REMOTE_WRITE_URL", receiver.URL+"/api/v1/push")
+	t.Setenv("FLOW_REMOTE_WRITE_HEADERS", "X-Scope-OrgID=tenant")
+	t.Setenv("FLOW_REMOTE_WRITE_BEARER_TOKEN_FILE", tokenFile)
+	leading := false
+	w, err := newRemoteWriterFromEnv(reg, func() bool { return leading })
+	assert.Assert(t, err)
+	w.instance = "collector-0"
+
+	// the standby replicas do not push
+	assert.Assert(t, w.write(time.UnixMilli(1000)))
+	assert.Equal(t, len(posted), 0)
+
+	leading = true
+	assert.Assert(t, w.write(time.UnixMilli(1000)))
+	samples, timestamps := decodeWriteRequest(t, <-posted)
+	target := "instance=collector-0;job=skupper-flow-collector;"
+	assert.DeepEqual(t, samples, map[string]float64{
+		"__name__=flows_total;address=backend;" + target:              3,
+		"__name__=flow_latency_seconds_bucket;" + target + "le=0.5;":  1,
+		"__name__=flow_latency_seconds_bucket;" + target + "le=+Inf;": 2,
+		"__name__=flow_latency_seconds_sum;" + target:                 2.1,
+		"__name__=flow_latency_seconds_count;" + target:               2,
+	})
+	for _, timestamp := range timestamps {
+		assert.Equal(t, timestamp, int64(1000))
+	}
+}
+
+func TestRemoteWriterFromEnv(t *testing.T) {
+	var tests = []struct {
+		doc      string
+		url      string
+		interval string
+		err      string
+	}{
+		{doc: "not configured"},
+		{doc: "configured", url: "https://mimir.example.com/api/v1/push", interval: "1m"},
+		{doc: "bad url", url: "mimir.example.com", err: "invalid FLOW_REMOTE_WRITE_URL"},
+		{doc: "bad interval", url: "https://mimir.example.com/api/v1/push", interval: "1ms", err: "invalid FLOW_REMOTE_WRITE_INTERVAL"},
+	}
+	for _, test := range tests {
+		t.Run(test.doc, func(t *testing.T) {
+			t.Setenv("FLOW_REMOTE_WRITE_URL", test.url)
+			t.Setenv("FLOW_REMOTE_WRITE_INTERVAL", test.interval)
+			w, err := newRemoteWriterFromEnv(prometheus.NewRegistry(), nil)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			assert.Equal(t, w != nil, test.url != "")
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	roleViewer = "viewer"
+	roleAdmin  = "admin"
+
+	// rolesFile in the users directory assigns the roles by user name, it
+	// is never accepted as a user
+	rolesFile       = ".roles"
+	rbacReviewCache = time.Minute
+)
+
+// roles is set when role based access is enabled, every authenticated user
+// is an admin otherwise
+var roles *roleAuthorizer
+
+type rbacDecision struct {
+	admin   bool
+	expires time.Time
+}
+
+// roleAuthorizer assigns a role to the authenticated users, from the roles
+// file of the users directory, then from the roles claim of the openid
+// token, then from the kubernetes rbac permissions of the user
+type roleAuthorizer struct {
+	users       map[string]string
+	claim       string
+	review      func(ctx context.Context, user string) (bool, error)
+	defaultRole string
+
+	lock     sync.Mutex
+	reviewed map[string]rbacDecision
+}
+
+func validRole(role string) bool {
+	return role == roleViewer || role == roleAdmin
+}
+
+func newRoleAuthorizerFromEnv(kubeClient kubernetes.Interface, namespace string) (*roleAuthorizer, error) {
+	users, err := readRolesFile(os.Getenv("FLOW_USERS"))
+	if err != nil {
+		return nil, err
+	}
+	rbac := false
+	if value := os.Getenv("FLOW_RBAC_ROLES"); value != "" {
+		rbac, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FLOW_RBAC_ROLES %q", value)
+		}
+	}
+	defaultRole := os.Getenv("FLOW_DEFAULT_ROLE")
+	claim := os.Getenv("FLOW_OIDC_ROLES_CLAIM")
+	if users == nil && claim == "" && !rbac && defaultRole == "" {
+		return nil, nil
+	}
+	if defaultRole == "" {
+		defaultRole = roleViewer
+	} else if !validRole(defaultRole) {
+		return nil, fmt.Errorf("invalid FLOW_DEFAULT_ROLE %q", defaultRole)
+	}
+	a := &roleAuthorizer{
+		users:       users,
+		claim:       claim,
+		defaultRole: defaultRole,
+		reviewed:    map[string]rbacDecision{},
+	}
+	if rbac {
+		if kubeClient == nil {
+			return nil, fmt.Errorf("kubernetes rbac roles are only supported on kubernetes")
+		}
+		a.review = siteAdminReview(kubeClient, namespace)
+	}
+	return a, nil
+}
+
+// readRolesFile parses the user=role lines of the roles file of the users
+// directory, it returns nil when there is no such file
+func readRolesFile(dir string) (map[string]string, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	file, err := os.Open(path.Join(dir, rolesFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	users := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		user, role, ok := strings.Cut(text, "=")
+		user, role = strings.TrimSpace(user), strings.TrimSpace(role)
+		if !ok || user == "" || !validRole(role) {
+			return nil, fmt.Errorf("invalid role assignment on line %d of %s", line, rolesFile)
+		}
+		users[user] = role
+	}
+	return users, scanner.Err()
+}
+
+// siteAdminReview asks the api server whether the user may update the site
+// configuration, which is what makes a user an admin of the site
+func siteAdminReview(kubeClient kubernetes.Interface, namespace string) func(context.Context, string) (bool, error) {
+	return func(ctx context.Context, user string) (bool, error) {
+		review := &authorizationv1.LocalSubjectAccessReview{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace},
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User: user,
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: namespace,
+					Verb:      "update",
+					Resource:  "configmaps",
+					Name:      types.SiteConfigMapName,
+				},
+			},
+		}
+		result, err := kubeClient.AuthorizationV1().LocalSubjectAccessReviews(namespace).Create(ctx, review, metav1.CreateOptions{})
+		if err != nil {
+			return false, err
+		}
+		return result.Status.Allowed, nil
+	}
+}
+
+func claimRole(value interface{}) string {
+	var values []string
+	switch value := value.(type) {
+	case string:
+		values = strings.Fields(value)
+	case []interface{}:
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	role := ""
+	for _, v := range values {
+		if v == roleAdmin {
+			return roleAdmin
+		} else if v == roleViewer {
+			role = roleViewer
+		}
+	}
+	return role
+}
+
+func (a *roleAuthorizer) reviewAdmin(ctx context.Context, user string) (bool, error) {
+	now := time.Now()
+	a.lock.Lock()
+	decision, ok := a.reviewed[user]
+	a.lock.Unlock()
+	if ok && now.Before(decision.expires) {
+		return decision.admin, nil
+	}
+	admin, err := a.review(ctx, user)
+	if err != nil {
+		return false, err
+	}
+	a.lock.Lock()
+	a.reviewed[user] = rbacDecision{admin: admin, expires: now.Add(rbacReviewCache)}
+	a.lock.Unlock()
+	return admin, nil
+}
+
+// role returns the role of the authenticated user of the request
+func (a *roleAuthorizer) role(r *http.Request, user UserResponse) string {
+	if a == nil {
+		return roleAdmin
+	}
+	if role, ok := a.users[user.Username]; ok && user.Username != "" {
+		return role
+	}
+	if a.claim != "" {
+		if claims, ok := r.Context().Value(openIDClaimsKey{}).(map[string]interface{}); ok {
+			if role := claimRole(claims[a.claim]); role != "" {
+				return role
+			}
+		}
+	}
+	if a.review != nil && user.Username != "" {
+		admin, err := a.reviewAdmin(r.Context(), user.Username)
+		if err != nil {
+			logger.Errorf("Failed to review the permissions of %s: %s", user.Username, err)
+		} else if admin {
+			return roleAdmin
+		} else {
+			return roleViewer
+		}
+	}
+	return a.defaultRole
+}
+
+// requireRole restricts the handler to the users holding the role, admins
+// hold every role
+func requireRole(role string, getUser func(*http.Request) UserResponse, h http.HandlerFunc) http.HandlerFunc {
+	if roles == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		held := roles.role(r, getUser(r))
+		if held != role && held != roleAdmin {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
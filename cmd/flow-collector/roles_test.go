@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"gotest.tools/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestReadRolesFile(t *testing.T) {
+	testTable := []struct {
+		name     string
+		content  string
+		expected map[string]string
+		err      string
+	}{
+		{
+			name:     "assignments",
+			content:  "# console roles\nalice=admin\n\n bob = viewer \n",
+			expected: map[string]string{"alice": "admin", "bob": "viewer"},
+		},
+		{
+			name:    "unknown-role",
+			content: "alice=admin\nbob=root\n",
+			err:     "invalid role assignment on line 2",
+		},
+		{
+			name:    "no-role",
+			content: "alice\n",
+			err:     "invalid role assignment on line 1",
+		},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			assert.Assert(t, os.WriteFile(path.Join(dir, rolesFile), []byte(test.content), 0600))
+			users, err := readRolesFile(dir)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			assert.DeepEqual(t, users, test.expected)
+		})
+	}
+
+	users, err := readRolesFile(t.TempDir())
+	assert.Assert(t, err)
+	assert.Assert(t, users == nil)
+}
+
+func TestRoleAuthorizerRole(t *testing.T) {
+	review := func(ctx context.Context, user string) (bool, error) {
+		switch user {
+		case "kube-admin":
+			return true, nil
+		case "kube-broken":
+			return false, fmt.Errorf("api server unavailable")
+		}
+		return false, nil
+	}
+	testTable := []struct {
+		name       string
+		authorizer *roleAuthorizer
+		user       string
+		claims     map[string]interface{}
+		expected   string
+	}{
+		{
+			name:     "roles-disabled",
+			user:     "alice",
+			expected: roleAdmin,
+		},
+		{
+			name:       "users-file",
+			authorizer: &roleAuthorizer{users: map[string]string{"alice": roleAdmin}, defaultRole: roleViewer},
+			user:       "alice",
+			expected:   roleAdmin,
+		},
+		{
+			name:       "default",
+			authorizer: &roleAuthorizer{users: map[string]string{"alice": roleAdmin}, defaultRole: roleViewer},
+			user:       "bob",
+			expected:   roleViewer,
+		},
+		{
+			name:       "claim-list",
+			authorizer: &roleAuthorizer{claim: "roles", defaultRole: roleViewer},
+			user:       "carol",
+			claims:     map[string]interface{}{"roles": []interface{}{"auditor", "admin"}},
+			expected:   roleAdmin,
+		},
+		{
+			name:       "claim-string",
+			authorizer: &roleAuthorizer{claim: "roles", defaultRole: roleAdmin},
+			user:       "carol",
+			claims:     map[string]interface{}{"roles": "viewer auditor"},
+			expected:   roleViewer,
+		},
+		{
+			name:       "claim-missing",
+			authorizer: &roleAuthorizer{claim: "roles", defaultRole: roleViewer},
+			user:       "carol",
+			claims:     map[string]interface{}{"groups": []interface{}{"admin"}},
+			expected:   roleViewer,
+		},
+		{
+			name:       "rbac-admin",
+			authorizer: &roleAuthorizer{review: review, defaultRole: roleViewer},
+			user:       "kube-admin",
+			expected:   roleAdmin,
+		},
+		{
+			name:       "rbac-viewer",
+			authorizer: &roleAuthorizer{review: review, defaultRole: roleAdmin},
+			user:       "kube-user",
+			expected:   roleViewer,
+		},
+		{
+			name:       "rbac-error",
+			authorizer: &roleAuthorizer{review: review, defaultRole: roleViewer},
+			user:       "kube-broken",
+			expected:   roleViewer,
+		},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			if test.authorizer != nil {
+				test.authorizer.reviewed = map[string]rbacDecision{}
+			}
+			r := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/user/", nil)
+			if test.claims != nil {
+				r = r.WithContext(context.WithValue(r.Context(), openIDClaimsKey{}, test.claims))
+			}
+			assert.Equal(t, test.authorizer.role(r, UserResponse{Username: test.user}), test.expected)
+		})
+	}
+}
+
+func TestRoleAuthorizerFromEnv(t *testing.T) {
+	t.Setenv("FLOW_USERS", "")
+	t.Setenv("FLOW_OIDC_ROLES_CLAIM", "")
+	t.Setenv("FLOW_DEFAULT_ROLE", "")
+	t.Setenv("FLOW_RBAC_ROLES", "")
+	authorizer, err := newRoleAuthorizerFromEnv(nil, "test")
+	assert.Assert(t, err)
+	assert.Assert(t, authorizer == nil)
+
+	t.Setenv("FLOW_DEFAULT_ROLE", "root")
+	_, err = newRoleAuthorizerFromEnv(nil, "test")
+	assert.ErrorContains(t, err, "invalid FLOW_DEFAULT_ROLE")
+
+	t.Setenv("FLOW_DEFAULT_ROLE", "")
+	t.Setenv("FLOW_RBAC_ROLES", "true")
+	_, err = newRoleAuthorizerFromEnv(nil, "test")
+	assert.ErrorContains(t, err, "only supported on kubernetes")
+
+	reviews := 0
+	kubeClient := fake.NewSimpleClientset()
+	kubeClient.Fake.PrependReactor("create", "localsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		reviews++
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.LocalSubjectAccessReview)
+		assert.Equal(t, review.Spec.ResourceAttributes.Namespace, "test")
+		assert.Equal(t, review.Spec.ResourceAttributes.Verb, "update")
+		review.Status.Allowed = review.Spec.User == "kube-admin"
+		return true, review, nil
+	})
+	authorizer, err = newRoleAuthorizerFromEnv(kubeClient, "test")
+	assert.Assert(t, err)
+	assert.Equal(t, authorizer.defaultRole, roleViewer)
+	r := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/user/", nil)
+	assert.Equal(t, authorizer.role(r, UserResponse{Username: "kube-admin"}), roleAdmin)
+	assert.Equal(t, authorizer.role(r, UserResponse{Username: "kube-admin"}), roleAdmin)
+	assert.Equal(t, authorizer.role(r, UserResponse{Username: "kube-user"}), roleViewer)
+	// decisions are cached
+	assert.Equal(t, reviews, 2)
+}
+
+func TestRequireRole(t *testing.T) {
+	defer func() { roles = nil }()
+	getUser := func(r *http.Request) UserResponse {
+		user, _, _ := r.BasicAuth()
+		return UserResponse{Username: user}
+	}
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+	testTable := []struct {
+		name     string
+		roles    *roleAuthorizer
+		user     string
+		role     string
+		expected int
+	}{
+		{name: "roles-disabled", user: "bob", role: roleAdmin, expected: http.StatusOK},
+		{name: "admin", roles: &roleAuthorizer{users: map[string]string{"alice": roleAdmin}, defaultRole: roleViewer}, user: "alice", role: roleAdmin, expected: http.StatusOK},
+		{name: "admin-as-viewer", roles: &roleAuthorizer{users: map[string]string{"alice": roleAdmin}, defaultRole: roleViewer}, user: "alice", role: roleViewer, expected: http.StatusOK},
+		{name: "viewer", roles: &roleAuthorizer{users: map[string]string{"alice": roleAdmin}, defaultRole: roleViewer}, user: "bob", role: roleViewer, expected: http.StatusOK},
+		{name: "viewer-forbidden", roles: &roleAuthorizer{users: map[string]string{"alice": roleAdmin}, defaultRole: roleViewer}, user: "bob", role: roleAdmin, expected: http.StatusForbidden},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			roles = test.roles
+			r := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/internal/prom/query/", nil)
+			r.SetBasicAuth(test.user, "password")
+			rec := httptest.NewRecorder()
+			requireRole(test.role, getUser, ok).ServeHTTP(rec, r)
+			assert.Equal(t, rec.Code, test.expected)
+		})
+	}
+}
+
+func TestAuthenticateRejectsRolesFile(t *testing.T) {
+	dir := t.TempDir()
+	assert.Assert(t, os.WriteFile(path.Join(dir, rolesFile), []byte("alice=admin"), 0600))
+	assert.Assert(t, !authenticate(dir, rolesFile, "alice=admin"))
+}
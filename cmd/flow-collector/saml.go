@@ -0,0 +1,551 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/skupperproject/skupper/api/types"
+)
+
+const (
+	samlProtocolNamespace  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	samlAssertionNamespace = "urn:oasis:names:tc:SAML:2.0:assertion"
+	samlMetadataNamespace  = "urn:oasis:names:tc:SAML:2.0:metadata"
+	samlRedirectBinding    = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	samlPostBinding        = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	samlBearerMethod       = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	samlStatusSuccess      = "urn:oasis:names:tc:SAML:2.0:status:Success"
+
+	samlSessionCookie     = "skupper-saml-session"
+	samlRequestCookie     = "skupper-saml-request"
+	samlSessionTtl        = 8 * time.Hour
+	samlClockSkew         = time.Minute
+	samlRequestTtl        = 10 * time.Minute
+	samlMaxResponseLength = 1 << 20
+)
+
+// samlAuth is set when the console is secured with a SAML identity
+// provider, authenticated then requires the session cookie issued once the
+// provider has posted a valid assertion
+var samlAuth *samlServiceProvider
+
+type samlUserKey struct{}
+
+type samlIdentityProvider struct {
+	entityID string
+	ssoURL   string
+	certs    []*x509.Certificate
+}
+
+type samlSession struct {
+	User    string `json:"u"`
+	Expires int64  `json:"e"`
+}
+
+// samlRequest is the login pending in the browser, kept in a signed cookie
+// so that any replica sharing the session key can consume the response
+type samlRequest struct {
+	Id      string `json:"i"`
+	Expires int64  `json:"e"`
+}
+
+// samlServiceProvider sends the browser to the identity provider with an
+// AuthnRequest and consumes the signed response posted back, only responses
+// to the request pending in the same browser are accepted and each
+// assertion is accepted once by a collector
+type samlServiceProvider struct {
+	entityID          string
+	acsURL            string
+	usernameAttribute string
+	secure            bool
+	idp               *samlIdentityProvider
+	sessionKey        []byte
+	now               func() time.Time
+
+	lock       sync.Mutex
+	assertions map[string]time.Time
+}
+
+func newSamlServiceProvider(metadataSource string, consoleURL string, usernameAttribute string) (*samlServiceProvider, error) {
+	base, err := url.Parse(consoleURL)
+	if err != nil || (base.Scheme != "https" && base.Scheme != "http") || base.Host == "" {
+		return nil, fmt.Errorf("invalid console url %q", consoleURL)
+	}
+	metadata, err := readSamlMetadata(metadataSource)
+	if err != nil {
+		return nil, err
+	}
+	idp, err := parseSamlMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+	sessionKey, err := sessionKeyFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	prefix := strings.TrimSuffix(consoleURL, "/") + "/api/v1alpha1/saml"
+	return &samlServiceProvider{
+		entityID:          prefix + "/metadata",
+		acsURL:            prefix + "/acs",
+		usernameAttribute: usernameAttribute,
+		secure:            base.Scheme == "https",
+		idp:               idp,
+		sessionKey:        sessionKey,
+		now:               time.Now,
+		assertions:        map[string]time.Time{},
+	}, nil
+}
+
+// readSamlMetadata loads the identity provider metadata from a url or a file
+func readSamlMetadata(source string) ([]byte, error) {
+	if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+		client := &http.Client{Timeout: 10 * time.Second}
+		resp, err := client.Get(source)
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch saml metadata: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unable to fetch saml metadata: %s returned %s", source, resp.Status)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, samlMaxResponseLength))
+	}
+	return os.ReadFile(source)
+}
+
+func parseSamlMetadata(data []byte) (*samlIdentityProvider, error) {
+	root, err := parseXml(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid saml metadata: %w", err)
+	}
+	descriptors := []*etree.Element{root}
+	if xmlIs(root, samlMetadataNamespace, "EntitiesDescriptor") {
+		descriptors = xmlChildElements(root, samlMetadataNamespace, "EntityDescriptor")
+	}
+	for _, descriptor := range descriptors {
+		sso := xmlChild(descriptor, samlMetadataNamespace, "IDPSSODescriptor")
+		if !xmlIs(descriptor, samlMetadataNamespace, "EntityDescriptor") || sso == nil {
+			continue
+		}
+		idp := &samlIdentityProvider{entityID: xmlAttr(descriptor, "entityID")}
+		for _, service := range xmlChildElements(sso, samlMetadataNamespace, "SingleSignOnService") {
+			if xmlAttr(service, "Binding") == samlRedirectBinding {
+				idp.ssoURL = xmlAttr(service, "Location")
+				break
+			}
+		}
+		for _, key := range xmlChildElements(sso, samlMetadataNamespace, "KeyDescriptor") {
+			if use := xmlAttr(key, "use"); use != "" && use != "signing" {
+				continue
+			}
+			keyInfo := xmlChild(key, xmldsigNamespace, "KeyInfo")
+			if keyInfo == nil {
+				continue
+			}
+			for _, data := range xmlChildElements(keyInfo, xmldsigNamespace, "X509Data") {
+				for _, encoded := range xmlChildElements(data, xmldsigNamespace, "X509Certificate") {
+					der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(xmlText(encoded)), ""))
+					if err != nil {
+						return nil, fmt.Errorf("invalid certificate in saml metadata: %w", err)
+					}
+					cert, err := x509.ParseCertificate(der)
+					if err != nil {
+						return nil, fmt.Errorf("invalid certificate in saml metadata: %w", err)
+					}
+					idp.certs = append(idp.certs, cert)
+				}
+			}
+		}
+		if idp.entityID == "" || idp.ssoURL == "" || len(idp.certs) == 0 {
+			return nil, fmt.Errorf("saml metadata must have an entity id, a redirect single sign-on service and a signing certificate")
+		}
+		return idp, nil
+	}
+	return nil, fmt.Errorf("saml metadata has no identity provider descriptor")
+}
+
+func escapeXml(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+func samlId() (string, error) {
+	id := make([]byte, 20)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	// ids must be xml names so they cannot start with a digit
+	return "_" + hex.EncodeToString(id), nil
+}
+
+// relayState only lets the browser return to a path of the console
+func relayState(target string) string {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return "/"
+	}
+	return target
+}
+
+func (sp *samlServiceProvider) pruneLocked(now time.Time) {
+	for id, expires := range sp.assertions {
+		if now.After(expires) {
+			delete(sp.assertions, id)
+		}
+	}
+}
+
+func (sp *samlServiceProvider) authnRequest(id string, now time.Time) string {
+	return fmt.Sprintf(`<samlp:AuthnRequest xmlns:samlp="%s" xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s" Destination="%s" AssertionConsumerServiceURL="%s" ProtocolBinding="%s">`+
+		`<saml:Issuer>%s</saml:Issuer><samlp:NameIDPolicy AllowCreate="true"/></samlp:AuthnRequest>`,
+		samlProtocolNamespace, samlAssertionNamespace, id, now.UTC().Format(time.RFC3339),
+		escapeXml(sp.idp.ssoURL), escapeXml(sp.acsURL), samlPostBinding, escapeXml(sp.entityID))
+}
+
+// login redirects the browser to the identity provider
+func (sp *samlServiceProvider) login(w http.ResponseWriter, r *http.Request) {
+	id, err := samlId()
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	now := sp.now()
+	expires := now.Add(samlRequestTtl)
+	http.SetCookie(w, sp.requestCookie(samlRequest{Id: id, Expires: expires.Unix()}, expires))
+
+	var deflated bytes.Buffer
+	writer, _ := flate.NewWriter(&deflated, flate.DefaultCompression)
+	writer.Write([]byte(sp.authnRequest(id, now)))
+	writer.Close()
+	query := url.Values{}
+	query.Set("SAMLRequest", base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	query.Set("RelayState", relayState(r.URL.Query().Get("return")))
+	separator := "?"
+	if strings.Contains(sp.idp.ssoURL, "?") {
+		separator = "&"
+	}
+	http.Redirect(w, r, sp.idp.ssoURL+separator+query.Encode(), http.StatusFound)
+}
+
+func samlTime(el *etree.Element, name string) (time.Time, bool, error) {
+	value := xmlAttr(el, name)
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return t, true, nil
+}
+
+func (sp *samlServiceProvider) checkIssuer(el *etree.Element, required bool) error {
+	issuer := xmlChild(el, samlAssertionNamespace, "Issuer")
+	if issuer == nil {
+		if required {
+			return fmt.Errorf("%s has no issuer", el.Tag)
+		}
+		return nil
+	}
+	if xmlText(issuer) != sp.idp.entityID {
+		return fmt.Errorf("%s issued by %q, expected %q", el.Tag, xmlText(issuer), sp.idp.entityID)
+	}
+	return nil
+}
+
+// consume validates a SAMLResponse to the pending request, returning the
+// username and when the session it starts must end
+func (sp *samlServiceProvider) consume(encoded string, requestId string) (string, time.Time, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(encoded), ""))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid encoding")
+	}
+	response, err := parseXml(data)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if !xmlIs(response, samlProtocolNamespace, "Response") {
+		return "", time.Time{}, fmt.Errorf("not a saml 2.0 response")
+	}
+	now := sp.now()
+	// from here on only the content covered by a signature is looked at
+	signedResponse, err := verifyEnvelopedSignature(response, sp.idp.certs, now)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid response signature: %w", err)
+	}
+	if signedResponse != nil {
+		response = signedResponse
+	}
+	if xmlAttr(response, "Version") != "2.0" {
+		return "", time.Time{}, fmt.Errorf("not a saml 2.0 response")
+	}
+	if destination := xmlAttr(response, "Destination"); destination != "" && destination != sp.acsURL {
+		return "", time.Time{}, fmt.Errorf("response sent to %q", destination)
+	}
+	if requestId == "" || xmlAttr(response, "InResponseTo") != requestId {
+		return "", time.Time{}, fmt.Errorf("response does not match a pending login")
+	}
+	if status := xmlChild(response, samlProtocolNamespace, "Status"); status == nil || xmlChild(status, samlProtocolNamespace, "StatusCode") == nil ||
+		xmlAttr(xmlChild(status, samlProtocolNamespace, "StatusCode"), "Value") != samlStatusSuccess {
+		return "", time.Time{}, fmt.Errorf("login was not successful")
+	}
+	if err := sp.checkIssuer(response, false); err != nil {
+		return "", time.Time{}, err
+	}
+	if len(xmlChildElements(response, samlAssertionNamespace, "EncryptedAssertion")) > 0 {
+		return "", time.Time{}, fmt.Errorf("encrypted assertions are not supported")
+	}
+	assertion := xmlChild(response, samlAssertionNamespace, "Assertion")
+	if assertion == nil {
+		return "", time.Time{}, fmt.Errorf("response must have a single assertion")
+	}
+	signedAssertion, err := verifyEnvelopedSignature(assertion, sp.idp.certs, now)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid assertion signature: %w", err)
+	}
+	if signedAssertion != nil {
+		assertion = signedAssertion
+	} else if signedResponse == nil {
+		return "", time.Time{}, fmt.Errorf("response is not signed")
+	}
+	if err := sp.checkIssuer(assertion, true); err != nil {
+		return "", time.Time{}, err
+	}
+	subject := xmlChild(assertion, samlAssertionNamespace, "Subject")
+	if subject == nil {
+		return "", time.Time{}, fmt.Errorf("assertion has no subject")
+	}
+	var assertionExpires time.Time
+	for _, confirmation := range xmlChildElements(subject, samlAssertionNamespace, "SubjectConfirmation") {
+		data := xmlChild(confirmation, samlAssertionNamespace, "SubjectConfirmationData")
+		if xmlAttr(confirmation, "Method") != samlBearerMethod || data == nil || xmlAttr(data, "Recipient") != sp.acsURL {
+			continue
+		}
+		if inResponseTo := xmlAttr(data, "InResponseTo"); inResponseTo != "" && inResponseTo != requestId {
+			continue
+		}
+		notOnOrAfter, ok, err := samlTime(data, "NotOnOrAfter")
+		if err != nil || !ok || !now.Add(-samlClockSkew).Before(notOnOrAfter) {
+			continue
+		}
+		assertionExpires = notOnOrAfter
+		break
+	}
+	if assertionExpires.IsZero() {
+		return "", time.Time{}, fmt.Errorf("assertion has no valid bearer confirmation")
+	}
+	conditions := xmlChild(assertion, samlAssertionNamespace, "Conditions")
+	if conditions == nil {
+		return "", time.Time{}, fmt.Errorf("assertion has no conditions")
+	}
+	if notBefore, ok, err := samlTime(conditions, "NotBefore"); err != nil || (ok && now.Add(samlClockSkew).Before(notBefore)) {
+		return "", time.Time{}, fmt.Errorf("assertion not valid yet")
+	}
+	if notOnOrAfter, ok, err := samlTime(conditions, "NotOnOrAfter"); err != nil || (ok && !now.Add(-samlClockSkew).Before(notOnOrAfter)) {
+		return "", time.Time{}, fmt.Errorf("assertion expired")
+	}
+	restrictions := xmlChildElements(conditions, samlAssertionNamespace, "AudienceRestriction")
+	if len(restrictions) == 0 {
+		return "", time.Time{}, fmt.Errorf("assertion has no audience restriction")
+	}
+	for _, restriction := range restrictions {
+		found := false
+		for _, audience := range xmlChildElements(restriction, samlAssertionNamespace, "Audience") {
+			found = found || xmlText(audience) == sp.entityID
+		}
+		if !found {
+			return "", time.Time{}, fmt.Errorf("assertion is not intended for %s", sp.entityID)
+		}
+	}
+
+	assertionId := xmlAttr(assertion, "ID")
+	if assertionId == "" {
+		return "", time.Time{}, fmt.Errorf("assertion has no id")
+	}
+	sp.lock.Lock()
+	sp.pruneLocked(now)
+	_, replayed := sp.assertions[assertionId]
+	if !replayed {
+		sp.assertions[assertionId] = assertionExpires.Add(samlClockSkew)
+	}
+	sp.lock.Unlock()
+	if replayed {
+		return "", time.Time{}, fmt.Errorf("assertion %q was already used", assertionId)
+	}
+
+	username := ""
+	if nameId := xmlChild(subject, samlAssertionNamespace, "NameID"); nameId != nil {
+		username = xmlText(nameId)
+	}
+	if sp.usernameAttribute != "" {
+		username = ""
+		for _, statement := range xmlChildElements(assertion, samlAssertionNamespace, "AttributeStatement") {
+			for _, attribute := range xmlChildElements(statement, samlAssertionNamespace, "Attribute") {
+				if xmlAttr(attribute, "Name") != sp.usernameAttribute && xmlAttr(attribute, "FriendlyName") != sp.usernameAttribute {
+					continue
+				}
+				if values := xmlChildElements(attribute, samlAssertionNamespace, "AttributeValue"); len(values) > 0 && username == "" {
+					username = xmlText(values[0])
+				}
+			}
+		}
+	}
+	if username == "" {
+		return "", time.Time{}, fmt.Errorf("assertion has no username")
+	}
+	sessionExpires := now.Add(samlSessionTtl)
+	if statement := xmlChild(assertion, samlAssertionNamespace, "AuthnStatement"); statement != nil {
+		if sessionNotOnOrAfter, ok, err := samlTime(statement, "SessionNotOnOrAfter"); err == nil && ok && sessionNotOnOrAfter.Before(sessionExpires) {
+			sessionExpires = sessionNotOnOrAfter
+		}
+	}
+	return username, sessionExpires, nil
+}
+
+func (sp *samlServiceProvider) sign(payload string) string {
+	mac := hmac.New(sha256.New, sp.sessionKey)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// cookie signs the value, the cookie is cleared when value is nil
+func (sp *samlServiceProvider) cookie(name string, value interface{}, expires time.Time, sameSite http.SameSite) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   sp.secure,
+		SameSite: sameSite,
+	}
+	if value == nil {
+		cookie.MaxAge = -1
+		return cookie
+	}
+	encoded, _ := json.Marshal(value)
+	payload := base64.RawURLEncoding.EncodeToString(encoded)
+	cookie.Value = payload + "." + sp.sign(payload)
+	cookie.Expires = expires
+	return cookie
+}
+
+// verifyCookie decodes the value of a cookie signed by a collector sharing
+// the session key
+func (sp *samlServiceProvider) verifyCookie(r *http.Request, name string, value interface{}) error {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return err
+	}
+	payload, signature, ok := strings.Cut(cookie.Value, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(sp.sign(payload))) {
+		return fmt.Errorf("invalid signature")
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(decoded, value)
+}
+
+func (sp *samlServiceProvider) sessionCookie(user string, expires time.Time) *http.Cookie {
+	return sp.cookie(samlSessionCookie, samlSession{User: user, Expires: expires.Unix()}, expires, http.SameSiteLaxMode)
+}
+
+// requestCookie must be sent along with the response the identity provider
+// posts from its own site, which only a cookie without same-site
+// restrictions is
+func (sp *samlServiceProvider) requestCookie(request interface{}, expires time.Time) *http.Cookie {
+	sameSite := http.SameSiteLaxMode
+	if sp.secure {
+		sameSite = http.SameSiteNoneMode
+	}
+	return sp.cookie(samlRequestCookie, request, expires, sameSite)
+}
+
+// pendingRequest returns the id of the login pending in the browser
+func (sp *samlServiceProvider) pendingRequest(r *http.Request) string {
+	request := samlRequest{}
+	if err := sp.verifyCookie(r, samlRequestCookie, &request); err != nil || !sp.now().Before(time.Unix(request.Expires, 0)) {
+		return ""
+	}
+	return request.Id
+}
+
+// acs is the assertion consumer service the identity provider posts to
+func (sp *samlServiceProvider) acs(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, samlMaxResponseLength)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	// the pending login is used up whatever the outcome
+	http.SetCookie(w, sp.requestCookie(nil, time.Time{}))
+	user, expires, err := sp.consume(r.PostForm.Get("SAMLResponse"), sp.pendingRequest(r))
+	if err != nil {
+		logger.Warnf("Rejected saml response: %s", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	http.SetCookie(w, sp.sessionCookie(user, expires))
+	http.Redirect(w, r, relayState(r.PostForm.Get("RelayState")), http.StatusSeeOther)
+}
+
+// metadata describes the service provider for registration with the
+// identity provider
+func (sp *samlServiceProvider) metadata(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+	fmt.Fprintf(w, `<md:EntityDescriptor xmlns:md="%s" entityID="%s"><md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="%s">`+
+		`<md:AssertionConsumerService Binding="%s" Location="%s" index="1"/></md:SPSSODescriptor></md:EntityDescriptor>`,
+		samlMetadataNamespace, escapeXml(sp.entityID), samlProtocolNamespace, samlPostBinding, escapeXml(sp.acsURL))
+}
+
+// authenticate verifies the session cookie of the request, returning the
+// request with the username in its context
+func (sp *samlServiceProvider) authenticate(r *http.Request) (*http.Request, error) {
+	if _, err := r.Cookie(samlSessionCookie); err != nil {
+		return nil, fmt.Errorf("no saml session")
+	}
+	session := samlSession{}
+	if err := sp.verifyCookie(r, samlSessionCookie, &session); err != nil || session.User == "" {
+		return nil, fmt.Errorf("invalid saml session")
+	}
+	if !sp.now().Before(time.Unix(session.Expires, 0)) {
+		return nil, fmt.Errorf("saml session expired")
+	}
+	return r.WithContext(context.WithValue(r.Context(), samlUserKey{}, session.User)), nil
+}
+
+func (sp *samlServiceProvider) getUser(r *http.Request) UserResponse {
+	userResponse := UserResponse{
+		Username: "",
+		AuthMode: types.ConsoleAuthModeSAML,
+	}
+	if user, ok := r.Context().Value(samlUserKey{}).(string); ok {
+		userResponse.Username = user
+	}
+	return userResponse
+}
+
+// logout clears the session cookie, the session at the identity provider
+// is left alone
+func (sp *samlServiceProvider) logout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, sp.cookie(samlSessionCookie, nil, time.Time{}, http.SameSiteLaxMode))
+	fmt.Fprintf(w, "%s", "Logged out")
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"gotest.tools/assert"
+)
+
+type samlTestIdp struct {
+	key  *rsa.PrivateKey
+	cert []byte
+}
+
+func newSamlTestIdp(t *testing.T) *samlTestIdp {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Assert(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Assert(t, err)
+	return &samlTestIdp{key: key, cert: cert}
+}
+
+func (idp *samlTestIdp) metadata() string {
+	return `<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" xmlns:ds="http://www.w3.org/2000/09/xmldsig#" entityID="https://idp.example.com">` +
+		`<md:IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">` +
+		`<md:KeyDescriptor use="signing"><ds:KeyInfo><ds:X509Data><ds:X509Certificate>` + base64.StdEncoding.EncodeToString(idp.cert) + `</ds:X509Certificate></ds:X509Data></ds:KeyInfo></md:KeyDescriptor>` +
+		`<md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://idp.example.com/sso?tenant=1"/>` +
+		`</md:IDPSSODescriptor></md:EntityDescriptor>`
+}
+
+// sign adds an enveloped signature over the element, in the exclusive
+// canonical form identity providers use
+func (idp *samlTestIdp) sign(t *testing.T, element string) string {
+	el, err := parseXml([]byte(element))
+	assert.Assert(t, err)
+	ctx := dsig.NewDefaultSigningContext(dsig.TLSCertKeyStore(tls.Certificate{Certificate: [][]byte{idp.cert}, PrivateKey: idp.key}))
+	ctx.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("")
+	signed, err := ctx.SignEnveloped(el)
+	assert.Assert(t, err)
+	doc := etree.NewDocument()
+	doc.SetRoot(signed)
+	out, err := doc.WriteToString()
+	assert.Assert(t, err)
+	return out
+}
+
+// pendingRequest returns the id of the login request the recorded response
+// left pending in the browser
+func pendingRequest(sp *samlServiceProvider, w *httptest.ResponseRecorder) string {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1alpha1/saml/acs", nil)
+	for _, cookie := range w.Result().Cookies() {
+		r.AddCookie(cookie)
+	}
+	return sp.pendingRequest(r)
+}
+
+func newSamlTestServiceProvider(t *testing.T, idp *samlTestIdp, usernameAttribute string) *samlServiceProvider {
+	metadata := filepath.Join(t.TempDir(), "metadata.xml")
+	assert.Assert(t, os.WriteFile(metadata, []byte(idp.metadata()), 0600))
+	sp, err := newSamlServiceProvider(metadata, "https://console.example.com/", usernameAttribute)
+	assert.Assert(t, err)
+	return sp
+}
+
+type samlTestResponse struct {
+	requestId     string
+	issuer        string
+	audience      string
+	recipient     string
+	notOnOrAfter  time.Time
+	signResponse  bool
+	signAssertion bool
+}
+
+func (r samlTestResponse) build(t *testing.T, idp *samlTestIdp) string {
+	assertion := fmt.Sprintf(`<saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_assertion-%s" Version="2.0" IssueInstant="%s">`+
+		`<saml:Issuer>%s</saml:Issuer>`+
+		`<saml:Subject><saml:NameID>alice@example.com</saml:NameID>`+
+		`<saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer"><saml:SubjectConfirmationData InResponseTo="%s" Recipient="%s" NotOnOrAfter="%s"/></saml:SubjectConfirmation></saml:Subject>`+
+		`<saml:Conditions NotBefore="%s" NotOnOrAfter="%s"><saml:AudienceRestriction><saml:Audience>%s</saml:Audience></saml:AudienceRestriction></saml:Conditions>`+
+		`<saml:AttributeStatement><saml:Attribute Name="uid"><saml:AttributeValue>alice</saml:AttributeValue></saml:Attribute></saml:AttributeStatement>`+
+		`</saml:Assertion>`,
+		r.requestId, time.Now().UTC().Format(time.RFC3339), r.issuer, r.requestId, r.recipient, r.notOnOrAfter.UTC().Format(time.RFC3339),
+		time.Now().Add(-time.Minute).UTC().Format(time.RFC3339), r.notOnOrAfter.UTC().Format(time.RFC3339), r.audience)
+	if r.signAssertion {
+		assertion = idp.sign(t, assertion)
+	}
+	response := fmt.Sprintf(`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_response-%s" Version="2.0" InResponseTo="%s" Destination="%s">`+
+		`<saml:Issuer>%s</saml:Issuer><samlp:Status><samlp:StatusCode Value="urn:oasis:names:tc:SAML:2.0:status:Success"/></samlp:Status>%s</samlp:Response>`,
+		r.requestId, r.requestId, r.recipient, r.issuer, assertion)
+	if r.signResponse {
+		response = idp.sign(t, response)
+	}
+	return response
+}
+
+func TestParseXml(t *testing.T) {
+	root, err := parseXml([]byte(`<a:root xmlns:a="urn:a" xmlns:b="urn:b" id="1" b:id="2"><a:child>ad<!---->min</a:child><b:child/></a:root>`))
+	assert.Assert(t, err)
+	assert.Assert(t, xmlIs(root, "urn:a", "root"))
+	assert.Equal(t, xmlAttr(root, "id"), "1")
+	assert.Equal(t, len(xmlChildElements(root, "urn:a", "child")), 1)
+	// a comment does not truncate the text
+	assert.Equal(t, xmlText(xmlChild(root, "urn:a", "child")), "admin")
+
+	_, err = parseXml([]byte(`<!DOCTYPE x [<!ENTITY e "e">]><x>&e;</x>`))
+	assert.Assert(t, err != nil)
+}
+
+func TestSamlConsume(t *testing.T) {
+	idp := newSamlTestIdp(t)
+	other := newSamlTestIdp(t)
+	testTable := []struct {
+		name              string
+		modify            func(r *samlTestResponse)
+		usernameAttribute string
+		signer            *samlTestIdp
+		tamper            func(response string) string
+		user              string
+		err               string
+	}{
+		{name: "assertion-signed", user: "alice@example.com"},
+		{name: "response-signed", modify: func(r *samlTestResponse) { r.signAssertion, r.signResponse = false, true }, user: "alice@example.com"},
+		{name: "username-attribute", usernameAttribute: "uid", user: "alice"},
+		{name: "unsigned", modify: func(r *samlTestResponse) { r.signAssertion = false }, err: "not signed"},
+		{name: "other-signer", signer: other, err: "trusted certs"},
+		{name: "tampered", tamper: func(response string) string {
+			return strings.Replace(response, "alice@example.com", "admin@example.com", 1)
+		}, err: "could not be verified"},
+		{name: "unknown-request", modify: func(r *samlTestResponse) { r.requestId = "_unknown" }, err: "pending login"},
+		{name: "wrong-issuer", modify: func(r *samlTestResponse) { r.issuer = "https://evil.example.com" }, err: "issued by"},
+		{name: "wrong-audience", modify: func(r *samlTestResponse) { r.audience = "https://other.example.com" }, err: "not intended"},
+		{name: "wrong-recipient", modify: func(r *samlTestResponse) { r.recipient = "https://other.example.com/acs" }, err: "sent to"},
+		{name: "expired", modify: func(r *samlTestResponse) { r.notOnOrAfter = time.Now().Add(-time.Hour) }, err: "bearer confirmation"},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			sp := newSamlTestServiceProvider(t, idp, test.usernameAttribute)
+			w := httptest.NewRecorder()
+			sp.login(w, httptest.NewRequest(http.MethodGet, "/api/v1alpha1/saml/login", nil))
+			requestId := pendingRequest(sp, w)
+			assert.Assert(t, requestId != "")
+			r := samlTestResponse{
+				requestId:     requestId,
+				issuer:        "https://idp.example.com",
+				audience:      sp.entityID,
+				recipient:     sp.acsURL,
+				notOnOrAfter:  time.Now().Add(5 * time.Minute),
+				signAssertion: true,
+			}
+			if test.modify != nil {
+				test.modify(&r)
+			}
+			signer := idp
+			if test.signer != nil {
+				signer = test.signer
+			}
+			response := r.build(t, signer)
+			if test.tamper != nil {
+				response = test.tamper(response)
+			}
+			user, expires, err := sp.consume(base64.StdEncoding.EncodeToString([]byte(response)), requestId)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			assert.Equal(t, user, test.user)
+			assert.Assert(t, expires.After(time.Now()))
+			_, _, err = sp.consume(base64.StdEncoding.EncodeToString([]byte(response)), "")
+			assert.ErrorContains(t, err, "pending login")
+		})
+	}
+}
+
+func TestSamlReplay(t *testing.T) {
+	idp := newSamlTestIdp(t)
+	sp := newSamlTestServiceProvider(t, idp, "")
+	r := samlTestResponse{
+		requestId:     "_request",
+		issuer:        "https://idp.example.com",
+		audience:      sp.entityID,
+		recipient:     sp.acsURL,
+		notOnOrAfter:  time.Now().Add(5 * time.Minute),
+		signAssertion: true,
+	}
+	response := base64.StdEncoding.EncodeToString([]byte(r.build(t, idp)))
+	_, _, err := sp.consume(response, "_request")
+	assert.Assert(t, err)
+	_, _, err = sp.consume(response, "_request")
+	assert.ErrorContains(t, err, "already used")
+}
+
+func TestSamlReplicas(t *testing.T) {
+	// the replicas share the session key, the login started on one is
+	// completed on the other
+	keyFile := filepath.Join(t.TempDir(), "key")
+	assert.Assert(t, os.WriteFile(keyFile, []byte(strings.Repeat("k", 32)), 0600))
+	t.Setenv("FLOW_SESSION_KEY_FILE", keyFile)
+	idp := newSamlTestIdp(t)
+	first := newSamlTestServiceProvider(t, idp, "")
+	second := newSamlTestServiceProvider(t, idp, "")
+
+	w := httptest.NewRecorder()
+	first.login(w, httptest.NewRequest(http.MethodGet, "/api/v1alpha1/saml/login", nil))
+	requestId := pendingRequest(second, w)
+	assert.Assert(t, requestId != "")
+	r := samlTestResponse{
+		requestId:     requestId,
+		issuer:        "https://idp.example.com",
+		audience:      second.entityID,
+		recipient:     second.acsURL,
+		notOnOrAfter:  time.Now().Add(5 * time.Minute),
+		signAssertion: true,
+	}
+	form := url.Values{"SAMLResponse": {base64.StdEncoding.EncodeToString([]byte(r.build(t, idp)))}, "RelayState": {"/#/topology"}}
+	acs := httptest.NewRequest(http.MethodPost, "/api/v1alpha1/saml/acs", strings.NewReader(form.Encode()))
+	acs.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, cookie := range w.Result().Cookies() {
+		acs.AddCookie(cookie)
+	}
+	w = httptest.NewRecorder()
+	second.acs(w, acs)
+	assert.Equal(t, w.Code, http.StatusSeeOther)
+	assert.Equal(t, w.Header().Get("Location"), "/#/topology")
+
+	// the session issued by one replica is accepted by the other
+	request := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/user/", nil)
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == samlRequestCookie {
+			assert.Equal(t, cookie.MaxAge, -1)
+		} else {
+			request.AddCookie(cookie)
+		}
+	}
+	authorized, err := first.authenticate(request)
+	assert.Assert(t, err)
+	assert.Equal(t, first.getUser(authorized).Username, "alice@example.com")
+}
+
+func TestSamlLogin(t *testing.T) {
+	sp := newSamlTestServiceProvider(t, newSamlTestIdp(t), "")
+	testTable := []struct {
+		target   string
+		expected string
+	}{
+		{target: "/#/topology", expected: "/#/topology"},
+		{target: "https://evil.example.com/", expected: "/"},
+		{target: "//evil.example.com/", expected: "/"},
+		{target: "/\\evil.example.com/", expected: "/"},
+	}
+	for _, test := range testTable {
+		t.Run(test.target, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			sp.login(w, httptest.NewRequest(http.MethodGet, "/api/v1alpha1/saml/login?return="+url.QueryEscape(test.target), nil))
+			assert.Equal(t, w.Code, http.StatusFound)
+			location, err := url.Parse(w.Header().Get("Location"))
+			assert.Assert(t, err)
+			assert.Equal(t, location.Host, "idp.example.com")
+			assert.Equal(t, location.Query().Get("tenant"), "1")
+			assert.Equal(t, location.Query().Get("RelayState"), test.expected)
+			deflated, err := base64.StdEncoding.DecodeString(location.Query().Get("SAMLRequest"))
+			assert.Assert(t, err)
+			request, err := io.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+			assert.Assert(t, err)
+			node, err := parseXml(request)
+			assert.Assert(t, err)
+			assert.Assert(t, xmlIs(node, samlProtocolNamespace, "AuthnRequest"))
+			assert.Equal(t, pendingRequest(sp, w), xmlAttr(node, "ID"))
+			assert.Equal(t, xmlAttr(node, "AssertionConsumerServiceURL"), "https://console.example.com/api/v1alpha1/saml/acs")
+		})
+	}
+}
+
+func TestSamlSession(t *testing.T) {
+	sp := newSamlTestServiceProvider(t, newSamlTestIdp(t), "")
+	cookie := sp.sessionCookie("alice", time.Now().Add(time.Hour))
+	assert.Assert(t, cookie.Secure && cookie.HttpOnly)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/user/", nil)
+	request.AddCookie(cookie)
+	authorized, err := sp.authenticate(request)
+	assert.Assert(t, err)
+	assert.Equal(t, sp.getUser(authorized).Username, "alice")
+
+	forged := *cookie
+	payload, signature, _ := strings.Cut(cookie.Value, ".")
+	decoded, _ := base64.RawURLEncoding.DecodeString(payload)
+	forged.Value = base64.RawURLEncoding.EncodeToString(bytes.Replace(decoded, []byte("alice"), []byte("admin"), 1)) + "." + signature
+	request = httptest.NewRequest(http.MethodGet, "/api/v1alpha1/user/", nil)
+	request.AddCookie(&forged)
+	_, err = sp.authenticate(request)
+	assert.ErrorContains(t, err, "invalid")
+
+	request = httptest.NewRequest(http.MethodGet, "/api/v1alpha1/user/", nil)
+	request.AddCookie(sp.sessionCookie("alice", time.Now().Add(-time.Minute)))
+	_, err = sp.authenticate(request)
+	assert.ErrorContains(t, err, "expired")
+}
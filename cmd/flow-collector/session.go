@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	sessionCookie        = "skupper-session"
+	defaultSessionTtl    = 8 * time.Hour
+	maxLoginRequestBytes = 1 << 16
+)
+
+// sessionAuth is set when the console users are authenticated by password,
+// a successful login exchanges the credentials for a signed session cookie
+// so the browser never has to prompt for basic auth
+var sessionAuth *sessionManager
+
+type sessionUserKey struct{}
+
+type consoleSession struct {
+	User    string `json:"u"`
+	Expires int64  `json:"e"`
+}
+
+type sessionManager struct {
+	check func(user string, password string) bool
+	key   []byte
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// sessionKeyFromEnv returns the key in the FLOW_SESSION_KEY_FILE, so that
+// replicas and restarted collectors accept each other's cookies, or a random
+// key otherwise
+func sessionKeyFromEnv() ([]byte, error) {
+	file := os.Getenv("FLOW_SESSION_KEY_FILE")
+	if file == "" {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	key := []byte(strings.TrimSpace(string(data)))
+	if len(key) < 32 {
+		return nil, fmt.Errorf("the session key in %s must be at least 32 bytes", file)
+	}
+	return key, nil
+}
+
+// newSessionManagerFromEnv signs the sessions with the key from
+// sessionKeyFromEnv; FLOW_SESSION_TTL sets how long sessions last
+func newSessionManagerFromEnv(check func(string, string) bool) (*sessionManager, error) {
+	ttl := defaultSessionTtl
+	if value := os.Getenv("FLOW_SESSION_TTL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid FLOW_SESSION_TTL %q", value)
+		}
+		ttl = parsed
+	}
+	key, err := sessionKeyFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return &sessionManager{
+		check: check,
+		key:   key,
+		ttl:   ttl,
+		now:   time.Now,
+	}, nil
+}
+
+func (s *sessionManager) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *sessionManager) cookie(r *http.Request, value string, expires time.Time) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     sessionCookie,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	}
+	if value == "" {
+		cookie.MaxAge = -1
+	} else {
+		cookie.Expires = expires
+	}
+	return cookie
+}
+
+func (s *sessionManager) sessionCookie(r *http.Request, user string, expires time.Time) *http.Cookie {
+	session, _ := json.Marshal(consoleSession{User: user, Expires: expires.Unix()})
+	payload := base64.RawURLEncoding.EncodeToString(session)
+	return s.cookie(r, payload+"."+s.sign(payload), expires)
+}
+
+func (s *sessionManager) session(r *http.Request) (consoleSession, error) {
+	session := consoleSession{}
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return session, fmt.Errorf("no session")
+	}
+	payload, signature, ok := strings.Cut(cookie.Value, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return session, fmt.Errorf("invalid session")
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return session, fmt.Errorf("invalid session")
+	}
+	if err := json.Unmarshal(decoded, &session); err != nil || session.User == "" {
+		return session, fmt.Errorf("invalid session")
+	}
+	if !s.now().Before(time.Unix(session.Expires, 0)) {
+		return session, fmt.Errorf("session expired")
+	}
+	return session, nil
+}
+
+// authenticate verifies the session cookie of the request, returning the
+// request with the username in its context
+func (s *sessionManager) authenticate(r *http.Request) (*http.Request, error) {
+	session, err := s.session(r)
+	if err != nil {
+		return nil, err
+	}
+	return r.WithContext(context.WithValue(r.Context(), sessionUserKey{}, session.User)), nil
+}
+
+func hasSessionCookie(r *http.Request) bool {
+	_, err := r.Cookie(sessionCookie)
+	return err == nil
+}
+
+func getSessionUser(r *http.Request) (string, bool) {
+	user, ok := r.Context().Value(sessionUserKey{}).(string)
+	return user, ok
+}
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type sessionResponse struct {
+	Username string `json:"username"`
+	Expires  int64  `json:"expires"`
+}
+
+// login exchanges the credentials, posted as json, as a form or in a basic
+// auth header, for a session cookie; failures never ask the browser for
+// basic auth
+func (s *sessionManager) login(w http.ResponseWriter, r *http.Request) {
+	credentials := loginRequest{}
+	if user, password, ok := r.BasicAuth(); ok {
+		credentials = loginRequest{Username: user, Password: password}
+	} else {
+		r.Body = http.MaxBytesReader(w, r.Body, maxLoginRequestBytes)
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
+				http.Error(w, "Bad Request", http.StatusBadRequest)
+				return
+			}
+		} else if err := r.ParseForm(); err == nil {
+			credentials = loginRequest{Username: r.PostForm.Get("username"), Password: r.PostForm.Get("password")}
+		} else {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+	}
+	if credentials.Username == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if wait := loginThrottle.locked(r, credentials.Username); wait > 0 {
+		tooManyLogins(w, wait)
+		return
+	}
+	ok := s.check(credentials.Username, credentials.Password)
+	loginThrottle.record(r, credentials.Username, ok)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	expires := s.now().Add(s.ttl)
+	http.SetCookie(w, s.sessionCookie(r, credentials.Username, expires))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessionResponse{Username: credentials.Username, Expires: expires.Unix()})
+}
+
+// sessionHandler reports the current session or ends it
+func (s *sessionManager) sessionHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		session, err := s.session(r)
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sessionResponse{Username: session.User, Expires: session.Expires})
+	case http.MethodDelete:
+		s.logout(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *sessionManager) logout(w http.ResponseWriter, r *http.Request) {
+	if session, err := s.session(r); err == nil {
+		logger.Infof("Ended the session of %s", session.User)
+	}
+	http.SetCookie(w, s.cookie(r, "", time.Time{}))
+	fmt.Fprintf(w, "%s", "Logged out")
+}
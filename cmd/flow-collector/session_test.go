@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func newTestSessionManager(t *testing.T) *sessionManager {
+	t.Setenv("FLOW_SESSION_TTL", "1h")
+	t.Setenv("FLOW_SESSION_KEY_FILE", "")
+	sessions, err := newSessionManagerFromEnv(func(user string, password string) bool {
+		return user == "alice" && password == "secret"
+	})
+	assert.Assert(t, err)
+	return sessions
+}
+
+func TestSessionLogin(t *testing.T) {
+	sessions := newTestSessionManager(t)
+	testTable := []struct {
+		name        string
+		contentType string
+		body        string
+		basicAuth   bool
+		status      int
+	}{
+		{name: "json", contentType: "application/json", body: `{"username":"alice","password":"secret"}`, status: http.StatusOK},
+		{name: "form", contentType: "application/x-www-form-urlencoded", body: url.Values{"username": {"alice"}, "password": {"secret"}}.Encode(), status: http.StatusOK},
+		{name: "basic", basicAuth: true, status: http.StatusOK},
+		{name: "wrong-password", contentType: "application/json", body: `{"username":"alice","password":"guess"}`, status: http.StatusUnauthorized},
+		{name: "no-user", contentType: "application/json", body: `{}`, status: http.StatusUnauthorized},
+		{name: "malformed", contentType: "application/json", body: `{"username":`, status: http.StatusBadRequest},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1alpha1/login", strings.NewReader(test.body))
+			if test.contentType != "" {
+				r.Header.Set("Content-Type", test.contentType)
+			}
+			if test.basicAuth {
+				r.SetBasicAuth("alice", "secret")
+			}
+			rec := httptest.NewRecorder()
+			sessions.login(rec, r)
+			assert.Equal(t, rec.Code, test.status)
+			// the browser must never be asked for basic auth
+			assert.Equal(t, rec.Header().Get("WWW-Authenticate"), "")
+			cookies := rec.Result().Cookies()
+			if test.status != http.StatusOK {
+				assert.Equal(t, len(cookies), 0)
+				return
+			}
+			assert.Equal(t, len(cookies), 1)
+			assert.Equal(t, cookies[0].Name, sessionCookie)
+			assert.Assert(t, cookies[0].HttpOnly)
+			response := sessionResponse{}
+			assert.Assert(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, response.Username, "alice")
+		})
+	}
+}
+
+func TestSessionAuthenticate(t *testing.T) {
+	sessions := newTestSessionManager(t)
+	now := time.Now()
+	valid := sessions.sessionCookie(httptest.NewRequest(http.MethodGet, "/", nil), "alice", now.Add(time.Hour))
+	expired := sessions.sessionCookie(httptest.NewRequest(http.MethodGet, "/", nil), "alice", now.Add(-time.Minute))
+	payload, _, _ := strings.Cut(valid.Value, ".")
+
+	testTable := []struct {
+		name   string
+		cookie *http.Cookie
+		err    string
+	}{
+		{name: "valid", cookie: valid},
+		{name: "none", err: "no session"},
+		{name: "expired", cookie: expired, err: "session expired"},
+		{name: "tampered", cookie: &http.Cookie{Name: sessionCookie, Value: payload + ".forged"}, err: "invalid session"},
+		{name: "unsigned", cookie: &http.Cookie{Name: sessionCookie, Value: payload}, err: "invalid session"},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/sites/", nil)
+			if test.cookie != nil {
+				r.AddCookie(test.cookie)
+			}
+			authorized, err := sessions.authenticate(r)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			user, ok := getSessionUser(authorized)
+			assert.Assert(t, ok)
+			assert.Equal(t, user, "alice")
+		})
+	}
+}
+
+func TestSessionHandler(t *testing.T) {
+	sessions := newTestSessionManager(t)
+	cookie := sessions.sessionCookie(httptest.NewRequest(http.MethodGet, "/", nil), "alice", time.Now().Add(time.Hour))
+
+	rec := httptest.NewRecorder()
+	sessions.sessionHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1alpha1/session", nil))
+	assert.Equal(t, rec.Code, http.StatusUnauthorized)
+	assert.Equal(t, rec.Header().Get("WWW-Authenticate"), "")
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/session", nil)
+	r.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	sessions.sessionHandler(rec, r)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	response := sessionResponse{}
+	assert.Assert(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, response.Username, "alice")
+
+	r = httptest.NewRequest(http.MethodDelete, "/api/v1alpha1/session", nil)
+	r.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	sessions.sessionHandler(rec, r)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	cleared := rec.Result().Cookies()
+	assert.Equal(t, len(cleared), 1)
+	assert.Equal(t, cleared[0].MaxAge, -1)
+}
+
+func TestSessionManagerFromEnv(t *testing.T) {
+	check := func(string, string) bool { return false }
+	t.Setenv("FLOW_SESSION_KEY_FILE", "")
+	t.Setenv("FLOW_SESSION_TTL", "soon")
+	_, err := newSessionManagerFromEnv(check)
+	assert.ErrorContains(t, err, "invalid FLOW_SESSION_TTL")
+
+	t.Setenv("FLOW_SESSION_TTL", "")
+	sessions, err := newSessionManagerFromEnv(check)
+	assert.Assert(t, err)
+	assert.Equal(t, sessions.ttl, defaultSessionTtl)
+
+	dir := t.TempDir()
+	t.Setenv("FLOW_SESSION_KEY_FILE", dir+"/missing")
+	_, err = newSessionManagerFromEnv(check)
+	assert.ErrorContains(t, err, "no such file")
+}
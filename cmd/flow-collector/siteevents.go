@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/flow"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const siteEventsKey = "events"
+
+// configMapSiteEventStore keeps the site events in the skupper-site-events
+// config map, only the leading replica writing them
+type configMapSiteEventStore struct {
+	client    kubernetes.Interface
+	namespace string
+	leading   func() bool
+}
+
+func newSiteEventStore(kubeClient kubernetes.Interface, namespace string, leading func() bool) flow.SiteEventStore {
+	if kubeClient == nil {
+		return nil
+	}
+	return &configMapSiteEventStore{client: kubeClient, namespace: namespace, leading: leading}
+}
+
+func (s *configMapSiteEventStore) Load() ([]flow.SiteEvent, error) {
+	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(context.Background(), types.SiteEventsConfigMap, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	events := []flow.SiteEvent{}
+	if data, ok := cm.Data[siteEventsKey]; ok {
+		if err := json.Unmarshal([]byte(data), &events); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+func (s *configMapSiteEventStore) Save(events []flow.SiteEvent) error {
+	if s.leading != nil && !s.leading() {
+		return nil
+	}
+	data, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	configMaps := s.client.CoreV1().ConfigMaps(s.namespace)
+	cm, err := configMaps.Get(ctx, types.SiteEventsConfigMap, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: types.SiteEventsConfigMap},
+			Data:       map[string]string{siteEventsKey: string(data)},
+		}
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+		return err
+	} else if err != nil {
+		return err
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[siteEventsKey] = string(data)
+	_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
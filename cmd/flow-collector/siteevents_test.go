@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/skupperproject/skupper/pkg/flow"
+	"gotest.tools/assert"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestConfigMapSiteEventStore(t *testing.T) {
+	assert.Assert(t, newSiteEventStore(nil, "test", nil) == nil)
+
+	leading := false
+	store := newSiteEventStore(fake.NewSimpleClientset(), "test", func() bool { return leading })
+	events, err := store.Load()
+	assert.Assert(t, err)
+	assert.Equal(t, len(events), 0)
+
+	saved := []flow.SiteEvent{
+		{Time: 100, Type: flow.SiteEventLinkEstablished, SiteId: "west", Subject: "east", Key: "link-1"},
+		{Time: 200, Type: flow.SiteEventLinkLost, SiteId: "west", Subject: "east", Key: "link-1"},
+	}
+	assert.Assert(t, store.Save(saved))
+	events, err = store.Load()
+	assert.Assert(t, err)
+	assert.Equal(t, len(events), 0)
+
+	leading = true
+	assert.Assert(t, store.Save(saved[:1]))
+	assert.Assert(t, store.Save(saved))
+	events, err = store.Load()
+	assert.Assert(t, err)
+	assert.DeepEqual(t, events, saved)
+}
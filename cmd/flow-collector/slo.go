@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/flow"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	slosKey = "slos"
+	// sloAnnotationInterval is how often the annotations of the services
+	// are read for their objectives
+	sloAnnotationInterval = 30 * time.Second
+)
+
+// configMapSloStore keeps the objectives declared through the api in the
+// skupper-slos config map, only the leading replica writing them
+type configMapSloStore struct {
+	client    kubernetes.Interface
+	namespace string
+	leading   func() bool
+}
+
+func newSloStore(kubeClient kubernetes.Interface, namespace string, leading func() bool) flow.SloStore {
+	if kubeClient == nil {
+		return nil
+	}
+	return &configMapSloStore{client: kubeClient, namespace: namespace, leading: leading}
+}
+
+func (s *configMapSloStore) Load() ([]flow.AddressSlo, error) {
+	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(context.Background(), types.SlosConfigMap, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	slos := []flow.AddressSlo{}
+	if data, ok := cm.Data[slosKey]; ok {
+		if err := json.Unmarshal([]byte(data), &slos); err != nil {
+			return nil, err
+		}
+	}
+	return slos, nil
+}
+
+func (s *configMapSloStore) Save(slos []flow.AddressSlo) error {
+	if s.leading != nil && !s.leading() {
+		return nil
+	}
+	data, err := json.Marshal(slos)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	configMaps := s.client.CoreV1().ConfigMaps(s.namespace)
+	cm, err := configMaps.Get(ctx, types.SlosConfigMap, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: types.SlosConfigMap},
+			Data:       map[string]string{slosKey: string(data)},
+		}
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+		return err
+	} else if err != nil {
+		return err
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[slosKey] = string(data)
+	_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
+
+// annotatedSlos returns the objectives declared by the annotations of the
+// services of the namespace, for the address they expose
+func annotatedSlos(ctx context.Context, kubeClient kubernetes.Interface, namespace string) ([]flow.AddressSlo, error) {
+	services, err := kubeClient.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	slos := []flow.AddressSlo{}
+	for _, service := range services.Items {
+		address := service.ObjectMeta.Name
+		if value, ok := service.ObjectMeta.Annotations[types.AddressQualifier]; ok {
+			address = value
+		}
+		slo, err := flow.SloFromAnnotations(address, service.ObjectMeta.Annotations)
+		if err != nil {
+			logger.Warnf("Ignoring the objectives of service %s: %s", service.ObjectMeta.Name, err)
+			continue
+		}
+		if slo != nil {
+			slos = append(slos, *slo)
+		}
+	}
+	return slos, nil
+}
+
+// watchSloAnnotations keeps the objectives of the collector in line with
+// the annotations of the services
+func watchSloAnnotations(kubeClient kubernetes.Interface, namespace string, fc *flow.FlowCollector, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(sloAnnotationInterval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), sloAnnotationInterval)
+		slos, err := annotatedSlos(ctx, kubeClient, namespace)
+		cancel()
+		if err != nil {
+			logger.Errorf("Unable to read the objectives of the services: %s", err)
+		} else {
+			fc.SetAnnotatedSlos(slos)
+		}
+		select {
+		case <-ticker.C:
+		case <-stopCh:
+			return
+		}
+	}
+}
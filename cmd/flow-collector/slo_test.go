@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/flow"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAnnotatedSlos(t *testing.T) {
+	service := func(name string, annotations map[string]string) *corev1.Service {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Annotations: annotations}}
+	}
+	kubeClient := fake.NewSimpleClientset(
+		service("backend", map[string]string{
+			types.ProxyQualifier:                "http",
+			types.SloLatencyAnnotation:          "250ms",
+			types.SloLatencyObjectiveAnnotation: "0.99",
+		}),
+		service("db", map[string]string{
+			types.AddressQualifier:            "database",
+			types.SloErrorObjectiveAnnotation: "0.999",
+		}),
+		service("broken", map[string]string{types.SloLatencyObjectiveAnnotation: "0.99"}),
+		service("plain", nil),
+	)
+	slos, err := annotatedSlos(context.Background(), kubeClient, "test")
+	assert.Assert(t, err)
+	assert.DeepEqual(t, slos, []flow.AddressSlo{
+		{Address: "backend", Latency: 250000, LatencyObjective: 0.99, Source: flow.SloSourceAnnotation},
+		{Address: "database", ErrorObjective: 0.999, Source: flow.SloSourceAnnotation},
+	})
+}
+
+func TestConfigMapSloStore(t *testing.T) {
+	assert.Assert(t, newSloStore(nil, "test", nil) == nil)
+
+	store := newSloStore(fake.NewSimpleClientset(), "test", nil)
+	slos, err := store.Load()
+	assert.Assert(t, err)
+	assert.Equal(t, len(slos), 0)
+
+	saved := []flow.AddressSlo{{Address: "backend", Latency: 250000, LatencyObjective: 0.99, Source: flow.SloSourceApi}}
+	assert.Assert(t, store.Save(saved))
+	assert.Assert(t, store.Save(saved))
+	slos, err = store.Load()
+	assert.Assert(t, err)
+	assert.DeepEqual(t, slos, saved)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/skupperproject/skupper/pkg/flow"
+)
+
+const (
+	defaultApiTimeout = 30 * time.Second
+	listTimeoutHint   = "narrow the query with limit and cursor, a smaller timeRangeStart/timeRangeEnd window or state=active"
+	promTimeoutHint   = "reduce the range or increase the step of the prometheus query"
+)
+
+// endpointTimeouts holds the request timeout for each collection of the
+// api, keyed by the first path segment after the api version (e.g. flows)
+type endpointTimeouts struct {
+	defaultTimeout time.Duration
+	endpoints      map[string]time.Duration
+}
+
+// parseEndpointTimeouts parses either a single duration applied to every
+// endpoint, or a list of the form default=30s,flows=10s,prom=1m
+func parseEndpointTimeouts(value string) (endpointTimeouts, error) {
+	timeouts := endpointTimeouts{
+		defaultTimeout: defaultApiTimeout,
+		endpoints:      map[string]time.Duration{},
+	}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		endpoint, duration, found := strings.Cut(entry, "=")
+		if !found {
+			endpoint, duration = "default", entry
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(duration))
+		if err != nil || timeout <= 0 {
+			return timeouts, fmt.Errorf("invalid timeout %q", entry)
+		}
+		endpoint = strings.TrimSpace(endpoint)
+		if endpoint == "default" {
+			timeouts.defaultTimeout = timeout
+		} else {
+			timeouts.endpoints[endpoint] = timeout
+		}
+	}
+	return timeouts, nil
+}
+
+// endpointName returns the collection a request path refers to, the
+// prometheus proxy being reported as prom
+func endpointName(path string) string {
+	path = strings.TrimPrefix(path, "/api/v1alpha1/")
+	path = strings.TrimPrefix(path, "internal/")
+	endpoint, _, _ := strings.Cut(path, "/")
+	return endpoint
+}
+
+func (t endpointTimeouts) forPath(path string) time.Duration {
+	if timeout, ok := t.endpoints[endpointName(path)]; ok {
+		return timeout
+	}
+	return t.defaultTimeout
+}
+
+// handler bounds the context of each request by the timeout of its endpoint
+func (t endpointTimeouts) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), t.forPath(r.URL.Path))
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type timeoutResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Hint   string `json:"hint,omitempty"`
+}
+
+func writeTimeout(w http.ResponseWriter, err error, hint string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusGatewayTimeout)
+	json.NewEncoder(w).Encode(timeoutResponse{
+		Status: "timeout",
+		Error:  err.Error(),
+		Hint:   hint,
+	})
+}
+
+// apiRequest hands a request to the collector, giving up if the context
+// expires before the collector gets to it
+func (c *Controller) apiRequest(ctx context.Context, request flow.ApiRequest) (flow.ApiResponse, error) {
+	select {
+	case c.FlowCollector.Request <- request:
+	case <-ctx.Done():
+		return flow.ApiResponse{}, ctx.Err()
+	}
+	// once accepted the response must be read, the collector is blocked on it
+	response := <-c.FlowCollector.Response
+	if response.Status == http.StatusGatewayTimeout {
+		return response, context.DeadlineExceeded
+	}
+	return response, nil
+}
+
+func (c *Controller) serveApiRequest(w http.ResponseWriter, r *http.Request, request flow.ApiRequest) {
+	response, err := c.apiRequest(r.Context(), request)
+	if err != nil {
+		writeTimeout(w, err, listTimeoutHint)
+		return
+	}
+	writeApiResponse(w, r, response)
+}
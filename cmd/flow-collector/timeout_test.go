@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/skupperproject/skupper/pkg/flow"
+	"gotest.tools/assert"
+)
+
+func TestParseEndpointTimeouts(t *testing.T) {
+	timeouts, err := parseEndpointTimeouts("")
+	assert.Assert(t, err)
+	assert.Equal(t, timeouts.forPath("/api/v1alpha1/flows/"), defaultApiTimeout)
+
+	timeouts, err = parseEndpointTimeouts("10s")
+	assert.Assert(t, err)
+	assert.Equal(t, timeouts.forPath("/api/v1alpha1/sites/"), 10*time.Second)
+
+	timeouts, err = parseEndpointTimeouts("default=20s, flows=5s, prom=1m")
+	assert.Assert(t, err)
+	assert.Equal(t, timeouts.forPath("/api/v1alpha1/sites/abc"), 20*time.Second)
+	assert.Equal(t, timeouts.forPath("/api/v1alpha1/flows/"), 5*time.Second)
+	assert.Equal(t, timeouts.forPath("/api/v1alpha1/internal/prom/query/"), time.Minute)
+
+	_, err = parseEndpointTimeouts("flows=fast")
+	assert.ErrorContains(t, err, "invalid timeout")
+	_, err = parseEndpointTimeouts("flows=-1s")
+	assert.ErrorContains(t, err, "invalid timeout")
+}
+
+func TestServeApiRequestTimeout(t *testing.T) {
+	// nothing reads the request channel, as if the collector was busy
+	c := &Controller{FlowCollector: flow.NewFlowCollector(flow.FlowCollectorSpec{})}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/flows/", nil).WithContext(ctx)
+	c.serveApiRequest(rec, req, flow.ApiRequest{RecordType: flow.Flow, Request: req})
+	assert.Equal(t, rec.Code, http.StatusGatewayTimeout)
+	assert.Assert(t, rec.Body.Len() > 0)
+	assert.Equal(t, rec.Header().Get("Content-Type"), "application/json")
+}
+
+// parsePrometheusDuration parses a duration parameter as the prometheus api
+// does, either float seconds or a prometheus duration
+func parsePrometheusDuration(s string) (time.Duration, error) {
+	if d, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(d * float64(time.Second)), nil
+	}
+	if d, err := model.ParseDuration(s); err == nil {
+		return time.Duration(d), nil
+	}
+	return 0, fmt.Errorf("cannot parse %q to a valid duration", s)
+}
+
+func TestPrometheusTimeout(t *testing.T) {
+	for _, remaining := range []time.Duration{time.Millisecond, 29999 * time.Millisecond, 29999999 * time.Microsecond, 2 * time.Minute} {
+		timeout, err := parsePrometheusDuration(prometheusTimeout(remaining))
+		assert.Assert(t, err)
+		assert.Equal(t, timeout, remaining.Truncate(time.Millisecond))
+	}
+}
+
+func TestProxyPrometheusTimeout(t *testing.T) {
+	var timeout string
+	var parseErr error
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout = r.URL.Query().Get("timeout")
+		_, parseErr = parsePrometheusDuration(timeout)
+		w.Write([]byte(`{"status":"success"}`))
+	}))
+	defer prometheus.Close()
+
+	c := &Controller{FlowCollector: flow.NewFlowCollector(flow.FlowCollectorSpec{})}
+	c.FlowCollector.Collector.PrometheusUrl = prometheus.URL + "/api/v1/"
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/internal/prom/query/?query=up", nil).WithContext(ctx)
+	c.proxyPrometheus(rec, req, "query")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Assert(t, timeout != "")
+	assert.Assert(t, parseErr)
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/skupperproject/skupper/pkg/encryption"
+	"github.com/skupperproject/skupper/pkg/utils"
+	"github.com/skupperproject/skupper/pkg/utils/configs"
+)
+
+const configDialTimeout = 5 * time.Second
+
+// configDurations are the settings holding a single duration
+var configDurations = []string{"FLOW_RECORD_TTL", "FLOW_SESSION_TTL", "FLOW_LOGIN_LOCKOUT", "FLOW_NETBOX_INTERVAL"}
+
+// configProblem is an invalid setting along with how to fix it
+type configProblem struct {
+	setting string
+	problem string
+	hint    string
+}
+
+// configProblems reports all the problems of the configuration at once
+type configProblems []configProblem
+
+func (p configProblems) Error() string {
+	lines := []string{fmt.Sprintf("%d configuration problems found:", len(p))}
+	if len(p) == 1 {
+		lines[0] = "1 configuration problem found:"
+	}
+	for _, problem := range p {
+		lines = append(lines, fmt.Sprintf("  - %s: %s (%s)", problem.setting, problem.problem, problem.hint))
+	}
+	return strings.Join(lines, "\n")
+}
+
+type configValidator struct {
+	problems configProblems
+}
+
+func (v *configValidator) add(setting string, hint string, format string, args ...interface{}) {
+	v.problems = append(v.problems, configProblem{setting: setting, problem: fmt.Sprintf(format, args...), hint: hint})
+}
+
+// validateCollectorConfig checks the console certificate in consoleDir, the
+// users directory, the router connection described by connectFile, the
+// prometheus url and the durations, so the collector fails at startup with
+// every problem found instead of one at a time
+func validateCollectorConfig(consoleDir string, connectFile string, prometheusUrl string) error {
+	v := &configValidator{}
+	v.validateConsoleTls(consoleDir)
+	v.validateUsers(os.Getenv("FLOW_USERS"))
+	v.validateConnect(connectFile)
+	v.validatePrometheusUrl(prometheusUrl)
+	v.validateDurations()
+	if len(v.problems) > 0 {
+		return v.problems
+	}
+	return nil
+}
+
+// readable returns the problem found opening the file, if any
+func readable(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func describeFileError(err error) string {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return "the file does not exist"
+	case errors.Is(err, os.ErrPermission):
+		return "the file is not readable by the collector"
+	}
+	return err.Error()
+}
+
+func (v *configValidator) validateConsoleTls(dir string) {
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	_, certErr := os.Stat(certFile)
+	_, keyErr := os.Stat(keyFile)
+	if certErr != nil && keyErr != nil {
+		// the console is served over plain http
+		return
+	}
+	const hint = "mount the console certificate secret with tls.crt and tls.key readable by the collector, check the defaultMode of the volume"
+	ok := true
+	for _, file := range []string{certFile, keyFile} {
+		if err := readable(file); err != nil {
+			v.add(file, hint, "%s", describeFileError(err))
+			ok = false
+		}
+	}
+	if ok {
+		if _, err := encryption.LoadX509KeyPair(certFile, keyFile); err != nil {
+			v.add(certFile, "regenerate the console certificate secret, the key must match the certificate", "invalid certificate: %s", err)
+		}
+	}
+	if _, err := utils.TlsServerConfig(os.Getenv("FLOW_TLS_MIN_VERSION"), os.Getenv("FLOW_TLS_CIPHER_SUITES"), os.Getenv("FLOW_TLS_CURVE_PREFERENCES")); err != nil {
+		v.add("FLOW_TLS_*", "use the tls version, cipher suite and curve names of the go crypto/tls package", "%s", err)
+	}
+}
+
+func (v *configValidator) validateUsers(dir string) {
+	if dir == "" {
+		return
+	}
+	const hint = "mount the secret holding a file per console user there, or unset FLOW_USERS"
+	info, err := os.Stat(dir)
+	if err != nil {
+		v.add("FLOW_USERS", hint, "%s: %s", dir, describeFileError(err))
+		return
+	} else if !info.IsDir() {
+		v.add("FLOW_USERS", hint, "%s is not a directory", dir)
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		v.add("FLOW_USERS", hint, "unable to list %s: %s", dir, err)
+		return
+	}
+	users := 0
+	for _, entry := range entries {
+		// the files of a mounted secret are behind hidden entries
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		name := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(name)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		users++
+		if err := readable(name); err != nil {
+			v.add("FLOW_USERS", hint, "user %s: %s", entry.Name(), describeFileError(err))
+		} else if info.Size() == 0 {
+			v.add("FLOW_USERS", "set the password of the user in the secret", "user %s has an empty password", entry.Name())
+		}
+	}
+	if users == 0 {
+		v.add("FLOW_USERS", hint, "%s has no users, nobody can log into the console", dir)
+	}
+}
+
+func (v *configValidator) validateConnect(file string) {
+	const hint = "mount the router client secret there, or set the FLOW_CONNECT_ENDPOINTS and FLOW_CONNECT_SASL_* variables"
+	conn, err := configs.LoadConnectInfo(file, "FLOW_CONNECT_")
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, os.ErrPermission) {
+			v.add(file, hint, "%s", describeFileError(err))
+		} else {
+			v.add(file, hint, "invalid router connection configuration: %s", strings.TrimPrefix(err.Error(), file+": "))
+		}
+		return
+	}
+	reachable := false
+	unreachable := []string{}
+	for _, endpoint := range conn.Endpoints {
+		if endpoint.Tls != nil {
+			v.validateConnectTls(endpoint)
+		}
+		c, err := net.DialTimeout("tcp", net.JoinHostPort(endpoint.Host, string(endpoint.Port)), configDialTimeout)
+		if err != nil {
+			unreachable = append(unreachable, fmt.Sprintf("%s: %s", endpoint.Url(), err))
+			continue
+		}
+		c.Close()
+		reachable = true
+	}
+	if !reachable {
+		v.add(file, "check the router is running and its address and port in connect.json", "no router endpoint reachable, %s", strings.Join(unreachable, ", "))
+	}
+}
+
+// validateConnectTls mirrors the loading of the router client certificate,
+// the ca being required to verify the router and the certificate being
+// optional, but complete when present
+func (v *configValidator) validateConnectTls(endpoint configs.ConnectEndpoint) {
+	const hint = "mount the router client certificate next to connect.json or set its tls files"
+	if endpoint.Tls.VerifyHost() {
+		if err := readable(endpoint.Tls.Ca); err != nil {
+			v.add(endpoint.Tls.Ca, hint, "ca of %s: %s", endpoint.Url(), describeFileError(err))
+		}
+	}
+	_, certErr := os.Stat(endpoint.Tls.Cert)
+	_, keyErr := os.Stat(endpoint.Tls.Key)
+	if certErr != nil && keyErr != nil {
+		return
+	}
+	for _, file := range []string{endpoint.Tls.Cert, endpoint.Tls.Key} {
+		if err := readable(file); err != nil {
+			v.add(file, hint, "client certificate of %s: %s", endpoint.Url(), describeFileError(err))
+			return
+		}
+	}
+	if _, err := encryption.LoadX509KeyPair(endpoint.Tls.Cert, endpoint.Tls.Key); err != nil {
+		v.add(endpoint.Tls.Cert, hint, "invalid client certificate of %s: %s", endpoint.Url(), err)
+	}
+}
+
+func (v *configValidator) validatePrometheusUrl(value string) {
+	if value == "" {
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		v.add("PROMETHEUS_URL", "use the url of the prometheus api, such as http://skupper-prometheus:9090/api/v1/", "invalid url %q: %s", value, err)
+	} else if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add("PROMETHEUS_URL", "use the url of the prometheus api, such as http://skupper-prometheus:9090/api/v1/", "invalid url %q, expected http:// or https:// followed by the host", value)
+	}
+}
+
+func (v *configValidator) validateDurations() {
+	const hint = "use a positive duration such as 90s, 30m or 12h"
+	for _, setting := range configDurations {
+		value := os.Getenv(setting)
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			v.add(setting, hint, "invalid duration %q", value)
+		}
+	}
+	if _, err := parseEndpointTimeouts(os.Getenv("API_TIMEOUTS")); err != nil {
+		v.add("API_TIMEOUTS", "use a default duration and endpoint=duration entries separated by commas, such as 30s,flowpairs=2m", "%s", err)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestValidateCollectorConfig(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Assert(t, err)
+	defer listener.Close()
+	_, routerPort, _ := net.SplitHostPort(listener.Addr().String())
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Assert(t, err)
+	_, closedPort, _ := net.SplitHostPort(closed.Addr().String())
+	closed.Close()
+
+	testTable := []struct {
+		name       string
+		consoleTls string
+		users      map[string]string
+		connect    string
+		prometheus string
+		env        map[string]string
+		problems   []string
+	}{
+		{
+			name:       "valid",
+			consoleTls: "keypair",
+			users:      map[string]string{"admin": "secret", "..data": ""},
+			connect:    fmt.Sprintf(`{"scheme": "amqp", "host": "127.0.0.1", "port": "%s"}`, routerPort),
+			prometheus: "http://skupper-prometheus:9090/api/v1/",
+			env:        map[string]string{"FLOW_SESSION_TTL": "12h", "API_TIMEOUTS": "30s,flowpairs=2m"},
+		},
+		{
+			name:    "plain-http-console",
+			connect: fmt.Sprintf(`{"scheme": "amqp", "host": "127.0.0.1", "port": "%s"}`, routerPort),
+		},
+		{
+			name:       "all-problems-at-once",
+			consoleTls: "missing-key",
+			users:      map[string]string{"admin": ""},
+			connect:    fmt.Sprintf(`{"scheme": "amqp", "host": "127.0.0.1", "port": "%s"}`, closedPort),
+			prometheus: "skupper-prometheus:9090",
+			env:        map[string]string{"FLOW_RECORD_TTL": "15", "FLOW_LOGIN_LOCKOUT": "-1m", "API_TIMEOUTS": "flowpairs"},
+			problems: []string{
+				"tls.key: the file does not exist",
+				"FLOW_USERS: user admin has an empty password",
+				"connect.json: no router endpoint reachable",
+				"PROMETHEUS_URL: invalid url",
+				`FLOW_RECORD_TTL: invalid duration "15"`,
+				`FLOW_LOGIN_LOCKOUT: invalid duration "-1m"`,
+				"API_TIMEOUTS: invalid timeout",
+			},
+		},
+		{
+			name:    "no-users-invalid-connect",
+			users:   map[string]string{},
+			connect: `{"scheme": "http", "host": "127.0.0.1"}`,
+			problems: []string{
+				"has no users",
+				`connect.json: invalid router connection configuration: unsupported scheme "http"`,
+			},
+		},
+		{
+			name: "missing-connect",
+			problems: []string{
+				"connect.json: the file does not exist",
+			},
+		},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			consoleDir := t.TempDir()
+			switch test.consoleTls {
+			case "keypair":
+				writeTestKeyPair(t, consoleDir, "console")
+			case "missing-key":
+				writeTestKeyPair(t, consoleDir, "console")
+				assert.Assert(t, os.Remove(filepath.Join(consoleDir, "tls.key")))
+			}
+			t.Setenv("FLOW_USERS", "")
+			if test.users != nil {
+				usersDir := t.TempDir()
+				for user, password := range test.users {
+					assert.Assert(t, os.WriteFile(filepath.Join(usersDir, user), []byte(password), 0600))
+				}
+				t.Setenv("FLOW_USERS", usersDir)
+			}
+			connectFile := filepath.Join(t.TempDir(), "connect.json")
+			if test.connect != "" {
+				assert.Assert(t, os.WriteFile(connectFile, []byte(test.connect), 0600))
+			}
+			for _, setting := range append(configDurations, "API_TIMEOUTS") {
+				t.Setenv(setting, test.env[setting])
+			}
+
+			err := validateCollectorConfig(consoleDir, connectFile, test.prometheus)
+			if len(test.problems) == 0 {
+				assert.Assert(t, err)
+				return
+			}
+			assert.Assert(t, err != nil)
+			problems, ok := err.(configProblems)
+			assert.Assert(t, ok)
+			assert.Equal(t, len(problems), len(test.problems), err.Error())
+			for _, expected := range test.problems {
+				assert.Assert(t, strings.Contains(err.Error(), expected), "%q not reported in %s", expected, err)
+			}
+		})
+	}
+}
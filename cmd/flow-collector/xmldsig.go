@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+const xmldsigNamespace = "http://www.w3.org/2000/09/xmldsig#"
+
+// only the rsa signatures and sha2 digests are accepted, sha1 is not
+var xmldsigSignatureMethods = map[string]bool{
+	dsig.RSASHA256SignatureMethod: true,
+	dsig.RSASHA512SignatureMethod: true,
+}
+
+var xmldsigDigestMethods = map[string]bool{
+	"http://www.w3.org/2001/04/xmlenc#sha256": true,
+	"http://www.w3.org/2001/04/xmlenc#sha512": true,
+}
+
+func parseXml(data []byte) (*etree.Element, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(data); err != nil {
+		return nil, err
+	}
+	for _, token := range doc.Child {
+		if _, ok := token.(*etree.Directive); ok {
+			// no DTDs, they are not expected and only open up entity tricks
+			return nil, fmt.Errorf("xml directives are not allowed")
+		}
+	}
+	root := doc.Root()
+	if root == nil {
+		return nil, fmt.Errorf("incomplete xml document")
+	}
+	return root, nil
+}
+
+func xmlIs(el *etree.Element, namespace string, local string) bool {
+	return el.Tag == local && el.NamespaceURI() == namespace
+}
+
+// xmlAttr returns the value of an attribute without namespace
+func xmlAttr(el *etree.Element, local string) string {
+	for _, attr := range el.Attr {
+		if attr.Space == "" && attr.Key == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func xmlChildElements(el *etree.Element, namespace string, local string) []*etree.Element {
+	var elements []*etree.Element
+	for _, child := range el.ChildElements() {
+		if xmlIs(child, namespace, local) {
+			elements = append(elements, child)
+		}
+	}
+	return elements
+}
+
+func xmlChild(el *etree.Element, namespace string, local string) *etree.Element {
+	if elements := xmlChildElements(el, namespace, local); len(elements) == 1 {
+		return elements[0]
+	}
+	return nil
+}
+
+// xmlText joins all the text of the element, so that a comment cannot
+// truncate a value
+func xmlText(el *etree.Element) string {
+	var b strings.Builder
+	for _, token := range el.Child {
+		if data, ok := token.(*etree.CharData); ok {
+			b.WriteString(data.Data)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// verifyEnvelopedSignature checks the signature enveloped in the element
+// against the trusted certificates, returning the signed content or nil
+// when the element has no signature. Only the returned element must be
+// trusted, the element passed in may carry content the signature does not
+// cover.
+func verifyEnvelopedSignature(el *etree.Element, certs []*x509.Certificate, now time.Time) (*etree.Element, error) {
+	signatures := xmlChildElements(el, xmldsigNamespace, "Signature")
+	if len(signatures) == 0 {
+		return nil, nil
+	} else if len(signatures) > 1 {
+		return nil, fmt.Errorf("multiple signatures")
+	}
+	if signedInfo := xmlChild(signatures[0], xmldsigNamespace, "SignedInfo"); signedInfo != nil {
+		if method := xmlChild(signedInfo, xmldsigNamespace, "SignatureMethod"); method == nil || !xmldsigSignatureMethods[xmlAttr(method, "Algorithm")] {
+			return nil, fmt.Errorf("unsupported signature method")
+		}
+		for _, reference := range xmlChildElements(signedInfo, xmldsigNamespace, "Reference") {
+			if method := xmlChild(reference, xmldsigNamespace, "DigestMethod"); method == nil || !xmldsigDigestMethods[xmlAttr(method, "Algorithm")] {
+				return nil, fmt.Errorf("unsupported digest method")
+			}
+		}
+	}
+	// the element carries the namespaces declared by its ancestors, as the
+	// canonical form it was signed in did
+	ctx, err := etreeutils.NSBuildParentContext(el)
+	if err != nil {
+		return nil, err
+	}
+	detached, err := etreeutils.NSDetatch(ctx, el)
+	if err != nil {
+		return nil, err
+	}
+	validation := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: certs})
+	validation.Clock = dsig.NewFakeClockAt(now)
+	return validation.Validate(detached)
+}
@@ -2,13 +2,17 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	neturl "net/url"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/skupperproject/skupper/pkg/cleanhttp"
+	"github.com/skupperproject/skupper/pkg/logging"
 	"github.com/skupperproject/skupper/pkg/utils"
 	"github.com/spf13/cobra"
 )
@@ -94,6 +98,72 @@ func policyCmd() *cobra.Command {
 	return policyCmd
 }
 
+// logLevelUrls are where the components of the pod serve their log level
+var logLevelUrls = map[string]string{
+	"service-controller": "http://localhost:8181" + logging.LevelPath,
+	"flow-collector":     "http://localhost:8011" + logging.LevelPath,
+}
+
+func logLevel(component string, level string, output string) error {
+	url, ok := logLevelUrls[component]
+	if !ok {
+		return fmt.Errorf("Invalid component %q, expected service-controller or flow-collector", component)
+	}
+	method := http.MethodGet
+	if level != "" {
+		method = http.MethodPut
+		url += "?level=" + neturl.QueryEscape(level)
+	}
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return err
+	}
+	client := cleanhttp.DefaultClient()
+	client.Timeout = time.Second * 10
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("The %s responded %s: %s", component, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if output == "json" {
+		fmt.Print(string(body))
+		return nil
+	}
+	var current struct {
+		Level string `json:"level"`
+	}
+	if err := json.Unmarshal(body, &current); err != nil {
+		return err
+	}
+	fmt.Println(current.Level)
+	return nil
+}
+
+func logLevelCmd() *cobra.Command {
+	var component string
+	cmd := &cobra.Command{
+		Use:   "loglevel [level]",
+		Short: "Shows or changes the log level of a component: debug, info, warn or error",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			level := ""
+			if len(args) > 0 {
+				level = args[0]
+			}
+			return logLevel(component, level, output)
+		},
+	}
+	cmd.Flags().StringVar(&component, "component", "service-controller", "The component, one of service-controller or flow-collector")
+	return cmd
+}
+
 func main() {
 	var rootCmd = &cobra.Command{Use: "get"}
 
@@ -113,6 +183,7 @@ func main() {
 	})
 
 	rootCmd.AddCommand(policyCmd())
+	rootCmd.AddCommand(logLevelCmd())
 
 	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", "", "The output format to use (one of json or text)")
 
@@ -27,7 +27,7 @@ func (h *ClaimHandler) Handle(name string, claim *corev1.Secret) error {
 	return nil
 }
 
-func newClaimHandler(cli *client.VanClient, siteId string) *SecretController {
+func newClaimHandler(cli *client.VanClient, siteId string, redeemed func(claimName string, url string)) *SecretController {
 	handler := &ClaimHandler{
 		name:      "ClaimHandler",
 		vanClient: cli,
@@ -37,7 +37,12 @@ func newClaimHandler(cli *client.VanClient, siteId string) *SecretController {
 	if site == nil {
 		site = &qdr.SiteMetadata{}
 	}
-	handler.redeemer = domain.NewClaimRedeemer(handler.name, site.Id, site.Version, handler.updateSecret, event.Recordf)
+	var siteName, siteMode string
+	if siteConfig, err := cli.SiteConfigInspect(context.TODO(), nil); err == nil && siteConfig != nil {
+		siteName, siteMode = siteConfig.Spec.SkupperName, siteConfig.Spec.RouterMode
+	}
+	handler.redeemer = domain.NewClaimRedeemer(handler.name, site.Id, siteName, siteMode, site.Version, handler.updateSecret, event.Recordf)
+	handler.redeemer.OnRedeemed(redeemed)
 	return NewSecretController(handler.name, types.ClaimRequestSelector, cli.KubeClient, cli.Namespace, handler)
 }
 
@@ -144,7 +144,7 @@ func TestClaimHandler(t *testing.T) {
 		siteId:    "site-a",
 	}
 	siteMeta, _ := cli.GetSiteMetadata()
-	handler.redeemer = domain.NewClaimRedeemer(handler.name, handler.siteId, siteMeta.Version, handler.updateSecret, event.Recordf)
+	handler.redeemer = domain.NewClaimRedeemer(handler.name, handler.siteId, "", "", siteMeta.Version, handler.updateSecret, event.Recordf)
 
 	verifier := &MockVerifier{
 		cli: &client.VanClient{
@@ -205,7 +205,7 @@ func TestInvalidClaims(t *testing.T) {
 		siteId:    "site-a",
 	}
 	siteMeta, _ := cli.GetSiteMetadata()
-	handler.redeemer = domain.NewClaimRedeemer(handler.name, handler.siteId, siteMeta.Version, handler.updateSecret, event.Recordf)
+	handler.redeemer = domain.NewClaimRedeemer(handler.name, handler.siteId, "", "", siteMeta.Version, handler.updateSecret, event.Recordf)
 
 	var tests = []struct {
 		secret *corev1.Secret
@@ -400,7 +400,7 @@ func TestIncompatibleClaims(t *testing.T) {
 				siteId:    "site-a",
 			}
 			siteMeta, _ := cli.GetSiteMetadata()
-			handler.redeemer = domain.NewClaimRedeemer(handler.name, handler.siteId, siteMeta.Version, handler.updateSecret, event.Recordf)
+			handler.redeemer = domain.NewClaimRedeemer(handler.name, handler.siteId, "", "", siteMeta.Version, handler.updateSecret, event.Recordf)
 
 			// defining the claim on the site that is going to redeem the claim
 			claim := newTestClaim(name, server.URL, password, test.clientSiteVersion)
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
 	"path"
@@ -22,6 +21,7 @@ import (
 	"github.com/skupperproject/skupper/pkg/certs"
 	"github.com/skupperproject/skupper/pkg/data"
 	"github.com/skupperproject/skupper/pkg/event"
+	"github.com/skupperproject/skupper/pkg/logging"
 	"github.com/skupperproject/skupper/pkg/qdr"
 )
 
@@ -53,6 +53,10 @@ func newConsoleServer(cli *client.VanClient, config *certs.TlsConfigRetriever, e
 }
 
 func authenticate(dir string, user string, password string) bool {
+	if strings.HasPrefix(user, ".") {
+		event.Recordf(HttpAuthFailure, "Failed to authenticate %s, no such user exists", user)
+		return false
+	}
 	filename := path.Join(dir, user)
 	file, err := os.Open(filename)
 	if err != nil {
@@ -70,7 +74,7 @@ func authenticate(dir string, user string, password string) bool {
 		event.Recordf(HttpAuthFailure, "Failed to authenticate %s: %s", user, err)
 		return false
 	}
-	return string(bytes) == password
+	return utils.VerifyPassword(string(bytes), password)
 }
 
 func authenticated(h http.Handler) http.Handler {
@@ -397,7 +401,7 @@ func (server *ConsoleServer) listen() {
 	if os.Getenv("METRICS_HOST") != "" {
 		addr = os.Getenv("METRICS_HOST") + addr
 	}
-	log.Printf("Console server listening on %s", addr)
+	logger.Infof("Console server listening on %s", addr)
 	r := mux.NewRouter()
 	r.Handle("/DATA", authenticated(server))
 	r.Handle("/tokens", authenticated(serveTokens(server.tokens)))
@@ -423,14 +427,15 @@ func (server *ConsoleServer) listen() {
 	r.Handle("/policy/outgoinglink/{hostname}", authenticated(server.policies.outgoingLink()))
 	r.Handle("/policy/list", authenticated(server.policies.dump()))
 	r.Handle("/servicecheck/{name}", authenticated(server.checkService()))
+	r.Handle(logging.LevelPath, authenticated(http.HandlerFunc(logging.LevelHandler)))
 	if os.Getenv("USE_CORS") != "" {
 		r.Use(cors)
 	}
 	_, err := os.Stat("/etc/service-controller/console/tls.crt")
 	if err == nil {
-		log.Fatal(http.ListenAndServeTLS(addr, "/etc/service-controller/console/tls.crt", "/etc/service-controller/console/tls.key", r))
+		logger.Fatalf("Console server failed: %s", http.ListenAndServeTLS(addr, "/etc/service-controller/console/tls.crt", "/etc/service-controller/console/tls.key", r))
 	} else {
-		log.Fatal(http.ListenAndServe(addr, r))
+		logger.Fatalf("Console server failed: %s", http.ListenAndServe(addr, r))
 	}
 }
 
@@ -448,7 +453,8 @@ func (server *ConsoleServer) listenLocal() {
 	r.Handle("/policy/incominglink", server.policies.incomingLink())
 	r.Handle("/policy/outgoinglink/{hostname}", server.policies.outgoingLink())
 	r.Handle("/policy/list", server.policies.dump())
-	log.Fatal(http.ListenAndServe(addr, r))
+	r.HandleFunc(logging.LevelPath, logging.LevelHandler)
+	logger.Fatalf("Local console server failed: %s", http.ListenAndServe(addr, r))
 }
 
 func (server *ConsoleServer) listenHealthz() {
@@ -458,7 +464,7 @@ func (server *ConsoleServer) listenHealthz() {
 		w.WriteHeader(200)
 		w.Write([]byte("ok"))
 	}))
-	log.Fatal(http.ListenAndServe(addr, r))
+	logger.Fatalf("Health check server failed: %s", http.ListenAndServe(addr, r))
 }
 
 func set(m map[string]map[string]bool, k1 string, k2 string) {
@@ -4,7 +4,6 @@ import (
 	"context"
 	jsonencoding "encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"strings"
 	"time"
@@ -69,6 +68,7 @@ type Controller struct {
 	siteQueryServer   *SiteQueryServer
 	tokenHandler      *SecretController
 	claimHandler      *SecretController
+	linkRevoker       *LinkRevoker
 	serviceSync       *service_sync.ServiceSync
 	flowController    *flow.FlowController
 	ipLookup          *IpLookup
@@ -214,7 +214,7 @@ func NewController(cli *client.VanClient, origin string, tlsConfig *certs.TlsCon
 	controller.byName = make(map[string]types.ServiceInterface)
 	controller.heardFrom = make(map[string]time.Time)
 
-	log.Println("Setting up event handlers")
+	logger.Infof("Setting up event handlers")
 	svcDefInformer.AddEventHandler(controller.newEventHandler("servicedefs", AnnotatedKey, ConfigMapResourceVersionTest))
 	bridgeDefInformer.AddEventHandler(controller.newEventHandler("bridges", AnnotatedKey, ConfigMapResourceVersionTest))
 	svcInformer.AddEventHandler(controller.newEventHandler("actual-services", AnnotatedKey, ServiceResourceVersionTest))
@@ -225,7 +225,10 @@ func NewController(cli *client.VanClient, origin string, tlsConfig *certs.TlsCon
 
 	controller.definitionMonitor = newDefinitionMonitor(controller.origin, controller.vanClient, controller.svcDefInformer, controller.svcInformer)
 	controller.tokenHandler = newTokenHandler(controller.vanClient, origin, controller.eventHandler)
-	controller.claimHandler = newClaimHandler(controller.vanClient, origin)
+	controller.claimHandler = newClaimHandler(controller.vanClient, origin, func(claimName string, url string) {
+		flow.RecordLogEvent(controller.flowController, flow.SiteEventTokenRedeemed, fmt.Sprintf("Token %s redeemed at %s", claimName, url))
+	})
+	controller.linkRevoker = newLinkRevoker(controller.vanClient, controller.consoleServer.agentPool)
 	handler := func(changed []types.ServiceInterface, deleted []string, origin string) error {
 		return kube.UpdateSkupperServices(changed, deleted, origin, cli.Namespace, cli.KubeClient)
 	}
@@ -352,14 +355,14 @@ func (c *Controller) Run(stopCh <-chan struct{}) error {
 	defer utilruntime.HandleCrash()
 	defer c.events.ShutDown()
 
-	log.Println("Starting the Skupper controller")
+	logger.Infof("Starting the Skupper controller")
 
-	log.Println("Waiting for informer caches to sync")
+	logger.Infof("Waiting for informer caches to sync")
 	if ok := cache.WaitForCacheSync(stopCh, c.svcDefInformer.HasSynced, c.bridgeDefInformer.HasSynced, c.svcInformer.HasSynced, c.headlessInformer.HasSynced, c.externalBridges.HasSynced); !ok {
 		return fmt.Errorf("Failed to wait for caches to sync")
 	}
 
-	log.Println("Starting workers")
+	logger.Infof("Starting workers")
 	if !c.disableServiceSync {
 		c.serviceSync.Start(stopCh)
 	}
@@ -374,14 +377,16 @@ func (c *Controller) Run(stopCh <-chan struct{}) error {
 	c.consoleServer.start(stopCh)
 	c.tokenHandler.start(stopCh)
 	c.claimHandler.start(stopCh)
+	c.linkRevoker.start(stopCh)
 	c.policyHandler.start(stopCh)
 
-	log.Println("Started workers")
+	logger.Infof("Started workers")
 	<-stopCh
-	log.Println("Shutting down workers")
+	logger.Infof("Shutting down workers")
 	c.definitionMonitor.stop()
 	c.tokenHandler.stop()
 	c.claimHandler.stop()
+	c.linkRevoker.stop()
 	c.policyHandler.stop()
 
 	return nil
@@ -793,7 +798,7 @@ func (c *Controller) processNextEvent() bool {
 				if err != nil {
 					return fmt.Errorf("Error reading skupper-services from cache: %s", err)
 				} else if !exists {
-					log.Println("skupper-services has been deleted!")
+					logger.Warnf("skupper-services has been deleted!")
 					return nil
 				}
 				var portAllocations map[string][]int
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/event"
+	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+const (
+	LinkRevokerEvent string = "LinkRevokerEvent"
+	// the router cannot refuse the revoked credentials by itself, the links
+	// they establish again are closed on this interval
+	linkRevokerInterval = 10 * time.Second
+	// the expired credentials are dropped from the CRL secret on this interval
+	linkRevokerPruneInterval = time.Hour
+)
+
+// LinkRevoker closes the links established with the link credentials
+// revoked in the CRL secret of the site
+type LinkRevoker struct {
+	name       string
+	kubeClient kubernetes.Interface
+	namespace  string
+	agentPool  *qdr.AgentPool
+	secrets    *SecretController
+	lock       sync.Mutex
+	revoked    []string
+}
+
+func newLinkRevoker(cli *client.VanClient, pool *qdr.AgentPool) *LinkRevoker {
+	revoker := &LinkRevoker{
+		name:       "LinkRevoker",
+		kubeClient: cli.KubeClient,
+		namespace:  cli.Namespace,
+		agentPool:  pool,
+	}
+	revoker.secrets = NewSecretController(revoker.name, types.SiteCrlSelector, cli.KubeClient, cli.Namespace, revoker)
+	return revoker
+}
+
+func (r *LinkRevoker) Handle(name string, secret *corev1.Secret) error {
+	revoked := []string{}
+	if secret != nil {
+		ca, err := r.kubeClient.CoreV1().Secrets(r.namespace).Get(context.TODO(), types.SiteCaSecret, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		revoked, err = kube.RevokedFingerprints(secret, ca)
+		if err != nil {
+			return err
+		}
+	}
+	r.lock.Lock()
+	r.revoked = revoked
+	r.lock.Unlock()
+	return r.closeRevokedLinks()
+}
+
+func (r *LinkRevoker) getRevoked() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.revoked
+}
+
+// revokedLinks returns the incoming links whose peer was authenticated with
+// one of the revoked certificates
+func revokedLinks(connections []qdr.Connection, revoked []string) []qdr.Connection {
+	links := []qdr.Connection{}
+	for _, connection := range connections {
+		if connection.Dir != "in" || connection.User == "" {
+			continue
+		}
+		if connection.Role != string(qdr.RoleInterRouter) && connection.Role != qdr.RoleEdge {
+			continue
+		}
+		for _, fingerprint := range revoked {
+			if certs.SameFingerprint(connection.User, fingerprint) {
+				links = append(links, connection)
+				break
+			}
+		}
+	}
+	return links
+}
+
+func (r *LinkRevoker) closeRevokedLinks() error {
+	revoked := r.getRevoked()
+	if len(revoked) == 0 {
+		return nil
+	}
+	agent, err := r.agentPool.Get()
+	if err != nil {
+		return fmt.Errorf("Could not get management agent: %s", err)
+	}
+	defer r.agentPool.Put(agent)
+	connections, err := agent.GetConnections()
+	if err != nil {
+		return err
+	}
+	for _, link := range revokedLinks(connections, revoked) {
+		if err := agent.CloseConnection(link.Identity); err != nil {
+			return err
+		}
+		event.Recordf(LinkRevokerEvent, "Closed link from %s (%s) established with a revoked credential", link.Container, link.Host)
+	}
+	return nil
+}
+
+func (r *LinkRevoker) enforce() {
+	if err := r.closeRevokedLinks(); err != nil {
+		event.Recordf(LinkRevokerEvent, "Failed to close the revoked links: %s", err)
+	}
+}
+
+func (r *LinkRevoker) prune() {
+	if err := kube.PruneIssuedCertificates(r.namespace, r.kubeClient); err != nil {
+		event.Recordf(LinkRevokerEvent, "Failed to drop the expired link credentials: %s", err)
+	}
+}
+
+func (r *LinkRevoker) start(stopCh <-chan struct{}) error {
+	if err := r.secrets.start(stopCh); err != nil {
+		return err
+	}
+	go wait.Until(r.enforce, linkRevokerInterval, stopCh)
+	go wait.Until(r.prune, linkRevokerPruneInterval, stopCh)
+	return nil
+}
+
+func (r *LinkRevoker) stop() {
+	r.secrets.stop()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+func TestRevokedLinks(t *testing.T) {
+	revoked := []string{"aa01", "bb02"}
+	var tests = []struct {
+		name       string
+		connection qdr.Connection
+		revoked    bool
+	}{
+		{
+			name:       "revoked inter-router link",
+			connection: qdr.Connection{Identity: "1", Dir: "in", Role: "inter-router", User: "AA01"},
+			revoked:    true,
+		},
+		{
+			name:       "revoked edge link",
+			connection: qdr.Connection{Identity: "2", Dir: "in", Role: "edge", User: "bb:02"},
+			revoked:    true,
+		},
+		{
+			name:       "valid link",
+			connection: qdr.Connection{Identity: "3", Dir: "in", Role: "inter-router", User: "cc03"},
+		},
+		{
+			name:       "outgoing link",
+			connection: qdr.Connection{Identity: "4", Dir: "out", Role: "inter-router", User: "aa01"},
+		},
+		{
+			name:       "client connection",
+			connection: qdr.Connection{Identity: "5", Dir: "in", Role: "normal", User: "aa01"},
+		},
+		{
+			name:       "unauthenticated link",
+			connection: qdr.Connection{Identity: "6", Dir: "in", Role: "inter-router"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			links := revokedLinks([]qdr.Connection{test.connection}, revoked)
+			assert.Equal(t, len(links) == 1, test.revoked)
+		})
+	}
+}
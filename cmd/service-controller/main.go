@@ -3,7 +3,6 @@ package main
 import (
 	"flag"
 	"fmt"
-	"log"
 	"os"
 	"os/signal"
 	"syscall"
@@ -14,6 +13,7 @@ import (
 	"github.com/skupperproject/skupper/pkg/certs"
 	"github.com/skupperproject/skupper/pkg/event"
 	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/logging"
 	"github.com/skupperproject/skupper/pkg/version"
 )
 
@@ -22,6 +22,8 @@ func describe(i interface{}) {
 	fmt.Println()
 }
 
+var logger = logging.New("service-controller")
+
 var onlyOneSignalHandler = make(chan struct{})
 var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
 
@@ -50,9 +52,13 @@ func main() {
 		os.Exit(0)
 	}
 
+	if err := logging.Configure("service-controller"); err != nil {
+		logger.Fatalf("Invalid logging configuration: %s", err)
+	}
+
 	// Startup message
-	log.Printf("Skupper service controller")
-	log.Printf("Version: %s", version.Version)
+	logger.Infof("Skupper service controller")
+	logger.Infof("Version: %s", version.Version)
 
 	origin := os.Getenv("SKUPPER_SITE_ID")
 	namespace := os.Getenv("SKUPPER_NAMESPACE")
@@ -64,7 +70,7 @@ func main() {
 	// todo, get context from env?
 	cli, err := client.NewClient(namespace, "", "")
 	if err != nil {
-		log.Fatal("Error getting van client", err.Error())
+		logger.Fatalf("Error getting van client: %s", err)
 	}
 
 	tlsConfig := certs.GetTlsConfigRetriever(true, types.ControllerConfigPath+"tls.crt", types.ControllerConfigPath+"tls.key", types.ControllerConfigPath+"ca.crt")
@@ -73,17 +79,17 @@ func main() {
 
 	controller, err := NewController(cli, origin, tlsConfig, disableServiceSync == "true")
 	if err != nil {
-		log.Fatal("Error getting new controller", err.Error())
+		logger.Fatalf("Error getting new controller: %s", err)
 	}
 
-	log.Println("Waiting for Skupper router component to start")
+	logger.Infof("Waiting for Skupper router component to start")
 	_, err = kube.WaitDeploymentReady(types.TransportDeploymentName, namespace, cli.KubeClient, time.Second*180, time.Second)
 	if err != nil {
-		log.Fatal("Error waiting for transport deployment to be ready: ", err.Error())
+		logger.Fatalf("Error waiting for transport deployment to be ready: %s", err)
 	}
 
 	// start the controller workers
 	if err = controller.Run(stopCh); err != nil {
-		log.Fatal("Error running controller: ", err.Error())
+		logger.Fatalf("Error running controller: %s", err)
 	}
 }
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
 	"strconv"
 	"strings"
 	"time"
@@ -81,7 +80,7 @@ func (c *PolicyController) OnDelete(obj interface{}) {
 }
 
 func (c *PolicyController) logRecord(msg string) {
-	log.Println(msg)
+	logger.Infof("%s", msg)
 	event.Record(c.name, msg)
 }
 
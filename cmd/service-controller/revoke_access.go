@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/client"
 )
 
@@ -25,9 +26,22 @@ func (m *AccessRevoker) revokeAccess() error {
 	return nil
 }
 
+func (m *AccessRevoker) revokeLinkCredentials(match string) ([]types.RevokedCertificate, error) {
+	return m.cli.RevokeLinkCredentials(context.Background(), match)
+}
+
 func serveAccessRevoker(m *AccessRevoker) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
+			if match := r.URL.Query().Get("link-credential"); match != "" {
+				revoked, err := m.revokeLinkCredentials(match)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				} else {
+					writeJson(revoked, w)
+				}
+				return
+			}
 			err := m.revokeAccess()
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/skupperproject/skupper/pkg/network"
 	"github.com/skupperproject/skupper/pkg/utils/configs"
+	"net/url"
 	"os"
 	"reflect"
 	"strconv"
@@ -13,6 +14,7 @@ import (
 
 	routev1 "github.com/openshift/api/route/v1"
 	"github.com/skupperproject/skupper/pkg/domain"
+	"github.com/skupperproject/skupper/pkg/logging"
 	"github.com/skupperproject/skupper/pkg/qdr"
 	"github.com/skupperproject/skupper/pkg/utils/formatter"
 	"github.com/skupperproject/skupper/pkg/version"
@@ -71,6 +73,7 @@ type SkupperDebugClient interface {
 	Events(cmd *cobra.Command, args []string) error
 	Service(cmd *cobra.Command, args []string) error
 	Policies(cmd *cobra.Command, args []string) error
+	LogLevel(cmd *cobra.Command, args []string) error
 	SkupperClientCommon
 }
 
@@ -89,6 +92,9 @@ type SkupperNetworkClient interface {
 	GetCurrentSiteId(ctx context.Context) (string, error)
 	Status(cmd *cobra.Command, args []string, ctx context.Context) (*network.NetworkStatusInfo, error)
 	StatusFlags(cmd *cobra.Command)
+	CollectorSummary(ctx context.Context) (*network.CollectorSummary, error)
+	CollectorPosture(ctx context.Context) ([]network.PostureReport, error)
+	History(ctx context.Context) ([]network.NetworkSnapshot, error)
 	SkupperClientCommon
 }
 
@@ -124,6 +130,7 @@ type ExposeOptions struct {
 	Aggregate                string
 	EventChannel             bool
 	Namespace                string
+	GenerateManifest         bool
 }
 
 type BindOptions struct {
@@ -167,35 +174,35 @@ func configureHeadlessProxy(spec *types.Headless, options *types.Tuning) error {
 		spec.NodeSelector = utils.LabelToMap(options.NodeSelector)
 	}
 	if options.Cpu != "" {
-		cpuQuantity, err := resource.ParseQuantity(options.Cpu)
-		if err == nil {
+		cpuQuantity, parseErr := resource.ParseQuantity(options.Cpu)
+		if parseErr == nil {
 			spec.CpuRequest = &cpuQuantity
 		} else {
-			err = fmt.Errorf("Invalid value for cpu: %s", err)
+			err = fmt.Errorf("Invalid value for cpu: %s", parseErr)
 		}
 	}
 	if options.Memory != "" {
-		memoryQuantity, err := resource.ParseQuantity(options.Memory)
-		if err == nil {
+		memoryQuantity, parseErr := resource.ParseQuantity(options.Memory)
+		if parseErr == nil {
 			spec.MemoryRequest = &memoryQuantity
 		} else {
-			err = fmt.Errorf("Invalid value for memory: %s", err)
+			err = fmt.Errorf("Invalid value for memory: %s", parseErr)
 		}
 	}
 	if options.CpuLimit != "" {
-		cpuQuantity, err := resource.ParseQuantity(options.CpuLimit)
-		if err == nil {
+		cpuQuantity, parseErr := resource.ParseQuantity(options.CpuLimit)
+		if parseErr == nil {
 			spec.CpuLimit = &cpuQuantity
 		} else {
-			err = fmt.Errorf("Invalid value for cpu: %s", err)
+			err = fmt.Errorf("Invalid value for cpu: %s", parseErr)
 		}
 	}
 	if options.MemoryLimit != "" {
-		memoryQuantity, err := resource.ParseQuantity(options.MemoryLimit)
-		if err == nil {
+		memoryQuantity, parseErr := resource.ParseQuantity(options.MemoryLimit)
+		if parseErr == nil {
 			spec.MemoryLimit = &memoryQuantity
 		} else {
-			err = fmt.Errorf("Invalid value for memory: %s", err)
+			err = fmt.Errorf("Invalid value for memory: %s", parseErr)
 		}
 	}
 	return err
@@ -231,6 +238,16 @@ func expose(cli types.VanClientInterface, ctx context.Context, targetType string
 				return "", err
 			}
 			err = configureHeadlessProxy(service.Headless, &options.ProxyTuning)
+			if err != nil {
+				return "", err
+			}
+			if options.GenerateManifest {
+				manifest, err := client.ServiceInterfaceManifest(cli.GetNamespace(), service)
+				if err != nil {
+					return "", err
+				}
+				return service.Address, writeServiceInterfaceManifest(manifest, service.Address)
+			}
 			return service.Address, cli.ServiceInterfaceUpdate(ctx, service)
 		} else {
 			if realClient {
@@ -285,6 +302,17 @@ func expose(cli types.VanClientInterface, ctx context.Context, targetType string
 		return "", err
 	}
 
+	if options.GenerateManifest {
+		if !realClient {
+			return "", fmt.Errorf("--generate-manifest is not supported by this client")
+		}
+		manifest, err := vanClient.ServiceInterfaceBindManifest(ctx, service, targetType, targetName, targetPorts, options.Namespace)
+		if err != nil {
+			return "", err
+		}
+		return options.Address, writeServiceInterfaceManifest(manifest, service.Address)
+	}
+
 	err = cli.ServiceInterfaceBind(ctx, service, targetType, targetName, targetPorts, options.Namespace)
 	if errors.IsNotFound(err) {
 		return "", SkupperNotInstalledError(cli.GetNamespace())
@@ -399,8 +427,9 @@ func asMap(entries []string) map[string]string {
 var LoadBalancerTimeout time.Duration
 
 type InitFlags struct {
-	routerMode string
-	labels     []string
+	routerMode          string
+	labels              []string
+	consolePasswordHash string
 }
 
 var initFlags InitFlags
@@ -439,6 +468,46 @@ installation that can then be connected to other skupper installations`,
 				return fmt.Errorf("The minimum value for flow-collector-record-ttl is 1 minute")
 			}
 
+			if _, err := utils.TlsServerConfig(routerCreateOpts.FlowCollector.TlsMinVersion, routerCreateOpts.FlowCollector.TlsCipherSuites, routerCreateOpts.FlowCollector.TlsCurvePreferences); err != nil {
+				return fmt.Errorf("Invalid flow collector tls settings: %s", err)
+			}
+
+			if acmeDomains := routerCreateOpts.FlowCollector.AcmeDomains; acmeDomains != "" {
+				if strings.Contains(acmeDomains, "*") {
+					return fmt.Errorf("The --flow-collector-acme-domains option does not support wildcard domains")
+				}
+				if routerCreateOpts.AuthMode == string(types.ConsoleAuthModeOpenshift) {
+					return fmt.Errorf("The --flow-collector-acme-domains option can't be used with the openshift console authentication")
+				}
+			}
+
+			if routerCreateOpts.SyslogAddress != "" {
+				if _, _, _, err := logging.ParseSyslogAddress(routerCreateOpts.SyslogAddress); err != nil {
+					return fmt.Errorf("Invalid --syslog-address: %s", err)
+				}
+			}
+
+			if vault := routerCreateOpts.Vault; vault.Address != "" {
+				if u, err := url.Parse(vault.Address); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fmt.Errorf("Invalid --vault-address %q, expected https://host:port", vault.Address)
+				}
+				if vault.Role == "" {
+					return fmt.Errorf("The --vault-role option is required with --vault-address")
+				}
+				if routerCreateOpts.RouterMode == string(types.TransportModeEdge) {
+					return fmt.Errorf("The --vault-address option can't be used with edge sites, they have no site CA")
+				}
+			}
+
+			if certManager := routerCreateOpts.CertManager; certManager.Issuer != "" {
+				if certManager.IssuerKind != types.CertManagerIssuerKind && certManager.IssuerKind != types.CertManagerClusterIssuerKind {
+					return fmt.Errorf("The --cert-manager-issuer-kind option must be %s or %s", types.CertManagerIssuerKind, types.CertManagerClusterIssuerKind)
+				}
+				if routerCreateOpts.Vault.Address != "" {
+					return fmt.Errorf("The --cert-manager-issuer and --vault-address options can't be used together")
+				}
+			}
+
 			if routerCreateOpts.EnableConsole && !routerCreateOpts.EnableFlowCollector {
 				return fmt.Errorf("The --enable-flow-collector option must be used with the --enable-console option")
 			}
@@ -451,6 +520,43 @@ installation that can then be connected to other skupper installations`,
 				return fmt.Errorf("for the console to work with this user or password, the --console-auth option must be set to internal")
 			}
 
+			if routerCreateOpts.AuthMode == types.ConsoleAuthModeOpenID && (routerCreateOpts.OpenIDIssuer == "" || routerCreateOpts.OpenIDClientId == "") {
+				return fmt.Errorf("the --console-openid-issuer and --console-openid-client-id options are required when --console-auth is set to openid")
+			}
+
+			if routerCreateOpts.AuthMode != types.ConsoleAuthModeOpenID && (len(routerCreateOpts.OpenIDIssuer) > 0 || len(routerCreateOpts.OpenIDClientId) > 0) {
+				return fmt.Errorf("the openid options are only valid when --console-auth is set to openid")
+			}
+
+			if routerCreateOpts.AuthMode == types.ConsoleAuthModeSAML && (routerCreateOpts.SAMLIdPMetadata == "" || routerCreateOpts.SAMLConsoleURL == "") {
+				return fmt.Errorf("the --console-saml-idp-metadata and --console-saml-url options are required when --console-auth is set to saml")
+			}
+
+			if routerCreateOpts.AuthMode != types.ConsoleAuthModeSAML && (len(routerCreateOpts.SAMLIdPMetadata) > 0 || len(routerCreateOpts.SAMLConsoleURL) > 0) {
+				return fmt.Errorf("the saml options are only valid when --console-auth is set to saml")
+			}
+
+			if initFlags.consolePasswordHash != "" {
+				if routerCreateOpts.AuthMode != "internal" {
+					return fmt.Errorf("the --console-password-hash option is only valid when --console-auth is set to internal")
+				}
+				if routerCreateOpts.User == "" {
+					routerCreateOpts.User = "admin"
+				}
+				generated := routerCreateOpts.Password == ""
+				if generated {
+					routerCreateOpts.Password = utils.RandomId(10)
+				}
+				hash, err := utils.HashPassword(routerCreateOpts.Password, initFlags.consolePasswordHash)
+				if err != nil {
+					return fmt.Errorf("Bad value for --console-password-hash: %s", err)
+				}
+				if generated {
+					fmt.Printf("The password of console user %s is %s, only its hash is stored\n", routerCreateOpts.User, routerCreateOpts.Password)
+				}
+				routerCreateOpts.Password = hash
+			}
+
 			return skupperCli.Create(cmd, args)
 		},
 	}
@@ -462,6 +568,8 @@ installation that can then be connected to other skupper installations`,
 
 	cmd.Flags().StringSliceVar(&initFlags.labels, "labels", []string{}, "Labels to add to resources created by skupper")
 	cmd.Flags().StringVarP(&routerLogging, "router-logging", "", "", "Logging settings for router. 'trace', 'debug', 'info' (default), 'notice', 'warning', and 'error' are valid values.")
+	cmd.Flags().StringVar(&initFlags.consolePasswordHash, "console-password-hash", "", "Store the console password hashed with one of: ["+strings.Join(utils.ValidPasswordHashes(), "|")+"]. A generated password is shown once. Valid only when --console-auth=internal")
+	cmd.Flags().StringSliceVar(&routerCreateOpts.CertificateHosts, "certificate-host", []string{}, "Additional DNS name or IP address for the certificate presented to linking sites, can be used multiple times.")
 
 	cmd.Flags().StringVarP(&routerCreateOpts.PrometheusServer.ExternalServer, "external-prometheus-server", "", "", "External prometheus server for metric aggregation. Valid only when --enable-flow-collector")
 	cmd.Flags().StringVarP(&routerCreateOpts.PrometheusServer.AuthMode, "prometheus-auth", "", "", "Authentication mode for skupper prometheus server. One of: 'tls', 'basic', 'unsecured'")
@@ -504,6 +612,7 @@ func NewCmdDelete(skupperCli SkupperSiteClient) *cobra.Command {
 }
 
 var forceHup bool
+var certificateHosts []string
 
 func NewCmdUpdate(skupperCli SkupperSiteClient) *cobra.Command {
 	cmd := &cobra.Command{
@@ -530,6 +639,7 @@ func NewCmdStatus(skupperCli SkupperSiteClient) *cobra.Command {
 		RunE:   skupperCli.Status,
 	}
 	cmd.Flags().BoolVarP(&verboseStatus, "verbose", "v", false, "Detailed information about Skupper status")
+	skupperCli.StatusFlags(cmd)
 	return cmd
 }
 
@@ -555,6 +665,7 @@ func NewCmdExpose(skupperCli SkupperServiceClient) *cobra.Command {
 
 	cmd.Flags().BoolVar(&exposeOpts.GeneratedCerts, "enable-tls", false, "If specified, the service will be exposed over TLS")
 	cmd.Flags().BoolVar(&exposeOpts.GeneratedCerts, "generate-tls-secrets", false, "If specified, the service will be exposed over TLS")
+	cmd.Flags().BoolVar(&exposeOpts.GenerateManifest, "generate-manifest", false, "If specified, the resources that would be applied are written to stdout instead")
 
 	f := cmd.Flag("enable-tls")
 	f.Deprecated = "use 'generate-tls-secrets' instead"
@@ -689,8 +800,10 @@ func NewCmdService() *cobra.Command {
 }
 
 var serviceToCreate types.ServiceInterface
+var serviceAggregateOptions types.AggregateOptions
 var serviceIngressMode string
 var createSvcWithGeneratedTlsCerts bool
+var createSvcGenerateManifest bool
 
 func NewCmdCreateService(skupperClient SkupperServiceClient) *cobra.Command {
 	cmd := &cobra.Command{
@@ -732,11 +845,18 @@ func NewCmdCreateService(skupperClient SkupperServiceClient) *cobra.Command {
 				serviceToCreate.TlsCredentials = types.SkupperServiceCertPrefix + serviceToCreate.Address
 			}
 
+			if !serviceAggregateOptions.IsEmpty() {
+				serviceToCreate.AggregateOptions = &serviceAggregateOptions
+			}
+
 			return skupperClient.Create(cmd, args)
 		},
 	}
 	cmd.Flags().StringVar(&serviceToCreate.Protocol, "protocol", "tcp", "The mapping in use for this service address (tcp, http, http2)")
 	cmd.Flags().StringVar(&serviceToCreate.Aggregate, "aggregate", "", "The aggregation strategy to use. One of 'json' or 'multipart'. If specified requests to this service will be sent to all registered implementations and the responses aggregated.")
+	cmd.Flags().StringVar(&serviceAggregateOptions.Timeout, "aggregate-timeout", "", "How long to wait for the responses of an aggregated service, e.g. 5s. Defaults to the router's timeout.")
+	cmd.Flags().StringVar(&serviceAggregateOptions.Partial, "aggregate-partial", "", "What to do when some responses of an aggregated service are missing. One of 'fail' (default) or 'allow' to aggregate the responses received.")
+	cmd.Flags().IntVar(&serviceAggregateOptions.MaxParallel, "aggregate-max-parallel", 0, "The maximum number of implementations of an aggregated service called at once for a request. Unlimited by default.")
 	cmd.Flags().StringVar(&serviceIngressMode, "enable-ingress", "", "Determines whether access to the Skupper service is enabled in this site. Valid values are Always (default) or Never.")
 	cmd.Flags().BoolVar(&serviceToCreate.EventChannel, "event-channel", false, "If specified, this service will be a channel for multicast events.")
 	cmd.Flags().BoolVar(&createSvcWithGeneratedTlsCerts, "enable-tls", false, "If specified, the service communication will be encrypted using TLS")
@@ -744,6 +864,7 @@ func NewCmdCreateService(skupperClient SkupperServiceClient) *cobra.Command {
 	cmd.Flags().BoolVar(&createSvcWithGeneratedTlsCerts, "generate-tls-secrets", false, "If specified, the service communication will be encrypted using TLS")
 	cmd.Flags().StringVar(&serviceToCreate.BridgeImage, "bridge-image", "", "The image to use for a bridge running external to the skupper router")
 	cmd.Flags().StringVar(&serviceToCreate.TlsCredentials, "tls-cert", "", "K8s secret name with custom certificates to encrypt the communication using TLS")
+	cmd.Flags().BoolVar(&createSvcGenerateManifest, "generate-manifest", false, "If specified, the resources that would be applied are written to stdout instead")
 
 	// platform specific flags
 	skupperClient.CreateFlags(cmd)
@@ -941,16 +1062,39 @@ func NewCmdDebugPolicies(skupperClient SkupperDebugClient) *cobra.Command {
 	return cmd
 }
 
+var logLevelComponent string
+
+func NewCmdDebugLogLevel(skupperClient SkupperDebugClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "log-level [debug|info|warn|error]",
+		Short: "Show or change the log level of the service controller or of the flow collector, without restarting them",
+		Example: `
+	# Turn on the debug logs of the flow collector during an incident
+	skupper debug log-level debug --component flow-collector`,
+		Args:   cobra.MaximumNArgs(1),
+		PreRun: skupperClient.NewClient,
+		RunE:   skupperClient.LogLevel,
+	}
+	cmd.Flags().StringVar(&logLevelComponent, "component", "service-controller", "The component, one of service-controller or flow-collector")
+	return cmd
+}
+
+var revokeLinkCredential string
+
 func NewCmdRevokeaccess(skupperClient SkupperSiteClient) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "revoke-access",
 		Short: "Revoke all previously granted access to the site.",
 		Long: `This will invalidate all previously issued tokens and require that all
-links to this site be re-established with new tokens.`,
+links to this site be re-established with new tokens.
+
+With --link-credential only the matching link credential is revoked, the
+links established with it are closed and the other links are kept.`,
 		Args:   cobra.ExactArgs(0),
 		PreRun: skupperClient.NewClient,
 		RunE:   skupperClient.RevokeAccess,
 	}
+	cmd.Flags().StringVar(&revokeLinkCredential, "link-credential", "", "Revoke only the link credential with this subject, serial number or fingerprint. The subject is the name of the token for cert tokens and the ID of the redeeming site for claims")
 	return cmd
 }
 
@@ -1032,7 +1176,7 @@ func init() {
 	rootCmd = &cobra.Command{Use: "skupper"}
 	routev1.AddToScheme(scheme.Scheme)
 
-	rootCmd.PersistentFlags().StringVarP(&config.Platform, "platform", "", "", "The platform type to use [kubernetes, podman]")
+	rootCmd.PersistentFlags().StringVarP(&config.Platform, "platform", "", "", "The platform type to use [kubernetes, podman, docker]")
 	rootCmd.ParseFlags(os.Args)
 
 	var skupperCli SkupperClient
@@ -1041,6 +1185,8 @@ func init() {
 		skupperCli = &SkupperKube{}
 	case types.PlatformPodman:
 		skupperCli = &SkupperPodman{}
+	case types.PlatformDocker:
+		skupperCli = &SkupperPodman{platform: types.PlatformDocker}
 	default:
 		fmt.Printf("invalid platform: %s", config.GetPlatform())
 		fmt.Println()
@@ -1066,8 +1212,10 @@ func init() {
 	cmdDebugEvents := NewCmdDebugEvents(skupperCli.Debug())
 	cmdDebugService := NewCmdDebugService(skupperCli.Debug())
 	cmdDebugPolicies := NewCmdDebugPolicies(skupperCli.Debug())
+	cmdDebugLogLevel := NewCmdDebugLogLevel(skupperCli.Debug())
 
-	// Gateway command is only valid on Kubernetes sites
+	// Gateway init, delete, expose and bundles are only valid on Kubernetes sites,
+	// podman sites bind and forward the host through the service containers
 	cmdGateway := NewCmdGateway()
 	if skupperKube, ok := skupperCli.(*SkupperKube); ok {
 		cmdInitGateway := NewCmdInitGateway(skupperKube)
@@ -1093,6 +1241,24 @@ func init() {
 		cmdGateway.AddCommand(cmdUnbindGateway)
 		cmdGateway.AddCommand(cmdForwardGateway)
 		cmdGateway.AddCommand(cmdUnforwardGateway)
+	} else if skupperPodman, ok := skupperCli.(*SkupperPodman); ok {
+		cmdGateway.AddCommand(NewCmdStatusGatewayPodman(skupperPodman))
+		cmdGateway.AddCommand(NewCmdBindGatewayPodman(skupperPodman))
+		cmdGateway.AddCommand(NewCmdUnbindGatewayPodman(skupperPodman))
+		cmdGateway.AddCommand(NewCmdForwardGatewayPodman(skupperPodman))
+		cmdGateway.AddCommand(NewCmdUnforwardGatewayPodman(skupperPodman))
+	}
+
+	// The podman sites are upgraded and exported with commands of their own
+	var cmdUpgrade, cmdExportCompose *cobra.Command
+	if skupperPodman, ok := skupperCli.(*SkupperPodman); ok {
+		cmdUpgrade = NewCmdUpgradePodman(skupperPodman.Site().(*SkupperPodmanSite))
+		cmdExportCompose = NewCmdExportComposePodman(skupperPodman.Site().(*SkupperPodmanSite))
+	}
+
+	cmdCertificate := NewCmdCertificate()
+	if skupperKube, ok := skupperCli.(*SkupperKube); ok {
+		cmdCertificate.AddCommand(NewCmdCertificateRotate(skupperKube))
 	}
 
 	// setup subcommands
@@ -1109,9 +1275,13 @@ func init() {
 
 	cmdDebug := NewCmdDebug()
 	cmdDebug.AddCommand(cmdDebugDump)
-	cmdDebug.AddCommand(cmdDebugEvents)
-	cmdDebug.AddCommand(cmdDebugService)
-	cmdDebug.AddCommand(cmdDebugPolicies)
+	// Only the dump is supported on podman sites
+	if config.GetPlatform() == types.PlatformKubernetes {
+		cmdDebug.AddCommand(cmdDebugEvents)
+		cmdDebug.AddCommand(cmdDebugService)
+		cmdDebug.AddCommand(cmdDebugPolicies)
+		cmdDebug.AddCommand(cmdDebugLogLevel)
+	}
 
 	cmdLink := NewCmdLink()
 	cmdLink.AddCommand(NewCmdLinkCreate(skupperCli.Link(), ""))
@@ -1127,9 +1297,16 @@ func init() {
 
 	cmdNetwork := NewCmdNetwork()
 	cmdNetwork.AddCommand(NewCmdNetworkStatus(skupperCli.Network()))
+	cmdNetwork.AddCommand(NewCmdNetworkDiff(skupperCli.Network()))
+	cmdNetwork.AddCommand(NewCmdNetworkPosture(skupperCli.Network()))
 
 	cmdSwitch := NewCmdSwitch()
 
+	cmdContext := NewCmdContext()
+
+	cmdHost := NewCmdHost()
+	cmdHost.AddCommand(NewCmdHostRegister())
+
 	addCommands(skupperCli, rootCmd,
 		cmdInit,
 		cmdDelete,
@@ -1145,9 +1322,16 @@ func init() {
 		cmdCompletion,
 		cmdGateway,
 		cmdRevokeAll,
-		cmdNetwork)
+		cmdCertificate,
+		cmdNetwork,
+		cmdContext)
+	if cmdUpgrade != nil {
+		addCommands(skupperCli, rootCmd, cmdUpgrade, cmdExportCompose)
+	}
 
 	rootCmd.AddCommand(cmdSwitch)
+	rootCmd.AddCommand(cmdHost)
+	rootCmd.AddCommand(NewCmdDashboard())
 	rootCmd.AddCommand(NewCmdMan())
 	skupperCli.Options(rootCmd)
 
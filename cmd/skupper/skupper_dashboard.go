@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/skupperproject/skupper/pkg/flow"
+	"github.com/spf13/cobra"
+)
+
+type dashboardOptions struct {
+	Title  string
+	Uid    string
+	Output string
+}
+
+var dashboardOpts dashboardOptions
+
+func NewCmdDashboard() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dashboard",
+		Short: "Generate a Grafana dashboard for the metrics of the flow collector",
+		Long: `Generate a Grafana dashboard for the metrics of the flow collector, showing the
+topology of the network, the latency per address and the throughput per site. The
+dashboard is ready to be imported, the Prometheus data source being selected in it.`,
+		Example: `
+	# Write the dashboard to a file, to be imported in Grafana
+	skupper dashboard --output skupper-dashboard.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			data, err := flow.GrafanaDashboard(dashboardOpts.Title, dashboardOpts.Uid)
+			if err != nil {
+				return fmt.Errorf("Unable to generate the dashboard: %w", err)
+			}
+			if dashboardOpts.Output == "" {
+				fmt.Println(string(data))
+				return nil
+			}
+			if err := os.WriteFile(dashboardOpts.Output, append(data, '\n'), 0644); err != nil {
+				return fmt.Errorf("Unable to write the dashboard: %w", err)
+			}
+			fmt.Printf("Dashboard written to %s\n", dashboardOpts.Output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&dashboardOpts.Title, "title", "Skupper Network", "Title of the dashboard")
+	cmd.Flags().StringVar(&dashboardOpts.Uid, "uid", "skupper-network", "Unique identifier of the dashboard in Grafana, so that imports replace it")
+	cmd.Flags().StringVarP(&dashboardOpts.Output, "output", "o", "", "File to write the dashboard to, instead of the standard output")
+	return cmd
+}
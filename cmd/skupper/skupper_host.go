@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/skupperproject/skupper/pkg/flow"
+	"github.com/spf13/cobra"
+)
+
+// hostPasswordEnv holds the password of the flow collector user, so that it
+// is not given on the command line
+const hostPasswordEnv = "SKUPPER_COLLECTOR_PASSWORD"
+
+type hostRegisterOptions struct {
+	CollectorUrl string
+	Site         string
+	CaFile       string
+	Token        string
+	TokenFile    string
+	User         string
+	Password     string
+	PasswordFile string
+	Source       string
+	Address      string
+	Processes    []string
+	Interval     time.Duration
+}
+
+var hostRegisterOpts hostRegisterOptions
+
+func NewCmdHost() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "host [command]",
+		Short: "Report plain hosts (VMs, bare metal) and their processes to a flow collector",
+	}
+	return cmd
+}
+
+func NewCmdHostRegister() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "register",
+		Short: "Register this host and its processes with the flow collector ingestion API",
+		Long: `Register this host and its processes with the flow collector ingestion API,
+so that non-containerized workloads reached through a gateway are shown in the console.
+With --interval the command keeps running as an agent, refreshing the records until
+it is interrupted, at which point the records are ended.
+
+The ingestion API is restricted to the admin role, so the user or the api token
+used must hold it. The password of the user is read from --password-file or from
+the ` + hostPasswordEnv + ` environment variable.`,
+		Example: `
+	# Register this host and a database process once
+	skupper host register --collector-url https://skupper.example.com:8010 --ca ca.crt --token-file token --site <site-id> --process postgres
+
+	# Keep the records up to date every minute
+	skupper host register --collector-url https://skupper.example.com:8010 --ca ca.crt --token-file token --site <site-id> --process postgres:databases --interval 1m`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			if hostRegisterOpts.CollectorUrl == "" {
+				return fmt.Errorf("--collector-url is required")
+			}
+			if hostRegisterOpts.Site == "" {
+				return fmt.Errorf("--site is required")
+			}
+			if err := readHostCredentials(&hostRegisterOpts); err != nil {
+				return err
+			}
+			client, err := newHostClient(hostRegisterOpts)
+			if err != nil {
+				return err
+			}
+			batch, err := newHostBatch(hostRegisterOpts)
+			if err != nil {
+				return err
+			}
+			if err := postHostBatch(client, hostRegisterOpts, batch); err != nil {
+				return err
+			}
+			fmt.Printf("Host %s registered with %d process(es)\n", *batch.Hosts[0].Name, len(batch.Processes))
+			if hostRegisterOpts.Interval == 0 {
+				return nil
+			}
+
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+			ticker := time.NewTicker(hostRegisterOpts.Interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					if err := postHostBatch(client, hostRegisterOpts, batch); err != nil {
+						fmt.Fprintf(os.Stderr, "Failed to refresh host records: %s\n", err)
+					}
+				case <-signals:
+					endHostBatch(&batch)
+					if err := postHostBatch(client, hostRegisterOpts, batch); err != nil {
+						return err
+					}
+					fmt.Printf("Host %s unregistered\n", *batch.Hosts[0].Name)
+					return nil
+				}
+			}
+		},
+	}
+	cmd.Flags().StringVar(&hostRegisterOpts.CollectorUrl, "collector-url", "", "URL of the flow collector, e.g. https://skupper.example.com:8010")
+	cmd.Flags().StringVar(&hostRegisterOpts.Site, "site", "", "Identity of the site the host is attached to, usually the site of its gateway")
+	cmd.Flags().StringVar(&hostRegisterOpts.CaFile, "ca", "", "File holding the certificate authority the flow collector certificate is verified with, the system ones being used otherwise")
+	cmd.Flags().StringVar(&hostRegisterOpts.Token, "token", "", "Api token for the flow collector, --token-file keeping it out of the process list")
+	cmd.Flags().StringVar(&hostRegisterOpts.TokenFile, "token-file", "", "File holding the api token for the flow collector")
+	cmd.Flags().StringVar(&hostRegisterOpts.User, "user", "", "User name for the flow collector")
+	cmd.Flags().StringVar(&hostRegisterOpts.PasswordFile, "password-file", "", "File holding the password of the user, read from "+hostPasswordEnv+" otherwise")
+	cmd.Flags().StringVar(&hostRegisterOpts.Source, "source", "skupper-host", "Name identifying the reporter in the collector")
+	cmd.Flags().StringVar(&hostRegisterOpts.Address, "address", "", "Address of the host processes, defaults to the first non-loopback address")
+	cmd.Flags().StringSliceVar(&hostRegisterOpts.Processes, "process", []string{}, "Process to report, as <name>[:<group>] (can be repeated)")
+	cmd.Flags().DurationVar(&hostRegisterOpts.Interval, "interval", 0, "Keep running and refresh the records at this interval")
+	return cmd
+}
+
+// readHostCredentials reads the api token or the password of the user from
+// their files or from the environment
+func readHostCredentials(opts *hostRegisterOptions) error {
+	if opts.TokenFile != "" {
+		if opts.Token != "" {
+			return fmt.Errorf("--token and --token-file cannot be used together")
+		}
+		token, err := os.ReadFile(opts.TokenFile)
+		if err != nil {
+			return fmt.Errorf("unable to read the api token: %w", err)
+		}
+		opts.Token = strings.TrimSpace(string(token))
+	}
+	if opts.User == "" {
+		if opts.PasswordFile != "" {
+			return fmt.Errorf("--password-file requires --user")
+		}
+		return nil
+	}
+	if opts.Token != "" {
+		return fmt.Errorf("--user cannot be used with an api token")
+	}
+	if opts.PasswordFile != "" {
+		password, err := os.ReadFile(opts.PasswordFile)
+		if err != nil {
+			return fmt.Errorf("unable to read the password: %w", err)
+		}
+		opts.Password = strings.TrimRight(string(password), "\r\n")
+	} else {
+		opts.Password = os.Getenv(hostPasswordEnv)
+	}
+	if opts.Password == "" {
+		return fmt.Errorf("no password for user %s, it is read from --password-file or %s", opts.User, hostPasswordEnv)
+	}
+	return nil
+}
+
+// newHostClient returns the client of the ingestion API, verifying the
+// flow collector certificate with the given certificate authority
+func newHostClient(opts hostRegisterOptions) (*http.Client, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	if opts.CaFile == "" {
+		return client, nil
+	}
+	ca, err := os.ReadFile(opts.CaFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the certificate authority: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificate found in %s", opts.CaFile)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		RootCAs:    roots,
+		MinVersion: tls.VersionTLS12,
+	}
+	client.Transport = transport
+	return client, nil
+}
+
+func newHostBatch(opts hostRegisterOptions) (flow.IngestBatch, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return flow.IngestBatch{}, err
+	}
+	address := opts.Address
+	if address == "" {
+		address = defaultHostAddress()
+	}
+	hostIdentity := "host-" + hostMachineId(hostname)
+	startTime := uint64(time.Now().UnixNano()) / uint64(time.Microsecond)
+	arch := runtime.GOARCH
+	operatingSystem := runtime.GOOS
+	host := flow.HostRecord{
+		Base: flow.Base{
+			Identity:  hostIdentity,
+			Parent:    opts.Site,
+			StartTime: startTime,
+		},
+		Name:            &hostname,
+		Arch:            &arch,
+		OperatingSystem: &operatingSystem,
+	}
+	if osId := readOsReleaseId("/etc/os-release"); osId != "" {
+		host.OperatingSystemId = &osId
+	}
+	if kernel := readFirstLine("/proc/sys/kernel/osrelease"); kernel != "" {
+		host.KernelVersion = &kernel
+	}
+
+	batch := flow.IngestBatch{
+		Source: opts.Source,
+		Hosts:  []flow.HostRecord{host},
+	}
+	for _, spec := range opts.Processes {
+		name, group, _ := strings.Cut(spec, ":")
+		if name == "" {
+			return batch, fmt.Errorf("invalid process %q, expected <name>[:<group>]", spec)
+		}
+		if group == "" {
+			group = name
+		}
+		process := flow.ProcessRecord{
+			Base: flow.Base{
+				Identity:  hostIdentity + "-" + name,
+				Parent:    opts.Site,
+				StartTime: startTime,
+			},
+			Name:      &name,
+			GroupName: &group,
+			HostName:  &hostname,
+		}
+		if address != "" {
+			process.SourceHost = &address
+		}
+		batch.Processes = append(batch.Processes, process)
+	}
+	return batch, nil
+}
+
+// endHostBatch marks all records in the batch as ended
+func endHostBatch(batch *flow.IngestBatch) {
+	now := uint64(time.Now().UnixNano()) / uint64(time.Microsecond)
+	for i := range batch.Hosts {
+		batch.Hosts[i].EndTime = now
+	}
+	for i := range batch.Processes {
+		batch.Processes[i].EndTime = now
+	}
+}
+
+func postHostBatch(client *http.Client, opts hostRegisterOptions, batch flow.IngestBatch) error {
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	url := strings.TrimSuffix(opts.CollectorUrl, "/") + "/api/v1alpha1/ingest/"
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if opts.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.Token)
+	} else if opts.User != "" {
+		req.SetBasicAuth(opts.User, opts.Password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("flow collector returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	result := flow.IngestResult{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return err
+	}
+	if len(result.Rejected) > 0 {
+		reasons := []string{}
+		for _, rejected := range result.Rejected {
+			reasons = append(reasons, fmt.Sprintf("%s %s: %s", rejected.RecType, rejected.Identity, rejected.Error))
+		}
+		return fmt.Errorf("flow collector rejected %d record(s): %s", len(result.Rejected), strings.Join(reasons, "; "))
+	}
+	return nil
+}
+
+// hostMachineId returns a stable identifier for the host, so records are
+// updated rather than duplicated when the command is run again
+func hostMachineId(hostname string) string {
+	if id := readFirstLine("/etc/machine-id"); id != "" {
+		return id
+	}
+	return hostname
+}
+
+func defaultHostAddress() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ""
+	}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && ipnet.IP.To4() != nil {
+			return ipnet.IP.String()
+		}
+	}
+	return ""
+}
+
+func readFirstLine(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	if scanner.Scan() {
+		return strings.TrimSpace(scanner.Text())
+	}
+	return ""
+}
+
+func readOsReleaseId(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "ID="); ok {
+			return strings.Trim(value, `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skupperproject/skupper/pkg/flow"
+	"gotest.tools/assert"
+)
+
+func TestNewHostBatch(t *testing.T) {
+	opts := hostRegisterOptions{
+		Site:      "site-1",
+		Source:    "inventory",
+		Address:   "10.0.0.5",
+		Processes: []string{"postgres:databases", "nginx"},
+	}
+	batch, err := newHostBatch(opts)
+	assert.Assert(t, err)
+	assert.Equal(t, batch.Source, "inventory")
+	assert.Equal(t, len(batch.Hosts), 1)
+	assert.Equal(t, batch.Hosts[0].Parent, "site-1")
+	assert.Equal(t, len(batch.Processes), 2)
+	assert.Equal(t, *batch.Processes[0].Name, "postgres")
+	assert.Equal(t, *batch.Processes[0].GroupName, "databases")
+	assert.Equal(t, *batch.Processes[1].GroupName, "nginx")
+	assert.Equal(t, *batch.Processes[1].SourceHost, "10.0.0.5")
+	assert.Equal(t, batch.Processes[0].Identity, batch.Hosts[0].Identity+"-postgres")
+
+	endHostBatch(&batch)
+	assert.Assert(t, batch.Hosts[0].EndTime >= batch.Hosts[0].StartTime)
+	assert.Assert(t, batch.Processes[1].EndTime > 0)
+
+	_, err = newHostBatch(hostRegisterOptions{Site: "site-1", Processes: []string{":group"}})
+	assert.ErrorContains(t, err, "invalid process")
+}
+
+func TestPostHostBatch(t *testing.T) {
+	rejected := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Method, http.MethodPost)
+		assert.Equal(t, r.URL.Path, "/api/v1alpha1/ingest/")
+		batch := flow.IngestBatch{}
+		assert.Assert(t, json.NewDecoder(r.Body).Decode(&batch))
+		result := flow.IngestResult{Accepted: len(batch.Hosts), Rejected: []flow.IngestError{}}
+		if rejected {
+			result.Rejected = append(result.Rejected, flow.IngestError{RecType: "HOST", Identity: "host-1", Error: "parent site site-1 not found"})
+		}
+		json.NewEncoder(w).Encode(result)
+	}))
+	defer server.Close()
+
+	opts := hostRegisterOptions{CollectorUrl: server.URL + "/", Site: "site-1", Source: "test"}
+	batch, err := newHostBatch(opts)
+	assert.Assert(t, err)
+	assert.Assert(t, postHostBatch(server.Client(), opts, batch))
+
+	rejected = true
+	assert.ErrorContains(t, postHostBatch(server.Client(), opts, batch), "parent site site-1 not found")
+}
+
+func TestReadHostCredentials(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	assert.Assert(t, os.WriteFile(tokenFile, []byte("skupper-token\n"), 0600))
+	passwordFile := filepath.Join(dir, "password")
+	assert.Assert(t, os.WriteFile(passwordFile, []byte("secret\n"), 0600))
+
+	opts := hostRegisterOptions{TokenFile: tokenFile}
+	assert.Assert(t, readHostCredentials(&opts))
+	assert.Equal(t, opts.Token, "skupper-token")
+
+	opts = hostRegisterOptions{User: "admin", PasswordFile: passwordFile}
+	assert.Assert(t, readHostCredentials(&opts))
+	assert.Equal(t, opts.Password, "secret")
+
+	t.Setenv(hostPasswordEnv, "from-env")
+	opts = hostRegisterOptions{User: "admin"}
+	assert.Assert(t, readHostCredentials(&opts))
+	assert.Equal(t, opts.Password, "from-env")
+
+	t.Setenv(hostPasswordEnv, "")
+	assert.ErrorContains(t, readHostCredentials(&hostRegisterOptions{User: "admin"}), "no password for user admin")
+	assert.ErrorContains(t, readHostCredentials(&hostRegisterOptions{Token: "a", TokenFile: tokenFile}), "cannot be used together")
+	assert.ErrorContains(t, readHostCredentials(&hostRegisterOptions{Token: "a", User: "admin"}), "cannot be used with an api token")
+	assert.ErrorContains(t, readHostCredentials(&hostRegisterOptions{PasswordFile: passwordFile}), "requires --user")
+}
+
+func TestNewHostClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer skupper-token" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(flow.IngestResult{Rejected: []flow.IngestError{}})
+	}))
+	defer server.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	assert.Assert(t, os.WriteFile(caFile, ca, 0600))
+
+	opts := hostRegisterOptions{CollectorUrl: server.URL, Site: "site-1", Source: "test", Token: "skupper-token"}
+	batch, err := newHostBatch(opts)
+	assert.Assert(t, err)
+
+	// the certificate of the collector is not trusted without the ca
+	client, err := newHostClient(opts)
+	assert.Assert(t, err)
+	assert.ErrorContains(t, postHostBatch(client, opts, batch), "certificate")
+
+	opts.CaFile = caFile
+	client, err = newHostClient(opts)
+	assert.Assert(t, err)
+	assert.Assert(t, postHostBatch(client, opts, batch))
+
+	opts.Token = "other-token"
+	assert.ErrorContains(t, postHostBatch(client, opts, batch), "401 Unauthorized")
+
+	_, err = newHostClient(hostRegisterOptions{CaFile: filepath.Join(t.TempDir(), "missing")})
+	assert.ErrorContains(t, err, "unable to read the certificate authority")
+}
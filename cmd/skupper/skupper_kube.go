@@ -11,7 +11,7 @@ var SkupperKubeCommands = []string{
 	"init", "delete", "update", "connection-token", "token", "link", "connect", "disconnect",
 	"check-connection", "status", "list-connectors", "expose", "unexpose", "list-exposed",
 	"service", "bind", "unbind", "version", "debug", "completion", "gateway", "revoke-access",
-	"network", "switch",
+	"certificate", "network", "switch",
 }
 
 type SkupperKube struct {
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/utils/formatter"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdCertificate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "certificate rotate",
+		Short: "Manage the certificates of the site",
+	}
+	return cmd
+}
+
+func NewCmdCertificateRotate(kube *SkupperKube) *cobra.Command {
+	options := types.CertificateRotateOptions{}
+	cmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "Regenerate the CAs and/or the certificates of the site",
+		Long: `Regenerate the site and local CAs and/or the router, controller and console
+certificates they sign, update their secrets and restart the router and then
+the service controller to load them.
+
+Rotating the site CA invalidates all previously issued tokens and requires
+that all links to this site be re-established with new tokens.`,
+		Args:   cobra.NoArgs,
+		PreRun: kube.NewClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			if !options.CA && !options.Leaf && !options.DryRun {
+				return fmt.Errorf("Select the certificates to rotate with --ca and/or --leaf, or use --dry-run to list them")
+			}
+			certificates, err := kube.Cli.CertificatesRotate(context.Background(), options)
+			if err != nil {
+				return fmt.Errorf("Unable to rotate the certificates: %w", err)
+			}
+			printCertificates(certificates, time.Now())
+			if options.CA && !options.DryRun {
+				fmt.Println("The site CA has been rotated, links to this site need to be re-established with new tokens")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&options.CA, "ca", false, "Regenerate the CAs of the site along with all the certificates they sign")
+	cmd.Flags().BoolVar(&options.Leaf, "leaf", false, "Regenerate the certificates signed by the CAs of the site, keeping the CAs")
+	cmd.Flags().BoolVar(&options.DryRun, "dry-run", false, "Only show the certificates of the site and their expiry dates")
+	return cmd
+}
+
+func printCertificates(certificates []types.CertificateInfo, now time.Time) {
+	if len(certificates) == 0 {
+		fmt.Println("No certificates found")
+		return
+	}
+	certList := formatter.NewList()
+	certList.Item("Certificates:")
+	for _, cert := range certificates {
+		status := "expires"
+		if cert.Rotated {
+			status = "rotated, expires"
+		}
+		item := certList.NewChild(fmt.Sprintf("%s (%s %s, in %d days)", cert.Name, status, cert.NotAfter.Local().Format(time.RFC3339), int(cert.NotAfter.Sub(now).Hours()/24)))
+		if cert.CA != "" {
+			item.NewChild(fmt.Sprintf("Signed by: %s", cert.CA))
+		}
+		if cert.Subject != "" {
+			item.NewChild(fmt.Sprintf("Subject: %s", cert.Subject))
+		}
+		if len(cert.Hosts) > 0 {
+			item.NewChild(fmt.Sprintf("Hosts: %s", strings.Join(cert.Hosts, ", ")))
+		}
+		if cert.Rotated && len(cert.Deployments) > 0 {
+			item.NewChild(fmt.Sprintf("Restarted: %s", strings.Join(cert.Deployments, ", ")))
+		}
+	}
+	certList.Print()
+}
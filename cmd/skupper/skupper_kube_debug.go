@@ -54,6 +54,20 @@ func (s *SkupperKubeDebug) Service(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func (s *SkupperKubeDebug) LogLevel(cmd *cobra.Command, args []string) error {
+	silenceCobra(cmd)
+	level := ""
+	if len(args) > 0 {
+		level = args[0]
+	}
+	output, err := s.kube.Cli.SkupperLogLevel(logLevelComponent, level)
+	if err != nil {
+		return fmt.Errorf("Unable to access the log level of the %s: %w", logLevelComponent, err)
+	}
+	os.Stdout.Write(output.Bytes())
+	return nil
+}
+
 func (s *SkupperKubeDebug) Policies(cmd *cobra.Command, args []string) error {
 	silenceCobra(cmd)
 	cpv := client.NewClusterPolicyValidator(s.kube.Cli.(*client.VanClient))
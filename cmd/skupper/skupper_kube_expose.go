@@ -89,7 +89,7 @@ func (s *SkupperKubeService) Expose(cmd *cobra.Command, args []string) error {
 	}
 
 	addr, err := expose(s.kube.Cli, context.Background(), targetType, targetName, exposeOpts)
-	if err == nil {
+	if err == nil && !exposeOpts.GenerateManifest {
 		fmt.Printf("%s %s exposed as %s\n", targetType, targetName, addr)
 	}
 	return err
@@ -1,18 +1,25 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/client"
 	"github.com/skupperproject/skupper/pkg/domain"
 	"github.com/skupperproject/skupper/pkg/domain/kube"
 	"github.com/skupperproject/skupper/pkg/qdr"
+	"github.com/skupperproject/skupper/pkg/utils"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/client-go/kubernetes/scheme"
 )
 
 type SkupperKubeLink struct {
@@ -37,6 +44,13 @@ func (s *SkupperKubeLink) Create(cmd *cobra.Command, args []string) error {
 		os.Exit(1)
 	}
 	connectorCreateOpts.SkupperNamespace = cli.GetNamespace()
+	mutual := isMutualClaim(connectorCreateOpts.Secret) && !linkOneWay
+	if mutual {
+		if err := s.attachReverseClaim(cmd, connectorCreateOpts.Secret); err != nil {
+			fmt.Printf("Unable to request a link back to this site, linking one way only: %s\n", err)
+			mutual = false
+		}
+	}
 	secret, err := cli.ConnectorCreateSecretFromData(context.Background(), connectorCreateOpts)
 	if err != nil {
 		return fmt.Errorf("Failed to create link: %w", err)
@@ -59,10 +73,37 @@ func (s *SkupperKubeLink) Create(cmd *cobra.Command, args []string) error {
 				secret.ObjectMeta.Name)
 		}
 	}
+	if mutual {
+		fmt.Println("The remote site will link back to this site once the token is redeemed.")
+	}
 	fmt.Println("Check the status of the link using 'skupper link status'.")
 	return nil
 }
 
+// attachReverseClaim adds a single use claim for this site to a mutual
+// claim, which the issuing site redeems to link back to this site
+func (s *SkupperKubeLink) attachReverseClaim(cmd *cobra.Command, secret *corev1.Secret) error {
+	cost := connectorCreateOpts.Cost
+	if cmd.Flag("reverse-cost").Changed {
+		cost = linkReverseCost
+	}
+	reverse, _, err := s.kube.Cli.TokenClaimCreate(context.Background(), "", []byte(utils.RandomId(24)), 15*time.Minute, 1)
+	if err != nil {
+		return err
+	}
+	reverse.ObjectMeta.Annotations[types.TokenCost] = strconv.Itoa(int(cost))
+	var buf bytes.Buffer
+	ys := json.NewYAMLSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+	if err := ys.Encode(reverse, &buf); err != nil {
+		return err
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[types.ClaimReverseDataKey] = buf.Bytes()
+	return nil
+}
+
 func (s *SkupperKubeLink) CreateFlags(cmd *cobra.Command) {
 	// TODO implement me
 	panic("implement me")
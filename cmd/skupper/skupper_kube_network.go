@@ -44,3 +44,17 @@ func (s *SkupperKubeNetwork) Status(cmd *cobra.Command, args []string, ctx conte
 }
 
 func (s *SkupperKubeNetwork) StatusFlags(cmd *cobra.Command) {}
+
+func (s *SkupperKubeNetwork) CollectorSummary(ctx context.Context) (*network.CollectorSummary, error) {
+	return nil, nil
+}
+
+// CollectorPosture requires the url of the flow collector, which is not
+// reachable from outside the cluster by default
+func (s *SkupperKubeNetwork) CollectorPosture(ctx context.Context) ([]network.PostureReport, error) {
+	return nil, fmt.Errorf("The url of the flow collector is required on kubernetes, use --collector-url")
+}
+
+func (s *SkupperKubeNetwork) History(ctx context.Context) ([]network.NetworkSnapshot, error) {
+	return s.kube.Cli.NetworkStatusHistory(ctx)
+}
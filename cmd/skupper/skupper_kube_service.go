@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
 	"github.com/spf13/cobra"
 )
 
@@ -25,6 +26,17 @@ func (s *SkupperKubeService) Platform() types.Platform {
 }
 
 func (s *SkupperKubeService) Create(cmd *cobra.Command, args []string) error {
+	if createSvcGenerateManifest {
+		vanClient, ok := s.kube.Cli.(*client.VanClient)
+		if !ok {
+			return fmt.Errorf("--generate-manifest is not supported by this client")
+		}
+		manifest, err := vanClient.ServiceInterfaceCreateManifest(context.Background(), &serviceToCreate)
+		if err != nil {
+			return err
+		}
+		return writeServiceInterfaceManifest(manifest, serviceToCreate.Address)
+	}
 	err := s.kube.Cli.ServiceInterfaceCreate(context.Background(), &serviceToCreate)
 	if err != nil {
 		return fmt.Errorf("%w", err)
@@ -171,7 +183,9 @@ func (s *SkupperKubeService) Bind(cmd *cobra.Command, args []string) error {
 			}
 
 			err = configureHeadlessProxy(service.Headless, &bindOptions.ProxyTuning)
-
+			if err != nil {
+				return err
+			}
 			return s.kube.Cli.ServiceInterfaceUpdate(context.Background(), service)
 		}
 
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"github.com/skupperproject/skupper/pkg/certs"
 	"github.com/skupperproject/skupper/pkg/network"
 	"github.com/skupperproject/skupper/pkg/utils"
 	"reflect"
@@ -135,9 +136,14 @@ func (s *SkupperKubeSite) CreateFlags(cmd *cobra.Command) {
 	cmd.Flag("ingress").Usage += " If not specified route is used when available, otherwise loadbalancer is used."
 	cmd.Flags().StringVarP(&routerCreateOpts.IngressHost, "ingress-host", "", "", "Hostname or alias by which the ingress route or proxy can be reached")
 	cmd.Flags().BoolVarP(&routerCreateOpts.CreateNetworkPolicy, "create-network-policy", "", false, "Create network policy to restrict access to skupper services exposed through this site to current pods in namespace")
-	cmd.Flags().StringVarP(&routerCreateOpts.AuthMode, "console-auth", "", "internal", "Authentication mode for console(s). One of: 'openshift', 'internal', 'unsecured'")
+	cmd.Flags().BoolVarP(&routerCreateOpts.ComponentNetworkPolicies, "create-component-network-policies", "", false, "Create network policies allowing only the required peers and ports to reach the router, controller, flow collector and prometheus pods, for namespaces denying ingress by default")
+	cmd.Flags().StringVarP(&routerCreateOpts.AuthMode, "console-auth", "", "internal", "Authentication mode for console(s). One of: 'openshift', 'internal', 'unsecured', 'openid', 'saml', 'kubernetes'")
 	cmd.Flags().StringVarP(&routerCreateOpts.User, "console-user", "", "", "Skupper console user. Valid only when --console-auth=internal")
 	cmd.Flags().StringVarP(&routerCreateOpts.Password, "console-password", "", "", "Skupper console user. Valid only when --console-auth=internal")
+	cmd.Flags().StringVarP(&routerCreateOpts.OpenIDIssuer, "console-openid-issuer", "", "", "Issuer url of the OpenID Connect provider validating console tokens. Valid only when --console-auth=openid")
+	cmd.Flags().StringVarP(&routerCreateOpts.OpenIDClientId, "console-openid-client-id", "", "", "Client id the console tokens must be issued for, required when --console-auth=openid")
+	cmd.Flags().StringVarP(&routerCreateOpts.SAMLIdPMetadata, "console-saml-idp-metadata", "", "", "Url or file path, as seen by the collector, of the SAML identity provider metadata. Valid only when --console-auth=saml")
+	cmd.Flags().StringVarP(&routerCreateOpts.SAMLConsoleURL, "console-saml-url", "", "", "External url of the console, used to build the SAML service provider endpoints. Valid only when --console-auth=saml")
 	cmd.Flags().StringVarP(&routerCreateOpts.ConsoleIngress, "console-ingress", "", "", "Determines if/how console is exposed outside cluster. If not specified uses value of --ingress. One of: ["+strings.Join(types.ValidIngressOptions(s.kube.Platform()), "|")+"].")
 	cmd.Flags().BoolVarP(&routerCreateOpts.EnableRestAPI, "enable-rest-api", "", false, "Enable REST API")
 	cmd.Flags().StringSliceVar(&s.kubeInit.ingressAnnotations, "ingress-annotations", []string{}, "Annotations to add to skupper ingress")
@@ -187,6 +193,19 @@ func (s *SkupperKubeSite) CreateFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.Memory, "flow-collector-memory", "", "Memory request for flow collector pods")
 	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.CpuLimit, "flow-collector-cpu-limit", "", "CPU limit for flow collector pods")
 	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.MemoryLimit, "flow-collector-memory-limit", "", "Memory limit for flow collector pods")
+	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.TlsMinVersion, "flow-collector-tls-min-version", "", "Minimum tls version accepted by the console and flow collector api, 1.2 (default) or 1.3")
+	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.TlsCipherSuites, "flow-collector-tls-cipher-suites", "", "Comma separated tls 1.2 cipher suites accepted by the console and flow collector api")
+	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.TlsCurvePreferences, "flow-collector-tls-curve-preferences", "", "Comma separated curves for the console and flow collector api key exchange, in order of preference (X25519, P256, P384, P521)")
+	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.AcmeDomains, "flow-collector-acme-domains", "", "Comma separated public domains of the console, its certificate being obtained and renewed through ACME (Let's Encrypt), the console must be reachable on port 443 of the domains")
+	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.AcmeEmail, "flow-collector-acme-email", "", "Contact email of the ACME account")
+	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.AcmeDirectory, "flow-collector-acme-directory", "", "ACME directory url, Let's Encrypt by default")
+
+	cmd.Flags().StringVar(&routerCreateOpts.Vault.Address, "vault-address", "", "Address of the HashiCorp Vault server whose pki secrets engine issues the site CA, inter-router and token certificates, instead of a self generated site CA")
+	cmd.Flags().StringVar(&routerCreateOpts.Vault.PkiPath, "vault-pki-path", certs.VaultDefaultPkiPath, "Mount path of the vault pki secrets engine")
+	cmd.Flags().StringVar(&routerCreateOpts.Vault.Role, "vault-role", "", "Vault pki role issuing the certificates, it must allow any common name")
+	cmd.Flags().StringVar(&routerCreateOpts.Vault.AuthRole, "vault-auth-role", "", "Vault kubernetes auth role of the service controller, the VAULT_TOKEN of the cli being used otherwise")
+	cmd.Flags().StringVar(&routerCreateOpts.CertManager.Issuer, "cert-manager-issuer", "", "Name of the cert-manager issuer of the site CAs, the router, console and claims certificates being then issued by cert-manager rather than generated")
+	cmd.Flags().StringVar(&routerCreateOpts.CertManager.IssuerKind, "cert-manager-issuer-kind", types.CertManagerIssuerKind, "Kind of the cert-manager issuer: Issuer or ClusterIssuer")
 
 	cmd.Flags().StringVar(&routerCreateOpts.PrometheusServer.Cpu, "prometheus-cpu", "", "CPU request for prometheus pods")
 	cmd.Flags().StringVar(&routerCreateOpts.PrometheusServer.Memory, "prometheus-memory", "", "Memory request for prometheus pods")
@@ -195,6 +214,7 @@ func (s *SkupperKubeSite) CreateFlags(cmd *cobra.Command) {
 
 	cmd.Flags().DurationVar(&LoadBalancerTimeout, "timeout", types.DefaultTimeoutDuration, "Configurable timeout for the ingress loadbalancer option.")
 	cmd.Flags().BoolVar(&routerCreateOpts.EnableSkupperEvents, "enable-skupper-events", true, "Enable sending Skupper events to Kubernetes")
+	cmd.Flags().StringVar(&routerCreateOpts.SyslogAddress, "syslog-address", "", "Syslog endpoint the service controller and flow collector also send their logs to, as udp://host:port, tcp://host:port or tls://host:port, the CA of a tls endpoint being read from the optional skupper-syslog-ca secret")
 
 	// hide run-as flags
 	f := cmd.Flag("run-as-user")
@@ -339,6 +359,29 @@ func (s *SkupperKubeSite) Update(cmd *cobra.Command, args []string) error {
 	silenceCobra(cmd)
 	cli := s.kube.Cli
 
+	if len(certificateHosts) > 0 {
+		siteConfig, err := cli.SiteConfigInspect(context.Background(), nil)
+		if err != nil {
+			return err
+		}
+		if siteConfig == nil {
+			return fmt.Errorf("Skupper is not enabled in '%s'", cli.GetNamespace())
+		}
+		spec := siteConfig.Spec
+		for _, host := range certificateHosts {
+			if !utils.StringSliceContains(spec.CertificateHosts, host) {
+				spec.CertificateHosts = append(spec.CertificateHosts, host)
+			}
+		}
+		updates, err := cli.SiteConfigUpdate(context.Background(), spec)
+		if err != nil {
+			return fmt.Errorf("Error while trying to update certificate hosts: %s", err)
+		}
+		for _, i := range updates {
+			fmt.Println("Updated", i)
+		}
+	}
+
 	updated, err := cli.RouterUpdateVersion(context.Background(), forceHup)
 	if err != nil {
 		return err
@@ -353,6 +396,7 @@ func (s *SkupperKubeSite) Update(cmd *cobra.Command, args []string) error {
 
 func (s *SkupperKubeSite) UpdateFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&forceHup, "force-restart", "", false, "Restart skupper daemons even if image tag is not updated")
+	cmd.Flags().StringSliceVar(&certificateHosts, "certificate-host", []string{}, "DNS name or IP address to add to the certificate presented to linking sites, can be used multiple times.")
 }
 
 func (s *SkupperKubeSite) Version(cmd *cobra.Command, args []string) error {
@@ -371,6 +415,16 @@ func (s *SkupperKubeSite) Version(cmd *cobra.Command, args []string) error {
 
 func (s *SkupperKubeSite) RevokeAccess(cmd *cobra.Command, args []string) error {
 	silenceCobra(cmd)
+	if revokeLinkCredential != "" {
+		revoked, err := s.kube.Cli.RevokeLinkCredentials(context.Background(), revokeLinkCredential)
+		if err != nil {
+			return fmt.Errorf("Unable to revoke the link credential: %w", err)
+		}
+		for _, cert := range revoked {
+			fmt.Printf("Revoked the link credential issued to %s (serial number %s)\n", cert.Subject, cert.Serial)
+		}
+		return nil
+	}
 	err := s.kube.Cli.RevokeAccess(context.Background())
 	if err != nil {
 		return fmt.Errorf("Unable to revoke access: %w", err)
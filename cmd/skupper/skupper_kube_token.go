@@ -34,6 +34,12 @@ func (s *SkupperKubeToken) Create(cmd *cobra.Command, args []string) error {
 	cli := s.kube.Cli
 	switch tokenType {
 	case "cert":
+		if tokenMutual {
+			return fmt.Errorf("--mutual option can only be used for a claim")
+		}
+		if len(tokenPolicy.AllowedSites) > 0 || tokenPolicy.EdgeOnly || tokenPolicy.MaxCost != 0 || tokenValidFrom != "" {
+			return fmt.Errorf("--allowed-sites, --edge-only, --max-cost and --valid-from options can only be used for a claim")
+		}
 		err := cli.ConnectorTokenCreateFile(context.Background(), clientIdentity, args[0])
 		if err != nil {
 			return fmt.Errorf("Failed to create token: %w", err)
@@ -47,7 +53,15 @@ func (s *SkupperKubeToken) Create(cmd *cobra.Command, args []string) error {
 		if password == "" {
 			password = utils.RandomId(24)
 		}
-		err := cli.TokenClaimCreateFile(context.Background(), name, []byte(password), expiry, uses, args[0])
+		policy := tokenPolicy
+		if tokenValidFrom != "" {
+			notBefore, err := parseValidFrom(tokenValidFrom, time.Now())
+			if err != nil {
+				return err
+			}
+			policy.NotBefore = notBefore
+		}
+		err := cli.TokenClaimCreateFileWithPolicy(context.Background(), name, []byte(password), expiry, uses, tokenMutual, policy, args[0])
 		if err != nil {
 			return fmt.Errorf("Failed to create token: %w", err)
 		}
@@ -98,6 +112,27 @@ func (s *SkupperKubeToken) CreateFlags(cmd *cobra.Command) {
 	cmd.Flags().DurationVarP(&expiry, "expiry", "", 15*time.Minute, "Expiration time for claim (only valid if --token-type=claim)")
 	cmd.Flags().IntVarP(&uses, "uses", "", 1, "Number of uses for which claim will be valid (only valid if --token-type=claim)")
 	cmd.Flags().StringVarP(&tokenTemplate, "template", "", "", "The name of a secret used as a template for the token")
+	cmd.Flags().BoolVarP(&tokenMutual, "mutual", "", false, "Link this site back to the site that redeems the claim, so that a single token pairs both sites (only valid if --token-type=claim)")
+	cmd.Flags().StringSliceVar(&tokenPolicy.AllowedSites, "allowed-sites", []string{}, "Names of the sites allowed to redeem the claim, shell patterns such as 'edge-*' being accepted. The names are the ones reported by the redeeming sites, so this is advisory (only valid if --token-type=claim)")
+	cmd.Flags().BoolVar(&tokenPolicy.EdgeOnly, "edge-only", false, "Only allow edge sites to redeem the claim (only valid if --token-type=claim)")
+	cmd.Flags().IntVar(&tokenPolicy.MaxCost, "max-cost", 0, "Highest cost of the link created with the claim, 0 for no limit (only valid if --token-type=claim)")
+	cmd.Flags().StringVar(&tokenValidFrom, "valid-from", "", "Start of the validity of the claim, as an RFC3339 time or a duration from now such as 2h, its expiry still counting from now (only valid if --token-type=claim)")
 	f := cmd.Flag("template")
 	f.Hidden = true
 }
+
+// parseValidFrom reads the start of the validity of a claim, as a time or a
+// duration from now
+func parseValidFrom(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("Invalid --valid-from %q, the duration must be positive", value)
+		}
+		return now.Add(d), nil
+	}
+	notBefore, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid --valid-from %q, expected an RFC3339 time such as 2006-01-02T15:04:05Z or a duration such as 2h", value)
+	}
+	return notBefore, nil
+}
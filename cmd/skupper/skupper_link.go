@@ -28,6 +28,12 @@ func NewCmdLink() *cobra.Command {
 }
 
 var connectorCreateOpts types.ConnectorCreateOptions
+var linkReverseCost int32
+var linkOneWay bool
+
+func isMutualClaim(secret *corev1.Secret) bool {
+	return secret.ObjectMeta.Annotations != nil && secret.ObjectMeta.Annotations[types.ClaimMutual] == "true"
+}
 
 func NewCmdLinkCreate(skupperClient SkupperLinkClient, flag string) *cobra.Command {
 
@@ -68,6 +74,8 @@ func NewCmdLinkCreate(skupperClient SkupperLinkClient, flag string) *cobra.Comma
 	}
 	cmd.Flags().StringVarP(&connectorCreateOpts.Name, flag, "", "", "Provide a specific name for the link (used when deleting it)")
 	cmd.Flags().Int32VarP(&connectorCreateOpts.Cost, "cost", "", 1, "Specify a cost for this link.")
+	cmd.Flags().Int32VarP(&linkReverseCost, "reverse-cost", "", 0, "Specify a cost for the link created back to this site when the token is mutual (defaults to --cost).")
+	cmd.Flags().BoolVarP(&linkOneWay, "one-way", "", false, "Do not create a link back to this site, even if the token is mutual.")
 
 	return cmd
 }
@@ -0,0 +1,56 @@
+package main
+
+import (
+	jsonencoding "encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// manifestOut is where --generate-manifest writes, instead of applying
+var manifestOut io.Writer = os.Stdout
+
+// writeManifest writes the kubernetes resource as yaml
+func writeManifest(obj runtime.Object) error {
+	s := json.NewYAMLSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+	if err := s.Encode(obj, manifestOut); err != nil {
+		return fmt.Errorf("Could not write manifest: %w", err)
+	}
+	return nil
+}
+
+// writeServiceInterfaceManifest writes the skupper-services fragment of a
+// service, preceded by how to merge it into the config map of the site
+func writeServiceInterfaceManifest(manifest *corev1.ConfigMap, address string) error {
+	_, err := fmt.Fprintf(manifestOut, "# Merge into the %s config map of the site with\n"+
+		"#   kubectl apply --server-side --field-manager=%s -f <file>\n"+
+		"# or\n"+
+		"#   kubectl patch configmap %s --type merge --patch-file <file>\n",
+		types.ServiceInterfaceConfigMap, client.ServiceInterfaceFieldManager(address), types.ServiceInterfaceConfigMap)
+	if err != nil {
+		return fmt.Errorf("Could not write manifest: %w", err)
+	}
+	return writeManifest(manifest)
+}
+
+// writeServiceFragment writes the skupper-services.json entries of a podman
+// site for the given service definitions
+func writeServiceFragment(services ...*types.ServiceInterface) error {
+	fragment := map[string]*types.ServiceInterface{}
+	for _, service := range services {
+		fragment[service.Address] = service
+	}
+	encoded, err := jsonencoding.MarshalIndent(fragment, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Could not encode service definition: %w", err)
+	}
+	_, err = fmt.Fprintln(manifestOut, string(encoded))
+	return err
+}
@@ -147,6 +147,12 @@ func (v *vanClientMock) TokenClaimCreate(ctx context.Context, name string, passw
 func (v *vanClientMock) TokenClaimCreateFile(ctx context.Context, subject string, password []byte, expiry time.Duration, uses int, secretFile string) error {
 	return nil
 }
+func (v *vanClientMock) MutualTokenClaimCreateFile(ctx context.Context, subject string, password []byte, expiry time.Duration, uses int, secretFile string) error {
+	return nil
+}
+func (v *vanClientMock) TokenClaimCreateFileWithPolicy(ctx context.Context, subject string, password []byte, expiry time.Duration, uses int, mutual bool, policy types.ClaimPolicy, secretFile string) error {
+	return nil
+}
 func (v *vanClientMock) ServiceInterfaceCreate(ctx context.Context, service *types.ServiceInterface) error {
 	return nil
 }
@@ -246,6 +252,10 @@ func (v *vanClientMock) SkupperPolicies(verbose bool) (*bytes.Buffer, error) {
 	return nil, nil
 }
 
+func (v *vanClientMock) SkupperLogLevel(component string, level string) (*bytes.Buffer, error) {
+	return nil, nil
+}
+
 func (v *vanClientMock) SkupperCheckService(service string, verbose bool) (*bytes.Buffer, error) {
 	return nil, nil
 }
@@ -305,6 +315,14 @@ func (v *vanClientMock) RevokeAccess(ctx context.Context) error {
 	return nil
 }
 
+func (v *vanClientMock) RevokeLinkCredentials(ctx context.Context, match string) ([]types.RevokedCertificate, error) {
+	return []types.RevokedCertificate{}, nil
+}
+
+func (v *vanClientMock) CertificatesRotate(ctx context.Context, options types.CertificateRotateOptions) ([]types.CertificateInfo, error) {
+	return []types.CertificateInfo{}, nil
+}
+
 func (v *vanClientMock) NetworkStatus(ctx context.Context) (*network.NetworkStatusInfo, error) {
 
 	routerStatus := network.RouterStatusInfo{
@@ -319,6 +337,10 @@ func (v *vanClientMock) NetworkStatus(ctx context.Context) (*network.NetworkStat
 	return &result, nil
 }
 
+func (v *vanClientMock) NetworkStatusHistory(ctx context.Context) ([]network.NetworkSnapshot, error) {
+	return []network.NetworkSnapshot{}, nil
+}
+
 func (v *vanClientMock) GetConsoleUrl(namespace string) (string, error) {
 	return "", nil
 }
@@ -707,7 +729,7 @@ func TestConsoleAuthArgs(t *testing.T) {
 		{
 			"console-auth is not internal and it should be",
 			[]string{"--console-auth", "something", "--console-user", "admin"},
-			"the --console-auth option must contain one of these values: [internal unsecured openshift]",
+			"the --console-auth option must contain one of these values: [internal unsecured openshift openid saml]",
 		},
 		{
 			"console-auth is unsecured and should be internal",
@@ -2,13 +2,17 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/pkg/network"
 	"github.com/skupperproject/skupper/pkg/utils/formatter"
 	"github.com/spf13/cobra"
+	"io"
+	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func NewCmdNetwork() *cobra.Command {
@@ -52,7 +56,21 @@ func NewCmdNetworkStatus(skupperClient SkupperNetworkClient) *cobra.Command {
 				return errStatus
 			}
 
-			return printNetworkStatus(currentSiteId, currentNetworkStatus)
+			if err := printNetworkStatus(currentSiteId, currentNetworkStatus); err != nil {
+				return err
+			}
+
+			summary, err := skupperClient.CollectorSummary(ctx)
+			if err != nil {
+				if verboseNetworkStatus {
+					fmt.Printf("Flow collector data is not available: %s\n", err)
+				}
+				return nil
+			}
+			if summary != nil {
+				printCollectorSummary(currentSiteId, summary)
+			}
+			return nil
 		},
 	}
 
@@ -149,3 +167,251 @@ func printNetworkStatus(currentSite string, currentNetworkStatus *network.Networ
 	}
 	return nil
 }
+
+func printCollectorSummary(currentSite string, summary *network.CollectorSummary) {
+	collectorList := formatter.NewList()
+	collectorList.Item("Flow collector:")
+
+	sites := collectorList.NewChild("Sites:")
+	for _, site := range summary.Sites {
+		if len(siteNetworkStatus) > 0 && siteNetworkStatus != site.Name {
+			continue
+		}
+		location := "[remote]"
+		if site.Identity == currentSite {
+			location = "[local]"
+		}
+		sites.NewChildWithDetail(fmt.Sprintf("%s %s(%s)\n", location, site.Identity, site.Name), map[string]string{"platform": site.Platform, "version": site.Version})
+	}
+
+	if len(summary.Addresses) > 0 {
+		services := collectorList.NewChild("Services:")
+		for _, address := range summary.Addresses {
+			services.NewChildWithDetail(fmt.Sprintln(address.Name), map[string]string{
+				"protocol":   address.Protocol,
+				"listeners":  strconv.Itoa(address.ListenerCount),
+				"connectors": strconv.Itoa(address.ConnectorCount),
+			})
+		}
+	}
+
+	traffic := []network.SiteTrafficInfo{}
+	for _, pair := range summary.Traffic {
+		if len(siteNetworkStatus) == 0 || siteNetworkStatus == pair.SourceSiteName || siteNetworkStatus == pair.DestinationSiteName {
+			traffic = append(traffic, pair)
+		}
+	}
+	if len(traffic) > 0 {
+		trafficLevel := collectorList.NewChild("Traffic:")
+		for _, pair := range traffic {
+			trafficLevel.NewChildWithDetail(fmt.Sprintf("%s -> %s\n", pair.SourceSiteName, pair.DestinationSiteName), map[string]string{"flows": strconv.FormatUint(pair.Flows, 10)})
+		}
+	}
+
+	collectorList.Print()
+}
+
+var networkDiffSince time.Duration
+
+func NewCmdNetworkDiff(skupperClient SkupperNetworkClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "diff",
+		Short:  "Shows the sites, links and services added, removed or changed since an earlier time.",
+		Long:   "Compares the current network with the snapshot of the network kept by the flow collector at the given time, as it was before a change, to verify the change.",
+		Args:   cobra.NoArgs,
+		PreRun: skupperClient.NewClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			if networkDiffSince <= 0 {
+				return fmt.Errorf("the --since duration must be positive")
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), types.DefaultTimeoutDuration)
+			defer cancel()
+
+			currentNetworkStatus, err := skupperClient.Status(cmd, args, ctx)
+			if err != nil && err.Error() == "status not ready" {
+				fmt.Println("Status pending...")
+				return nil
+			} else if err != nil {
+				return err
+			}
+			history, err := skupperClient.History(ctx)
+			if err != nil {
+				return fmt.Errorf("unable to retrieve the network history: %w", err)
+			}
+			if len(history) == 0 {
+				fmt.Println("No network history available yet, it is recorded by the flow collector.")
+				return nil
+			}
+			now := time.Now()
+			earlier, found := network.NetworkSnapshotAt(history, now.Add(-networkDiffSince))
+			if !found {
+				fmt.Printf("The network history starts at %s, comparing with the oldest snapshot.\n", earlier.Time.Local().Format(time.RFC3339))
+			}
+
+			changes := network.DiffNetworkSnapshots(earlier, network.NewNetworkSnapshot(currentNetworkStatus, now))
+			printNetworkChanges(earlier.Time, changes)
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&networkDiffSince, "since", time.Hour, "Compare with the network as it was this long ago, e.g. 30m or 2h")
+	return cmd
+}
+
+func printNetworkChanges(since time.Time, changes []network.NetworkChange) {
+	if len(changes) == 0 {
+		fmt.Printf("No changes in the network since %s\n", since.Local().Format(time.RFC3339))
+		return
+	}
+	changeList := formatter.NewList()
+	changeList.Item(fmt.Sprintf("Changes in the network since %s:", since.Local().Format(time.RFC3339)))
+	headings := map[string]string{"site": "Sites:", "link": "Links:", "service": "Services:"}
+	level := changeList
+	kind := ""
+	// the changes are grouped by kind
+	for _, change := range changes {
+		if change.Kind != kind {
+			kind = change.Kind
+			level = changeList.NewChild(headings[kind])
+		}
+		item := fmt.Sprintf("%s %s", change.Change, change.Name)
+		if change.Detail != "" {
+			item = fmt.Sprintf("%s (%s)", item, change.Detail)
+		}
+		level.NewChild(item)
+	}
+	changeList.Print()
+}
+
+type networkPostureOptions struct {
+	CollectorUrl string
+	User         string
+	Password     string
+	WarningsOnly bool
+}
+
+var networkPostureOpts networkPostureOptions
+
+func NewCmdNetworkPosture(skupperClient SkupperNetworkClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "posture",
+		Short: "Shows the certificate expiration dates, tls settings and authentication modes reported by the flow collector.",
+		Long: `Shows the security posture reported by the flow collector of the site and by its peer collectors:
+the expiration dates of the certificates, the tls versions used by the router listeners and links,
+and the authentication mode of each component, with a warning for the weak or expiring credentials.`,
+		Example: `
+	# Show the posture of the current podman site
+	skupper network posture
+
+	# Show the posture reported by a flow collector reachable at its url
+	skupper network posture --collector-url https://skupper.example.com:8010 --user admin --password secret`,
+		Args: cobra.NoArgs,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if networkPostureOpts.CollectorUrl == "" {
+				skupperClient.NewClient(cmd, args)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			ctx, cancel := context.WithTimeout(context.Background(), types.DefaultTimeoutDuration)
+			defer cancel()
+
+			var reports []network.PostureReport
+			var err error
+			if networkPostureOpts.CollectorUrl != "" {
+				reports, err = fetchCollectorPosture(ctx, &http.Client{Timeout: 30 * time.Second}, networkPostureOpts)
+			} else {
+				reports, err = skupperClient.CollectorPosture(ctx)
+			}
+			if err != nil {
+				return fmt.Errorf("Unable to retrieve the security posture: %w", err)
+			}
+			printNetworkPosture(reports, networkPostureOpts.WarningsOnly)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&networkPostureOpts.CollectorUrl, "collector-url", "", "URL of the flow collector, e.g. https://skupper.example.com:8010, required on kubernetes")
+	cmd.Flags().StringVar(&networkPostureOpts.User, "user", "", "User name for the flow collector")
+	cmd.Flags().StringVar(&networkPostureOpts.Password, "password", "", "Password for the flow collector")
+	cmd.Flags().BoolVar(&networkPostureOpts.WarningsOnly, "warnings", false, "Only show the warnings")
+	return cmd
+}
+
+func fetchCollectorPosture(ctx context.Context, client *http.Client, opts networkPostureOptions) ([]network.PostureReport, error) {
+	url := strings.TrimSuffix(opts.CollectorUrl, "/") + "/api/v1alpha1/posture/"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if opts.User != "" {
+		req.SetBasicAuth(opts.User, opts.Password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return nil, fmt.Errorf("flow collector returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	reports := []network.PostureReport{}
+	if err := json.NewDecoder(resp.Body).Decode(&reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+func printNetworkPosture(reports []network.PostureReport, warningsOnly bool) {
+	postureList := formatter.NewList()
+	postureList.Item("Security posture:")
+	for _, report := range reports {
+		collector := postureList.NewChild(fmt.Sprintf("Collector %s:", report.Collector))
+		if !warningsOnly {
+			if len(report.Certificates) > 0 {
+				certificates := collector.NewChild("Certificates:")
+				for _, cert := range report.Certificates {
+					certificates.NewChildWithDetail(fmt.Sprintf("%s (%s)\n", cert.Subject, cert.Name), map[string]string{
+						"expires": cert.NotAfter.Local().Format(time.RFC3339),
+						"key":     fmt.Sprintf("%s %d", cert.KeyAlgorithm, cert.KeySize),
+						"status":  cert.Status,
+					})
+				}
+			}
+			if len(report.Tls) > 0 {
+				listeners := collector.NewChild("TLS:")
+				for _, t := range report.Tls {
+					detail := map[string]string{"encrypted": strconv.FormatBool(t.Encrypted), "status": t.Status}
+					if t.TlsVersion != "" {
+						detail["version"] = t.TlsVersion
+					}
+					if t.Role != "" {
+						detail["role"] = t.Role
+					}
+					if t.Direction != "" {
+						detail["direction"] = t.Direction
+					}
+					listeners.NewChildWithDetail(fmt.Sprintf("%s %s\n", t.Kind, t.Name), detail)
+				}
+			}
+			if len(report.Auth) > 0 {
+				auth := collector.NewChild("Authentication:")
+				for _, a := range report.Auth {
+					auth.NewChildWithDetail(fmt.Sprintln(a.Component), map[string]string{"mode": a.Mode, "status": a.Status})
+				}
+			}
+		}
+		if len(report.Warnings) > 0 {
+			warnings := collector.NewChild("Warnings:")
+			for _, warning := range report.Warnings {
+				warnings.NewChild(warning)
+			}
+		}
+		for _, err := range report.Errors {
+			collector.NewChild("Error: " + err)
+		}
+	}
+	postureList.Print()
+}
@@ -6,7 +6,11 @@ import (
 	"os"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	clientdocker "github.com/skupperproject/skupper/client/docker"
 	clientpodman "github.com/skupperproject/skupper/client/podman"
+	"github.com/skupperproject/skupper/pkg/config"
+	"github.com/skupperproject/skupper/pkg/domain/docker"
 	"github.com/skupperproject/skupper/pkg/domain/podman"
 	"github.com/skupperproject/skupper/pkg/utils"
 	"github.com/spf13/cobra"
@@ -16,12 +20,16 @@ var notImplementedErr = fmt.Errorf("Not implemented")
 
 var SkupperPodmanCommands = []string{
 	"switch", "init", "delete", "status", "version", "token", "link",
-	"service", "expose", "unexpose", "revoke-access", "update", "network",
+	"service", "expose", "unexpose", "revoke-access", "update", "upgrade", "network",
+	"context", "gateway", "debug", "export-compose",
 }
 
+// SkupperPodman manages the sites of the container engine platforms, podman
+// and docker
 type SkupperPodman struct {
+	platform           types.Platform
 	cliFactory         clientpodman.RestClientFactory
-	cli                *clientpodman.PodmanRestClient
+	cli                container.EngineClient
 	currentSite        *podman.Site
 	siteHandlerFactory podman.SiteHandlerFactory
 	site               *SkupperPodmanSite
@@ -29,6 +37,8 @@ type SkupperPodman struct {
 	link               *SkupperPodmanLink
 	service            *SkupperPodmanService
 	network            *SkupperPodmanNetwork
+	host               string
+	siteId             string
 	exit               exitHandler
 	output             io.Writer
 }
@@ -56,7 +66,9 @@ func (s *SkupperPodman) Service() SkupperServiceClient {
 }
 
 func (s *SkupperPodman) Debug() SkupperDebugClient {
-	return &SkupperPodmanDebug{}
+	return &SkupperPodmanDebug{
+		podman: s,
+	}
 }
 
 func (s *SkupperPodman) Link() SkupperLinkClient {
@@ -98,32 +110,78 @@ func (s *SkupperPodman) NewClient(cmd *cobra.Command, args []string) {
 		s.output = os.Stdout
 	}
 	out := s.output
+	if s.exit == nil {
+		s.exit = os.Exit
+	}
+	if cmd.Name() == "status" && statusAll {
+		// the sites of all the hosts are read by the command itself
+		return
+	}
+	// a remote host can be given through --host or the current context
+	remoteEndpoint, err := s.resolveHost()
+	if err != nil {
+		fmt.Fprintf(out, "invalid %s host - %s", s.Platform(), err)
+		fmt.Fprintln(out)
+		s.exit(1)
+		return
+	}
+	// a site id selects one of the local sites, served by its own podman service
+	if s.siteId != "" {
+		remoteEndpoint, err = s.resolveSiteId(cmd.Name() == "init", remoteEndpoint)
+		if err != nil {
+			fmt.Fprintf(out, "unable to use site id %s - %s", s.siteId, err)
+			fmt.Fprintln(out)
+			s.exit(1)
+			return
+		}
+	}
+	podman.RemoteEndpoint = remoteEndpoint
 	switch cmd.Name() {
 	case "init":
 		// require site not present
 		if len(args) == 1 {
 			endpoint = args[0]
 		}
+		endpoint = utils.DefaultStr(endpoint, remoteEndpoint)
 		isInitCmd = true
 	case "version":
 		exitOnError = false
+		endpoint = remoteEndpoint
 	default:
-		podmanCfg, err := podman.NewPodmanConfigFileHandler().GetConfig()
+		if remoteEndpoint != "" {
+			endpoint = remoteEndpoint
+			break
+		}
+		podmanCfg, err := podman.NewConfigFileHandler(s.Platform()).GetConfig()
 		if err != nil {
-			fmt.Fprintln(out, "error reading current podman endpoint")
+			fmt.Fprintf(out, "error reading current %s endpoint", s.Platform())
+			fmt.Fprintln(out)
 			return
 		}
 		endpoint = podmanCfg.Endpoint
 	}
-	if s.cliFactory == nil {
-		s.cliFactory = clientpodman.NewPodmanClient
-	}
-	if s.exit == nil {
-		s.exit = os.Exit
-	}
-	c, err := s.cliFactory(endpoint, "")
+	c, err := s.newEngineClient(endpoint)
 	if err != nil {
-		if exitOnError {
+		if exitOnError && s.Platform() == types.PlatformDocker {
+			fmt.Fprintf(out, "Docker endpoint is not available: %s",
+				utils.DefaultStr(endpoint, clientdocker.GetDefaultDockerEndpoint()))
+			fmt.Fprintln(out)
+			fmt.Fprintln(out, "Reason:", err)
+			recommendation := `
+Recommendation:
+
+	Make sure the docker daemon is running and reachable.
+	On most systems you can execute:
+
+		sudo systemctl enable --now docker
+
+	The user must be a member of the docker group, otherwise a
+	rootless docker daemon can be used instead. Alternatively you
+	can set the DOCKER_HOST environment variable to the endpoint
+	of the docker daemon.`
+			fmt.Fprintln(out, recommendation)
+			s.exit(1)
+		} else if exitOnError {
 			fmt.Fprintf(out, "Podman endpoint is not available: %s",
 				utils.DefaultStr(endpoint, clientpodman.GetDefaultPodmanEndpoint()))
 			fmt.Fprintln(out)
@@ -153,6 +211,9 @@ Recommendation:
 	// Ensure that site does not exist on init, but exists for all other commands
 	if s.siteHandlerFactory == nil {
 		s.siteHandlerFactory = podman.NewSiteHandler
+		if s.Platform() == types.PlatformDocker {
+			s.siteHandlerFactory = docker.NewSiteHandler
+		}
 	}
 	siteHandler, err := s.siteHandlerFactory(endpoint)
 	if err != nil {
@@ -179,7 +240,8 @@ Recommendation:
 			if ok && siteHandlerPodman.AnyResourceLeft() {
 				fmt.Fprintln(out, "Reason:", err)
 				fmt.Fprintln(out)
-				fmt.Fprintln(out, "There are podman resources missing or left from an earlier installation")
+				fmt.Fprintf(out, "There are %s resources missing or left from an earlier installation", s.Platform())
+				fmt.Fprintln(out)
 				fmt.Fprintln(out, "To clean them up, run: skupper delete")
 			}
 			s.exit(0)
@@ -192,12 +254,74 @@ Recommendation:
 }
 
 func (s *SkupperPodman) Platform() types.Platform {
-	return types.PlatformPodman
+	if s.platform == "" {
+		return types.PlatformPodman
+	}
+	return s.platform
+}
+
+// newEngineClient returns the client of the container engine of the
+// platform, the podman one being created by the cliFactory
+func (s *SkupperPodman) newEngineClient(endpoint string) (container.EngineClient, error) {
+	if s.Platform() != types.PlatformPodman {
+		return podman.NewEngineClient(s.Platform(), endpoint)
+	}
+	if s.cliFactory == nil {
+		s.cliFactory = clientpodman.NewPodmanClient
+	}
+	c, err := s.cliFactory(endpoint, "")
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// newSiteHandler returns the site handler of the platform
+func (s *SkupperPodman) newSiteHandler(endpoint string) (*podman.SiteHandler, error) {
+	return podman.NewSiteHandlerFor(s.Platform(), endpoint)
 }
 
 func (s *SkupperPodman) SupportedCommands() []string {
 	return SkupperPodmanCommands
 }
 
-func (s *SkupperPodman) Options(cmd *cobra.Command) {
+func (s *SkupperPodman) Options(rootCmd *cobra.Command) {
+	rootCmd.PersistentFlags().StringVarP(&s.host, "host", "", "",
+		"The podman host to manage, an endpoint as in ssh://user@host/run/user/1000/podman/podman.sock or the name of a context")
+	rootCmd.PersistentFlags().StringVarP(&s.siteId, "site-id", "", "",
+		"Identifies one of the podman sites of the local host, each one running on its own podman storage and service")
+}
+
+func (s *SkupperPodman) resolveHost() (string, error) {
+	// the contexts hold podman hosts only
+	if s.Platform() != types.PlatformPodman {
+		return s.host, nil
+	}
+	contexts, err := podman.NewPodmanContextsFileHandler().GetContexts()
+	if err != nil {
+		return "", err
+	}
+	return contexts.Resolve(s.host)
+}
+
+// resolveSiteId returns the endpoint of the podman service of the local
+// site identified by --site-id, the service being started on init
+func (s *SkupperPodman) resolveSiteId(init bool, remoteEndpoint string) (string, error) {
+	if s.Platform() != types.PlatformPodman {
+		return "", fmt.Errorf("site ids are only available on podman")
+	}
+	if remoteEndpoint != "" {
+		return "", fmt.Errorf("site ids cannot be used along with a remote host")
+	}
+	if err := config.ValidateSiteId(s.siteId); err != nil {
+		return "", err
+	}
+	config.SiteId = s.siteId
+	service := config.NewPodmanSiteService(s.siteId)
+	if init {
+		if err := service.Create(); err != nil {
+			return "", err
+		}
+	}
+	return service.Endpoint(), nil
 }
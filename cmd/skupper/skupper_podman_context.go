@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/skupperproject/skupper/pkg/domain/podman"
+	"github.com/skupperproject/skupper/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var contextIdentity string
+var contextTLSCA string
+var contextTLSCert string
+var contextTLSKey string
+
+func NewCmdContext() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "context [command]",
+		Short: "Manage the podman hosts that can be selected with --host",
+		Example: `
+	# Add a remote host, reached over ssh
+	skupper context add edge ssh://skupper@edge.example.com/run/user/1000/podman/podman.sock --identity ~/.ssh/id_ed25519
+
+	# Add a remote host, reached over tls
+	skupper context add lab tcp://lab.example.com:8888 --tls-ca ~/.lab/ca.pem --tls-cert ~/.lab/cert.pem --tls-key ~/.lab/key.pem
+
+	# Manage the site of a remote host
+	skupper status --host edge
+
+	# Use the remote host by default
+	skupper context use edge`,
+	}
+	cmd.AddCommand(NewCmdContextAdd())
+	cmd.AddCommand(NewCmdContextList())
+	cmd.AddCommand(NewCmdContextRemove())
+	cmd.AddCommand(NewCmdContextUse())
+	return cmd
+}
+
+func updateContexts(update func(contexts *podman.Contexts) error) error {
+	handler := podman.NewPodmanContextsFileHandler()
+	contexts, err := handler.GetContexts()
+	if err != nil {
+		return err
+	}
+	if err := update(contexts); err != nil {
+		return err
+	}
+	return handler.Save(contexts)
+}
+
+func NewCmdContextAdd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add <name> <host>",
+		Short: "Add or replace a podman host, as in ssh://user@host[:port]/run/user/1000/podman/podman.sock or tcp://host:port",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			err := updateContexts(func(contexts *podman.Contexts) error {
+				return contexts.Add(podman.Context{
+					Name:     args[0],
+					Host:     args[1],
+					Identity: contextIdentity,
+					TLSCA:    contextTLSCA,
+					TLSCert:  contextTLSCert,
+					TLSKey:   contextTLSKey,
+				})
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Context %s added\n", args[0])
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&contextIdentity, "identity", "", "The ssh private key used to reach the host")
+	cmd.Flags().StringVar(&contextTLSCA, "tls-ca", "", "The CA certificate the tls certificate of a tcp host is verified with")
+	cmd.Flags().StringVar(&contextTLSCert, "tls-cert", "", "The client certificate presented to a tcp host over tls")
+	cmd.Flags().StringVar(&contextTLSKey, "tls-key", "", "The private key of the client certificate presented to a tcp host over tls")
+	return cmd
+}
+
+func NewCmdContextList() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the podman hosts, the current one being marked with *",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			contexts, err := podman.NewPodmanContextsFileHandler().GetContexts()
+			if err != nil {
+				return err
+			}
+			if len(contexts.Contexts) == 0 {
+				fmt.Println("No contexts defined")
+				return nil
+			}
+			tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "CURRENT\tNAME\tHOST\tIDENTITY")
+			for _, ctx := range contexts.Contexts {
+				current := ""
+				if ctx.Name == contexts.Current {
+					current = "*"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", current, ctx.Name, ctx.Host, utils.DefaultStr(ctx.Identity, ctx.TLSCert))
+			}
+			return tw.Flush()
+		},
+	}
+	return cmd
+}
+
+func NewCmdContextRemove() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove a podman host",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			err := updateContexts(func(contexts *podman.Contexts) error {
+				return contexts.Remove(args[0])
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Context %s removed\n", args[0])
+			return nil
+		},
+	}
+	return cmd
+}
+
+func NewCmdContextUse() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "use <name>",
+		Short: "Select the podman host used when --host is not given, - selects the local host",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			err := updateContexts(func(contexts *podman.Contexts) error {
+				if args[0] == "-" {
+					contexts.Current = ""
+					return nil
+				}
+				if _, ok := contexts.Get(args[0]); !ok {
+					return fmt.Errorf("context %s not found", args[0])
+				}
+				contexts.Current = args[0]
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if args[0] == "-" {
+				fmt.Println("Switched to the local podman host")
+			} else {
+				fmt.Printf("Switched to context %s\n", args[0])
+			}
+			return nil
+		},
+	}
+	return cmd
+}
@@ -1,15 +1,29 @@
 package main
 
 import (
+	"fmt"
+
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/version"
 	"github.com/spf13/cobra"
 )
 
 type SkupperPodmanDebug struct {
+	podman *SkupperPodman
 }
 
 func (s *SkupperPodmanDebug) Dump(cmd *cobra.Command, args []string) error {
-	return notImplementedErr
+	silenceCobra(cmd)
+	siteHandler, err := s.podman.newSiteHandler("")
+	if err != nil {
+		return fmt.Errorf("Unable to communicate with Skupper site - %w", err)
+	}
+	file, err := siteHandler.DebugDump(args[0], version.Version)
+	if err != nil {
+		return fmt.Errorf("Unable to save skupper dump details: %w", err)
+	}
+	fmt.Println("Skupper dump details written to compressed archive: ", file)
+	return nil
 }
 
 func (s *SkupperPodmanDebug) Events(cmd *cobra.Command, args []string) error {
@@ -24,8 +38,14 @@ func (s *SkupperPodmanDebug) Policies(cmd *cobra.Command, args []string) error {
 	return notImplementedErr
 }
 
-func (s *SkupperPodmanDebug) NewClient(cmd *cobra.Command, args []string) {}
+func (s *SkupperPodmanDebug) LogLevel(cmd *cobra.Command, args []string) error {
+	return notImplementedErr
+}
+
+func (s *SkupperPodmanDebug) NewClient(cmd *cobra.Command, args []string) {
+	s.podman.NewClient(cmd, args)
+}
 
 func (s *SkupperPodmanDebug) Platform() types.Platform {
-	return types.PlatformPodman
+	return s.podman.Platform()
 }
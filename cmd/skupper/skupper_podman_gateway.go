@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/skupperproject/skupper/pkg/domain/podman"
+	"github.com/skupperproject/skupper/pkg/utils/formatter"
+	"github.com/spf13/cobra"
+)
+
+// On podman sites the gateway commands bind the processes of the local host
+// through the service containers, so there is no gateway to initialize
+
+var gatewayLoopback bool
+
+func NewCmdBindGatewayPodman(s *SkupperPodman) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "bind <address> <host> <port...>",
+		Short:  "Bind a process of the host to the service network",
+		Args:   bindGatewayArgs,
+		PreRun: s.NewClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			host := args[1]
+			ports := []int{}
+			if len(args) == 2 {
+				parts := strings.Split(args[1], ":")
+				port, _ := strconv.Atoi(parts[1])
+				host = parts[0]
+				ports = append(ports, port)
+			} else {
+				for _, p := range args[2:] {
+					port, _ := strconv.Atoi(p)
+					ports = append(ports, port)
+				}
+			}
+			gateway := podman.NewGatewayHandlerPodman(s.cli)
+			if err := gateway.Bind(args[0], host, ports); err != nil {
+				return fmt.Errorf("error binding service %s - %w", args[0], err)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func NewCmdUnbindGatewayPodman(s *SkupperPodman) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "unbind <address> [host]",
+		Short:  "Unbind a process of the host from the service network",
+		Args:   cobra.RangeArgs(1, 2),
+		PreRun: s.NewClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			host := ""
+			if len(args) > 1 {
+				host = args[1]
+			}
+			gateway := podman.NewGatewayHandlerPodman(s.cli)
+			if err := gateway.Unbind(args[0], host); err != nil {
+				return fmt.Errorf("error unbinding service %s - %w", args[0], err)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func NewCmdForwardGatewayPodman(s *SkupperPodman) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "forward <address> <port...>",
+		Short:  "Forward an address from ports of the host to the service network",
+		Args:   cobra.MinimumNArgs(2),
+		PreRun: s.NewClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			ports := []int{}
+			for _, p := range args[1:] {
+				port, err := strconv.Atoi(p)
+				if err != nil {
+					return fmt.Errorf("%s is not a valid forward port", p)
+				}
+				ports = append(ports, port)
+			}
+			gateway := podman.NewGatewayHandlerPodman(s.cli)
+			if err := gateway.Forward(args[0], ports, gatewayLoopback); err != nil {
+				return fmt.Errorf("error forwarding service %s - %w", args[0], err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&gatewayLoopback, "loopback", "", false, "Forward from loopback only")
+	return cmd
+}
+
+func NewCmdUnforwardGatewayPodman(s *SkupperPodman) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "unforward <address>",
+		Short:  "Stop forwarding an address from ports of the host",
+		Args:   cobra.ExactArgs(1),
+		PreRun: s.NewClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			gateway := podman.NewGatewayHandlerPodman(s.cli)
+			if err := gateway.Unforward(args[0]); err != nil {
+				return fmt.Errorf("error unforwarding service %s - %w", args[0], err)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func NewCmdStatusGatewayPodman(s *SkupperPodman) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "status",
+		Short:  "Report the processes of the host bound and the addresses forwarded",
+		Args:   cobra.NoArgs,
+		PreRun: s.NewClient,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			silenceCobra(cmd)
+			gateway := podman.NewGatewayHandlerPodman(s.cli)
+			forwards, bindings, err := gateway.List()
+			if err != nil {
+				return fmt.Errorf("error retrieving gateway status - %w", err)
+			}
+			l := formatter.NewList()
+			if len(forwards) == 0 && len(bindings) == 0 {
+				l.Item("No bindings or forwards defined on the host")
+				l.Print()
+				return nil
+			}
+			l.Item(fmt.Sprintf("Gateway Definition: (host address: %s)", gateway.HostAddress()))
+			if len(bindings) > 0 {
+				bindingsList := l.NewChild("Bindings:")
+				for _, binding := range bindings {
+					bindingsList.NewChild(fmt.Sprintf("%s %s %s %s", binding.Address, binding.Protocol, binding.Host, gatewayPorts(binding.Ports)))
+				}
+			}
+			if len(forwards) > 0 {
+				forwardsList := l.NewChild("Forwards:")
+				for _, forward := range forwards {
+					host := forward.Host
+					if host == "" {
+						host = "0.0.0.0"
+					}
+					forwardsList.NewChild(fmt.Sprintf("%s %s %s %s", forward.Address, forward.Protocol, host, gatewayPorts(forward.Ports)))
+				}
+			}
+			l.Print()
+			return nil
+		},
+	}
+	return cmd
+}
+
+// gatewayPorts formats the ports of the service mapped to the ports of the
+// host as servicePort:hostPort
+func gatewayPorts(ports map[int]int) string {
+	var svcPorts []int
+	for port := range ports {
+		svcPorts = append(svcPorts, port)
+	}
+	sort.Ints(svcPorts)
+	var mapped []string
+	for _, port := range svcPorts {
+		mapped = append(mapped, fmt.Sprintf("%d:%d", port, ports[port]))
+	}
+	return strings.Join(mapped, " ")
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/pkg/domain"
 	"github.com/skupperproject/skupper/pkg/domain/podman"
@@ -14,6 +16,9 @@ type SkupperPodmanLink struct {
 
 func (s *SkupperPodmanLink) Create(cmd *cobra.Command, args []string) error {
 	// reading secret from file
+	if isMutualClaim(connectorCreateOpts.Secret) && !linkOneWay {
+		fmt.Println("Podman sites cannot accept a link back from the remote site, linking one way only.")
+	}
 	linkHandler := podman.NewLinkHandlerPodman(s.podman.currentSite, s.podman.cli)
 	return linkHandler.Create(connectorCreateOpts.Secret, connectorCreateOpts.Name, int(connectorCreateOpts.Cost))
 }
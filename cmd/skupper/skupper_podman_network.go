@@ -35,12 +35,36 @@ func (s *SkupperPodmanNetwork) Status(cmd *cobra.Command, args []string, ctx con
 
 func (s *SkupperPodmanNetwork) StatusFlags(cmd *cobra.Command) {}
 
+// CollectorSummary returns nil when the site has no flow collector
+func (s *SkupperPodmanNetwork) CollectorSummary(ctx context.Context) (*network.CollectorSummary, error) {
+	if s.podman.currentSite == nil || !s.podman.currentSite.EnableFlowCollector {
+		return nil, nil
+	}
+	collector, err := podman.NewFlowCollectorClient(s.podman.currentSite, s.podman.cli)
+	if err != nil {
+		return nil, err
+	}
+	return collector.Summary(ctx)
+}
+
+func (s *SkupperPodmanNetwork) CollectorPosture(ctx context.Context) ([]network.PostureReport, error) {
+	collector, err := podman.NewFlowCollectorClient(s.podman.currentSite, s.podman.cli)
+	if err != nil {
+		return nil, err
+	}
+	return collector.Posture(ctx)
+}
+
+func (s *SkupperPodmanNetwork) History(ctx context.Context) ([]network.NetworkSnapshot, error) {
+	return s.NetworkStatusHandler().GetHistory()
+}
+
 func (s *SkupperPodmanNetwork) NewClient(cmd *cobra.Command, args []string) {
 	s.podman.NewClient(cmd, args)
 }
 
 func (s *SkupperPodmanNetwork) Platform() types.Platform {
-	return types.PlatformPodman
+	return s.podman.Platform()
 }
 
 func (s *SkupperPodmanNetwork) NetworkStatusHandler() *podman.NetworkStatusHandler {
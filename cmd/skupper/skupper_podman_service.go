@@ -25,9 +25,11 @@ const (
 
 type PodmanServiceCreateFlags struct {
 	ContainerName string
-	HostIP        string
+	HostIPs       []string
 	HostPorts     []string
 	Labels        map[string]string
+	Publish       string
+	SocketDir     string
 }
 
 type PodmanExposeFlags struct {
@@ -35,7 +37,38 @@ type PodmanExposeFlags struct {
 }
 
 func (p *PodmanServiceCreateFlags) HasHostBindings() bool {
-	return p.HostIP != "" || len(p.HostPorts) > 0
+	return len(p.HostIPs) > 0 || len(p.HostPorts) > 0
+}
+
+// ApplyPublish sets how the service is reachable from the host, the host
+// ports being bound when requested or implied by the host bindings
+func (p *PodmanServiceCreateFlags) ApplyPublish(service *podman.Service) error {
+	service.Publish = p.Publish
+	service.SocketDir = p.SocketDir
+	if !p.HasHostBindings() && p.Publish != podman.PublishHost {
+		return service.ValidatePublish()
+	}
+	portMap, err := p.ToPortMapping(*service)
+	if err != nil {
+		return err
+	}
+	if len(p.HostIPs) > 0 {
+		service.Ingress.SetHost(p.HostIPs[0])
+	}
+	if len(p.HostIPs) > 1 {
+		service.HostIPs = p.HostIPs
+	}
+	service.Ingress.SetPorts(portMap)
+	return service.ValidatePublish()
+}
+
+func (p *PodmanServiceCreateFlags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&p.ContainerName, "container-name", "", "Use a different container name")
+	cmd.Flags().StringSliceVar(&p.HostIPs, "host-ip", []string{}, "Host IP addresses used to bind service ports")
+	cmd.Flags().StringSliceVar(&p.HostPorts, "host-port", []string{}, "The host ports to bind with the service (you can also use colon to map service-port to a host-port).")
+	cmd.Flags().StringVar(&p.Publish, "publish", "", fmt.Sprintf("How the service is reachable from the host, one of: %s (defaults to %s when host ips or ports are bound, otherwise %s)",
+		strings.Join(podman.ValidPublishStrategies, ", "), podman.PublishHost, podman.PublishContainer))
+	cmd.Flags().StringVar(&p.SocketDir, "socket-dir", "", "Existing directory of the host where a unix socket per service port is created, when published through unix sockets")
 }
 
 func (p *PodmanServiceCreateFlags) ToPortMapping(service podman.Service) (map[int]int, error) {
@@ -94,15 +127,16 @@ func (s *SkupperPodmanService) Create(cmd *cobra.Command, args []string) error {
 	servicePodman.ContainerName = s.createFlags.ContainerName
 	servicePodman.Labels = s.createFlags.Labels
 
-	// Validating if ingress host/ports should be bound
-	if s.createFlags.HasHostBindings() {
-		hostIp := s.createFlags.HostIP
-		portMap, err := s.createFlags.ToPortMapping(*servicePodman)
-		if err != nil {
+	// Validating how the service is published on the host
+	if err = s.createFlags.ApplyPublish(servicePodman); err != nil {
+		return err
+	}
+
+	if createSvcGenerateManifest {
+		if err = domain.ValidateService(servicePodman); err != nil {
 			return err
 		}
-		servicePodman.Ingress.SetHost(hostIp)
-		servicePodman.Ingress.SetPorts(portMap)
+		return writeServiceFragment(servicePodman.AsServiceInterface())
 	}
 
 	// Create service
@@ -114,9 +148,7 @@ func (s *SkupperPodmanService) Create(cmd *cobra.Command, args []string) error {
 
 func (s *SkupperPodmanService) CreateFlags(cmd *cobra.Command) {
 	s.createFlags.Labels = map[string]string{}
-	cmd.Flags().StringVar(&s.createFlags.ContainerName, "container-name", "", "Use a different container name")
-	cmd.Flags().StringVar(&s.createFlags.HostIP, "host-ip", "", "Host IP address used to bind service ports")
-	cmd.Flags().StringSliceVar(&s.createFlags.HostPorts, "host-port", []string{}, "The host ports to bind with the service (you can also use colon to map service-port to a host-port).")
+	s.createFlags.AddFlags(cmd)
 	cmd.Flags().StringToStringVar(&s.createFlags.Labels, "label", s.createFlags.Labels, "Labels to the new service (comma separated list of key and value pairs split by equals")
 }
 
@@ -160,15 +192,24 @@ func (s *SkupperPodmanService) Status(cmd *cobra.Command, args []string) error {
 					containerPorts := svcPodman.ContainerPorts()
 					if len(containerPorts) > 0 {
 						ingress := svc.NewChild("Host ports:")
-						ingressInfo := fmt.Sprintf("ip: %s - ports: ", utils.DefaultStr(svcPodman.Ingress.GetHost(), "*"))
-						for i, portInfo := range containerPorts {
-							if i > 0 {
-								ingressInfo += ", "
+						hostIp := utils.DefaultStr(svcPodman.Ingress.GetHost(), "*")
+						if len(svcPodman.HostIPs) > 0 {
+							hostIp = strings.Join(svcPodman.HostIPs, ", ")
+						}
+						ingressInfo := fmt.Sprintf("ip: %s - ports: ", hostIp)
+						var mappings []string
+						for _, portInfo := range containerPorts {
+							mapping := fmt.Sprintf("%s -> %s", portInfo.Host, portInfo.Target)
+							if !utils.StringSliceContains(mappings, mapping) {
+								mappings = append(mappings, mapping)
 							}
-							ingressInfo += fmt.Sprintf("%s -> %s", portInfo.Host, portInfo.Target)
 						}
+						ingressInfo += strings.Join(mappings, ", ")
 						ingress.NewChild(ingressInfo)
 					}
+					if svcPodman.GetPublish() == podman.PublishSocket {
+						svc.NewChild("Unix socket:").NewChild(svcPodman.SocketPath(port))
+					}
 					if len(svcPodman.GetEgressResolvers()) > 0 {
 						targets := svc.NewChild("Targets:")
 						for _, t := range svcPodman.GetEgressResolvers() {
@@ -231,7 +272,7 @@ func (s *SkupperPodmanService) Bind(cmd *cobra.Command, args []string) error {
 	// Setting up the egress info
 	egressResolver := &domain.EgressResolverHost{}
 	egressResolver.Ports = portMapping
-	egressResolver.Host = host
+	egressResolver.Host = podman.HostTarget(s.podman.cli, host)
 
 	return s.svcHandler.AddEgressResolver(address, egressResolver)
 }
@@ -276,10 +317,11 @@ func (s *SkupperPodmanService) Unbind(cmd *cobra.Command, args []string) error {
 	podmanService := service.(*podman.Service)
 
 	// Retrieving egress info
+	target := podman.HostTarget(s.podman.cli, host)
 	var egressResolver *domain.EgressResolver
 	for _, e := range podmanService.GetEgressResolvers() {
 		egressResolverHost := e.(*domain.EgressResolverHost)
-		if egressResolverHost.Host == host {
+		if egressResolverHost.Host == host || egressResolverHost.Host == target {
 			egressResolver = &e
 			break
 		}
@@ -309,15 +351,9 @@ func (s *SkupperPodmanService) Expose(cmd *cobra.Command, args []string) error {
 		servicePodman.SetTlsCredentials(exposeOpts.Address)
 	}
 
-	// Validating if ingress host/ports should be bound
-	if s.exposeFlags.HasHostBindings() {
-		hostIp := s.exposeFlags.HostIP
-		portMap, err := s.exposeFlags.ToPortMapping(*servicePodman)
-		if err != nil {
-			return err
-		}
-		servicePodman.Ingress.SetHost(hostIp)
-		servicePodman.Ingress.SetPorts(portMap)
+	// Validating how the service is published on the host
+	if err := s.exposeFlags.ApplyPublish(servicePodman); err != nil {
+		return err
 	}
 
 	// Exposed resource
@@ -329,16 +365,28 @@ func (s *SkupperPodmanService) Expose(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Setting up the egress info
+	// Setting up the egress info, processes running on the local host are
+	// targeted at the address the containers reach the host at
 	egressResolver := &domain.EgressResolverHost{}
 	egressResolver.Ports = portMapping
-	egressResolver.Host = host
+	egressResolver.Host = podman.HostTarget(s.podman.cli, host)
 	servicePodman.AddEgressResolver(egressResolver)
 
+	if exposeOpts.GenerateManifest {
+		if err := domain.ValidateService(servicePodman); err != nil {
+			return err
+		}
+		return writeServiceFragment(servicePodman.AsServiceInterface())
+	}
+
 	// Create service
 	if err := s.svcHandler.Create(servicePodman); err != nil {
 		return err
 	}
+	if egressResolver.Host != host {
+		fmt.Printf("The host is reached from the containers as %s, the process exposed must not listen on loopback only\n",
+			egressResolver.Host)
+	}
 
 	return nil
 }
@@ -352,7 +400,10 @@ func (s *SkupperPodmanService) ExposeFlags(cmd *cobra.Command) {
 	cmd.Short = "Expose one or more network services"
 	cmd.Example = `
         # exposing a service running on the local machine
-        skupper expose host host.containers.internal --address my-service --port 8080
+        skupper expose host localhost --address my-service --port 8080
+
+        # exposing a service running on the local machine, mapping the service port to the port it listens on
+        skupper expose host host.containers.internal --address my-service --port 8080 --target-port 9090
 
         # exposing a local network IP
         skupper expose host 10.0.0.1 --address my-service --port 8080
@@ -362,9 +413,7 @@ func (s *SkupperPodmanService) ExposeFlags(cmd *cobra.Command) {
 
 	s.createFlags.Labels = map[string]string{}
 	s.exposeFlags.PodmanServiceCreateFlags = &PodmanServiceCreateFlags{}
-	cmd.Flags().StringVar(&s.exposeFlags.ContainerName, "container-name", "", "Use a different container name")
-	cmd.Flags().StringVar(&s.exposeFlags.HostIP, "host-ip", "", "Host IP address used to bind service ports")
-	cmd.Flags().StringSliceVar(&s.exposeFlags.HostPorts, "host-port", []string{}, "The host ports to bind with the service (you can also use colon to map service-port to a host-port).")
+	s.exposeFlags.AddFlags(cmd)
 	cmd.Flags().StringToStringVar(&s.exposeFlags.Labels, "label", s.createFlags.Labels, "Labels to the new service (comma separated list of key and value pairs split by equals")
 }
 
@@ -380,10 +429,11 @@ func (s *SkupperPodmanService) Unexpose(cmd *cobra.Command, args []string) error
 	podmanService := service.(*podman.Service)
 
 	// Retrieving egress info
+	target := podman.HostTarget(s.podman.cli, host)
 	var egressResolver *domain.EgressResolver
 	for _, e := range podmanService.GetEgressResolvers() {
 		egressResolverHost := e.(*domain.EgressResolverHost)
-		if egressResolverHost.Host == host {
+		if egressResolverHost.Host == host || egressResolverHost.Host == target {
 			egressResolver = &e
 			break
 		}
@@ -404,7 +454,7 @@ func (s *SkupperPodmanService) UnexposeFlags(cmd *cobra.Command) error {
 	cmd.Short = "Unexpose one or more network services"
 	cmd.Example = `
         # unexposing a service running on the local machine
-        skupper unexpose host host.containers.internal --address my-service
+        skupper unexpose host localhost --address my-service
 
         # unexposing a local network IP
         skupper unexpose host 10.0.0.1 --address my-service
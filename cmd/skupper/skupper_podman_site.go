@@ -4,36 +4,65 @@ import (
 	"context"
 	"fmt"
 	"github.com/skupperproject/skupper/pkg/network"
+	"io"
 	"net"
 	"os"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/pkg/config"
 	"github.com/skupperproject/skupper/pkg/domain"
 	podman "github.com/skupperproject/skupper/pkg/domain/podman"
+	"github.com/skupperproject/skupper/pkg/encryption"
 	"github.com/skupperproject/skupper/pkg/utils"
+	"github.com/skupperproject/skupper/pkg/version"
 	"github.com/spf13/cobra"
 )
 
 type SkupperPodmanSite struct {
-	podman *SkupperPodman
-	flags  PodmanInitFlags
-	up     *domain.UpdateProcessor
+	podman  *SkupperPodman
+	flags   PodmanInitFlags
+	up      *domain.UpdateProcessor
+	update  PodmanUpdateFlags
+	upgrade *domain.UpdateProcessor
+}
+
+type PodmanUpdateFlags struct {
+	EnableFlowCollector bool
+	EnableConsole       bool
 }
 
 type PodmanInitFlags struct {
-	IngressHosts                 []string
-	IngressBindIPs               []string
-	IngressBindInterRouterPort   int
-	IngressBindEdgePort          int
-	IngressBindFlowCollectorPort int
-	ContainerNetwork             string
-	EnableIPV6                   bool
-	PodmanEndpoint               string
-	Timeout                      time.Duration
+	IngressHosts                   []string
+	IngressBindIPs                 []string
+	IngressBindInterRouterPort     int
+	IngressBindEdgePort            int
+	IngressBindFlowCollectorPort   int
+	IngressBindMetricsExporterPort int
+	ContainerNetwork               string
+	EnableIPV6                     bool
+	ContainerNetworkSubnets        []string
+	EnableMetricsExporter          bool
+	PodmanEndpoint                 string
+	Timeout                        time.Duration
+	CredentialsEncryption          string
+	CredentialsKeyringKey          string
+	SystemdUnits                   bool
+	KubePlay                       bool
+	PrivilegedPorts                string
+	VolumeHostPaths                map[string]string
+	VolumeMountOptions             map[string]string
+	RestartPolicy                  string
+	HealthcheckInterval            time.Duration
+	ImagePullPolicy                string
+	RegistryAuthFile               string
+	RegistryUsername               string
+	RegistryPassword               string
+	PinImageDigests                bool
 }
 
 func (s *SkupperPodmanSite) Create(cmd *cobra.Command, args []string) error {
@@ -47,7 +76,7 @@ func (s *SkupperPodmanSite) Create(cmd *cobra.Command, args []string) error {
 	}
 
 	// Validating ingress mode
-	routerCreateOpts.Platform = types.PlatformPodman
+	routerCreateOpts.Platform = s.podman.Platform()
 	if err := routerCreateOpts.CheckIngress(); err != nil {
 		return err
 	}
@@ -57,28 +86,54 @@ func (s *SkupperPodmanSite) Create(cmd *cobra.Command, args []string) error {
 		SiteCommon: &domain.SiteCommon{
 			Name:     siteName,
 			Mode:     initFlags.routerMode,
-			Platform: types.PlatformPodman,
+			Platform: string(s.podman.Platform()),
 		},
-		IngressHosts:                 s.flags.IngressHosts,
-		IngressBindIPs:               s.flags.IngressBindIPs,
-		IngressBindInterRouterPort:   s.flags.IngressBindInterRouterPort,
-		IngressBindEdgePort:          s.flags.IngressBindEdgePort,
-		IngressBindFlowCollectorPort: s.flags.IngressBindFlowCollectorPort,
-		ContainerNetwork:             s.flags.ContainerNetwork,
-		EnableIPV6:                   s.flags.EnableIPV6,
-		PodmanEndpoint:               s.flags.PodmanEndpoint,
-		EnableFlowCollector:          routerCreateOpts.EnableFlowCollector,
-		EnableConsole:                routerCreateOpts.EnableConsole,
-		AuthMode:                     routerCreateOpts.AuthMode,
-		ConsoleUser:                  routerCreateOpts.User,
-		ConsolePassword:              routerCreateOpts.Password,
-		RouterOpts:                   routerCreateOpts.Router,
-		ControllerOpts:               routerCreateOpts.Controller,
-		FlowCollectorOpts:            routerCreateOpts.FlowCollector,
-		PrometheusOpts:               routerCreateOpts.PrometheusServer,
-	}
-
-	siteHandler, err := podman.NewSitePodmanHandler(site.PodmanEndpoint)
+		IngressHosts:                   s.flags.IngressHosts,
+		CertificateHosts:               routerCreateOpts.CertificateHosts,
+		IngressBindIPs:                 s.flags.IngressBindIPs,
+		IngressBindInterRouterPort:     s.flags.IngressBindInterRouterPort,
+		IngressBindEdgePort:            s.flags.IngressBindEdgePort,
+		IngressBindFlowCollectorPort:   s.flags.IngressBindFlowCollectorPort,
+		IngressBindMetricsExporterPort: s.flags.IngressBindMetricsExporterPort,
+		ContainerNetwork:               s.flags.ContainerNetwork,
+		ContainerNetworkSubnets:        s.flags.ContainerNetworkSubnets,
+		EnableIPV6:                     s.flags.EnableIPV6,
+		PodmanEndpoint:                 s.flags.PodmanEndpoint,
+		EnableFlowCollector:            routerCreateOpts.EnableFlowCollector,
+		EnableConsole:                  routerCreateOpts.EnableConsole,
+		EnableMetricsExporter:          s.flags.EnableMetricsExporter,
+		AuthMode:                       routerCreateOpts.AuthMode,
+		ConsoleUser:                    routerCreateOpts.User,
+		ConsolePassword:                routerCreateOpts.Password,
+		RouterOpts:                     routerCreateOpts.Router,
+		ControllerOpts:                 routerCreateOpts.Controller,
+		FlowCollectorOpts:              routerCreateOpts.FlowCollector,
+		PrometheusOpts:                 routerCreateOpts.PrometheusServer,
+		EnableSystemdUnits:             s.flags.SystemdUnits,
+		EnableKubePlay:                 s.flags.KubePlay,
+		PrivilegedPorts:                s.flags.PrivilegedPorts,
+		VolumeHostPaths:                s.flags.VolumeHostPaths,
+		VolumeMountOptions:             s.flags.VolumeMountOptions,
+		RestartPolicy:                  s.flags.RestartPolicy,
+		HealthcheckInterval:            s.flags.HealthcheckInterval,
+		ImagePullPolicy:                s.flags.ImagePullPolicy,
+		RegistryAuthFile:               s.flags.RegistryAuthFile,
+		RegistryUsername:               s.flags.RegistryUsername,
+		RegistryPassword:               utils.DefaultStr(s.flags.RegistryPassword, os.Getenv("SKUPPER_REGISTRY_PASSWORD")),
+		PinImageDigests:                s.flags.PinImageDigests,
+	}
+	if s.flags.CredentialsEncryption != "" {
+		site.CredentialsEncryption = encryption.Options{Method: encryption.Method(s.flags.CredentialsEncryption)}
+		if site.CredentialsEncryption.Method == encryption.MethodKeyring {
+			site.CredentialsEncryption.KeyringKey = s.flags.CredentialsKeyringKey
+		}
+	}
+
+	if site.PodmanEndpoint == "" {
+		site.PodmanEndpoint = podman.RemoteEndpoint
+	}
+
+	siteHandler, err := s.podman.newSiteHandler(site.PodmanEndpoint)
 	if err != nil {
 		initErr := fmt.Errorf("Unable to initialize Skupper - %w", err)
 
@@ -98,16 +153,18 @@ func (s *SkupperPodmanSite) Create(cmd *cobra.Command, args []string) error {
 			if hostnameErr == nil {
 				ingressHosts = append(ingressHosts, hostname)
 			}
-			// Get all system's unicast interface addresses
+			// Get all system's unicast interface addresses, the IPv6 ones
+			// only when the site is bound to IPv6 addresses
+			ipv6 := s.flags.EnableIPV6 || hasIPv6(s.flags.IngressBindIPs)
 			addresses, addressesErr := net.InterfaceAddrs()
 			if addressesErr == nil {
 				for _, address := range addresses {
 					ipnet, ok := address.(*net.IPNet)
-					if ok && !ipnet.IP.IsLoopback() && ipnet.IP.To4() != nil {
-						ipv4ValidAddress := ipnet.IP.String()
-						ingressHosts = append(ingressHosts, ipv4ValidAddress)
-						// Try a reverse lookup of a valid IPv4 address
-						fqdns, err := net.LookupAddr(ipv4ValidAddress)
+					if ok && !ipnet.IP.IsLoopback() && (ipnet.IP.To4() != nil || ipv6 && ipnet.IP.IsGlobalUnicast()) {
+						validAddress := ipnet.IP.String()
+						ingressHosts = append(ingressHosts, validAddress)
+						// Try a reverse lookup of a valid address
+						fqdns, err := net.LookupAddr(validAddress)
 						if err == nil {
 							for _, fqdn := range fqdns {
 								if !utils.StringSliceContains(ingressHosts, fqdn) {
@@ -175,7 +232,7 @@ func (s *SkupperPodmanSite) CreateFlags(cmd *cobra.Command) {
 
 	// --ingress-bind-ip
 	cmd.Flags().StringSliceVarP(&s.flags.IngressBindIPs, "ingress-bind-ip", "", []string{},
-		"IP addresses in the host machines that will be bound to the inter-router and edge ports.")
+		"IPv4 or IPv6 addresses in the host machines that will be bound to the inter-router and edge ports.")
 
 	// --bind-port (interior)
 	cmd.Flags().IntVar(&s.flags.IngressBindInterRouterPort, "bind-port", int(types.InterRouterListenerPort),
@@ -183,17 +240,58 @@ func (s *SkupperPodmanSite) CreateFlags(cmd *cobra.Command) {
 	// --bind-port-edge
 	cmd.Flags().IntVar(&s.flags.IngressBindEdgePort, "bind-port-edge", int(types.EdgeListenerPort),
 		"ingress host binding port used for incoming links from sites using edge mode")
+	// --privileged-ports
+	cmd.Flags().StringVar(&s.flags.PrivilegedPorts, "privileged-ports", podman.PrivilegedPortsRemap,
+		"How ports below net.ipv4.ip_unprivileged_port_start are handled when the container engine is rootless. "+
+			"Valid values: remap (bind them 8000 ports above, so 443 becomes 8443), fail, "+
+			"socket-activation (remap them and accept the privileged ports through systemd socket units)")
 	// --container-network
 	cmd.Flags().StringVar(&s.flags.ContainerNetwork, "container-network", container.ContainerNetworkName,
 		"container network name to be used")
 	// --enable-ipv6
 	cmd.Flags().BoolVarP(&s.flags.EnableIPV6, "enable-ipv6", "", false,
-		"Enable IPV6 on the container network to be created (ignored when using an existing container network)")
+		"Enable IPV6 (dual-stack) on the container network to be created (ignored when using an existing container network)")
+	// --container-network-subnet
+	cmd.Flags().StringSliceVar(&s.flags.ContainerNetworkSubnets, "container-network-subnet", []string{},
+		"IPv4 and/or IPv6 subnets (CIDR) of the container network to be created, an IPv6 subnet enables IPV6 "+
+			"(ignored when using an existing container network)")
+	// --volume-host-path
+	cmd.Flags().StringToStringVar(&s.flags.VolumeHostPaths, "volume-host-path", map[string]string{},
+		"Directories of the host backing the volumes of the site, as volume=/absolute/path (e.g. skupper-router-certs=/srv/skupper/certs). "+
+			"Valid volumes: "+strings.Join(podman.SkupperContainerVolumes, ", "))
+	// --volume-mount-options
+	cmd.Flags().StringToStringVar(&s.flags.VolumeMountOptions, "volume-mount-options", map[string]string{},
+		"Options the volumes are mounted with, as volume=opt1,opt2 (e.g. skupper-internal=z,U). Valid options: "+
+			strings.Join(podman.ValidMountOptions, ", ")+" (default: z). Use Z only for volumes mounted by a single container")
+	// --restart-policy
+	cmd.Flags().StringVar(&s.flags.RestartPolicy, "restart-policy", podman.RestartAlways,
+		"Restart policy of the site containers. Valid values: "+strings.Join(podman.ValidRestartPolicies, ", "))
+	// --healthcheck-interval
+	cmd.Flags().DurationVar(&s.flags.HealthcheckInterval, "healthcheck-interval", podman.HealthcheckIntervalDefault,
+		"How often the router, controller and flow collector containers are verified healthy, 0 disables the healthchecks")
+	// --image-pull-policy
+	cmd.Flags().StringVar(&s.flags.ImagePullPolicy, "image-pull-policy", container.PullAlways,
+		"When the images of the site are pulled. Valid values: "+strings.Join(container.ValidPullPolicies, ", ")+
+			" (never requires the images to be loaded, as in air-gapped hosts)")
+	// --registry-auth-file
+	cmd.Flags().StringVar(&s.flags.RegistryAuthFile, "registry-auth-file", "",
+		"Auth file written by podman or docker login holding the registry credentials "+
+			"(default: $REGISTRY_AUTH_FILE for podman, $DOCKER_CONFIG/config.json for docker)")
+	// --registry-username
+	cmd.Flags().StringVar(&s.flags.RegistryUsername, "registry-username", "",
+		"Username to authenticate against the registry of the images")
+	// --registry-password
+	cmd.Flags().StringVar(&s.flags.RegistryPassword, "registry-password", "",
+		"Password to authenticate against the registry of the images (can also be set through SKUPPER_REGISTRY_PASSWORD)")
+	// --pin-image-digests
+	cmd.Flags().BoolVar(&s.flags.PinImageDigests, "pin-image-digests", false,
+		"Run the containers from the digests of the pulled images, so they are not changed by retagged images")
 	// --podman-endpoint
 	cmd.Flags().StringVar(&s.flags.PodmanEndpoint, "podman-endpoint", "",
-		"local podman endpoint to use")
+		"podman (or docker) endpoint to use: a local socket, ssh://[user@]host[:port]/path/to/podman.sock[?identity=key] "+
+			"or tcp://host:port, over tls with https:// or with the tls-ca, tls-cert and tls-key query parameters naming the certificates")
 
-	cmd.Flags().BoolVarP(&routerCreateOpts.EnableConsole, "enable-console", "", false, "Enable skupper console must be used in conjunction with '--enable-flow-collector' flag")
+	cmd.Flags().BoolVarP(&routerCreateOpts.EnableConsole, "enable-console", "", false, "Enable skupper console, along with the flow collector serving it and the prometheus server holding its metrics")
 	cmd.Flags().StringVarP(&routerCreateOpts.AuthMode, "console-auth", "", "internal", "Authentication mode for console(s). One of: 'internal', 'unsecured'")
 	cmd.Flags().StringVarP(&routerCreateOpts.User, "console-user", "", "", "Skupper console user. Valid only when --console-auth=internal")
 	cmd.Flags().StringVarP(&routerCreateOpts.Password, "console-password", "", "", "Skupper console user. Valid only when --console-auth=internal")
@@ -201,17 +299,47 @@ func (s *SkupperPodmanSite) CreateFlags(cmd *cobra.Command) {
 	// --bind-port-flow-collector
 	cmd.Flags().IntVar(&s.flags.IngressBindFlowCollectorPort, "bind-port-flow-collector", int(types.FlowCollectorDefaultServicePort),
 		"ingress host binding port used for flow-collector and console")
+	// --enable-metrics-exporter
+	cmd.Flags().BoolVarP(&s.flags.EnableMetricsExporter, "enable-metrics-exporter", "", false,
+		"Expose the metrics of the local router to an external prometheus server")
+	// --bind-port-metrics-exporter
+	cmd.Flags().IntVar(&s.flags.IngressBindMetricsExporterPort, "bind-port-metrics-exporter", int(types.MetricsExporterDefaultPort),
+		"ingress host binding port used for the router metrics exporter")
 	cmd.Flags().DurationVar(&routerCreateOpts.FlowCollector.FlowRecordTtl, "flow-collector-record-ttl", 0, "Time after which terminated flow records are deleted, i.e. those flow records that have an end time set. Default is 30 minutes.")
+	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.TlsMinVersion, "flow-collector-tls-min-version", "", "Minimum tls version accepted by the console and flow collector api, 1.2 (default) or 1.3")
+	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.TlsCipherSuites, "flow-collector-tls-cipher-suites", "", "Comma separated tls 1.2 cipher suites accepted by the console and flow collector api")
+	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.TlsCurvePreferences, "flow-collector-tls-curve-preferences", "", "Comma separated curves for the console and flow collector api key exchange, in order of preference (X25519, P256, P384, P521)")
+	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.AcmeDomains, "flow-collector-acme-domains", "", "Comma separated public domains of the console, its certificate being obtained and renewed through ACME (Let's Encrypt), the console must be reachable on port 443 of the domains")
+	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.AcmeEmail, "flow-collector-acme-email", "", "Contact email of the ACME account")
+	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.AcmeDirectory, "flow-collector-acme-directory", "", "ACME directory url, Let's Encrypt by default")
 
 	// limits
-	cmd.Flags().StringVar(&routerCreateOpts.Router.CpuLimit, "router-cpu-limit", "", "CPU limit for router container (decimal)")
-	cmd.Flags().StringVar(&routerCreateOpts.Router.MemoryLimit, "router-memory-limit", "", "Memory limit for router container (bytes)")
-	cmd.Flags().StringVar(&routerCreateOpts.Controller.CpuLimit, "controller-cpu-limit", "", "CPU limit for controller container (decimal)")
-	cmd.Flags().StringVar(&routerCreateOpts.Controller.MemoryLimit, "controller-memory-limit", "", "Memory limit for controller container (bytes)")
-	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.CpuLimit, "flow-collector-cpu-limit", "", "CPU limit for flow collector container (decimal)")
-	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.MemoryLimit, "flow-collector-memory-limit", "", "Memory limit for flow collector container (bytes)")
-	cmd.Flags().StringVar(&routerCreateOpts.PrometheusServer.CpuLimit, "prometheus-cpu-limit", "", "CPU limit for prometheus container (decimal)")
-	cmd.Flags().StringVar(&routerCreateOpts.PrometheusServer.MemoryLimit, "prometheus-memory-limit", "", "Memory limit for prometheus container (bytes)")
+	cmd.Flags().StringVar(&routerCreateOpts.Router.CpuLimit, "router-cpu-limit", "", "CPU limit for router container (number of cpus, e.g. 0.5, 2 or 500m)")
+	cmd.Flags().StringVar(&routerCreateOpts.Router.MemoryLimit, "router-memory-limit", "", "Memory limit for router container (bytes or with a unit, 512m and 1g being binary units as with podman, 512Mi and 1Gi binary and 512M and 1G decimal kubernetes quantities)")
+	cmd.Flags().StringVar(&routerCreateOpts.Controller.CpuLimit, "controller-cpu-limit", "", "CPU limit for controller container (number of cpus, e.g. 0.5, 2 or 500m)")
+	cmd.Flags().StringVar(&routerCreateOpts.Controller.MemoryLimit, "controller-memory-limit", "", "Memory limit for controller container (bytes or with a unit, 512m and 1g being binary units as with podman, 512Mi and 1Gi binary and 512M and 1G decimal kubernetes quantities)")
+	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.CpuLimit, "flow-collector-cpu-limit", "", "CPU limit for flow collector container (number of cpus, e.g. 0.5, 2 or 500m)")
+	cmd.Flags().StringVar(&routerCreateOpts.FlowCollector.MemoryLimit, "flow-collector-memory-limit", "", "Memory limit for flow collector container (bytes or with a unit, 512m and 1g being binary units as with podman, 512Mi and 1Gi binary and 512M and 1G decimal kubernetes quantities)")
+	cmd.Flags().StringVar(&routerCreateOpts.PrometheusServer.CpuLimit, "prometheus-cpu-limit", "", "CPU limit for prometheus container (number of cpus, e.g. 0.5, 2 or 500m)")
+	cmd.Flags().StringVar(&routerCreateOpts.PrometheusServer.MemoryLimit, "prometheus-memory-limit", "", "Memory limit for prometheus container (bytes or with a unit, 512m and 1g being binary units as with podman, 512Mi and 1Gi binary and 512M and 1G decimal kubernetes quantities)")
+
+	// prometheus storage
+	cmd.Flags().StringVar(&routerCreateOpts.PrometheusServer.RetentionTime, "prometheus-retention-time", "", "How long the prometheus container retains the flow collector metrics (e.g. 15d). Valid only when --enable-flow-collector")
+	cmd.Flags().StringVar(&routerCreateOpts.PrometheusServer.RetentionSize, "prometheus-retention-size", "", "Maximum size of the metrics stored by the prometheus container (e.g. 512MB). Valid only when --enable-flow-collector")
+
+	// credentials encryption
+	cmd.Flags().StringVar(&s.flags.CredentialsEncryption, "credentials-encryption", "", "Encrypt the site credentials at rest. One of: 'keyring', 'passphrase' (read from "+encryption.PassphraseEnvVar+"). The key is handed over to the controller and flow collector containers in a file only the site user can read")
+	cmd.Flags().StringVar(&s.flags.CredentialsKeyringKey, "credentials-keyring-key", "skupper", "Name of the user key in the kernel keyring used to encrypt the site credentials. Valid only when --credentials-encryption=keyring")
+
+	// --systemd-units
+	cmd.Flags().BoolVar(&s.flags.SystemdUnits, "systemd-units", false,
+		"Install a systemd unit per container (the router being started first) instead of the startup service, "+
+			"so the site survives host reboots. The units are system units when running as root. Only valid on the podman platform")
+
+	// --kube-play
+	cmd.Flags().BoolVar(&s.flags.KubePlay, "kube-play", false,
+		"Render each container as a Kubernetes pod YAML, kept under "+podman.GetKubePlayDir()+", "+
+			"and launch it through podman kube play (requires the podman command line)")
 
 	cmd.Flags().DurationVar(&s.flags.Timeout, "timeout", types.DefaultTimeoutDuration, "Configurable timeout for site initialization")
 
@@ -219,35 +347,96 @@ func (s *SkupperPodmanSite) CreateFlags(cmd *cobra.Command) {
 
 func (s *SkupperPodmanSite) Delete(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
-	siteHandler, err := podman.NewSitePodmanHandler("")
+	siteHandler, err := s.podman.newSiteHandler("")
 	if err != nil {
 		return fmt.Errorf("Unable to delete Skupper - %w", err)
 	}
 	if s.podman.currentSite == nil && !siteHandler.AnyResourceLeft() {
 		fmt.Printf("Skupper is not enabled for user '%s'", podman.Username)
 		fmt.Println()
-		return nil
+		return s.removeSiteService()
 	}
 	err = siteHandler.Delete()
 	if err != nil {
 		return err
 	}
+	if err = s.removeSiteService(); err != nil {
+		return err
+	}
 	fmt.Println("Skupper is now removed for user '" + podman.Username + "'.")
 	return nil
 }
 
+// removeSiteService removes the podman service and the storage of the site
+// identified by --site-id
+func (s *SkupperPodmanSite) removeSiteService() error {
+	if config.SiteId == "" {
+		return nil
+	}
+	if err := config.NewPodmanSiteService(config.SiteId).Remove(); err != nil {
+		return fmt.Errorf("Unable to remove the podman service of site %s - %w", config.SiteId, err)
+	}
+	return nil
+}
+
 func (s *SkupperPodmanSite) DeleteFlags(cmd *cobra.Command) {}
 
+// List reports the sites of the local podman host and of the hosts of all
+// the contexts
 func (s *SkupperPodmanSite) List(cmd *cobra.Command, args []string) error {
-	return notImplementedErr
+	silenceCobra(cmd)
+	if s.podman.Platform() != types.PlatformPodman {
+		return fmt.Errorf("the sites of all the hosts can only be listed on podman")
+	}
+	localEndpoint := ""
+	if podmanCfg, err := podman.NewPodmanConfigFileHandler().GetConfig(); err == nil {
+		localEndpoint = podmanCfg.Endpoint
+	}
+	contexts, err := podman.NewPodmanContextsFileHandler().GetContexts()
+	if err != nil {
+		return err
+	}
+	hosts := append(podman.SiteHosts(localEndpoint, contexts), podman.LocalSiteHosts(config.ListSiteIds())...)
+	sites := podman.SummarizeSites(hosts, podman.SummarizeSite)
+	return printSiteSummaries(os.Stdout, sites)
+}
+
+func printSiteSummaries(w io.Writer, sites []podman.SiteSummary) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tSITE\tMODE\tLINKS\tSERVICES\tSTATUS")
+	var unavailable []podman.SiteSummary
+	for _, site := range sites {
+		switch {
+		case site.Err != nil && !site.Enabled:
+			fmt.Fprintf(tw, "%s\t\t\t\t\tunavailable\n", site.Host.Name)
+			unavailable = append(unavailable, site)
+		case !site.Enabled:
+			fmt.Fprintf(tw, "%s\t\t\t\t\tnot enabled\n", site.Host.Name)
+		case site.Err != nil:
+			fmt.Fprintf(tw, "%s\t%s\t%s\t\t\tenabled\n", site.Host.Name, site.Name, site.Mode)
+			unavailable = append(unavailable, site)
+		default:
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\tenabled\n", site.Host.Name, site.Name, site.Mode, site.Links, site.Services)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, site := range unavailable {
+		fmt.Fprintf(w, "Warning: %s: %s\n", site.Host.Name, site.Err)
+	}
+	return nil
 }
 
 func (s *SkupperPodmanSite) ListFlags(cmd *cobra.Command) {}
 
 func (s *SkupperPodmanSite) Status(cmd *cobra.Command, args []string) error {
 	silenceCobra(cmd)
+	if statusAll {
+		return s.List(cmd, args)
+	}
 
-	siteHandler, err := podman.NewSitePodmanHandler("")
+	siteHandler, err := s.podman.newSiteHandler("")
 
 	podmanSiteVersion := s.podman.currentSite.Version
 	if podmanSiteVersion != "" && !utils.IsValidFor(podmanSiteVersion, network.MINIMUM_PODMAN_VERSION) {
@@ -281,7 +470,7 @@ func (s *SkupperPodmanSite) Status(cmd *cobra.Command, args []string) error {
 	}
 
 	statusOutput.mode = site.GetMode()
-	statusOutput.enabledIn = PlatformSupport{"podman", podman.Username}
+	statusOutput.enabledIn = PlatformSupport{string(s.podman.Platform()), podman.Username}
 
 	var currentSite = statusManager.GetSiteById(site.Id)
 
@@ -302,9 +491,23 @@ func (s *SkupperPodmanSite) Status(cmd *cobra.Command, args []string) error {
 
 	statusOutput.exposedServices = len(currentStatus.Addresses)
 
+	if health, err := siteHandler.ComponentHealth(); err == nil {
+		for _, c := range health {
+			statusOutput.components = append(statusOutput.components, ComponentStatusData{name: c.Name, health: c.Health})
+		}
+	}
+
 	if site.EnableFlowCollector {
 		statusOutput.consoleUrl = site.GetConsoleUrl()
-		statusOutput.credentials = PlatformSupport{"podman volume", "'skupper-console-users'"}
+		statusOutput.credentials = PlatformSupport{string(s.podman.Platform()) + " volume", "'skupper-console-users'"}
+
+		// the router view above is kept when the collector cannot be reached
+		summary, err := s.collectorSummary()
+		if err == nil {
+			statusOutput.collector = NewCollectorStatusData(site.Id, summary)
+		} else if verboseStatus {
+			statusOutput.warnings = append(statusOutput.warnings, fmt.Sprintf("flow collector data is not available: %s", err))
+		}
 	}
 
 	if verboseStatus {
@@ -322,7 +525,19 @@ func (s *SkupperPodmanSite) Status(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func (s *SkupperPodmanSite) StatusFlags(cmd *cobra.Command) {}
+var statusAll bool
+
+func (s *SkupperPodmanSite) StatusFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&statusAll, "all", false, "Report the sites of the local podman host and of the hosts of all the contexts")
+}
+
+func (s *SkupperPodmanSite) collectorSummary() (*network.CollectorSummary, error) {
+	collector, err := podman.NewFlowCollectorClient(s.podman.currentSite, s.podman.cli)
+	if err != nil {
+		return nil, err
+	}
+	return collector.Summary(context.Background())
+}
 
 func (s *SkupperPodmanSite) NewClient(cmd *cobra.Command, args []string) {
 	var initArgs []string
@@ -337,19 +552,147 @@ func (s *SkupperPodmanSite) Platform() types.Platform {
 }
 
 func (s *SkupperPodmanSite) Update(cmd *cobra.Command, args []string) error {
-	siteHandler, err := podman.NewSitePodmanHandler("")
+	siteHandler, err := s.podman.newSiteHandler("")
 	if err != nil {
 		return fmt.Errorf("Unable to communicate with Skupper site - %w", err)
 	}
+	if len(certificateHosts) > 0 {
+		if err = siteHandler.AddCertificateHosts(certificateHosts...); err != nil {
+			return fmt.Errorf("Error while trying to update certificate hosts - %w", err)
+		}
+		fmt.Println("Updated certificate hosts")
+	}
+	if cmd.Flags().Changed("enable-flow-collector") || cmd.Flags().Changed("enable-console") {
+		if err = s.updateFlowCollector(cmd, siteHandler); err != nil {
+			return fmt.Errorf("Error while trying to update the flow collector - %w", err)
+		}
+	}
 	siteHandler.SetUpdateProcessor(s.up)
 	return siteHandler.Update()
 }
 
+// updateFlowCollector deploys or removes the flow collector and the console,
+// the one not given keeping its current state unless it conflicts
+func (s *SkupperPodmanSite) updateFlowCollector(cmd *cobra.Command, siteHandler *podman.SiteHandler) error {
+	site := s.podman.currentSite
+	if site == nil {
+		return fmt.Errorf("Skupper is not enabled for user '%s'", podman.Username)
+	}
+	enableFlowCollector, enableConsole := site.EnableFlowCollector, site.EnableConsole
+	if cmd.Flags().Changed("enable-flow-collector") {
+		enableFlowCollector = s.update.EnableFlowCollector
+		if !enableFlowCollector {
+			enableConsole = false
+		}
+	}
+	if cmd.Flags().Changed("enable-console") {
+		enableConsole = s.update.EnableConsole
+		if enableConsole && cmd.Flags().Changed("enable-flow-collector") && !enableFlowCollector {
+			return fmt.Errorf("the console cannot be enabled without the flow collector")
+		}
+		if enableConsole {
+			enableFlowCollector = true
+		}
+	}
+	if enableFlowCollector == site.EnableFlowCollector && enableConsole == site.EnableConsole {
+		return nil
+	}
+	if s.up.DryRun {
+		fmt.Printf("The flow collector would be enabled: %t, the console would be enabled: %t\n", enableFlowCollector, enableConsole)
+		return nil
+	}
+	ctx, cn := context.WithTimeout(context.Background(), s.up.Timeout)
+	defer cn()
+	if err := siteHandler.UpdateFlowCollector(ctx, enableFlowCollector, enableConsole); err != nil {
+		return err
+	}
+	fmt.Printf("Updated the flow collector (enabled: %t) and the console (enabled: %t)\n", enableFlowCollector, enableConsole)
+	return nil
+}
+
 func (s *SkupperPodmanSite) UpdateFlags(cmd *cobra.Command) {
 	s.up = &domain.UpdateProcessor{}
 	cmd.Flags().BoolVar(&s.up.DryRun, "dry-run", false, "only prints the tasks to be performed, but does not run any action")
 	cmd.Flags().BoolVar(&s.up.Verbose, "verbose", false, "displays tasks and post tasks being executed")
 	cmd.Flags().DurationVar(&s.up.Timeout, "timeout", types.DefaultTimeoutDuration, "Configurable timeout for site update")
+	cmd.Flags().StringSliceVar(&certificateHosts, "certificate-host", []string{}, "DNS name or IP address to add to the certificate presented to linking sites, can be used multiple times.")
+	cmd.Flags().BoolVar(&s.update.EnableFlowCollector, "enable-flow-collector", false, "Deploy or remove the flow collector of the site, along with the prometheus server it queries")
+	cmd.Flags().BoolVar(&s.update.EnableConsole, "enable-console", false, "Deploy or remove the console of the site, the flow collector serving it being deployed when needed")
+}
+
+func NewCmdUpgradePodman(s *SkupperPodmanSite) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Upgrade the podman site to " + version.Version,
+		Long: "Upgrade the podman site to " + version.Version + ", pulling the images of all the components before " +
+			"any container is updated, migrating the site configuration and restarting the router last",
+		Args:   cobra.NoArgs,
+		PreRun: s.NewClient,
+		RunE:   s.Upgrade,
+	}
+	s.upgrade = &domain.UpdateProcessor{}
+	cmd.Flags().BoolVar(&s.upgrade.DryRun, "dry-run", false, "only prints the tasks to be performed, but does not run any action")
+	cmd.Flags().BoolVar(&s.upgrade.Verbose, "verbose", false, "displays tasks and post tasks being executed")
+	cmd.Flags().DurationVar(&s.upgrade.Timeout, "timeout", types.DefaultTimeoutDuration, "Configurable timeout for site upgrade")
+	cmd.Flags().StringVar(&s.flags.ImagePullPolicy, "image-pull-policy", container.PullAlways,
+		"When the images of the new version are pulled. Valid values: "+strings.Join(container.ValidPullPolicies, ", "))
+	cmd.Flags().StringVar(&s.flags.RegistryAuthFile, "registry-auth-file", "",
+		"Auth file written by podman or docker login holding the registry credentials")
+	cmd.Flags().StringVar(&s.flags.RegistryUsername, "registry-username", "",
+		"Username to authenticate against the registry of the images")
+	cmd.Flags().StringVar(&s.flags.RegistryPassword, "registry-password", "",
+		"Password to authenticate against the registry of the images (can also be set through SKUPPER_REGISTRY_PASSWORD)")
+	return cmd
+}
+
+func (s *SkupperPodmanSite) Upgrade(cmd *cobra.Command, args []string) error {
+	silenceCobra(cmd)
+	siteHandler, err := s.podman.newSiteHandler("")
+	if err != nil {
+		return fmt.Errorf("Unable to communicate with Skupper site - %w", err)
+	}
+	siteHandler.SetUpdateProcessor(s.upgrade)
+	return siteHandler.Upgrade(&podman.Site{
+		ImagePullPolicy:  s.flags.ImagePullPolicy,
+		RegistryAuthFile: s.flags.RegistryAuthFile,
+		RegistryUsername: s.flags.RegistryUsername,
+		RegistryPassword: utils.DefaultStr(s.flags.RegistryPassword, os.Getenv("SKUPPER_REGISTRY_PASSWORD")),
+	})
+}
+
+func NewCmdExportComposePodman(s *SkupperPodmanSite) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export-compose [output-file]",
+		Short: "Export the podman site as a compose file",
+		Long: "Export the containers of the podman site as a compose file, to be used with docker compose or podman-compose. " +
+			"The networks and volumes of the site are declared as external, as they hold its configuration and certificates. " +
+			"The file is written to the standard output when no output file is given.",
+		Args:   cobra.MaximumNArgs(1),
+		PreRun: s.NewClient,
+		RunE:   s.ExportCompose,
+	}
+	return cmd
+}
+
+func (s *SkupperPodmanSite) ExportCompose(cmd *cobra.Command, args []string) error {
+	silenceCobra(cmd)
+	siteHandler, err := s.podman.newSiteHandler("")
+	if err != nil {
+		return fmt.Errorf("Unable to communicate with Skupper site - %w", err)
+	}
+	data, err := siteHandler.ExportCompose()
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		fmt.Print(string(data))
+		return nil
+	}
+	if err = os.WriteFile(args[0], data, 0600); err != nil {
+		return fmt.Errorf("unable to write %s - %w", args[0], err)
+	}
+	fmt.Printf("Compose file written to %s\n", args[0])
+	return nil
 }
 
 func (s *SkupperPodmanSite) Version(cmd *cobra.Command, args []string) error {
@@ -374,9 +717,23 @@ func (s *SkupperPodmanSite) Version(cmd *cobra.Command, args []string) error {
 }
 
 func (s *SkupperPodmanSite) RevokeAccess(cmd *cobra.Command, args []string) error {
-	siteHandler, err := podman.NewSitePodmanHandler("")
+	if revokeLinkCredential != "" {
+		return fmt.Errorf("--link-credential is not supported on podman sites")
+	}
+	siteHandler, err := s.podman.newSiteHandler("")
 	if err != nil {
 		return fmt.Errorf("Unable to communicate with Skupper site - %w", err)
 	}
 	return siteHandler.RevokeAccess()
 }
+
+// hasIPv6 tells if any of the given addresses is an IPv6 one
+func hasIPv6(addresses []string) bool {
+	for _, address := range addresses {
+		ip := net.ParseIP(strings.Trim(address, "[]"))
+		if ip != nil && ip.To4() == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -176,3 +176,20 @@ func (sh *siteHandlerMock) Update() error {
 func (sh *siteHandlerMock) RevokeAccess() error {
 	return fmt.Errorf("not implemented")
 }
+
+func TestPrintSiteSummaries(t *testing.T) {
+	sites := []podman.SiteSummary{
+		{Host: podman.SiteHost{Name: "local", Local: true}, Name: "west", Mode: "interior", Links: 2, Services: 3, Enabled: true},
+		{Host: podman.SiteHost{Name: "edge"}, Err: fmt.Errorf("podman endpoint is not available - connection refused")},
+		{Host: podman.SiteHost{Name: "lab"}},
+	}
+	out := &bytes.Buffer{}
+	assert.Assert(t, printSiteSummaries(out, sites))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, len(lines), 5)
+	assert.Assert(t, strings.HasPrefix(lines[0], "HOST"))
+	assert.Equal(t, strings.Join(strings.Fields(lines[1]), " "), "local west interior 2 3 enabled")
+	assert.Equal(t, strings.Join(strings.Fields(lines[2]), " "), "edge unavailable")
+	assert.Equal(t, strings.Join(strings.Fields(lines[3]), " "), "lab not enabled")
+	assert.Equal(t, lines[4], "Warning: edge: podman endpoint is not available - connection refused")
+}
@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"text/tabwriter"
+
+	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/pkg/network"
 )
 
 type PlatformSupport struct {
@@ -25,6 +29,34 @@ type StatusData struct {
 	exposedServices     int
 	consoleUrl          string
 	credentials         PlatformSupport
+	collector           *CollectorStatusData
+	components          []ComponentStatusData
+}
+
+// ComponentStatusData is the health state of a container of the site
+type ComponentStatusData struct {
+	name   string
+	health string
+}
+
+// CollectorStatusData is the part of the status reported by the flow collector
+type CollectorStatusData struct {
+	remoteSites []string
+	services    int
+	flows       uint64
+}
+
+func NewCollectorStatusData(currentSite string, summary *network.CollectorSummary) *CollectorStatusData {
+	data := &CollectorStatusData{services: len(summary.Addresses)}
+	for _, site := range summary.Sites {
+		if site.Identity != currentSite {
+			data.remoteSites = append(data.remoteSites, site.Name)
+		}
+	}
+	for _, pair := range summary.Traffic {
+		data.flows += pair.Flows
+	}
+	return data
 }
 
 func PrintStatus(data StatusData) error {
@@ -73,6 +105,31 @@ func PrintStatus(data StatusData) error {
 	}
 	fmt.Println()
 
+	if data.collector != nil {
+		if len(data.collector.remoteSites) == 0 {
+			fmt.Printf("The flow collector sees no remote sites.")
+		} else {
+			fmt.Printf("The flow collector sees remote sites: %s.", strings.Join(data.collector.remoteSites, ", "))
+		}
+		fmt.Printf(" It reports %d services and %d flows between sites.", data.collector.services, data.collector.flows)
+		fmt.Println()
+	}
+
+	if len(data.components) > 0 {
+		var notHealthy []string
+		for _, c := range data.components {
+			if c.health != container.HealthHealthy {
+				notHealthy = append(notHealthy, fmt.Sprintf("%s (%s)", c.name, c.health))
+			}
+		}
+		if len(notHealthy) == 0 {
+			fmt.Printf("The site containers are healthy.")
+		} else {
+			fmt.Printf("The site containers are not all healthy: %s.", strings.Join(notHealthy, ", "))
+		}
+		fmt.Println()
+	}
+
 	if len(data.consoleUrl) > 0 {
 		fmt.Println("The site console url is: ", data.consoleUrl)
 		if len(data.credentials.supportName) > 0 {
@@ -110,6 +167,16 @@ func PrintVerboseStatus(data StatusData) error {
 
 	fmt.Fprintf(writer, "%s:\t %s \n", "exposed services", strconv.Itoa(data.exposedServices))
 
+	if data.collector != nil {
+		fmt.Fprintf(writer, "%s:\t %s \n", "collector remote sites", strings.Join(data.collector.remoteSites, ", "))
+		fmt.Fprintf(writer, "%s:\t %s \n", "collector services", strconv.Itoa(data.collector.services))
+		fmt.Fprintf(writer, "%s:\t %s \n", "collector flows", strconv.FormatUint(data.collector.flows, 10))
+	}
+
+	for _, c := range data.components {
+		fmt.Fprintf(writer, "%s:\t %s \n", "health "+c.name, c.health)
+	}
+
 	if len(data.consoleUrl) > 0 {
 		fmt.Fprintf(writer, "%s:\t %s \n", "site console url", data.consoleUrl)
 	}
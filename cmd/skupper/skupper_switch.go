@@ -11,7 +11,7 @@ import (
 )
 
 func NewCmdSwitch() *cobra.Command {
-	validPlatforms := []string{"kubernetes", "podman", "-"}
+	validPlatforms := []string{"kubernetes", "podman", "docker", "-"}
 	cmd := &cobra.Command{
 		Use:       "switch <platform>",
 		Short:     fmt.Sprintf("Select the platform to manage (valid platforms: %s)", strings.Join(validPlatforms, ", ")),
@@ -27,6 +27,9 @@ func NewCmdSwitch() *cobra.Command {
 	# Switch to podman
 	skupper switch podman
 
+	# Switch to docker
+	skupper switch docker
+
 	# Switch back to the previous platform
 	skupper switch -`,
 		RunE: func(cmd *cobra.Command, args []string) error {
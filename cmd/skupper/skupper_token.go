@@ -23,6 +23,9 @@ var password string
 var expiry time.Duration
 var uses int
 var tokenTemplate string
+var tokenMutual bool
+var tokenPolicy types.ClaimPolicy
+var tokenValidFrom string
 
 func NewCmdTokenCreate(skupperClient SkupperTokenClient, flag string) *cobra.Command {
 	subflag := ""
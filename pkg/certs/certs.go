@@ -33,6 +33,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/serializer/json"
 	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/skupperproject/skupper/pkg/encryption"
+	"github.com/skupperproject/skupper/pkg/fips"
 )
 
 func publicKey(priv interface{}) interface{} {
@@ -57,6 +60,8 @@ type CertificateAuthority struct {
 	Certificate *x509.Certificate
 	Key         interface{}
 	CrtData     []byte
+	// vault issues the certificates when the CA has no key
+	vault *VaultPki
 }
 
 type CertificateData map[string][]byte
@@ -70,6 +75,12 @@ func decodeDataElement(in []byte, name string) []byte {
 }
 
 func getCAFromSecret(secret *corev1.Secret) CertificateAuthority {
+	if vault := VaultPkiFromSecret(secret); vault != nil {
+		return CertificateAuthority{
+			CrtData: secret.Data["tls.crt"],
+			vault:   vault,
+		}
+	}
 	cert, err := x509.ParseCertificate(decodeDataElement(secret.Data["tls.crt"], "certificate"))
 	if err != nil {
 		log.Fatal("failed to get CA certificate from secret")
@@ -86,7 +97,14 @@ func getCAFromSecret(secret *corev1.Secret) CertificateAuthority {
 }
 
 func generateSecret(name string, subject string, hosts string, ca *CertificateAuthority, expiration time.Duration) corev1.Secret {
-	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if ca != nil && ca.vault != nil {
+		secret, err := ca.vault.issueSecret(name, subject, hosts, expiration, ca.CrtData)
+		if err != nil {
+			log.Fatalf("Failed to issue certificate %s through vault: %s", name, err)
+		}
+		return secret
+	}
+	priv, err := rsa.GenerateKey(rand.Reader, fips.RSAKeyBits())
 	if err != nil {
 		log.Fatalf("failed to generate private key: %s", err)
 	}
@@ -128,7 +146,7 @@ func generateSecret(name string, subject string, hosts string, ca *CertificateAu
 	if ca == nil {
 		// self signed
 		template.IsCA = true
-		template.KeyUsage |= x509.KeyUsageCertSign
+		template.KeyUsage |= x509.KeyUsageCertSign | x509.KeyUsageCRLSign
 		parent = &template
 		cakey = priv
 	} else {
@@ -308,7 +326,7 @@ func getTlsConfig(verify bool, cert, key, ca string) (*tls.Config, error) {
 	config.InsecureSkipVerify = true
 	if verify {
 		certPool := x509.NewCertPool()
-		file, err := os.ReadFile(ca)
+		file, err := encryption.ReadFile(ca)
 		if err != nil {
 			return nil, err
 		}
@@ -320,13 +338,14 @@ func getTlsConfig(verify bool, cert, key, ca string) (*tls.Config, error) {
 	_, errCert := os.Stat(cert)
 	_, errKey := os.Stat(key)
 	if errCert == nil || errKey == nil {
-		tlsCert, err := tls.LoadX509KeyPair(cert, key)
+		tlsCert, err := encryption.LoadX509KeyPair(cert, key)
 		if err != nil {
 			log.Fatal("Could not load x509 key pair", err.Error())
 		}
 		config.Certificates = []tls.Certificate{tlsCert}
 	}
 	config.MinVersion = tls.VersionTLS10
+	fips.RestrictTls(&config)
 
 	return &config, nil
 }
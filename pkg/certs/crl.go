@@ -0,0 +1,156 @@
+package certs
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ErrCannotSignCRL is returned for CAs that have no key or that were not
+// issued with the CRL signing key usage, such as those of older sites
+var ErrCannotSignCRL = errors.New("the CA is not allowed to sign certificate revocation lists")
+
+// IssuedCertificate is a certificate issued by the site CA to a linked site
+type IssuedCertificate struct {
+	Serial      string     `json:"serial"`
+	Fingerprint string     `json:"fingerprint"`
+	Subject     string     `json:"subject"`
+	NotAfter    time.Time  `json:"notAfter"`
+	RevokedAt   *time.Time `json:"revokedAt,omitempty"`
+}
+
+func (c IssuedCertificate) Revoked() bool {
+	return c.RevokedAt != nil
+}
+
+// CertificateFingerprint returns the hex encoded SHA-256 digest of the
+// certificate, as reported by the router for the peers it authenticates
+func CertificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// SameFingerprint compares fingerprints regardless of their case and of the
+// separators between their bytes
+func SameFingerprint(a string, b string) bool {
+	normalize := func(fingerprint string) string {
+		return strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+	}
+	return normalize(a) == normalize(b)
+}
+
+func NewIssuedCertificate(data []byte) (IssuedCertificate, error) {
+	cert, err := DecodeCertificate(data)
+	if err != nil {
+		return IssuedCertificate{}, err
+	}
+	return IssuedCertificate{
+		Serial:      cert.SerialNumber.Text(16),
+		Fingerprint: CertificateFingerprint(cert),
+		Subject:     cert.Subject.CommonName,
+		NotAfter:    cert.NotAfter,
+	}, nil
+}
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block of type private key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// GenerateCRL returns the PEM encoded revocation list of the revoked
+// certificates, signed by the CA in the secret
+func GenerateCRL(ca *corev1.Secret, revoked []IssuedCertificate, number int64, now time.Time) ([]byte, error) {
+	if len(ca.Data["tls.key"]) == 0 {
+		return nil, ErrCannotSignCRL
+	}
+	cert, err := DecodeCertificate(ca.Data["tls.crt"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA certificate: %w", err)
+	}
+	if cert.KeyUsage&x509.KeyUsageCRLSign == 0 {
+		return nil, ErrCannotSignCRL
+	}
+	key, err := parsePrivateKey(ca.Data["tls.key"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA private key: %w", err)
+	}
+	// the list is only signed again when certificates are issued or revoked,
+	// it stays valid until the last of the revoked certificates expires
+	template := &x509.RevocationList{
+		Number:     big.NewInt(number),
+		ThisUpdate: now,
+		NextUpdate: now.Add(24 * time.Hour),
+	}
+	for _, issued := range revoked {
+		if !issued.Revoked() {
+			continue
+		}
+		if issued.NotAfter.After(template.NextUpdate) {
+			template.NextUpdate = issued.NotAfter
+		}
+		serial, ok := new(big.Int).SetString(issued.Serial, 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid serial number %q", issued.Serial)
+		}
+		template.RevokedCertificates = append(template.RevokedCertificates, pkix.RevokedCertificate{
+			SerialNumber:   serial,
+			RevocationTime: *issued.RevokedAt,
+		})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, template, cert, key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), nil
+}
+
+// RevokedSerials returns the serial numbers of the certificates in the PEM
+// encoded revocation list, once its signature is verified against the CA in
+// the secret
+func RevokedSerials(data []byte, ca *corev1.Secret) ([]string, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "X509 CRL" {
+		return nil, fmt.Errorf("failed to decode PEM block of type X509 CRL")
+	}
+	crl, err := x509.ParseRevocationList(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := DecodeCertificate(ca.Data["tls.crt"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA certificate: %w", err)
+	}
+	if err := crl.CheckSignatureFrom(cert); err != nil {
+		return nil, fmt.Errorf("revocation list not signed by the CA: %w", err)
+	}
+	serials := []string{}
+	for _, entry := range crl.RevokedCertificates {
+		serials = append(serials, entry.SerialNumber.Text(16))
+	}
+	return serials, nil
+}
@@ -0,0 +1,298 @@
+package certs
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/pkg/fips"
+)
+
+const (
+	// the annotations of a CA secret whose certificates are issued by vault
+	VaultAddressAnnotation  = "skupper.io/vault-address"
+	VaultPkiPathAnnotation  = "skupper.io/vault-pki-path"
+	VaultRoleAnnotation     = "skupper.io/vault-role"
+	VaultAuthRoleAnnotation = "skupper.io/vault-auth-role"
+
+	VaultDefaultPkiPath = "pki"
+	vaultRequestTimeout = 30 * time.Second
+)
+
+// VaultServiceAccountTokenFile is the token presented to the kubernetes
+// auth method of vault
+var VaultServiceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// VaultPki issues the certificates of a CA through the pki secrets engine of
+// HashiCorp Vault instead of signing them with a generated CA key. The token
+// is read from VAULT_TOKEN or, when AuthRole is set, obtained through the
+// kubernetes auth method (mounted at VAULT_AUTH_PATH, kubernetes by default)
+// with the service account token. VAULT_CACERT verifies the vault server.
+type VaultPki struct {
+	Address  string
+	PkiPath  string
+	Role     string
+	AuthRole string
+}
+
+// VaultPkiFromSecret returns the vault issuing the certificates of the CA
+// secret, nil when the CA is self generated
+func VaultPkiFromSecret(secret *corev1.Secret) *VaultPki {
+	if secret == nil || secret.Annotations[VaultAddressAnnotation] == "" {
+		return nil
+	}
+	return &VaultPki{
+		Address:  secret.Annotations[VaultAddressAnnotation],
+		PkiPath:  secret.Annotations[VaultPkiPathAnnotation],
+		Role:     secret.Annotations[VaultRoleAnnotation],
+		AuthRole: secret.Annotations[VaultAuthRoleAnnotation],
+	}
+}
+
+func (v *VaultPki) pkiPath() string {
+	if v.PkiPath == "" {
+		return VaultDefaultPkiPath
+	}
+	return strings.Trim(v.PkiPath, "/")
+}
+
+func (v *VaultPki) annotate(secret *corev1.Secret) {
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[VaultAddressAnnotation] = v.Address
+	secret.Annotations[VaultPkiPathAnnotation] = v.pkiPath()
+	secret.Annotations[VaultRoleAnnotation] = v.Role
+	if v.AuthRole != "" {
+		secret.Annotations[VaultAuthRoleAnnotation] = v.AuthRole
+	}
+}
+
+type vaultResponse struct {
+	Data map[string]interface{} `json:"data"`
+	Auth *struct {
+		ClientToken string `json:"client_token"`
+	} `json:"auth"`
+	Errors []string `json:"errors"`
+}
+
+func (v *VaultPki) client() (*http.Client, error) {
+	client := &http.Client{Timeout: vaultRequestTimeout}
+	if caFile := os.Getenv("VAULT_CACERT"); caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read VAULT_CACERT: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificate found in VAULT_CACERT %s", caFile)
+		}
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+	}
+	return client, nil
+}
+
+func (v *VaultPki) request(client *http.Client, method string, path string, token string, body interface{}) (*vaultResponse, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(v.Address, "/")+"/v1/"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	result := &vaultResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid response from vault to %s: %w", path, err)
+	}
+	if resp.StatusCode >= 300 {
+		if len(result.Errors) > 0 {
+			return nil, fmt.Errorf("vault request %s failed: %s", path, strings.Join(result.Errors, ", "))
+		}
+		return nil, fmt.Errorf("vault request %s failed: %s", path, resp.Status)
+	}
+	return result, nil
+}
+
+func (v *VaultPki) token(client *http.Client) (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+	if v.AuthRole == "" {
+		return "", fmt.Errorf("no vault token, set VAULT_TOKEN or the vault kubernetes auth role")
+	}
+	jwt, err := os.ReadFile(VaultServiceAccountTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the service account token for the vault kubernetes auth: %w", err)
+	}
+	authPath := strings.Trim(os.Getenv("VAULT_AUTH_PATH"), "/")
+	if authPath == "" {
+		authPath = "kubernetes"
+	}
+	result, err := v.request(client, http.MethodPost, "auth/"+authPath+"/login", "", map[string]string{
+		"role": v.AuthRole,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	})
+	if err != nil {
+		return "", err
+	}
+	if result.Auth == nil || result.Auth.ClientToken == "" {
+		return "", fmt.Errorf("no token returned by the vault kubernetes auth")
+	}
+	return result.Auth.ClientToken, nil
+}
+
+func dataString(data map[string]interface{}, key string) string {
+	value, _ := data[key].(string)
+	return value
+}
+
+// CACertificates returns the pem chain of the issuer of the role, trusted
+// by the sites linked with this one
+func (v *VaultPki) CACertificates() ([]byte, error) {
+	client, err := v.client()
+	if err != nil {
+		return nil, err
+	}
+	result, err := v.request(client, http.MethodGet, v.pkiPath()+"/cert/ca_chain", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	chain := dataString(result.Data, "certificate")
+	if strings.TrimSpace(chain) == "" {
+		// the root issuers have no chain
+		if result, err = v.request(client, http.MethodGet, v.pkiPath()+"/cert/ca", "", nil); err != nil {
+			return nil, err
+		}
+		chain = dataString(result.Data, "certificate")
+	}
+	if _, err := DecodeCertificate([]byte(chain)); err != nil {
+		return nil, fmt.Errorf("invalid CA certificate returned by vault: %w", err)
+	}
+	return []byte(strings.TrimSpace(chain) + "\n"), nil
+}
+
+// Issue has vault issue a certificate and key for the subject and hosts,
+// the role deciding the names allowed and the key type. The ttl of the role
+// is used when expiration is 0.
+func (v *VaultPki) Issue(subject string, hosts string, expiration time.Duration) (certificate []byte, key []byte, err error) {
+	client, err := v.client()
+	if err != nil {
+		return nil, nil, err
+	}
+	token, err := v.token(client)
+	if err != nil {
+		return nil, nil, err
+	}
+	request := map[string]string{"common_name": subject}
+	dnsNames, ips := []string{}, []string{}
+	for _, h := range strings.Split(hosts, ",") {
+		h = strings.TrimSpace(h)
+		if h == "" || h == subject {
+			continue
+		}
+		if net.ParseIP(h) != nil {
+			ips = append(ips, h)
+		} else {
+			dnsNames = append(dnsNames, h)
+		}
+	}
+	if len(dnsNames) > 0 {
+		request["alt_names"] = strings.Join(dnsNames, ",")
+	}
+	if len(ips) > 0 {
+		request["ip_sans"] = strings.Join(ips, ",")
+	}
+	if expiration > 0 {
+		request["ttl"] = fmt.Sprintf("%ds", int64(expiration.Seconds()))
+	}
+	result, err := v.request(client, http.MethodPost, v.pkiPath()+"/issue/"+v.Role, token, request)
+	if err != nil {
+		return nil, nil, err
+	}
+	certificate = []byte(dataString(result.Data, "certificate"))
+	key = []byte(dataString(result.Data, "private_key"))
+	if len(certificate) == 0 || len(key) == 0 {
+		return nil, nil, fmt.Errorf("no certificate issued by vault for %s", subject)
+	}
+	cert, err := DecodeCertificate(certificate)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := fips.ValidatePublicKey(cert.PublicKey); err != nil {
+		return nil, nil, fmt.Errorf("certificate issued by vault for %s: %w", subject, err)
+	}
+	return append(bytes.TrimSpace(certificate), '\n'), append(bytes.TrimSpace(key), '\n'), nil
+}
+
+// GenerateVaultCASecret creates the secret of a CA whose certificates are
+// issued by vault, it holds the CA chain but no key
+func GenerateVaultCASecret(name string, vault VaultPki) (corev1.Secret, error) {
+	chain, err := vault.CACertificates()
+	if err != nil {
+		return corev1.Secret{}, err
+	}
+	secret := corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Data: map[string][]byte{
+			"tls.crt": chain,
+			"ca.crt":  chain,
+		},
+	}
+	vault.annotate(&secret)
+	return secret, nil
+}
+
+func (v *VaultPki) issueSecret(name string, subject string, hosts string, expiration time.Duration, caData []byte) (corev1.Secret, error) {
+	certificate, key, err := v.Issue(subject, hosts, expiration)
+	if err != nil {
+		return corev1.Secret{}, err
+	}
+	return corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Type: "kubernetes.io/tls",
+		Data: map[string][]byte{
+			"tls.crt": certificate,
+			"tls.key": key,
+			"ca.crt":  caData,
+		},
+	}, nil
+}
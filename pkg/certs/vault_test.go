@@ -0,0 +1,85 @@
+package certs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestVaultIssuedSecrets(t *testing.T) {
+	vaultCA := GenerateCASecret("vault-ca", "vault-ca")
+	issued := GenerateSecret("issued", "skupper-router", "", &vaultCA)
+	requests := []map[string]string{}
+	tokens := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+		switch r.URL.Path {
+		case "/v1/pki_int/cert/ca_chain":
+			response = map[string]interface{}{"data": map[string]string{"certificate": ""}}
+		case "/v1/pki_int/cert/ca":
+			response = map[string]interface{}{"data": map[string]string{"certificate": string(vaultCA.Data["tls.crt"])}}
+		case "/v1/auth/kubernetes/login":
+			body := map[string]string{}
+			assert.Assert(t, json.NewDecoder(r.Body).Decode(&body))
+			if body["role"] != "skupper" || body["jwt"] != "service-account-token" {
+				w.WriteHeader(http.StatusForbidden)
+				response = map[string]interface{}{"errors": []string{"permission denied"}}
+				break
+			}
+			response = map[string]interface{}{"auth": map[string]string{"client_token": "kubernetes-token"}}
+		case "/v1/pki_int/issue/skupper":
+			body := map[string]string{}
+			assert.Assert(t, json.NewDecoder(r.Body).Decode(&body))
+			requests = append(requests, body)
+			tokens = append(tokens, r.Header.Get("X-Vault-Token"))
+			response = map[string]interface{}{"data": map[string]string{
+				"certificate": string(issued.Data["tls.crt"]),
+				"private_key": string(issued.Data["tls.key"]),
+			}}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			response = map[string]interface{}{"errors": []string{}}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.Assert(t, os.WriteFile(tokenFile, []byte("service-account-token\n"), 0600))
+	VaultServiceAccountTokenFile = tokenFile
+	t.Setenv("VAULT_TOKEN", "")
+
+	ca, err := GenerateVaultCASecret("skupper-site-ca", VaultPki{Address: server.URL, PkiPath: "/pki_int/", Role: "skupper", AuthRole: "skupper"})
+	assert.Assert(t, err)
+	assert.DeepEqual(t, ca.Data["tls.crt"], vaultCA.Data["tls.crt"])
+	_, hasKey := ca.Data["tls.key"]
+	assert.Assert(t, !hasKey, "the CA key stays in vault")
+	assert.DeepEqual(t, *VaultPkiFromSecret(&ca), VaultPki{Address: server.URL, PkiPath: "pki_int", Role: "skupper", AuthRole: "skupper"})
+	assert.Assert(t, VaultPkiFromSecret(&vaultCA) == nil)
+
+	server1 := GenerateSecret("skupper-site-server", "skupper-inter-router", "skupper-inter-router.test,10.0.0.1", &ca)
+	assert.DeepEqual(t, server1.Data["tls.crt"], issued.Data["tls.crt"])
+	assert.DeepEqual(t, server1.Data["tls.key"], issued.Data["tls.key"])
+	assert.DeepEqual(t, server1.Data["ca.crt"], vaultCA.Data["tls.crt"])
+	assert.DeepEqual(t, requests[0], map[string]string{"common_name": "skupper-inter-router", "alt_names": "skupper-inter-router.test", "ip_sans": "10.0.0.1"})
+	assert.Equal(t, tokens[0], "kubernetes-token")
+
+	t.Setenv("VAULT_TOKEN", "cli-token")
+	simple := GenerateSimpleSecret("token", &ca)
+	assert.DeepEqual(t, simple.Data["ca.crt"], vaultCA.Data["tls.crt"])
+	GenerateSecretWithExpiration("token", "token", "", 0, &ca)
+	assert.DeepEqual(t, requests[1], map[string]string{"common_name": "token"})
+	assert.Equal(t, tokens[1], "cli-token")
+
+	_, err = GenerateVaultCASecret("skupper-site-ca", VaultPki{Address: server.URL, PkiPath: "missing", Role: "skupper"})
+	assert.ErrorContains(t, err, "vault request missing/cert/ca_chain failed")
+	t.Setenv("VAULT_TOKEN", "")
+	_, _, err = (&VaultPki{Address: server.URL, PkiPath: "pki_int", Role: "skupper", AuthRole: "other"}).Issue("token", "", 0)
+	assert.ErrorContains(t, err, "permission denied")
+	_, _, err = (&VaultPki{Address: server.URL, PkiPath: "pki_int", Role: "skupper"}).Issue("token", "", 0)
+	assert.ErrorContains(t, err, "no vault token")
+}
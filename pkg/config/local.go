@@ -7,6 +7,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/pkg/utils"
@@ -150,6 +151,54 @@ func GetDataHome() string {
 	return path.Join(dataHome, "skupper")
 }
 
+// SiteId identifies the podman site managed when several sites run on the
+// same host, each one with its own podman storage and data directory
+var SiteId string
+
+var siteIdRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,30}[a-z0-9])?$`)
+
+// ValidateSiteId verifies the site id can name the directories and the
+// systemd units of a site
+func ValidateSiteId(siteId string) error {
+	if !siteIdRegex.MatchString(siteId) {
+		return fmt.Errorf("invalid site id %q, expected up to 32 lowercase alphanumeric characters or '-'", siteId)
+	}
+	return nil
+}
+
+// GetSiteDataHome returns the directory holding the files of the current
+// site, the data home itself for the default site
+func GetSiteDataHome() string {
+	if SiteId == "" {
+		return GetDataHome()
+	}
+	return path.Join(GetDataHome(), "sites", SiteId)
+}
+
+// SiteUnitName returns the name of a systemd unit of the current site,
+// prefixed by the site id so the units of the sites do not collide
+func SiteUnitName(name string) string {
+	if SiteId == "" {
+		return name
+	}
+	return SiteId + "-" + name
+}
+
+// ListSiteIds returns the ids of the sites created with a site id
+func ListSiteIds() []string {
+	entries, err := os.ReadDir(path.Join(GetDataHome(), "sites"))
+	if err != nil {
+		return nil
+	}
+	var siteIds []string
+	for _, entry := range entries {
+		if entry.IsDir() && ValidateSiteId(entry.Name()) == nil {
+			siteIds = append(siteIds, entry.Name())
+		}
+	}
+	return siteIds
+}
+
 func GetConfigHome() string {
 	configHome, ok := os.LookupEnv("XDG_CONFIG_HOME")
 	if !ok {
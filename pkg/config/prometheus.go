@@ -3,6 +3,9 @@ package config
 import (
 	"bytes"
 	_ "embed"
+	"fmt"
+	"regexp"
+	"strings"
 	"text/template"
 
 	"golang.org/x/crypto/bcrypt"
@@ -14,6 +17,9 @@ var (
 
 	//go:embed prometheus-web-config.yml.template
 	WebConfigForPrometheus string
+
+	prometheusDuration = regexp.MustCompile(`^([0-9]+(ms|s|m|h|d|w|y))+$`)
+	prometheusSize     = regexp.MustCompile(`^[0-9]+(B|KB|MB|GB|TB|PB|EB)$`)
 )
 
 type PrometheusInfo struct {
@@ -43,6 +49,51 @@ func ScrapeWebConfigForPrometheus(info PrometheusInfo) string {
 	return buf.String()
 }
 
+// PrometheusServerArgs returns the arguments used to start the prometheus
+// server, setting the storage retention only when provided
+func PrometheusServerArgs(retentionTime string, retentionSize string) []string {
+	args := []string{
+		"--config.file=/etc/prometheus/prometheus.yml",
+		"--storage.tsdb.path=/prometheus/",
+		"--web.config.file=/etc/prometheus/web-config.yml",
+		// keeps the flow pairs attached to the collector metrics
+		"--enable-feature=exemplar-storage",
+	}
+	if retentionTime != "" {
+		args = append(args, "--storage.tsdb.retention.time="+retentionTime)
+	}
+	if retentionSize != "" {
+		args = append(args, "--storage.tsdb.retention.size="+retentionSize)
+	}
+	return args
+}
+
+// PrometheusRetentionFromArgs returns the storage retention time and size set
+// by the arguments of a running prometheus server
+func PrometheusRetentionFromArgs(args []string) (string, string) {
+	var retentionTime, retentionSize string
+	for _, arg := range args {
+		if value, ok := strings.CutPrefix(arg, "--storage.tsdb.retention.time="); ok {
+			retentionTime = value
+		} else if value, ok := strings.CutPrefix(arg, "--storage.tsdb.retention.size="); ok {
+			retentionSize = value
+		}
+	}
+	return retentionTime, retentionSize
+}
+
+// ValidatePrometheusRetention verifies the retention time and size use the
+// duration and byte units accepted by prometheus (i.e: 15d, 512MB)
+func ValidatePrometheusRetention(retentionTime string, retentionSize string) error {
+	if retentionTime != "" && !prometheusDuration.MatchString(retentionTime) {
+		return fmt.Errorf("invalid prometheus retention time %q (e.g. 12h, 15d, 2w)", retentionTime)
+	}
+	if retentionSize != "" && !prometheusSize.MatchString(retentionSize) {
+		return fmt.Errorf("invalid prometheus retention size %q (e.g. 512MB, 10GB)", retentionSize)
+	}
+	return nil
+}
+
 func HashPrometheusPassword(password string) ([]byte, error) {
 	return bcrypt.GenerateFromPassword([]byte(password), 14)
 }
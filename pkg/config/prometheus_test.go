@@ -0,0 +1,58 @@
+package config
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestPrometheusServerArgs(t *testing.T) {
+	args := PrometheusServerArgs("", "")
+	assert.Equal(t, len(args), 4)
+	assert.Equal(t, args[3], "--enable-feature=exemplar-storage")
+
+	args = PrometheusServerArgs("15d", "512MB")
+	assert.Equal(t, len(args), 6)
+	assert.Equal(t, args[4], "--storage.tsdb.retention.time=15d")
+	assert.Equal(t, args[5], "--storage.tsdb.retention.size=512MB")
+}
+
+func TestPrometheusRetentionFromArgs(t *testing.T) {
+	retentionTime, retentionSize := PrometheusRetentionFromArgs(PrometheusServerArgs("", ""))
+	assert.Equal(t, retentionTime, "")
+	assert.Equal(t, retentionSize, "")
+
+	retentionTime, retentionSize = PrometheusRetentionFromArgs(PrometheusServerArgs("15d", "512MB"))
+	assert.Equal(t, retentionTime, "15d")
+	assert.Equal(t, retentionSize, "512MB")
+
+	retentionTime, retentionSize = PrometheusRetentionFromArgs(PrometheusServerArgs("", "10GB"))
+	assert.Equal(t, retentionTime, "")
+	assert.Equal(t, retentionSize, "10GB")
+}
+
+func TestValidatePrometheusRetention(t *testing.T) {
+	tests := []struct {
+		name          string
+		retentionTime string
+		retentionSize string
+		expectedError string
+	}{
+		{name: "empty"},
+		{name: "valid", retentionTime: "15d", retentionSize: "10GB"},
+		{name: "compound-time", retentionTime: "1w2d12h"},
+		{name: "invalid-time", retentionTime: "15 days", expectedError: "invalid prometheus retention time"},
+		{name: "missing-time-unit", retentionTime: "15", expectedError: "invalid prometheus retention time"},
+		{name: "invalid-size", retentionSize: "10G", expectedError: "invalid prometheus retention size"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidatePrometheusRetention(test.retentionTime, test.retentionSize)
+			if test.expectedError == "" {
+				assert.Assert(t, err)
+			} else {
+				assert.ErrorContains(t, err, test.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/skupperproject/skupper/pkg/utils"
+)
+
+var (
+	//go:embed systemd_container.template
+	SystemdContainerTemplate string
+)
+
+// systemdContainerMarker heads the unit files of the containers, so they
+// can be told apart from the other units when removed
+const systemdContainerMarker = "# Generated by skupper for the"
+
+// SystemdContainerUnits are the systemd units starting each container of a
+// podman site on boot, the first container (the router) being started
+// before the others. The units are installed at user level, unless running
+// as root, in which case they are system units.
+type SystemdContainerUnits struct {
+	Containers []string
+	System     bool
+	UnitDir    string
+	RuntimeDir string
+	Podman     string
+}
+
+type systemdContainerUnit struct {
+	Marker     string
+	Container  string
+	After      []string
+	RuntimeDir string
+	Podman     string
+	WantedBy   string
+}
+
+func NewSystemdContainerUnits(containers ...string) *SystemdContainerUnits {
+	u := &SystemdContainerUnits{
+		Containers: containers,
+		System:     os.Getuid() == 0,
+		UnitDir:    path.Join(GetConfigHome(), "systemd/user"),
+		RuntimeDir: GetRuntimeDir(),
+		Podman:     "/usr/bin/podman",
+	}
+	if u.System {
+		u.UnitDir = "/etc/systemd/system"
+		u.RuntimeDir = "/run"
+	}
+	if podman, err := exec.LookPath("podman"); err == nil {
+		u.Podman = podman
+	}
+	return u
+}
+
+// UnitName returns the name of the unit of the given container
+func (u *SystemdContainerUnits) UnitName(container string) string {
+	return container + ".service"
+}
+
+// Render returns the unit file of the given container
+func (u *SystemdContainerUnits) Render(container string) (string, error) {
+	unit := systemdContainerUnit{
+		Marker:     systemdContainerMarker,
+		Container:  container,
+		RuntimeDir: u.RuntimeDir,
+		Podman:     u.Podman,
+		WantedBy:   "default.target",
+	}
+	if u.System {
+		unit.WantedBy = "multi-user.target"
+	}
+	if len(u.Containers) > 0 && container != u.Containers[0] {
+		unit.After = []string{u.UnitName(u.Containers[0])}
+	}
+	var buf bytes.Buffer
+	tmpl := template.Must(template.New("skupper-container").Parse(SystemdContainerTemplate))
+	if err := tmpl.Execute(&buf, unit); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (u *SystemdContainerUnits) Create() error {
+	if !u.System && !IsSystemdUserEnabled() {
+		return fmt.Errorf("SystemD is not enabled at user level")
+	}
+	if err := os.MkdirAll(u.UnitDir, 0755); err != nil {
+		return fmt.Errorf("unable to create base directory %s - %q", u.UnitDir, err)
+	}
+
+	var unitNames []string
+	for _, container := range u.Containers {
+		unit, err := u.Render(container)
+		if err != nil {
+			return err
+		}
+		unitName := u.UnitName(container)
+		if err = os.WriteFile(path.Join(u.UnitDir, unitName), []byte(unit), 0644); err != nil {
+			return fmt.Errorf("Unable to write unit file %s: %w", unitName, err)
+		}
+		unitNames = append(unitNames, unitName)
+	}
+
+	if err := u.systemctl("daemon-reload"); err != nil {
+		return fmt.Errorf("Unable to reload the systemd units: %w", err)
+	}
+	if err := u.systemctl(append([]string{"enable", "--now"}, unitNames...)...); err != nil {
+		return fmt.Errorf("Unable to enable the container units: %w", err)
+	}
+	return nil
+}
+
+// InstalledUnits returns the units of the containers found in the unit
+// directory
+func (u *SystemdContainerUnits) InstalledUnits() ([]string, error) {
+	unitFiles, err := filepath.Glob(path.Join(u.UnitDir, "*.service"))
+	if err != nil {
+		return nil, err
+	}
+	var unitNames []string
+	for _, unitFile := range unitFiles {
+		data, err := os.ReadFile(unitFile)
+		if err != nil || !strings.HasPrefix(string(data), systemdContainerMarker) {
+			continue
+		}
+		unitNames = append(unitNames, filepath.Base(unitFile))
+	}
+	return unitNames, nil
+}
+
+// Remove stops and removes the units of the given containers found in the
+// unit directory, or the units of all the containers when none is given
+func (u *SystemdContainerUnits) Remove(containers ...string) error {
+	installed, err := u.InstalledUnits()
+	if err != nil {
+		return err
+	}
+	var unitNames []string
+	for _, unitName := range installed {
+		if len(containers) == 0 || utils.StringSliceContains(containers, strings.TrimSuffix(unitName, ".service")) {
+			unitNames = append(unitNames, unitName)
+		}
+	}
+	if len(unitNames) == 0 {
+		return nil
+	}
+	_ = u.systemctl(append([]string{"disable", "--now"}, unitNames...)...)
+	for _, unitName := range unitNames {
+		_ = os.Remove(path.Join(u.UnitDir, unitName))
+	}
+	_ = u.systemctl("daemon-reload")
+	_ = u.systemctl(append([]string{"reset-failed"}, unitNames...)...)
+	return nil
+}
+
+func (u *SystemdContainerUnits) systemctl(args ...string) error {
+	if !u.System {
+		args = append([]string{"--user"}, args...)
+	}
+	return exec.Command("systemctl", args...).Run()
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSystemdContainerUnitsRender(t *testing.T) {
+	units := &SystemdContainerUnits{
+		Containers: []string{"skupper-router", "skupper-controller-podman"},
+		UnitDir:    "/home/skupper/.config/systemd/user",
+		RuntimeDir: "/run/user/1000",
+		Podman:     "/usr/bin/podman",
+	}
+
+	router, err := units.Render("skupper-router")
+	assert.Assert(t, err)
+	assert.Equal(t, router, `# Generated by skupper for the skupper-router container
+[Unit]
+Description=Skupper skupper-router container
+Wants=network-online.target
+After=network-online.target
+RequiresMountsFor=/run/user/1000/containers
+
+[Service]
+Restart=on-failure
+RestartSec=5
+TimeoutStopSec=70
+ExecStart=/usr/bin/podman start --attach skupper-router
+ExecStop=/usr/bin/podman stop -t 10 skupper-router
+Type=simple
+
+[Install]
+WantedBy=default.target
+`)
+
+	units.System = true
+	controller, err := units.Render("skupper-controller-podman")
+	assert.Assert(t, err)
+	assert.Equal(t, controller, `# Generated by skupper for the skupper-controller-podman container
+[Unit]
+Description=Skupper skupper-controller-podman container
+Wants=network-online.target
+After=network-online.target skupper-router.service
+Requires=skupper-router.service
+RequiresMountsFor=/run/user/1000/containers
+
+[Service]
+Restart=on-failure
+RestartSec=5
+TimeoutStopSec=70
+ExecStart=/usr/bin/podman start --attach skupper-controller-podman
+ExecStop=/usr/bin/podman stop -t 10 skupper-controller-podman
+Type=simple
+
+[Install]
+WantedBy=multi-user.target
+`)
+}
+
+func TestSystemdContainerUnitsRemove(t *testing.T) {
+	units := &SystemdContainerUnits{
+		Containers: []string{"skupper-router", "skupper-controller-podman", "skupper-flow-collector"},
+		UnitDir:    t.TempDir(),
+		RuntimeDir: "/run/user/1000",
+		Podman:     "/usr/bin/podman",
+	}
+	for _, container := range units.Containers {
+		unit, err := units.Render(container)
+		assert.Assert(t, err)
+		assert.Assert(t, os.WriteFile(path.Join(units.UnitDir, units.UnitName(container)), []byte(unit), 0644))
+	}
+	assert.Assert(t, os.WriteFile(path.Join(units.UnitDir, "backend.service"), []byte("[Unit]\n"), 0644))
+
+	installed, err := units.InstalledUnits()
+	assert.Assert(t, err)
+	assert.DeepEqual(t, installed, []string{"skupper-controller-podman.service", "skupper-flow-collector.service", "skupper-router.service"})
+
+	assert.Assert(t, units.Remove("skupper-flow-collector"))
+	installed, err = units.InstalledUnits()
+	assert.Assert(t, err)
+	assert.DeepEqual(t, installed, []string{"skupper-controller-podman.service", "skupper-router.service"})
+
+	assert.Assert(t, units.Remove())
+	installed, err = units.InstalledUnits()
+	assert.Assert(t, err)
+	assert.Assert(t, installed == nil)
+	_, err = os.Stat(path.Join(units.UnitDir, "backend.service"))
+	assert.Assert(t, err)
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var (
+	//go:embed systemd_podman_site.template
+	SystemdPodmanSiteTemplate string
+)
+
+// systemdPodmanSiteMarker heads the unit file of the podman service of a site
+const systemdPodmanSiteMarker = "# Generated by skupper for the podman service of the"
+
+// PodmanSiteService is the podman service of a site identified by a site id.
+// Each site gets its own podman storage, so the containers, volumes and
+// networks of the sites running on the same host do not collide, and its
+// podman service serves the API of that storage through its own socket.
+// The service also starts the containers of the site on boot.
+type PodmanSiteService struct {
+	SiteId     string
+	System     bool
+	UnitDir    string
+	Podman     string
+	Root       string
+	RunRoot    string
+	TmpDir     string
+	SocketFile string
+}
+
+type podmanSiteUnit struct {
+	Marker     string
+	SiteId     string
+	Podman     string
+	GlobalArgs string
+	Endpoint   string
+	WantedBy   string
+}
+
+func NewPodmanSiteService(siteId string) *PodmanSiteService {
+	runDir := path.Join(GetRuntimeDir(), "skupper", siteId)
+	p := &PodmanSiteService{
+		SiteId:     siteId,
+		System:     os.Getuid() == 0,
+		UnitDir:    path.Join(GetConfigHome(), "systemd/user"),
+		Podman:     "/usr/bin/podman",
+		Root:       path.Join(GetDataHome(), "sites", siteId, "storage"),
+		RunRoot:    path.Join(runDir, "storage"),
+		TmpDir:     path.Join(runDir, "tmp"),
+		SocketFile: path.Join(runDir, "podman.sock"),
+	}
+	if p.System {
+		p.UnitDir = systemdSystemUnitDir
+	}
+	if podman, err := exec.LookPath("podman"); err == nil {
+		p.Podman = podman
+	}
+	return p
+}
+
+// Endpoint returns the endpoint of the podman service of the site
+func (p *PodmanSiteService) Endpoint() string {
+	return "unix://" + p.SocketFile
+}
+
+// UnitName returns the name of the unit running the podman service
+func (p *PodmanSiteService) UnitName() string {
+	return "skupper-podman-" + p.SiteId + ".service"
+}
+
+// GlobalArgs returns the podman flags selecting the storage of the site
+func (p *PodmanSiteService) GlobalArgs() []string {
+	return []string{
+		"--root", p.Root,
+		"--runroot", p.RunRoot,
+		"--tmpdir", p.TmpDir,
+		"--network-config-dir", path.Join(p.Root, "networks"),
+	}
+}
+
+// Render returns the unit file of the podman service
+func (p *PodmanSiteService) Render() (string, error) {
+	unit := podmanSiteUnit{
+		Marker:     systemdPodmanSiteMarker,
+		SiteId:     p.SiteId,
+		Podman:     p.Podman,
+		GlobalArgs: strings.Join(p.GlobalArgs(), " "),
+		Endpoint:   p.Endpoint(),
+		WantedBy:   "default.target",
+	}
+	if p.System {
+		unit.WantedBy = "multi-user.target"
+	}
+	var buf bytes.Buffer
+	tmpl := template.Must(template.New("skupper-podman-site").Parse(SystemdPodmanSiteTemplate))
+	if err := tmpl.Execute(&buf, unit); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Create installs and starts the podman service, waiting for its socket
+func (p *PodmanSiteService) Create() error {
+	if !p.System && !IsSystemdUserEnabled() {
+		return fmt.Errorf("SystemD is not enabled at user level, the podman service of the site can be started with: %s %s system service --time=0 %s",
+			p.Podman, strings.Join(p.GlobalArgs(), " "), p.Endpoint())
+	}
+	for _, dir := range []string{p.UnitDir, p.Root, p.RunRoot, p.TmpDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("unable to create directory %s - %q", dir, err)
+		}
+	}
+	unit, err := p.Render()
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(path.Join(p.UnitDir, p.UnitName()), []byte(unit), 0644); err != nil {
+		return fmt.Errorf("Unable to write unit file %s: %w", p.UnitName(), err)
+	}
+	if err = p.systemctl("daemon-reload"); err != nil {
+		return fmt.Errorf("Unable to reload the systemd units: %w", err)
+	}
+	if err = p.systemctl("enable", "--now", p.UnitName()); err != nil {
+		return fmt.Errorf("Unable to enable the podman service of the site: %w", err)
+	}
+	for i := 0; i < 20; i++ {
+		if _, err = os.Stat(p.SocketFile); err == nil {
+			return nil
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return fmt.Errorf("the podman service of the site is not listening on %s", p.SocketFile)
+}
+
+// Remove stops the podman service and removes the storage and the data
+// directory of the site
+func (p *PodmanSiteService) Remove() error {
+	unitFile := path.Join(p.UnitDir, p.UnitName())
+	if data, err := os.ReadFile(unitFile); err == nil && strings.HasPrefix(string(data), systemdPodmanSiteMarker) {
+		_ = p.systemctl("disable", "--now", p.UnitName())
+		_ = os.Remove(unitFile)
+		_ = p.systemctl("daemon-reload")
+		_ = p.systemctl("reset-failed", p.UnitName())
+	}
+	// the storage of rootless podman holds files owned by the subordinate
+	// ids of the user, so it is removed by podman itself
+	reset := exec.Command(p.Podman, append(p.GlobalArgs(), "system", "reset", "--force")...)
+	if out, err := reset.CombinedOutput(); err != nil {
+		return fmt.Errorf("unable to remove the podman storage of the site: %s - %w", strings.TrimSpace(string(out)), err)
+	}
+	_ = os.RemoveAll(path.Dir(p.RunRoot))
+	return os.RemoveAll(path.Dir(p.Root))
+}
+
+func (p *PodmanSiteService) systemctl(args ...string) error {
+	if !p.System {
+		args = append([]string{"--user"}, args...)
+	}
+	return exec.Command("systemctl", args...).Run()
+}
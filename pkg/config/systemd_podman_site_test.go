@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestValidateSiteId(t *testing.T) {
+	for _, siteId := range []string{"a", "lab1", "west-1"} {
+		assert.Assert(t, ValidateSiteId(siteId))
+	}
+	for _, siteId := range []string{"", "Lab1", "lab/1", "-lab", "lab-", "lab.1", "a123456789012345678901234567890123"} {
+		assert.ErrorContains(t, ValidateSiteId(siteId), "invalid site id")
+	}
+}
+
+func TestSiteDataHome(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	defer func() { SiteId = "" }()
+
+	assert.Equal(t, GetSiteDataHome(), path.Join(dataHome, "skupper"))
+	assert.Equal(t, SiteUnitName("skupper-router.service"), "skupper-router.service")
+	assert.Assert(t, ListSiteIds() == nil)
+
+	SiteId = "west"
+	assert.Equal(t, GetSiteDataHome(), path.Join(dataHome, "skupper/sites/west"))
+	assert.Equal(t, SiteUnitName("skupper-router.service"), "west-skupper-router.service")
+	assert.Assert(t, os.MkdirAll(path.Join(GetSiteDataHome(), "storage"), 0755))
+	assert.Assert(t, os.MkdirAll(path.Join(dataHome, "skupper/sites/East"), 0755))
+	assert.DeepEqual(t, ListSiteIds(), []string{"west"})
+}
+
+func TestPodmanSiteServiceRender(t *testing.T) {
+	service := &PodmanSiteService{
+		SiteId:     "west",
+		Podman:     "/usr/bin/podman",
+		Root:       "/home/skupper/.local/share/skupper/sites/west/storage",
+		RunRoot:    "/run/user/1000/skupper/west/storage",
+		TmpDir:     "/run/user/1000/skupper/west/tmp",
+		SocketFile: "/run/user/1000/skupper/west/podman.sock",
+	}
+	assert.Equal(t, service.UnitName(), "skupper-podman-west.service")
+	assert.Equal(t, service.Endpoint(), "unix:///run/user/1000/skupper/west/podman.sock")
+
+	unit, err := service.Render()
+	assert.Assert(t, err)
+	globalArgs := "--root /home/skupper/.local/share/skupper/sites/west/storage --runroot /run/user/1000/skupper/west/storage " +
+		"--tmpdir /run/user/1000/skupper/west/tmp --network-config-dir /home/skupper/.local/share/skupper/sites/west/storage/networks"
+	assert.Equal(t, unit, `# Generated by skupper for the podman service of the west site
+[Unit]
+Description=Skupper west site podman service
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=exec
+ExecStart=/usr/bin/podman `+globalArgs+` system service --time=0 unix:///run/user/1000/skupper/west/podman.sock
+ExecStartPost=-/usr/bin/podman `+globalArgs+` start --all --filter restart-policy=always
+ExecStop=-/usr/bin/podman `+globalArgs+` stop --all -t 10
+TimeoutStopSec=70
+
+[Install]
+WantedBy=default.target
+`)
+}
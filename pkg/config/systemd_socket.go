@@ -0,0 +1,200 @@
+package config
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+var (
+	//go:embed systemd_socket.template
+	SystemdSocketTemplate string
+	//go:embed systemd_socket_proxy.template
+	SystemdSocketProxyTemplate string
+)
+
+// systemdSocketMarker heads the unit files of the sockets, so they can be
+// told apart from the other units when removed
+const systemdSocketMarker = "# Generated by skupper to forward the"
+
+// systemdSystemUnitDir holds the units of the system manager, the only one
+// allowed to bind the privileged ports
+const systemdSystemUnitDir = "/etc/systemd/system"
+
+// SocketForward is a privileged port accepted by a socket unit, its
+// connections being forwarded to the port bound by a rootless container
+type SocketForward struct {
+	Name   string
+	Listen []string
+	Target string
+}
+
+// SystemdSocketUnits are the system units accepting the connections to the
+// privileged ports of a rootless site, each socket activating a
+// systemd-socket-proxyd service that forwards them to the unprivileged
+// port bound by the container. As the units must be installed by root,
+// they are written to the data home when not running as root, along with
+// the commands installing them.
+type SystemdSocketUnits struct {
+	Forwards []SocketForward
+	System   bool
+	UnitDir  string
+	Proxy    string
+}
+
+type systemdSocketUnit struct {
+	SocketForward
+	Marker string
+	Unit   string
+	Proxy  string
+}
+
+func NewSystemdSocketUnits(forwards ...SocketForward) *SystemdSocketUnits {
+	u := &SystemdSocketUnits{
+		Forwards: forwards,
+		System:   os.Getuid() == 0,
+		UnitDir:  path.Join(GetSiteDataHome(), "systemd"),
+		Proxy:    "/usr/lib/systemd/systemd-socket-proxyd",
+	}
+	if u.System {
+		u.UnitDir = systemdSystemUnitDir
+	}
+	for _, proxy := range []string{u.Proxy, "/lib/systemd/systemd-socket-proxyd"} {
+		if _, err := os.Stat(proxy); err == nil {
+			u.Proxy = proxy
+			break
+		}
+	}
+	return u
+}
+
+// UnitName returns the name of the units of the given forward, without
+// their .socket and .service suffixes
+func (u *SystemdSocketUnits) UnitName(forward SocketForward) string {
+	return SiteUnitName("skupper-" + forward.Name)
+}
+
+// Render returns the socket and the service unit files of the given forward
+func (u *SystemdSocketUnits) Render(forward SocketForward) (string, string, error) {
+	unit := systemdSocketUnit{
+		SocketForward: forward,
+		Marker:        systemdSocketMarker,
+		Unit:          u.UnitName(forward),
+		Proxy:         u.Proxy,
+	}
+	var socket, service bytes.Buffer
+	if err := template.Must(template.New("skupper-socket").Parse(SystemdSocketTemplate)).Execute(&socket, unit); err != nil {
+		return "", "", err
+	}
+	if err := template.Must(template.New("skupper-socket-proxy").Parse(SystemdSocketProxyTemplate)).Execute(&service, unit); err != nil {
+		return "", "", err
+	}
+	return socket.String(), service.String(), nil
+}
+
+// Create writes the units and enables the sockets when running as root,
+// otherwise the commands installing them are returned
+func (u *SystemdSocketUnits) Create() ([]string, error) {
+	if _, err := os.Stat(u.Proxy); err != nil {
+		return nil, fmt.Errorf("systemd-socket-proxyd is not available - %w", err)
+	}
+	if err := os.MkdirAll(u.UnitDir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create base directory %s - %q", u.UnitDir, err)
+	}
+
+	var unitFiles, sockets []string
+	for _, forward := range u.Forwards {
+		socket, service, err := u.Render(forward)
+		if err != nil {
+			return nil, err
+		}
+		unitName := u.UnitName(forward)
+		for _, unit := range []struct{ fileName, data string }{
+			{fileName: unitName + ".socket", data: socket},
+			{fileName: unitName + ".service", data: service},
+		} {
+			unitFile := path.Join(u.UnitDir, unit.fileName)
+			if err = os.WriteFile(unitFile, []byte(unit.data), 0644); err != nil {
+				return nil, fmt.Errorf("Unable to write unit file %s: %w", unit.fileName, err)
+			}
+			unitFiles = append(unitFiles, unitFile)
+		}
+		sockets = append(sockets, unitName+".socket")
+	}
+
+	commands := [][]string{
+		{"systemctl", "daemon-reload"},
+		append([]string{"systemctl", "enable", "--now"}, sockets...),
+	}
+	if !u.System {
+		install := append(append([]string{"cp"}, unitFiles...), systemdSystemUnitDir)
+		return sudo(append([][]string{install}, commands...)), nil
+	}
+	for _, command := range commands {
+		if err := systemctl(command[1:]...); err != nil {
+			return nil, fmt.Errorf("Unable to enable the socket units: %w", err)
+		}
+	}
+	return nil, nil
+}
+
+// Remove stops and removes the socket units of the site found in the unit
+// directory when running as root, otherwise the commands removing them are
+// returned
+func (u *SystemdSocketUnits) Remove() []string {
+	socketFiles, err := filepath.Glob(path.Join(u.UnitDir, u.UnitName(SocketForward{Name: "*"})+".socket"))
+	if err != nil {
+		return nil
+	}
+	var units, unitFiles []string
+	for _, socketFile := range socketFiles {
+		data, err := os.ReadFile(socketFile)
+		if err != nil || !strings.HasPrefix(string(data), systemdSocketMarker) {
+			continue
+		}
+		unitName := strings.TrimSuffix(filepath.Base(socketFile), ".socket")
+		units = append(units, unitName+".socket", unitName+".service")
+		unitFiles = append(unitFiles, socketFile, strings.TrimSuffix(socketFile, ".socket")+".service")
+	}
+	if len(units) == 0 {
+		return nil
+	}
+	if !u.System {
+		for _, unitFile := range unitFiles {
+			_ = os.Remove(unitFile)
+		}
+		var systemUnitFiles []string
+		for _, unit := range units {
+			systemUnitFiles = append(systemUnitFiles, path.Join(systemdSystemUnitDir, unit))
+		}
+		return sudo([][]string{
+			append([]string{"systemctl", "disable", "--now"}, units...),
+			append([]string{"rm", "-f"}, systemUnitFiles...),
+			{"systemctl", "daemon-reload"},
+		})
+	}
+	_ = systemctl(append([]string{"disable", "--now"}, units...)...)
+	for _, unitFile := range unitFiles {
+		_ = os.Remove(unitFile)
+	}
+	_ = systemctl("daemon-reload")
+	return nil
+}
+
+func sudo(commands [][]string) []string {
+	var lines []string
+	for _, command := range commands {
+		lines = append(lines, "sudo "+strings.Join(command, " "))
+	}
+	return lines
+}
+
+func systemctl(args ...string) error {
+	return exec.Command("systemctl", args...).Run()
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSystemdSocketUnits(t *testing.T) {
+	unitDir := t.TempDir()
+	proxy := path.Join(t.TempDir(), "systemd-socket-proxyd")
+	assert.Assert(t, os.WriteFile(proxy, nil, 0755))
+	forward := SocketForward{
+		Name:   "inter-router",
+		Listen: []string{"10.0.0.1:443", "[fd00::1]:443"},
+		Target: "10.0.0.1:8443",
+	}
+	units := &SystemdSocketUnits{
+		Forwards: []SocketForward{forward},
+		UnitDir:  unitDir,
+		Proxy:    proxy,
+	}
+
+	socket, service, err := units.Render(forward)
+	assert.Assert(t, err)
+	assert.Equal(t, socket, `# Generated by skupper to forward the inter-router port
+[Unit]
+Description=Skupper inter-router port socket
+
+[Socket]
+ListenStream=10.0.0.1:443
+ListenStream=[fd00::1]:443
+FreeBind=true
+
+[Install]
+WantedBy=sockets.target
+`)
+	assert.Equal(t, service, `# Generated by skupper to forward the inter-router port
+[Unit]
+Description=Skupper inter-router port forwarder
+Requires=skupper-inter-router.socket
+After=skupper-inter-router.socket
+
+[Service]
+Type=notify
+ExecStart=`+proxy+` 10.0.0.1:8443
+DynamicUser=yes
+PrivateTmp=yes
+`)
+
+	// not being root, the commands installing the units are returned
+	commands, err := units.Create()
+	assert.Assert(t, err)
+	assert.DeepEqual(t, commands, []string{
+		"sudo cp " + path.Join(unitDir, "skupper-inter-router.socket") + " " + path.Join(unitDir, "skupper-inter-router.service") + " /etc/systemd/system",
+		"sudo systemctl daemon-reload",
+		"sudo systemctl enable --now skupper-inter-router.socket",
+	})
+	_, err = os.Stat(path.Join(unitDir, "skupper-inter-router.service"))
+	assert.Assert(t, err)
+
+	assert.DeepEqual(t, units.Remove(), []string{
+		"sudo systemctl disable --now skupper-inter-router.socket skupper-inter-router.service",
+		"sudo rm -f /etc/systemd/system/skupper-inter-router.socket /etc/systemd/system/skupper-inter-router.service",
+		"sudo systemctl daemon-reload",
+	})
+	_, err = os.Stat(path.Join(unitDir, "skupper-inter-router.socket"))
+	assert.Assert(t, os.IsNotExist(err))
+	assert.Assert(t, units.Remove() == nil)
+}
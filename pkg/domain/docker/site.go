@@ -0,0 +1,29 @@
+package docker
+
+import (
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/domain"
+	"github.com/skupperproject/skupper/pkg/domain/podman"
+)
+
+// Docker sites are made of the same containers, volumes and network as
+// the podman ones, the site handlers of pkg/domain/podman manage them
+// through the docker engine api.
+
+func NewSiteHandler(endpoint string) (domain.SiteHandler, error) {
+	siteHandler, err := NewSiteDockerHandler(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return siteHandler, nil
+}
+
+func NewSiteDockerHandler(endpoint string) (*podman.SiteHandler, error) {
+	return podman.NewSiteHandlerFor(types.PlatformDocker, endpoint)
+}
+
+// NewDockerConfigFileHandler returns the handler of the local docker
+// configuration, holding the endpoint of the docker daemon
+func NewDockerConfigFileHandler() podman.ConfigFileHandler {
+	return podman.NewConfigFileHandler(types.PlatformDocker)
+}
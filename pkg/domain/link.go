@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"log"
@@ -37,22 +38,32 @@ type EventLogger func(name string, format string, args ...interface{})
 
 type ClaimRedeemer struct {
 	siteId      string
+	siteName    string
+	siteMode    string
 	siteVersion string
 	updateFn    SecretUpdateFn
 	name        string
 	logger      EventLogger
+	redeemed    func(claimName string, url string)
 }
 
-func NewClaimRedeemer(name, siteId, siteVersion string, secretUpdater SecretUpdateFn, event EventLogger) *ClaimRedeemer {
+func NewClaimRedeemer(name, siteId, siteName, siteMode, siteVersion string, secretUpdater SecretUpdateFn, event EventLogger) *ClaimRedeemer {
 	return &ClaimRedeemer{
 		name:        name,
 		siteId:      siteId,
+		siteName:    siteName,
+		siteMode:    siteMode,
 		siteVersion: siteVersion,
 		updateFn:    secretUpdater,
 		logger:      event,
 	}
 }
 
+// OnRedeemed has the function called with each claim redeemed for a token
+func (c *ClaimRedeemer) OnRedeemed(fn func(claimName string, url string)) {
+	c.redeemed = fn
+}
+
 func (c *ClaimRedeemer) handleError(claim *corev1.Secret, text string, failed bool) error {
 	if failed {
 		if claim.ObjectMeta.Annotations == nil {
@@ -107,6 +118,21 @@ func (c *ClaimRedeemer) RedeemClaim(claim *corev1.Secret) error {
 	}
 	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(password))
 	request.Header.Add("skupper-site-name", c.siteId)
+	// reported for the policy of the claim to be enforced
+	if c.siteName != "" {
+		request.Header.Add(types.ClaimSiteNameHeader, c.siteName)
+	}
+	if c.siteMode != "" {
+		request.Header.Add(types.ClaimSiteModeHeader, c.siteMode)
+	}
+	cost := claim.ObjectMeta.Annotations[types.TokenCost]
+	if cost == "" {
+		cost = "1"
+	}
+	request.Header.Add(types.ClaimLinkCostHeader, cost)
+	if reverse, ok := claim.Data[types.ClaimReverseDataKey]; ok {
+		request.Header.Add(types.ClaimReverseHeader, base64.StdEncoding.EncodeToString(reverse))
+	}
 	query := request.URL.Query()
 	query.Add("site-version", c.siteVersion)
 	request.URL.RawQuery = query.Encode()
@@ -125,7 +151,8 @@ func (c *ClaimRedeemer) RedeemClaim(claim *corev1.Secret) error {
 	if response.StatusCode != http.StatusOK {
 		fmt.Printf("Claim request failed with code: %d", response.StatusCode)
 		fmt.Println()
-		return c.handleError(claim, strings.TrimSpace(string(body)), response.StatusCode == http.StatusNotFound)
+		// claims refused by their policy will not be accepted later either
+		return c.handleError(claim, strings.TrimSpace(string(body)), response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusPreconditionFailed)
 	}
 	s := json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme, json.SerializerOptions{Yaml: true})
 	var token corev1.Secret
@@ -145,6 +172,9 @@ func (c *ClaimRedeemer) RedeemClaim(claim *corev1.Secret) error {
 		return fmt.Errorf("Could not store connection token for claim %q: %s", claim.ObjectMeta.Name, err)
 	}
 	c.logger(c.name, "Retrieved token %s from %s", token.ObjectMeta.Name, url)
+	if c.redeemed != nil {
+		c.redeemed(claim.ObjectMeta.Name, url)
+	}
 	return nil
 }
 
@@ -10,6 +10,7 @@ import (
 
 const (
 	SharedTlsCertificates = "skupper-router-certs"
+	CredentialsKeyVolume  = "skupper-credentials-key"
 )
 
 var (
@@ -22,6 +23,7 @@ var (
 		SharedTlsCertificates,
 		types.ConsoleServerSecret,
 		types.ConsoleUsersSecret,
+		CredentialsKeyVolume,
 		types.NetworkStatusConfigMapName,
 		"prometheus-server-config",
 		"prometheus-storage-volume",
@@ -5,15 +5,15 @@ import (
 	"strconv"
 
 	"github.com/skupperproject/skupper/api/types"
-	"github.com/skupperproject/skupper/client/podman"
+	"github.com/skupperproject/skupper/client/container"
 	"github.com/skupperproject/skupper/pkg/domain"
 )
 
 type SkupperComponentHandler struct {
-	cli *podman.PodmanRestClient
+	cli container.EngineClient
 }
 
-func NewSkupperComponentHandlerPodman(cli *podman.PodmanRestClient) *SkupperComponentHandler {
+func NewSkupperComponentHandlerPodman(cli container.EngineClient) *SkupperComponentHandler {
 	return &SkupperComponentHandler{
 		cli: cli,
 	}
@@ -82,6 +82,15 @@ func (s *SkupperComponentHandler) Get(name string) (domain.SkupperComponent, err
 			MemoryLimit:   c.MaxMemoryBytes,
 			Cpus:          c.MaxCpus,
 		}
+	case types.MetricsExporterContainerName:
+		component = &domain.MetricsExporter{
+			Image:         c.Image,
+			Env:           c.Env,
+			Labels:        c.Labels,
+			SiteIngresses: siteIngresses,
+			MemoryLimit:   c.MaxMemoryBytes,
+			Cpus:          c.MaxCpus,
+		}
 	default:
 		return nil, fmt.Errorf("invalid component: %s", componentName)
 	}
@@ -0,0 +1,193 @@
+package podman
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/pkg/utils"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// ComposeFile is a compose file, as read by docker compose and
+// podman-compose, describing the containers of a site
+type ComposeFile struct {
+	Services map[string]ComposeService  `yaml:"services"`
+	Networks map[string]ComposeResource `yaml:"networks,omitempty"`
+	Volumes  map[string]ComposeResource `yaml:"volumes,omitempty"`
+}
+
+type ComposeService struct {
+	Image         string                           `yaml:"image"`
+	ContainerName string                           `yaml:"container_name"`
+	Entrypoint    []string                         `yaml:"entrypoint,omitempty"`
+	Command       []string                         `yaml:"command,omitempty"`
+	Environment   map[string]string                `yaml:"environment,omitempty"`
+	Labels        map[string]string                `yaml:"labels,omitempty"`
+	Networks      map[string]ComposeServiceNetwork `yaml:"networks,omitempty"`
+	Ports         []string                         `yaml:"ports,omitempty"`
+	Volumes       []string                         `yaml:"volumes,omitempty"`
+	Restart       string                           `yaml:"restart,omitempty"`
+	Cpus          float64                          `yaml:"cpus,omitempty"`
+	MemLimit      int64                            `yaml:"mem_limit,omitempty"`
+	Healthcheck   *ComposeHealthcheck              `yaml:"healthcheck,omitempty"`
+	DependsOn     []string                         `yaml:"depends_on,omitempty"`
+}
+
+type ComposeServiceNetwork struct {
+	Aliases []string `yaml:"aliases,omitempty"`
+}
+
+type ComposeHealthcheck struct {
+	Test        []string `yaml:"test"`
+	Interval    string   `yaml:"interval,omitempty"`
+	Timeout     string   `yaml:"timeout,omitempty"`
+	StartPeriod string   `yaml:"start_period,omitempty"`
+	Retries     int      `yaml:"retries,omitempty"`
+}
+
+// ComposeResource is a network or a volume of the compose file
+type ComposeResource struct {
+	Name     string `yaml:"name"`
+	External bool   `yaml:"external"`
+}
+
+// ExportCompose renders the containers of the site as a compose file. The
+// networks and volumes of the site are declared as external ones, as they
+// hold the configuration and the certificates of the site, so the file
+// describes the containers of a site already initialized on the host.
+func (s *SiteHandler) ExportCompose() ([]byte, error) {
+	containers, err := s.skupperContainers()
+	if err != nil {
+		return nil, err
+	}
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("Skupper is not enabled")
+	}
+	compose := NewComposeFile(containers)
+	data, err := yaml.Marshal(compose)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing compose file - %w", err)
+	}
+	return data, nil
+}
+
+// NewComposeFile returns the compose file describing the given inspected
+// containers, leaving out the containers of the kube play pods and the
+// backups left by an interrupted update
+func NewComposeFile(containers []*container.Container) *ComposeFile {
+	compose := &ComposeFile{
+		Services: map[string]ComposeService{},
+		Networks: map[string]ComposeResource{},
+		Volumes:  map[string]ComposeResource{},
+	}
+	for _, c := range containers {
+		if c.Pod != "" {
+			continue
+		}
+		if component, ok := c.Labels[types.ComponentAnnotation]; ok && component != c.Name {
+			continue
+		}
+		compose.Services[c.Name] = composeService(c)
+		for name := range c.Networks {
+			compose.Networks[name] = ComposeResource{Name: name, External: true}
+		}
+		for _, v := range c.Mounts {
+			if v.Name != "" {
+				compose.Volumes[v.Name] = ComposeResource{Name: v.Name, External: true}
+			}
+		}
+	}
+	// the router is started first
+	if _, ok := compose.Services[types.TransportDeploymentName]; ok {
+		for name, service := range compose.Services {
+			if name != types.TransportDeploymentName {
+				service.DependsOn = []string{types.TransportDeploymentName}
+				compose.Services[name] = service
+			}
+		}
+	}
+	return compose
+}
+
+func composeService(c *container.Container) ComposeService {
+	service := ComposeService{
+		Image:         c.Image,
+		ContainerName: c.Name,
+		Entrypoint:    c.EntryPoint,
+		Command:       c.Command,
+		Environment:   c.Env,
+		Labels:        c.Labels,
+		Networks:      map[string]ComposeServiceNetwork{},
+		Restart:       c.RestartPolicy,
+		Cpus:          c.MaxCpus,
+		MemLimit:      c.MaxMemoryBytes,
+	}
+	for name, network := range c.Networks {
+		var aliases []string
+		for _, alias := range network.Aliases {
+			// the aliases podman adds on its own
+			if alias == c.Name || (c.ID != "" && strings.HasPrefix(c.ID, alias)) {
+				continue
+			}
+			aliases = append(aliases, alias)
+		}
+		service.Networks[name] = ComposeServiceNetwork{Aliases: aliases}
+	}
+	for _, port := range c.Ports {
+		p := port.Host + ":" + port.Target
+		if port.HostIP != "" {
+			p = port.HostIP + ":" + p
+		}
+		if port.Protocol != "" && port.Protocol != "tcp" {
+			p = p + "/" + port.Protocol
+		}
+		service.Ports = append(service.Ports, p)
+	}
+	sort.Strings(service.Ports)
+	for _, v := range c.Mounts {
+		source := utils.DefaultStr(v.Name, v.Source)
+		service.Volumes = append(service.Volumes, composeVolume(source, v.Destination, !v.RW, strings.Split(v.Mode, ",")))
+	}
+	for _, m := range c.FileMounts {
+		readOnly := utils.StringSliceContains(m.Options, "ro")
+		service.Volumes = append(service.Volumes, composeVolume(m.Source, m.Destination, readOnly, m.Options))
+	}
+	if hc := c.Healthcheck; hc != nil && len(hc.Command) > 0 {
+		service.Healthcheck = &ComposeHealthcheck{
+			Test:    append([]string{"CMD"}, hc.Command...),
+			Retries: hc.Retries,
+		}
+		if hc.Interval > 0 {
+			service.Healthcheck.Interval = hc.Interval.String()
+		}
+		if hc.Timeout > 0 {
+			service.Healthcheck.Timeout = hc.Timeout.String()
+		}
+		if hc.StartPeriod > 0 {
+			service.Healthcheck.StartPeriod = hc.StartPeriod.String()
+		}
+	}
+	return service
+}
+
+// composeVolume returns the short syntax of a volume of a service, keeping
+// only the options compose understands
+func composeVolume(source, destination string, readOnly bool, options []string) string {
+	volume := source + ":" + destination
+	var opts []string
+	if readOnly {
+		opts = append(opts, "ro")
+	}
+	for _, opt := range options {
+		if opt == "z" || opt == "Z" {
+			opts = append(opts, opt)
+		}
+	}
+	if len(opts) > 0 {
+		volume = volume + ":" + strings.Join(opts, ",")
+	}
+	return volume
+}
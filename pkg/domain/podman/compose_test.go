@@ -0,0 +1,89 @@
+package podman
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	"gotest.tools/assert"
+)
+
+func TestNewComposeFile(t *testing.T) {
+	router := &container.Container{
+		ID:    "3f1a2b4c5d6e7f80",
+		Name:  types.TransportDeploymentName,
+		Image: "quay.io/skupper/skupper-router:main",
+		Env:   map[string]string{"QDROUTERD_CONF": "/etc/skupper-router/config/skrouterd.json"},
+		Labels: map[string]string{
+			"application":             types.AppName,
+			types.ComponentAnnotation: types.TransportDeploymentName,
+		},
+		Networks: map[string]container.ContainerNetworkInfo{
+			"skupper": {Aliases: []string{types.TransportDeploymentName, "3f1a2b4c5d6e", types.TransportServiceName}},
+		},
+		Mounts: []container.Volume{
+			{Name: types.TransportConfigMapName, Destination: "/etc/skupper-router/config", RW: true, Mode: "z"},
+		},
+		FileMounts: []container.FileMount{
+			{Source: "/home/skupper/ca.crt", Destination: "/etc/ca.crt", Options: []string{"rbind", "ro"}},
+		},
+		Ports: []container.Port{
+			{Host: "55671", Target: "55671", Protocol: "tcp"},
+			{Host: "45671", HostIP: "10.0.0.1", Target: "45671", Protocol: "tcp"},
+		},
+		RestartPolicy: RestartAlways,
+		Healthcheck: &container.Healthcheck{
+			Command:  []string{"curl", "--fail", "http://localhost:9090/healthz"},
+			Interval: 10 * time.Second,
+			Retries:  3,
+		},
+	}
+	controller := &container.Container{
+		Name:  types.ControllerPodmanContainerName,
+		Image: "quay.io/skupper/controller-podman:main",
+		Labels: map[string]string{
+			"application":             types.AppName,
+			types.ComponentAnnotation: types.ControllerPodmanContainerName,
+		},
+		Networks: map[string]container.ContainerNetworkInfo{"skupper": {}},
+		Mounts: []container.Volume{
+			{Name: types.TransportConfigMapName, Destination: "/etc/skupper-router/config", RW: false},
+		},
+		RestartPolicy: RestartAlways,
+	}
+	// the backup of the router left by an interrupted update
+	backup := &container.Container{
+		Name:   types.TransportDeploymentName + "-1700000000",
+		Labels: router.Labels,
+	}
+	// a router played in a pod
+	pod := &container.Container{Name: "skupper-router-pod", Pod: "skupper", Labels: map[string]string{}}
+
+	compose := NewComposeFile([]*container.Container{router, controller, backup, pod})
+	assert.Equal(t, len(compose.Services), 2)
+	assert.DeepEqual(t, compose.Networks, map[string]ComposeResource{"skupper": {Name: "skupper", External: true}})
+	assert.DeepEqual(t, compose.Volumes, map[string]ComposeResource{
+		types.TransportConfigMapName: {Name: types.TransportConfigMapName, External: true},
+	})
+
+	service := compose.Services[types.TransportDeploymentName]
+	assert.Equal(t, service.ContainerName, types.TransportDeploymentName)
+	assert.Equal(t, service.Restart, RestartAlways)
+	assert.DeepEqual(t, service.Networks, map[string]ComposeServiceNetwork{"skupper": {Aliases: []string{types.TransportServiceName}}})
+	assert.DeepEqual(t, service.Ports, []string{"10.0.0.1:45671:45671", "55671:55671"})
+	assert.DeepEqual(t, service.Volumes, []string{
+		types.TransportConfigMapName + ":/etc/skupper-router/config:z",
+		"/home/skupper/ca.crt:/etc/ca.crt:ro",
+	})
+	assert.DeepEqual(t, service.Healthcheck, &ComposeHealthcheck{
+		Test:     []string{"CMD", "curl", "--fail", "http://localhost:9090/healthz"},
+		Interval: "10s",
+		Retries:  3,
+	})
+	assert.Assert(t, service.DependsOn == nil)
+
+	service = compose.Services[types.ControllerPodmanContainerName]
+	assert.DeepEqual(t, service.Volumes, []string{types.TransportConfigMapName + ":/etc/skupper-router/config:ro"})
+	assert.DeepEqual(t, service.DependsOn, []string{types.TransportDeploymentName})
+}
@@ -0,0 +1,163 @@
+package podman
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/skupperproject/skupper/client/podman"
+	"github.com/skupperproject/skupper/pkg/config"
+)
+
+var (
+	ContextsFile = path.Join(config.GetDataHome(), "podman-contexts.yaml")
+	// RemoteEndpoint is set from the --host flag and takes precedence over
+	// the endpoint saved on init
+	RemoteEndpoint string
+)
+
+// Context names a podman host, so it can be used with --host NAME
+type Context struct {
+	Name     string `yaml:"name"`
+	Host     string `yaml:"host"`
+	Identity string `yaml:"identity,omitempty"`
+	TLSCA    string `yaml:"tlsCa,omitempty"`
+	TLSCert  string `yaml:"tlsCert,omitempty"`
+	TLSKey   string `yaml:"tlsKey,omitempty"`
+}
+
+// Endpoint returns the host with the identity file of ssh hosts or the
+// certificates of tcp hosts, if any
+func (c Context) Endpoint() string {
+	if !podman.IsSSHEndpoint(c.Host) {
+		return podman.WithTLSFiles(c.Host, map[string]string{
+			podman.TLSCAParam:   c.TLSCA,
+			podman.TLSCertParam: c.TLSCert,
+			podman.TLSKeyParam:  c.TLSKey,
+		})
+	}
+	if c.Identity == "" {
+		return c.Host
+	}
+	u, err := url.Parse(c.Host)
+	if err != nil {
+		return c.Host
+	}
+	q := u.Query()
+	q.Set("identity", c.Identity)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+type Contexts struct {
+	Current  string    `yaml:"current,omitempty"`
+	Contexts []Context `yaml:"contexts"`
+}
+
+func (c *Contexts) Get(name string) (Context, bool) {
+	for _, ctx := range c.Contexts {
+		if ctx.Name == name {
+			return ctx, true
+		}
+	}
+	return Context{}, false
+}
+
+func (c *Contexts) Add(ctx Context) error {
+	if ctx.Name == "" || strings.Contains(ctx.Name, "/") || strings.Contains(ctx.Name, ":") {
+		return fmt.Errorf("invalid context name %q", ctx.Name)
+	}
+	if err := ValidateHost(ctx.Endpoint()); err != nil {
+		return err
+	}
+	for i, existing := range c.Contexts {
+		if existing.Name == ctx.Name {
+			c.Contexts[i] = ctx
+			return nil
+		}
+	}
+	c.Contexts = append(c.Contexts, ctx)
+	return nil
+}
+
+func (c *Contexts) Remove(name string) error {
+	for i, ctx := range c.Contexts {
+		if ctx.Name == name {
+			c.Contexts = append(c.Contexts[:i], c.Contexts[i+1:]...)
+			if c.Current == name {
+				c.Current = ""
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("context %s not found", name)
+}
+
+// Resolve returns the endpoint of a host, which is either an endpoint
+// or the name of a context, the current context being used when the host
+// is empty
+func (c *Contexts) Resolve(host string) (string, error) {
+	if host == "" {
+		if c.Current == "" {
+			return "", nil
+		}
+		host = c.Current
+	}
+	if strings.Contains(host, "://") || strings.HasPrefix(host, "/") {
+		return host, ValidateHost(host)
+	}
+	ctx, ok := c.Get(host)
+	if !ok {
+		return "", fmt.Errorf("context %s not found", host)
+	}
+	return ctx.Endpoint(), nil
+}
+
+// ValidateHost verifies the endpoint of a podman host
+func ValidateHost(host string) error {
+	if podman.IsSSHEndpoint(host) {
+		_, err := podman.ParseSSHEndpoint(host)
+		return err
+	}
+	if strings.HasPrefix(host, "/") {
+		return nil
+	}
+	if podman.IsTLSEndpoint(host) {
+		_, _, err := podman.ParseTLSEndpoint(host)
+		return err
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "unix", "tcp", "http", "https":
+		return nil
+	}
+	return fmt.Errorf("invalid host %q, expected ssh://, unix://, tcp://, http:// or https://", host)
+}
+
+type contextsFileHandler struct {
+	config *config.ConfigFileHandlerCommon
+}
+
+func (p *contextsFileHandler) GetContexts() (*Contexts, error) {
+	err := p.config.Load()
+	if err != nil {
+		return nil, err
+	}
+	return p.config.GetData().(*Contexts), nil
+}
+
+func (p *contextsFileHandler) Save(contexts *Contexts) error {
+	p.config.SetData(contexts)
+	return p.config.Save()
+}
+
+func NewPodmanContextsFileHandler() *contextsFileHandler {
+	c := &config.ConfigFileHandlerCommon{}
+	c.SetFileName(ContextsFile)
+	c.SetData(&Contexts{})
+	return &contextsFileHandler{config: c}
+}
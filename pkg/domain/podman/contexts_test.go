@@ -0,0 +1,49 @@
+package podman
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestContextsResolve(t *testing.T) {
+	contexts := &Contexts{}
+	assert.Assert(t, contexts.Add(Context{Name: "edge", Host: "ssh://skupper@edge.example.com/run/user/1000/podman/podman.sock", Identity: "/keys/edge"}))
+	assert.Assert(t, contexts.Add(Context{Name: "lab", Host: "tcp://lab.example.com:8888"}))
+	assert.ErrorContains(t, contexts.Add(Context{Name: "bad", Host: "ssh://edge.example.com"}), "podman socket is required")
+	assert.ErrorContains(t, contexts.Add(Context{Name: "bad", Host: "ftp://edge.example.com"}), "invalid host")
+	assert.ErrorContains(t, contexts.Add(Context{Name: "a/b", Host: "tcp://lab.example.com:8888"}), "invalid context name")
+
+	testTable := []struct {
+		doc      string
+		current  string
+		host     string
+		expected string
+		err      string
+	}{
+		{doc: "local"},
+		{doc: "current", current: "lab", expected: "tcp://lab.example.com:8888"},
+		{doc: "name", current: "lab", host: "edge", expected: "ssh://skupper@edge.example.com/run/user/1000/podman/podman.sock?identity=%2Fkeys%2Fedge"},
+		{doc: "endpoint", current: "lab", host: "unix:///run/podman/podman.sock", expected: "unix:///run/podman/podman.sock"},
+		{doc: "socket file", host: "/run/podman/podman.sock", expected: "/run/podman/podman.sock"},
+		{doc: "unknown", host: "gone", err: "context gone not found"},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			contexts.Current = test.current
+			endpoint, err := contexts.Resolve(test.host)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			assert.Equal(t, endpoint, test.expected)
+		})
+	}
+
+	contexts.Current = "edge"
+	assert.Assert(t, contexts.Remove("edge"))
+	assert.Equal(t, contexts.Current, "")
+	assert.Equal(t, len(contexts.Contexts), 1)
+	assert.ErrorContains(t, contexts.Remove("edge"), "not found")
+}
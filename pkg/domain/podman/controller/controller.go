@@ -7,8 +7,10 @@ import (
 	"time"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
 	clientpodman "github.com/skupperproject/skupper/client/podman"
 	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/config"
 	"github.com/skupperproject/skupper/pkg/domain/podman"
 	"github.com/skupperproject/skupper/pkg/flow"
 	"github.com/skupperproject/skupper/pkg/qdr"
@@ -18,7 +20,7 @@ import (
 
 // ControllerPodman defines the podman site implementation of the controller.
 type ControllerPodman struct {
-	cli               *clientpodman.PodmanRestClient
+	cli               container.EngineClient
 	cfg               *podman.Config
 	tlsConfig         *certs.TlsConfigRetriever
 	origin            string
@@ -28,13 +30,14 @@ type ControllerPodman struct {
 }
 
 func NewControllerPodman(origin string, tlsConfig *certs.TlsConfigRetriever) (*ControllerPodman, error) {
-	cfg, err := podman.NewPodmanConfigFileHandler().GetConfig()
+	platform := config.GetPlatform()
+	cfg, err := podman.NewConfigFileHandler(platform).GetConfig()
 	if err != nil {
-		return nil, fmt.Errorf("error reading podman site config - %s", err)
+		return nil, fmt.Errorf("error reading %s site config - %s", platform, err)
 	}
-	podmanCli, err := clientpodman.NewPodmanClient(cfg.Endpoint, "")
+	podmanCli, err := podman.NewEngineClient(platform, cfg.Endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("error creating podman client - %s", err)
+		return nil, fmt.Errorf("error creating %s client - %s", platform, err)
 	}
 	c := &ControllerPodman{
 		cli:       podmanCli,
@@ -64,9 +67,9 @@ func (c *ControllerPodman) Run(stopCh <-chan struct{}) error {
 		log.Fatalf("unable to determine if %s container is running - %s", types.TransportDeploymentName, err)
 	}
 
-	siteHandler, err := podman.NewSitePodmanHandler(c.cfg.Endpoint)
+	siteHandler, err := podman.NewSiteHandlerFor(c.cli.Platform(), c.cfg.Endpoint)
 	if err != nil {
-		log.Fatalf("unable to communicate with podman - %s", err)
+		log.Fatalf("unable to communicate with %s - %s", c.cli.Platform(), err)
 	}
 
 	site, err := siteHandler.Get()
@@ -102,6 +105,13 @@ func (c *ControllerPodman) Run(stopCh <-chan struct{}) error {
 	// ProcessRecord container informer
 	c.containerInformer = clientpodman.NewContainerInformer(c.cli)
 	c.containerInformer.AddInformer(NewContainerProcessInformer(c.cli, c.origin, c.site, flowController))
+
+	// Reconciles the containers of the site components
+	if !c.site.EnableKubePlay {
+		reconciler := NewContainerReconciler(c.cli)
+		c.containerInformer.AddInformer(reconciler)
+		reconciler.Start(stopCh)
+	}
 	c.containerInformer.Start(stopCh)
 
 	// ProcessRecord watcher for service targets (using IP addresses)
@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/qdr"
+)
+
+// routerManagement is the management data of the local router that is
+// exported as metrics
+type routerManagement interface {
+	GetConnections() ([]qdr.Connection, error)
+	GetLocalTcpConnections() ([]qdr.TcpConnection, error)
+	GetLocalHttpRequestInfo() ([]qdr.HttpRequestInfo, error)
+}
+
+// RouterMetricsCollector queries the local router on every scrape
+type RouterMetricsCollector struct {
+	management func() (routerManagement, func(), error)
+
+	up               *prometheus.Desc
+	connections      *prometheus.Desc
+	tcpConnections   *prometheus.Desc
+	tcpBytes         *prometheus.Desc
+	httpRequests     *prometheus.Desc
+	httpBytes        *prometheus.Desc
+	httpMaxLatency   *prometheus.Desc
+	httpResponseCode *prometheus.Desc
+}
+
+func newRouterMetricsCollector(management func() (routerManagement, func(), error)) *RouterMetricsCollector {
+	return &RouterMetricsCollector{
+		management: management,
+		up: prometheus.NewDesc("skupper_router_up",
+			"Whether the management data of the local router could be read", nil, nil),
+		connections: prometheus.NewDesc("skupper_router_connections",
+			"Open router connections", []string{"role", "dir"}, nil),
+		tcpConnections: prometheus.NewDesc("skupper_router_tcp_connections",
+			"Open tcp connections", []string{"address", "direction"}, nil),
+		tcpBytes: prometheus.NewDesc("skupper_router_tcp_connection_bytes",
+			"Bytes transferred by the open tcp connections", []string{"address", "direction", "flow"}, nil),
+		httpRequests: prometheus.NewDesc("skupper_router_http_requests_total",
+			"Http requests handled", []string{"address", "direction"}, nil),
+		httpBytes: prometheus.NewDesc("skupper_router_http_bytes_total",
+			"Bytes transferred by the http requests", []string{"address", "direction", "flow"}, nil),
+		httpMaxLatency: prometheus.NewDesc("skupper_router_http_max_latency_milliseconds",
+			"Highest http request latency", []string{"address", "direction"}, nil),
+		httpResponseCode: prometheus.NewDesc("skupper_router_http_responses_total",
+			"Http responses by status code", []string{"address", "direction", "code"}, nil),
+	}
+}
+
+func (c *RouterMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.up
+	ch <- c.connections
+	ch <- c.tcpConnections
+	ch <- c.tcpBytes
+	ch <- c.httpRequests
+	ch <- c.httpBytes
+	ch <- c.httpMaxLatency
+	ch <- c.httpResponseCode
+}
+
+func (c *RouterMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	if err := c.collect(ch); err != nil {
+		log.Printf("Unable to read router management data - %s", err)
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 0)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 1)
+}
+
+type addressDirection struct {
+	address   string
+	direction string
+}
+
+func (c *RouterMetricsCollector) collect(ch chan<- prometheus.Metric) error {
+	management, done, err := c.management()
+	if err != nil {
+		return err
+	}
+	defer done()
+	connections, err := management.GetConnections()
+	if err != nil {
+		return err
+	}
+	tcpConnections, err := management.GetLocalTcpConnections()
+	if err != nil {
+		return err
+	}
+	httpRequests, err := management.GetLocalHttpRequestInfo()
+	if err != nil {
+		return err
+	}
+
+	byRole := map[[2]string]int{}
+	for _, conn := range connections {
+		byRole[[2]string{conn.Role, conn.Dir}]++
+	}
+	for key, count := range byRole {
+		ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(count), key[0], key[1])
+	}
+
+	tcpCount := map[addressDirection]int{}
+	tcpBytesIn := map[addressDirection]int{}
+	tcpBytesOut := map[addressDirection]int{}
+	for _, conn := range tcpConnections {
+		key := addressDirection{conn.Address, conn.Direction}
+		tcpCount[key]++
+		tcpBytesIn[key] += conn.BytesIn
+		tcpBytesOut[key] += conn.BytesOut
+	}
+	for key, count := range tcpCount {
+		ch <- prometheus.MustNewConstMetric(c.tcpConnections, prometheus.GaugeValue, float64(count), key.address, key.direction)
+		ch <- prometheus.MustNewConstMetric(c.tcpBytes, prometheus.GaugeValue, float64(tcpBytesIn[key]), key.address, key.direction, "in")
+		ch <- prometheus.MustNewConstMetric(c.tcpBytes, prometheus.GaugeValue, float64(tcpBytesOut[key]), key.address, key.direction, "out")
+	}
+
+	// the router keeps one record per address, direction and peer site
+	httpCount := map[addressDirection]int{}
+	httpBytesIn := map[addressDirection]int{}
+	httpBytesOut := map[addressDirection]int{}
+	httpLatency := map[addressDirection]int{}
+	httpCodes := map[addressDirection]map[string]int{}
+	for _, req := range httpRequests {
+		key := addressDirection{req.Address, req.Direction}
+		httpCount[key] += req.Requests
+		httpBytesIn[key] += req.BytesIn
+		httpBytesOut[key] += req.BytesOut
+		if req.MaxLatency > httpLatency[key] {
+			httpLatency[key] = req.MaxLatency
+		}
+		if httpCodes[key] == nil {
+			httpCodes[key] = map[string]int{}
+		}
+		for code, count := range req.Details {
+			httpCodes[key][code] += count
+		}
+	}
+	for key, count := range httpCount {
+		ch <- prometheus.MustNewConstMetric(c.httpRequests, prometheus.CounterValue, float64(count), key.address, key.direction)
+		ch <- prometheus.MustNewConstMetric(c.httpBytes, prometheus.CounterValue, float64(httpBytesIn[key]), key.address, key.direction, "in")
+		ch <- prometheus.MustNewConstMetric(c.httpBytes, prometheus.CounterValue, float64(httpBytesOut[key]), key.address, key.direction, "out")
+		ch <- prometheus.MustNewConstMetric(c.httpMaxLatency, prometheus.GaugeValue, float64(httpLatency[key]), key.address, key.direction)
+		for code, count := range httpCodes[key] {
+			ch <- prometheus.MustNewConstMetric(c.httpResponseCode, prometheus.CounterValue, float64(count), key.address, key.direction, code)
+		}
+	}
+	return nil
+}
+
+// MetricsExporter serves the metrics of the local router to an external
+// prometheus server
+type MetricsExporter struct {
+	address   string
+	collector *RouterMetricsCollector
+}
+
+func NewMetricsExporter(address string, tlsConfig *certs.TlsConfigRetriever) *MetricsExporter {
+	pool := qdr.NewAgentPool("amqps://"+types.LocalTransportServiceName+":5671", tlsConfig)
+	management := func() (routerManagement, func(), error) {
+		agent, err := pool.Get()
+		if err != nil {
+			return nil, nil, err
+		}
+		return agent, func() { pool.Put(agent) }, nil
+	}
+	return &MetricsExporter{
+		address:   address,
+		collector: newRouterMetricsCollector(management),
+	}
+}
+
+func (e *MetricsExporter) Run(stopCh <-chan struct{}) error {
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(e.collector); err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	server := &http.Server{
+		Addr:              e.address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-stopCh
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+	log.Printf("Serving router metrics on %s", e.address)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
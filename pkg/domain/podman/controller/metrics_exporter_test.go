@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/skupperproject/skupper/pkg/qdr"
+	"gotest.tools/assert"
+)
+
+type fakeRouterManagement struct {
+	connections    []qdr.Connection
+	tcpConnections []qdr.TcpConnection
+	httpRequests   []qdr.HttpRequestInfo
+	err            error
+}
+
+func (m *fakeRouterManagement) GetConnections() ([]qdr.Connection, error) {
+	return m.connections, m.err
+}
+
+func (m *fakeRouterManagement) GetLocalTcpConnections() ([]qdr.TcpConnection, error) {
+	return m.tcpConnections, m.err
+}
+
+func (m *fakeRouterManagement) GetLocalHttpRequestInfo() ([]qdr.HttpRequestInfo, error) {
+	return m.httpRequests, m.err
+}
+
+func TestRouterMetricsCollector(t *testing.T) {
+	management := &fakeRouterManagement{
+		connections: []qdr.Connection{
+			{Role: "inter-router", Dir: "in"},
+			{Role: "inter-router", Dir: "in"},
+			{Role: "normal", Dir: "out"},
+		},
+		tcpConnections: []qdr.TcpConnection{
+			{Address: "backend:8080", Direction: qdr.DirectionIn, BytesIn: 10, BytesOut: 100},
+			{Address: "backend:8080", Direction: qdr.DirectionIn, BytesIn: 5, BytesOut: 50},
+		},
+		httpRequests: []qdr.HttpRequestInfo{
+			{Address: "web:80", Direction: qdr.DirectionOut, Requests: 3, MaxLatency: 12, Details: map[string]int{"GET:200": 3}},
+			{Address: "web:80", Direction: qdr.DirectionOut, Requests: 2, MaxLatency: 30, Details: map[string]int{"GET:200": 1, "GET:500": 1}},
+		},
+	}
+	collector := newRouterMetricsCollector(func() (routerManagement, func(), error) {
+		return management, func() {}, nil
+	})
+
+	expected := `
+# HELP skupper_router_connections Open router connections
+# TYPE skupper_router_connections gauge
+skupper_router_connections{dir="in",role="inter-router"} 2
+skupper_router_connections{dir="out",role="normal"} 1
+# HELP skupper_router_http_max_latency_milliseconds Highest http request latency
+# TYPE skupper_router_http_max_latency_milliseconds gauge
+skupper_router_http_max_latency_milliseconds{address="web:80",direction="out"} 30
+# HELP skupper_router_http_requests_total Http requests handled
+# TYPE skupper_router_http_requests_total counter
+skupper_router_http_requests_total{address="web:80",direction="out"} 5
+# HELP skupper_router_http_responses_total Http responses by status code
+# TYPE skupper_router_http_responses_total counter
+skupper_router_http_responses_total{address="web:80",code="GET:200",direction="out"} 4
+skupper_router_http_responses_total{address="web:80",code="GET:500",direction="out"} 1
+# HELP skupper_router_tcp_connection_bytes Bytes transferred by the open tcp connections
+# TYPE skupper_router_tcp_connection_bytes gauge
+skupper_router_tcp_connection_bytes{address="backend:8080",direction="in",flow="in"} 15
+skupper_router_tcp_connection_bytes{address="backend:8080",direction="in",flow="out"} 150
+# HELP skupper_router_tcp_connections Open tcp connections
+# TYPE skupper_router_tcp_connections gauge
+skupper_router_tcp_connections{address="backend:8080",direction="in"} 2
+# HELP skupper_router_up Whether the management data of the local router could be read
+# TYPE skupper_router_up gauge
+skupper_router_up 1
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"skupper_router_connections", "skupper_router_http_max_latency_milliseconds", "skupper_router_http_requests_total",
+		"skupper_router_http_responses_total", "skupper_router_tcp_connection_bytes", "skupper_router_tcp_connections", "skupper_router_up")
+	assert.Assert(t, err)
+
+	management.err = fmt.Errorf("connection refused")
+	assert.Equal(t, testutil.CollectAndCount(collector), 1)
+	assert.Equal(t, testutil.ToFloat64(collector), float64(0))
+}
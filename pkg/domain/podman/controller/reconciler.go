@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/pkg/domain/podman"
+	"github.com/skupperproject/skupper/pkg/utils"
+)
+
+const reconcileResyncPeriod = 10 * time.Second
+
+// reconciledComponents are the components whose containers are recreated,
+// the controller running the reconciler not being one of them
+var reconciledComponents = []string{
+	types.TransportDeploymentName,
+	types.FlowCollectorContainerName,
+	types.PrometheusDeploymentName,
+	types.MetricsExporterContainerName,
+}
+
+// ContainerReconciler brings the containers of the site components back,
+// as the kubernetes controllers do with the pods of a deployment. The
+// containers removed are recreated from their last inspected definition,
+// and the containers exited with a failure are started, unless the
+// container engine restarts them through their restart policy.
+//
+// The reconciler is notified of the container changes by the container
+// informer and verifies the components on every resync. A missing
+// container is only recreated when still missing on the next check, as
+// the containers are briefly missing while their images are updated.
+type ContainerReconciler struct {
+	cli          container.EngineClient
+	resyncPeriod time.Duration
+	mutex        sync.Mutex
+	components   map[string]*container.Container
+	missing      map[string]bool
+	events       chan struct{}
+}
+
+func NewContainerReconciler(cli container.EngineClient) *ContainerReconciler {
+	return &ContainerReconciler{
+		cli:          cli,
+		resyncPeriod: reconcileResyncPeriod,
+		components:   map[string]*container.Container{},
+		missing:      map[string]bool{},
+		events:       make(chan struct{}, 1),
+	}
+}
+
+func (r *ContainerReconciler) Start(stopCh <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(r.resyncPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+			case <-r.events:
+			}
+			r.reconcile()
+		}
+	}()
+}
+
+// isComponent tells whether the container is the one of a reconciled
+// component, the containers of the kube play pods being left to podman
+func isComponent(c *container.Container) bool {
+	component := c.Labels[types.ComponentAnnotation]
+	return c.Name == component && c.Pod == "" && utils.StringSliceContains(reconciledComponents, component)
+}
+
+func (r *ContainerReconciler) OnAdd(obj *container.Container) {
+	r.track(obj)
+}
+
+func (r *ContainerReconciler) OnUpdate(oldObj, newObj *container.Container) {
+	r.track(newObj)
+}
+
+func (r *ContainerReconciler) OnDelete(obj *container.Container) {
+	if !isComponent(obj) {
+		return
+	}
+	select {
+	case r.events <- struct{}{}:
+	default:
+	}
+}
+
+func (r *ContainerReconciler) track(c *container.Container) {
+	if !isComponent(c) {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.components[c.Name] = c
+}
+
+func (r *ContainerReconciler) reconcile() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for name, component := range r.components {
+		c, err := r.cli.ContainerInspect(name)
+		if err != nil {
+			if !r.missing[name] {
+				r.missing[name] = true
+				continue
+			}
+			// the site is being removed
+			if _, err = r.cli.VolumeInspect(types.TransportConfigMapName); err != nil {
+				return
+			}
+			log.Printf("Recreating the %s container, as it has been removed", name)
+			if err = container.RecreateContainer(r.cli, component); err != nil {
+				log.Printf("unable to recreate the %s container - %s", name, err)
+				continue
+			}
+			delete(r.missing, name)
+			continue
+		}
+		delete(r.missing, name)
+		if exitedUnexpectedly(c) {
+			log.Printf("Starting the %s container, as it has exited with code %d", name, c.ExitCode)
+			if err = r.cli.ContainerStart(name); err != nil {
+				log.Printf("unable to start the %s container - %s", name, err)
+			}
+		}
+	}
+}
+
+// exitedUnexpectedly tells whether the container has exited with a failure
+// it is not restarted from by the container engine, the containers stopped
+// being left stopped
+func exitedUnexpectedly(c *container.Container) bool {
+	if c.Running || c.ExitCode == 0 || c.ExitCode == 128+15 {
+		return false
+	}
+	switch c.RestartPolicy {
+	case podman.RestartAlways, podman.RestartUnlessStopped, podman.RestartOnFailure:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/pkg/domain/podman"
+	"gotest.tools/assert"
+)
+
+func TestIsComponent(t *testing.T) {
+	labels := func(component string) map[string]string {
+		return map[string]string{types.ComponentAnnotation: component}
+	}
+	assert.Assert(t, isComponent(&container.Container{Name: types.TransportDeploymentName, Labels: labels(types.TransportDeploymentName)}))
+	assert.Assert(t, isComponent(&container.Container{Name: types.PrometheusDeploymentName, Labels: labels(types.PrometheusDeploymentName)}))
+	// the controller itself
+	assert.Assert(t, !isComponent(&container.Container{Name: types.ControllerPodmanContainerName, Labels: labels(types.ControllerPodmanContainerName)}))
+	// the backup of a container being updated
+	assert.Assert(t, !isComponent(&container.Container{Name: types.TransportDeploymentName + "-1700000000", Labels: labels(types.TransportDeploymentName)}))
+	// a container of a kube play pod
+	assert.Assert(t, !isComponent(&container.Container{Name: types.TransportDeploymentName, Pod: "skupper", Labels: labels(types.TransportDeploymentName)}))
+	assert.Assert(t, !isComponent(&container.Container{Name: "backend"}))
+}
+
+func TestExitedUnexpectedly(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		container container.Container
+		expected  bool
+	}{
+		{name: "running", container: container.Container{Running: true, RestartPolicy: podman.RestartNo}},
+		{name: "stopped", container: container.Container{ExitCode: 143, RestartPolicy: podman.RestartNo}},
+		{name: "completed", container: container.Container{ExitCode: 0, RestartPolicy: podman.RestartNo}},
+		{name: "failed", container: container.Container{ExitCode: 1, RestartPolicy: podman.RestartNo}, expected: true},
+		{name: "failed-no-policy", container: container.Container{ExitCode: 137}, expected: true},
+		{name: "failed-always", container: container.Container{ExitCode: 1, RestartPolicy: podman.RestartAlways}},
+		{name: "failed-on-failure", container: container.Container{ExitCode: 1, RestartPolicy: podman.RestartOnFailure}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, exitedUnexpectedly(&test.container), test.expected)
+		})
+	}
+}
@@ -11,7 +11,6 @@ import (
 
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/client/container"
-	clientpodman "github.com/skupperproject/skupper/client/podman"
 	"github.com/skupperproject/skupper/pkg/domain"
 	"github.com/skupperproject/skupper/pkg/domain/podman"
 	"github.com/skupperproject/skupper/pkg/flow"
@@ -23,10 +22,10 @@ import (
 // Podman site beacons
 //
 
-func SendPodmanHostRecord(cli *clientpodman.PodmanRestClient, site *podman.Site, origin string,
+func SendPodmanHostRecord(cli container.EngineClient, site *podman.Site, origin string,
 	flowController *flow.FlowController, startTime uint64) {
 
-	var platform string = types.PlatformPodman
+	var platform string = string(cli.Platform())
 	versionInfo, err := cli.Version()
 	if err != nil {
 		log.Fatalf("error retrieving podman host info - %s", err)
@@ -223,13 +222,13 @@ func (s *ServiceTargetWatcher) OnRemove(name string) {
 // ContainerProcessInformer monitors podman containers, sending ProcessRecord
 // instances to the flow controller
 type ContainerProcessInformer struct {
-	cli            *clientpodman.PodmanRestClient
+	cli            container.EngineClient
 	origin         string
 	site           *podman.Site
 	flowController *flow.FlowController
 }
 
-func NewContainerProcessInformer(cli *clientpodman.PodmanRestClient, origin string, site *podman.Site, flowController *flow.FlowController) *ContainerProcessInformer {
+func NewContainerProcessInformer(cli container.EngineClient, origin string, site *podman.Site, flowController *flow.FlowController) *ContainerProcessInformer {
 	return &ContainerProcessInformer{
 		cli:            cli,
 		origin:         origin,
@@ -8,9 +8,8 @@ import (
 
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/client/container"
-	"github.com/skupperproject/skupper/client/generated/libpod/client/volumes"
-	"github.com/skupperproject/skupper/client/podman"
 	"github.com/skupperproject/skupper/pkg/certs"
+	"github.com/skupperproject/skupper/pkg/encryption"
 	"github.com/skupperproject/skupper/pkg/kube"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -23,8 +22,62 @@ var credentialMountInContainer = map[string]string{
 	types.SiteServerSecret:    "/etc/skupper-router-certs/skupper-internal/",
 }
 
+// where the containers decrypting the credentials find their key
+const (
+	credentialsKeyMount = "/etc/skupper-credentials-key"
+	credentialsKeyFile  = "key"
+)
+
+// the credentials read by the controller and the flow collector only, the
+// router is unable to decrypt the credentials mounted in its container
+var encryptedCredentials = map[string]bool{
+	types.LocalClientSecret:   true,
+	types.ConsoleServerSecret: true,
+}
+
 type CredentialHandler struct {
-	cli *podman.PodmanRestClient
+	cli        container.EngineClient
+	encryption encryption.Options
+	volume     func(name string) *container.Volume
+}
+
+// WithEncryption encrypts the cert authorities and the credentials that are
+// not read by the router when they are created
+func (p *CredentialHandler) WithEncryption(options encryption.Options) *CredentialHandler {
+	p.encryption = options
+	return p
+}
+
+// WithVolumes defines the volumes created for the cert authorities and the
+// credentials, so they can be backed by host paths or mounted with options
+func (p *CredentialHandler) WithVolumes(volume func(name string) *container.Volume) *CredentialHandler {
+	p.volume = volume
+	return p
+}
+
+// readCredentialFile reads a file of the credential volume, from its mount
+// point when running in a container, decrypting it. The data read is
+// returned along with the decryption errors.
+func (p *CredentialHandler) readCredentialFile(v *container.Volume, mountPoint string, name string) ([]byte, error) {
+	var data []byte
+	if !p.cli.IsRunningInContainer() {
+		content, err := v.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		data = []byte(content)
+	} else {
+		var err error
+		data, err = os.ReadFile(path.Join(mountPoint, name))
+		if err != nil {
+			return nil, err
+		}
+	}
+	plain, err := encryption.Decrypt(data)
+	if err != nil {
+		return data, fmt.Errorf("error decrypting %s from volume %s - %w", name, v.Name, err)
+	}
+	return plain, nil
 }
 
 func (p *CredentialHandler) ListCertAuthorities() ([]types.CertAuthority, error) {
@@ -84,33 +137,19 @@ func (p *CredentialHandler) ListCredentials() ([]types.Credential, error) {
 			// CA defined
 			if file.Name() == types.ClaimCaCertDataKey {
 				var ca *types.CertAuthority
-				var content string
-				var contentData []byte
-				if !p.cli.IsRunningInContainer() {
-					content, err = v.ReadFile(file.Name())
-				} else {
-					contentData, err = os.ReadFile(path.Join(mountPoint, file.Name()))
-					content = string(contentData)
-				}
+				content, err := p.readCredentialFile(v, mountPoint, file.Name())
 				if err != nil {
 					return nil, fmt.Errorf("error validating cert authority - %w", err)
 				}
-				ca = p.getCertAuthorityForCaCrt(content)
+				ca = p.getCertAuthorityForCaCrt(string(content))
 				if ca != nil {
 					cred.CA = ca.Name
 				}
 			} else if file.Name() == "connect.json" {
 				cred.ConnectJson = true
 			} else if file.Name() == "tls.crt" {
-				var dataStr string
-				var data []byte
-				var err error
-				if !p.cli.IsRunningInContainer() {
-					dataStr, err = v.ReadFile(file.Name())
-				} else {
-					data, err = os.ReadFile(path.Join(mountPoint, file.Name()))
-					dataStr = string(data)
-				}
+				data, err := p.readCredentialFile(v, mountPoint, file.Name())
+				dataStr := string(data)
 				if dataStr == "" {
 					empty = true
 					continue
@@ -138,7 +177,7 @@ func (p *CredentialHandler) ListCredentials() ([]types.Credential, error) {
 	return creds, nil
 }
 
-func NewPodmanCredentialHandler(cli *podman.PodmanRestClient) *CredentialHandler {
+func NewPodmanCredentialHandler(cli container.EngineClient) *CredentialHandler {
 	return &CredentialHandler{
 		cli: cli,
 	}
@@ -170,7 +209,7 @@ func (p *CredentialHandler) LoadVolumeAsSecret(vol *container.Volume) (*corev1.S
 		if file.IsDir() {
 			continue
 		}
-		data, err := os.ReadFile(path.Join(vol.Source, file.Name()))
+		data, err := encryption.ReadFile(path.Join(vol.Source, file.Name()))
 		if err != nil {
 			return nil, fmt.Errorf("error reading file %s for secret %s - %v", file.Name(), vol.Name, err)
 		}
@@ -183,8 +222,15 @@ func (p *CredentialHandler) LoadVolumeAsSecret(vol *container.Volume) (*corev1.S
 func (p *CredentialHandler) SaveSecretAsVolume(secret *corev1.Secret, kind string) (*container.Volume, error) {
 	vol, err := p.cli.VolumeInspect(secret.Name)
 
+	options := encryption.Options{}
+	if err == nil {
+		// rewritten credentials keep the encryption of the current files
+		options = volumeEncryption(vol)
+	} else if kind == "CertAuthority" || encryptedCredentials[secret.Name] {
+		options = p.encryption
+	}
 	if err != nil {
-		if _, notFound := err.(*volumes.VolumeInspectLibpodNotFound); !notFound {
+		if !isVolumeNotFound(err) {
 			return nil, err
 		}
 		// creating new volume
@@ -193,25 +239,57 @@ func (p *CredentialHandler) SaveSecretAsVolume(secret *corev1.Secret, kind strin
 			return nil, fmt.Errorf("error marshalling secret info for %s - %v", secret.Name, err)
 		}
 		vol = &container.Volume{
-			Name: secret.Name,
-			Labels: map[string]string{
-				types.InternalMetadataQualifier: string(metadataStr),
-				types.SkupperTypeQualifier:      kind,
-			},
+			Name:   secret.Name,
+			Labels: map[string]string{},
 		}
+		if p.volume != nil {
+			vol = p.volume(secret.Name)
+		}
+		vol.GetLabels()[types.InternalMetadataQualifier] = string(metadataStr)
+		vol.GetLabels()[types.SkupperTypeQualifier] = kind
 		vol, err = p.cli.VolumeCreate(vol)
 		if err != nil {
 			return nil, fmt.Errorf("error creating volume %s - %v", secret.Name, err)
 		}
 	}
-	_, err = vol.CreateDataFiles(secret.Data, true)
+	data := secret.Data
+	if options.Enabled() {
+		data = map[string][]byte{}
+		for name, value := range secret.Data {
+			if data[name], err = encryption.Encrypt(value, options); err != nil {
+				return nil, fmt.Errorf("error encrypting %s for secret %s - %w", name, secret.Name, err)
+			}
+		}
+	}
+	_, err = vol.CreateDataFiles(data, true)
 	return nil, err
 }
 
+// volumeEncryption returns how the files of the volume are encrypted
+func volumeEncryption(vol *container.Volume) encryption.Options {
+	files, err := vol.ListFiles()
+	if err != nil {
+		return encryption.Options{}
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		content, err := vol.ReadFile(file.Name())
+		if err != nil {
+			continue
+		}
+		if options, ok := encryption.OptionsOf([]byte(content)); ok {
+			return options
+		}
+	}
+	return encryption.Options{}
+}
+
 func (p *CredentialHandler) NewCertAuthority(ca types.CertAuthority) (*corev1.Secret, error) {
 	_, err := p.GetSecret(ca.Name)
 	if err != nil {
-		if _, notFound := err.(*volumes.VolumeInspectLibpodNotFound); !notFound {
+		if !isVolumeNotFound(err) {
 			return nil, fmt.Errorf("Failed to check CA %s : %w", ca.Name, err)
 		}
 	}
@@ -227,7 +305,7 @@ func (p *CredentialHandler) DeleteCertAuthority(id string) error {
 func (p *CredentialHandler) removeVolume(id string) error {
 	_, err := p.cli.VolumeInspect(id)
 	if err != nil {
-		if _, notFound := err.(*volumes.VolumeInspectLibpodNotFound); !notFound {
+		if !isVolumeNotFound(err) {
 			return fmt.Errorf("Failed to check volume %s : %w", id, err)
 		}
 	}
@@ -275,7 +353,8 @@ func (p *CredentialHandler) getCertAuthorityForCaCrt(caCrtContent string) *types
 			return nil
 		}
 		content, _ := v.ReadFile("tls.crt")
-		if caCrtContent == content {
+		data, _ := encryption.Decrypt([]byte(content))
+		if caCrtContent == string(data) {
 			return &ca
 		}
 	}
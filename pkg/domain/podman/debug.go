@@ -0,0 +1,274 @@
+package podman
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/pkg/certs"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// sensitiveEnv matches the environment variables whose values are not
+// written to the debug dump
+var sensitiveEnv = regexp.MustCompile(`(?i)(pass|secret|token|credential)`)
+
+var skstatFlags = []string{"-g", "-c", "-l", "-n", "-e", "-a", "-m", "-p"}
+
+// CertificateInfo is the metadata of a certificate of the site written to
+// the debug dump, its private key is never written
+type CertificateInfo struct {
+	Name         string    `yaml:"name"`
+	Subject      string    `yaml:"subject"`
+	Issuer       string    `yaml:"issuer"`
+	SerialNumber string    `yaml:"serialNumber"`
+	IsCA         bool      `yaml:"isCA"`
+	DNSNames     []string  `yaml:"dnsNames,omitempty"`
+	IPAddresses  []string  `yaml:"ipAddresses,omitempty"`
+	NotBefore    time.Time `yaml:"notBefore"`
+	NotAfter     time.Time `yaml:"notAfter"`
+}
+
+// DebugDump writes the state of the site to a compressed tar archive, as the
+// debug dump of the kubernetes sites does: the versions, the inspected
+// containers and their logs, the router config and statistics and the
+// metadata of the certificates. The name of the archive written is returned.
+func (s *SiteHandler) DebugDump(tarName string, cliVersion string) (string, error) {
+	dumpFile := tarName
+	if filepath.Ext(dumpFile) == "" {
+		dumpFile = dumpFile + ".tar.gz"
+	}
+	tarFile, err := os.Create(dumpFile)
+	if err != nil {
+		return dumpFile, err
+	}
+	defer tarFile.Close()
+	gz := gzip.NewWriter(tarFile)
+	defer gz.Close()
+	tw := tar.NewWriter(gz)
+	defer tw.Close()
+
+	containers, err := s.skupperContainers()
+	if err != nil {
+		return dumpFile, err
+	}
+	if err = writeDumpYaml(tw, "/skupper-info/versions.yaml", s.dumpVersions(containers, cliVersion)); err != nil {
+		return dumpFile, err
+	}
+	for _, c := range containers {
+		if err = s.dumpContainer(tw, c); err != nil {
+			return dumpFile, err
+		}
+	}
+	for _, name := range []string{types.TransportConfigMapName, types.ServiceInterfaceConfigMap} {
+		if err = s.dumpVolumeFiles(tw, name); err != nil {
+			return dumpFile, err
+		}
+	}
+	if err = writeDumpYaml(tw, "/skupper-info/certificates.yaml", s.certificatesInfo()); err != nil {
+		return dumpFile, err
+	}
+	// the networks the containers are attached to
+	networks := map[string]bool{}
+	for _, c := range containers {
+		for name := range c.Networks {
+			if networks[name] {
+				continue
+			}
+			networks[name] = true
+			network, err := s.cli.NetworkInspect(name)
+			if err != nil {
+				continue
+			}
+			if err = writeDumpYaml(tw, "/networks/"+name+".yaml", network); err != nil {
+				return dumpFile, err
+			}
+		}
+	}
+	return dumpFile, nil
+}
+
+// skupperContainers returns the inspected containers owned by skupper,
+// including the service containers
+func (s *SiteHandler) skupperContainers() ([]*container.Container, error) {
+	list, err := s.cli.ContainerList()
+	if err != nil {
+		return nil, fmt.Errorf("error listing containers - %w", err)
+	}
+	var containers []*container.Container
+	for _, c := range list {
+		if OwnedBySkupper("container", c.Labels) != nil {
+			continue
+		}
+		inspected, err := s.cli.ContainerInspect(c.Name)
+		if err != nil {
+			continue
+		}
+		containers = append(containers, inspected)
+	}
+	return containers, nil
+}
+
+func (s *SiteHandler) dumpVersions(containers []*container.Container, cliVersion string) map[string]interface{} {
+	versions := map[string]interface{}{
+		"cli": cliVersion,
+	}
+	if engine, err := s.cli.Version(); err == nil {
+		versions[string(s.cli.Platform())] = engine
+	}
+	if routerConfig, err := NewRouterConfigHandlerPodman(s.cli).GetRouterConfig(); err == nil {
+		versions["site"] = routerConfig.GetSiteMetadata().Version
+	}
+	images := map[string]string{}
+	for _, c := range containers {
+		image := c.Image
+		if img, err := s.cli.ImageInspect(c.Image); err == nil && img.Digest != "" && !strings.Contains(image, "@") {
+			image = fmt.Sprintf("%s (%s)", image, img.Digest)
+		}
+		images[c.Name] = image
+	}
+	versions["images"] = images
+	return versions
+}
+
+// dumpContainer writes the inspected container, with the sensitive values of
+// its environment redacted, and its logs. The router statistics are written
+// along with the router container.
+func (s *SiteHandler) dumpContainer(tw *tar.Writer, c *container.Container) error {
+	redacted := *c
+	redacted.Env = redactedEnv(c.Env)
+	dir := "/containers/" + c.Name
+	if err := writeDumpYaml(tw, dir+"/container.yaml", redacted); err != nil {
+		return err
+	}
+	if logs, err := s.cli.ContainerLogs(c.Name); err == nil {
+		if err = writeDumpFile(tw, dir+"/logs.txt", []byte(logs)); err != nil {
+			return err
+		}
+	}
+	if c.Name != types.TransportDeploymentName || !c.Running {
+		return nil
+	}
+	for _, flag := range skstatFlags {
+		out, err := s.cli.ContainerExec(c.Name, []string{"skstat", flag})
+		if err != nil {
+			continue
+		}
+		if err = writeDumpFile(tw, dir+"/skstat/skstat"+flag+".txt", []byte(out)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// redactedEnv returns the environment of a container without the values of
+// its sensitive variables
+func redactedEnv(env map[string]string) map[string]string {
+	redacted := map[string]string{}
+	for name, value := range env {
+		if sensitiveEnv.MatchString(name) {
+			value = "<redacted>"
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+// dumpVolumeFiles writes the files of the given config volume
+func (s *SiteHandler) dumpVolumeFiles(tw *tar.Writer, name string) error {
+	v, err := s.cli.VolumeInspect(name)
+	if err != nil {
+		return nil
+	}
+	files, err := v.ListFiles()
+	if err != nil {
+		return nil
+	}
+	for _, file := range files {
+		if file.IsDir() || strings.HasSuffix(file.Name(), ".lock") {
+			continue
+		}
+		data, err := v.ReadFile(file.Name())
+		if err != nil {
+			continue
+		}
+		if err = writeDumpFile(tw, "/volumes/"+name+"/"+file.Name(), []byte(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// certificatesInfo returns the metadata of the certificates of the cert
+// authorities and credentials of the site
+func (s *SiteHandler) certificatesInfo() []CertificateInfo {
+	credHandler := NewPodmanCredentialHandler(s.cli)
+	var names []string
+	if cas, err := credHandler.ListCertAuthorities(); err == nil {
+		for _, ca := range cas {
+			names = append(names, ca.Name)
+		}
+	}
+	if creds, err := credHandler.ListCredentials(); err == nil {
+		for _, cred := range creds {
+			names = append(names, cred.Name)
+		}
+	}
+	var infos []CertificateInfo
+	for _, name := range names {
+		secret, err := credHandler.GetSecret(name)
+		if err != nil {
+			continue
+		}
+		cert, err := certs.DecodeCertificate(secret.Data["tls.crt"])
+		if err != nil {
+			continue
+		}
+		info := CertificateInfo{
+			Name:         name,
+			Subject:      cert.Subject.String(),
+			Issuer:       cert.Issuer.String(),
+			SerialNumber: cert.SerialNumber.String(),
+			IsCA:         cert.IsCA,
+			DNSNames:     cert.DNSNames,
+			NotBefore:    cert.NotBefore,
+			NotAfter:     cert.NotAfter,
+		}
+		for _, ip := range cert.IPAddresses {
+			info.IPAddresses = append(info.IPAddresses, ip.String())
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+func writeDumpYaml(tw *tar.Writer, name string, obj interface{}) error {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("error serializing %s - %w", name, err)
+	}
+	return writeDumpFile(tw, name, data)
+}
+
+func writeDumpFile(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("Failed to write tar file header: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("Failed to write to tar archive: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package podman
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestRedactedEnv(t *testing.T) {
+	env := redactedEnv(map[string]string{
+		"SKUPPER_PLATFORM":     "podman",
+		"FLOW_USERS":           "/etc/console-users",
+		"SKUPPER_CONSOLE_PASS": "admin",
+		"REGISTRY_PASSWORD":    "secret",
+		"GITHUB_TOKEN":         "ghp_123",
+		"AWS_SECRET_KEY":       "abc",
+	})
+	assert.DeepEqual(t, env, map[string]string{
+		"SKUPPER_PLATFORM":     "podman",
+		"FLOW_USERS":           "/etc/console-users",
+		"SKUPPER_CONSOLE_PASS": "<redacted>",
+		"REGISTRY_PASSWORD":    "<redacted>",
+		"GITHUB_TOKEN":         "<redacted>",
+		"AWS_SECRET_KEY":       "<redacted>",
+	})
+}
+
+func TestWriteDumpYaml(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	assert.Assert(t, writeDumpYaml(tw, "/skupper-info/versions.yaml", map[string]string{"cli": "1.5.0"}))
+	assert.Assert(t, tw.Close())
+
+	tr := tar.NewReader(&buf)
+	hdr, err := tr.Next()
+	assert.Assert(t, err)
+	assert.Equal(t, hdr.Name, "/skupper-info/versions.yaml")
+	data, err := io.ReadAll(tr)
+	assert.Assert(t, err)
+	assert.Equal(t, string(data), "cli: 1.5.0\n")
+}
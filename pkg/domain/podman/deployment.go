@@ -8,8 +8,8 @@ import (
 
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/client/container"
-	"github.com/skupperproject/skupper/client/podman"
 	"github.com/skupperproject/skupper/pkg/domain"
+	"github.com/skupperproject/skupper/pkg/utils"
 )
 
 type SkupperDeployment struct {
@@ -19,6 +19,10 @@ type SkupperDeployment struct {
 	VolumeMounts   map[string]string
 	Networks       []string
 	SELinuxDisable bool
+	Command        []string
+	RestartPolicy  string
+	Healthcheck    *container.Healthcheck
+	PinImageDigest bool
 }
 
 func (s *SkupperDeployment) GetName() string {
@@ -26,10 +30,10 @@ func (s *SkupperDeployment) GetName() string {
 }
 
 type SkupperDeploymentHandler struct {
-	cli *podman.PodmanRestClient
+	cli container.EngineClient
 }
 
-func NewSkupperDeploymentHandlerPodman(cli *podman.PodmanRestClient) *SkupperDeploymentHandler {
+func NewSkupperDeploymentHandlerPodman(cli container.EngineClient) *SkupperDeploymentHandler {
 	return &SkupperDeploymentHandler{
 		cli: cli,
 	}
@@ -57,7 +61,7 @@ func (s *SkupperDeploymentHandler) Deploy(ctx context.Context, deployment domain
 	for _, component := range deployment.GetComponents() {
 
 		// Pulling image first
-		err = s.cli.ImagePull(ctx, component.GetImage())
+		err = s.pullImage(ctx, podmanDeployment, component)
 		if err != nil {
 			return err
 		}
@@ -91,7 +95,7 @@ func (s *SkupperDeploymentHandler) Deploy(ctx context.Context, deployment domain
 					return err
 				}
 				volume.Destination = destDir
-				volume.Mode = "z" // shared between containers
+				volume.Mode = volumeMode(volume) // shared between containers by default
 				mounts = append(mounts, *volume)
 			}
 		}
@@ -119,9 +123,11 @@ func (s *SkupperDeploymentHandler) Deploy(ctx context.Context, deployment domain
 			Mounts:         mounts,
 			FileMounts:     fileMounts,
 			Ports:          ports,
-			RestartPolicy:  "always",
+			Command:        podmanDeployment.Command,
+			RestartPolicy:  utils.DefaultStr(podmanDeployment.RestartPolicy, RestartAlways),
 			MaxMemoryBytes: component.GetMemoryLimit(),
 			MaxCpus:        component.GetCpus(),
+			Healthcheck:    podmanDeployment.Healthcheck,
 		}
 
 		if podmanDeployment.SELinuxDisable {
@@ -212,6 +218,9 @@ func (s *SkupperDeploymentHandler) List() ([]domain.SkupperDeployment, error) {
 			Aliases:                 aliases,
 			VolumeMounts:            mounts,
 			Networks:                ci.NetworkNames(),
+			Command:                 ci.Command,
+			RestartPolicy:           ci.RestartPolicy,
+			Healthcheck:             ci.Healthcheck,
 		}
 		depMap[deployName] = deployment
 
@@ -0,0 +1,40 @@
+package podman
+
+import (
+	"fmt"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/client/docker"
+	"github.com/skupperproject/skupper/client/generated/libpod/client/volumes"
+	"github.com/skupperproject/skupper/client/podman"
+)
+
+// NewEngineClient returns a client of the container engine of the given
+// platform, sites of the docker platform are managed the same way as the
+// podman ones
+func NewEngineClient(platform types.Platform, endpoint string) (container.EngineClient, error) {
+	switch platform {
+	case types.PlatformDocker:
+		cli, err := docker.NewDockerClient(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		return cli, nil
+	case types.PlatformPodman:
+		cli, err := podman.NewPodmanClient(endpoint, "")
+		if err != nil {
+			return nil, err
+		}
+		return cli, nil
+	}
+	return nil, fmt.Errorf("invalid container engine platform: %s", platform)
+}
+
+// isVolumeNotFound tells if the volume inspected does not exist
+func isVolumeNotFound(err error) bool {
+	if _, notFound := err.(*volumes.VolumeInspectLibpodNotFound); notFound {
+		return true
+	}
+	return docker.IsNotFound(err)
+}
@@ -0,0 +1,210 @@
+package podman
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/pkg/encryption"
+	"github.com/skupperproject/skupper/pkg/network"
+	"github.com/skupperproject/skupper/pkg/utils"
+)
+
+const flowCollectorTimeout = 5 * time.Second
+
+const (
+	// the cli authenticates to the collector with an api token of its own,
+	// the console password being possibly stored as a hash
+	consoleCliTokenName = "skupper-cli"
+	consoleCliTokenFile = ".cli-token"
+	// the api token hashes read by the collector from the users directory
+	consoleTokensFile = ".tokens"
+	// the key signing the console sessions, so they survive a restart
+	consoleSessionKeyFile = ".session-key"
+)
+
+// FlowCollectorClient reads the network as seen by the flow collector of
+// the podman site
+type FlowCollectorClient struct {
+	url      string
+	user     string
+	password string
+	token    string
+	client   *http.Client
+}
+
+func NewFlowCollectorClient(site *Site, cli container.EngineClient) (*FlowCollectorClient, error) {
+	if site == nil || !site.EnableFlowCollector {
+		return nil, fmt.Errorf("flow collector is not enabled")
+	}
+	consoleUrl, err := url.Parse(site.GetConsoleUrl())
+	if err != nil {
+		return nil, err
+	}
+	// the collector port is published on all addresses by default
+	if ip := net.ParseIP(consoleUrl.Hostname()); ip != nil && ip.IsUnspecified() {
+		consoleUrl.Host = net.JoinHostPort("127.0.0.1", consoleUrl.Port())
+	}
+	ca, err := readConsoleCa(cli)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read flow collector ca - %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(ca)) {
+		return nil, fmt.Errorf("no certificates found in flow collector ca")
+	}
+	if site.AuthMode != types.ConsoleAuthModeInternal {
+		return newFlowCollectorClient(consoleUrl.String(), "", "", roots), nil
+	}
+	if site.ConsoleCliToken != "" {
+		return newFlowCollectorClient(consoleUrl.String(), "", "", roots).withToken(site.ConsoleCliToken), nil
+	}
+	if utils.IsPasswordHash(site.ConsolePassword) {
+		return nil, fmt.Errorf("the console password of the site is stored hashed and the site has no cli token")
+	}
+	return newFlowCollectorClient(consoleUrl.String(), site.ConsoleUser, site.ConsolePassword, roots), nil
+}
+
+// newConsoleCliToken returns an api token of the sk_<name>_<secret> form the
+// collector accepts, along with the sha256 hash it keeps of the token
+func newConsoleCliToken() (string, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	token := "sk_" + consoleCliTokenName + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	sum := sha256.Sum256([]byte(token))
+	return token, hex.EncodeToString(sum[:]), nil
+}
+
+func readConsoleCa(cli container.EngineClient) (string, error) {
+	if cli.IsRunningInContainer() {
+		data, err := encryption.ReadFile(path.Join(credentialMountInContainer[types.ConsoleServerSecret], "ca.crt"))
+		return string(data), err
+	}
+	v, err := cli.VolumeInspect(types.ConsoleServerSecret)
+	if err != nil {
+		return "", err
+	}
+	content, err := v.ReadFile("ca.crt")
+	if err != nil {
+		return "", err
+	}
+	data, err := encryption.Decrypt([]byte(content))
+	return string(data), err
+}
+
+func newFlowCollectorClient(baseUrl string, user string, password string, roots *x509.CertPool) *FlowCollectorClient {
+	tlsConfig := &tls.Config{
+		// the collector is reached through a published port, so its
+		// certificate is verified against the site ca but not its hosts
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return fmt.Errorf("flow collector presented no certificate")
+			}
+			certs := make([]*x509.Certificate, 0, len(rawCerts))
+			for _, raw := range rawCerts {
+				cert, err := x509.ParseCertificate(raw)
+				if err != nil {
+					return err
+				}
+				certs = append(certs, cert)
+			}
+			intermediates := x509.NewCertPool()
+			for _, cert := range certs[1:] {
+				intermediates.AddCert(cert)
+			}
+			_, err := certs[0].Verify(x509.VerifyOptions{
+				Roots:         roots,
+				Intermediates: intermediates,
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			})
+			return err
+		},
+	}
+	return &FlowCollectorClient{
+		url:      baseUrl,
+		user:     user,
+		password: password,
+		client: &http.Client{
+			Timeout:   flowCollectorTimeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}
+}
+
+func (c *FlowCollectorClient) withToken(token string) *FlowCollectorClient {
+	c.token = token
+	return c
+}
+
+func (c *FlowCollectorClient) get(ctx context.Context, collection string, results interface{}) error {
+	payload := struct {
+		Results interface{} `json:"results"`
+	}{Results: results}
+	return c.request(ctx, collection+"/", &payload)
+}
+
+func (c *FlowCollectorClient) request(ctx context.Context, path string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+"/api/v1alpha1/"+path, nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else if c.user != "" {
+		req.SetBasicAuth(c.user, c.password)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("flow collector returned %s for %s", resp.Status, strings.TrimSuffix(path, "/"))
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// Summary returns the sites, addresses and site to site traffic currently
+// known to the flow collector
+func (c *FlowCollectorClient) Summary(ctx context.Context) (*network.CollectorSummary, error) {
+	summary := &network.CollectorSummary{}
+	if err := c.get(ctx, "sites", &summary.Sites); err != nil {
+		return nil, err
+	}
+	if err := c.get(ctx, "addresses", &summary.Addresses); err != nil {
+		return nil, err
+	}
+	if err := c.get(ctx, "sitepairs", &summary.Traffic); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// Posture returns the security posture of the site, followed by those of
+// the peer collectors
+func (c *FlowCollectorClient) Posture(ctx context.Context) ([]network.PostureReport, error) {
+	reports := []network.PostureReport{}
+	if err := c.request(ctx, "posture/", &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
@@ -0,0 +1,92 @@
+package podman
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestFlowCollectorSummary(t *testing.T) {
+	responses := map[string]string{
+		"/api/v1alpha1/sites/":     `{"results":[{"identity":"site-a","name":"west","nameSpace":"west","platform":"podman"},{"identity":"site-b","name":"east","platform":"kubernetes"}],"status":"","count":2}`,
+		"/api/v1alpha1/addresses/": `{"results":[{"identity":"addr-1","name":"backend:8080","protocol":"tcp","listenerCount":2,"connectorCount":1}],"status":"","count":1}`,
+		"/api/v1alpha1/posture/":   `[{"collector":"collector-a","certificates":[{"name":"skupper-site-ca","subject":"skupper-site-ca","status":"expiring"}],"tls":[],"auth":[],"warnings":["certificate skupper-site-ca (skupper-site-ca) expires on 2024-01-08T00:00:00Z"]}]`,
+		"/api/v1alpha1/sitepairs/": `{"results":[{"identity":"site-a-to-site-b","pairType":"SITE","recordCount":42,"sourceId":"site-a","sourceName":"west","destinationId":"site-b","destinationName":"east"}],"status":"","count":1}`,
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "admin" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, response)
+	}))
+	defer server.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	summary, err := newFlowCollectorClient(server.URL, "admin", "secret", roots).Summary(context.Background())
+	assert.Assert(t, err)
+	assert.Equal(t, len(summary.Sites), 2)
+	assert.Equal(t, summary.Sites[0].Name, "west")
+	assert.Equal(t, summary.Sites[0].Namespace, "west")
+	assert.Equal(t, summary.Sites[1].Platform, "kubernetes")
+	assert.Equal(t, len(summary.Addresses), 1)
+	assert.Equal(t, summary.Addresses[0].ListenerCount, 2)
+	assert.Equal(t, len(summary.Traffic), 1)
+	assert.Equal(t, summary.Traffic[0].DestinationSiteName, "east")
+	assert.Equal(t, summary.Traffic[0].Flows, uint64(42))
+
+	reports, err := newFlowCollectorClient(server.URL, "admin", "secret", roots).Posture(context.Background())
+	assert.Assert(t, err)
+	assert.Equal(t, len(reports), 1)
+	assert.Equal(t, reports[0].Collector, "collector-a")
+	assert.Equal(t, reports[0].Certificates[0].Status, "expiring")
+	assert.Equal(t, len(reports[0].Warnings), 1)
+
+	_, err = newFlowCollectorClient(server.URL, "admin", "wrong", roots).Summary(context.Background())
+	assert.ErrorContains(t, err, "401")
+
+	// a collector certificate not issued by the site ca is refused
+	_, err = newFlowCollectorClient(server.URL, "admin", "secret", x509.NewCertPool()).Summary(context.Background())
+	assert.ErrorContains(t, err, "certificate")
+}
+
+func TestFlowCollectorCliToken(t *testing.T) {
+	token, hash, err := newConsoleCliToken()
+	assert.Assert(t, err)
+	assert.Assert(t, strings.HasPrefix(token, "sk_skupper-cli_"))
+	sum := sha256.Sum256([]byte(token))
+	assert.Equal(t, hash, hex.EncodeToString(sum[:]))
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, ok := r.BasicAuth(); ok || r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"results":[],"status":"","count":0}`)
+	}))
+	defer server.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	// the token is sent instead of the stored console password
+	summary, err := newFlowCollectorClient(server.URL, "", "", roots).withToken(token).Summary(context.Background())
+	assert.Assert(t, err)
+	assert.Equal(t, len(summary.Sites), 0)
+
+	_, err = newFlowCollectorClient(server.URL, "admin", "$2a$10$hash", roots).Summary(context.Background())
+	assert.ErrorContains(t, err, "401")
+}
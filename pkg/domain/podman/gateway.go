@@ -0,0 +1,236 @@
+package podman
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/pkg/domain"
+)
+
+const (
+	// PodmanHostAddress is the name podman resolves to the host in the
+	// containers
+	PodmanHostAddress = "host.containers.internal"
+)
+
+// GatewayHandler binds the processes of the host into the service network
+// through the service containers of the site: a binding targets a process
+// listening on the host and a forward publishes a service on ports of the
+// host, so no separate gateway router is needed as on kubernetes sites.
+type GatewayHandler struct {
+	cli        container.EngineClient
+	svcHandler *ServiceHandler
+}
+
+func NewGatewayHandlerPodman(cli container.EngineClient) *GatewayHandler {
+	return &GatewayHandler{
+		cli:        cli,
+		svcHandler: NewServiceHandlerPodman(cli),
+	}
+}
+
+// GatewayForward is a service published on ports of the host
+type GatewayForward struct {
+	Address  string
+	Protocol string
+	Host     string
+	Ports    map[int]int
+}
+
+// GatewayBinding is a process of the host targeted by a service
+type GatewayBinding struct {
+	Address  string
+	Protocol string
+	Host     string
+	Ports    map[int]int
+}
+
+// HostAddress returns the address the containers reach the host at
+func (g *GatewayHandler) HostAddress() string {
+	return HostAddress(g.cli)
+}
+
+// HostAddress returns the address the containers of the site reach the host
+// at, docker does not resolve a name for the host by default, so the gateway
+// of the site network is used instead
+func HostAddress(cli container.EngineClient) string {
+	if cli.Platform() != types.PlatformDocker {
+		return PodmanHostAddress
+	}
+	siteHandler := NewSitePodmanHandlerFromCli(cli)
+	site, err := siteHandler.Get()
+	if err != nil {
+		return PodmanHostAddress
+	}
+	network, err := cli.NetworkInspect(site.(*Site).ContainerNetwork)
+	if err != nil {
+		return PodmanHostAddress
+	}
+	for _, subnet := range network.Subnets {
+		if ip := net.ParseIP(subnet.Gateway); ip != nil && ip.To4() != nil {
+			return subnet.Gateway
+		}
+	}
+	return PodmanHostAddress
+}
+
+// isLocalHost tells if the host refers to the host the containers run on
+func isLocalHost(host string) bool {
+	switch host {
+	case "", "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+// HostTarget returns the address the service containers reach the given host
+// at, so processes running directly on the host the containers run on can be
+// targeted as localhost or as host.containers.internal on any engine
+func HostTarget(cli container.EngineClient, host string) string {
+	if isLocalHost(host) || host == PodmanHostAddress {
+		return HostAddress(cli)
+	}
+	return host
+}
+
+// Bind targets the service at a process of the host listening on the given
+// ports, one per port of the service
+func (g *GatewayHandler) Bind(address string, host string, ports []int) error {
+	svc, err := g.svcHandler.Get(address)
+	if err != nil {
+		return err
+	}
+	host = HostTarget(g.cli, host)
+	targetPorts, err := mapServicePorts(svc, ports)
+	if err != nil {
+		return err
+	}
+	return g.svcHandler.AddEgressResolver(address, &domain.EgressResolverHost{
+		Host:  host,
+		Ports: targetPorts,
+	})
+}
+
+// Unbind removes the processes of the given host, the local one if empty,
+// targeted by the service
+func (g *GatewayHandler) Unbind(address string, host string) error {
+	host = HostTarget(g.cli, host)
+	bindings, err := g.bindings(address, host)
+	if err != nil {
+		return err
+	}
+	if len(bindings) == 0 {
+		return fmt.Errorf("service %s is not bound to %s", address, host)
+	}
+	for _, binding := range bindings {
+		if err = g.svcHandler.RemoveEgressResolver(address, binding); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Forward publishes the service on the given ports of the host, one per
+// port of the service, only on the loopback interface if requested
+func (g *GatewayHandler) Forward(address string, ports []int, loopback bool) error {
+	svc, err := g.svcHandler.Get(address)
+	if err != nil {
+		return err
+	}
+	hostPorts, err := mapServicePorts(svc, ports)
+	if err != nil {
+		return err
+	}
+	ingress := &domain.AddressIngressCommon{}
+	if loopback {
+		ingress.SetHost("127.0.0.1")
+	}
+	ingress.SetPorts(hostPorts)
+	return g.svcHandler.UpdateIngress(address, ingress)
+}
+
+// Unforward stops publishing the service on ports of the host
+func (g *GatewayHandler) Unforward(address string) error {
+	svc, err := g.svcHandler.Get(address)
+	if err != nil {
+		return err
+	}
+	if len(svc.(*Service).ContainerPorts()) == 0 {
+		return fmt.Errorf("service %s is not forwarded from the host", address)
+	}
+	ingress := &domain.AddressIngressCommon{}
+	ingress.SetPorts(map[int]int{})
+	return g.svcHandler.UpdateIngress(address, ingress)
+}
+
+// List returns the services forwarded from the host and the processes of
+// the host bound to the services
+func (g *GatewayHandler) List() ([]GatewayForward, []GatewayBinding, error) {
+	services, err := g.svcHandler.List()
+	if err != nil {
+		return nil, nil, err
+	}
+	hostAddress := g.HostAddress()
+	var forwards []GatewayForward
+	var bindings []GatewayBinding
+	for _, svc := range services {
+		svcPodman := svc.(*Service)
+		if len(svcPodman.ContainerPorts()) > 0 {
+			ports := map[int]int{}
+			for port, hostPort := range svcPodman.GetIngress().GetPorts() {
+				if hostPort != 0 {
+					ports[port] = hostPort
+				}
+			}
+			forwards = append(forwards, GatewayForward{
+				Address:  svcPodman.GetAddress(),
+				Protocol: svcPodman.GetProtocol(),
+				Host:     svcPodman.GetIngress().GetHost(),
+				Ports:    ports,
+			})
+		}
+		for _, resolver := range svcPodman.GetEgressResolvers() {
+			if resolverHost, ok := resolver.(*domain.EgressResolverHost); ok && resolverHost.Host == hostAddress {
+				bindings = append(bindings, GatewayBinding{
+					Address:  svcPodman.GetAddress(),
+					Protocol: svcPodman.GetProtocol(),
+					Host:     resolverHost.Host,
+					Ports:    resolverHost.Ports,
+				})
+			}
+		}
+	}
+	return forwards, bindings, nil
+}
+
+func (g *GatewayHandler) bindings(address string, hostAddress string) ([]domain.EgressResolver, error) {
+	svc, err := g.svcHandler.Get(address)
+	if err != nil {
+		return nil, err
+	}
+	var bindings []domain.EgressResolver
+	for _, resolver := range svc.GetEgressResolvers() {
+		if resolverHost, ok := resolver.(*domain.EgressResolverHost); ok && resolverHost.Host == hostAddress {
+			bindings = append(bindings, resolver)
+		}
+	}
+	return bindings, nil
+}
+
+// mapServicePorts maps the ports of the service, sorted, to the given ports
+func mapServicePorts(svc domain.Service, ports []int) (map[int]int, error) {
+	svcPorts := append([]int{}, svc.GetPorts()...)
+	sort.Ints(svcPorts)
+	if len(ports) != len(svcPorts) {
+		return nil, fmt.Errorf("service %s defines %d ports but %d given (all ports must be mapped)",
+			svc.GetAddress(), len(svcPorts), len(ports))
+	}
+	mapped := map[int]int{}
+	for i, port := range svcPorts {
+		mapped[port] = ports[i]
+	}
+	return mapped, nil
+}
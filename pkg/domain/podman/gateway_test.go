@@ -0,0 +1,32 @@
+package podman
+
+import (
+	"testing"
+
+	"github.com/skupperproject/skupper/pkg/domain"
+	"gotest.tools/assert"
+)
+
+func TestMapServicePorts(t *testing.T) {
+	svc := &Service{
+		ServiceCommon: &domain.ServiceCommon{
+			Address: "backend",
+			Ports:   []int{9090, 8080},
+		},
+	}
+	mapped, err := mapServicePorts(svc, []int{18080, 19090})
+	assert.Assert(t, err)
+	assert.DeepEqual(t, mapped, map[int]int{8080: 18080, 9090: 19090})
+
+	_, err = mapServicePorts(svc, []int{18080})
+	assert.ErrorContains(t, err, "service backend defines 2 ports but 1 given")
+}
+
+func TestIsLocalHost(t *testing.T) {
+	for _, host := range []string{"", "localhost", "127.0.0.1", "::1"} {
+		assert.Assert(t, isLocalHost(host), host)
+	}
+	for _, host := range []string{"10.0.0.1", "backend.example.com", PodmanHostAddress} {
+		assert.Assert(t, !isLocalHost(host), host)
+	}
+}
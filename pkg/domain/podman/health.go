@@ -0,0 +1,113 @@
+package podman
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/pkg/utils"
+)
+
+const (
+	RestartAlways        = "always"
+	RestartOnFailure     = "on-failure"
+	RestartUnlessStopped = "unless-stopped"
+	RestartNo            = "no"
+
+	// HealthcheckIntervalDefault is how often the engine verifies the
+	// components are healthy
+	HealthcheckIntervalDefault = 30 * time.Second
+)
+
+var ValidRestartPolicies = []string{RestartAlways, RestartOnFailure, RestartUnlessStopped, RestartNo}
+
+// ComponentHealth is the health state of a container of the site
+type ComponentHealth struct {
+	Name   string
+	Health string
+}
+
+// ValidateRestartPolicy verifies the restart policy of the site containers
+// is known to podman and docker
+func (s *Site) ValidateRestartPolicy() error {
+	if s.RestartPolicy != "" && !utils.StringSliceContains(ValidRestartPolicies, s.RestartPolicy) {
+		return fmt.Errorf("invalid restart policy: %s - valid policies are: %s", s.RestartPolicy, ValidRestartPolicies)
+	}
+	if s.HealthcheckInterval < 0 {
+		return fmt.Errorf("invalid healthcheck interval: %s", s.HealthcheckInterval)
+	}
+	return nil
+}
+
+// GetRestartPolicy returns the restart policy of the site containers, they
+// are always restarted by default
+func (s *Site) GetRestartPolicy() string {
+	return utils.DefaultStr(s.RestartPolicy, RestartAlways)
+}
+
+// healthcheck returns the healthcheck running the given command in a
+// component, nil when healthchecks are disabled for the site
+func (s *Site) healthcheck(command ...string) *container.Healthcheck {
+	if s.HealthcheckInterval == 0 {
+		return nil
+	}
+	return &container.Healthcheck{
+		Command:     command,
+		Interval:    s.HealthcheckInterval,
+		Timeout:     5 * time.Second,
+		StartPeriod: time.Minute,
+		Retries:     3,
+	}
+}
+
+// routerHealthcheck probes the healthz endpoint of the router http listener
+func (s *Site) routerHealthcheck() *container.Healthcheck {
+	return s.healthcheck("curl", "-sf", fmt.Sprintf("http://localhost:%d/healthz", types.TransportLivenessPort))
+}
+
+// controllerHealthcheck probes the healthz endpoint of the controller
+func (s *Site) controllerHealthcheck() *container.Healthcheck {
+	return s.healthcheck("curl", "-sf", fmt.Sprintf("http://localhost:%d/healthz", types.ControllerLivenessPort))
+}
+
+// flowCollectorHealthcheck probes the readiness endpoint of the flow
+// collector, served over tls with the console certificate
+func (s *Site) flowCollectorHealthcheck() *container.Healthcheck {
+	return s.healthcheck("curl", "-sfk", fmt.Sprintf("https://localhost:%d/readyz", types.FlowCollectorDefaultServicePort))
+}
+
+// prometheusHealthcheck probes the readiness endpoint of the prometheus
+// server, the prometheus image providing wget only
+func (s *Site) prometheusHealthcheck() *container.Healthcheck {
+	return s.healthcheck("wget", "-q", "-O", "/dev/null", fmt.Sprintf("http://localhost:%d/-/ready", types.PrometheusServerDefaultServicePort))
+}
+
+// ComponentHealth returns the health state of the site containers running
+// a healthcheck
+func (s *SiteHandler) ComponentHealth() ([]ComponentHealth, error) {
+	containers, err := s.cli.ContainerList()
+	if err != nil {
+		return nil, fmt.Errorf("error listing containers - %w", err)
+	}
+	var health []ComponentHealth
+	for _, c := range containers {
+		if _, ok := c.Labels[types.ComponentAnnotation]; !ok {
+			continue
+		}
+		ci, err := s.cli.ContainerInspect(c.Name)
+		if err != nil || ci.Healthcheck == nil {
+			continue
+		}
+		state := ci.Health
+		if !ci.Running {
+			state = "stopped"
+		}
+		health = append(health, ComponentHealth{Name: ci.Name, Health: utils.DefaultStr(state, container.HealthStarting)})
+	}
+	sort.Slice(health, func(i, j int) bool {
+		return health[i].Name < health[j].Name
+	})
+	return health, nil
+}
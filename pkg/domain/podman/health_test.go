@@ -0,0 +1,78 @@
+package podman
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/client/podman"
+	"gotest.tools/assert"
+)
+
+func TestValidateRestartPolicy(t *testing.T) {
+	for _, restartPolicy := range append([]string{""}, ValidRestartPolicies...) {
+		site := &Site{RestartPolicy: restartPolicy, HealthcheckInterval: HealthcheckIntervalDefault}
+		assert.Assert(t, site.ValidateRestartPolicy())
+	}
+	site := &Site{RestartPolicy: "sometimes"}
+	assert.ErrorContains(t, site.ValidateRestartPolicy(), "invalid restart policy: sometimes")
+	site = &Site{HealthcheckInterval: -time.Second}
+	assert.ErrorContains(t, site.ValidateRestartPolicy(), "invalid healthcheck interval: -1s")
+}
+
+func TestSiteHealthchecks(t *testing.T) {
+	site := &Site{}
+	assert.Equal(t, site.GetRestartPolicy(), RestartAlways)
+	assert.Assert(t, site.routerHealthcheck() == nil)
+
+	site = &Site{RestartPolicy: RestartOnFailure, HealthcheckInterval: 10 * time.Second}
+	assert.Equal(t, site.GetRestartPolicy(), RestartOnFailure)
+	router := site.routerHealthcheck()
+	assert.DeepEqual(t, router.Command, []string{"curl", "-sf", "http://localhost:9090/healthz"})
+	assert.Equal(t, router.Interval, 10*time.Second)
+	assert.DeepEqual(t, site.controllerHealthcheck().Command, []string{"curl", "-sf", "http://localhost:8182/healthz"})
+	assert.DeepEqual(t, site.flowCollectorHealthcheck().Command, []string{"curl", "-sfk", "https://localhost:8010/readyz"})
+}
+
+func TestComponentHealth(t *testing.T) {
+	healthcheck := &container.Healthcheck{Command: []string{"true"}, Interval: time.Second}
+	cli := podman.NewPodmanClientMock([]*container.Container{
+		{
+			Name:        types.TransportDeploymentName,
+			Labels:      map[string]string{types.ComponentAnnotation: types.TransportDeploymentName},
+			Healthcheck: healthcheck,
+			Running:     true,
+			Health:      container.HealthHealthy,
+		},
+		{
+			Name:        types.ControllerPodmanContainerName,
+			Labels:      map[string]string{types.ComponentAnnotation: types.ControllerPodmanContainerName},
+			Healthcheck: healthcheck,
+			Running:     true,
+		},
+		{
+			Name:        types.FlowCollectorContainerName,
+			Labels:      map[string]string{types.ComponentAnnotation: types.FlowCollectorContainerName},
+			Healthcheck: healthcheck,
+		},
+		{
+			Name:    types.PrometheusDeploymentName,
+			Labels:  map[string]string{types.ComponentAnnotation: types.PrometheusDeploymentName},
+			Running: true,
+		},
+		{
+			Name:        "backend",
+			Healthcheck: healthcheck,
+			Running:     true,
+			Health:      container.HealthUnhealthy,
+		},
+	})
+	health, err := NewSitePodmanHandlerFromCli(cli).ComponentHealth()
+	assert.Assert(t, err)
+	assert.DeepEqual(t, health, []ComponentHealth{
+		{Name: types.FlowCollectorContainerName, Health: "stopped"},
+		{Name: types.ControllerPodmanContainerName, Health: container.HealthStarting},
+		{Name: types.TransportDeploymentName, Health: container.HealthHealthy},
+	})
+}
@@ -0,0 +1,62 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/pkg/domain"
+	"github.com/skupperproject/skupper/pkg/utils"
+)
+
+// ValidatePullOptions verifies the image pull policy and the registry
+// credentials of the site
+func (s *Site) ValidatePullOptions() error {
+	if s.ImagePullPolicy != "" && !utils.StringSliceContains(container.ValidPullPolicies, s.ImagePullPolicy) {
+		return fmt.Errorf("invalid image pull policy: %s - valid policies are: %s", s.ImagePullPolicy, container.ValidPullPolicies)
+	}
+	if (s.RegistryUsername == "") != (s.RegistryPassword == "") {
+		return fmt.Errorf("the registry username and password must be provided together")
+	}
+	if s.RegistryAuthFile != "" {
+		if s.RegistryUsername != "" {
+			return fmt.Errorf("the registry auth file and username cannot be provided together")
+		}
+		if _, err := os.Stat(s.RegistryAuthFile); err != nil {
+			return fmt.Errorf("invalid registry auth file - %w", err)
+		}
+	}
+	return nil
+}
+
+// pullOptions returns how the images of the site components are pulled
+func (s *Site) pullOptions() container.PullOptions {
+	return container.PullOptions{
+		Policy:   s.ImagePullPolicy,
+		AuthFile: s.RegistryAuthFile,
+		Username: s.RegistryUsername,
+		Password: s.RegistryPassword,
+	}
+}
+
+// pullImage pulls the image of the given component and, when the deployment
+// pins the image digests, makes the component run the exact image pulled
+func (s *SkupperDeploymentHandler) pullImage(ctx context.Context, deployment *SkupperDeployment, component domain.SkupperComponent) error {
+	image := component.GetImage()
+	if err := s.cli.ImagePull(ctx, image); err != nil {
+		return err
+	}
+	if !deployment.PinImageDigest || strings.Contains(image, "@") {
+		return nil
+	}
+	img, err := s.cli.ImageInspect(image)
+	if err != nil {
+		return fmt.Errorf("error inspecting image %s - %w", image, err)
+	}
+	if img.Digest != "" {
+		component.SetImage(container.PinnedImage(image, img.Digest))
+	}
+	return nil
+}
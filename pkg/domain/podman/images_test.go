@@ -0,0 +1,79 @@
+package podman
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/pkg/images"
+	"gotest.tools/assert"
+)
+
+func TestValidatePullOptions(t *testing.T) {
+	authFile := path.Join(t.TempDir(), "auth.json")
+	assert.Assert(t, os.WriteFile(authFile, []byte(`{"auths": {}}`), 0600))
+
+	testTable := []struct {
+		doc  string
+		site *Site
+		err  string
+	}{
+		{doc: "defaults", site: &Site{}},
+		{doc: "pull policies", site: &Site{ImagePullPolicy: container.PullNever}},
+		{doc: "credentials", site: &Site{RegistryUsername: "skupper", RegistryPassword: "secret"}},
+		{doc: "auth file", site: &Site{RegistryAuthFile: authFile}},
+		{doc: "invalid pull policy", site: &Site{ImagePullPolicy: "newer"},
+			err: "invalid image pull policy: newer"},
+		{doc: "username without password", site: &Site{RegistryUsername: "skupper"},
+			err: "the registry username and password must be provided together"},
+		{doc: "auth file and credentials", site: &Site{RegistryAuthFile: authFile, RegistryUsername: "skupper", RegistryPassword: "secret"},
+			err: "the registry auth file and username cannot be provided together"},
+		{doc: "missing auth file", site: &Site{RegistryAuthFile: path.Join(t.TempDir(), "missing.json")},
+			err: "invalid registry auth file"},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			err := test.site.ValidatePullOptions()
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+		})
+	}
+}
+
+func TestPinnedImage(t *testing.T) {
+	const digest = "sha256:3c4f8a"
+	testTable := []struct {
+		image    string
+		expected string
+	}{
+		{image: "quay.io/skupper/skupper-router:main", expected: "quay.io/skupper/skupper-router@" + digest},
+		{image: "quay.io/skupper/skupper-router", expected: "quay.io/skupper/skupper-router@" + digest},
+		{image: "localhost:5000/skupper-router:2.5", expected: "localhost:5000/skupper-router@" + digest},
+		{image: "localhost:5000/skupper-router", expected: "localhost:5000/skupper-router@" + digest},
+		{image: "quay.io/skupper/skupper-router@sha256:1b2e", expected: "quay.io/skupper/skupper-router@" + digest},
+	}
+	for _, test := range testTable {
+		t.Run(test.image, func(t *testing.T) {
+			assert.Equal(t, container.PinnedImage(test.image, digest), test.expected)
+		})
+	}
+}
+
+func TestComponentImage(t *testing.T) {
+	assert.Equal(t, componentImage(types.TransportDeploymentName), images.GetRouterImageName())
+	assert.Equal(t, componentImage(types.ControllerPodmanContainerName), images.GetControllerPodmanImageName())
+	assert.Equal(t, componentImage(types.MetricsExporterContainerName), images.GetControllerPodmanImageName())
+	assert.Equal(t, componentImage(types.FlowCollectorContainerName), images.GetFlowCollectorImageName())
+	assert.Equal(t, componentImage(types.PrometheusDeploymentName), images.GetPrometheusServerImageName())
+	assert.Equal(t, componentImage("backend"), "")
+
+	image, err := pinnedComponentImage(context.Background(), nil, &Site{}, types.TransportDeploymentName)
+	assert.Assert(t, err)
+	assert.Equal(t, image, images.GetRouterImageName())
+}
@@ -0,0 +1,276 @@
+package podman
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/client/podman"
+	"github.com/skupperproject/skupper/pkg/config"
+	"github.com/skupperproject/skupper/pkg/domain"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// KubePlayDeploymentHandler deploys each skupper deployment as a pod,
+// described by a kubernetes YAML file and launched through podman kube play.
+// The YAML files are kept under the data home, so the site definition can be
+// inspected, modified and played again.
+//
+// As podman names the containers of a pod <pod>-<container>, the containers
+// are renamed after the deployment is played, so they can be reached by the
+// same names as the ones created by the SkupperDeploymentHandler.
+type KubePlayDeploymentHandler struct {
+	*SkupperDeploymentHandler
+	endpoint string
+	Dir      string
+}
+
+func NewKubePlayDeploymentHandler(cli container.EngineClient) *KubePlayDeploymentHandler {
+	return &KubePlayDeploymentHandler{
+		SkupperDeploymentHandler: NewSkupperDeploymentHandlerPodman(cli),
+		endpoint:                 cli.GetEndpoint(),
+		Dir:                      GetKubePlayDir(),
+	}
+}
+
+// GetKubePlayDir returns the directory holding the YAML files of the pods
+func GetKubePlayDir() string {
+	return path.Join(config.GetSiteDataHome(), "kube")
+}
+
+// FileName returns the YAML file describing the pod of the given deployment
+func (k *KubePlayDeploymentHandler) FileName(name string) string {
+	return path.Join(k.Dir, name+".yaml")
+}
+
+// Deploy renders the deployment as a pod and plays it
+func (k *KubePlayDeploymentHandler) Deploy(ctx context.Context, deployment domain.SkupperDeployment) error {
+	if len(deployment.GetComponents()) > 1 {
+		return fmt.Errorf("podman implementation currently allows only one component per deployment")
+	}
+	podmanDeployment := deployment.(*SkupperDeployment)
+	for _, component := range deployment.GetComponents() {
+		if err := k.pullImage(ctx, podmanDeployment, component); err != nil {
+			return err
+		}
+	}
+
+	data, err := RenderKubePod(podmanDeployment)
+	if err != nil {
+		return fmt.Errorf("error rendering pod for %s - %w", deployment.GetName(), err)
+	}
+	if err = os.MkdirAll(k.Dir, 0755); err != nil {
+		return fmt.Errorf("unable to create directory %s - %w", k.Dir, err)
+	}
+	fileName := k.FileName(deployment.GetName())
+	if err = os.WriteFile(fileName, data, 0644); err != nil {
+		return fmt.Errorf("unable to write %s - %w", fileName, err)
+	}
+
+	args := []string{"kube", "play", "--replace"}
+	for _, network := range podmanDeployment.Networks {
+		networkOpts := network
+		for i, alias := range podmanDeployment.Aliases {
+			sep := ","
+			if i == 0 {
+				sep = ":"
+			}
+			networkOpts += sep + "alias=" + alias
+		}
+		args = append(args, "--network", networkOpts)
+	}
+	args = append(args, fileName)
+	if err = k.podman(args...); err != nil {
+		return fmt.Errorf("error playing %s - %w", fileName, err)
+	}
+
+	for _, component := range deployment.GetComponents() {
+		podContainer := deployment.GetName() + "-" + component.Name()
+		if err = k.podman("rename", podContainer, component.Name()); err != nil {
+			_ = k.Undeploy(deployment.GetName())
+			return fmt.Errorf("error renaming container %s - %w", podContainer, err)
+		}
+	}
+	return nil
+}
+
+// Undeploy removes the pod of the given deployment along with its YAML file
+func (k *KubePlayDeploymentHandler) Undeploy(name string) error {
+	if err := k.podman("pod", "rm", "--force", "--ignore", name); err != nil {
+		return fmt.Errorf("error removing pod %s - %w", name, err)
+	}
+	_ = os.Remove(k.FileName(name))
+	return k.SkupperDeploymentHandler.Undeploy(name)
+}
+
+// podman runs the podman command line against the endpoint of the site
+func (k *KubePlayDeploymentHandler) podman(args ...string) error {
+	args = append(podmanConnectionArgs(k.endpoint), args...)
+	var stderr bytes.Buffer
+	cmd := exec.Command("podman", args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// podmanConnectionArgs returns the flags of the podman command line
+// reaching the given endpoint, none when the default endpoint is used
+func podmanConnectionArgs(endpoint string) []string {
+	switch {
+	case endpoint == "" || endpoint == podman.GetDefaultPodmanEndpoint():
+		return nil
+	case strings.HasPrefix(endpoint, "/"):
+		return []string{"--url", "unix://" + endpoint}
+	case podman.IsSSHEndpoint(endpoint):
+		u, err := podman.ParseSSHEndpoint(endpoint)
+		if err != nil {
+			return []string{"--url", endpoint}
+		}
+		identity := u.Query().Get("identity")
+		u.RawQuery = ""
+		if identity != "" {
+			return []string{"--url", u.String(), "--identity", identity}
+		}
+		return []string{"--url", u.String()}
+	case strings.HasPrefix(endpoint, "http://"):
+		return []string{"--url", "tcp://" + strings.TrimPrefix(endpoint, "http://")}
+	case !strings.Contains(endpoint, "://"):
+		return []string{"--url", "tcp://" + endpoint}
+	}
+	return []string{"--url", endpoint}
+}
+
+// kubeRestartPolicy returns the pod restart policy matching the restart
+// policy of the site containers
+func kubeRestartPolicy(restartPolicy string) corev1.RestartPolicy {
+	switch restartPolicy {
+	case RestartOnFailure:
+		return corev1.RestartPolicyOnFailure
+	case RestartNo:
+		return corev1.RestartPolicyNever
+	}
+	return corev1.RestartPolicyAlways
+}
+
+// RenderKubePod returns the YAML of the pod running the components of the
+// given deployment, named volumes being claimed and files mounted from the host
+func RenderKubePod(deployment *SkupperDeployment) ([]byte, error) {
+	labels := map[string]string{
+		"application":             types.AppName,
+		types.ComponentAnnotation: deployment.GetName(),
+	}
+	pod := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   deployment.GetName(),
+			Labels: labels,
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: kubeRestartPolicy(deployment.RestartPolicy),
+		},
+	}
+
+	var volumeNames []string
+	for volumeName := range deployment.VolumeMounts {
+		volumeNames = append(volumeNames, volumeName)
+	}
+	sort.Strings(volumeNames)
+	var volumeMounts []corev1.VolumeMount
+	for i, volumeName := range volumeNames {
+		volume := corev1.Volume{Name: volumeName}
+		if strings.HasPrefix(volumeName, "/") {
+			volume.Name = fmt.Sprintf("host-file-%d", i)
+			volume.HostPath = &corev1.HostPathVolumeSource{Path: volumeName}
+		} else {
+			volume.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{ClaimName: volumeName}
+		}
+		pod.Spec.Volumes = append(pod.Spec.Volumes, volume)
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      volume.Name,
+			MountPath: deployment.VolumeMounts[volumeName],
+		})
+	}
+
+	for _, component := range deployment.GetComponents() {
+		for k, v := range component.GetLabels() {
+			labels[k] = v
+		}
+		c := corev1.Container{
+			Name:         component.Name(),
+			Image:        component.GetImage(),
+			Args:         deployment.Command,
+			VolumeMounts: volumeMounts,
+		}
+		env := component.GetEnv()
+		var envNames []string
+		for name := range env {
+			envNames = append(envNames, name)
+		}
+		sort.Strings(envNames)
+		for _, name := range envNames {
+			c.Env = append(c.Env, corev1.EnvVar{Name: name, Value: env[name]})
+		}
+		for _, siteIngress := range component.GetSiteIngresses() {
+			c.Ports = append(c.Ports, corev1.ContainerPort{
+				ContainerPort: int32(siteIngress.GetTarget().GetPort()),
+				HostPort:      int32(siteIngress.GetPort()),
+				HostIP:        siteIngress.GetHost(),
+				Protocol:      corev1.ProtocolTCP,
+			})
+		}
+		limits := corev1.ResourceList{}
+		if memory := component.GetMemoryLimit(); memory > 0 {
+			limits[corev1.ResourceMemory] = *resource.NewQuantity(memory, resource.BinarySI)
+		}
+		if cpus := component.GetCpus(); cpus > 0 {
+			limits[corev1.ResourceCPU] = *resource.NewMilliQuantity(int64(cpus*1000), resource.DecimalSI)
+		}
+		if len(limits) > 0 {
+			c.Resources.Limits = limits
+		}
+		if hc := deployment.Healthcheck; hc != nil {
+			// podman kube play runs the liveness probe as the healthcheck
+			c.LivenessProbe = &corev1.Probe{
+				Handler: corev1.Handler{
+					Exec: &corev1.ExecAction{Command: hc.Command},
+				},
+				InitialDelaySeconds: int32(hc.StartPeriod.Seconds()),
+				PeriodSeconds:       int32(hc.Interval.Seconds()),
+				TimeoutSeconds:      int32(hc.Timeout.Seconds()),
+				FailureThreshold:    int32(hc.Retries),
+			}
+		}
+		if deployment.SELinuxDisable {
+			c.SecurityContext = &corev1.SecurityContext{
+				SELinuxOptions: &corev1.SELinuxOptions{Type: "spc_t"},
+			}
+		}
+		pod.Spec.Containers = append(pod.Spec.Containers, c)
+	}
+
+	var buf bytes.Buffer
+	s := json.NewYAMLSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+	if err := s.Encode(pod, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
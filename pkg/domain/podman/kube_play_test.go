@@ -0,0 +1,128 @@
+package podman
+
+import (
+	"testing"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/domain"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestRenderKubePod(t *testing.T) {
+	deployment := &SkupperDeployment{
+		Name: types.TransportDeploymentName,
+		SkupperDeploymentCommon: &domain.SkupperDeploymentCommon{
+			Components: []domain.SkupperComponent{
+				&domain.Router{
+					Image:  "quay.io/skupper/skupper-router:main",
+					Env:    map[string]string{"QDROUTERD_CONF": "/etc/skupper-router/config/skrouterd.json", "APPLICATION_NAME": "skupper-router"},
+					Labels: map[string]string{"application": types.AppName},
+					SiteIngresses: []domain.SiteIngress{
+						&domain.SiteIngressCommon{
+							Name:   types.InterRouterIngressPrefix,
+							Host:   "127.0.0.1",
+							Port:   55671,
+							Target: &domain.PortCommon{Name: types.InterRouterIngressPrefix, Port: 55671},
+						},
+					},
+					MemoryLimit: 1073741824,
+					Cpus:        2,
+				},
+			},
+		},
+		Aliases: []string{types.TransportServiceName, types.LocalTransportServiceName},
+		VolumeMounts: map[string]string{
+			types.LocalServerSecret:             "/etc/skupper-router-certs/skupper-amqps/",
+			"/run/user/1000/podman/podman.sock": "/tmp/podman.sock",
+		},
+		Networks:       []string{"skupper"},
+		SELinuxDisable: true,
+	}
+
+	data, err := RenderKubePod(deployment)
+	assert.Assert(t, err)
+
+	s := json.NewYAMLSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+	obj, _, err := s.Decode(data, nil, nil)
+	assert.Assert(t, err)
+	pod, ok := obj.(*corev1.Pod)
+	assert.Assert(t, ok)
+
+	assert.Equal(t, pod.Name, types.TransportDeploymentName)
+	assert.Equal(t, pod.Labels["application"], types.AppName)
+	assert.Equal(t, pod.Labels[types.ComponentAnnotation], types.TransportDeploymentName)
+	assert.Equal(t, pod.Spec.RestartPolicy, corev1.RestartPolicyAlways)
+	assert.DeepEqual(t, pod.Spec.Volumes, []corev1.Volume{
+		{Name: "host-file-0", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/run/user/1000/podman/podman.sock"}}},
+		{Name: types.LocalServerSecret, VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: types.LocalServerSecret}}},
+	})
+
+	assert.Equal(t, len(pod.Spec.Containers), 1)
+	c := pod.Spec.Containers[0]
+	assert.Equal(t, c.Name, types.TransportDeploymentName)
+	assert.Equal(t, c.Image, "quay.io/skupper/skupper-router:main")
+	assert.DeepEqual(t, c.Env, []corev1.EnvVar{
+		{Name: "APPLICATION_NAME", Value: "skupper-router"},
+		{Name: "QDROUTERD_CONF", Value: "/etc/skupper-router/config/skrouterd.json"},
+	})
+	assert.DeepEqual(t, c.Ports, []corev1.ContainerPort{
+		{ContainerPort: 55671, HostPort: 55671, HostIP: "127.0.0.1", Protocol: corev1.ProtocolTCP},
+	})
+	assert.DeepEqual(t, c.VolumeMounts, []corev1.VolumeMount{
+		{Name: "host-file-0", MountPath: "/tmp/podman.sock"},
+		{Name: types.LocalServerSecret, MountPath: "/etc/skupper-router-certs/skupper-amqps/"},
+	})
+	assert.Equal(t, c.Resources.Limits.Memory().Value(), int64(1073741824))
+	assert.Equal(t, c.Resources.Limits.Cpu().Value(), int64(2))
+	assert.Equal(t, c.SecurityContext.SELinuxOptions.Type, "spc_t")
+}
+
+func TestRenderKubePodHealthcheck(t *testing.T) {
+	site := &Site{RestartPolicy: RestartOnFailure, HealthcheckInterval: HealthcheckIntervalDefault}
+	deployment := &SkupperDeployment{
+		Name: types.TransportDeploymentName,
+		SkupperDeploymentCommon: &domain.SkupperDeploymentCommon{
+			Components: []domain.SkupperComponent{&domain.Router{}},
+		},
+		RestartPolicy: site.GetRestartPolicy(),
+		Healthcheck:   site.routerHealthcheck(),
+	}
+
+	data, err := RenderKubePod(deployment)
+	assert.Assert(t, err)
+
+	s := json.NewYAMLSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+	obj, _, err := s.Decode(data, nil, nil)
+	assert.Assert(t, err)
+	pod, ok := obj.(*corev1.Pod)
+	assert.Assert(t, ok)
+
+	assert.Equal(t, pod.Spec.RestartPolicy, corev1.RestartPolicyOnFailure)
+	probe := pod.Spec.Containers[0].LivenessProbe
+	assert.Assert(t, probe != nil)
+	assert.DeepEqual(t, probe.Exec.Command, []string{"curl", "-sf", "http://localhost:9090/healthz"})
+	assert.Equal(t, probe.PeriodSeconds, int32(30))
+	assert.Equal(t, probe.InitialDelaySeconds, int32(60))
+	assert.Equal(t, probe.FailureThreshold, int32(3))
+}
+
+func TestPodmanConnectionArgs(t *testing.T) {
+	testTable := []struct {
+		endpoint string
+		expected []string
+	}{
+		{endpoint: ""},
+		{endpoint: "/run/podman/podman.sock", expected: []string{"--url", "unix:///run/podman/podman.sock"}},
+		{endpoint: "unix:///run/podman/podman.sock", expected: []string{"--url", "unix:///run/podman/podman.sock"}},
+		{endpoint: "http://lab.example.com:8888", expected: []string{"--url", "tcp://lab.example.com:8888"}},
+		{endpoint: "lab.example.com:8888", expected: []string{"--url", "tcp://lab.example.com:8888"}},
+		{endpoint: "ssh://skupper@edge.example.com/run/user/1000/podman/podman.sock?identity=%2Fkeys%2Fedge",
+			expected: []string{"--url", "ssh://skupper@edge.example.com/run/user/1000/podman/podman.sock", "--identity", "/keys/edge"}},
+	}
+	for _, test := range testTable {
+		assert.DeepEqual(t, podmanConnectionArgs(test.endpoint), test.expected)
+	}
+}
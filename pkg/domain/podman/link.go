@@ -10,7 +10,6 @@ import (
 
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/client/container"
-	"github.com/skupperproject/skupper/client/podman"
 	"github.com/skupperproject/skupper/pkg/domain"
 	"github.com/skupperproject/skupper/pkg/qdr"
 	"github.com/skupperproject/skupper/pkg/utils"
@@ -18,7 +17,7 @@ import (
 )
 
 type LinkHandler struct {
-	cli                  *podman.PodmanRestClient
+	cli                  container.EngineClient
 	routerCfgHandler     qdr.RouterConfigHandler
 	routerManager        domain.RouterEntityManager
 	credHandler          *CredentialHandler
@@ -27,7 +26,7 @@ type LinkHandler struct {
 	networkStatusHandler *NetworkStatusHandler
 }
 
-func NewLinkHandlerPodman(site *Site, cli *podman.PodmanRestClient) *LinkHandler {
+func NewLinkHandlerPodman(site *Site, cli container.EngineClient) *LinkHandler {
 	l := &LinkHandler{
 		site: site,
 		cli:  cli,
@@ -35,7 +34,7 @@ func NewLinkHandlerPodman(site *Site, cli *podman.PodmanRestClient) *LinkHandler
 	l.routerCfgHandler = NewRouterConfigHandlerPodman(cli)
 	l.routerManager = NewRouterEntityManagerPodman(cli)
 	l.credHandler = NewPodmanCredentialHandler(cli)
-	l.redeemer = domain.NewClaimRedeemer("LinkHandlerPodman", site.GetId(), site.GetVersion(), l.updateClaim, l.log)
+	l.redeemer = domain.NewClaimRedeemer("LinkHandlerPodman", site.GetId(), site.GetName(), site.GetMode(), site.GetVersion(), l.updateClaim, l.log)
 	return l
 }
 
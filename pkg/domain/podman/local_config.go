@@ -3,6 +3,7 @@ package podman
 import (
 	"path"
 
+	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/pkg/config"
 )
 
@@ -14,6 +15,13 @@ type Config struct {
 	Endpoint string `yaml:"endpoint"`
 }
 
+// ConfigFileHandler reads and saves the local configuration of a
+// container engine platform
+type ConfigFileHandler interface {
+	GetConfig() (*Config, error)
+	Save(config *Config) error
+}
+
 type configFileHandler struct {
 	config *config.ConfigFileHandlerCommon
 }
@@ -32,8 +40,18 @@ func (p *configFileHandler) Save(config *Config) error {
 }
 
 func NewPodmanConfigFileHandler() *configFileHandler {
+	return NewConfigFileHandler(types.PlatformPodman)
+}
+
+// NewConfigFileHandler returns the handler of the local configuration of
+// the given container engine platform, stored as <platform>.yaml
+func NewConfigFileHandler(platform types.Platform) *configFileHandler {
+	configFile := ConfigFile
+	if platform != types.PlatformPodman || config.SiteId != "" {
+		configFile = path.Join(config.GetSiteDataHome(), string(platform)+".yaml")
+	}
 	c := &config.ConfigFileHandlerCommon{}
-	c.SetFileName(ConfigFile)
+	c.SetFileName(configFile)
 	c.SetData(&Config{})
 	p := &configFileHandler{config: c}
 	return p
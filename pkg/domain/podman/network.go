@@ -0,0 +1,76 @@
+package podman
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/skupperproject/skupper/client/container"
+)
+
+// ValidateNetworkOpts validates the subnets of the container network and
+// the addresses the site is bound to, which can be IPv4 or IPv6, the IPv6
+// addresses being accepted with or without brackets. A container network
+// with an IPv6 subnet is dual-stack, so IPv6 is enabled for it.
+func (s *Site) ValidateNetworkOpts() error {
+	for i, ip := range s.IngressBindIPs {
+		if ip == "" {
+			continue
+		}
+		ip = trimBrackets(ip)
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("invalid ingress bind ip: %s", s.IngressBindIPs[i])
+		}
+		s.IngressBindIPs[i] = ip
+	}
+	for i, host := range s.IngressHosts {
+		s.IngressHosts[i] = trimBrackets(host)
+	}
+	for i, host := range s.CertificateHosts {
+		s.CertificateHosts[i] = trimBrackets(host)
+	}
+	for _, subnet := range s.ContainerNetworkSubnets {
+		ip, _, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return fmt.Errorf("invalid container network subnet: %s", subnet)
+		}
+		if ip.To4() == nil {
+			s.EnableIPV6 = true
+		}
+	}
+	return nil
+}
+
+// bindHosts returns the specific addresses the site is bound to, so they
+// can be used to reach it
+func (s *Site) bindHosts() []string {
+	var hosts []string
+	for _, ip := range s.IngressBindIPs {
+		if parsed := net.ParseIP(ip); parsed != nil && !parsed.IsUnspecified() {
+			hosts = append(hosts, ip)
+		}
+	}
+	return hosts
+}
+
+// containerNetwork returns the definition of the container network to be
+// created for the site
+func (s *Site) containerNetwork() *container.Network {
+	network := &container.Network{
+		Name:     s.ContainerNetwork,
+		IPV6:     s.EnableIPV6,
+		DNS:      true,
+		Internal: false,
+	}
+	for _, subnet := range s.ContainerNetworkSubnets {
+		network.Subnets = append(network.Subnets, &container.Subnet{Subnet: subnet})
+	}
+	return network
+}
+
+func trimBrackets(host string) string {
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		return host[1 : len(host)-1]
+	}
+	return host
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/rogpeppe/go-internal/lockedfile"
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/client/container"
-	"github.com/skupperproject/skupper/client/podman"
 	"github.com/skupperproject/skupper/pkg/network"
 )
 
@@ -19,14 +18,15 @@ var (
 )
 
 const (
-	NetworkStatusFile = "skupper-network-status.json"
+	NetworkStatusFile        = "skupper-network-status.json"
+	NetworkStatusHistoryFile = "skupper-network-status-history.json"
 )
 
 type NetworkStatusHandler struct {
-	cli *podman.PodmanRestClient
+	cli container.EngineClient
 }
 
-func (n *NetworkStatusHandler) WithClient(cli *podman.PodmanRestClient) *NetworkStatusHandler {
+func (n *NetworkStatusHandler) WithClient(cli container.EngineClient) *NetworkStatusHandler {
 	n.cli = cli
 	return n
 }
@@ -36,20 +36,29 @@ func (n *NetworkStatusHandler) isRunningInContainer() bool {
 }
 
 func (n *NetworkStatusHandler) Update(networkStatusJson string) error {
+	return n.update(NetworkStatusFile, networkStatusJson)
+}
+
+// UpdateHistory saves the snapshots of the network kept by the collector
+func (n *NetworkStatusHandler) UpdateHistory(historyJson string) error {
+	return n.update(NetworkStatusHistoryFile, historyJson)
+}
+
+func (n *NetworkStatusHandler) update(fileName string, data string) error {
 	if n.isRunningInContainer() {
-		return n.updateInContainer([]byte(networkStatusJson))
+		return n.updateInContainer(NetworkStatusMountPoint+"/"+fileName, []byte(data))
 	}
 	// Updating through libpod
 	networkStatusVol, err := n.cli.VolumeInspect(NetworkStatusVolume)
 	if err != nil {
 		return fmt.Errorf("error retrieving %s volume: %s", NetworkStatusVolume, err)
 	}
-	_, err = networkStatusVol.CreateFile(NetworkStatusFile, []byte(networkStatusJson), true)
+	_, err = networkStatusVol.CreateFile(fileName, []byte(data), true)
 	return err
 }
 
-func (n *NetworkStatusHandler) updateInContainer(networkStatusJson []byte) error {
-	networkStatusLockFile := NetworkStatusFileInternal() + ".lock"
+func (n *NetworkStatusHandler) updateInContainer(fileName string, networkStatusJson []byte) error {
+	networkStatusLockFile := fileName + ".lock"
 	unlockFn, err := lockedfile.MutexAt(networkStatusLockFile).Lock()
 	if err != nil {
 		return fmt.Errorf("unable to unlock %s: %s", networkStatusLockFile, err)
@@ -59,38 +68,57 @@ func (n *NetworkStatusHandler) updateInContainer(networkStatusJson []byte) error
 		_ = os.Remove(networkStatusLockFile)
 	}()
 	var f *os.File
-	if f, err = os.Create(NetworkStatusFileInternal()); err == nil {
+	if f, err = os.Create(fileName); err == nil {
 		_, err = f.Write(networkStatusJson)
+		f.Close()
 	}
 	if err != nil {
-		return fmt.Errorf("error writing to %s: %s", NetworkStatusFileInternal(), err)
+		return fmt.Errorf("error writing to %s: %s", fileName, err)
 	}
 	return nil
 }
 
 func (n *NetworkStatusHandler) Get() (*network.NetworkStatusInfo, error) {
-	var networkStatusJson string
+	networkStatusJson, err := n.get(NetworkStatusFile)
+	if err != nil {
+		return nil, err
+	}
+	var networkStatus network.NetworkStatusInfo
+	err = json.Unmarshal([]byte(networkStatusJson), &networkStatus)
+	return &networkStatus, err
+}
+
+// GetHistory returns the snapshots of the network kept by the collector,
+// the history being empty until the collector saves it
+func (n *NetworkStatusHandler) GetHistory() ([]network.NetworkSnapshot, error) {
+	historyJson, err := n.get(NetworkStatusHistoryFile)
+	if err != nil {
+		return nil, err
+	}
+	return network.UnmarshalNetworkStatusHistory(historyJson)
+}
+
+func (n *NetworkStatusHandler) get(fileName string) (string, error) {
+	var data string
 	var err error
 	if n.isRunningInContainer() {
-		networkStatusJson, err = n.getInContainer()
+		data, err = n.getInContainer(NetworkStatusMountPoint + "/" + fileName)
 	} else {
 		var networkStatusVol *container.Volume
 		networkStatusVol, err = n.cli.VolumeInspect(NetworkStatusVolume)
 		if err != nil {
-			return nil, fmt.Errorf("error retrieving %s volume: %s", NetworkStatusVolume, err)
+			return "", fmt.Errorf("error retrieving %s volume: %s", NetworkStatusVolume, err)
 		}
-		networkStatusJson, err = networkStatusVol.ReadFile(NetworkStatusFile)
+		data, err = networkStatusVol.ReadFile(fileName)
 	}
 	if err != nil {
-		return nil, fmt.Errorf("error reading %s: %s", NetworkStatusVolume, err)
+		return "", fmt.Errorf("error reading %s: %s", NetworkStatusVolume, err)
 	}
-	var networkStatus network.NetworkStatusInfo
-	err = json.Unmarshal([]byte(networkStatusJson), &networkStatus)
-	return &networkStatus, err
+	return data, nil
 }
 
-func (n *NetworkStatusHandler) getInContainer() (string, error) {
-	nsData, err := os.ReadFile(NetworkStatusFileInternal())
+func (n *NetworkStatusHandler) getInContainer(fileName string) (string, error) {
+	nsData, err := os.ReadFile(fileName)
 	if err != nil {
 		return "", err
 	}
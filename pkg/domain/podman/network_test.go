@@ -0,0 +1,36 @@
+package podman
+
+import (
+	"testing"
+
+	"github.com/skupperproject/skupper/client/container"
+	"gotest.tools/assert"
+)
+
+func TestValidateNetworkOpts(t *testing.T) {
+	site := &Site{
+		IngressHosts:            []string{"[2001:db8::1]", "skupper.example.com"},
+		IngressBindIPs:          []string{"[2001:db8::1]", "0.0.0.0", ""},
+		ContainerNetwork:        "skupper",
+		ContainerNetworkSubnets: []string{"10.89.10.0/24", "fd00:10:89::/64"},
+	}
+	assert.Assert(t, site.ValidateNetworkOpts())
+	assert.DeepEqual(t, site.IngressHosts, []string{"2001:db8::1", "skupper.example.com"})
+	assert.DeepEqual(t, site.IngressBindIPs, []string{"2001:db8::1", "0.0.0.0", ""})
+	assert.DeepEqual(t, site.bindHosts(), []string{"2001:db8::1"})
+	assert.Assert(t, site.EnableIPV6)
+	assert.DeepEqual(t, site.containerNetwork(), &container.Network{
+		Name: "skupper",
+		Subnets: []*container.Subnet{
+			{Subnet: "10.89.10.0/24"},
+			{Subnet: "fd00:10:89::/64"},
+		},
+		IPV6: true,
+		DNS:  true,
+	})
+
+	site = &Site{IngressBindIPs: []string{"2001:db8::zz"}}
+	assert.ErrorContains(t, site.ValidateNetworkOpts(), "invalid ingress bind ip: 2001:db8::zz")
+	site = &Site{ContainerNetworkSubnets: []string{"10.89.10.0"}}
+	assert.ErrorContains(t, site.ValidateNetworkOpts(), "invalid container network subnet: 10.89.10.0")
+}
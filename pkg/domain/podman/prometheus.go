@@ -0,0 +1,21 @@
+package podman
+
+import (
+	"fmt"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+// prometheusUrl returns the url of the prometheus api queried by the flow
+// collector, the prometheus container being reached through its alias
+func prometheusUrl() string {
+	return fmt.Sprintf("http://%s:%d/api/v1/", types.PrometheusDeploymentName, types.PrometheusServerDefaultServicePort)
+}
+
+// enableConsoleComponents enables the flow collector serving the console,
+// which is deployed along with the prometheus server it queries
+func (s *Site) enableConsoleComponents() {
+	if s.EnableConsole {
+		s.EnableFlowCollector = true
+	}
+}
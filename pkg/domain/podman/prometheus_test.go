@@ -0,0 +1,27 @@
+package podman
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/podman"
+	"gotest.tools/assert"
+)
+
+func TestConsoleDeployments(t *testing.T) {
+	site := &Site{EnableConsole: true, ContainerNetwork: "skupper", HealthcheckInterval: 10 * time.Second}
+	site.enableConsoleComponents()
+	assert.Assert(t, site.EnableFlowCollector)
+
+	handler := NewSitePodmanHandlerFromCli(podman.NewPodmanClientMock(nil))
+	flowCollector := handler.prepareFlowCollectorDeployment(site)
+	env := flowCollector.GetComponents()[0].GetEnv()
+	assert.Equal(t, env["ENABLE_CONSOLE"], "true")
+	assert.Equal(t, env["PROMETHEUS_URL"], "http://skupper-prometheus:9090/api/v1/")
+
+	prometheus := handler.preparePrometheusDeployment(site).(*SkupperDeployment)
+	assert.DeepEqual(t, prometheus.Aliases, []string{types.PrometheusDeploymentName})
+	assert.DeepEqual(t, prometheus.Healthcheck.Command, []string{"wget", "-q", "-O", "/dev/null", "http://localhost:9090/-/ready"})
+	assert.Equal(t, prometheus.VolumeMounts["prometheus-storage-volume"], "/prometheus")
+}
@@ -0,0 +1,178 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/pkg/images"
+	"github.com/skupperproject/skupper/pkg/utils"
+)
+
+const (
+	// PublishContainer makes the service reachable only from the containers
+	// connected to the site network, through the name of its container
+	PublishContainer = "container"
+	// PublishHost publishes the ports of the service on ports of the host,
+	// bound to all the addresses of the host or to the given ones
+	PublishHost = "host"
+	// PublishSocket makes the service reachable through a unix socket per
+	// port, created in a directory of the host
+	PublishSocket = "socket"
+
+	PublishQualifier   = types.InternalQualifier + "/publish"
+	SocketDirQualifier = types.InternalQualifier + "/socket-dir"
+	SocketQualifier    = types.InternalQualifier + "/socket"
+
+	// socketMountPath is where the directory of the sockets is mounted in the
+	// socket proxy containers
+	socketMountPath = "/run/skupper/sockets"
+)
+
+var ValidPublishStrategies = []string{PublishContainer, PublishHost, PublishSocket}
+
+// GetPublish returns how the service is reachable from the host, services
+// defined before publish strategies existed are published on the host only
+// when host ports are bound
+func (s *Service) GetPublish() string {
+	if s.Publish != "" {
+		return s.Publish
+	}
+	if len(s.ContainerPorts()) > 0 {
+		return PublishHost
+	}
+	return PublishContainer
+}
+
+// ValidatePublish verifies the publish strategy of the service is consistent
+// with the host bindings it defines
+func (s *Service) ValidatePublish() error {
+	if s.Publish != "" && !utils.StringSliceContains(ValidPublishStrategies, s.Publish) {
+		return fmt.Errorf("invalid publish strategy: %s - valid strategies are: %s", s.Publish, ValidPublishStrategies)
+	}
+	hostBindings := len(s.ContainerPorts()) > 0 || len(s.HostIPs) > 0
+	switch s.Publish {
+	case PublishContainer:
+		if hostBindings {
+			return fmt.Errorf("host ports and ips cannot be bound when the service is published on the container network only")
+		}
+	case PublishSocket:
+		if hostBindings {
+			return fmt.Errorf("host ports and ips cannot be bound when the service is published through unix sockets")
+		}
+		if s.SocketDir == "" || !filepath.IsAbs(s.SocketDir) {
+			return fmt.Errorf("an absolute socket directory is required to publish the service through unix sockets")
+		}
+	}
+	if s.Publish != PublishSocket && s.SocketDir != "" {
+		return fmt.Errorf("a socket directory can only be set when the service is published through unix sockets")
+	}
+	return nil
+}
+
+// SocketPath returns the path of the unix socket, on the host, the given
+// port of the service is reachable through
+func (s *Service) SocketPath(port int) string {
+	return path.Join(s.SocketDir, s.socketFile(port))
+}
+
+func (s *Service) socketFile(port int) string {
+	return fmt.Sprintf("%s-%d.sock", s.GetAddress(), port)
+}
+
+func (s *Service) socketContainerName(port int) string {
+	return fmt.Sprintf("%s-socket-%d", s.GetContainerName(), port)
+}
+
+// publishLabels returns the labels of the service container describing how
+// the service is published
+func (s *Service) publishLabels() map[string]string {
+	labels := map[string]string{}
+	if s.Publish != "" {
+		labels[PublishQualifier] = s.Publish
+	}
+	if s.SocketDir != "" {
+		labels[SocketDirQualifier] = s.SocketDir
+	}
+	return labels
+}
+
+// createSocketProxies runs a container per port of the service listening on
+// a unix socket of the host and proxying the connections to the service
+// container through the site network
+func (s *ServiceHandler) createSocketProxies(svc *Service, networks []string) error {
+	image := images.GetSocatImageName()
+	if err := s.cli.ImagePull(context.Background(), image); err != nil {
+		return fmt.Errorf("error pulling image %s - %w", image, err)
+	}
+	ports := append([]int{}, svc.GetPorts()...)
+	sort.Ints(ports)
+	for _, port := range ports {
+		c := &container.Container{
+			Name:  svc.socketContainerName(port),
+			Image: image,
+			Command: []string{
+				fmt.Sprintf("UNIX-LISTEN:%s,fork,unlink-early,mode=666", path.Join(socketMountPath, svc.socketFile(port))),
+				fmt.Sprintf("TCP:%s:%d", svc.GetContainerName(), port),
+			},
+			Labels: map[string]string{
+				types.ComponentAnnotation: svc.socketContainerName(port),
+				SocketQualifier:           svc.GetAddress(),
+			},
+			FileMounts: []container.FileMount{
+				{
+					Source:      svc.SocketDir,
+					Destination: socketMountPath,
+					Options:     []string{"z"},
+				},
+			},
+			Networks:      map[string]container.ContainerNetworkInfo{},
+			RestartPolicy: "always",
+		}
+		for _, network := range networks {
+			c.Networks[network] = container.ContainerNetworkInfo{ID: network}
+		}
+		if err := s.cli.ContainerCreate(c); err != nil {
+			return fmt.Errorf("error creating container %s - %w", c.Name, err)
+		}
+		if err := s.cli.ContainerStart(c.Name); err != nil {
+			return fmt.Errorf("error starting container %s - %w", c.Name, err)
+		}
+	}
+	return nil
+}
+
+// removeSocketProxies removes the socket proxy containers of the service
+func (s *ServiceHandler) removeSocketProxies(address string) {
+	containers, err := s.cli.ContainerList()
+	if err != nil {
+		return
+	}
+	for _, c := range containers {
+		if c.Labels[SocketQualifier] != address {
+			continue
+		}
+		_ = s.cli.ContainerStop(c.Name)
+		_ = s.cli.ContainerRemove(c.Name)
+	}
+}
+
+// readPublish restores how the service is published from the labels and the
+// ports of its container
+func (s *Service) readPublish(c *container.Container) {
+	s.Publish = c.Labels[PublishQualifier]
+	s.SocketDir = c.Labels[SocketDirQualifier]
+	var hostIPs []string
+	for _, port := range c.Ports {
+		if !utils.StringSliceContains(hostIPs, port.HostIP) {
+			hostIPs = append(hostIPs, port.HostIP)
+		}
+	}
+	if len(hostIPs) > 1 {
+		s.HostIPs = hostIPs
+	}
+}
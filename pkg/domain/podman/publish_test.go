@@ -0,0 +1,83 @@
+package podman
+
+import (
+	"testing"
+
+	"github.com/skupperproject/skupper/pkg/domain"
+	"gotest.tools/assert"
+)
+
+func TestValidatePublish(t *testing.T) {
+	testTable := []struct {
+		doc       string
+		publish   string
+		hostPorts map[int]int
+		hostIPs   []string
+		socketDir string
+		expected  string
+		err       string
+	}{
+		{doc: "no host bindings", expected: PublishContainer},
+		{doc: "host ports bound", hostPorts: map[int]int{8080: 18080}, expected: PublishHost},
+		{doc: "container", publish: PublishContainer, expected: PublishContainer},
+		{doc: "container with host ports", publish: PublishContainer, hostPorts: map[int]int{8080: 18080},
+			err: "host ports and ips cannot be bound when the service is published on the container network only"},
+		{doc: "host ips", publish: PublishHost, hostPorts: map[int]int{8080: 8080}, hostIPs: []string{"127.0.0.1", "::1"}, expected: PublishHost},
+		{doc: "socket", publish: PublishSocket, socketDir: "/run/user/1000/skupper", expected: PublishSocket},
+		{doc: "socket without directory", publish: PublishSocket,
+			err: "an absolute socket directory is required to publish the service through unix sockets"},
+		{doc: "socket with relative directory", publish: PublishSocket, socketDir: "sockets",
+			err: "an absolute socket directory is required to publish the service through unix sockets"},
+		{doc: "socket with host ports", publish: PublishSocket, socketDir: "/run/user/1000/skupper", hostPorts: map[int]int{8080: 18080},
+			err: "host ports and ips cannot be bound when the service is published through unix sockets"},
+		{doc: "socket directory when published on the host", publish: PublishHost, socketDir: "/run/user/1000/skupper",
+			err: "a socket directory can only be set when the service is published through unix sockets"},
+		{doc: "invalid", publish: "nodeport",
+			err: "invalid publish strategy: nodeport"},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			svc := &Service{
+				ServiceCommon: &domain.ServiceCommon{
+					Address: "backend",
+					Ports:   []int{8080},
+					Ingress: &domain.AddressIngressCommon{},
+				},
+				Publish:   test.publish,
+				HostIPs:   test.hostIPs,
+				SocketDir: test.socketDir,
+			}
+			if test.hostPorts != nil {
+				svc.Ingress.SetPorts(test.hostPorts)
+			}
+			err := svc.ValidatePublish()
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			assert.Equal(t, svc.GetPublish(), test.expected)
+		})
+	}
+}
+
+func TestContainerPortsHostIPs(t *testing.T) {
+	svc := &Service{
+		ServiceCommon: &domain.ServiceCommon{
+			Address: "backend",
+			Ports:   []int{8080},
+			Ingress: &domain.AddressIngressCommon{},
+		},
+		HostIPs: []string{"127.0.0.1", "::1"},
+	}
+	svc.Ingress.SetPorts(map[int]int{8080: 18080})
+	ports := svc.ContainerPorts()
+	assert.Equal(t, len(ports), 2)
+	for i, hostIP := range svc.HostIPs {
+		assert.Equal(t, ports[i].HostIP, hostIP)
+		assert.Equal(t, ports[i].Host, "18080")
+		assert.Equal(t, ports[i].Target, "8080")
+	}
+	svc.SocketDir = "/run/user/1000/skupper"
+	assert.Equal(t, svc.SocketPath(8080), "/run/user/1000/skupper/backend-8080.sock")
+}
@@ -0,0 +1,78 @@
+package podman
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/skupperproject/skupper/api/types"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// memoryUnitRegex matches the memory limits given like through podman's
+// --memory flag, as in 512m or 1g (binary units). Only the lower case units
+// are matched, the upper case ones being the decimal units of the kubernetes
+// quantities.
+var memoryUnitRegex = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([bkmgt])b?$`)
+
+var memoryUnits = map[string]float64{
+	"b": 1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+	"t": 1 << 40,
+}
+
+// ParseCpuLimit parses a cpu limit given as a number of cpus (0.5, 2) or
+// as a kubernetes quantity (500m)
+func ParseCpuLimit(cpuLimit string) (float64, error) {
+	if cpuLimit == "" {
+		return 0, nil
+	}
+	cpus, err := strconv.ParseFloat(cpuLimit, 64)
+	if err != nil {
+		quantity, qErr := resource.ParseQuantity(cpuLimit)
+		if qErr != nil {
+			return 0, fmt.Errorf("invalid cpu limit: %s", cpuLimit)
+		}
+		cpus = float64(quantity.MilliValue()) / 1000
+	}
+	if cpus < 0 {
+		return 0, fmt.Errorf("invalid cpu limit: %s", cpuLimit)
+	}
+	return cpus, nil
+}
+
+// ParseMemoryLimit parses a memory limit given in bytes, with a lower case
+// podman unit (512m and 1g, binary units) or as a kubernetes quantity (512Mi
+// and 1Gi, binary units, or 512M and 1G, decimal units)
+func ParseMemoryLimit(memoryLimit string) (int64, error) {
+	if memoryLimit == "" {
+		return 0, nil
+	}
+	if bytes, err := strconv.ParseInt(memoryLimit, 10, 64); err == nil && bytes >= 0 {
+		return bytes, nil
+	}
+	if match := memoryUnitRegex.FindStringSubmatch(memoryLimit); match != nil {
+		value, _ := strconv.ParseFloat(match[1], 64)
+		return int64(value * memoryUnits[match[2]]), nil
+	}
+	quantity, err := resource.ParseQuantity(memoryLimit)
+	if err != nil || quantity.Sign() < 0 {
+		return 0, fmt.Errorf("invalid memory limit: %s", memoryLimit)
+	}
+	return quantity.Value(), nil
+}
+
+// tuningLimits returns the cpus and the bytes of memory a component is
+// limited to, the limits being validated when the site is created
+func tuningLimits(tuning types.Tuning) (float64, int64) {
+	cpus, _ := ParseCpuLimit(tuning.CpuLimit)
+	memoryLimit, _ := ParseMemoryLimit(tuning.MemoryLimit)
+	return cpus, memoryLimit
+}
+
+// formatCpuLimit returns the cpu limit of a component as given to the cli
+func formatCpuLimit(cpus float64) string {
+	return strconv.FormatFloat(cpus, 'f', -1, 64)
+}
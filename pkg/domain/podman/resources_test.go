@@ -0,0 +1,77 @@
+package podman
+
+import (
+	"testing"
+
+	"github.com/skupperproject/skupper/api/types"
+	"gotest.tools/assert"
+)
+
+func TestParseCpuLimit(t *testing.T) {
+	testTable := []struct {
+		cpuLimit string
+		expected float64
+		err      string
+	}{
+		{cpuLimit: "", expected: 0},
+		{cpuLimit: "2", expected: 2},
+		{cpuLimit: "0.5", expected: 0.5},
+		{cpuLimit: "500m", expected: 0.5},
+		{cpuLimit: "1500m", expected: 1.5},
+		{cpuLimit: "-1", err: "invalid cpu limit: -1"},
+		{cpuLimit: "two", err: "invalid cpu limit: two"},
+	}
+	for _, test := range testTable {
+		t.Run(test.cpuLimit, func(t *testing.T) {
+			cpus, err := ParseCpuLimit(test.cpuLimit)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			assert.Equal(t, cpus, test.expected)
+		})
+	}
+}
+
+func TestParseMemoryLimit(t *testing.T) {
+	testTable := []struct {
+		memoryLimit string
+		expected    int64
+		err         string
+	}{
+		{memoryLimit: "", expected: 0},
+		{memoryLimit: "1073741824", expected: 1073741824},
+		{memoryLimit: "512k", expected: 512 * 1024},
+		{memoryLimit: "512m", expected: 512 * 1024 * 1024},
+		{memoryLimit: "512mb", expected: 512 * 1024 * 1024},
+		{memoryLimit: "1g", expected: 1024 * 1024 * 1024},
+		{memoryLimit: "1.5g", expected: 1536 * 1024 * 1024},
+		{memoryLimit: "1G", expected: 1000 * 1000 * 1000},
+		{memoryLimit: "512M", expected: 512 * 1000 * 1000},
+		{memoryLimit: "512Mi", expected: 512 * 1024 * 1024},
+		{memoryLimit: "2Gi", expected: 2 * 1024 * 1024 * 1024},
+		{memoryLimit: "-1", err: "invalid memory limit: -1"},
+		{memoryLimit: "lots", err: "invalid memory limit: lots"},
+		{memoryLimit: "512MB", err: "invalid memory limit: 512MB"},
+	}
+	for _, test := range testTable {
+		t.Run(test.memoryLimit, func(t *testing.T) {
+			bytes, err := ParseMemoryLimit(test.memoryLimit)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			assert.Equal(t, bytes, test.expected)
+		})
+	}
+}
+
+func TestTuningLimits(t *testing.T) {
+	cpus, memoryLimit := tuningLimits(types.Tuning{CpuLimit: "250m", MemoryLimit: "256m"})
+	assert.Equal(t, cpus, 0.25)
+	assert.Equal(t, memoryLimit, int64(256*1024*1024))
+	assert.Equal(t, formatCpuLimit(cpus), "0.25")
+	assert.Equal(t, formatCpuLimit(2), "2")
+}
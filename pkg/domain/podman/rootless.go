@@ -0,0 +1,190 @@
+package podman
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/skupperproject/skupper/client/podman"
+	"github.com/skupperproject/skupper/pkg/config"
+)
+
+const (
+	// PrivilegedPortsRemap binds the ports below the first unprivileged
+	// port of a rootless engine to a higher port instead
+	PrivilegedPortsRemap = "remap"
+	// PrivilegedPortsFail refuses to create a site binding ports below the
+	// first unprivileged port of a rootless engine
+	PrivilegedPortsFail = "fail"
+	// PrivilegedPortsSocketActivation binds the privileged ports through
+	// system socket units, forwarding their connections to the remapped
+	// ports bound by the rootless containers
+	PrivilegedPortsSocketActivation = "socket-activation"
+
+	// privilegedPortOffset is added to the privileged ports remapped, so 443
+	// becomes 8443
+	privilegedPortOffset = 8000
+)
+
+// UnprivilegedPortStartFile holds the first port that users are allowed
+// to bind on the local host
+var UnprivilegedPortStartFile = "/proc/sys/net/ipv4/ip_unprivileged_port_start"
+
+// unprivilegedPortStart returns the first port a rootless container engine
+// can bind on the local host
+func unprivilegedPortStart() int {
+	data, err := os.ReadFile(UnprivilegedPortStartFile)
+	if err != nil {
+		return 1024
+	}
+	start, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 1024
+	}
+	return start
+}
+
+type bindPort struct {
+	name string
+	port *int
+}
+
+// bindPorts returns the ports of the host bound by the site
+func (s *Site) bindPorts() []bindPort {
+	var ports []bindPort
+	if len(s.IngressHosts) > 0 {
+		ports = append(ports,
+			bindPort{name: "inter-router", port: &s.IngressBindInterRouterPort},
+			bindPort{name: "edge", port: &s.IngressBindEdgePort},
+		)
+	}
+	if s.EnableFlowCollector {
+		ports = append(ports, bindPort{name: "flow collector", port: &s.IngressBindFlowCollectorPort})
+	}
+	if s.EnableMetricsExporter {
+		ports = append(ports, bindPort{name: "metrics exporter", port: &s.IngressBindMetricsExporterPort})
+	}
+	return ports
+}
+
+// ValidatePrivilegedPorts verifies the site binds no port below the first
+// unprivileged port of a rootless engine, remapping them unless told to
+// fail, and returns what has been remapped
+func (s *Site) ValidatePrivilegedPorts(unprivilegedPortStart int) ([]string, error) {
+	var remapped []string
+	for _, bp := range s.bindPorts() {
+		port := *bp.port
+		if port <= 0 || port >= unprivilegedPortStart {
+			continue
+		}
+		sysctl := fmt.Sprintf("sudo sysctl -w net.ipv4.ip_unprivileged_port_start=%d", port)
+		newPort := port + privilegedPortOffset
+		if s.PrivilegedPorts == PrivilegedPortsFail || newPort < unprivilegedPortStart || newPort > 65535 {
+			return nil, fmt.Errorf("rootless %s cannot bind the %s port %d (ports below %d are privileged), "+
+				"use a port above %d or allow it with: %s", s.GetPlatform(), bp.name, port,
+				unprivilegedPortStart, unprivilegedPortStart-1, sysctl)
+		}
+		for _, other := range s.bindPorts() {
+			if *other.port == newPort {
+				return nil, fmt.Errorf("rootless %s cannot bind the %s port %d and the port it would be remapped to, %d, is used by the %s port, "+
+					"use a port above %d or allow it with: %s", s.GetPlatform(), bp.name, port, newPort, other.name,
+					unprivilegedPortStart-1, sysctl)
+			}
+		}
+		*bp.port = newPort
+		if s.PrivilegedPorts == PrivilegedPortsSocketActivation {
+			s.socketForwards = append(s.socketForwards, s.socketForward(bp.name, port, newPort))
+			remapped = append(remapped, fmt.Sprintf("The %s port %d is privileged for rootless %s, it is bound to %d "+
+				"and %d is accepted through socket activation", bp.name, port, s.GetPlatform(), newPort, port))
+			continue
+		}
+		remapped = append(remapped, fmt.Sprintf("The %s port %d is privileged for rootless %s and has been remapped to %d, "+
+			"forward %d to it or allow it with: %s", bp.name, port, s.GetPlatform(), newPort, port, sysctl))
+	}
+	return remapped, nil
+}
+
+// socketForward returns how the connections to a privileged port are
+// forwarded to the port bound by the container, on the same addresses
+func (s *Site) socketForward(name string, port int, targetPort int) config.SocketForward {
+	forward := config.SocketForward{
+		Name:   strings.ReplaceAll(name, " ", "-"),
+		Target: net.JoinHostPort("127.0.0.1", strconv.Itoa(targetPort)),
+	}
+	for _, ip := range s.IngressBindIPs {
+		if ip == "" || ip == "0.0.0.0" || ip == "::" {
+			continue
+		}
+		forward.Listen = append(forward.Listen, net.JoinHostPort(ip, strconv.Itoa(port)))
+	}
+	if len(forward.Listen) == 0 {
+		forward.Listen = []string{strconv.Itoa(port)}
+	} else {
+		host, _, _ := net.SplitHostPort(forward.Listen[0])
+		forward.Target = net.JoinHostPort(host, strconv.Itoa(targetPort))
+	}
+	return forward
+}
+
+func (s *Site) ValidatePrivilegedPortsOpt() error {
+	switch s.PrivilegedPorts {
+	case "", PrivilegedPortsRemap, PrivilegedPortsFail, PrivilegedPortsSocketActivation:
+		return nil
+	}
+	return fmt.Errorf("invalid privileged ports handling: %s - valid values: %s, %s, %s", s.PrivilegedPorts,
+		PrivilegedPortsRemap, PrivilegedPortsFail, PrivilegedPortsSocketActivation)
+}
+
+// handlePrivilegedPorts verifies the ports bound by a site running on a
+// local rootless engine, as the unprivileged port range of remote hosts
+// is unknown
+func (s *SiteHandler) handlePrivilegedPorts(site *Site) error {
+	if s.cli == nil || !s.cli.IsSockEndpoint() || podman.IsSSHEndpoint(s.endpoint) {
+		return nil
+	}
+	version, err := s.cli.Version()
+	if err != nil || !version.Rootless {
+		return nil
+	}
+	remapped, err := site.ValidatePrivilegedPorts(unprivilegedPortStart())
+	if err != nil {
+		return err
+	}
+	for _, msg := range remapped {
+		fmt.Println(msg)
+	}
+	return nil
+}
+
+// createSocketUnits installs the socket units accepting the privileged
+// ports of the site, or tells how to install them when not running as root
+func (s *SiteHandler) createSocketUnits(site *Site) error {
+	if len(site.socketForwards) == 0 {
+		return nil
+	}
+	commands, err := config.NewSystemdSocketUnits(site.socketForwards...).Create()
+	if err != nil {
+		return fmt.Errorf("error creating socket units: %w", err)
+	}
+	if len(commands) > 0 {
+		fmt.Println("The privileged ports are accepted by system socket units, install them as root with:")
+		for _, command := range commands {
+			fmt.Println("  " + command)
+		}
+	}
+	return nil
+}
+
+// removeSocketUnits removes the socket units accepting the privileged ports
+// of the site, or tells how to remove them when not running as root
+func (s *SiteHandler) removeSocketUnits() {
+	commands := config.NewSystemdSocketUnits().Remove()
+	if len(commands) > 0 {
+		fmt.Println("The socket units accepting the privileged ports must be removed as root with:")
+		for _, command := range commands {
+			fmt.Println("  " + command)
+		}
+	}
+}
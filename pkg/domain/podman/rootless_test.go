@@ -0,0 +1,88 @@
+package podman
+
+import (
+	"testing"
+
+	"github.com/skupperproject/skupper/pkg/config"
+	"github.com/skupperproject/skupper/pkg/domain"
+	"gotest.tools/assert"
+)
+
+func TestValidatePrivilegedPorts(t *testing.T) {
+	testTable := []struct {
+		doc             string
+		interRouterPort int
+		edgePort        int
+		flowCollector   bool
+		mode            string
+		expectedPort    int
+		remapped        int
+		err             string
+	}{
+		{doc: "unprivileged", interRouterPort: 55671, edgePort: 45671, expectedPort: 55671},
+		{doc: "remapped", interRouterPort: 443, edgePort: 45671, mode: PrivilegedPortsRemap, expectedPort: 8443, remapped: 1},
+		{doc: "remap by default", interRouterPort: 443, edgePort: 45671, mode: "", expectedPort: 8443, remapped: 1},
+		{doc: "fail", interRouterPort: 443, edgePort: 45671, mode: PrivilegedPortsFail,
+			err: "rootless podman cannot bind the inter-router port 443 (ports below 1024 are privileged), use a port above 1023 or allow it with: sudo sysctl -w net.ipv4.ip_unprivileged_port_start=443"},
+		{doc: "remapped port in use", interRouterPort: 443, edgePort: 8443, mode: PrivilegedPortsRemap,
+			err: "the port it would be remapped to, 8443, is used by the edge port"},
+		{doc: "flow collector", interRouterPort: 55671, edgePort: 45671, flowCollector: true, expectedPort: 55671, remapped: 1},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			site := &Site{
+				SiteCommon:                   &domain.SiteCommon{},
+				IngressHosts:                 []string{"127.0.0.1"},
+				IngressBindInterRouterPort:   test.interRouterPort,
+				IngressBindEdgePort:          test.edgePort,
+				EnableFlowCollector:          test.flowCollector,
+				IngressBindFlowCollectorPort: 443,
+				PrivilegedPorts:              test.mode,
+			}
+			remapped, err := site.ValidatePrivilegedPorts(1024)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			assert.Equal(t, len(remapped), test.remapped)
+			assert.Equal(t, site.IngressBindInterRouterPort, test.expectedPort)
+			if test.flowCollector {
+				assert.Equal(t, site.IngressBindFlowCollectorPort, 8443)
+			}
+		})
+	}
+}
+
+func TestSocketActivatedPorts(t *testing.T) {
+	site := &Site{
+		SiteCommon:                 &domain.SiteCommon{},
+		IngressHosts:               []string{"10.0.0.1"},
+		IngressBindIPs:             []string{"10.0.0.1"},
+		IngressBindInterRouterPort: 443,
+		IngressBindEdgePort:        45671,
+		PrivilegedPorts:            PrivilegedPortsSocketActivation,
+	}
+	assert.Assert(t, site.ValidatePrivilegedPortsOpt())
+	remapped, err := site.ValidatePrivilegedPorts(1024)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, remapped, []string{
+		"The inter-router port 443 is privileged for rootless podman, it is bound to 8443 and 443 is accepted through socket activation",
+	})
+	assert.Equal(t, site.IngressBindInterRouterPort, 8443)
+	assert.DeepEqual(t, site.socketForwards, []config.SocketForward{
+		{Name: "inter-router", Listen: []string{"10.0.0.1:443"}, Target: "10.0.0.1:8443"},
+	})
+
+	site = &Site{
+		SiteCommon:                   &domain.SiteCommon{},
+		EnableFlowCollector:          true,
+		IngressBindFlowCollectorPort: 443,
+		PrivilegedPorts:              PrivilegedPortsSocketActivation,
+	}
+	_, err = site.ValidatePrivilegedPorts(1024)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, site.socketForwards, []config.SocketForward{
+		{Name: "flow-collector", Listen: []string{"443"}, Target: "127.0.0.1:8443"},
+	})
+}
@@ -6,20 +6,20 @@ import (
 	"strings"
 
 	"github.com/skupperproject/skupper/api/types"
-	"github.com/skupperproject/skupper/client/podman"
+	"github.com/skupperproject/skupper/client/container"
 	"github.com/skupperproject/skupper/pkg/qdr"
 )
 
 type RouterEntityManager struct {
-	cli       *podman.PodmanRestClient
+	cli       container.EngineClient
 	container string
 }
 
-func NewRouterEntityManagerPodman(cli *podman.PodmanRestClient) *RouterEntityManager {
+func NewRouterEntityManagerPodman(cli container.EngineClient) *RouterEntityManager {
 	return NewRouterEntityManagerPodmanFor(cli, types.TransportDeploymentName)
 }
 
-func NewRouterEntityManagerPodmanFor(cli *podman.PodmanRestClient, container string) *RouterEntityManager {
+func NewRouterEntityManagerPodmanFor(cli container.EngineClient, container string) *RouterEntityManager {
 	return &RouterEntityManager{
 		cli:       cli,
 		container: container,
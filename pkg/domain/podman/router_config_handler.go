@@ -7,16 +7,14 @@ import (
 
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/client/container"
-	"github.com/skupperproject/skupper/client/generated/libpod/client/volumes"
-	"github.com/skupperproject/skupper/client/podman"
 	"github.com/skupperproject/skupper/pkg/qdr"
 )
 
 type RouterConfigHandler struct {
-	cli *podman.PodmanRestClient
+	cli container.EngineClient
 }
 
-func NewRouterConfigHandlerPodman(cli *podman.PodmanRestClient) *RouterConfigHandler {
+func NewRouterConfigHandlerPodman(cli container.EngineClient) *RouterConfigHandler {
 	return &RouterConfigHandler{
 		cli: cli,
 	}
@@ -58,7 +56,7 @@ func (r *RouterConfigHandler) SaveRouterConfig(config *qdr.RouterConfig) error {
 	var configVolume *container.Volume
 	configVolume, err := r.cli.VolumeInspect(types.TransportConfigMapName)
 	if err != nil {
-		if _, notFound := err.(*volumes.VolumeInspectLibpodNotFound); !notFound {
+		if !isVolumeNotFound(err) {
 			return fmt.Errorf("error retrieving volume %s - %v", types.TransportConfigMapName, err)
 		}
 		// try to create volume since not found given
@@ -82,7 +80,7 @@ func (r *RouterConfigHandler) RemoveRouterConfig() error {
 	if err == nil {
 		return nil
 	}
-	if _, notFound := err.(*volumes.VolumeInspectLibpodNotFound); notFound {
+	if isVolumeNotFound(err) {
 		return nil
 	}
 	return fmt.Errorf("error removing router config - %v", err)
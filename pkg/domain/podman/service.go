@@ -10,7 +10,6 @@ import (
 	"github.com/rogpeppe/go-internal/lockedfile"
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/client/container"
-	"github.com/skupperproject/skupper/client/podman"
 	"github.com/skupperproject/skupper/pkg/certs"
 	"github.com/skupperproject/skupper/pkg/domain"
 	"github.com/skupperproject/skupper/pkg/images"
@@ -35,6 +34,12 @@ var (
 type Service struct {
 	*domain.ServiceCommon
 	ContainerName string
+	// Publish is how the service is reachable from the host
+	Publish string
+	// HostIPs are the addresses of the host the ports are published on,
+	// when more than the one of the ingress
+	HostIPs   []string
+	SocketDir string
 }
 
 func (s *Service) GetContainerName() string {
@@ -43,15 +48,16 @@ func (s *Service) GetContainerName() string {
 
 func (s *Service) AsServiceInterface() *types.ServiceInterface {
 	svc := &types.ServiceInterface{
-		Address:        s.Address,
-		Protocol:       s.Protocol,
-		Ports:          s.Ports,
-		EventChannel:   s.EventChannel,
-		Aggregate:      s.Aggregate,
-		Labels:         s.Labels,
-		Targets:        []types.ServiceInterfaceTarget{},
-		Origin:         s.Origin,
-		TlsCredentials: s.TlsCredentials,
+		Address:          s.Address,
+		Protocol:         s.Protocol,
+		Ports:            s.Ports,
+		EventChannel:     s.EventChannel,
+		Aggregate:        s.Aggregate,
+		AggregateOptions: s.AggregateOptions,
+		Labels:           s.Labels,
+		Targets:          []types.ServiceInterfaceTarget{},
+		Origin:           s.Origin,
+		TlsCredentials:   s.TlsCredentials,
 	}
 
 	for _, egressResolver := range s.EgressResolvers {
@@ -74,21 +80,30 @@ func (s *Service) ContainerPorts() []container.Port {
 		if hostPort == 0 {
 			continue
 		}
-		ports = append(ports, container.Port{
-			Host:   strconv.Itoa(hostPort),
-			HostIP: s.GetIngress().GetHost(),
-			Target: strconv.Itoa(port),
-		})
+		for _, hostIP := range s.hostIPs() {
+			ports = append(ports, container.Port{
+				Host:   strconv.Itoa(hostPort),
+				HostIP: hostIP,
+				Target: strconv.Itoa(port),
+			})
+		}
 	}
 	return ports
 }
 
+func (s *Service) hostIPs() []string {
+	if len(s.HostIPs) > 0 {
+		return s.HostIPs
+	}
+	return []string{s.GetIngress().GetHost()}
+}
+
 type ServiceHandler struct {
-	cli     *podman.PodmanRestClient
+	cli     container.EngineClient
 	handler *ServiceInterfaceHandler
 }
 
-func NewServiceHandlerPodman(cli *podman.PodmanRestClient) *ServiceHandler {
+func NewServiceHandlerPodman(cli container.EngineClient) *ServiceHandler {
 	return &ServiceHandler{
 		cli:     cli,
 		handler: NewServiceInterfaceHandlerPodman(cli),
@@ -122,11 +137,17 @@ func (s *ServiceHandler) validateNewService(servicePodman *Service) error {
 		return fmt.Errorf("a container named %s already exists", servicePodman.GetContainerName())
 	}
 
+	if err := servicePodman.ValidatePublish(); err != nil {
+		return err
+	}
+
 	// Validating if ingress ports are available
 	if servicePodman.Ingress != nil && servicePodman.Ingress.GetPorts() != nil && len(servicePodman.Ingress.GetPorts()) > 0 {
 		for port, hostPort := range servicePodman.Ingress.GetPorts() {
-			if utils.TcpPortInUse(servicePodman.Ingress.GetHost(), hostPort) {
-				return fmt.Errorf("ingress port %d is already in use", hostPort)
+			for _, hostIP := range servicePodman.hostIPs() {
+				if utils.TcpPortInUse(hostIP, hostPort) {
+					return fmt.Errorf("ingress port %d is already in use", hostPort)
+				}
 			}
 			if !utils.IntSliceContains(servicePodman.Ports, port) {
 				return fmt.Errorf("service does not specify mapped port %d", port)
@@ -160,10 +181,7 @@ func (s *ServiceHandler) createService(servicePodman *Service) error {
 	}()
 
 	// Site handler instance
-	siteHandler, err := NewSitePodmanHandler("")
-	if err != nil {
-		return fmt.Errorf("error verifying site - %w", err)
-	}
+	siteHandler := NewSitePodmanHandlerFromCli(s.cli)
 	site, err := siteHandler.Get()
 	if err != nil {
 		return fmt.Errorf("error retrieving site info - %w", err)
@@ -248,8 +266,7 @@ func (s *ServiceHandler) createService(servicePodman *Service) error {
 	}
 	site.GetDeployments()[0].GetComponents()[0].GetImage()
 	podmanSite := site.(*Site)
-	cpuLimit, _ := strconv.Atoi(podmanSite.RouterOpts.CpuLimit)
-	memoryLimit, _ := strconv.ParseInt(podmanSite.RouterOpts.MemoryLimit, 10, 64)
+	cpuLimit, memoryLimit := tuningLimits(podmanSite.RouterOpts.Tuning)
 	c := &container.Container{
 		Name:  servicePodman.GetContainerName(),
 		Image: utils.DefaultStr(routerContainer.Image, images.GetRouterImageName()),
@@ -267,7 +284,7 @@ func (s *ServiceHandler) createService(servicePodman *Service) error {
 		Mounts:         routerContainer.Mounts,
 		Networks:       map[string]container.ContainerNetworkInfo{},
 		Ports:          servicePodman.ContainerPorts(),
-		RestartPolicy:  "always",
+		RestartPolicy:  podmanSite.GetRestartPolicy(),
 	}
 	for netName, _ := range routerContainer.Networks {
 		c.Networks[netName] = container.ContainerNetworkInfo{
@@ -277,6 +294,9 @@ func (s *ServiceHandler) createService(servicePodman *Service) error {
 	for l, v := range servicePodman.Labels {
 		c.Labels[l] = v
 	}
+	for l, v := range servicePodman.publishLabels() {
+		c.Labels[l] = v
+	}
 	err = s.cli.ContainerCreate(c)
 	if err != nil {
 		return fmt.Errorf("error creating container %s - %w", c.Name, err)
@@ -290,6 +310,16 @@ func (s *ServiceHandler) createService(servicePodman *Service) error {
 	if err != nil {
 		return fmt.Errorf("error starting container %s - %w", c.Name, err)
 	}
+
+	// Publishing the service through unix sockets of the host
+	if servicePodman.GetPublish() == PublishSocket {
+		cleanupFns = append(cleanupFns, func() {
+			s.removeSocketProxies(servicePodman.GetAddress())
+		})
+		if err = s.createSocketProxies(servicePodman, c.NetworkNames()); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -310,6 +340,9 @@ func (s *ServiceHandler) Delete(address string) error {
 		return fmt.Errorf("container %s is not managed by Skupper", svcPodman.ContainerName)
 	}
 
+	// Remove the socket proxies of services published through unix sockets
+	s.removeSocketProxies(address)
+
 	// Stop container
 	_ = s.cli.ContainerStop(svcPodman.GetContainerName())
 
@@ -339,6 +372,46 @@ func (s *ServiceHandler) Delete(address string) error {
 	return nil
 }
 
+// UpdateIngress publishes the ports of the service on the host, as given by
+// the ingress, replacing the container of the service as the ports of an
+// existing container cannot be changed
+func (s *ServiceHandler) UpdateIngress(address string, ingress domain.AddressIngress) error {
+	svc, err := s.Get(address)
+	if err != nil {
+		return err
+	}
+	svcPodman := svc.(*Service)
+	if svcPodman.ContainerName == "" {
+		return fmt.Errorf("service %s is not defined on this site", address)
+	}
+	if svcPodman.GetPublish() == PublishSocket {
+		return fmt.Errorf("service %s is published through unix sockets", address)
+	}
+	current := map[int]int{}
+	for port, hostPort := range svcPodman.GetIngress().GetPorts() {
+		current[port] = hostPort
+	}
+	for port, hostPort := range ingress.GetPorts() {
+		if !utils.IntSliceContains(svcPodman.Ports, port) {
+			return fmt.Errorf("service does not specify mapped port %d", port)
+		}
+		if hostPort != 0 && current[port] != hostPort && utils.TcpPortInUse(ingress.GetHost(), hostPort) {
+			return fmt.Errorf("ingress port %d is already in use", hostPort)
+		}
+	}
+	svcPodman.Ingress = ingress
+	svcPodman.HostIPs = nil
+	_, err = s.cli.ContainerUpdate(svcPodman.ContainerName, func(newContainer *container.Container) {
+		newContainer.Ports = svcPodman.ContainerPorts()
+		// the publish strategy follows the ports bound from now on
+		delete(newContainer.Labels, PublishQualifier)
+	})
+	if err != nil {
+		return fmt.Errorf("error updating container %s - %w", svcPodman.ContainerName, err)
+	}
+	return nil
+}
+
 func (s *ServiceHandler) Get(address string) (domain.Service, error) {
 	svcs, err := s.handler.List()
 	if err != nil {
@@ -415,10 +488,7 @@ func (s *ServiceHandler) SaveServiceRouterConfig(address string, config *qdr.Rou
 }
 
 func (s *ServiceHandler) AddEgressResolver(address string, egressResolver domain.EgressResolver) error {
-	siteHandler, err := NewSitePodmanHandler("")
-	if err != nil {
-		return fmt.Errorf("error preparing site handler - %w", err)
-	}
+	siteHandler := NewSitePodmanHandlerFromCli(s.cli)
 	site, err := siteHandler.Get()
 	if err != nil {
 		return fmt.Errorf("error retrieving site info - %w", err)
@@ -588,10 +658,10 @@ func (s *ServiceHandler) RemoveAllEgressResolvers(address string) error {
 }
 
 type ServiceInterfaceHandler struct {
-	cli *podman.PodmanRestClient
+	cli container.EngineClient
 }
 
-func NewServiceInterfaceHandlerPodman(cli *podman.PodmanRestClient) *ServiceInterfaceHandler {
+func NewServiceInterfaceHandlerPodman(cli container.EngineClient) *ServiceInterfaceHandler {
 	return &ServiceInterfaceHandler{
 		cli: cli,
 	}
@@ -710,15 +780,16 @@ func (s *ServiceInterfaceHandler) Delete(address string) error {
 func (s *ServiceInterfaceHandler) ToServicePodman(svcIface *types.ServiceInterface, newService bool) (*Service, error) {
 	svc := &Service{
 		ServiceCommon: &domain.ServiceCommon{
-			Address:        svcIface.Address,
-			Protocol:       svcIface.Protocol,
-			Ports:          svcIface.Ports,
-			EventChannel:   svcIface.EventChannel,
-			Aggregate:      svcIface.Aggregate,
-			Labels:         svcIface.Labels,
-			Origin:         svcIface.Origin,
-			TlsCredentials: svcIface.TlsCredentials,
-			Ingress:        &domain.AddressIngressCommon{},
+			Address:          svcIface.Address,
+			Protocol:         svcIface.Protocol,
+			Ports:            svcIface.Ports,
+			EventChannel:     svcIface.EventChannel,
+			Aggregate:        svcIface.Aggregate,
+			AggregateOptions: svcIface.AggregateOptions,
+			Labels:           svcIface.Labels,
+			Origin:           svcIface.Origin,
+			TlsCredentials:   svcIface.TlsCredentials,
+			Ingress:          &domain.AddressIngressCommon{},
 		},
 	}
 
@@ -753,6 +824,7 @@ func (s *ServiceInterfaceHandler) ToServicePodman(svcIface *types.ServiceInterfa
 
 			// setting remaining information
 			svc.ContainerName = svcContainer.Name
+			svc.readPublish(svcContainer)
 
 			// reading ingress info from container spec
 			if len(svcContainer.Ports) > 0 {
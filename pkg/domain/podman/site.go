@@ -3,8 +3,10 @@ package podman
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -15,6 +17,8 @@ import (
 	"github.com/skupperproject/skupper/client/podman"
 	"github.com/skupperproject/skupper/pkg/config"
 	"github.com/skupperproject/skupper/pkg/domain"
+	"github.com/skupperproject/skupper/pkg/encryption"
+	"github.com/skupperproject/skupper/pkg/fips"
 	"github.com/skupperproject/skupper/pkg/images"
 	"github.com/skupperproject/skupper/pkg/qdr"
 	"github.com/skupperproject/skupper/pkg/utils"
@@ -25,27 +29,46 @@ import (
 
 type Site struct {
 	*domain.SiteCommon
-	IngressHosts                 []string
-	IngressBindIPs               []string
-	IngressBindInterRouterPort   int
-	IngressBindEdgePort          int
-	IngressBindFlowCollectorPort int
-	ContainerNetwork             string
-	EnableIPV6                   bool
-	PodmanEndpoint               string
-	EnableFlowCollector          bool
-	EnableConsole                bool
-	AuthMode                     string
-	ConsoleUser                  string
-	ConsolePassword              string
-	RouterOpts                   types.RouterOptions
-	PrometheusOpts               types.PrometheusServerOptions
-	ControllerOpts               types.ControllerOptions
-	FlowCollectorOpts            types.FlowCollectorOptions
+	IngressHosts                   []string
+	CertificateHosts               []string
+	IngressBindIPs                 []string
+	IngressBindInterRouterPort     int
+	IngressBindEdgePort            int
+	IngressBindFlowCollectorPort   int
+	IngressBindMetricsExporterPort int
+	ContainerNetwork               string
+	ContainerNetworkSubnets        []string
+	EnableIPV6                     bool
+	PodmanEndpoint                 string
+	EnableFlowCollector            bool
+	EnableConsole                  bool
+	EnableMetricsExporter          bool
+	AuthMode                       string
+	ConsoleUser                    string
+	ConsolePassword                string
+	ConsoleCliToken                string
+	RouterOpts                     types.RouterOptions
+	PrometheusOpts                 types.PrometheusServerOptions
+	ControllerOpts                 types.ControllerOptions
+	FlowCollectorOpts              types.FlowCollectorOptions
+	CredentialsEncryption          encryption.Options
+	EnableSystemdUnits             bool
+	EnableKubePlay                 bool
+	PrivilegedPorts                string
+	VolumeHostPaths                map[string]string
+	VolumeMountOptions             map[string]string
+	RestartPolicy                  string
+	HealthcheckInterval            time.Duration
+	ImagePullPolicy                string
+	RegistryAuthFile               string
+	RegistryUsername               string
+	RegistryPassword               string
+	PinImageDigests                bool
+	socketForwards                 []config.SocketForward
 }
 
 func (s *Site) GetPlatform() string {
-	return "podman"
+	return utils.DefaultStr(s.Platform, types.PlatformPodman)
 }
 
 func (s *Site) GetIngressClasses() []string {
@@ -58,8 +81,8 @@ func (s *Site) GetConsoleUrl() string {
 		if len(s.IngressBindIPs) > 0 {
 			ipAddr = utils.DefaultStr(s.IngressBindIPs[0], ipAddr)
 		}
-		port := s.IngressBindFlowCollectorPort
-		return fmt.Sprintf("https://%s:%d", ipAddr, port)
+		port := strconv.Itoa(s.IngressBindFlowCollectorPort)
+		return "https://" + net.JoinHostPort(ipAddr, port)
 	}
 	return ""
 }
@@ -67,6 +90,16 @@ func (s *Site) GetConsoleUrl() string {
 func (s *Site) validateCreate() error {
 	validationFunctions := []func() error{
 		s.ValidateTuningOpts,
+		s.ValidatePrometheusOpts,
+		s.ValidateNetworkOpts,
+		s.CredentialsEncryption.Validate,
+		s.ValidateKubePlay,
+		s.ValidatePrivilegedPortsOpt,
+		s.ValidateVolumeOpts,
+		s.ValidateRestartPolicy,
+		s.ValidatePullOptions,
+		s.ValidateSiteIdOpts,
+		s.ValidateSystemdUnitsOpts,
 	}
 	for _, fn := range validationFunctions {
 		if err := fn(); err != nil {
@@ -91,30 +124,64 @@ func (s *Site) ValidateTuningOpts() error {
 		"prometheus":     s.PrometheusOpts.Tuning.MemoryLimit,
 	}
 	for component, cpuLimit := range cpuLimits {
-		if cpuLimit != "" {
-			if _, err = strconv.Atoi(cpuLimit); err != nil {
-				return fmt.Errorf("invalid cpu limit (decimal) for %s: %s", component, cpuLimit)
-			}
+		if _, err = ParseCpuLimit(cpuLimit); err != nil {
+			return fmt.Errorf("invalid cpu limit (number of cpus) for %s: %s", component, cpuLimit)
 		}
 	}
 	for component, memoryLimit := range memoryLimits {
-		if memoryLimit != "" {
-			if _, err = strconv.ParseInt(memoryLimit, 10, 64); err != nil {
-				return fmt.Errorf("invalid memory limit (bytes) for %s: %s", component, memoryLimit)
-			}
+		if _, err = ParseMemoryLimit(memoryLimit); err != nil {
+			return fmt.Errorf("invalid memory limit (bytes or with a unit) for %s: %s", component, memoryLimit)
 		}
 	}
 	return nil
 }
 
+func (s *Site) ValidateKubePlay() error {
+	if s.EnableKubePlay && s.GetPlatform() != types.PlatformPodman {
+		return fmt.Errorf("podman kube play is not available on the %s platform", s.GetPlatform())
+	}
+	if s.EnableKubePlay && podman.IsTLSEndpoint(s.PodmanEndpoint) {
+		return fmt.Errorf("podman kube play cannot reach the podman service through a tls endpoint")
+	}
+	return nil
+}
+
+// ValidateSiteIdOpts verifies the options of a site identified by a site id,
+// the containers of which are started by the podman service of the site
+func (s *Site) ValidateSiteIdOpts() error {
+	if config.SiteId == "" {
+		return nil
+	}
+	if s.GetPlatform() != types.PlatformPodman {
+		return fmt.Errorf("site ids are not available on the %s platform", s.GetPlatform())
+	}
+	if s.EnableSystemdUnits {
+		return fmt.Errorf("the containers of the site %s are started by its podman service, systemd units cannot be installed", config.SiteId)
+	}
+	return nil
+}
+
+// ValidateSystemdUnitsOpts verifies the systemd units can be installed, the
+// docker containers being restarted on boot by docker itself
+func (s *Site) ValidateSystemdUnitsOpts() error {
+	if s.EnableSystemdUnits && s.GetPlatform() != types.PlatformPodman {
+		return fmt.Errorf("systemd units are not available on the %s platform, it restarts the containers on boot by itself", s.GetPlatform())
+	}
+	return nil
+}
+
+func (s *Site) ValidatePrometheusOpts() error {
+	return config.ValidatePrometheusRetention(s.PrometheusOpts.RetentionTime, s.PrometheusOpts.RetentionSize)
+}
+
 type SiteHandler struct {
-	cli                  *podman.PodmanRestClient
+	cli                  container.EngineClient
 	endpoint             string
 	up                   *domain.UpdateProcessor
 	networkStatusHandler *NetworkStatusHandler
 }
 
-func NewSitePodmanHandlerFromCli(cli *podman.PodmanRestClient) *SiteHandler {
+func NewSitePodmanHandlerFromCli(cli container.EngineClient) *SiteHandler {
 	return &SiteHandler{
 		cli:      cli,
 		endpoint: cli.GetEndpoint(),
@@ -128,20 +195,29 @@ func NewSiteHandler(endpoint string) (domain.SiteHandler, error) {
 }
 
 func NewSitePodmanHandler(endpoint string) (*SiteHandler, error) {
+	return NewSiteHandlerFor(types.PlatformPodman, endpoint)
+}
+
+// NewSiteHandlerFor returns the handler of the site running on the
+// container engine of the given platform
+func NewSiteHandlerFor(platform types.Platform, endpoint string) (*SiteHandler, error) {
+	if endpoint == "" && platform == types.PlatformPodman {
+		endpoint = RemoteEndpoint
+	}
 	if endpoint == "" {
-		podmanCfg, err := NewPodmanConfigFileHandler().GetConfig()
+		engineCfg, err := NewConfigFileHandler(platform).GetConfig()
 		if err != nil {
-			return nil, fmt.Errorf("Unable to load local podman configuration - %w", err)
+			return nil, fmt.Errorf("Unable to load local %s configuration - %w", platform, err)
 		}
-		endpoint = podmanCfg.Endpoint
+		endpoint = engineCfg.Endpoint
 	}
-	c, err := podman.NewPodmanClient(endpoint, "")
+	c, err := NewEngineClient(platform, endpoint)
 	if err != nil {
 		return nil, err
 	}
 	return &SiteHandler{
 		cli:      c,
-		endpoint: endpoint,
+		endpoint: c.GetEndpoint(),
 	}, nil
 }
 
@@ -162,13 +238,17 @@ func (s *SiteHandler) prepare(ctx context.Context, site domain.Site) (domain.Sit
 	if !ok {
 		return nil, fmt.Errorf("not a valid podman site definition")
 	}
-	podmanSite.Platform = types.PlatformPodman
+	podmanSite.Platform = podmanSite.GetPlatform()
+	if s.cli != nil {
+		podmanSite.Platform = string(s.cli.Platform())
+	}
 	if podmanSite.Mode == "" {
 		podmanSite.Mode = "interior"
 	}
 	if podmanSite.ContainerNetwork == "" {
 		podmanSite.ContainerNetwork = container.ContainerNetworkName
 	}
+	podmanSite.enableConsoleComponents()
 
 	// Validating basic info
 	if err := podmanSite.ValidateMinimumRequirements(); err != nil {
@@ -183,8 +263,20 @@ func (s *SiteHandler) prepare(ctx context.Context, site domain.Site) (domain.Sit
 		return nil, err
 	}
 
+	// Rootless engines cannot bind the privileged ports
+	if err := s.handlePrivilegedPorts(podmanSite); err != nil {
+		return nil, err
+	}
+
 	// Preparing site
 	domain.ConfigureSiteCredentials(podmanSite, podmanSite.IngressHosts...)
+	credentials := podmanSite.GetCredentials()
+	for i, cred := range credentials {
+		if cred.Name == types.SiteServerSecret {
+			credentials[i].Hosts = appendHosts(cred.Hosts, podmanSite.CertificateHosts...)
+			credentials[i].Hosts = appendHosts(credentials[i].Hosts, podmanSite.bindHosts()...)
+		}
+	}
 	s.ConfigurePodmanDeployments(podmanSite)
 
 	if err := s.canCreate(ctx, podmanSite); err != nil {
@@ -201,6 +293,9 @@ func (s *SiteHandler) ConfigurePodmanDeployments(site *Site) {
 		site.Deployments = append(site.Deployments, s.prepareFlowCollectorDeployment(site))
 		site.Deployments = append(site.Deployments, s.preparePrometheusDeployment(site))
 	}
+	if site.EnableMetricsExporter {
+		site.Deployments = append(site.Deployments, s.prepareMetricsExporterDeployment(site))
+	}
 }
 
 func (s *SiteHandler) prepareRouterDeployment(site *Site) *SkupperDeployment {
@@ -213,8 +308,7 @@ func (s *SiteHandler) prepareRouterDeployment(site *Site) *SkupperDeployment {
 	if !site.IsEdge() {
 		volumeMounts[types.SiteServerSecret] = "/etc/skupper-router-certs/skupper-internal/"
 	}
-	memoryLimit, _ := strconv.ParseInt(site.RouterOpts.MemoryLimit, 10, 64)
-	cpus, _ := strconv.Atoi(site.RouterOpts.CpuLimit)
+	cpus, memoryLimit := tuningLimits(site.RouterOpts.Tuning)
 	routerComponent := &domain.Router{
 		// TODO ADD Labels
 		Labels: map[string]string{},
@@ -234,9 +328,12 @@ func (s *SiteHandler) prepareRouterDeployment(site *Site) *SkupperDeployment {
 				routerComponent,
 			},
 		},
-		Aliases:      []string{types.TransportServiceName, types.LocalTransportServiceName},
-		VolumeMounts: volumeMounts,
-		Networks:     []string{site.ContainerNetwork},
+		Aliases:        []string{types.TransportServiceName, types.LocalTransportServiceName},
+		VolumeMounts:   volumeMounts,
+		Networks:       []string{site.ContainerNetwork},
+		RestartPolicy:  site.GetRestartPolicy(),
+		PinImageDigest: site.PinImageDigests,
+		Healthcheck:    site.routerHealthcheck(),
 	}
 
 	// If ingress mode is none, then ingress hosts will be empty
@@ -286,6 +383,7 @@ func (s *SiteHandler) Create(ctx context.Context, site domain.Site) error {
 		return err
 	}
 	podmanSite = preparedSite.(*Site)
+	s.cli.SetPullOptions(podmanSite.pullOptions())
 
 	// cleanup on error
 	defer func() {
@@ -297,12 +395,15 @@ func (s *SiteHandler) Create(ctx context.Context, site domain.Site) error {
 		}
 	}()
 
-	// Save podman local configuration
-	err = NewPodmanConfigFileHandler().Save(&Config{
-		Endpoint: s.endpoint,
-	})
-	if err != nil {
-		return err
+	// Save podman local configuration, remote hosts are reached through
+	// the --host flag or a context instead
+	if !podman.IsSSHEndpoint(s.endpoint) {
+		err = NewConfigFileHandler(s.cli.Platform()).Save(&Config{
+			Endpoint: s.endpoint,
+		})
+		if err != nil {
+			return err
+		}
 	}
 
 	// Create network
@@ -314,9 +415,14 @@ func (s *SiteHandler) Create(ctx context.Context, site domain.Site) error {
 		_ = s.cli.NetworkRemove(podmanSite.ContainerNetwork)
 	})
 
+	// Create the directories of the volumes backed by host paths
+	if err = s.createVolumeHostPaths(podmanSite); err != nil {
+		return err
+	}
+
 	// Create cert authorities and credentials
 	var credHandler types.CredentialHandler
-	credHandler = NewPodmanCredentialHandler(s.cli)
+	credHandler = NewPodmanCredentialHandler(s.cli).WithEncryption(podmanSite.CredentialsEncryption).WithVolumes(podmanSite.volume)
 
 	// - creating cert authorities
 	cleanupFns = append(cleanupFns, func() {
@@ -342,26 +448,12 @@ func (s *SiteHandler) Create(ctx context.Context, site domain.Site) error {
 		}
 	}
 
-	// Create initial transport config file
-	podmanSite.RouterOpts.MaxFrameSize = types.RouterMaxFrameSizeDefault
-	podmanSite.RouterOpts.MaxSessionFrames = types.RouterMaxSessionFramesDefault
-	initialRouterConfig := qdr.InitialConfigSkupperRouter(podmanSite.GetName(), podmanSite.GetId(), version.Version, podmanSite.IsEdge(), 3, podmanSite.RouterOpts)
-	var routerConfigHandler qdr.RouterConfigHandler
-	routerConfigHandler = NewRouterConfigHandlerPodman(s.cli)
-	err = routerConfigHandler.SaveRouterConfig(&initialRouterConfig)
-	cleanupFns = append(cleanupFns, func() {
-		_ = routerConfigHandler.RemoveRouterConfig()
-	})
-	if err != nil {
-		return err
-	}
-
 	// Verify volumes not yet created and create them
 	for _, volumeName := range SkupperContainerVolumes {
 		var vol *container.Volume
 		vol, err = s.cli.VolumeInspect(volumeName)
 		if vol == nil && err != nil {
-			vol, err = s.cli.VolumeCreate(&container.Volume{Name: volumeName})
+			vol, err = s.cli.VolumeCreate(podmanSite.volume(volumeName))
 			if err != nil {
 				return err
 			}
@@ -373,18 +465,37 @@ func (s *SiteHandler) Create(ctx context.Context, site domain.Site) error {
 		}
 	}
 
+	// Create initial transport config file
+	podmanSite.RouterOpts.MaxFrameSize = types.RouterMaxFrameSizeDefault
+	podmanSite.RouterOpts.MaxSessionFrames = types.RouterMaxSessionFramesDefault
+	initialRouterConfig := qdr.InitialConfigSkupperRouter(podmanSite.GetName(), podmanSite.GetId(), version.Version, podmanSite.IsEdge(), 3, podmanSite.RouterOpts)
+	var routerConfigHandler qdr.RouterConfigHandler
+	routerConfigHandler = NewRouterConfigHandlerPodman(s.cli)
+	err = routerConfigHandler.SaveRouterConfig(&initialRouterConfig)
+	cleanupFns = append(cleanupFns, func() {
+		_ = routerConfigHandler.RemoveRouterConfig()
+	})
+	if err != nil {
+		return err
+	}
+
 	// Create console user
 	if err = s.createConsoleUser(podmanSite); err != nil {
 		return err
 	}
 
+	// Hand the credentials key over to the containers
+	if err = s.createCredentialsKey(podmanSite); err != nil {
+		return err
+	}
+
 	// Create prometheus config
 	if err = s.createPrometheusConfigFiles(podmanSite); err != nil {
 		return err
 	}
 
 	// Deploy container(s)
-	deployHandler := NewSkupperDeploymentHandlerPodman(s.cli)
+	deployHandler := s.deploymentHandler(podmanSite)
 	for _, depl := range podmanSite.GetDeployments() {
 		err = deployHandler.Deploy(ctx, depl)
 		if err != nil {
@@ -394,19 +505,51 @@ func (s *SiteHandler) Create(ctx context.Context, site domain.Site) error {
 			_ = deployHandler.Undeploy(depl.GetName())
 		})
 	}
+	if podmanSite.EnableKubePlay {
+		fmt.Printf("The pods of the site are described at %s\n", GetKubePlayDir())
+	}
 
-	// Creating startup scripts first
-	scripts := config.GetStartupScripts(types.PlatformPodman)
-	err = scripts.Create()
-	if err != nil {
-		return fmt.Errorf("error creating startup scripts: %w\n", err)
+	// Accepting the privileged ports through socket activation
+	if err = s.createSocketUnits(podmanSite); err != nil {
+		return err
+	}
+
+	// The startup scripts and service can only be installed locally
+	if podman.IsSSHEndpoint(s.endpoint) {
+		fmt.Printf("The startup service is not installed on remote hosts, Skupper will not start on boot of the remote host.\n")
+		return nil
+	}
+
+	// Docker restarts the containers on boot by itself
+	if s.cli.Platform() == types.PlatformDocker {
+		return nil
 	}
 
-	// Creating systemd user service
-	if err = config.NewSystemdServiceInfo(types.PlatformPodman).Create(); err != nil {
-		fmt.Printf("Unable to create startup service - %v\n", err)
-		fmt.Printf("The startup scripts: %s and %s are available at %s\n,",
-			scripts.GetStartFileName(), scripts.GetStopFileName(), scripts.GetPath())
+	if config.SiteId != "" {
+		fmt.Printf("The containers of the site are started on boot by %s\n", config.NewPodmanSiteService(config.SiteId).UnitName())
+	} else if podmanSite.EnableSystemdUnits {
+		// Creating a systemd unit per container, the router being started first
+		var containers []string
+		for _, depl := range podmanSite.GetDeployments() {
+			containers = append(containers, depl.GetName())
+		}
+		if err = config.NewSystemdContainerUnits(containers...).Create(); err != nil {
+			return fmt.Errorf("error creating systemd units: %w", err)
+		}
+	} else {
+		// Creating startup scripts first
+		scripts := config.GetStartupScripts(types.PlatformPodman)
+		err = scripts.Create()
+		if err != nil {
+			return fmt.Errorf("error creating startup scripts: %w\n", err)
+		}
+
+		// Creating systemd user service
+		if err = config.NewSystemdServiceInfo(types.PlatformPodman).Create(); err != nil {
+			fmt.Printf("Unable to create startup service - %v\n", err)
+			fmt.Printf("The startup scripts: %s and %s are available at %s\n,",
+				scripts.GetStartFileName(), scripts.GetStopFileName(), scripts.GetPath())
+		}
 	}
 
 	// Validate if lingering is enabled for current user
@@ -421,9 +564,10 @@ func (s *SiteHandler) canCreate(ctx context.Context, site *Site) error {
 
 	// Validating podman endpoint
 	if s.cli == nil {
-		cli, err := podman.NewPodmanClient(site.PodmanEndpoint, "")
+		platform := types.Platform(site.GetPlatform())
+		cli, err := NewEngineClient(platform, site.PodmanEndpoint)
 		if err != nil {
-			return fmt.Errorf("unable to communicate with podman service through %s - %v", site.PodmanEndpoint, err)
+			return fmt.Errorf("unable to communicate with %s service through %s - %v", platform, site.PodmanEndpoint, err)
 		}
 		s.cli = cli
 	}
@@ -444,9 +588,9 @@ func (s *SiteHandler) canCreate(ctx context.Context, site *Site) error {
 	}
 
 	// Validating skupper networks available
-	net, err := cli.NetworkInspect(site.ContainerNetwork)
-	if err == nil && net != nil {
-		if !net.DNS {
+	network, err := cli.NetworkInspect(site.ContainerNetwork)
+	if err == nil && network != nil {
+		if !network.DNS {
 			return fmt.Errorf("network %s cannot be used as DNS is not enabled, fix the existing network or use a different one", site.ContainerNetwork)
 		}
 	}
@@ -456,7 +600,7 @@ func (s *SiteHandler) canCreate(ctx context.Context, site *Site) error {
 		for _, skupperComp := range skupperDepl.GetComponents() {
 			for _, ingress := range skupperComp.GetSiteIngresses() {
 				if utils.TcpPortInUse(ingress.GetHost(), ingress.GetPort()) {
-					return fmt.Errorf("ingress port already bound %s:%d", ingress.GetHost(), ingress.GetPort())
+					return fmt.Errorf("ingress port already bound %s", net.JoinHostPort(ingress.GetHost(), strconv.Itoa(ingress.GetPort())))
 				}
 
 			}
@@ -464,24 +608,19 @@ func (s *SiteHandler) canCreate(ctx context.Context, site *Site) error {
 	}
 
 	// Validate network ability to resolve names
-	if net == nil {
-		createdNetwork, err := cli.NetworkCreate(&container.Network{
-			Name:     site.ContainerNetwork,
-			IPV6:     site.EnableIPV6,
-			DNS:      true,
-			Internal: false,
-		})
+	if network == nil {
+		createdNetwork, err := cli.NetworkCreate(site.containerNetwork())
 		if err != nil {
 			return fmt.Errorf("error validating network creation - %v", err)
 		}
-		defer func(cli *podman.PodmanRestClient, id string) {
+		defer func(cli container.EngineClient, id string) {
 			err := cli.NetworkRemove(id)
 			if err != nil {
 				fmt.Printf("ERROR removing network %s - %v\n", id, err)
 			}
 		}(cli, site.ContainerNetwork)
 		if !createdNetwork.DNS {
-			return fmt.Errorf("network %s cannot resolve names - podman plugins must be installed", site.ContainerNetwork)
+			return fmt.Errorf("network %s cannot resolve names - %s plugins must be installed", site.ContainerNetwork, cli.Platform())
 		}
 	}
 
@@ -501,6 +640,9 @@ func (s *SiteHandler) canCreate(ctx context.Context, site *Site) error {
 	}
 	defer cli.VolumeRemove(testVolumeName)
 	if _, err = v.ListFiles(); err != nil {
+		if cli.Platform() == types.PlatformDocker {
+			return fmt.Errorf("The docker volumes must be accessible, run as root or use a rootless docker daemon - %w", err)
+		}
 		return fmt.Errorf("You cannot use a remote podman endpoint - %w", err)
 	}
 
@@ -517,12 +659,7 @@ func (s *SiteHandler) createNetwork(site *Site) error {
 	if err == nil && existingNet != nil {
 		return nil
 	}
-	_, err = s.cli.NetworkCreate(&container.Network{
-		Name:     site.ContainerNetwork,
-		IPV6:     site.EnableIPV6,
-		DNS:      true,
-		Internal: false,
-	})
+	_, err = s.cli.NetworkCreate(site.containerNetwork())
 	if err != nil {
 		return fmt.Errorf("error creating network %s - %v", site.ContainerNetwork, err)
 	}
@@ -547,7 +684,7 @@ func (s *SiteHandler) Get() (domain.Site, error) {
 	site.Mode = string(routerConfig.Metadata.Mode)
 	site.Id = routerConfig.GetSiteMetadata().Id
 	site.Version = routerConfig.GetSiteMetadata().Version
-	site.Platform = types.PlatformPodman
+	site.Platform = string(s.cli.Platform())
 
 	// Reading cert authorities
 	credHandler := NewPodmanCredentialHandler(s.cli)
@@ -574,6 +711,10 @@ func (s *SiteHandler) Get() (domain.Site, error) {
 			site.IngressHosts = cred.Hosts
 		}
 	}
+	if vol, err := s.cli.VolumeInspect(types.LocalClientSecret); err == nil {
+		site.CredentialsEncryption = volumeEncryption(vol)
+	}
+	s.readVolumes(site)
 
 	// Reading deployments
 	deployHandler := NewSkupperDeploymentHandlerPodman(s.cli)
@@ -586,6 +727,11 @@ func (s *SiteHandler) Get() (domain.Site, error) {
 	}
 	site.Deployments = deps
 
+	// Sites played through podman kube play run their containers in pods
+	if router, err := s.cli.ContainerInspect(types.TransportDeploymentName); err == nil {
+		site.EnableKubePlay = router.Pod != ""
+	}
+
 	routerFound := false
 	ctrlFound := false
 	for _, dep := range site.GetDeployments() {
@@ -600,6 +746,8 @@ func (s *SiteHandler) Get() (domain.Site, error) {
 					site.IngressBindEdgePort = siteIng.GetPort()
 				} else if siteIng.GetTarget().GetPort() == int(types.FlowCollectorDefaultServicePort) {
 					site.IngressBindFlowCollectorPort = siteIng.GetPort()
+				} else if siteIng.GetTarget().GetPort() == int(types.MetricsExporterDefaultPort) {
+					site.IngressBindMetricsExporterPort = siteIng.GetPort()
 				}
 			}
 			switch c := comp.(type) {
@@ -608,7 +756,12 @@ func (s *SiteHandler) Get() (domain.Site, error) {
 				c.GetSiteIngresses()
 				site.RouterOpts.Logging = qdr.GetRouterLogging(routerConfig)
 				site.RouterOpts.MemoryLimit = strconv.FormatInt(c.MemoryLimit, 10)
-				site.RouterOpts.CpuLimit = strconv.Itoa(c.Cpus)
+				site.RouterOpts.CpuLimit = formatCpuLimit(c.Cpus)
+				site.RestartPolicy = depPodman.RestartPolicy
+				site.PinImageDigests = strings.Contains(c.GetImage(), "@")
+				if depPodman.Healthcheck != nil {
+					site.HealthcheckInterval = depPodman.Healthcheck.Interval
+				}
 			case *domain.FlowCollector:
 				enableConsole, _ := strconv.ParseBool(c.Env["ENABLE_CONSOLE"])
 				consoleUsers, _ := c.Env["FLOW_USERS"]
@@ -620,25 +773,35 @@ func (s *SiteHandler) Get() (domain.Site, error) {
 				site.EnableConsole = enableConsole
 				site.EnableFlowCollector = true
 				site.FlowCollectorOpts.FlowRecordTtl, _ = time.ParseDuration(c.Env["FLOW_RECORD_TTL"])
+				site.FlowCollectorOpts.TlsMinVersion = c.Env["FLOW_TLS_MIN_VERSION"]
+				site.FlowCollectorOpts.TlsCipherSuites = c.Env["FLOW_TLS_CIPHER_SUITES"]
+				site.FlowCollectorOpts.TlsCurvePreferences = c.Env["FLOW_TLS_CURVE_PREFERENCES"]
+				site.FlowCollectorOpts.AcmeDomains = c.Env["FLOW_ACME_DOMAINS"]
+				site.FlowCollectorOpts.AcmeEmail = c.Env["FLOW_ACME_EMAIL"]
+				site.FlowCollectorOpts.AcmeDirectory = c.Env["FLOW_ACME_DIRECTORY"]
 				site.FlowCollectorOpts.MemoryLimit = strconv.FormatInt(c.MemoryLimit, 10)
-				site.FlowCollectorOpts.CpuLimit = strconv.Itoa(c.Cpus)
+				site.FlowCollectorOpts.CpuLimit = formatCpuLimit(c.Cpus)
 				user, password, err := s.getConsoleUserPass()
 				if err != nil {
 					fmt.Println("error retrieving console user and password -", err)
 				}
 				site.ConsoleUser = user
 				site.ConsolePassword = password
+				site.ConsoleCliToken, _ = s.getConsoleCliToken()
 			case *domain.Controller:
 				ctrlFound = true
 				site.ControllerOpts.MemoryLimit = strconv.FormatInt(c.MemoryLimit, 10)
-				site.ControllerOpts.CpuLimit = strconv.Itoa(c.Cpus)
+				site.ControllerOpts.CpuLimit = formatCpuLimit(c.Cpus)
 			case *domain.Prometheus:
 				site.PrometheusOpts, err = s.getPrometheusServerOptions()
+				site.PrometheusOpts.RetentionTime, site.PrometheusOpts.RetentionSize = config.PrometheusRetentionFromArgs(depPodman.Command)
 				site.PrometheusOpts.MemoryLimit = strconv.FormatInt(c.MemoryLimit, 10)
-				site.PrometheusOpts.CpuLimit = strconv.Itoa(c.Cpus)
+				site.PrometheusOpts.CpuLimit = formatCpuLimit(c.Cpus)
 				if err != nil {
 					fmt.Println("error retrieving prometheus options -", err)
 				}
+			case *domain.MetricsExporter:
+				site.EnableMetricsExporter = true
 			}
 		}
 	}
@@ -671,14 +834,38 @@ func (s *SiteHandler) Delete() error {
 	}
 	podmanSite := site.(*Site)
 
-	deployHandler := NewSkupperDeploymentHandlerPodman(s.cli)
+	deployHandler := s.deploymentHandler(podmanSite)
 	deploys, err := deployHandler.List()
 	if err != nil {
 		return fmt.Errorf("error retrieving deployments - %w", err)
 	}
 
+	// Removing the units of the containers first, so systemd does not
+	// restart them
+	if !podman.IsSSHEndpoint(s.endpoint) && s.cli.Platform() == types.PlatformPodman && config.SiteId == "" {
+		if err = config.NewSystemdContainerUnits().Remove(); err != nil {
+			fmt.Printf("Unable to remove the systemd units of the containers - %v\n", err)
+		}
+	}
+	if !podman.IsSSHEndpoint(s.endpoint) {
+		s.removeSocketUnits()
+	}
+
+	// Removing the controller first, so it does not recreate the containers
+	// being removed
+	for _, dep := range deploys {
+		if dep.GetName() == types.ControllerPodmanContainerName {
+			if err = deployHandler.Undeploy(dep.GetName()); err != nil {
+				return fmt.Errorf("error removing deployment %s - %w", dep.GetName(), err)
+			}
+		}
+	}
+
 	// Stopping and removing containers
 	for _, dep := range deploys {
+		if dep.GetName() == types.ControllerPodmanContainerName {
+			continue
+		}
 		err = deployHandler.Undeploy(dep.GetName())
 		if err != nil {
 			return fmt.Errorf("error removing deployment %s - %w", dep.GetName(), err)
@@ -696,6 +883,14 @@ func (s *SiteHandler) Delete() error {
 	return nil
 }
 
+// deploymentHandler returns the handler deploying the containers of the site
+func (s *SiteHandler) deploymentHandler(site *Site) domain.SkupperDeploymentHandler {
+	if site.EnableKubePlay {
+		return NewKubePlayDeploymentHandler(s.cli)
+	}
+	return NewSkupperDeploymentHandlerPodman(s.cli)
+}
+
 func (s *SiteHandler) removePodmanResources() error {
 	// removing containers owned by Skupper
 	containers, err := s.cli.ContainerList()
@@ -711,6 +906,15 @@ func (s *SiteHandler) removePodmanResources() error {
 		}
 	}
 
+	// Removing the pods played through podman kube play
+	if s.cli.Platform() == types.PlatformPodman {
+		kubePlay := NewKubePlayDeploymentHandler(s.cli)
+		podFiles, _ := filepath.Glob(kubePlay.FileName("*"))
+		for _, podFile := range podFiles {
+			_ = kubePlay.Undeploy(strings.TrimSuffix(filepath.Base(podFile), ".yaml"))
+		}
+	}
+
 	// Removing volumes
 	volumeList, err := s.cli.VolumeList()
 	if err != nil {
@@ -724,13 +928,20 @@ func (s *SiteHandler) removePodmanResources() error {
 		}
 	}
 
-	// Removing startup files and service
+	// Removing startup files and service, the podman service of a site
+	// identified by a site id being removed along with its storage
+	if podman.IsSSHEndpoint(s.endpoint) || s.cli.Platform() == types.PlatformDocker || config.SiteId != "" {
+		return nil
+	}
 	scripts := config.GetStartupScripts(types.PlatformPodman)
 	scripts.Remove()
 	systemd := config.NewSystemdServiceInfo(types.PlatformPodman)
 	if err = systemd.Remove(); err != nil {
 		fmt.Printf("Unable to remove systemd service - %v\n", err)
 	}
+	if err = config.NewSystemdContainerUnits().Remove(); err != nil {
+		fmt.Printf("Unable to remove the systemd units of the containers - %v\n", err)
+	}
 
 	return nil
 }
@@ -760,6 +971,8 @@ func (s *SiteHandler) Update() error {
 	  Registering tasks to be analyzed against current site version
 	*/
 
+	// pulls the images before any container is updated
+	s.up.RegisterTasks(NewImagesPullTask(s.cli))
 	// updates images for all skupper containers
 	s.up.RegisterTasks(NewContainerImagesTask(s.cli))
 	// updates site version number (always the last one)
@@ -779,6 +992,18 @@ func (s *SiteHandler) Update() error {
 	return s.up.Process(ctx, site.GetVersion())
 }
 
+// Upgrade updates the site to the current version, pulling the images of
+// the components with the given options. The images are all pulled before
+// any container is updated, the configuration is then migrated by the tasks
+// of the versions in between, and the router is restarted last.
+func (s *SiteHandler) Upgrade(site *Site) error {
+	if err := site.ValidatePullOptions(); err != nil {
+		return err
+	}
+	s.cli.SetPullOptions(site.pullOptions())
+	return s.Update()
+}
+
 func (s *SiteHandler) RevokeAccess() error {
 	site, err := s.Get()
 	if err != nil {
@@ -793,12 +1018,118 @@ func (s *SiteHandler) RevokeAccess() error {
 		return fmt.Errorf("error creating site CA - %w", err)
 	}
 
-	// Regenerating Site Server
-	_, err = credHandler.NewCredential(types.Credential{
+	return s.regenerateSiteServer(podmanSite.IngressHosts)
+}
+
+// AddCertificateHosts adds DNS names or IP addresses to the certificate
+// presented to linking sites, the router is restarted to load it
+func (s *SiteHandler) AddCertificateHosts(hosts ...string) error {
+	site, err := s.Get()
+	if err != nil {
+		return err
+	}
+	podmanSite := site.(*Site)
+	return s.regenerateSiteServer(appendHosts(podmanSite.IngressHosts, hosts...))
+}
+
+// UpdateFlowCollector deploys or removes the flow collector of an existing
+// site, along with the prometheus server it queries. The flow collector is
+// recreated when only the console is enabled or disabled, as it is told so
+// by its environment.
+func (s *SiteHandler) UpdateFlowCollector(ctx context.Context, enableFlowCollector bool, enableConsole bool) error {
+	site, err := s.Get()
+	if err != nil {
+		return err
+	}
+	podmanSite := site.(*Site)
+	if enableConsole {
+		enableFlowCollector = true
+	}
+	if podmanSite.EnableFlowCollector == enableFlowCollector && podmanSite.EnableConsole == enableConsole {
+		return nil
+	}
+
+	// The controller recreates the containers it has seen removed, so it is
+	// stopped while the components change
+	if controller, err := s.cli.ContainerInspect(types.ControllerPodmanContainerName); err == nil && controller.Running {
+		if err = s.cli.ContainerStop(types.ControllerPodmanContainerName); err != nil {
+			return fmt.Errorf("error stopping %s - %w", types.ControllerPodmanContainerName, err)
+		}
+		defer s.cli.ContainerStart(types.ControllerPodmanContainerName)
+	}
+
+	deployHandler := s.deploymentHandler(podmanSite)
+	containerUnits := s.containerUnits()
+	if podmanSite.EnableFlowCollector {
+		removed := []string{types.FlowCollectorContainerName}
+		if !enableFlowCollector {
+			removed = append(removed, types.PrometheusDeploymentName)
+		}
+		// Removing the units first, so systemd does not restart the containers
+		if containerUnits != nil {
+			if err = containerUnits.Remove(removed...); err != nil {
+				return fmt.Errorf("error removing systemd units: %w", err)
+			}
+		}
+		for _, name := range removed {
+			if err = deployHandler.Undeploy(name); err != nil {
+				return fmt.Errorf("error removing deployment %s - %w", name, err)
+			}
+		}
+	}
+	if !enableFlowCollector {
+		return nil
+	}
+
+	// The console users and the prometheus config are created along with
+	// the site, so only the containers are missing
+	deployments := []domain.SkupperDeployment{}
+	if !podmanSite.EnableFlowCollector {
+		if podmanSite.IngressBindFlowCollectorPort == 0 {
+			podmanSite.IngressBindFlowCollectorPort = int(types.FlowCollectorDefaultServicePort)
+		}
+		podmanSite.PrometheusOpts, _ = s.getPrometheusServerOptions()
+		deployments = append(deployments, s.preparePrometheusDeployment(podmanSite))
+	}
+	podmanSite.EnableFlowCollector = true
+	podmanSite.EnableConsole = enableConsole
+	deployments = append(deployments, s.prepareFlowCollectorDeployment(podmanSite))
+	containers := []string{types.TransportDeploymentName}
+	for _, depl := range deployments {
+		if err = deployHandler.Deploy(ctx, depl); err != nil {
+			return err
+		}
+		containers = append(containers, depl.GetName())
+	}
+	if containerUnits != nil {
+		// the units of the containers already running are left as they are
+		if err = config.NewSystemdContainerUnits(containers...).Create(); err != nil {
+			return fmt.Errorf("error creating systemd units: %w", err)
+		}
+	}
+	return nil
+}
+
+// containerUnits returns the systemd units of the containers of the site,
+// when the site has been created with them
+func (s *SiteHandler) containerUnits() *config.SystemdContainerUnits {
+	if podman.IsSSHEndpoint(s.endpoint) || s.cli.Platform() != types.PlatformPodman || config.SiteId != "" {
+		return nil
+	}
+	units := config.NewSystemdContainerUnits()
+	if installed, err := units.InstalledUnits(); err != nil || len(installed) == 0 {
+		return nil
+	}
+	return units
+}
+
+func (s *SiteHandler) regenerateSiteServer(hosts []string) error {
+	credHandler := NewPodmanCredentialHandler(s.cli)
+	_, err := credHandler.NewCredential(types.Credential{
 		CA:          types.SiteCaSecret,
 		Name:        types.SiteServerSecret,
 		Subject:     types.TransportServiceName,
-		Hosts:       podmanSite.IngressHosts,
+		Hosts:       hosts,
 		ConnectJson: false,
 	})
 	if err != nil {
@@ -813,6 +1144,63 @@ func (s *SiteHandler) RevokeAccess() error {
 	return nil
 }
 
+func appendHosts(hosts []string, extra ...string) []string {
+	result := append([]string{}, hosts...)
+	for _, host := range extra {
+		if !utils.StringSliceContains(result, host) {
+			result = append(result, host)
+		}
+	}
+	return result
+}
+
+// setCredentialsKey mounts the key of the encrypted credentials in the
+// containers decrypting them
+func setCredentialsKey(site *Site, volumeMounts map[string]string, env map[string]string) {
+	if site.CredentialsEncryption.Enabled() {
+		volumeMounts[CredentialsKeyVolume] = credentialsKeyMount
+		env[encryption.KeyFileEnvVar] = path.Join(credentialsKeyMount, credentialsKeyFile)
+	}
+}
+
+// setFipsMode runs the containers in FIPS mode when the site is created in
+// FIPS mode
+func setFipsMode(env map[string]string) {
+	if fips.Enabled() {
+		env[fips.EnvVar] = "true"
+	}
+}
+
+// createCredentialsKey hands the key of the encrypted credentials, as
+// resolved by the cli, over to the containers in a file that only the site
+// user can read
+func (s *SiteHandler) createCredentialsKey(site *Site) error {
+	if !site.CredentialsEncryption.Enabled() {
+		return nil
+	}
+	key, err := site.CredentialsEncryption.Key()
+	if err != nil {
+		return err
+	}
+	v, err := s.cli.VolumeInspect(CredentialsKeyVolume)
+	if err != nil {
+		return err
+	}
+	// restricted before the key is written to it
+	f, err := v.CreateFile(credentialsKeyFile, nil, true)
+	if err != nil {
+		return fmt.Errorf("error creating the credentials key - %w", err)
+	}
+	defer f.Close()
+	if err = f.Chmod(0400); err != nil {
+		return fmt.Errorf("error restricting the credentials key - %w", err)
+	}
+	if _, err = f.Write(key); err != nil {
+		return fmt.Errorf("error writing the credentials key - %w", err)
+	}
+	return nil
+}
+
 func (s *SiteHandler) prepareFlowCollectorDeployment(site *Site) *SkupperDeployment {
 	// Flow Collector Deployment
 	volumeMounts := map[string]string{
@@ -820,30 +1208,41 @@ func (s *SiteHandler) prepareFlowCollectorDeployment(site *Site) *SkupperDeploym
 		types.ConsoleUsersSecret:  "/etc/console-users",
 		types.ConsoleServerSecret: "/etc/service-controller/console",
 	}
-	endpoint := site.PodmanEndpoint
-	if s.cli.IsSockEndpoint() {
-		sockFile := strings.TrimPrefix(s.cli.GetEndpoint(), "unix://")
-		endpoint = "/tmp/podman.sock"
-		volumeMounts[sockFile] = endpoint
-	}
-	memoryLimit, _ := strconv.ParseInt(site.FlowCollectorOpts.MemoryLimit, 10, 64)
-	cpus, _ := strconv.Atoi(site.FlowCollectorOpts.CpuLimit)
+	engineEnv := s.engineEnv(site, volumeMounts)
+	cpus, memoryLimit := tuningLimits(site.FlowCollectorOpts.Tuning)
 	flowComponent := &domain.FlowCollector{
 		// TODO ADD Labels
 		Labels: map[string]string{},
 		Env: map[string]string{
-			"ENABLE_CONSOLE":   fmt.Sprintf("%v", site.EnableConsole),
-			"FLOW_RECORD_TTL":  site.FlowCollectorOpts.FlowRecordTtl.String(),
-			"SKUPPER_PLATFORM": types.PlatformPodman,
-			"PODMAN_ENDPOINT":  endpoint,
+			"ENABLE_CONSOLE":  fmt.Sprintf("%v", site.EnableConsole),
+			"FLOW_RECORD_TTL": site.FlowCollectorOpts.FlowRecordTtl.String(),
+			"PROMETHEUS_URL":  prometheusUrl(),
 		},
 		MemoryLimit: memoryLimit,
 		Cpus:        cpus,
 	}
+	for name, value := range engineEnv {
+		flowComponent.Env[name] = value
+	}
+	setCredentialsKey(site, volumeMounts, flowComponent.Env)
+	setFipsMode(flowComponent.Env)
+	flowComponent.Env["FLOW_SESSION_KEY_FILE"] = path.Join("/etc/console-users", consoleSessionKeyFile)
 	if site.AuthMode != types.ConsoleAuthModeUnsecured {
 		flowComponent.Env["FLOW_USERS"] = "/etc/console-users"
 		site.AuthMode = types.ConsoleAuthModeInternal
 	}
+	for name, value := range map[string]string{
+		"FLOW_TLS_MIN_VERSION":       site.FlowCollectorOpts.TlsMinVersion,
+		"FLOW_TLS_CIPHER_SUITES":     site.FlowCollectorOpts.TlsCipherSuites,
+		"FLOW_TLS_CURVE_PREFERENCES": site.FlowCollectorOpts.TlsCurvePreferences,
+		"FLOW_ACME_DOMAINS":          site.FlowCollectorOpts.AcmeDomains,
+		"FLOW_ACME_EMAIL":            site.FlowCollectorOpts.AcmeEmail,
+		"FLOW_ACME_DIRECTORY":        site.FlowCollectorOpts.AcmeDirectory,
+	} {
+		if value != "" {
+			flowComponent.Env[name] = value
+		}
+	}
 	flowDeployment := &SkupperDeployment{
 		Name: types.FlowCollectorContainerName,
 		SkupperDeploymentCommon: &domain.SkupperDeploymentCommon{
@@ -855,6 +1254,9 @@ func (s *SiteHandler) prepareFlowCollectorDeployment(site *Site) *SkupperDeploym
 		VolumeMounts:   volumeMounts,
 		Networks:       []string{site.ContainerNetwork},
 		SELinuxDisable: true,
+		RestartPolicy:  site.GetRestartPolicy(),
+		PinImageDigest: site.PinImageDigests,
+		Healthcheck:    site.flowCollectorHealthcheck(),
 	}
 
 	// Defining site ingresses
@@ -879,6 +1281,39 @@ func (s *SiteHandler) prepareFlowCollectorDeployment(site *Site) *SkupperDeploym
 	return flowDeployment
 }
 
+// engineEnv returns the environment of the containers reaching the
+// container engine, its socket being mounted when used
+func (s *SiteHandler) engineEnv(site *Site, volumeMounts map[string]string) map[string]string {
+	platform := s.cli.Platform()
+	endpoint := site.PodmanEndpoint
+	if s.cli.IsSockEndpoint() {
+		sockFile := s.cli.GetSockFile()
+		endpoint = fmt.Sprintf("/tmp/%s.sock", platform)
+		volumeMounts[sockFile] = endpoint
+	} else if platform == types.PlatformPodman && podman.IsTLSEndpoint(endpoint) {
+		// the certificates are mounted, so the endpoint names them in the container
+		files := podman.TLSFiles(endpoint)
+		for param, file := range files {
+			files[param] = path.Join("/etc/skupper-engine-tls", param+".pem")
+			volumeMounts[file] = files[param]
+		}
+		endpoint = podman.WithTLSFiles(endpoint, files)
+	}
+	if platform == types.PlatformDocker {
+		if s.cli.IsSockEndpoint() {
+			endpoint = "unix://" + endpoint
+		}
+		return map[string]string{
+			"SKUPPER_PLATFORM": string(platform),
+			"DOCKER_HOST":      endpoint,
+		}
+	}
+	return map[string]string{
+		"SKUPPER_PLATFORM": string(platform),
+		"PODMAN_ENDPOINT":  endpoint,
+	}
+}
+
 func (s *SiteHandler) createConsoleUser(site *Site) error {
 	v, err := s.cli.VolumeInspect(types.ConsoleUsersSecret)
 	if err != nil {
@@ -886,13 +1321,37 @@ func (s *SiteHandler) createConsoleUser(site *Site) error {
 	}
 	user := utils.DefaultStr(site.ConsoleUser, "admin")
 	password := utils.DefaultStr(site.ConsolePassword, utils.RandomId(10))
-	_, err = v.CreateFiles(map[string]string{user: password}, false)
+	token, hash, err := newConsoleCliToken()
+	if err != nil {
+		return fmt.Errorf("error creating console cli token - %w", err)
+	}
+	_, err = v.CreateFiles(map[string]string{
+		user:                  password,
+		consoleCliTokenFile:   token,
+		consoleTokensFile:     consoleCliTokenName + "=" + hash + "\n",
+		consoleSessionKeyFile: utils.RandomId(64),
+	}, false)
 	if err != nil {
 		return fmt.Errorf("error creating console user - %w", err)
 	}
 	return nil
 }
 
+// getConsoleCliToken returns the api token the cli authenticates to the
+// flow collector with, sites created by older versions have none
+func (s *SiteHandler) getConsoleCliToken() (string, error) {
+	if s.cli.IsRunningInContainer() {
+		data, err := os.ReadFile(path.Join("/etc/console-users", consoleCliTokenFile))
+		return strings.TrimSpace(string(data)), err
+	}
+	v, err := s.cli.VolumeInspect(types.ConsoleUsersSecret)
+	if err != nil {
+		return "", err
+	}
+	token, err := v.ReadFile(consoleCliTokenFile)
+	return strings.TrimSpace(token), err
+}
+
 func (s *SiteHandler) getConsoleUserPass() (string, string, error) {
 	v, err := s.cli.VolumeInspect(types.ConsoleUsersSecret)
 	if err != nil {
@@ -909,11 +1368,17 @@ func (s *SiteHandler) getConsoleUserPass() (string, string, error) {
 	if err != nil {
 		return "", "", err
 	}
-	if len(files) == 0 {
+	// the tokens files are not users
+	user := ""
+	for _, f := range files {
+		if !strings.HasPrefix(f.Name(), ".") {
+			user = f.Name()
+			break
+		}
+	}
+	if user == "" {
 		return "", "", fmt.Errorf("console user is not defined")
 	}
-	f := files[0]
-	user := f.Name()
 	var pass string
 	if !s.cli.IsRunningInContainer() {
 		pass, err = v.ReadFile(user)
@@ -942,14 +1407,8 @@ func (s *SiteHandler) prepareControllerDeployment(site *Site) *SkupperDeployment
 		types.SiteServerSecret:           "/etc/skupper-router-certs/skupper-internal/",
 	}
 
-	endpoint := site.PodmanEndpoint
-	if s.cli.IsSockEndpoint() {
-		sockFile := strings.TrimPrefix(s.cli.GetEndpoint(), "unix://")
-		endpoint = "/tmp/podman.sock"
-		volumeMounts[sockFile] = endpoint
-	}
-	memoryLimit, _ := strconv.ParseInt(site.ControllerOpts.MemoryLimit, 10, 64)
-	cpus, _ := strconv.Atoi(site.ControllerOpts.CpuLimit)
+	engineEnv := s.engineEnv(site, volumeMounts)
+	cpus, memoryLimit := tuningLimits(site.ControllerOpts.Tuning)
 	ctrlComponent := &domain.Controller{
 		// TODO ADD Labels
 		Labels: map[string]string{},
@@ -957,12 +1416,15 @@ func (s *SiteHandler) prepareControllerDeployment(site *Site) *SkupperDeployment
 			"SKUPPER_SITE_NAME":   site.GetName(),
 			"SKUPPER_SITE_ID":     site.GetId(),
 			"SKUPPER_ROUTER_MODE": site.GetMode(),
-			"SKUPPER_PLATFORM":    types.PlatformPodman,
-			"PODMAN_ENDPOINT":     endpoint,
 		},
 		MemoryLimit: memoryLimit,
 		Cpus:        cpus,
 	}
+	for name, value := range engineEnv {
+		ctrlComponent.Env[name] = value
+	}
+	setCredentialsKey(site, volumeMounts, ctrlComponent.Env)
+	setFipsMode(ctrlComponent.Env)
 	if site.AuthMode != types.ConsoleAuthModeUnsecured {
 		ctrlComponent.Env["FLOW_USERS"] = "/etc/console-users"
 		ctrlComponent.Env["METRICS_USERS"] = "/etc/console-users"
@@ -979,6 +1441,9 @@ func (s *SiteHandler) prepareControllerDeployment(site *Site) *SkupperDeployment
 		VolumeMounts:   volumeMounts,
 		Networks:       []string{site.ContainerNetwork},
 		SELinuxDisable: true,
+		RestartPolicy:  site.GetRestartPolicy(),
+		PinImageDigest: site.PinImageDigests,
+		Healthcheck:    site.controllerHealthcheck(),
 	}
 
 	// Defining site ingresses
@@ -993,8 +1458,7 @@ func (s *SiteHandler) preparePrometheusDeployment(site *Site) domain.SkupperDepl
 		"prometheus-server-config":  "/etc/prometheus",
 		"prometheus-storage-volume": "/prometheus",
 	}
-	memoryLimit, _ := strconv.ParseInt(site.PrometheusOpts.MemoryLimit, 10, 64)
-	cpus, _ := strconv.Atoi(site.PrometheusOpts.CpuLimit)
+	cpus, memoryLimit := tuningLimits(site.PrometheusOpts.Tuning)
 	prometheusComponent := &domain.Prometheus{
 		// TODO ADD Labels
 		Labels:      map[string]string{},
@@ -1012,10 +1476,67 @@ func (s *SiteHandler) preparePrometheusDeployment(site *Site) domain.SkupperDepl
 		VolumeMounts:   volumeMounts,
 		Networks:       []string{site.ContainerNetwork},
 		SELinuxDisable: true,
+		Command:        config.PrometheusServerArgs(site.PrometheusOpts.RetentionTime, site.PrometheusOpts.RetentionSize),
+		RestartPolicy:  site.GetRestartPolicy(),
+		PinImageDigest: site.PinImageDigests,
+		Healthcheck:    site.prometheusHealthcheck(),
 	}
 	return prometheusDeployment
 }
 
+func (s *SiteHandler) prepareMetricsExporterDeployment(site *Site) *SkupperDeployment {
+	// Router metrics exporter, scraped by a prometheus server outside the site
+	volumeMounts := map[string]string{
+		types.LocalClientSecret: "/etc/messaging",
+	}
+	exporterComponent := &domain.MetricsExporter{
+		// TODO ADD Labels
+		Labels: map[string]string{},
+		Env: map[string]string{
+			"SKUPPER_SITE_ID":  site.GetId(),
+			"SKUPPER_PLATFORM": string(s.cli.Platform()),
+		},
+	}
+	setCredentialsKey(site, volumeMounts, exporterComponent.Env)
+	setFipsMode(exporterComponent.Env)
+	exporterDeployment := &SkupperDeployment{
+		Name: types.MetricsExporterContainerName,
+		SkupperDeploymentCommon: &domain.SkupperDeploymentCommon{
+			Components: []domain.SkupperComponent{
+				exporterComponent,
+			},
+		},
+		Aliases:        []string{types.MetricsExporterContainerName},
+		VolumeMounts:   volumeMounts,
+		Networks:       []string{site.ContainerNetwork},
+		SELinuxDisable: true,
+		Command:        []string{"/app/controller-podman", fmt.Sprintf("-metrics-exporter=:%d", types.MetricsExporterDefaultPort)},
+		RestartPolicy:  site.GetRestartPolicy(),
+		PinImageDigest: site.PinImageDigests,
+	}
+
+	// Defining site ingresses
+	ingressBindIps := site.IngressBindIPs
+	if len(ingressBindIps) == 0 {
+		ingressBindIps = append(ingressBindIps, "")
+	}
+	for _, ingressBindIp := range ingressBindIps {
+		exporterComponent.SiteIngresses = append(exporterComponent.SiteIngresses, &SiteIngressHost{
+			SiteIngressCommon: &domain.SiteIngressCommon{
+				Name: types.MetricsExporterContainerName,
+				Host: ingressBindIp,
+				Port: site.IngressBindMetricsExporterPort,
+				Target: &domain.PortCommon{
+					Name: types.MetricsExporterContainerName,
+					Port: int(types.MetricsExporterDefaultPort),
+				},
+			},
+		})
+	}
+
+	return exporterDeployment
+}
+
 func (s *SiteHandler) getPrometheusServerOptions() (types.PrometheusServerOptions, error) {
 	var prometheusConfig types.PrometheusServerOptions
 	v, err := s.cli.VolumeInspect("prometheus-server-config")
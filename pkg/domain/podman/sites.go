@@ -0,0 +1,112 @@
+package podman
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/skupperproject/skupper/client/podman"
+	"github.com/skupperproject/skupper/pkg/config"
+)
+
+// SiteHost is a podman host the site of which is listed, either the local
+// host of the user or the host of a context
+type SiteHost struct {
+	Name     string
+	Endpoint string
+	Local    bool
+}
+
+// SiteSummary describes the site of a podman host, Enabled being false
+// when the host has no site and Err telling why it could not be read
+type SiteSummary struct {
+	Host     SiteHost
+	Name     string
+	Id       string
+	Mode     string
+	Version  string
+	Links    int
+	Services int
+	Enabled  bool
+	Err      error
+}
+
+// SiteHosts returns the local podman host followed by the hosts of the
+// contexts
+func SiteHosts(localEndpoint string, contexts *Contexts) []SiteHost {
+	hosts := []SiteHost{{Name: "local", Endpoint: localEndpoint, Local: true}}
+	if contexts == nil {
+		return hosts
+	}
+	for _, ctx := range contexts.Contexts {
+		hosts = append(hosts, SiteHost{Name: ctx.Name, Endpoint: ctx.Endpoint()})
+	}
+	return hosts
+}
+
+// LocalSiteHosts returns the hosts of the local sites identified by the
+// given site ids, each one served by its own podman service
+func LocalSiteHosts(siteIds []string) []SiteHost {
+	var hosts []SiteHost
+	for _, siteId := range siteIds {
+		hosts = append(hosts, SiteHost{
+			Name:     "local/" + siteId,
+			Endpoint: config.NewPodmanSiteService(siteId).Endpoint(),
+			Local:    true,
+		})
+	}
+	return hosts
+}
+
+// SummarizeSites reads the sites of the hosts concurrently, as reaching
+// the remote hosts can be slow, and returns them in the order of the hosts
+func SummarizeSites(hosts []SiteHost, summarize func(host SiteHost) SiteSummary) []SiteSummary {
+	summaries := make([]SiteSummary, len(hosts))
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host SiteHost) {
+			defer wg.Done()
+			summaries[i] = summarize(host)
+		}(i, host)
+	}
+	wg.Wait()
+	return summaries
+}
+
+// SummarizeSite reads the site of a podman host along with the number of
+// its links and services
+func SummarizeSite(host SiteHost) SiteSummary {
+	summary := SiteSummary{Host: host}
+	cli, err := podman.NewPodmanClient(host.Endpoint, "")
+	if err == nil {
+		err = cli.Validate()
+	}
+	if err != nil {
+		summary.Err = fmt.Errorf("podman endpoint is not available - %w", err)
+		return summary
+	}
+	current, err := NewSitePodmanHandlerFromCli(cli).Get()
+	if err != nil {
+		// no site has been initialized on the host
+		return summary
+	}
+	site := current.(*Site)
+	summary.Enabled = true
+	summary.Name = site.GetName()
+	summary.Id = site.GetId()
+	summary.Mode = site.GetMode()
+	summary.Version = site.GetVersion()
+	links, err := NewLinkHandlerPodman(site, cli).List()
+	if err != nil {
+		summary.Err = fmt.Errorf("error retrieving links - %w", err)
+		return summary
+	}
+	summary.Links = len(links)
+	services, err := NewServiceInterfaceHandlerPodman(cli).List()
+	if err != nil {
+		summary.Err = fmt.Errorf("error retrieving services - %w", err)
+		return summary
+	}
+	summary.Services = len(services)
+	return summary
+}
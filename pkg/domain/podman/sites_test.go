@@ -0,0 +1,78 @@
+package podman
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/config"
+	"github.com/skupperproject/skupper/pkg/domain"
+	"gotest.tools/assert"
+)
+
+func TestSummarizeSites(t *testing.T) {
+	contexts := &Contexts{}
+	assert.Assert(t, contexts.Add(Context{Name: "edge", Host: "ssh://skupper@edge.example.com/run/user/1000/podman/podman.sock"}))
+	assert.Assert(t, contexts.Add(Context{Name: "lab", Host: "tcp://lab.example.com:8888"}))
+
+	hosts := SiteHosts("unix:///run/user/1000/podman/podman.sock", contexts)
+	assert.Equal(t, len(hosts), 3)
+	assert.DeepEqual(t, hosts[0], SiteHost{Name: "local", Endpoint: "unix:///run/user/1000/podman/podman.sock", Local: true})
+	assert.DeepEqual(t, hosts[1], SiteHost{Name: "edge", Endpoint: "ssh://skupper@edge.example.com/run/user/1000/podman/podman.sock"})
+	assert.DeepEqual(t, hosts[2], SiteHost{Name: "lab", Endpoint: "tcp://lab.example.com:8888"})
+	assert.Equal(t, len(SiteHosts("", nil)), 1)
+
+	// the slowest host is read first, the order of the hosts must be kept
+	summaries := SummarizeSites(hosts, func(host SiteHost) SiteSummary {
+		summary := SiteSummary{Host: host}
+		switch host.Name {
+		case "local":
+			time.Sleep(50 * time.Millisecond)
+			summary.Enabled = true
+			summary.Name = "west"
+		case "edge":
+			summary.Err = fmt.Errorf("podman endpoint is not available")
+		}
+		return summary
+	})
+	assert.Equal(t, len(summaries), 3)
+	assert.Equal(t, summaries[0].Host.Name, "local")
+	assert.Equal(t, summaries[0].Name, "west")
+	assert.Assert(t, summaries[0].Enabled)
+	assert.Equal(t, summaries[1].Host.Name, "edge")
+	assert.ErrorContains(t, summaries[1].Err, "not available")
+	assert.Equal(t, summaries[2].Host.Name, "lab")
+	assert.Assert(t, !summaries[2].Enabled)
+}
+
+func TestLocalSiteHosts(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	assert.Assert(t, LocalSiteHosts(nil) == nil)
+	assert.DeepEqual(t, LocalSiteHosts([]string{"east", "west"}), []SiteHost{
+		{Name: "local/east", Endpoint: "unix:///run/user/1000/skupper/east/podman.sock", Local: true},
+		{Name: "local/west", Endpoint: "unix:///run/user/1000/skupper/west/podman.sock", Local: true},
+	})
+}
+
+func TestValidateSiteIdOpts(t *testing.T) {
+	defer func() { config.SiteId = "" }()
+	site := &Site{SiteCommon: &domain.SiteCommon{}, EnableSystemdUnits: true}
+	assert.Assert(t, site.ValidateSiteIdOpts())
+
+	config.SiteId = "west"
+	assert.ErrorContains(t, site.ValidateSiteIdOpts(), "the containers of the site west are started by its podman service")
+	site.EnableSystemdUnits = false
+	assert.Assert(t, site.ValidateSiteIdOpts())
+	site.Platform = string(types.PlatformDocker)
+	assert.ErrorContains(t, site.ValidateSiteIdOpts(), "site ids are not available on the docker platform")
+}
+
+func TestValidateSystemdUnitsOpts(t *testing.T) {
+	site := &Site{SiteCommon: &domain.SiteCommon{}, EnableSystemdUnits: true}
+	assert.Assert(t, site.ValidateSystemdUnitsOpts())
+	site.Platform = string(types.PlatformDocker)
+	assert.ErrorContains(t, site.ValidateSystemdUnitsOpts(), "systemd units are not available on the docker platform")
+	site.EnableSystemdUnits = false
+	assert.Assert(t, site.ValidateSystemdUnitsOpts())
+}
@@ -3,16 +3,22 @@ package podman
 import (
 	"context"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/skupperproject/skupper/api/types"
-	clientpodman "github.com/skupperproject/skupper/client/podman"
+	"github.com/skupperproject/skupper/client/container"
 	"github.com/skupperproject/skupper/pkg/domain"
 	"github.com/skupperproject/skupper/pkg/images"
 	"github.com/skupperproject/skupper/pkg/utils"
 	"github.com/skupperproject/skupper/pkg/version"
 )
 
-func NewVersionUpdateTask(cli *clientpodman.PodmanRestClient) *VersionUpdateTask {
+// routerReadyTimeout is how long the router is waited for after its image
+// is updated
+const routerReadyTimeout = 2 * time.Minute
+
+func NewVersionUpdateTask(cli container.EngineClient) *VersionUpdateTask {
 	return &VersionUpdateTask{
 		cli:     cli,
 		version: version.Version,
@@ -20,7 +26,7 @@ func NewVersionUpdateTask(cli *clientpodman.PodmanRestClient) *VersionUpdateTask
 }
 
 type VersionUpdateTask struct {
-	cli     *clientpodman.PodmanRestClient
+	cli     container.EngineClient
 	version string
 }
 
@@ -60,7 +66,101 @@ func (v *VersionUpdateTask) Run(context.Context) *domain.UpdateResult {
 	return res
 }
 
-func NewContainerImagesTask(cli *clientpodman.PodmanRestClient) *ContainerImagesTask {
+// componentImage returns the image the given component runs in the current
+// version
+func componentImage(name string) string {
+	switch name {
+	case types.TransportDeploymentName:
+		return images.GetRouterImageName()
+	case types.FlowCollectorContainerName:
+		return images.GetFlowCollectorImageName()
+	case types.ControllerPodmanContainerName, types.MetricsExporterContainerName:
+		return images.GetControllerPodmanImageName()
+	case types.PrometheusDeploymentName:
+		return images.GetPrometheusServerImageName()
+	}
+	return ""
+}
+
+// pinnedComponentImage returns the image of the given component pinned to
+// the digest of the image pulled, when the site pins the image digests
+func pinnedComponentImage(ctx context.Context, cli container.EngineClient, site *Site, name string) (string, error) {
+	image := componentImage(name)
+	if image == "" || !site.PinImageDigests {
+		return image, nil
+	}
+	if err := cli.ImagePull(ctx, image); err != nil {
+		return "", fmt.Errorf("error pulling image %s - %w", image, err)
+	}
+	img, err := cli.ImageInspect(image)
+	if err != nil {
+		return "", fmt.Errorf("error inspecting image %s - %w", image, err)
+	}
+	if img.Digest == "" {
+		return image, nil
+	}
+	return container.PinnedImage(image, img.Digest), nil
+}
+
+func NewImagesPullTask(cli container.EngineClient) *ImagesPullTask {
+	return &ImagesPullTask{
+		cli:     cli,
+		version: version.Version,
+	}
+}
+
+// ImagesPullTask pulls the images of the current version before any
+// container is updated, so the components are not left stopped while
+// their images are pulled
+type ImagesPullTask struct {
+	cli     container.EngineClient
+	version string
+}
+
+func (u *ImagesPullTask) Info() string {
+	return "Pulls skupper podman container images"
+}
+
+func (u *ImagesPullTask) AppliesTo(siteVersion string) bool {
+	curVersion := utils.ParseVersion(siteVersion)
+	return !(&curVersion).IsUndefined() && utils.LessRecentThanVersion(siteVersion, u.version)
+}
+
+func (u *ImagesPullTask) Version() string {
+	return "*"
+}
+
+func (u *ImagesPullTask) Priority() domain.UpdatePriority {
+	return domain.PriorityFirst
+}
+
+func (u *ImagesPullTask) Run(ctx context.Context) *domain.UpdateResult {
+	var result = &domain.UpdateResult{}
+	sh := NewSitePodmanHandlerFromCli(u.cli)
+	site, err := sh.Get()
+	if err != nil {
+		result.AddErrors(fmt.Errorf("error retrieving site info: %s", err))
+		return result
+	}
+	pulled := map[string]bool{}
+	for _, dep := range site.GetDeployments() {
+		for _, cmp := range dep.GetComponents() {
+			image := componentImage(cmp.Name())
+			if image == "" || pulled[image] {
+				continue
+			}
+			if err = u.cli.ImagePull(ctx, image); err != nil {
+				result.AddErrors(fmt.Errorf("error pulling image %s: %s", image, err))
+				return result
+			}
+			pulled[image] = true
+			result.AddChange(fmt.Sprintf("image pulled: %s", image))
+		}
+	}
+	return result
+}
+
+func NewContainerImagesTask(cli container.EngineClient) *ContainerImagesTask {
 	return &ContainerImagesTask{
 		cli:     cli,
 		version: version.Version,
@@ -68,7 +168,7 @@ func NewContainerImagesTask(cli *clientpodman.PodmanRestClient) *ContainerImages
 }
 
 type ContainerImagesTask struct {
-	cli     *clientpodman.PodmanRestClient
+	cli     container.EngineClient
 	version string
 }
 
@@ -97,21 +197,23 @@ func (u *ContainerImagesTask) Run(ctx context.Context) *domain.UpdateResult {
 		result.AddErrors(fmt.Errorf("error retrieving site info: %s", err))
 		return result
 	}
+	podmanSite := site.(*Site)
+	// the router is updated last, so the links of the site only go down
+	// once, when the other components already run their new images
+	deployments := site.GetDeployments()
+	sort.SliceStable(deployments, func(i, j int) bool {
+		return deployments[i].GetName() != types.TransportDeploymentName && deployments[j].GetName() == types.TransportDeploymentName
+	})
 	// updating images for all running components
-	for _, dep := range site.GetDeployments() {
+	routerUpdated := false
+	for _, dep := range deployments {
 		for _, cmp := range dep.GetComponents() {
-			var image string
-			switch cmp.Name() {
-			case types.TransportDeploymentName:
-				image = images.GetRouterImageName()
-			case types.FlowCollectorContainerName:
-				image = images.GetFlowCollectorImageName()
-			case types.ControllerPodmanContainerName:
-				image = images.GetControllerPodmanImageName()
-			case types.PrometheusDeploymentName:
-				image = images.GetPrometheusServerImageName()
+			image, err := pinnedComponentImage(ctx, u.cli, podmanSite, cmp.Name())
+			if err != nil {
+				result.AddErrors(err)
+				return result
 			}
-			if image != cmp.GetImage() {
+			if image != "" && image != cmp.GetImage() {
 				_, err = u.cli.ContainerUpdateImage(ctx, cmp.Name(), image)
 				if err != nil {
 					result.AddErrors(fmt.Errorf("error updating container: %s - image: %s - %s",
@@ -119,9 +221,16 @@ func (u *ContainerImagesTask) Run(ctx context.Context) *domain.UpdateResult {
 					return result
 				}
 				result.AddChange(fmt.Sprintf("container updated: %s - image: %s", cmp.Name(), image))
+				routerUpdated = routerUpdated || cmp.Name() == types.TransportDeploymentName
 			}
 		}
 	}
+	// the service containers are updated once the router is ready again
+	if routerUpdated {
+		if err = u.waitRouterReady(ctx); err != nil {
+			result.AddWarnings(fmt.Sprintf("%s is not ready yet: %s", types.TransportDeploymentName, err))
+		}
+	}
 	// updating service containers
 	updSvcResult := u.updateServiceContainers(ctx)
 	if len(updSvcResult.Errors) > 0 {
@@ -134,6 +243,31 @@ func (u *ContainerImagesTask) Run(ctx context.Context) *domain.UpdateResult {
 	return result
 }
 
+// waitRouterReady waits for the router container to run and, when it has a
+// healthcheck, to be healthy
+func (u *ContainerImagesTask) waitRouterReady(ctx context.Context) error {
+	ctx, cn := context.WithTimeout(ctx, routerReadyTimeout)
+	defer cn()
+	var notReady error
+	err := utils.RetryErrorWithContext(ctx, time.Second, func() error {
+		router, err := u.cli.ContainerInspect(types.TransportDeploymentName)
+		if err != nil {
+			notReady = err
+		} else if !router.Running {
+			notReady = fmt.Errorf("container is not running")
+		} else if router.Healthcheck != nil && router.Health != container.HealthHealthy {
+			notReady = fmt.Errorf("container is %s", utils.DefaultStr(router.Health, container.HealthStarting))
+		} else {
+			notReady = nil
+		}
+		return notReady
+	})
+	if err != nil && notReady != nil {
+		return notReady
+	}
+	return err
+}
+
 func (u *ContainerImagesTask) updateServiceContainers(ctx context.Context) domain.UpdateResult {
 	var result domain.UpdateResult
 	sh := NewServiceHandlerPodman(u.cli)
@@ -6,16 +6,15 @@ import (
 
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/client/container"
-	clientpodman "github.com/skupperproject/skupper/client/podman"
 	"github.com/skupperproject/skupper/pkg/domain"
 	"github.com/skupperproject/skupper/pkg/utils"
 )
 
 type SkupperNetworkStatusVolume struct {
-	cli *clientpodman.PodmanRestClient
+	cli container.EngineClient
 }
 
-func (m *SkupperNetworkStatusVolume) WithCli(cli *clientpodman.PodmanRestClient) *SkupperNetworkStatusVolume {
+func (m *SkupperNetworkStatusVolume) WithCli(cli container.EngineClient) *SkupperNetworkStatusVolume {
 	m.cli = cli
 	return m
 }
@@ -0,0 +1,112 @@
+package podman
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	"github.com/skupperproject/skupper/client/podman"
+	"github.com/skupperproject/skupper/pkg/utils"
+)
+
+const (
+	// MountOptionsQualifier labels the volumes mounted with options other
+	// than the default shared SELinux relabeling (z)
+	MountOptionsQualifier = types.InternalQualifier + "/mount-options"
+)
+
+var ValidMountOptions = []string{"z", "Z", "U", "ro", "rw", "nocopy"}
+
+// ValidateVolumeOpts verifies the host paths and the mount options are
+// given for volumes of the site, the host paths being absolute and the
+// options known
+func (s *Site) ValidateVolumeOpts() error {
+	for name, hostPath := range s.VolumeHostPaths {
+		if !utils.StringSliceContains(SkupperContainerVolumes, name) {
+			return fmt.Errorf("invalid volume %s - valid volumes are: %s", name, SkupperContainerVolumes)
+		}
+		if !filepath.IsAbs(hostPath) {
+			return fmt.Errorf("the host path of volume %s must be absolute: %s", name, hostPath)
+		}
+	}
+	for name, options := range s.VolumeMountOptions {
+		if !utils.StringSliceContains(SkupperContainerVolumes, name) {
+			return fmt.Errorf("invalid volume %s - valid volumes are: %s", name, SkupperContainerVolumes)
+		}
+		opts := strings.Split(options, ",")
+		for _, opt := range opts {
+			if !utils.StringSliceContains(ValidMountOptions, opt) {
+				return fmt.Errorf("invalid mount option %q for volume %s - valid options are: %s", opt, name, ValidMountOptions)
+			}
+		}
+		if utils.StringSliceContains(opts, "z") && utils.StringSliceContains(opts, "Z") {
+			return fmt.Errorf("volume %s cannot be relabeled as both shared (z) and private (Z)", name)
+		}
+		if utils.StringSliceContains(opts, "ro") && utils.StringSliceContains(opts, "rw") {
+			return fmt.Errorf("volume %s cannot be mounted both read-only (ro) and read-write (rw)", name)
+		}
+	}
+	return nil
+}
+
+// volume returns the definition of the named volume of the site, backed by
+// a directory of the host and mounted with custom options when given
+func (s *Site) volume(name string) *container.Volume {
+	v := &container.Volume{
+		Name:   name,
+		Labels: map[string]string{},
+	}
+	if hostPath, ok := s.VolumeHostPaths[name]; ok {
+		v.Options = container.BindVolumeOptions(hostPath)
+	}
+	if options, ok := s.VolumeMountOptions[name]; ok {
+		v.Labels[MountOptionsQualifier] = options
+	}
+	return v
+}
+
+// createVolumeHostPaths creates the directories backing volumes, which is
+// only possible when the container engine runs on the local host
+func (s *SiteHandler) createVolumeHostPaths(site *Site) error {
+	if len(site.VolumeHostPaths) == 0 || !s.cli.IsSockEndpoint() || podman.IsSSHEndpoint(s.endpoint) {
+		return nil
+	}
+	for name, hostPath := range site.VolumeHostPaths {
+		if err := os.MkdirAll(hostPath, 0755); err != nil {
+			return fmt.Errorf("unable to create the host path of volume %s - %w", name, err)
+		}
+	}
+	return nil
+}
+
+// readVolumes restores the host paths and the mount options of the volumes
+// of the site
+func (s *SiteHandler) readVolumes(site *Site) {
+	for _, name := range SkupperContainerVolumes {
+		vol, err := s.cli.VolumeInspect(name)
+		if err != nil {
+			continue
+		}
+		if hostPath := container.VolumeSource("", vol.Options); hostPath != "" {
+			if site.VolumeHostPaths == nil {
+				site.VolumeHostPaths = map[string]string{}
+			}
+			site.VolumeHostPaths[name] = hostPath
+		}
+		if options, ok := vol.GetLabels()[MountOptionsQualifier]; ok {
+			if site.VolumeMountOptions == nil {
+				site.VolumeMountOptions = map[string]string{}
+			}
+			site.VolumeMountOptions[name] = options
+		}
+	}
+}
+
+// volumeMode returns the options a volume is mounted with, the shared
+// SELinux relabeling (z) by default
+func volumeMode(v *container.Volume) string {
+	return utils.DefaultStr(v.GetLabels()[MountOptionsQualifier], "z")
+}
@@ -0,0 +1,65 @@
+package podman
+
+import (
+	"testing"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/client/container"
+	"gotest.tools/assert"
+)
+
+func TestValidateVolumeOpts(t *testing.T) {
+	testTable := []struct {
+		doc          string
+		hostPaths    map[string]string
+		mountOptions map[string]string
+		err          string
+	}{
+		{doc: "defaults"},
+		{doc: "host path", hostPaths: map[string]string{types.TransportConfigMapName: "/srv/skupper/internal"}},
+		{doc: "mount options", mountOptions: map[string]string{types.SiteServerSecret: "Z,ro", types.TransportConfigMapName: "z,U"}},
+		{doc: "unknown volume host path", hostPaths: map[string]string{"skupper-data": "/srv/skupper/data"},
+			err: "invalid volume skupper-data"},
+		{doc: "relative host path", hostPaths: map[string]string{types.TransportConfigMapName: "skupper/internal"},
+			err: "the host path of volume skupper-internal must be absolute"},
+		{doc: "unknown volume mount options", mountOptions: map[string]string{"skupper-data": "Z"},
+			err: "invalid volume skupper-data"},
+		{doc: "invalid mount option", mountOptions: map[string]string{types.TransportConfigMapName: "Z,noexec"},
+			err: `invalid mount option "noexec" for volume skupper-internal`},
+		{doc: "shared and private relabeling", mountOptions: map[string]string{types.TransportConfigMapName: "z,Z"},
+			err: "volume skupper-internal cannot be relabeled as both shared (z) and private (Z)"},
+		{doc: "read-only and read-write", mountOptions: map[string]string{types.TransportConfigMapName: "ro,rw"},
+			err: "volume skupper-internal cannot be mounted both read-only (ro) and read-write (rw)"},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			site := &Site{
+				VolumeHostPaths:    test.hostPaths,
+				VolumeMountOptions: test.mountOptions,
+			}
+			err := site.ValidateVolumeOpts()
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+		})
+	}
+}
+
+func TestSiteVolume(t *testing.T) {
+	site := &Site{
+		VolumeHostPaths:    map[string]string{types.TransportConfigMapName: "/srv/skupper/internal"},
+		VolumeMountOptions: map[string]string{types.TransportConfigMapName: "Z"},
+	}
+	internal := site.volume(types.TransportConfigMapName)
+	assert.Equal(t, internal.Name, types.TransportConfigMapName)
+	assert.DeepEqual(t, internal.Options, container.BindVolumeOptions("/srv/skupper/internal"))
+	assert.Equal(t, container.VolumeSource("/var/lib/volumes/skupper-internal/_data", internal.Options), "/srv/skupper/internal")
+	assert.Equal(t, volumeMode(internal), "Z")
+
+	certs := site.volume(types.SiteServerSecret)
+	assert.Assert(t, certs.Options == nil)
+	assert.Equal(t, container.VolumeSource("/var/lib/volumes/skupper-site-server/_data", certs.Options), "/var/lib/volumes/skupper-site-server/_data")
+	assert.Equal(t, volumeMode(certs), "z")
+}
@@ -33,6 +33,8 @@ type Service interface {
 	SetEventChannel(eventChannel bool)
 	GetAggregate() string
 	SetAggregate(strategy string)
+	GetAggregateOptions() *types.AggregateOptions
+	SetAggregateOptions(options *types.AggregateOptions)
 	GetLabels() map[string]string
 	SetLabels(labels map[string]string)
 	GetOrigin() string
@@ -55,6 +57,7 @@ type ServiceCommon struct {
 	Ports            []int
 	EventChannel     bool
 	Aggregate        string
+	AggregateOptions *types.AggregateOptions
 	Labels           map[string]string
 	Origin           string
 	TlsCredentials   string
@@ -103,6 +106,14 @@ func (s *ServiceCommon) SetAggregate(strategy string) {
 	s.Aggregate = strategy
 }
 
+func (s *ServiceCommon) GetAggregateOptions() *types.AggregateOptions {
+	return s.AggregateOptions
+}
+
+func (s *ServiceCommon) SetAggregateOptions(options *types.AggregateOptions) {
+	s.AggregateOptions = options
+}
+
 func (s *ServiceCommon) GetLabels() map[string]string {
 	return s.Labels
 }
@@ -192,6 +203,10 @@ func ValidateService(service Service) error {
 		return fmt.Errorf("%s is not a valid mapping. Choose 'tcp', 'http' or 'http2'.", service.GetProtocol())
 	} else if service.GetAggregate() != "" && service.GetProtocol() != "http" {
 		return fmt.Errorf("The aggregate option is currently only valid for http")
+	} else if !service.GetAggregateOptions().IsEmpty() && service.GetAggregate() == "" {
+		return fmt.Errorf("The aggregate timeout, partial and max parallel options require an aggregation strategy")
+	} else if err := service.GetAggregateOptions().Validate(); err != nil {
+		return err
 	} else if service.IsEventChannel() && service.GetProtocol() != "http" {
 		return fmt.Errorf("The event-channel option is currently only valid for http")
 	} else if service.IsTls() && service.GetProtocol() != "http2" {
@@ -249,16 +264,18 @@ func CreateRouterServiceConfig(site Site, parentRouterConfig *qdr.RouterConfig,
 				SslProfile: service.GetTlsCredentials(),
 			})
 		case "http":
-			svcRouterConfig.AddHttpListener(qdr.HttpEndpoint{
+			httpListener := qdr.HttpEndpoint{
 				Name:         listenerName,
 				Port:         listenerPort,
 				Address:      listenerAddr,
 				SiteId:       siteId,
 				Aggregation:  service.GetAggregate(),
 				EventChannel: service.IsEventChannel(),
-			})
+			}
+			httpListener.SetAggregateOptions(service.GetAggregateOptions())
+			svcRouterConfig.AddHttpListener(httpListener)
 		case "http2":
-			svcRouterConfig.AddHttpListener(qdr.HttpEndpoint{
+			httpListener := qdr.HttpEndpoint{
 				Name:            listenerName,
 				Port:            listenerPort,
 				Address:         listenerAddr,
@@ -267,7 +284,9 @@ func CreateRouterServiceConfig(site Site, parentRouterConfig *qdr.RouterConfig,
 				Aggregation:     service.GetAggregate(),
 				EventChannel:    service.IsEventChannel(),
 				SslProfile:      service.GetTlsCredentials(),
-			})
+			}
+			httpListener.SetAggregateOptions(service.GetAggregateOptions())
+			svcRouterConfig.AddHttpListener(httpListener)
 		}
 	}
 
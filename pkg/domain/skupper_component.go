@@ -14,7 +14,7 @@ type SkupperComponent interface {
 	GetLabels() map[string]string
 	GetSiteIngresses() []SiteIngress
 	GetMemoryLimit() int64
-	GetCpus() int
+	GetCpus() float64
 }
 
 type SkupperComponentHandler interface {
@@ -28,7 +28,7 @@ type Router struct {
 	Labels        map[string]string
 	SiteIngresses []SiteIngress
 	MemoryLimit   int64
-	Cpus          int
+	Cpus          float64
 }
 
 func (r *Router) Name() string {
@@ -65,7 +65,7 @@ func (r *Router) GetMemoryLimit() int64 {
 	return r.MemoryLimit
 }
 
-func (r *Router) GetCpus() int {
+func (r *Router) GetCpus() float64 {
 	return r.Cpus
 }
 
@@ -75,7 +75,7 @@ type FlowCollector struct {
 	Labels        map[string]string
 	SiteIngresses []SiteIngress
 	MemoryLimit   int64
-	Cpus          int
+	Cpus          float64
 }
 
 func (r *FlowCollector) Name() string {
@@ -112,7 +112,7 @@ func (r *FlowCollector) GetMemoryLimit() int64 {
 	return r.MemoryLimit
 }
 
-func (r *FlowCollector) GetCpus() int {
+func (r *FlowCollector) GetCpus() float64 {
 	return r.Cpus
 }
 
@@ -122,7 +122,7 @@ type Controller struct {
 	Labels        map[string]string
 	SiteIngresses []SiteIngress
 	MemoryLimit   int64
-	Cpus          int
+	Cpus          float64
 }
 
 func (s *Controller) Name() string {
@@ -159,7 +159,7 @@ func (s *Controller) GetMemoryLimit() int64 {
 	return s.MemoryLimit
 }
 
-func (s *Controller) GetCpus() int {
+func (s *Controller) GetCpus() float64 {
 	return s.Cpus
 }
 
@@ -169,7 +169,7 @@ type Prometheus struct {
 	Labels        map[string]string
 	SiteIngresses []SiteIngress
 	MemoryLimit   int64
-	Cpus          int
+	Cpus          float64
 }
 
 func (s *Prometheus) Name() string {
@@ -206,6 +206,55 @@ func (s *Prometheus) GetMemoryLimit() int64 {
 	return s.MemoryLimit
 }
 
-func (s *Prometheus) GetCpus() int {
+func (s *Prometheus) GetCpus() float64 {
+	return s.Cpus
+}
+
+// MetricsExporter exposes the management data of the local router as
+// prometheus metrics, it runs from the podman controller image
+type MetricsExporter struct {
+	Image         string
+	Env           map[string]string
+	Labels        map[string]string
+	SiteIngresses []SiteIngress
+	MemoryLimit   int64
+	Cpus          float64
+}
+
+func (s *MetricsExporter) Name() string {
+	return types.MetricsExporterContainerName
+}
+
+func (s *MetricsExporter) GetImage() string {
+	return utils.StrDefault(images.GetControllerPodmanImageName(), s.Image)
+}
+
+func (s *MetricsExporter) SetImage(image string) {
+	s.Image = image
+}
+
+func (s *MetricsExporter) GetEnv() map[string]string {
+	if s.Env == nil {
+		s.Env = map[string]string{}
+	}
+	return s.Env
+}
+
+func (s *MetricsExporter) GetLabels() map[string]string {
+	if s.Labels == nil {
+		s.Labels = map[string]string{}
+	}
+	return s.Labels
+}
+
+func (s *MetricsExporter) GetSiteIngresses() []SiteIngress {
+	return s.SiteIngresses
+}
+
+func (s *MetricsExporter) GetMemoryLimit() int64 {
+	return s.MemoryLimit
+}
+
+func (s *MetricsExporter) GetCpus() float64 {
 	return s.Cpus
 }
@@ -0,0 +1,235 @@
+// Package encryption encrypts the credentials of podman sites at rest, the
+// encrypted files describing where their key is found so that they are
+// decrypted transparently by the processes reading them
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// PassphraseEnvVar holds the passphrase of the credentials encrypted with
+// a passphrase
+const PassphraseEnvVar = "SKUPPER_CREDENTIALS_PASSPHRASE"
+
+// KeyFileEnvVar holds the path of a file with the key of the credentials.
+// The cli resolves the key, from the passphrase or the kernel keyring, and
+// hands it over to the containers in a file only their user can read, so
+// that they need neither the passphrase in their environment nor access to
+// the keyring of the host.
+const KeyFileEnvVar = "SKUPPER_CREDENTIALS_KEY_FILE"
+
+const (
+	header   = "SKUPPER-ENCRYPTED v1 "
+	saltSize = 16
+)
+
+type Method string
+
+const (
+	// MethodKeyring uses a user key of the kernel keyring
+	MethodKeyring Method = "keyring"
+	// MethodPassphrase uses the passphrase in SKUPPER_CREDENTIALS_PASSPHRASE
+	MethodPassphrase Method = "passphrase"
+)
+
+// Options selects how the credentials are encrypted, no encryption when the
+// method is empty
+type Options struct {
+	Method     Method
+	KeyringKey string
+}
+
+func (o Options) Enabled() bool {
+	return o.Method != ""
+}
+
+func (o Options) String() string {
+	if o.Method == MethodKeyring {
+		return fmt.Sprintf("%s:%s", o.Method, o.KeyringKey)
+	}
+	return string(o.Method)
+}
+
+// Validate checks the key of the options is available
+func (o Options) Validate() error {
+	if !o.Enabled() {
+		return nil
+	}
+	_, err := o.secret()
+	return err
+}
+
+// Key returns the key of the options as resolved by the cli, to be handed
+// over to the containers through the file of KeyFileEnvVar
+func (o Options) Key() ([]byte, error) {
+	if !o.Enabled() {
+		return nil, nil
+	}
+	return o.secret()
+}
+
+func (o Options) secret() ([]byte, error) {
+	if keyFile := os.Getenv(KeyFileEnvVar); keyFile != "" {
+		key, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the credentials key - %w", err)
+		}
+		if len(key) == 0 {
+			return nil, fmt.Errorf("the credentials key in %s is empty", keyFile)
+		}
+		return key, nil
+	}
+	switch o.Method {
+	case MethodKeyring:
+		if o.KeyringKey == "" {
+			return nil, fmt.Errorf("the name of the kernel keyring key is required")
+		}
+		key, err := readKeyringKey(o.KeyringKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read key %q from the kernel keyring - %w", o.KeyringKey, err)
+		}
+		return key, nil
+	case MethodPassphrase:
+		passphrase := os.Getenv(PassphraseEnvVar)
+		if passphrase == "" {
+			return nil, fmt.Errorf("the passphrase must be set in %s", PassphraseEnvVar)
+		}
+		return []byte(passphrase), nil
+	}
+	return nil, fmt.Errorf("invalid encryption method %q, expected %s or %s", o.Method, MethodKeyring, MethodPassphrase)
+}
+
+func parseOptions(value string) (Options, error) {
+	method, key, _ := strings.Cut(value, ":")
+	options := Options{Method: Method(method), KeyringKey: key}
+	switch options.Method {
+	case MethodKeyring, MethodPassphrase:
+		return options, nil
+	}
+	return options, fmt.Errorf("invalid encryption method %q", method)
+}
+
+func (o Options) aead(salt []byte) (cipher.AEAD, error) {
+	secret, err := o.secret()
+	if err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key(secret, salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// IsEncrypted tells whether the data was returned by Encrypt
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(header))
+}
+
+// OptionsOf returns the options the data was encrypted with
+func OptionsOf(data []byte) (Options, bool) {
+	if !IsEncrypted(data) {
+		return Options{}, false
+	}
+	line, _, found := bytes.Cut(data[len(header):], []byte("\n"))
+	if !found {
+		return Options{}, false
+	}
+	options, err := parseOptions(string(line))
+	return options, err == nil
+}
+
+// Encrypt returns the data encrypted with AES-GCM, the key being derived
+// from the secret of the options, preceded by a header line describing the
+// options
+func Encrypt(data []byte, options Options) ([]byte, error) {
+	prefix := []byte(header + options.String() + "\n")
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := options.aead(salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	result := make([]byte, 0, len(prefix)+len(salt)+len(nonce)+len(data)+aead.Overhead())
+	result = append(result, prefix...)
+	result = append(result, salt...)
+	result = append(result, nonce...)
+	return aead.Seal(result, nonce, data, prefix), nil
+}
+
+// Decrypt returns the data as is when it is not encrypted
+func Decrypt(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	line, sealed, found := bytes.Cut(data[len(header):], []byte("\n"))
+	if !found {
+		return nil, fmt.Errorf("invalid encrypted data, no header")
+	}
+	options, err := parseOptions(string(line))
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < saltSize {
+		return nil, fmt.Errorf("invalid encrypted data, too short")
+	}
+	aead, err := options.aead(sealed[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+	sealed = sealed[saltSize:]
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("invalid encrypted data, too short")
+	}
+	prefix := data[:len(header)+len(line)+1]
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], prefix)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt the data, the %s key does not match - %w", options.Method, err)
+	}
+	return plain, nil
+}
+
+// ReadFile reads the file, decrypting it when encrypted
+func ReadFile(name string) ([]byte, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := Decrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return plain, nil
+}
+
+// LoadX509KeyPair is tls.LoadX509KeyPair for files that may be encrypted
+func LoadX509KeyPair(certFile string, keyFile string) (tls.Certificate, error) {
+	cert, err := ReadFile(certFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	key, err := ReadFile(keyFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(cert, key)
+}
@@ -0,0 +1,97 @@
+package encryption
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestEncryptPassphrase(t *testing.T) {
+	t.Setenv(PassphraseEnvVar, "secret")
+	options := Options{Method: MethodPassphrase}
+	plain := []byte("-----BEGIN CERTIFICATE-----\n")
+
+	encrypted, err := Encrypt(plain, options)
+	assert.Assert(t, err)
+	assert.Assert(t, IsEncrypted(encrypted))
+	assert.Assert(t, !IsEncrypted(plain))
+	found, ok := OptionsOf(encrypted)
+	assert.Assert(t, ok)
+	assert.Equal(t, found, options)
+
+	decrypted, err := Decrypt(encrypted)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, decrypted, plain)
+
+	// plain data is returned as is
+	decrypted, err = Decrypt(plain)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, decrypted, plain)
+
+	file := filepath.Join(t.TempDir(), "tls.crt")
+	assert.Assert(t, os.WriteFile(file, encrypted, 0600))
+	decrypted, err = ReadFile(file)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, decrypted, plain)
+
+	t.Setenv(PassphraseEnvVar, "other")
+	_, err = Decrypt(encrypted)
+	assert.ErrorContains(t, err, "the passphrase key does not match")
+
+	t.Setenv(PassphraseEnvVar, "")
+	_, err = Decrypt(encrypted)
+	assert.ErrorContains(t, err, "the passphrase must be set in "+PassphraseEnvVar)
+}
+
+func TestEncryptTampered(t *testing.T) {
+	t.Setenv(PassphraseEnvVar, "secret")
+	encrypted, err := Encrypt([]byte("data"), Options{Method: MethodPassphrase})
+	assert.Assert(t, err)
+
+	tampered := append([]byte{}, encrypted...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = Decrypt(tampered)
+	assert.ErrorContains(t, err, "unable to decrypt")
+
+	// the header is authenticated along with the data
+	relabeled := append([]byte(header+"keyring:skupper\n"), encrypted[len(header+"passphrase\n"):]...)
+	found, ok := OptionsOf(relabeled)
+	assert.Assert(t, ok)
+	assert.Equal(t, found, Options{Method: MethodKeyring, KeyringKey: "skupper"})
+	_, err = Decrypt(relabeled)
+	assert.Assert(t, err != nil)
+	_, err = Decrypt(append([]byte(header+"passphrase \n"), encrypted[len(header+"passphrase\n"):]...))
+	assert.ErrorContains(t, err, "invalid encryption method")
+}
+
+func TestOptionsValidate(t *testing.T) {
+	t.Setenv(PassphraseEnvVar, "")
+	assert.Assert(t, Options{}.Validate())
+	assert.ErrorContains(t, Options{Method: MethodPassphrase}.Validate(), PassphraseEnvVar)
+	assert.ErrorContains(t, Options{Method: MethodKeyring}.Validate(), "the name of the kernel keyring key is required")
+	assert.ErrorContains(t, Options{Method: "vault"}.Validate(), "invalid encryption method")
+}
+
+func TestEncryptKeyFile(t *testing.T) {
+	t.Setenv(PassphraseEnvVar, "secret")
+	options := Options{Method: MethodPassphrase}
+	encrypted, err := Encrypt([]byte("data"), options)
+	assert.Assert(t, err)
+	key, err := options.Key()
+	assert.Assert(t, err)
+
+	// the containers read the key from the file the cli wrote it to
+	t.Setenv(PassphraseEnvVar, "")
+	keyFile := filepath.Join(t.TempDir(), "key")
+	assert.Assert(t, os.WriteFile(keyFile, key, 0400))
+	t.Setenv(KeyFileEnvVar, keyFile)
+	decrypted, err := Decrypt(encrypted)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, decrypted, []byte("data"))
+
+	assert.Assert(t, os.Remove(keyFile))
+	_, err = Decrypt(encrypted)
+	assert.ErrorContains(t, err, "unable to read the credentials key")
+}
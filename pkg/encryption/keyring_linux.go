@@ -0,0 +1,29 @@
+//go:build linux
+// +build linux
+
+package encryption
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// readKeyringKey returns the payload of the user key, searched in the user
+// keyring first and then in the session keyring
+func readKeyringKey(description string) ([]byte, error) {
+	id, err := unix.KeyctlSearch(unix.KEY_SPEC_USER_KEYRING, "user", description, 0)
+	if err != nil {
+		id, err = unix.KeyctlSearch(unix.KEY_SPEC_SESSION_KEYRING, "user", description, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+	size, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	payload := make([]byte, size)
+	if _, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, payload, 0); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package encryption
+
+import (
+	"fmt"
+)
+
+func readKeyringKey(description string) ([]byte, error) {
+	return nil, fmt.Errorf("the kernel keyring is only available on linux")
+}
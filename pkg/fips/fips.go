@@ -0,0 +1,140 @@
+// Package fips restricts the certificates generated and the tls
+// configurations to the algorithms and key sizes approved by FIPS 140, the
+// mode being enabled when built with the fips tag or at runtime through
+// SKUPPER_FIPS_MODE
+package fips
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// EnvVar enables the FIPS mode at runtime
+const EnvVar = "SKUPPER_FIPS_MODE"
+
+const (
+	// RSAKeySize of the keys generated in FIPS mode
+	RSAKeySize = 3072
+	// RouterCiphers of the router ssl profiles in FIPS mode, in the openssl
+	// format
+	RouterCiphers = "ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256"
+	// RouterProtocols of the router ssl profiles in FIPS mode, tls 1.3 is
+	// left out as its chacha20 suite cannot be disabled
+	RouterProtocols = "TLSv1.2"
+)
+
+// CipherSuites approved for tls 1.2
+var CipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+}
+
+// CurvePreferences approved for the key exchange, X25519 is not
+var CurvePreferences = []tls.CurveID{
+	tls.CurveP384,
+	tls.CurveP256,
+	tls.CurveP521,
+}
+
+// Enabled tells whether the FIPS mode is on
+func Enabled() bool {
+	if buildEnabled {
+		return true
+	}
+	enabled, _ := strconv.ParseBool(os.Getenv(EnvVar))
+	return enabled
+}
+
+// RSAKeyBits returns the size of the rsa keys to generate
+func RSAKeyBits() int {
+	if Enabled() {
+		return RSAKeySize
+	}
+	return 2048
+}
+
+// RestrictTls limits the configuration to tls 1.2 and to the approved cipher
+// suites and curves, it is a no-op when the FIPS mode is off. The go tls 1.3
+// suites are not configurable and include chacha20, hence tls 1.2 only.
+func RestrictTls(config *tls.Config) {
+	if !Enabled() {
+		return
+	}
+	config.MinVersion = tls.VersionTLS12
+	config.MaxVersion = tls.VersionTLS12
+	config.CipherSuites = approved(config.CipherSuites, CipherSuites)
+	config.CurvePreferences = approved(config.CurvePreferences, CurvePreferences)
+}
+
+// approved keeps the configured items that are approved, all the approved
+// ones when none is configured
+func approved[T comparable](configured []T, allowed []T) []T {
+	if len(configured) == 0 {
+		return append([]T{}, allowed...)
+	}
+	result := []T{}
+	for _, item := range configured {
+		for _, a := range allowed {
+			if item == a {
+				result = append(result, item)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// ValidateCipherSuite refuses the cipher suites that are not approved in
+// FIPS mode
+func ValidateCipherSuite(id uint16) error {
+	if !Enabled() || len(approved([]uint16{id}, CipherSuites)) == 1 {
+		return nil
+	}
+	return fmt.Errorf("cipher suite %s is not allowed in FIPS mode", tls.CipherSuiteName(id))
+}
+
+// ValidateCurve refuses the curves that are not approved in FIPS mode
+func ValidateCurve(id tls.CurveID) error {
+	if !Enabled() || len(approved([]tls.CurveID{id}, CurvePreferences)) == 1 {
+		return nil
+	}
+	return fmt.Errorf("curve %s is not allowed in FIPS mode", id)
+}
+
+// ValidateTlsVersion refuses the versions other than tls 1.2 in FIPS mode
+func ValidateTlsVersion(version uint16) error {
+	if !Enabled() || version == tls.VersionTLS12 {
+		return nil
+	}
+	return fmt.Errorf("only tls 1.2 is allowed in FIPS mode")
+}
+
+// ValidatePublicKey refuses the keys that are not approved in FIPS mode, for
+// the certificates issued by an external CA
+func ValidatePublicKey(key crypto.PublicKey) error {
+	if !Enabled() {
+		return nil
+	}
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() >= 2048 {
+			return nil
+		}
+		return fmt.Errorf("rsa keys of %d bits are not allowed in FIPS mode, 2048 at least", k.N.BitLen())
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256(), elliptic.P384(), elliptic.P521():
+			return nil
+		}
+		return fmt.Errorf("curve %s is not allowed in FIPS mode", k.Curve.Params().Name)
+	}
+	return fmt.Errorf("%T keys are not allowed in FIPS mode", key)
+}
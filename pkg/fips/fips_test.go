@@ -0,0 +1,43 @@
+package fips
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestRestrictTls(t *testing.T) {
+	t.Setenv(EnvVar, "true")
+	config := &tls.Config{
+		MinVersion:       tls.VersionTLS10,
+		CipherSuites:     []uint16{tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+	}
+	RestrictTls(config)
+	assert.Equal(t, config.MinVersion, uint16(tls.VersionTLS12))
+	assert.Equal(t, config.MaxVersion, uint16(tls.VersionTLS12))
+	assert.DeepEqual(t, config.CipherSuites, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256})
+	assert.DeepEqual(t, config.CurvePreferences, []tls.CurveID{tls.CurveP256})
+	assert.Equal(t, RSAKeyBits(), RSAKeySize)
+
+	config = &tls.Config{}
+	RestrictTls(config)
+	assert.DeepEqual(t, config.CipherSuites, CipherSuites)
+	assert.DeepEqual(t, config.CurvePreferences, CurvePreferences)
+}
+
+func TestValidatePublicKey(t *testing.T) {
+	t.Setenv(EnvVar, "true")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Assert(t, err)
+	assert.ErrorContains(t, ValidatePublicKey(&rsaKey.PublicKey), "rsa keys of 1024 bits are not allowed in FIPS mode")
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.Assert(t, err)
+	assert.Assert(t, ValidatePublicKey(&ecKey.PublicKey))
+	assert.ErrorContains(t, ValidatePublicKey("key"), "string keys are not allowed in FIPS mode")
+}
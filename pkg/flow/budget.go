@@ -0,0 +1,96 @@
+package flow
+
+import (
+	"runtime"
+	"sort"
+)
+
+// fraction of the flows shed each time the budget is found exceeded
+const shedFraction = 0.1
+
+// memoryBudget bounds the heap used by the collector. Once the limit is
+// exceeded the collector stops reading records (letting the senders block)
+// and sheds flows until usage drops back under the resume threshold.
+type memoryBudget struct {
+	limit     uint64
+	resumeAt  uint64
+	usage     func() uint64
+	throttled bool
+}
+
+func newMemoryBudget(limit uint64) *memoryBudget {
+	if limit == 0 {
+		return nil
+	}
+	return &memoryBudget{
+		limit:    limit,
+		resumeAt: limit / 10 * 9,
+		usage:    heapInUse,
+	}
+}
+
+func heapInUse() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+func (fc *FlowCollector) checkMemoryBudget() {
+	if fc.budget == nil {
+		return
+	}
+	usage := fc.budget.usage()
+	switch {
+	case usage > fc.budget.limit:
+		if !fc.budget.throttled {
+			logger.Warnf("Memory usage %d exceeds budget %d, pausing record ingestion", usage, fc.budget.limit)
+			fc.budget.throttled = true
+			if fc.metrics != nil {
+				fc.metrics.memoryThrottled.Set(1)
+			}
+		}
+		if shed := fc.shedFlows(int(float64(len(fc.Flows))*shedFraction) + 1); shed > 0 {
+			fc.changed()
+			logger.Warnf("Shed %d flow records to reduce memory usage", shed)
+			runtime.GC()
+		}
+	case fc.budget.throttled && usage < fc.budget.resumeAt:
+		logger.Infof("Memory usage %d back under budget, resuming record ingestion", usage)
+		fc.budget.throttled = false
+		if fc.metrics != nil {
+			fc.metrics.memoryThrottled.Set(0)
+		}
+	}
+}
+
+// shedFlows deletes up to count flows with their flow pairs, terminated
+// flows first and then the oldest active ones, returning the number deleted
+func (fc *FlowCollector) shedFlows(count int) int {
+	flows := make([]*FlowRecord, 0, len(fc.Flows))
+	for _, flow := range fc.Flows {
+		flows = append(flows, flow)
+	}
+	sort.Slice(flows, func(i, j int) bool {
+		if (flows[i].EndTime == 0) != (flows[j].EndTime == 0) {
+			return flows[i].EndTime != 0
+		}
+		if flows[i].EndTime != flows[j].EndTime {
+			return flows[i].EndTime < flows[j].EndTime
+		}
+		return flows[i].StartTime < flows[j].StartTime
+	})
+	if count > len(flows) {
+		count = len(flows)
+	}
+	for _, flow := range flows[:count] {
+		fc.deleteRecord(flow)
+		if flowPair, ok := fc.FlowPairs["fp-"+flow.Identity]; ok {
+			fc.deleteRecord(flowPair)
+		}
+	}
+	if fc.metrics != nil && count > 0 {
+		fc.metrics.recordsShed.WithLabelValues(recordNames[Flow]).Add(float64(count))
+	}
+	fc.countIgnored(IgnoreMemoryPressure, Flow, count)
+	return count
+}
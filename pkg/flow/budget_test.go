@@ -0,0 +1,62 @@
+package flow
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestShedFlows(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{})
+	flows := []*FlowRecord{
+		{Base: Base{Identity: "flow:active-old", StartTime: 100}},
+		{Base: Base{Identity: "flow:active-new", StartTime: 300}},
+		{Base: Base{Identity: "flow:ended-late", StartTime: 100, EndTime: 500}},
+		{Base: Base{Identity: "flow:ended-early", StartTime: 200, EndTime: 400}},
+	}
+	for _, flow := range flows {
+		fc.Flows[flow.Identity] = flow
+	}
+	fc.FlowPairs["fp-flow:ended-early"] = &FlowPairRecord{Base: Base{Identity: "fp-flow:ended-early"}}
+
+	assert.Equal(t, fc.shedFlows(1), 1)
+	_, ok := fc.Flows["flow:ended-early"]
+	assert.Assert(t, !ok)
+	assert.Equal(t, len(fc.FlowPairs), 0)
+
+	assert.Equal(t, fc.shedFlows(2), 2)
+	assert.Equal(t, len(fc.Flows), 1)
+	_, ok = fc.Flows["flow:active-new"]
+	assert.Assert(t, ok)
+
+	assert.Equal(t, fc.shedFlows(5), 1)
+	assert.Equal(t, len(fc.Flows), 0)
+}
+
+func TestCheckMemoryBudget(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{MemoryBudget: 1000})
+	usage := uint64(0)
+	fc.budget.usage = func() uint64 { return usage }
+	for _, id := range []string{"flow:0", "flow:1"} {
+		fc.Flows[id] = &FlowRecord{Base: Base{Identity: id}}
+	}
+
+	fc.checkMemoryBudget()
+	assert.Assert(t, !fc.budget.throttled)
+
+	usage = 1500
+	fc.checkMemoryBudget()
+	assert.Assert(t, fc.budget.throttled)
+	assert.Equal(t, len(fc.Flows), 1)
+
+	// stays throttled until usage drops below the resume threshold
+	usage = 950
+	fc.checkMemoryBudget()
+	assert.Assert(t, fc.budget.throttled)
+
+	usage = 800
+	fc.checkMemoryBudget()
+	assert.Assert(t, !fc.budget.throttled)
+
+	assert.Assert(t, NewFlowCollector(FlowCollectorSpec{}).budget == nil)
+}
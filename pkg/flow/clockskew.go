@@ -0,0 +1,111 @@
+package flow
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	DefaultClockSkewThreshold = time.Second
+	// clockSkewSmoothing is the weight of the latest heartbeat in the
+	// measured skew, smoothing out the jitter of their time in transit
+	clockSkewSmoothing = 0.2
+)
+
+func getClockSkewThreshold(threshold time.Duration) time.Duration {
+	if threshold <= 0 {
+		return DefaultClockSkewThreshold
+	}
+	return threshold
+}
+
+func absMicros(micros int64) time.Duration {
+	if micros < 0 {
+		micros = -micros
+	}
+	return time.Duration(micros) * time.Microsecond
+}
+
+// sourceSiteId returns the site of an event source, the identity of a
+// controller being that of its site
+func (fc *FlowCollector) sourceSiteId(source *EventSourceRecord) string {
+	if router, ok := fc.Routers[source.Identity]; ok {
+		return router.Parent
+	}
+	return source.Identity
+}
+
+func (fc *FlowCollector) clockSkewLabels(source *EventSourceRecord) prometheus.Labels {
+	labels := beaconAgeLabels(*source)
+	siteId := fc.sourceSiteId(source)
+	siteName := ""
+	if site, ok := fc.Sites[siteId]; ok && site.Name != nil {
+		siteName = *site.Name
+	}
+	labels["site"] = siteName + "@_@" + siteId
+	return labels
+}
+
+// observeClockSkew measures how far the clock of an event source is ahead
+// of the clock of the collector from the time of its heartbeats, warning
+// when the skew goes past the threshold since it corrupts the latencies
+// computed across sites
+func (fc *FlowCollector) observeClockSkew(source *eventSource, heartbeatTime uint64, now uint64) {
+	if heartbeatTime == 0 {
+		return
+	}
+	measured := int64(heartbeatTime) - int64(now)
+	skew := measured
+	if source.ClockSkew != nil {
+		skew = int64((1-clockSkewSmoothing)*float64(*source.ClockSkew) + clockSkewSmoothing*float64(measured))
+	}
+	source.ClockSkew = &skew
+	if fc.metrics != nil {
+		fc.metrics.clockSkew.With(fc.clockSkewLabels(&source.EventSourceRecord)).Set(float64(skew) / float64(oneSecond))
+	}
+	skewed := absMicros(skew) > fc.clockSkewThreshold
+	if skewed == source.clockSkewed {
+		return
+	}
+	source.clockSkewed = skewed
+	event := SiteEvent{
+		Type:    SiteEventClockSynced,
+		Time:    now,
+		Subject: source.Identity,
+		Detail:  fmt.Sprintf("clock skew %s", time.Duration(skew)*time.Microsecond),
+		Key:     "clock/" + source.Identity,
+	}
+	if skewed {
+		event.Type = SiteEventClockSkew
+		logger.Warnf("The clock of event source %s is off by %s, over the threshold of %s, the latencies across sites will be off too", source.Identity, time.Duration(skew)*time.Microsecond, fc.clockSkewThreshold)
+	}
+	if router, ok := fc.Routers[source.Identity]; ok && router.Name != nil {
+		event.Subject = *router.Name
+	}
+	event.SiteId, event.SiteName = fc.siteEventSite(source.Identity)
+	fc.siteEvents.add(event)
+}
+
+// updateSiteClockSkew sets the clock skew of each site to the largest skew
+// of its controller and routers
+func (fc *FlowCollector) updateSiteClockSkew() {
+	skews := map[string]int64{}
+	for _, source := range fc.eventSources {
+		if source.ClockSkew == nil {
+			continue
+		}
+		siteId := fc.sourceSiteId(&source.EventSourceRecord)
+		if current, ok := skews[siteId]; !ok || absMicros(*source.ClockSkew) > absMicros(current) {
+			skews[siteId] = *source.ClockSkew
+		}
+	}
+	for siteId, site := range fc.Sites {
+		if skew, ok := skews[siteId]; ok {
+			site.ClockSkew = &skew
+		} else {
+			site.ClockSkew = nil
+		}
+	}
+}
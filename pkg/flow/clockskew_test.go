@@ -0,0 +1,59 @@
+package flow
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestObserveClockSkew(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{
+		Mode:    RecordMetrics,
+		PromReg: prometheus.NewRegistry(),
+	})
+	fc.metrics = fc.NewMetrics(fc.prometheusReg)
+	siteName, routerName := "west", "router-a"
+	fc.Sites["site-1"] = &SiteRecord{Base: Base{Identity: "site-1"}, Name: &siteName}
+	fc.Routers["router:a"] = &RouterRecord{Base: Base{Identity: "router:a", Parent: "site-1"}, Name: &routerName}
+	router := &eventSource{EventSourceRecord: EventSourceRecord{
+		Base:   Base{Identity: "router:a"},
+		Beacon: &BeaconRecord{SourceType: recordNames[Router]},
+	}}
+	controller := &eventSource{EventSourceRecord: EventSourceRecord{
+		Base:   Base{Identity: "site-1"},
+		Beacon: &BeaconRecord{SourceType: recordNames[Controller]},
+	}}
+	fc.eventSources["router:a"], fc.eventSources["site-1"] = router, controller
+	now := uint64(1700000000) * oneSecond
+
+	// the heartbeats without a time are left out
+	fc.observeClockSkew(router, 0, now)
+	assert.Assert(t, router.ClockSkew == nil)
+
+	// the router two seconds behind
+	fc.observeClockSkew(router, now-2*oneSecond, now)
+	assert.Equal(t, *router.ClockSkew, -int64(2*oneSecond))
+	gauge, err := fc.metrics.clockSkew.GetMetricWith(prometheus.Labels{"eventSource": "router:a", "sourceType": recordNames[Router], "site": "west@_@site-1"})
+	assert.Assert(t, err)
+	assert.Equal(t, testutil.ToFloat64(gauge), -2.0)
+	events, _ := fc.siteEvents.query(siteEventFilter{limit: -1})
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].Type, SiteEventClockSkew)
+	assert.Equal(t, events[0].Subject, "router-a")
+	assert.Equal(t, events[0].SiteName, "west")
+
+	// smoothed back within the threshold
+	for i := 0; i < 10; i++ {
+		fc.observeClockSkew(router, now, now)
+	}
+	assert.Assert(t, absMicros(*router.ClockSkew) < DefaultClockSkewThreshold)
+	events, _ = fc.siteEvents.query(siteEventFilter{limit: -1})
+	assert.Equal(t, events[0].Type, SiteEventClockSynced)
+
+	// the site has the largest skew of its sources
+	fc.observeClockSkew(controller, now+500*oneSecond/1000, now)
+	fc.updateSiteClockSkew()
+	assert.Equal(t, *fc.Sites["site-1"].ClockSkew, int64(500*oneSecond/1000))
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/gob"
 	"fmt"
-	"log"
 	"net/http"
 	"time"
 
@@ -12,10 +11,14 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/logging"
 	"github.com/skupperproject/skupper/pkg/messaging"
+	"github.com/skupperproject/skupper/pkg/network"
 	"github.com/skupperproject/skupper/pkg/version"
 )
 
+var logger = logging.New("collector")
+
 type senderDirect struct {
 	sender    *sender
 	outgoing  chan interface{}
@@ -31,12 +34,16 @@ type ApiRequest struct {
 type ApiResponse struct {
 	Body   *string
 	Status int
+	// ETag is the entity tag of the response, for the records served as of
+	// the revision it was read at
+	ETag string
 }
 
 type eventSource struct {
 	EventSourceRecord
-	receivers []*receiver
-	send      *senderDirect
+	receivers   []*receiver
+	send        *senderDirect
+	clockSkewed bool
 }
 
 type collectorMetrics struct {
@@ -49,8 +56,39 @@ type collectorMetrics struct {
 	activeFlows     *prometheus.GaugeVec
 	lastAccessed    *prometheus.GaugeVec
 	flowLatency     *prometheus.HistogramVec
+	addressLatency  *prometheus.HistogramVec
+	sitePairLatency *prometheus.HistogramVec
 	activeReconcile *prometheus.GaugeVec
 	apiQueryLatency *prometheus.HistogramVec
+	recordsShed     *prometheus.CounterVec
+	memoryThrottled prometheus.Gauge
+	taggedFlows     *prometheus.CounterVec
+	taggedOctets    *prometheus.CounterVec
+	droppedRecords  *prometheus.CounterVec
+	ignoredRecords  *prometheus.CounterVec
+	fanoutLatency   *prometheus.HistogramVec
+	fanoutTargets   *prometheus.HistogramVec
+	sloTarget       *prometheus.GaugeVec
+	sloCompliance   *prometheus.GaugeVec
+	sloBurnRate     *prometheus.GaugeVec
+	flowFailures    *prometheus.CounterVec
+	httpResponses   *prometheus.CounterVec
+
+	processOctetsSent        *prometheus.CounterVec
+	processOctetsReceived    *prometheus.CounterVec
+	processActiveConnections *prometheus.GaugeVec
+	processOpenedConnections *prometheus.CounterVec
+	processClosedConnections *prometheus.CounterVec
+
+	recordsHeld      *prometheus.GaugeVec
+	recordsReceived  *prometheus.CounterVec
+	recordsPurged    *prometheus.CounterVec
+	amqpReconnects   *prometheus.CounterVec
+	beaconAge        *prometheus.GaugeVec
+	clockSkew        *prometheus.GaugeVec
+	ingestionLag     *prometheus.GaugeVec
+	siteProbeLatency *prometheus.HistogramVec
+	siteProbesLost   *prometheus.CounterVec
 }
 
 func (fc *FlowCollector) NewMetrics(reg prometheus.Registerer) *collectorMetrics {
@@ -104,12 +142,38 @@ func (fc *FlowCollector) NewMetrics(reg prometheus.Registerer) *collectorMetrics
 			[]string{"sourceSite", "destSite", "address", "protocol", "direction", "sourceProcess", "destProcess"}),
 		flowLatency: prometheus.NewHistogramVec(
 			prometheus.HistogramOpts{
-				Name: "flow_latency_microseconds",
-				Help: "The measure latency for the direction of flow",
-				//                 1ms,  2 ms, 5ms,  10ms,  100ms,  1s,      10s
-				Buckets: []float64{1000, 2000, 5000, 10000, 100000, 1000000, 10000000},
+				Name:    "flow_latency_microseconds",
+				Help:    "The measure latency for the direction of flow",
+				Buckets: fc.latencyBuckets,
 			},
 			[]string{"sourceSite", "destSite", "address", "protocol", "direction", "sourceProcess", "destProcess"}),
+		addressLatency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "address_latency_microseconds",
+				Help:    "The measure latency of the flows, partitioned by address",
+				Buckets: fc.latencyBuckets,
+			},
+			[]string{"address", "protocol", "direction"}),
+		sitePairLatency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "site_pair_latency_microseconds",
+				Help:    "The measure latency of the flows, partitioned by source and destination site",
+				Buckets: fc.latencyBuckets,
+			},
+			[]string{"sourceSite", "destSite", "direction"}),
+		siteProbeLatency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "site_probe_latency_microseconds",
+				Help:    "The round trip time of the latency probes, partitioned by source and destination site",
+				Buckets: fc.latencyBuckets,
+			},
+			[]string{"sourceSite", "destSite"}),
+		siteProbesLost: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "site_probes_lost_total",
+				Help: "The latency probes not replied to, partitioned by source and destination site",
+			},
+			[]string{"sourceSite", "destSite"}),
 		activeReconcile: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "active_reconciles",
@@ -124,6 +188,158 @@ func (fc *FlowCollector) NewMetrics(reg prometheus.Registerer) *collectorMetrics
 				Buckets: []float64{10, 100, 1000, 2000, 5000, 10000, 100000, 1000000, 10000000},
 			},
 			[]string{"recordType", "handler"}),
+		recordsShed: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "collector_records_shed_total",
+				Help: "Records deleted by the collector to stay within its memory budget, partitioned by record type",
+			},
+			[]string{"recordType"}),
+		taggedFlows: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "tagged_flows_total",
+				Help: "Flow pairs matched by the tagging rules, partitioned by tag",
+			},
+			[]string{"tag", "address"}),
+		taggedOctets: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "tagged_octets_total",
+				Help: "Octets of the flow pairs matched by the tagging rules, partitioned by tag",
+			},
+			[]string{"tag", "address"}),
+		droppedRecords: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "collector_records_dropped_total",
+				Help: "Records dropped by the collector because they could not be decoded or applied, partitioned by reason",
+			},
+			[]string{"reason"}),
+		ignoredRecords: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "collector_records_ignored_total",
+				Help: "Records let go by the collector before they were accounted for in the flow pairs and metrics, partitioned by reason and record type",
+			},
+			[]string{"reason", "recordType"}),
+		fanoutLatency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: "aggregate_fanout_latency_microseconds",
+				Help: "The duration of requests fanned out to several targets, partitioned by address",
+				//                 1ms,  2 ms, 5ms,  10ms,  100ms,  1s,      10s
+				Buckets: []float64{1000, 2000, 5000, 10000, 100000, 1000000, 10000000},
+			},
+			[]string{"address"}),
+		fanoutTargets: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "aggregate_fanout_targets",
+				Help:    "The number of targets requests were fanned out to, partitioned by address",
+				Buckets: []float64{2, 3, 5, 10, 20, 50},
+			},
+			[]string{"address"}),
+		sloTarget: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "slo_objective_ratio",
+				Help: "The fraction of the flows of an address to meet an objective, partitioned by address and objective",
+			},
+			[]string{"address", "objective"}),
+		sloCompliance: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "slo_compliance_ratio",
+				Help: "The fraction of the flows of an address meeting an objective over the last hour, partitioned by address and objective",
+			},
+			[]string{"address", "objective"}),
+		sloBurnRate: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "slo_burn_rate",
+				Help: "The pace at which the error budget of an objective is spent, 1 spending it exactly over the window, partitioned by address, objective and window",
+			},
+			[]string{"address", "objective", "window"}),
+		flowFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "flow_failures_total",
+				Help: "Flows terminated by a failure, partitioned by address, pair of sites, class and layer of the failure",
+			},
+			[]string{"address", "sourceSite", "destSite", "class", "layer"}),
+		httpResponses: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_responses_total",
+				Help: "Responses to the http requests, partitioned by address, process pair and class of status code",
+			},
+			[]string{"address", "sourceProcess", "destProcess", "class"}),
+		processOctetsSent: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "process_octets_sent_total",
+				Help: "Octets sent by the processes over their connections, partitioned by process",
+			},
+			[]string{"process", "processGroup"}),
+		processOctetsReceived: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "process_octets_received_total",
+				Help: "Octets received by the processes over their connections, partitioned by process",
+			},
+			[]string{"process", "processGroup"}),
+		processActiveConnections: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "process_active_connections",
+				Help: "Number of connections of the processes that are currently open, partitioned by process",
+			},
+			[]string{"process", "processGroup"}),
+		processOpenedConnections: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "process_connections_opened_total",
+				Help: "Connections opened by or to the processes, partitioned by process",
+			},
+			[]string{"process", "processGroup"}),
+		processClosedConnections: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "process_connections_closed_total",
+				Help: "Connections of the processes that were closed, partitioned by process",
+			},
+			[]string{"process", "processGroup"}),
+		recordsHeld: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "collector_records",
+				Help: "Records currently held by the collector, partitioned by record type",
+			},
+			[]string{"recordType"}),
+		recordsReceived: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "collector_records_received_total",
+				Help: "Records received by the collector from the routers and controllers, partitioned by record type",
+			},
+			[]string{"recordType"}),
+		recordsPurged: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "collector_records_purged_total",
+				Help: "Records removed by the collector once aged out or gone with their event source, partitioned by record type",
+			},
+			[]string{"recordType"}),
+		amqpReconnects: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "collector_amqp_reconnects_total",
+				Help: "Connections to the router reestablished by the collector, partitioned by link role",
+			},
+			[]string{"role"}),
+		beaconAge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "collector_event_source_beacon_age_seconds",
+				Help: "Time since the collector last heard from the event source",
+			},
+			[]string{"eventSource", "sourceType"}),
+		clockSkew: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "collector_event_source_clock_skew_seconds",
+				Help: "How far the clock of the event source is ahead of the clock of the collector, measured with its heartbeats",
+			},
+			[]string{"eventSource", "sourceType", "site"}),
+		ingestionLag: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "collector_event_source_ingestion_lag_seconds",
+				Help: "Time the latest records of the event source waited to be applied since they were received by the collector",
+			},
+			[]string{"eventSource", "sourceType"}),
+		memoryThrottled: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "collector_memory_throttled",
+				Help: "Set to 1 while record ingestion is paused because the memory budget is exceeded",
+			}),
 	}
 	reg.MustRegister(m.info)
 	reg.MustRegister(m.collectorOctets)
@@ -134,8 +350,37 @@ func (fc *FlowCollector) NewMetrics(reg prometheus.Registerer) *collectorMetrics
 	reg.MustRegister(m.activeFlows)
 	reg.MustRegister(m.lastAccessed)
 	reg.MustRegister(m.flowLatency)
+	reg.MustRegister(m.addressLatency)
+	reg.MustRegister(m.sitePairLatency)
+	reg.MustRegister(m.siteProbeLatency)
+	reg.MustRegister(m.siteProbesLost)
 	reg.MustRegister(m.activeReconcile)
 	reg.MustRegister(m.apiQueryLatency)
+	reg.MustRegister(m.recordsShed)
+	reg.MustRegister(m.memoryThrottled)
+	reg.MustRegister(m.taggedFlows)
+	reg.MustRegister(m.taggedOctets)
+	reg.MustRegister(m.droppedRecords)
+	reg.MustRegister(m.ignoredRecords)
+	reg.MustRegister(m.fanoutLatency)
+	reg.MustRegister(m.fanoutTargets)
+	reg.MustRegister(m.sloTarget)
+	reg.MustRegister(m.sloCompliance)
+	reg.MustRegister(m.sloBurnRate)
+	reg.MustRegister(m.flowFailures)
+	reg.MustRegister(m.httpResponses)
+	reg.MustRegister(m.processOctetsSent)
+	reg.MustRegister(m.processOctetsReceived)
+	reg.MustRegister(m.processActiveConnections)
+	reg.MustRegister(m.processOpenedConnections)
+	reg.MustRegister(m.processClosedConnections)
+	reg.MustRegister(m.recordsHeld)
+	reg.MustRegister(m.recordsReceived)
+	reg.MustRegister(m.recordsPurged)
+	reg.MustRegister(m.amqpReconnects)
+	reg.MustRegister(m.beaconAge)
+	reg.MustRegister(m.clockSkew)
+	reg.MustRegister(m.ingestionLag)
 	return m
 
 }
@@ -159,6 +404,29 @@ type FlowCollectorSpec struct {
 	PromReg           prometheus.Registerer
 	ConnectionFactory messaging.ConnectionFactory
 	FlowRecordTtl     time.Duration
+	MemoryBudget      uint64
+	TagRules          []TagRule
+	FlowEvents        FlowEventSink
+	// LatencyBuckets are the upper bounds in microseconds of the latency
+	// histograms, DefaultLatencyBuckets when empty
+	LatencyBuckets []float64
+	// Leading reports whether this collector is the active replica, only
+	// the active replica writes the network status. Nil means always.
+	Leading func() bool
+	// SiteEvents keeps the site events across restarts, they are only held
+	// in memory when nil
+	SiteEvents SiteEventStore
+	// Slos keeps the address objectives declared through the api across
+	// restarts, they are only held in memory when nil
+	Slos SloStore
+	// StaleSourceAfter is the silence after which an event source and its
+	// routers and sites are marked stale, and StaleSourceGrace the time
+	// they are shown as stale before their records are purged
+	StaleSourceAfter time.Duration
+	StaleSourceGrace time.Duration
+	// ClockSkewThreshold is the clock skew of an event source past which
+	// a warning is logged, DefaultClockSkewThreshold when zero
+	ClockSkewThreshold time.Duration
 }
 
 type FlowCollector struct {
@@ -193,14 +461,34 @@ type FlowCollector struct {
 	Processes               map[string]*ProcessRecord
 	ProcessGroups           map[string]*ProcessGroupRecord
 	VanAddresses            map[string]*VanAddressRecord
+	SiteLatencies           map[string]*SiteLatencyRecord
 	flowsToProcessReconcile map[string]string
 	flowsToPairReconcile    map[string]*FlowToPairRecord
 	connectorsToReconcile   map[string]string
 	processesToReconcile    map[string]*ProcessRecord
 	aggregatesToReconcile   map[string]*FlowPairRecord
+	siteIndex               *siteIndex
+	budget                  *memoryBudget
+	tagRules                []TagRule
+	drops                   *dropLog
+	addressHistory          map[string]*addressHistory
+	fanoutTargets           map[string]int
+	flowEvents              FlowEventSink
+	leading                 func() bool
+	latencyBuckets          []float64
+	networkHistory          []network.NetworkSnapshot
+	siteEvents              *siteEventLog
+	slos                    *sloTracker
+	sloUpdates              chan []AddressSlo
+	staleSourceAfter        time.Duration
+	staleSourceGrace        time.Duration
+	clockSkewThreshold      time.Duration
 
 	begin           time.Time
 	networkStatusUp bool
+	// revision counts the changes of the records, only read and written
+	// from the collector goroutine
+	revision uint64
 }
 
 func getTtl(ttl time.Duration) time.Duration {
@@ -221,6 +509,9 @@ func NewFlowCollector(spec FlowCollectorSpec) *FlowCollector {
 		startTime:               uint64(time.Now().UnixNano()) / uint64(time.Microsecond),
 		connectionFactory:       spec.ConnectionFactory,
 		recordTtl:               getTtl(spec.FlowRecordTtl),
+		staleSourceAfter:        getStaleSourceAfter(spec.StaleSourceAfter),
+		staleSourceGrace:        getStaleSourceGrace(spec.StaleSourceGrace),
+		clockSkewThreshold:      getClockSkewThreshold(spec.ClockSkewThreshold),
 		prometheusReg:           spec.PromReg,
 		beaconsIncoming:         make(chan []interface{}, 10),
 		heartbeatsIncoming:      make(chan []interface{}, 10),
@@ -241,6 +532,7 @@ func NewFlowCollector(spec FlowCollectorSpec) *FlowCollector {
 		FlowPairs:               make(map[string]*FlowPairRecord),
 		FlowAggregates:          make(map[string]*FlowAggregateRecord),
 		VanAddresses:            make(map[string]*VanAddressRecord),
+		SiteLatencies:           make(map[string]*SiteLatencyRecord),
 		Processes:               make(map[string]*ProcessRecord),
 		ProcessGroups:           make(map[string]*ProcessGroupRecord),
 		flowsToProcessReconcile: make(map[string]string),
@@ -248,6 +540,18 @@ func NewFlowCollector(spec FlowCollectorSpec) *FlowCollector {
 		connectorsToReconcile:   make(map[string]string),
 		processesToReconcile:    make(map[string]*ProcessRecord),
 		aggregatesToReconcile:   make(map[string]*FlowPairRecord),
+		siteIndex:               newSiteIndex(),
+		budget:                  newMemoryBudget(spec.MemoryBudget),
+		tagRules:                spec.TagRules,
+		drops:                   newDropLog(),
+		addressHistory:          make(map[string]*addressHistory),
+		fanoutTargets:           make(map[string]int),
+		flowEvents:              spec.FlowEvents,
+		leading:                 spec.Leading,
+		latencyBuckets:          getLatencyBuckets(spec.LatencyBuckets),
+		siteEvents:              newSiteEventLog(spec.SiteEvents),
+		slos:                    newSloTracker(spec.Slos),
+		sloUpdates:              make(chan []AddressSlo, 1),
 	}
 	fc.Collector = CollectorRecord{
 		Base: Base{
@@ -262,11 +566,37 @@ func NewFlowCollector(spec FlowCollectorSpec) *FlowCollector {
 
 func (fc *FlowCollector) serveRecords(request ApiRequest) ApiResponse {
 	request.HandlerName = mux.CurrentRoute(request.Request).GetName()
+	if request.Request.Method == http.MethodPost && request.HandlerName == "ingest" {
+		return fc.ingest(request)
+	}
+	if request.HandlerName == "drops" {
+		return fc.serveDrops()
+	}
+	if request.HandlerName == "diagnostics" {
+		return fc.serveDiagnostics()
+	}
+	if request.HandlerName == "siteevents" {
+		return fc.serveSiteEvents(request)
+	}
+	if request.HandlerName == "slos" || request.HandlerName == "slo" {
+		return fc.serveSlos(request)
+	}
 	response := ApiResponse{
 		Body:   nil,
 		Status: http.StatusOK,
 	}
-	result, err := fc.retrieve(request)
+	if request.Request.Context().Err() != nil {
+		// the client gave up while the request was queued
+		response.Status = http.StatusGatewayTimeout
+		return response
+	}
+	etag, notModified := fc.notModified(request.Request)
+	response.ETag = etag
+	if notModified {
+		response.Status = http.StatusNotModified
+		return response
+	}
+	result, err := fc.retrieve(request.Request.Context(), request)
 	if err == nil {
 		response.Body = result
 	} else {
@@ -286,7 +616,7 @@ func getRealSizeOf(v interface{}) (int, error) {
 func (c *FlowCollector) beaconUpdate(beacon BeaconRecord) {
 	if source, ok := c.eventSources[beacon.Identity]; !ok {
 		var receivers []*receiver
-		log.Printf("COLLECTOR: Detected event source %s of type %s \n", beacon.Identity, beacon.SourceType)
+		logger.Infof("Detected event source %s of type %s", beacon.Identity, beacon.SourceType)
 		receivers = append(receivers, newReceiver(c.connectionFactory, beacon.Address, c.recordsIncoming))
 		if beacon.SourceType == recordNames[Router] {
 			switch c.mode {
@@ -298,8 +628,13 @@ func (c *FlowCollector) beaconUpdate(beacon BeaconRecord) {
 		} else if beacon.SourceType == recordNames[Controller] {
 			receivers = append(receivers, newReceiver(c.connectionFactory, beacon.Address+".heartbeats", c.heartbeatsIncoming))
 		}
+		for _, r := range receivers {
+			c.countReconnects("receiver", &r.base)
+			r.source = beacon.Identity
+		}
 		outgoing := make(chan interface{})
 		s := newSender(c.connectionFactory, beacon.Direct, false, outgoing)
+		c.countReconnects("sender", &s.base)
 		if c.connectionFactory != nil {
 			s.start()
 		}
@@ -342,59 +677,110 @@ func (c *FlowCollector) recordUpdates(stopCh <-chan struct{}) {
 	defer tickerReconcile.Stop()
 	tickerAge := time.NewTicker(5 * time.Second)
 	defer tickerAge.Stop()
+	tickerBudget := time.NewTicker(time.Second)
+	defer tickerBudget.Stop()
 
 	for {
+		// stop reading records while over the memory budget, the receivers
+		// then block and stop granting credit to the routers
+		recordsIncoming := c.recordsIncoming
+		if c.budget != nil && c.budget.throttled {
+			recordsIncoming = nil
+		}
 		select {
 		case beaconUpdates := <-c.beaconsIncoming:
+			c.changed()
 			for _, beaconUpdate := range beaconUpdates {
+				if drop, ok := beaconUpdate.(droppedRecord); ok {
+					c.recordDrop(drop.reason, drop.source, drop.recType, "", drop.detail)
+					continue
+				}
 				beacon, ok := beaconUpdate.(BeaconRecord)
 				if !ok {
-					log.Println("COLLECTOR: Unable to convert interface to beacon")
+					logger.Errorf("Unable to convert interface to beacon")
 				} else {
 					c.beaconUpdate(beacon)
 				}
 			}
 		case heartbeatUpdates := <-c.heartbeatsIncoming:
+			c.changed()
 			for _, heartbeatUpdate := range heartbeatUpdates {
+				if drop, ok := heartbeatUpdate.(droppedRecord); ok {
+					c.recordDrop(drop.reason, drop.source, drop.recType, "", drop.detail)
+					continue
+				}
+				if batch, ok := heartbeatUpdate.(receivedBatch); ok {
+					c.observeIngestionLag(batch, time.Now())
+					continue
+				}
 				heartbeat, ok := heartbeatUpdate.(HeartbeatRecord)
 				if !ok {
-					log.Println("COLLECTOR: Unable to convert interface to heartbeat")
+					logger.Errorf("Unable to convert interface to heartbeat")
 				} else {
+					c.countReceived(heartbeat)
 					err := c.updateRecord(heartbeat)
 					if err != nil {
-						log.Println("COLLECTOR: heartbeat record error", err.Error())
+						c.recordInvalid(heartbeat, err)
 					}
 				}
 			}
-		case recordUpdates := <-c.recordsIncoming:
+		case recordUpdates := <-recordsIncoming:
+			c.changed()
 			for _, update := range recordUpdates {
+				if drop, ok := update.(droppedRecord); ok {
+					c.recordDrop(drop.reason, drop.source, drop.recType, "", drop.detail)
+					continue
+				}
+				if batch, ok := update.(receivedBatch); ok {
+					c.observeIngestionLag(batch, time.Now())
+					continue
+				}
 				size, _ := getRealSizeOf(update)
 				if c.mode == RecordMetrics {
 					c.metrics.collectorOctets.Add(float64(size))
 				}
+				c.countReceived(update)
 				err := c.updateRecord(update)
 				if err != nil {
-					log.Println("COLLECTOR: Update record error", err.Error())
+					c.recordInvalid(update, err)
 				}
 			}
 		case request := <-c.Request:
 			response := c.serveRecords(request)
+			if request.Request.Method != http.MethodGet && request.Request.Method != http.MethodHead {
+				c.changed()
+			}
 			c.Response <- response
+		case slos := <-c.sloUpdates:
+			c.changed()
+			c.setAnnotatedSlos(slos)
 		case <-tickerFlush.C:
 			for address, sender := range c.pendingFlush {
 				if sender.heartbeat {
-					log.Println("COLLECTOR: Sending flush to ", address)
+					logger.Debugf("Sending flush to %s", address)
 					sender.outgoing <- &FlushRecord{Address: address}
 					delete(c.pendingFlush, address)
 				}
 			}
 		case <-tickerReconcile.C:
+			if c.pendingReconcile() {
+				c.changed()
+			}
 			if c.mode == RecordMetrics {
 				c.reconcileFlowRecords()
 			}
 			c.reconcileConnectorRecords()
 		case <-tickerAge.C:
+			c.changed()
 			c.ageAndPurgeRecords()
+			c.updateSiteClockSkew()
+			c.updateIngestionLag(time.Now())
+			c.updateSelfMetrics(time.Now())
+			c.siteEvents.age(time.Now())
+			c.siteEvents.flush()
+			c.updateSloMetrics(time.Now())
+		case <-tickerBudget.C:
+			c.checkMemoryBudget()
 		case <-stopCh:
 			return
 		}
@@ -441,6 +827,7 @@ func (c *FlowCollector) run(stopCh <-chan struct{}) {
 		c.metrics.info.With(prometheus.Labels{"version": version.Version}).Set(1)
 	}
 	c.beaconReceiver = newReceiver(c.connectionFactory, BeaconAddress, c.beaconsIncoming)
+	c.countReconnects("receiver", &c.beaconReceiver.base)
 	c.beaconReceiver.start()
 
 	done := make(chan struct{})
@@ -449,7 +836,7 @@ func (c *FlowCollector) run(stopCh <-chan struct{}) {
 		c.recordUpdates(stopCh)
 	}()
 	<-done
-	log.Println("COLLECTOR: Finished running. Shutting down")
+	logger.Infof("Finished running. Shutting down")
 	for _, eventsource := range c.eventSources {
 		for _, receiver := range eventsource.receivers {
 			receiver.stop()
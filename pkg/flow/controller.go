@@ -1,14 +1,15 @@
 package flow
 
 import (
-	"log"
 	"os"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/pkg/config"
+	"github.com/skupperproject/skupper/pkg/logging"
 	"github.com/skupperproject/skupper/pkg/messaging"
 )
 
@@ -16,6 +17,8 @@ const (
 	FlowControllerEvent string = "FlowControllerEvent"
 )
 
+var controllerLogger = logging.New("flow-controller")
+
 type FlowController struct {
 	origin               string
 	connectionFactory    messaging.ConnectionFactory
@@ -27,8 +30,15 @@ type FlowController struct {
 	processRecords       map[string]*ProcessRecord
 	hostRecords          map[string]*HostRecord
 	hostOutgoing         chan *HostRecord
+	logEventOutgoing     chan *LogEventRecord
 	siteRecordController siteRecordController
 	startTime            int64
+	probeInterval        time.Duration
+	prober               *latencyProber
+	probesIncoming       chan []interface{}
+	probeRepliesIncoming chan []interface{}
+	probeOutgoing        chan interface{}
+	latencyOutgoing      chan *SiteLatencyRecord
 }
 
 type PolicyEvaluator interface {
@@ -49,8 +59,17 @@ func NewFlowController(origin string, version string, creationTime uint64, conne
 		processRecords:       make(map[string]*ProcessRecord),
 		hostRecords:          make(map[string]*HostRecord),
 		hostOutgoing:         make(chan *HostRecord, 10),
+		logEventOutgoing:     make(chan *LogEventRecord, 10),
 		siteRecordController: newSiteRecordController(creationTime, version, policyEvaluator),
 		startTime:            time.Now().Unix(),
+		probeInterval:        getProbeInterval(os.Getenv(LatencyProbeIntervalEnv)),
+		probesIncoming:       make(chan []interface{}, 10),
+		probeRepliesIncoming: make(chan []interface{}, 10),
+		probeOutgoing:        make(chan interface{}, 1),
+		latencyOutgoing:      make(chan *SiteLatencyRecord, 10),
+	}
+	if fc.probeInterval > 0 {
+		fc.prober = newLatencyProber(origin)
 	}
 	return fc
 }
@@ -105,6 +124,31 @@ func UpdateHost(c *FlowController, deleted bool, name string, host *HostRecord)
 	return nil
 }
 
+// RecordLogEvent sends a log event of the site, its text starting with the
+// type of the site event for the collectors to record it as such, as in
+// "token-redeemed: ...". The event is dropped rather than blocking the
+// caller when the controller is not sending.
+func RecordLogEvent(c *FlowController, eventType string, detail string) {
+	text := detail
+	if eventType != "" {
+		text = eventType + ": " + detail
+	}
+	logEvent := &LogEventRecord{
+		Base: Base{
+			RecType:   recordNames[LogEvent],
+			Identity:  uuid.New().String(),
+			Parent:    c.origin,
+			StartTime: uint64(time.Now().UnixNano()) / uint64(time.Microsecond),
+		},
+		LogText: &text,
+	}
+	select {
+	case c.logEventOutgoing <- logEvent:
+	default:
+		controllerLogger.Warnf("Dropped the log event %q, the flow controller is not sending", text)
+	}
+}
+
 func (c *FlowController) updateBeacon(stopCh <-chan struct{}) {
 	tickerAge := time.NewTicker(10 * time.Minute)
 	defer tickerAge.Stop()
@@ -179,11 +223,15 @@ func (c *FlowController) updateRecords(stopCh <-chan struct{}, siteRecordsIncomi
 			c.recordOutgoing <- site
 		case host := <-c.hostOutgoing:
 			c.recordOutgoing <- host
+		case logEvent := <-c.logEventOutgoing:
+			c.recordOutgoing <- logEvent
+		case latency := <-c.latencyOutgoing:
+			c.recordOutgoing <- latency
 		case flushUpdates := <-c.flushIncoming:
 			for _, flushUpdate := range flushUpdates {
 				_, ok := flushUpdate.(FlushRecord)
 				if !ok {
-					log.Println("Unable to convert interface to flush")
+					controllerLogger.Errorf("Unable to convert interface to flush")
 				}
 			}
 			c.recordOutgoing <- c.siteRecordController.Record()
@@ -193,6 +241,11 @@ func (c *FlowController) updateRecords(stopCh <-chan struct{}, siteRecordsIncomi
 			for _, host := range c.hostRecords {
 				c.recordOutgoing <- host
 			}
+			if c.prober != nil {
+				for _, latency := range c.prober.records() {
+					c.recordOutgoing <- latency
+				}
+			}
 		case <-tickerAge.C:
 		case <-stopCh:
 			return
@@ -210,21 +263,42 @@ func (c *FlowController) run(stopCh <-chan struct{}) {
 	heartbeatSender := newSender(c.connectionFactory, RecordPrefix+c.origin+".heartbeats", true, c.heartbeatOutgoing)
 	recordSender := newSender(c.connectionFactory, RecordPrefix+c.origin, false, c.recordOutgoing)
 	flushReceiver := newReceiver(c.connectionFactory, DirectPrefix+c.origin, c.flushIncoming)
+	// the probes of the other sites are replied to whether or not the site
+	// probes them in turn
+	probeReceiver := newReceiver(c.connectionFactory, ProbeAddress, c.probesIncoming)
 
 	beaconSender.start()
 	heartbeatSender.start()
 	recordSender.start()
 	flushReceiver.start()
+	probeReceiver.start()
 
 	go c.updateBeacon(stopCh)
 	go c.updateHeartbeats(stopCh)
 	go c.updateRecords(stopCh, c.siteRecordController.Start(stopCh))
+	go c.respondProbes(stopCh)
+
+	var probeSender *sender
+	var probeReplyReceiver *receiver
+	if c.prober != nil {
+		controllerLogger.Infof("Probing the latency to the other sites every %s", c.probeInterval)
+		probeSender = newSender(c.connectionFactory, ProbeAddress, true, c.probeOutgoing)
+		probeReplyReceiver = newReceiver(c.connectionFactory, probeReplyAddress(c.origin), c.probeRepliesIncoming)
+		probeSender.start()
+		probeReplyReceiver.start()
+		go c.updateProbes(stopCh)
+	}
 	<-stopCh
 
 	beaconSender.stop()
 	heartbeatSender.stop()
 	recordSender.stop()
 	flushReceiver.stop()
+	probeReceiver.stop()
+	if c.prober != nil {
+		probeSender.stop()
+		probeReplyReceiver.stop()
+	}
 }
 
 type siteRecordController struct {
@@ -248,8 +322,8 @@ func newSiteRecordController(createdAt uint64, version string, policyEvaluator P
 	if platform == "" || platform == types.PlatformKubernetes {
 		platformStr = string(types.PlatformKubernetes)
 		policy = policyEvaluator.Enabled()
-	} else if platform == types.PlatformPodman {
-		platformStr = string(types.PlatformPodman)
+	} else if platform == types.PlatformPodman || platform == types.PlatformDocker {
+		platformStr = string(platform)
 	}
 	return siteRecordController{
 		Identity:        os.Getenv("SKUPPER_SITE_ID"),
@@ -0,0 +1,221 @@
+package flow
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	dashboardWidth      = 24
+	dashboardRange      = "[$__rate_interval]"
+	dashboardDatasource = "${datasource}"
+)
+
+// siteName keeps the name of the site labels, which the collector sets as
+// <name>@_@<identity>
+func siteName(expr string, label string) string {
+	return fmt.Sprintf(`label_replace(%s, "%s", "$1", "%s", "(.*)@_@.*")`, expr, label, label)
+}
+
+type grafanaDatasource struct {
+	Type string `json:"type"`
+	Uid  string `json:"uid"`
+}
+
+type grafanaTarget struct {
+	Datasource   grafanaDatasource `json:"datasource"`
+	Expr         string            `json:"expr"`
+	LegendFormat string            `json:"legendFormat,omitempty"`
+	Format       string            `json:"format,omitempty"`
+	Instant      bool              `json:"instant,omitempty"`
+	RefId        string            `json:"refId"`
+}
+
+type grafanaGridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type grafanaFieldConfig struct {
+	Defaults  map[string]interface{} `json:"defaults"`
+	Overrides []interface{}          `json:"overrides"`
+}
+
+type grafanaPanel struct {
+	Id          int                 `json:"id"`
+	Type        string              `json:"type"`
+	Title       string              `json:"title"`
+	Description string              `json:"description,omitempty"`
+	Datasource  *grafanaDatasource  `json:"datasource,omitempty"`
+	GridPos     grafanaGridPos      `json:"gridPos"`
+	Targets     []grafanaTarget     `json:"targets,omitempty"`
+	FieldConfig *grafanaFieldConfig `json:"fieldConfig,omitempty"`
+	Collapsed   *bool               `json:"collapsed,omitempty"`
+}
+
+type grafanaVariable struct {
+	Name       string             `json:"name"`
+	Label      string             `json:"label"`
+	Type       string             `json:"type"`
+	Query      interface{}        `json:"query"`
+	Datasource *grafanaDatasource `json:"datasource,omitempty"`
+	Definition string             `json:"definition,omitempty"`
+	Refresh    int                `json:"refresh,omitempty"`
+	IncludeAll bool               `json:"includeAll,omitempty"`
+	Multi      bool               `json:"multi,omitempty"`
+	AllValue   string             `json:"allValue,omitempty"`
+}
+
+type grafanaDashboard struct {
+	Uid           string                 `json:"uid,omitempty"`
+	Title         string                 `json:"title"`
+	Tags          []string               `json:"tags"`
+	Editable      bool                   `json:"editable"`
+	SchemaVersion int                    `json:"schemaVersion"`
+	Refresh       string                 `json:"refresh"`
+	Time          map[string]string      `json:"time"`
+	Templating    map[string]interface{} `json:"templating"`
+	Panels        []grafanaPanel         `json:"panels"`
+}
+
+// dashboardLayout places the panels of the dashboard row after row
+type dashboardLayout struct {
+	panels []grafanaPanel
+	x, y   int
+	height int
+}
+
+func (l *dashboardLayout) row(title string) {
+	if l.x > 0 {
+		l.y += l.height
+		l.x = 0
+	}
+	collapsed := false
+	l.panels = append(l.panels, grafanaPanel{
+		Id:        len(l.panels) + 1,
+		Type:      "row",
+		Title:     title,
+		GridPos:   grafanaGridPos{H: 1, W: dashboardWidth, X: 0, Y: l.y},
+		Collapsed: &collapsed,
+	})
+	l.y++
+}
+
+// panel adds a panel of the given width, the queries legends alternating
+// with their expressions
+func (l *dashboardLayout) panel(panelType string, title string, description string, width int, height int, unit string, queries ...string) *grafanaPanel {
+	if l.x+width > dashboardWidth {
+		l.y += l.height
+		l.x = 0
+	}
+	datasource := grafanaDatasource{Type: "prometheus", Uid: dashboardDatasource}
+	panel := grafanaPanel{
+		Id:          len(l.panels) + 1,
+		Type:        panelType,
+		Title:       title,
+		Description: description,
+		Datasource:  &datasource,
+		GridPos:     grafanaGridPos{H: height, W: width, X: l.x, Y: l.y},
+		FieldConfig: &grafanaFieldConfig{Defaults: map[string]interface{}{"unit": unit}, Overrides: []interface{}{}},
+	}
+	for i := 0; i+1 < len(queries); i += 2 {
+		panel.Targets = append(panel.Targets, grafanaTarget{
+			Datasource:   datasource,
+			LegendFormat: queries[i],
+			Expr:         queries[i+1],
+			RefId:        string(rune('A' + i/2)),
+		})
+	}
+	l.x += width
+	l.height = height
+	l.panels = append(l.panels, panel)
+	return &l.panels[len(l.panels)-1]
+}
+
+// table adds a panel showing the current value of its query by label
+func (l *dashboardLayout) table(title string, description string, unit string, expr string) {
+	panel := l.panel("table", title, description, dashboardWidth, 8, unit, "", expr)
+	panel.Targets[0].Format = "table"
+	panel.Targets[0].Instant = true
+}
+
+func latencyQuantile(quantile string, metric string, by string, selector string) string {
+	return fmt.Sprintf("histogram_quantile(%s, sum by (le, %s) (rate(%s_bucket%s%s)))", quantile, by, metric, selector, dashboardRange)
+}
+
+// GrafanaDashboard returns the json of a grafana dashboard built on the
+// metrics the collector registers, ready to be imported, the prometheus
+// data source being chosen from a variable of the dashboard
+func GrafanaDashboard(title string, uid string) ([]byte, error) {
+	address := `{address=~"$address"}`
+	l := &dashboardLayout{}
+
+	l.row("Topology")
+	l.panel("stat", "Sites", "Sites exchanging traffic", 6, 4, "short",
+		"", `count(count by (sourceSite) (flows_total))`)
+	l.panel("stat", "Addresses", "Addresses that were served", 6, 4, "short",
+		"", `count(count by (address) (flows_total))`)
+	l.panel("stat", "Active flows", "Flows that are currently open", 6, 4, "short",
+		"", `sum(active_flows)`)
+	l.panel("stat", "Throughput", "Octets per second across the network", 6, 4, "Bps",
+		"", `sum(rate(octets_total`+dashboardRange+`))`)
+	l.table("Traffic between sites", "Octets per second from a site to another", "Bps",
+		siteName(siteName(`sum by (sourceSite, destSite) (rate(octets_total`+dashboardRange+`))`, "sourceSite"), "destSite"))
+
+	l.row("Latency per address")
+	for _, quantile := range []struct{ name, value string }{{"p50", "0.5"}, {"p95", "0.95"}, {"p99", "0.99"}} {
+		l.panel("timeseries", quantile.name+" latency", "Latency of the flows of the address, as measured by the routers", 8, 8, "µs",
+			"{{address}} {{direction}}", latencyQuantile(quantile.value, "address_latency_microseconds", "address, direction", address))
+	}
+	l.panel("timeseries", "Flow rate", "New flows per second by address", 12, 8, "short",
+		"{{address}}", `sum by (address) (rate(flows_total`+address+dashboardRange+`))`)
+	l.panel("timeseries", "Active flows", "Open flows by address", 12, 8, "short",
+		"{{address}}", `sum by (address) (active_flows`+address+`)`)
+
+	l.row("Throughput per site")
+	l.panel("timeseries", "Sent", "Octets per second sent from the site", 12, 8, "Bps",
+		"{{sourceSite}}", siteName(`sum by (sourceSite) (rate(octets_total`+dashboardRange+`))`, "sourceSite"))
+	l.panel("timeseries", "Received", "Octets per second received by the site", 12, 8, "Bps",
+		"{{destSite}}", siteName(`sum by (destSite) (rate(octets_total`+dashboardRange+`))`, "destSite"))
+	l.panel("timeseries", "p95 latency between sites", "Latency of the flows from a site to another", 24, 8, "µs",
+		"{{sourceSite}} → {{destSite}}", siteName(siteName(latencyQuantile("0.95", "site_pair_latency_microseconds", "sourceSite, destSite", ""), "sourceSite"), "destSite"))
+
+	dashboard := grafanaDashboard{
+		Uid:           uid,
+		Title:         title,
+		Tags:          []string{"skupper"},
+		Editable:      true,
+		SchemaVersion: 36,
+		Refresh:       "30s",
+		Time:          map[string]string{"from": "now-1h", "to": "now"},
+		Templating: map[string]interface{}{
+			"list": []grafanaVariable{
+				{
+					Name:  "datasource",
+					Label: "Data source",
+					Type:  "datasource",
+					Query: "prometheus",
+				},
+				{
+					Name:       "address",
+					Label:      "Address",
+					Type:       "query",
+					Datasource: &grafanaDatasource{Type: "prometheus", Uid: dashboardDatasource},
+					Definition: "label_values(flows_total, address)",
+					Query: map[string]string{
+						"query": "label_values(flows_total, address)",
+						"refId": "PrometheusVariableQueryEditor-VariableQuery",
+					},
+					Refresh:    2,
+					IncludeAll: true,
+					Multi:      true,
+					AllValue:   ".*",
+				},
+			},
+		},
+		Panels: l.panels,
+	}
+	return json.MarshalIndent(dashboard, "", "  ")
+}
@@ -0,0 +1,76 @@
+package flow
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gotest.tools/assert"
+)
+
+// describedMetrics records the names of the metrics registered with it
+type describedMetrics map[string]bool
+
+var fqName = regexp.MustCompile(`fqName: "([^"]+)"`)
+
+func (d describedMetrics) Register(c prometheus.Collector) error {
+	descs := make(chan *prometheus.Desc)
+	go func() {
+		c.Describe(descs)
+		close(descs)
+	}()
+	for desc := range descs {
+		if match := fqName.FindStringSubmatch(desc.String()); match != nil {
+			d[match[1]] = true
+		}
+	}
+	return nil
+}
+
+func (d describedMetrics) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		d.Register(c)
+	}
+}
+
+func (d describedMetrics) Unregister(c prometheus.Collector) bool {
+	return false
+}
+
+func TestGrafanaDashboard(t *testing.T) {
+	registered := describedMetrics{}
+	fc := NewFlowCollector(FlowCollectorSpec{Mode: RecordMetrics})
+	fc.NewMetrics(registered)
+
+	data, err := GrafanaDashboard("Skupper", "skupper-network")
+	assert.Assert(t, err)
+	dashboard := grafanaDashboard{}
+	assert.Assert(t, json.Unmarshal(data, &dashboard))
+	assert.Equal(t, dashboard.Title, "Skupper")
+	assert.Equal(t, dashboard.Uid, "skupper-network")
+
+	metricName := regexp.MustCompile(`\b([a-z]+_[a-z_]+)[{\[)]`)
+	ids := map[int]bool{}
+	rows := []string{}
+	for _, panel := range dashboard.Panels {
+		assert.Assert(t, !ids[panel.Id], "duplicate panel id %d", panel.Id)
+		ids[panel.Id] = true
+		assert.Assert(t, panel.GridPos.X+panel.GridPos.W <= dashboardWidth)
+		if panel.Type == "row" {
+			rows = append(rows, panel.Title)
+			continue
+		}
+		assert.Assert(t, len(panel.Targets) > 0, panel.Title)
+		for _, target := range panel.Targets {
+			names := metricName.FindAllStringSubmatch(target.Expr, -1)
+			assert.Assert(t, len(names) > 0, target.Expr)
+			for _, name := range names {
+				metric := strings.TrimSuffix(name[1], "_bucket")
+				assert.Assert(t, registered[metric], "%s is not a collector metric", metric)
+			}
+		}
+	}
+	assert.DeepEqual(t, rows, []string{"Topology", "Latency per address", "Throughput per site"})
+}
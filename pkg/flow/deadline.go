@@ -0,0 +1,28 @@
+package flow
+
+import "context"
+
+const (
+	// the context of a query is checked once every so many records visited
+	queryDeadlineInterval = 256
+	queryDeadlineStatus   = "deadline reached, the results are partial"
+)
+
+// queryDeadline stops the record iterations of a query once its context is
+// done, the records gathered until then being returned as a partial page
+type queryDeadline struct {
+	ctx   context.Context
+	count int
+	hit   bool
+}
+
+func (d *queryDeadline) reached() bool {
+	if d.hit {
+		return true
+	}
+	d.count++
+	if d.count%queryDeadlineInterval == 0 && d.ctx.Err() != nil {
+		d.hit = true
+	}
+	return d.hit
+}
@@ -0,0 +1,61 @@
+package flow
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// DiagnosticsResponse is the state of the collector included in the
+// diagnostic dumps attached to the bug reports
+type DiagnosticsResponse struct {
+	Collector string `json:"collector"`
+	StartTime uint64 `json:"startTime"`
+	Uptime    string `json:"uptime"`
+	// Records is the number of records held by type
+	Records map[string]int `json:"records"`
+	// Pending is the number of records waiting to be reconciled by kind
+	Pending      map[string]int      `json:"pending"`
+	Drops        map[string]uint64   `json:"drops"`
+	Ignored      map[string]uint64   `json:"ignored"`
+	EventSources []EventSourceRecord `json:"eventSources"`
+}
+
+func (fc *FlowCollector) diagnostics(now time.Time) DiagnosticsResponse {
+	diagnostics := DiagnosticsResponse{
+		Collector: fc.Collector.Identity,
+		StartTime: fc.startTime,
+		Uptime:    now.Sub(time.UnixMicro(int64(fc.startTime))).Round(time.Second).String(),
+		Records:   map[string]int{},
+		Pending: map[string]int{
+			"flowsToProcess": len(fc.flowsToProcessReconcile),
+			"flowsToPair":    len(fc.flowsToPairReconcile),
+			"connectors":     len(fc.connectorsToReconcile),
+			"processes":      len(fc.processesToReconcile),
+			"aggregates":     len(fc.aggregatesToReconcile),
+			"sourceFlushes":  len(fc.pendingFlush),
+		},
+		Drops:        fc.drops.counts,
+		Ignored:      fc.drops.ignored,
+		EventSources: []EventSourceRecord{},
+	}
+	for recordType, count := range fc.heldRecords() {
+		diagnostics.Records[recordNames[recordType]] = count
+	}
+	for _, source := range fc.eventSources {
+		diagnostics.EventSources = append(diagnostics.EventSources, source.EventSourceRecord)
+	}
+	return diagnostics
+}
+
+func (fc *FlowCollector) serveDiagnostics() ApiResponse {
+	response := ApiResponse{Status: http.StatusOK}
+	body, err := json.Marshal(fc.diagnostics(time.Now()))
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		return response
+	}
+	s := string(body)
+	response.Body = &s
+	return response
+}
@@ -0,0 +1,33 @@
+package flow
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gotest.tools/assert"
+)
+
+func TestServeDiagnostics(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{
+		Mode:    RecordMetrics,
+		PromReg: prometheus.NewRegistry(),
+	})
+	fc.Flows["flow:0"] = &FlowRecord{Base: Base{Identity: "flow:0", StartTime: 100}}
+	fc.flowsToPairReconcile["flow:0"] = &FlowToPairRecord{}
+	fc.eventSources["router:0"] = &eventSource{EventSourceRecord: EventSourceRecord{Base: Base{Identity: "router:0"}}}
+	fc.drops.counts[DropParseError] = 3
+
+	response := fc.serveDiagnostics()
+	assert.Equal(t, response.Status, http.StatusOK)
+	diagnostics := DiagnosticsResponse{}
+	assert.Assert(t, json.Unmarshal([]byte(*response.Body), &diagnostics))
+	assert.Equal(t, diagnostics.Collector, fc.Collector.Identity)
+	assert.Equal(t, diagnostics.Records[recordNames[Flow]], 1)
+	assert.Equal(t, diagnostics.Records[recordNames[EventSource]], 1)
+	assert.Equal(t, diagnostics.Pending["flowsToPair"], 1)
+	assert.Equal(t, diagnostics.Drops[DropParseError], uint64(3))
+	assert.Equal(t, len(diagnostics.EventSources), 1)
+	assert.Equal(t, diagnostics.EventSources[0].Identity, "router:0")
+}
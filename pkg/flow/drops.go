@@ -0,0 +1,178 @@
+package flow
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	DropParseError     = "parse-error"
+	DropUnknownSubject = "unknown-subject"
+	DropUnknownType    = "unknown-type"
+	DropInvalidRecord  = "invalid-record"
+
+	// the records decoded and applied but then let go before they could
+	// be accounted for, the console then understating the traffic
+	IgnoreUnknownParent  = "unknown-parent"
+	IgnoreUnknownFlow    = "unknown-flow"
+	IgnoreTtlPurge       = "ttl-purge"
+	IgnoreMemoryPressure = "memory-pressure"
+
+	maxDropSamples  = 100
+	dropLogInterval = time.Minute
+)
+
+// droppedRecord is returned by decode in place of a record it could not
+// convert, so the drop is accounted for by the update loop
+type droppedRecord struct {
+	reason  string
+	source  string
+	recType string
+	detail  string
+}
+
+type DropSample struct {
+	Time     uint64 `json:"time"`
+	Reason   string `json:"reason"`
+	Source   string `json:"source,omitempty"`
+	RecType  string `json:"recType,omitempty"`
+	Identity string `json:"identity,omitempty"`
+	Detail   string `json:"detail"`
+}
+
+type DropsResponse struct {
+	Counts  map[string]uint64 `json:"counts"`
+	Samples []DropSample      `json:"samples"`
+	// Ignored counts the flows let go by reason
+	Ignored map[string]uint64 `json:"ignored"`
+}
+
+// dropLog keeps the drop counts by reason and the most recent samples, and
+// logs at most one line per reason and interval
+type dropLog struct {
+	counts     map[string]uint64
+	samples    []DropSample
+	next       int
+	lastLogged map[string]time.Time
+	suppressed map[string]int
+	ignored    map[string]uint64
+}
+
+func newDropLog() *dropLog {
+	return &dropLog{
+		counts:     map[string]uint64{},
+		ignored:    map[string]uint64{},
+		lastLogged: map[string]time.Time{},
+		suppressed: map[string]int{},
+	}
+}
+
+func (d *dropLog) add(sample DropSample, now time.Time) {
+	d.counts[sample.Reason]++
+	if len(d.samples) < maxDropSamples {
+		d.samples = append(d.samples, sample)
+	} else {
+		d.samples[d.next] = sample
+	}
+	d.next = (d.next + 1) % maxDropSamples
+	if last, ok := d.lastLogged[sample.Reason]; ok && now.Sub(last) < dropLogInterval {
+		d.suppressed[sample.Reason]++
+		return
+	}
+	if suppressed := d.suppressed[sample.Reason]; suppressed > 0 {
+		logger.Warnf("Dropped record from %q (%s): %s, %d similar drops not logged", sample.Source, sample.Reason, sample.Detail, suppressed)
+	} else {
+		logger.Warnf("Dropped record from %q (%s): %s", sample.Source, sample.Reason, sample.Detail)
+	}
+	d.lastLogged[sample.Reason] = now
+	d.suppressed[sample.Reason] = 0
+}
+
+// recent returns the retained samples, newest first
+func (d *dropLog) recent() []DropSample {
+	samples := make([]DropSample, 0, len(d.samples))
+	for i := 1; i <= len(d.samples); i++ {
+		samples = append(samples, d.samples[(d.next-i+len(d.samples))%len(d.samples)])
+	}
+	return samples
+}
+
+func (fc *FlowCollector) recordDrop(reason string, source string, recType string, identity string, detail string) {
+	now := time.Now()
+	fc.drops.add(DropSample{
+		Time:     uint64(now.UnixNano()) / uint64(time.Microsecond),
+		Reason:   reason,
+		Source:   source,
+		RecType:  recType,
+		Identity: identity,
+		Detail:   detail,
+	}, now)
+	if fc.metrics != nil {
+		fc.metrics.droppedRecords.With(prometheus.Labels{"reason": reason}).Inc()
+	}
+}
+
+// countIgnored accounts for the records of a type let go for a reason
+func (fc *FlowCollector) countIgnored(reason string, recordType int, count int) {
+	if count == 0 {
+		return
+	}
+	fc.drops.ignored[reason] += uint64(count)
+	if fc.metrics != nil {
+		fc.metrics.ignoredRecords.With(prometheus.Labels{"reason": reason, "recordType": recordNames[recordType]}).Add(float64(count))
+	}
+}
+
+func recordBase(record interface{}) (Base, bool) {
+	v := reflect.ValueOf(record)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return Base{}, false
+	}
+	field := v.FieldByName("Base")
+	if !field.IsValid() {
+		return Base{}, false
+	}
+	base, ok := field.Interface().(Base)
+	return base, ok
+}
+
+// recordInvalid accounts for a decoded record that failed to update
+func (fc *FlowCollector) recordInvalid(record interface{}, err error) {
+	source, recType, identity := "", "", ""
+	switch r := record.(type) {
+	case HeartbeatRecord:
+		source, recType, identity = r.Source, "HEARTBEAT", r.Identity
+	default:
+		if base, ok := recordBase(record); ok {
+			source, recType, identity = base.Source, base.RecType, base.Identity
+		}
+	}
+	fc.recordDrop(DropInvalidRecord, source, recType, identity, err.Error())
+}
+
+func (fc *FlowCollector) serveDrops() ApiResponse {
+	response := ApiResponse{
+		Body:   nil,
+		Status: http.StatusOK,
+	}
+	drops := DropsResponse{
+		Counts:  fc.drops.counts,
+		Samples: fc.drops.recent(),
+		Ignored: fc.drops.ignored,
+	}
+	body, err := json.Marshal(drops)
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		return response
+	}
+	s := string(body)
+	response.Body = &s
+	return response
+}
@@ -0,0 +1,135 @@
+package flow
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	amqp "github.com/interconnectedcloud/go-amqp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestDecodeDrops(t *testing.T) {
+	testTable := []struct {
+		name    string
+		subject string
+		value   interface{}
+		reason  string
+	}{
+		{
+			name:    "unknown-subject",
+			subject: "GOSSIP",
+			reason:  DropUnknownSubject,
+		},
+		{
+			name:    "not-a-list",
+			subject: "RECORD",
+			value:   "garbage",
+			reason:  DropParseError,
+		},
+		{
+			name:    "not-a-map",
+			subject: "RECORD",
+			value:   []interface{}{uint32(42)},
+			reason:  DropParseError,
+		},
+		{
+			name:    "unknown-type",
+			subject: "RECORD",
+			value:   []interface{}{map[interface{}]interface{}{uint32(TypeOfRecord): uint32(99), uint32(Identity): "x"}},
+			reason:  DropUnknownType,
+		},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			msg := &amqp.Message{
+				Properties: &amqp.MessageProperties{Subject: test.subject, To: RecordPrefix + "router-1"},
+				Value:      test.value,
+			}
+			records := decode(msg)
+			assert.Equal(t, len(records), 1)
+			drop, ok := records[0].(droppedRecord)
+			assert.Assert(t, ok)
+			assert.Equal(t, drop.reason, test.reason)
+			assert.Equal(t, drop.source, "router-1")
+		})
+	}
+}
+
+func TestDropLog(t *testing.T) {
+	drops := newDropLog()
+	now := time.Now()
+	for i := 0; i < maxDropSamples+5; i++ {
+		drops.add(DropSample{Reason: DropParseError, Detail: fmt.Sprintf("drop %d", i)}, now.Add(time.Duration(i)*time.Second))
+	}
+	drops.add(DropSample{Reason: DropUnknownType, Detail: "last"}, now)
+	assert.Equal(t, drops.counts[DropParseError], uint64(maxDropSamples+5))
+	assert.Equal(t, drops.counts[DropUnknownType], uint64(1))
+
+	recent := drops.recent()
+	assert.Equal(t, len(recent), maxDropSamples)
+	assert.Equal(t, recent[0].Detail, "last")
+	assert.Equal(t, recent[1].Detail, fmt.Sprintf("drop %d", maxDropSamples+4))
+	assert.Equal(t, recent[maxDropSamples-1].Detail, "drop 6")
+
+	// one log line per reason and interval, the rest are counted
+	assert.Equal(t, drops.suppressed[DropParseError], 44)
+	assert.Equal(t, drops.suppressed[DropUnknownType], 0)
+}
+
+func TestServeDrops(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{})
+	fc.recordInvalid(HeartbeatRecord{Source: "router-1", Identity: "hb-1"}, fmt.Errorf("bad heartbeat"))
+	fc.recordInvalid(FlowRecord{Base: Base{Identity: "flow-1", RecType: recordNames[Flow], Source: "router-2"}}, fmt.Errorf("bad flow"))
+
+	response := fc.serveDrops()
+	assert.Equal(t, response.Status, 200)
+	drops := DropsResponse{}
+	assert.Assert(t, json.Unmarshal([]byte(*response.Body), &drops))
+	assert.DeepEqual(t, drops.Counts, map[string]uint64{DropInvalidRecord: 2})
+	assert.Equal(t, len(drops.Samples), 2)
+	assert.Equal(t, drops.Samples[0].Identity, "flow-1")
+	assert.Equal(t, drops.Samples[0].RecType, recordNames[Flow])
+	assert.Equal(t, drops.Samples[0].Source, "router-2")
+	assert.Equal(t, drops.Samples[1].RecType, "HEARTBEAT")
+	assert.Equal(t, drops.Samples[1].Detail, "bad heartbeat")
+}
+
+func TestIgnoredRecords(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{
+		Mode:    RecordMetrics,
+		PromReg: prometheus.NewRegistry(),
+	})
+	fc.metrics = fc.NewMetrics(fc.prometheusReg)
+
+	// an update of a flow never seen
+	assert.Assert(t, fc.updateRecord(FlowRecord{Base: Base{Identity: "flow:missed", RecType: recordNames[Flow]}}))
+	_, ok := fc.Flows["flow:missed"]
+	assert.Assert(t, !ok)
+
+	// an active flow whose listener or connector is unknown, and an ended
+	// one purged in the course of things
+	fc.Flows["flow:orphan"] = &FlowRecord{Base: Base{Identity: "flow:orphan", Parent: "listener:gone", StartTime: 100}}
+	fc.Flows["flow:ended"] = &FlowRecord{Base: Base{Identity: "flow:ended", Parent: "listener:gone", StartTime: 100, EndTime: 200}}
+	assert.Assert(t, fc.ageAndPurgeRecords())
+	assert.Equal(t, len(fc.Flows), 0)
+
+	fc.Flows["flow:0"] = &FlowRecord{Base: Base{Identity: "flow:0", StartTime: 100}}
+	fc.Flows["flow:1"] = &FlowRecord{Base: Base{Identity: "flow:1", StartTime: 100}}
+	assert.Equal(t, fc.shedFlows(2), 2)
+
+	response := fc.serveDrops()
+	drops := DropsResponse{}
+	assert.Assert(t, json.Unmarshal([]byte(*response.Body), &drops))
+	assert.DeepEqual(t, drops.Ignored, map[string]uint64{
+		IgnoreUnknownFlow:    1,
+		IgnoreUnknownParent:  1,
+		IgnoreMemoryPressure: 2,
+	})
+	counter, err := fc.metrics.ignoredRecords.GetMetricWith(prometheus.Labels{"reason": IgnoreMemoryPressure, "recordType": recordNames[Flow]})
+	assert.Assert(t, err)
+	assert.Equal(t, testutil.ToFloat64(counter), 2.0)
+}
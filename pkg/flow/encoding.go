@@ -2,7 +2,7 @@ package flow
 
 import (
 	"encoding/json"
-	"log"
+	"fmt"
 	"reflect"
 	"strings"
 
@@ -95,6 +95,45 @@ func encodeFlush(fr *FlushRecord) (*amqp.Message, error) {
 	return &request, nil
 }
 
+func asProbeMessage(msg *amqp.Message) ProbeRecord {
+	result := ProbeRecord{}
+	if identity, ok := msg.ApplicationProperties["id"].(string); ok {
+		result.Identity = identity
+	}
+	if source, ok := msg.ApplicationProperties["source"].(string); ok {
+		result.Source = source
+	}
+	if responder, ok := msg.ApplicationProperties["responder"].(string); ok {
+		result.Responder = responder
+	}
+	if sent, ok := msg.ApplicationProperties["sent"].(uint64); ok {
+		result.Sent = sent
+	}
+	return result
+}
+
+// encodeProbe addresses the probes to the controllers of all the sites and
+// the replies to the controller of the site that sent the probe
+func encodeProbe(probe *ProbeRecord) (*amqp.Message, error) {
+	var request amqp.Message
+	var properties amqp.MessageProperties
+	properties.To = ProbeAddress
+	if probe.Responder != "" {
+		properties.To = probeReplyAddress(probe.Source)
+	}
+	properties.Subject = "PROBE"
+	request.Properties = &properties
+	request.ApplicationProperties = make(map[string]interface{})
+	request.ApplicationProperties["id"] = probe.Identity
+	request.ApplicationProperties["source"] = probe.Source
+	if probe.Responder != "" {
+		request.ApplicationProperties["responder"] = probe.Responder
+	}
+	request.ApplicationProperties["sent"] = probe.Sent
+
+	return &request, nil
+}
+
 func encodeSite(site *SiteRecord) (*amqp.Message, error) {
 	var record []interface{}
 	var request amqp.Message
@@ -201,6 +240,71 @@ func encodeHost(host *HostRecord) (*amqp.Message, error) {
 	return &request, nil
 }
 
+func encodeLogEvent(logEvent *LogEventRecord) (*amqp.Message, error) {
+	var record []interface{}
+	var request amqp.Message
+	var properties amqp.MessageProperties
+	properties.Subject = "RECORD"
+	properties.To = RecordPrefix + logEvent.Parent
+	request.Properties = &properties
+
+	m := make(map[interface{}]interface{})
+	m[uint32(TypeOfRecord)] = uint32(LogEvent)
+	m[uint32(Identity)] = logEvent.Identity
+	m[uint32(Parent)] = logEvent.Parent
+	m[uint32(StartTime)] = logEvent.StartTime
+	if logEvent.LogSeverity != nil {
+		m[uint32(LogSeverity)] = *logEvent.LogSeverity
+	}
+	if logEvent.LogText != nil {
+		m[uint32(LogText)] = *logEvent.LogText
+	}
+	if logEvent.SourceFile != nil {
+		m[uint32(SourceFile)] = *logEvent.SourceFile
+	}
+	if logEvent.SourceLine != nil {
+		m[uint32(SourceLine)] = *logEvent.SourceLine
+	}
+	record = append(record, m)
+
+	request.Value = record
+
+	return &request, nil
+}
+
+func encodeSiteLatency(latency *SiteLatencyRecord) (*amqp.Message, error) {
+	var record []interface{}
+	var request amqp.Message
+	var properties amqp.MessageProperties
+	properties.Subject = "RECORD"
+	properties.To = RecordPrefix + latency.Parent
+	request.Properties = &properties
+
+	m := make(map[interface{}]interface{})
+	m[uint32(TypeOfRecord)] = uint32(SiteLatency)
+	m[uint32(Identity)] = latency.Identity
+	m[uint32(Parent)] = latency.Parent
+	m[uint32(StartTime)] = latency.StartTime
+	m[uint32(EndTime)] = latency.EndTime
+	if latency.DestinationId != nil {
+		m[uint32(PeerIdentity)] = *latency.DestinationId
+	}
+	if latency.Latency != nil {
+		m[uint32(Latency)] = *latency.Latency
+	}
+	if latency.ProbesSent != nil {
+		m[uint32(ProbesSent)] = *latency.ProbesSent
+	}
+	if latency.ProbesLost != nil {
+		m[uint32(ProbesLost)] = *latency.ProbesLost
+	}
+	record = append(record, m)
+
+	request.Value = record
+
+	return &request, nil
+}
+
 func decode(msg *amqp.Message) []interface{} {
 	var result []interface{}
 
@@ -213,19 +317,24 @@ func decode(msg *amqp.Message) []interface{} {
 		result = append(result, asHeartbeatMessage(msg))
 	case "FLUSH":
 		result = append(result, asFlushMessage(msg))
+	case "PROBE":
+		result = append(result, asProbeMessage(msg))
 	case "RECORD":
 		if records, ok := msg.Value.([]interface{}); !ok {
-			log.Printf("COLLECTOR: Unable to convert message of type %d to record list \n", reflect.TypeOf(msg.Value))
+			result = append(result, droppedRecord{reason: DropParseError, source: source, detail: fmt.Sprintf("unable to convert message of type %v to record list", reflect.TypeOf(msg.Value))})
 		} else {
 			for _, record := range records {
+				r, ok := record.(map[interface{}]interface{})
+				if !ok {
+					result = append(result, droppedRecord{reason: DropParseError, source: source, detail: fmt.Sprintf("unable to convert record of type %v to attribute map", reflect.TypeOf(record))})
+					continue
+				}
 				m := make(map[string]interface{})
-				if r, ok := record.(map[interface{}]interface{}); ok {
-					for k, v := range r {
-						if k.(uint32) < uint32(len(attributeNames)) {
-							m[attributeNames[k.(uint32)]] = v
-						} else {
-							log.Printf("COLLECTOR: Detected flow attribute out of range for record conversion %d \n ", k.(uint32))
-						}
+				for k, v := range r {
+					if key, ok := k.(uint32); ok && key < uint32(len(attributeNames)) {
+						m[attributeNames[key]] = v
+					} else {
+						logger.Warnf("Detected flow attribute out of range for record conversion %v", k)
 					}
 				}
 				var rt int
@@ -458,6 +567,12 @@ func decode(msg *amqp.Message) []interface{} {
 					if v, ok := m["StreamIdentity"].(uint64); ok {
 						flow.StreamIdentity = &v
 					}
+					if v, ok := m["TraceParent"].(string); ok {
+						flow.TraceParent = &v
+					}
+					if v, ok := m["TraceState"].(string); ok {
+						flow.TraceState = &v
+					}
 					result = append(result, flow)
 				case Process:
 					process := ProcessRecord{
@@ -489,13 +604,30 @@ func decode(msg *amqp.Message) []interface{} {
 						process.ProcessRole = &v
 					}
 					result = append(result, process)
+				case SiteLatency:
+					latency := SiteLatencyRecord{
+						Base: base,
+					}
+					if v, ok := m["PeerIdentity"].(string); ok {
+						latency.DestinationId = &v
+					}
+					if v, ok := m["Latency"].(uint64); ok {
+						latency.Latency = &v
+					}
+					if v, ok := m["ProbesSent"].(uint64); ok {
+						latency.ProbesSent = &v
+					}
+					if v, ok := m["ProbesLost"].(uint64); ok {
+						latency.ProbesLost = &v
+					}
+					result = append(result, latency)
 				default:
-					log.Println("Unrecognized record type", rt)
+					result = append(result, droppedRecord{reason: DropUnknownType, source: source, detail: fmt.Sprintf("unrecognized record type %d", rt)})
 				}
 			}
 		}
 	default:
-		log.Println("Unrecognized message subject")
+		result = append(result, droppedRecord{reason: DropUnknownSubject, source: source, detail: fmt.Sprintf("unrecognized message subject %q", msg.Properties.Subject)})
 	}
 	return result
 }
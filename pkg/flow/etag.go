@@ -0,0 +1,53 @@
+package flow
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// changed records that the records may have changed, invalidating the
+// entity tags of the api responses
+func (fc *FlowCollector) changed() {
+	fc.revision++
+}
+
+// pendingReconcile tells whether the reconciliation of the records may
+// change them
+func (fc *FlowCollector) pendingReconcile() bool {
+	return len(fc.flowsToProcessReconcile) > 0 || len(fc.flowsToPairReconcile) > 0 || len(fc.connectorsToReconcile) > 0
+}
+
+// revisionETag returns the entity tag of the api responses for the current
+// revision of the records. It is weak as the same body may be served with
+// different content encodings, and it holds the start time of the collector
+// as the revisions start over when the collector is restarted.
+func (fc *FlowCollector) revisionETag() string {
+	return fmt.Sprintf("W/\"%x-%x\"", fc.startTime, fc.revision)
+}
+
+// notModified returns the entity tag of a read request, and whether the
+// client already holds the response to it, so that the records are not
+// encoded again. The responses relying on the default time window may be
+// served as not modified for up to the period of the age ticker after a
+// record leaves the window, the ticker changing the revision.
+func (fc *FlowCollector) notModified(r *http.Request) (string, bool) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return "", false
+	}
+	etag := fc.revisionETag()
+	ifNoneMatch := r.Header.Get("If-None-Match")
+	return etag, ifNoneMatch != "" && etagMatches(ifNoneMatch, etag)
+}
+
+// etagMatches reports whether the If-None-Match header value matches the
+// given entity tag, using the weak comparison required for conditional GETs
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package flow
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestNotModified(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{})
+	etag := fc.revisionETag()
+
+	testTable := []struct {
+		name        string
+		method      string
+		ifNoneMatch string
+		notModified bool
+	}{
+		{
+			name:   "unconditional",
+			method: http.MethodGet,
+		},
+		{
+			name:        "matching",
+			method:      http.MethodGet,
+			ifNoneMatch: etag,
+			notModified: true,
+		},
+		{
+			name:        "matching-in-list",
+			method:      http.MethodHead,
+			ifNoneMatch: `"other", ` + strings.TrimPrefix(etag, "W/"),
+			notModified: true,
+		},
+		{
+			name:        "stale",
+			method:      http.MethodGet,
+			ifNoneMatch: `"stale"`,
+		},
+		{
+			name:        "not-a-get",
+			method:      http.MethodPost,
+			ifNoneMatch: etag,
+		},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, "/api/v1alpha1/sites/", nil)
+			if test.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", test.ifNoneMatch)
+			}
+			responseETag, notModified := fc.notModified(req)
+			assert.Equal(t, notModified, test.notModified)
+			if test.method == http.MethodPost {
+				assert.Equal(t, responseETag, "")
+			} else {
+				assert.Equal(t, responseETag, etag)
+			}
+		})
+	}
+
+	// a change of the records invalidates the entity tags issued before
+	fc.changed()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1alpha1/sites/", nil)
+	req.Header.Set("If-None-Match", etag)
+	responseETag, notModified := fc.notModified(req)
+	assert.Assert(t, !notModified)
+	assert.Assert(t, responseETag != etag)
+
+	// the revisions of a restarted collector do not match the previous ones
+	restarted := NewFlowCollector(FlowCollectorSpec{})
+	restarted.startTime = fc.startTime + 1
+	restarted.revision = fc.revision
+	assert.Assert(t, restarted.revisionETag() != fc.revisionETag())
+}
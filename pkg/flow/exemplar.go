@@ -0,0 +1,36 @@
+package flow
+
+import (
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// exemplarLabel names the flow pair an observation came from, so that a
+// spike in a chart leads to the flow in the console
+const exemplarLabel = "flowpair_id"
+
+// flowPairExemplar returns the exemplar of the observations of a flow pair,
+// nil when the identity is too long to be an exemplar
+func flowPairExemplar(identity string) prometheus.Labels {
+	if utf8.RuneCountInString(exemplarLabel+identity) > prometheus.ExemplarMaxRunes {
+		return nil
+	}
+	return prometheus.Labels{exemplarLabel: identity}
+}
+
+func observeWithExemplar(observer prometheus.Observer, value float64, exemplar prometheus.Labels) {
+	if e, ok := observer.(prometheus.ExemplarObserver); ok && exemplar != nil {
+		e.ObserveWithExemplar(value, exemplar)
+		return
+	}
+	observer.Observe(value)
+}
+
+func addWithExemplar(counter prometheus.Counter, value float64, exemplar prometheus.Labels) {
+	if e, ok := counter.(prometheus.ExemplarAdder); ok && exemplar != nil {
+		e.AddWithExemplar(value, exemplar)
+		return
+	}
+	counter.Add(value)
+}
@@ -0,0 +1,36 @@
+package flow
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"gotest.tools/assert"
+)
+
+func TestFlowPairExemplar(t *testing.T) {
+	assert.DeepEqual(t, flowPairExemplar("fp-router:1"), prometheus.Labels{"flowpair_id": "fp-router:1"})
+	assert.Assert(t, flowPairExemplar("fp-"+strings.Repeat("x", 120)) == nil)
+}
+
+func TestObserveWithExemplar(t *testing.T) {
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "flow_latency_microseconds", Buckets: []float64{1000}})
+	observeWithExemplar(histogram, 500, flowPairExemplar("fp-router:1"))
+	observeWithExemplar(histogram, 5000, nil)
+	m := &dto.Metric{}
+	assert.Assert(t, histogram.Write(m))
+	assert.Equal(t, m.GetHistogram().GetSampleCount(), uint64(2))
+	exemplar := m.GetHistogram().GetBucket()[0].GetExemplar()
+	assert.Equal(t, exemplar.GetLabel()[0].GetName(), "flowpair_id")
+	assert.Equal(t, exemplar.GetLabel()[0].GetValue(), "fp-router:1")
+	assert.Equal(t, exemplar.GetValue(), 500.0)
+
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "octets_total"})
+	addWithExemplar(counter, 100, flowPairExemplar("fp-router:1"))
+	addWithExemplar(counter, 10, nil)
+	m = &dto.Metric{}
+	assert.Assert(t, counter.Write(m))
+	assert.Equal(t, m.GetCounter().GetValue(), 110.0)
+	assert.Equal(t, m.GetCounter().GetExemplar().GetLabel()[0].GetValue(), "fp-router:1")
+}
@@ -0,0 +1,126 @@
+package flow
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	FailureRefused = "connection-refused"
+	FailureReset   = "connection-reset"
+	FailureTls     = "tls-failure"
+	FailureNoRoute = "no-route"
+	FailureTimeout = "timeout"
+	FailureOther   = "other"
+
+	// the layer of a failure tells the errors of the applications, as a
+	// server refusing or resetting connections, from the problems of the
+	// network carrying them
+	FailureLayerApplication = "application"
+	FailureLayerNetwork     = "network"
+	FailureLayerUnknown     = "unknown"
+)
+
+// failurePatterns are matched in order against the lower cased termination
+// reason of a flow
+var failurePatterns = []struct {
+	class    string
+	patterns []string
+}{
+	{FailureTls, []string{"tls", "ssl", "certificate", "handshake"}},
+	{FailureRefused, []string{"refused"}},
+	{FailureReset, []string{"reset", "broken pipe"}},
+	{FailureNoRoute, []string{"no route", "unreachable", "no path", "no consumer", "unroutable"}},
+	{FailureTimeout, []string{"timeout", "timed out"}},
+}
+
+var failureLayers = map[string]string{
+	FailureRefused: FailureLayerApplication,
+	FailureReset:   FailureLayerApplication,
+	FailureTls:     FailureLayerNetwork,
+	FailureNoRoute: FailureLayerNetwork,
+	FailureTimeout: FailureLayerNetwork,
+	FailureOther:   FailureLayerUnknown,
+}
+
+// ClassifyFailure returns the class of the termination reason of a flow
+func ClassifyFailure(reason string) string {
+	reason = strings.ToLower(reason)
+	for _, p := range failurePatterns {
+		for _, pattern := range p.patterns {
+			if strings.Contains(reason, pattern) {
+				return p.class
+			}
+		}
+	}
+	return FailureOther
+}
+
+func FailureLayer(class string) string {
+	if layer, ok := failureLayers[class]; ok {
+		return layer
+	}
+	return FailureLayerUnknown
+}
+
+// failureAddress returns the address of a failed flow, which may not have
+// been paired and so be found through its listener or connector only
+func (fc *FlowCollector) failureAddress(flow *FlowRecord) string {
+	if address := fc.flowAddress(flow); address != "" {
+		return address
+	}
+	if listener, ok := fc.Listeners[flow.Parent]; ok && listener.Address != nil {
+		return *listener.Address
+	}
+	if connector, ok := fc.Connectors[flow.Parent]; ok && connector.Address != nil {
+		return *connector.Address
+	}
+	return ""
+}
+
+func (fc *FlowCollector) siteLabel(flow *FlowRecord) string {
+	if flow == nil {
+		return ""
+	}
+	siteId := fc.getRecordSiteId(*flow)
+	if siteId == "" {
+		return ""
+	}
+	siteName := ""
+	if site, ok := fc.Sites[siteId]; ok && site.Name != nil {
+		siteName = *site.Name
+	}
+	return siteName + "@_@" + siteId
+}
+
+// recordFailure classifies the termination reason of a flow the first time
+// it is known and counts the failure by address and pair of sites
+func (fc *FlowCollector) recordFailure(flow *FlowRecord) {
+	if flow.Reason == nil || *flow.Reason == "" || flow.FailureClass != nil {
+		return
+	}
+	class := ClassifyFailure(*flow.Reason)
+	flow.FailureClass = &class
+	if fc.metrics == nil {
+		return
+	}
+	var counterFlow *FlowRecord
+	if flow.CounterFlow != nil {
+		counterFlow = fc.Flows[*flow.CounterFlow]
+	}
+	sourceSite, destSite := fc.siteLabel(flow), fc.siteLabel(counterFlow)
+	if fc.getFlowPlace(flow) == serverSide {
+		sourceSite, destSite = destSite, sourceSite
+	}
+	labels := prometheus.Labels{
+		"address":    fc.failureAddress(flow),
+		"sourceSite": sourceSite,
+		"destSite":   destSite,
+		"class":      class,
+		"layer":      FailureLayer(class),
+	}
+	if m, err := fc.metrics.flowFailures.GetMetricWith(labels); err == nil {
+		m.Inc()
+	}
+}
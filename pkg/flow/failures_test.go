@@ -0,0 +1,65 @@
+package flow
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	for reason, class := range map[string]string{
+		"Connection refused":                  FailureRefused,
+		"connection reset by peer":            FailureReset,
+		"TLS handshake failed":                FailureTls,
+		"certificate verify failed":           FailureTls,
+		"No route to destination":             FailureNoRoute,
+		"host unreachable":                    FailureNoRoute,
+		"connect timed out":                   FailureTimeout,
+		"the application closed unexpectedly": FailureOther,
+	} {
+		assert.Equal(t, ClassifyFailure(reason), class, reason)
+	}
+	assert.Equal(t, FailureLayer(FailureRefused), FailureLayerApplication)
+	assert.Equal(t, FailureLayer(FailureNoRoute), FailureLayerNetwork)
+	assert.Equal(t, FailureLayer(FailureOther), FailureLayerUnknown)
+}
+
+func TestRecordFailure(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{
+		Mode:    RecordMetrics,
+		PromReg: prometheus.NewRegistry(),
+	})
+	fc.metrics = fc.NewMetrics(fc.prometheusReg)
+	west, east, address := "west", "east", "backend"
+	fc.Sites["site-west"] = &SiteRecord{Base: Base{Identity: "site-west"}, Name: &west}
+	fc.Sites["site-east"] = &SiteRecord{Base: Base{Identity: "site-east"}, Name: &east}
+	fc.Routers["router-west"] = &RouterRecord{Base: Base{Identity: "router-west", Parent: "site-west"}}
+	fc.Routers["router-east"] = &RouterRecord{Base: Base{Identity: "router-east", Parent: "site-east"}}
+	fc.Listeners["listener-1"] = &ListenerRecord{Base: Base{Identity: "listener-1", Parent: "router-west"}, Address: &address}
+	fc.Connectors["connector-1"] = &ConnectorRecord{Base: Base{Identity: "connector-1", Parent: "router-east"}, Address: &address}
+
+	clientId, refused := "client-1", "connection refused"
+	fc.Flows["client-1"] = &FlowRecord{Base: Base{Identity: "client-1", Parent: "listener-1"}}
+	server := &FlowRecord{Base: Base{Identity: "server-1", Parent: "connector-1"}, CounterFlow: &clientId, Reason: &refused}
+	fc.Flows["server-1"] = server
+	fc.recordFailure(server)
+	// counted once whatever the updates of the reason
+	fc.recordFailure(server)
+	assert.Equal(t, *server.FailureClass, FailureRefused)
+
+	fc.recordFailure(fc.Flows["client-1"])
+	assert.Assert(t, fc.Flows["client-1"].FailureClass == nil)
+
+	counter, err := fc.metrics.flowFailures.GetMetricWith(prometheus.Labels{
+		"address":    "backend",
+		"sourceSite": "west@_@site-west",
+		"destSite":   "east@_@site-east",
+		"class":      FailureRefused,
+		"layer":      FailureLayerApplication,
+	})
+	assert.Assert(t, err)
+	assert.Equal(t, testutil.ToFloat64(counter), 1.0)
+	assert.Equal(t, testutil.CollectAndCount(fc.metrics.flowFailures), 1)
+}
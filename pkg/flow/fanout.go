@@ -0,0 +1,40 @@
+package flow
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// addFanoutTarget counts the server side flows paired with a client flow,
+// the requests of an aggregated or multicast service are answered by more
+// than one
+func (fc *FlowCollector) addFanoutTarget(forwardFlow *FlowRecord) {
+	if forwardFlow.EndTime != 0 {
+		// paired too late to be part of the fan-out latency
+		return
+	}
+	fc.fanoutTargets[forwardFlow.Identity]++
+}
+
+// observeFanout records the latency of a client flow that was fanned out
+// to several targets once it has ended
+func (fc *FlowCollector) observeFanout(forwardFlow *FlowRecord) {
+	targets, ok := fc.fanoutTargets[forwardFlow.Identity]
+	if !ok {
+		return
+	}
+	delete(fc.fanoutTargets, forwardFlow.Identity)
+	if targets < 2 || fc.metrics == nil || forwardFlow.EndTime < forwardFlow.StartTime {
+		return
+	}
+	address := ""
+	if va, ok := fc.VanAddresses[forwardFlow.addressId]; ok {
+		address = va.Name
+	}
+	labels := prometheus.Labels{"address": address}
+	if m, err := fc.metrics.fanoutLatency.GetMetricWith(labels); err == nil {
+		m.Observe(float64(forwardFlow.EndTime - forwardFlow.StartTime))
+	}
+	if m, err := fc.metrics.fanoutTargets.GetMetricWith(labels); err == nil {
+		m.Observe(float64(targets))
+	}
+}
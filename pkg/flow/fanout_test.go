@@ -0,0 +1,39 @@
+package flow
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestAggregateFanout(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{
+		Mode:    RecordMetrics,
+		PromReg: prometheus.NewRegistry(),
+	})
+	fc.metrics = fc.NewMetrics(fc.prometheusReg)
+	addressName := "mc/inventory:8080"
+	fc.VanAddresses["address:0"] = &VanAddressRecord{Base: Base{Identity: "address:0"}, Name: addressName}
+
+	fannedOut := &FlowRecord{Base: Base{Identity: "flow:0", StartTime: 1000}, addressId: "address:0"}
+	single := &FlowRecord{Base: Base{Identity: "flow:1", StartTime: 1000}, addressId: "address:0"}
+	for i := 0; i < 3; i++ {
+		fc.addFanoutTarget(fannedOut)
+	}
+	fc.addFanoutTarget(single)
+
+	single.EndTime = 2000
+	fc.observeFanout(single)
+	assert.Equal(t, testutil.CollectAndCount(fc.metrics.fanoutLatency), 0)
+
+	fannedOut.EndTime = 6000
+	fc.observeFanout(fannedOut)
+	assert.Equal(t, testutil.CollectAndCount(fc.metrics.fanoutLatency), 1)
+	assert.Equal(t, len(fc.fanoutTargets), 0)
+
+	// flows paired after they ended are not counted
+	fc.addFanoutTarget(fannedOut)
+	assert.Equal(t, len(fc.fanoutTargets), 0)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net"
 	"sort"
 	"strings"
@@ -18,6 +17,7 @@ import (
 	"github.com/skupperproject/skupper/pkg/config"
 	"github.com/skupperproject/skupper/pkg/domain/podman"
 	"github.com/skupperproject/skupper/pkg/kube"
+	"github.com/skupperproject/skupper/pkg/network"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
@@ -40,7 +40,7 @@ func (fc *FlowCollector) inferGatewaySite(siteId string) error {
 						Name:      &name,
 						NameSpace: &namespace,
 					}
-					log.Printf("FLOW_LOG: %s\n", prettyPrint(site))
+					logger.Infof("FLOW_LOG: %s", prettyPrint(site))
 					fc.Sites[siteId] = &site
 				}
 			}
@@ -80,7 +80,7 @@ func (fc *FlowCollector) inferGatewayProcess(siteId string, host string) error {
 			}
 		}
 		if !procFound {
-			log.Printf("COLLECTOR: Inferring gateway process %s \n", host)
+			logger.Infof("Inferring gateway process %s", host)
 			procIdentity := uuid.New().String()
 			fc.Processes[procIdentity] = &ProcessRecord{
 				Base: Base{
@@ -96,6 +96,7 @@ func (fc *FlowCollector) inferGatewayProcess(siteId string, host string) error {
 				SourceHost:    &host,
 				ProcessRole:   &External,
 			}
+			fc.siteIndex.add(fc.Processes[procIdentity])
 		}
 	}
 	return nil
@@ -455,6 +456,7 @@ func (fc *FlowCollector) linkFlowPair(flow *FlowRecord) (*FlowPairRecord, bool)
 		return fp, ok
 	}
 	fp.FlowTrace = fc.annotateFlowTrace(destFlow)
+	fp.TraceId = flowPairTraceId(fp)
 
 	// setup flow metrics inc flow count, set octets, assign metric to flow, etc.
 	addressId := fwdLabels["addressId"]
@@ -463,14 +465,33 @@ func (fc *FlowCollector) linkFlowPair(flow *FlowRecord) (*FlowPairRecord, bool)
 		delete(fwdLabels, "addressId")
 		revLabels["address"] = va.Name
 		delete(revLabels, "addressId")
+		exemplar := flowPairExemplar(fp.Identity)
+		sourceFlow.exemplar, destFlow.exemplar = exemplar, exemplar
 		err := fc.setupFlowMetrics(va, sourceFlow, fwdLabels)
 		if err != nil {
-			log.Println("COLLECTOR: metric setup error", err.Error())
+			logger.Errorf("Metric setup error: %s", err)
 		}
 		err = fc.setupFlowMetrics(va, destFlow, revLabels)
 		if err != nil {
-			log.Println("COLLECTOR: metric setup error", err.Error())
+			logger.Errorf("Metric setup error: %s", err)
 		}
+		err = fc.setupProcessMetrics(sourceFlow, destFlow)
+		if err != nil {
+			logger.Errorf("Process metric setup error: %s", err)
+		}
+		err = fc.setupProcessMetrics(destFlow, sourceFlow)
+		if err != nil {
+			logger.Errorf("Process metric setup error: %s", err)
+		}
+		// later octet updates are added to the address history by flow
+		var octets uint64
+		for _, flow := range []*FlowRecord{sourceFlow, destFlow} {
+			flow.addressId = va.Identity
+			if flow.Octets != nil {
+				octets += *flow.Octets
+			}
+		}
+		fc.addAddressHistory(va.Identity, octets, 1)
 	}
 
 	return fp, ok
@@ -491,6 +512,9 @@ var defaultRetry = wait.Backoff{
 var netUpdateCt int
 
 func (fc *FlowCollector) updateNetworkStatus() error {
+	if fc.leading != nil && !fc.leading() {
+		return nil
+	}
 	var err error
 	networkData := map[string]string{}
 	platform := config.GetPlatform()
@@ -542,6 +566,14 @@ func (fc *FlowCollector) updateNetworkStatus() error {
 				return err
 			}
 
+			if fc.networkHistory == nil {
+				fc.networkHistory, err = network.UnmarshalNetworkStatusHistory(configMap.Data[network.NetworkStatusHistoryKey])
+				if err != nil {
+					logger.Warnf("Discarding the unreadable network status history: %s", err)
+					fc.networkHistory = []network.NetworkSnapshot{}
+				}
+			}
+			fc.recordNetworkSnapshot(networkData)
 			configMap.Data = networkData
 
 			_, err = cli.KubeClient.CoreV1().ConfigMaps(cli.Namespace).Update(context.TODO(), configMap, metav1.UpdateOptions{})
@@ -554,20 +586,46 @@ func (fc *FlowCollector) updateNetworkStatus() error {
 		})
 		if !fc.networkStatusUp && len(networkStatus.Sites) > 0 && len(networkStatus.Sites[0].RouterStatus) > 0 {
 			fc.networkStatusUp = true
-			log.Printf("COLLECTOR: First functional network status update written after %s and %d updates\n", time.Since(fc.begin), netUpdateCt)
+			logger.Infof("First functional network status update written after %s and %d updates", time.Since(fc.begin), netUpdateCt)
 		}
-	} else if platform == types.PlatformPodman {
+	} else if platform == types.PlatformPodman || platform == types.PlatformDocker {
 		networkStatusHandler := &podman.NetworkStatusHandler{}
 		err = networkStatusHandler.Update(networkData["NetworkStatus"])
+		if err != nil {
+			return err
+		}
+		if fc.networkHistory == nil {
+			if fc.networkHistory, err = networkStatusHandler.GetHistory(); err != nil {
+				fc.networkHistory = []network.NetworkSnapshot{}
+			}
+		}
+		fc.recordNetworkSnapshot(networkData)
+		err = networkStatusHandler.UpdateHistory(networkData[network.NetworkStatusHistoryKey])
 	}
 	return err
 }
 
+// recordNetworkSnapshot adds the network status to the history when the
+// sites, links or services changed, so the current network can be compared
+// with an earlier one
+func (fc *FlowCollector) recordNetworkSnapshot(networkData map[string]string) {
+	var status network.NetworkStatusInfo
+	if err := json.Unmarshal([]byte(networkData["NetworkStatus"]), &status); err != nil {
+		return
+	}
+	fc.networkHistory = network.AddNetworkSnapshot(fc.networkHistory, network.NewNetworkSnapshot(&status, time.Now()))
+	history, err := json.Marshal(fc.networkHistory)
+	if err != nil {
+		return
+	}
+	networkData[network.NetworkStatusHistoryKey] = string(history)
+}
+
 func (fc *FlowCollector) addRecord(record interface{}) error {
 	if record == nil {
 		return fmt.Errorf("No record to add")
 	}
-	log.Printf("FLOW_LOG: %s\n", prettyPrint(record))
+	logger.Infof("FLOW_LOG: %s", prettyPrint(record))
 
 	switch record.(type) {
 	case *SiteRecord:
@@ -618,9 +676,15 @@ func (fc *FlowCollector) addRecord(record interface{}) error {
 		if va, ok := record.(*VanAddressRecord); ok {
 			fc.VanAddresses[va.Identity] = va
 		}
+	case *SiteLatencyRecord:
+		if latency, ok := record.(*SiteLatencyRecord); ok {
+			fc.SiteLatencies[latency.Identity] = latency
+		}
 	default:
 		return fmt.Errorf("Unknown record type to add")
 	}
+	fc.recordSiteEvent(record, true)
+	fc.siteIndex.add(record)
 	if fc.mode == RecordStatus {
 		fc.updateNetworkStatus()
 	}
@@ -631,7 +695,7 @@ func (fc *FlowCollector) deleteRecord(record interface{}) error {
 	if record == nil {
 		return fmt.Errorf("No record to delete")
 	}
-	log.Printf("FLOW_LOG: %s\n", prettyPrint(record))
+	logger.Infof("FLOW_LOG: %s", prettyPrint(record))
 	switch record.(type) {
 	case *SiteRecord:
 		if site, ok := record.(*SiteRecord); ok {
@@ -662,6 +726,7 @@ func (fc *FlowCollector) deleteRecord(record interface{}) error {
 	case *FlowRecord:
 		if flow, ok := record.(*FlowRecord); ok {
 			delete(fc.Flows, flow.Identity)
+			delete(fc.fanoutTargets, flow.Identity)
 		}
 	case *FlowPairRecord:
 		if flowPair, ok := record.(*FlowPairRecord); ok {
@@ -682,10 +747,17 @@ func (fc *FlowCollector) deleteRecord(record interface{}) error {
 	case *VanAddressRecord:
 		if va, ok := record.(*VanAddressRecord); ok {
 			delete(fc.VanAddresses, va.Identity)
+			delete(fc.addressHistory, va.Identity)
+		}
+	case *SiteLatencyRecord:
+		if latency, ok := record.(*SiteLatencyRecord); ok {
+			delete(fc.SiteLatencies, latency.Identity)
 		}
 	default:
 		return fmt.Errorf("Unknown record type to delete")
 	}
+	fc.recordSiteEvent(record, false)
+	fc.siteIndex.remove(record)
 	if fc.mode == RecordStatus {
 		fc.updateNetworkStatus()
 	}
@@ -709,6 +781,7 @@ func (fc *FlowCollector) updateRecord(record interface{}) error {
 				eventsource.LastHeard = uint64(time.Now().UnixNano()) / uint64(time.Microsecond)
 				eventsource.Heartbeats++
 				eventsource.Messages++
+				fc.observeClockSkew(eventsource, heartbeat.Now, eventsource.LastHeard)
 			}
 			if pending, ok := fc.pendingFlush[heartbeat.Source]; ok {
 				pending.heartbeat = true
@@ -731,6 +804,7 @@ func (fc *FlowCollector) updateRecord(record interface{}) error {
 							}
 						}
 					}
+					fc.endSiteLatencies(current.Identity, current.EndTime)
 					fc.deleteRecord(current)
 				} else {
 					updatesNetworkStatus = true
@@ -753,6 +827,7 @@ func (fc *FlowCollector) updateRecord(record interface{}) error {
 					fc.deleteRecord(current)
 				} else {
 					*current = host
+					fc.siteIndex.add(current)
 				}
 			}
 			fc.updateLastHeard(host.Source)
@@ -772,6 +847,7 @@ func (fc *FlowCollector) updateRecord(record interface{}) error {
 					fc.deleteRecord(current)
 				} else if router.Parent != "" && current.Parent == "" {
 					current.Parent = router.Parent
+					fc.siteIndex.add(current)
 					if _, ok := fc.Sites[current.Parent]; !ok {
 						fc.inferGatewaySite(current.Parent)
 					}
@@ -779,9 +855,15 @@ func (fc *FlowCollector) updateRecord(record interface{}) error {
 			}
 			fc.updateLastHeard(router.Source)
 		}
+	case SiteLatencyRecord:
+		if latency, ok := record.(SiteLatencyRecord); ok {
+			fc.updateSiteLatency(latency)
+			fc.updateLastHeard(latency.Source)
+		}
 	case LogEventRecord:
 		if logEvent, ok := record.(LogEventRecord); ok {
-			log.Printf("LOG_EVENT: %s \n", prettyPrint(logEvent))
+			logger.Infof("LOG_EVENT: %s", prettyPrint(logEvent))
+			fc.recordLogEvent(logEvent)
 		}
 	case LinkRecord:
 		if link, ok := record.(LinkRecord); ok {
@@ -1013,7 +1095,7 @@ func (fc *FlowCollector) updateRecord(record interface{}) error {
 			if current, ok := fc.Flows[flow.Identity]; !ok {
 				if flow.StartTime != 0 && flow.EndTime != 0 {
 					if flow.Parent == "" {
-						log.Printf("COLLECTOR: Incomplete flow record for identity %s details %+v\n", flow.Identity, flow)
+						logger.Warnf("Incomplete flow record for identity %s details %+v", flow.Identity, flow)
 					}
 				}
 				if flow.StartTime != 0 {
@@ -1029,12 +1111,17 @@ func (fc *FlowCollector) updateRecord(record interface{}) error {
 						}
 					}
 					fc.addRecord(&flow)
+					fc.recordFailure(&flow)
 					if flow.CounterFlow != nil {
 						fc.flowsToPairReconcile[flow.Identity] = &FlowToPairRecord{
 							forwardId: *flow.CounterFlow,
 							created:   uint64(time.Now().UnixNano()) / uint64(time.Microsecond)}
 					}
 					fc.flowsToProcessReconcile[flow.Identity] = flow.Identity
+				} else {
+					// an update of a flow whose start was missed or that
+					// was already purged
+					fc.countIgnored(IgnoreUnknownFlow, Flow, 1)
 				}
 			} else {
 				if current.SourceHost == nil && flow.SourceHost != nil {
@@ -1058,9 +1145,19 @@ func (fc *FlowCollector) updateRecord(record interface{}) error {
 				if flow.Octets != nil {
 					current.Octets = flow.Octets
 					if current.octetMetric != nil {
-						current.octetMetric.Add(float64(*current.Octets - current.lastOctets))
+						addWithExemplar(current.octetMetric, float64(*current.Octets-current.lastOctets), current.exemplar)
+					}
+					if current.processMetrics != nil && *current.Octets > current.lastOctets {
+						current.processMetrics.addOctets(*current.Octets - current.lastOctets)
+					}
+					if *current.Octets > current.lastOctets {
+						fc.addTaggedOctets(current, *current.Octets-current.lastOctets)
+						if current.addressId != "" {
+							fc.addAddressHistory(current.addressId, *current.Octets-current.lastOctets, 0)
+						}
 					}
 					current.lastOctets = *current.Octets
+					fc.tagFlowPair(fc.flowPairForFlow(current))
 				}
 				if flow.OctetsOut != nil {
 					current.OctetsOut = flow.OctetsOut
@@ -1075,23 +1172,32 @@ func (fc *FlowCollector) updateRecord(record interface{}) error {
 					current.WindowSize = flow.WindowSize
 				}
 				if flow.Latency != nil {
+					observe := current.Latency == nil
 					current.Latency = flow.Latency
+					if observe {
+						observeLatency(current)
+					}
 				}
 				if flow.Trace != nil {
 					current.Trace = flow.Trace
 				}
 				if flow.Reason != nil {
 					current.Reason = flow.Reason
+					fc.recordFailure(current)
 				}
 				if flow.Method != nil {
 					current.Method = flow.Method
 				}
 				if flow.Result != nil {
 					current.Result = flow.Result
+					if flowPair, ok := fc.flowPairOf(current); ok {
+						fc.countHttpResponse(flowPair)
+					}
 				}
 				if flow.StreamIdentity != nil {
 					current.StreamIdentity = flow.StreamIdentity
 				}
+				fc.captureTraceContext(current, &flow)
 				if flow.CounterFlow != nil && current.CounterFlow == nil {
 					current.CounterFlow = flow.CounterFlow
 				}
@@ -1100,11 +1206,19 @@ func (fc *FlowCollector) updateRecord(record interface{}) error {
 					if current.activeFlowMetric != nil {
 						current.activeFlowMetric.Dec()
 					}
+					if current.processMetrics != nil {
+						current.processMetrics.close()
+					}
 					if fc.getFlowPlace(current) == clientSide {
 						if flowpair, ok := fc.FlowPairs["fp-"+current.Identity]; ok {
 							flowpair.EndTime = current.EndTime
 							flowpair.Duration = flowpair.EndTime - flowpair.StartTime
+							if flowpair.ProcessAggregateId != nil {
+								fc.emitFlowEvent(flowpair, FlowEventClose)
+							}
 						}
+						fc.observeFanout(current)
+						fc.observeSlo(current)
 					}
 				}
 			}
@@ -1204,8 +1318,9 @@ func (fc *FlowCollector) updateRecord(record interface{}) error {
 	return nil
 }
 
-func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
+func (fc *FlowCollector) retrieve(ctx context.Context, request ApiRequest) (*string, error) {
 	vars := mux.Vars(request.Request)
+	deadline := &queryDeadline{ctx: ctx}
 	url := request.Request.URL
 	queryParams := getQueryParams(url)
 	var retrieveError error = nil
@@ -1243,12 +1358,13 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 			processes := []ProcessRecord{}
 			if id, ok := vars["id"]; ok {
 				if site, ok := fc.Sites[id]; ok {
-					for _, process := range fc.Processes {
-						if process.Parent == site.Identity {
-							p.TotalCount++
-							if filterRecord(*process, queryParams) && process.Base.TimeRangeValid(queryParams) {
-								processes = append(processes, *process)
-							}
+					for _, process := range fc.siteIndex.processes(site.Identity) {
+						if deadline.reached() {
+							break
+						}
+						p.TotalCount++
+						if filterRecord(process, queryParams) && process.Base.TimeRangeValid(queryParams) {
+							processes = append(processes, process)
 						}
 					}
 				}
@@ -1258,12 +1374,13 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 			routers := []RouterRecord{}
 			if id, ok := vars["id"]; ok {
 				if site, ok := fc.Sites[id]; ok {
-					for _, router := range fc.Routers {
-						if router.Parent == site.Identity {
-							p.TotalCount++
-							if filterRecord(*router, queryParams) && router.Base.TimeRangeValid(queryParams) {
-								routers = append(routers, *router)
-							}
+					for _, router := range fc.siteIndex.routers(site.Identity) {
+						if deadline.reached() {
+							break
+						}
+						p.TotalCount++
+						if filterRecord(router, queryParams) && router.Base.TimeRangeValid(queryParams) {
+							routers = append(routers, router)
 						}
 					}
 				}
@@ -1288,12 +1405,13 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 			hosts := []HostRecord{}
 			if id, ok := vars["id"]; ok {
 				if site, ok := fc.Sites[id]; ok {
-					for _, host := range fc.Hosts {
-						if host.Parent == site.Identity {
-							p.TotalCount++
-							if filterRecord(*host, queryParams) && host.Base.TimeRangeValid(queryParams) {
-								hosts = append(hosts, *host)
-							}
+					for _, host := range fc.siteIndex.hosts(site.Identity) {
+						if deadline.reached() {
+							break
+						}
+						p.TotalCount++
+						if filterRecord(host, queryParams) && host.Base.TimeRangeValid(queryParams) {
+							hosts = append(hosts, host)
 						}
 					}
 				}
@@ -1342,8 +1460,14 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 			if id, ok := vars["id"]; ok {
 				if _, ok := fc.Routers[id]; ok {
 					for connId, connector := range fc.Connectors {
+						if deadline.reached() {
+							break
+						}
 						if connector.Parent == id {
 							for _, flow := range fc.Flows {
+								if deadline.reached() {
+									break
+								}
 								if flow.Parent == connId {
 									p.TotalCount++
 									if filterRecord(*flow, queryParams) && flow.Base.TimeRangeValid(queryParams) {
@@ -1361,8 +1485,14 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 						}
 					}
 					for listenerId, listener := range fc.Listeners {
+						if deadline.reached() {
+							break
+						}
 						if listener.Parent == id {
 							for _, flow := range fc.Flows {
+								if deadline.reached() {
+									break
+								}
 								if flow.Parent == listenerId {
 									p.TotalCount++
 									if filterRecord(*flow, queryParams) && flow.Base.TimeRangeValid(queryParams) {
@@ -1402,6 +1532,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 			if id, ok := vars["id"]; ok {
 				if router, ok := fc.Routers[id]; ok {
 					for _, listener := range fc.Listeners {
+						if deadline.reached() {
+							break
+						}
 						if listener.Parent == router.Identity {
 							p.TotalCount++
 							if filterRecord(*listener, queryParams) && listener.Base.TimeRangeValid(queryParams) {
@@ -1417,6 +1550,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 			if id, ok := vars["id"]; ok {
 				if router, ok := fc.Routers[id]; ok {
 					for _, connector := range fc.Connectors {
+						if deadline.reached() {
+							break
+						}
 						if connector.Parent == router.Identity {
 							p.TotalCount++
 							if filterRecord(*connector, queryParams) && connector.Base.TimeRangeValid(queryParams) {
@@ -1452,6 +1588,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 		case "list":
 			listeners := []ListenerRecord{}
 			for _, listener := range fc.Listeners {
+				if deadline.reached() {
+					break
+				}
 				if filterRecord(*listener, queryParams) && listener.Base.TimeRangeValid(queryParams) {
 					listeners = append(listeners, *listener)
 				}
@@ -1470,6 +1609,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 			if id, ok := vars["id"]; ok {
 				if _, ok := fc.Listeners[id]; ok {
 					for _, flow := range fc.Flows {
+						if deadline.reached() {
+							break
+						}
 						if flow.Parent == id {
 							p.TotalCount++
 							if filterRecord(*flow, queryParams) && flow.Base.TimeRangeValid(queryParams) {
@@ -1493,6 +1635,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 		case "list":
 			connectors := []ConnectorRecord{}
 			for _, connector := range fc.Connectors {
+				if deadline.reached() {
+					break
+				}
 				if filterRecord(*connector, queryParams) && connector.Base.TimeRangeValid(queryParams) {
 					connectors = append(connectors, *connector)
 				}
@@ -1511,6 +1656,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 			if id, ok := vars["id"]; ok {
 				if _, ok := fc.Connectors[id]; ok {
 					for _, flow := range fc.Flows {
+						if deadline.reached() {
+							break
+						}
 						if flow.Parent == id {
 							p.TotalCount++
 							if filterRecord(*flow, queryParams) && flow.Base.TimeRangeValid(queryParams) {
@@ -1565,8 +1713,14 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 			if id, ok := vars["id"]; ok {
 				if vanaddr, ok := fc.VanAddresses[id]; ok {
 					for connId, connector := range fc.Connectors {
+						if deadline.reached() {
+							break
+						}
 						if *connector.Address == vanaddr.Name {
 							for _, flow := range fc.Flows {
+								if deadline.reached() {
+									break
+								}
 								if flow.Parent == connId && *connector.Protocol == "tcp" {
 									p.TotalCount++
 									if filterRecord(*flow, queryParams) && flow.Base.TimeRangeValid(queryParams) {
@@ -1584,8 +1738,14 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 						}
 					}
 					for listenerId, listener := range fc.Listeners {
+						if deadline.reached() {
+							break
+						}
 						if *listener.Address == vanaddr.Name {
 							for _, flow := range fc.Flows {
+								if deadline.reached() {
+									break
+								}
 								if flow.Parent == listenerId && *listener.Protocol == "tcp" {
 									p.TotalCount++
 									if filterRecord(*flow, queryParams) && flow.Base.TimeRangeValid(queryParams) {
@@ -1611,8 +1771,14 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 				if vanaddr, ok := fc.VanAddresses[id]; ok {
 					// forward flow for a flow pair is indexed by listener flow id
 					for listenerId, listener := range fc.Listeners {
+						if deadline.reached() {
+							break
+						}
 						if *listener.Address == vanaddr.Name {
 							for flowId, flow := range fc.Flows {
+								if deadline.reached() {
+									break
+								}
 								if flow.Parent == listenerId && flow.CounterFlow != nil {
 									if flowpair, ok := fc.FlowPairs["fp-"+flowId]; ok {
 										p.TotalCount++
@@ -1642,6 +1808,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 			if id, ok := vars["id"]; ok {
 				if vanaddr, ok := fc.VanAddresses[id]; ok {
 					for _, connector := range fc.Connectors {
+						if deadline.reached() {
+							break
+						}
 						if *connector.Address == vanaddr.Name && connector.ProcessId != nil {
 							if process, ok := fc.Processes[*connector.ProcessId]; ok {
 								if filterRecord(*process, queryParams) && process.Base.TimeRangeValid(queryParams) {
@@ -1662,8 +1831,14 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 			if id, ok := vars["id"]; ok {
 				if vanaddr, ok := fc.VanAddresses[id]; ok {
 					for _, connector := range fc.Connectors {
+						if deadline.reached() {
+							break
+						}
 						if *connector.Address == vanaddr.Name && connector.ProcessId != nil {
 							for _, aggregate := range fc.FlowAggregates {
+								if deadline.reached() {
+									break
+								}
 								if aggregate.PairType == recordNames[Process] {
 									if *connector.ProcessId == *aggregate.DestinationId {
 										p.TotalCount++
@@ -1683,6 +1858,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 			if id, ok := vars["id"]; ok {
 				if vanaddr, ok := fc.VanAddresses[id]; ok {
 					for _, listener := range fc.Listeners {
+						if deadline.reached() {
+							break
+						}
 						if *listener.Address == vanaddr.Name {
 							p.TotalCount++
 							if filterRecord(*listener, queryParams) && listener.Base.TimeRangeValid(queryParams) {
@@ -1698,6 +1876,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 			if id, ok := vars["id"]; ok {
 				if vanaddr, ok := fc.VanAddresses[id]; ok {
 					for _, connector := range fc.Connectors {
+						if deadline.reached() {
+							break
+						}
 						if *connector.Address == vanaddr.Name {
 							p.TotalCount++
 							if filterRecord(*connector, queryParams) && connector.Base.TimeRangeValid(queryParams) {
@@ -1708,12 +1889,29 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 				}
 			}
 			retrieveError = sortAndSlice(connectors, &p, queryParams)
+		case "forecast":
+			if id, ok := vars["id"]; ok {
+				if address, ok := fc.VanAddresses[id]; ok {
+					history, ok := fc.addressHistory[id]
+					if !ok {
+						history = &addressHistory{}
+					}
+					forecast := history.forecast(time.Now(), forecastHours(url.Query().Get("hours")))
+					forecast.Identity = address.Identity
+					forecast.Address = address.Name
+					p.Count = 1
+					p.Results = forecast
+				}
+			}
 		}
 	case Process:
 		switch request.HandlerName {
 		case "list":
 			processes := []ProcessRecord{}
 			for _, process := range fc.Processes {
+				if deadline.reached() {
+					break
+				}
 				if filterRecord(*process, queryParams) && process.Base.TimeRangeValid(queryParams) {
 					if process.connector != nil {
 						process.Addresses = nil
@@ -1740,6 +1938,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 			flows := []FlowRecord{}
 			if id, ok := vars["id"]; ok {
 				for _, flow := range fc.Flows {
+					if deadline.reached() {
+						break
+					}
 					if flow.Process != nil && *flow.Process == id {
 						p.TotalCount++
 						if filterRecord(*flow, queryParams) && flow.Base.TimeRangeValid(queryParams) {
@@ -1754,6 +1955,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 			if id, ok := vars["id"]; ok {
 				if _, ok := fc.Processes[id]; ok {
 					for _, connector := range fc.Connectors {
+						if deadline.reached() {
+							break
+						}
 						if connector.ProcessId != nil && *connector.ProcessId == id {
 							for _, address := range fc.VanAddresses {
 								if *connector.Address == address.Name {
@@ -1788,6 +1992,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 			for _, processGroup := range fc.ProcessGroups {
 				count := 0
 				for _, process := range fc.Processes {
+					if deadline.reached() {
+						break
+					}
 					if *process.GroupIdentity == processGroup.Identity {
 						count++
 					}
@@ -1804,6 +2011,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 				if processGroup, ok := fc.ProcessGroups[id]; ok {
 					count := 0
 					for _, process := range fc.Processes {
+						if deadline.reached() {
+							break
+						}
 						if *process.GroupIdentity == processGroup.Identity {
 							count++
 						}
@@ -1818,6 +2028,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 			if id, ok := vars["id"]; ok {
 				if processGroup, ok := fc.ProcessGroups[id]; ok {
 					for _, process := range fc.Processes {
+						if deadline.reached() {
+							break
+						}
 						if *process.GroupIdentity == processGroup.Identity {
 							p.TotalCount++
 							if filterRecord(*process, queryParams) && process.Base.TimeRangeValid(queryParams) {
@@ -1834,6 +2047,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 		case "list":
 			flows := []FlowRecord{}
 			for _, flow := range fc.Flows {
+				if deadline.reached() {
+					break
+				}
 				if filterRecord(*flow, queryParams) && flow.Base.TimeRangeValid(queryParams) {
 					flows = append(flows, *flow)
 				}
@@ -1864,6 +2080,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 		case "list":
 			flowPairs := []FlowPairRecord{}
 			for _, flowPair := range fc.FlowPairs {
+				if deadline.reached() {
+					break
+				}
 				if filterRecord(*flowPair, queryParams) && flowPair.Base.TimeRangeValid(queryParams) {
 					flowPairs = append(flowPairs, *flowPair)
 				}
@@ -1885,6 +2104,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 		case "list":
 			aggregates := []FlowAggregateRecord{}
 			for _, aggregate := range fc.FlowAggregates {
+				if deadline.reached() {
+					break
+				}
 				if aggregate.PairType == recordNames[Site] {
 					p.TotalCount++
 					if sourceId == "" && destinationId == "" ||
@@ -1908,6 +2130,30 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 				}
 			}
 		}
+	case SiteLatency:
+		sourceId := url.Query().Get("sourceId")
+		destinationId := url.Query().Get("destinationId")
+		switch request.HandlerName {
+		case "list":
+			latencies := []SiteLatencyRecord{}
+			for _, latency := range fc.SiteLatencies {
+				if sourceId != "" && sourceId != latency.Parent || destinationId != "" && destinationId != *latency.DestinationId {
+					continue
+				}
+				if filterRecord(*latency, queryParams) {
+					latencies = append(latencies, *latency)
+				}
+			}
+			p.TotalCount = len(fc.SiteLatencies)
+			retrieveError = sortAndSlice(latencies, &p, queryParams)
+		case "item":
+			if id, ok := vars["id"]; ok {
+				if latency, ok := fc.SiteLatencies[id]; ok {
+					p.Count = 1
+					p.Results = latency
+				}
+			}
+		}
 	case ProcessGroupPair:
 		sourceId := url.Query().Get("sourceId")
 		destinationId := url.Query().Get("destinationId")
@@ -1915,6 +2161,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 		case "list":
 			aggregates := []FlowAggregateRecord{}
 			for _, aggregate := range fc.FlowAggregates {
+				if deadline.reached() {
+					break
+				}
 				if aggregate.PairType == recordNames[ProcessGroup] {
 					p.TotalCount++
 					if sourceId == "" && destinationId == "" ||
@@ -1945,6 +2194,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 		case "list":
 			aggregates := []FlowAggregateRecord{}
 			for _, aggregate := range fc.FlowAggregates {
+				if deadline.reached() {
+					break
+				}
 				if aggregate.PairType == recordNames[Process] {
 					p.TotalCount++
 					if sourceId == "" && destinationId == "" ||
@@ -1990,6 +2242,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 		case "sitepair-list":
 			aggregates := []FlowAggregateRecord{}
 			for _, aggregate := range fc.FlowAggregates {
+				if deadline.reached() {
+					break
+				}
 				if aggregate.PairType == recordNames[Site] {
 					p.TotalCount++
 					if sourceId == "" && destinationId == "" ||
@@ -2013,6 +2268,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 		case "processpair-list":
 			aggregates := []FlowAggregateRecord{}
 			for _, aggregate := range fc.FlowAggregates {
+				if deadline.reached() {
+					break
+				}
 				if aggregate.PairType == recordNames[Process] {
 					p.TotalCount++
 					if sourceId == "" && destinationId == "" ||
@@ -2036,6 +2294,9 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 		case "processgrouppair-list":
 			aggregates := []FlowAggregateRecord{}
 			for _, aggregate := range fc.FlowAggregates {
+				if deadline.reached() {
+					break
+				}
 				if aggregate.PairType == recordNames[ProcessGroup] {
 					p.TotalCount++
 					if sourceId == "" && destinationId == "" ||
@@ -2123,10 +2384,16 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 			retrieveError = sortAndSlice(flowPairs, &p, queryParams)
 		}
 	default:
-		log.Println("COLLECTOR: Unrecognize record request", request.RecordType)
+		logger.Errorf("Unrecognized record request %v", request.RecordType)
 	}
 	if retrieveError != nil {
 		p.Status = retrieveError.Error()
+	} else if deadline.hit {
+		// the records gathered so far are returned, but no cursor as the
+		// records not yet visited could sort before the last one returned
+		p.Status = queryDeadlineStatus
+		p.Partial = true
+		p.NextCursor = ""
 	}
 	p.elapsed = uint64(time.Now().UnixNano())/uint64(time.Microsecond) - p.timestamp
 	apiQueryLatencyMetric, err := fc.metrics.apiQueryLatency.GetMetricWith(map[string]string{"recordType": recordNames[request.RecordType], "handler": request.HandlerName})
@@ -2135,7 +2402,7 @@ func (fc *FlowCollector) retrieve(request ApiRequest) (*string, error) {
 	}
 	data, err := json.MarshalIndent(p, "", " ")
 	if err != nil {
-		log.Println("COLLECTOR: Error marshalling results", err.Error())
+		logger.Errorf("Error marshalling results: %s", err)
 		return nil, err
 	}
 	sd := string(data)
@@ -2156,7 +2423,6 @@ func (fc *FlowCollector) getFlowProcess(id string) (ProcessRecord, bool) {
 func (fc *FlowCollector) setupFlowMetrics(va *VanAddressRecord, flow *FlowRecord, metricLabel prometheus.Labels) error {
 	var flowMetric prometheus.Counter
 	var octetMetric prometheus.Counter
-	var flowLatencyMetric prometheus.Observer
 	var lastAccessedMetric prometheus.Gauge
 	var activeFlowMetric prometheus.Gauge
 	var err error
@@ -2196,21 +2462,15 @@ func (fc *FlowCollector) setupFlowMetrics(va *VanAddressRecord, flow *FlowRecord
 	}
 	flow.octetMetric = octetMetric
 	if flow.Octets != nil {
-		octetMetric.Add(float64(*flow.Octets))
+		addWithExemplar(octetMetric, float64(*flow.Octets), flow.exemplar)
 		flow.lastOctets = *flow.Octets
 	}
 
-	if flowLatencyMetric, ok = va.flowLatency[key]; !ok {
-		flowLatencyMetric, err = fc.metrics.flowLatency.GetMetricWith(metricLabel)
-		if err != nil {
-			return err
-		} else {
-			va.flowLatency[key] = flowLatencyMetric
-		}
-	}
-	if flow.Latency != nil {
-		flowLatencyMetric.Observe(float64(*flow.Latency))
+	flow.latencyMetrics, err = fc.latencyObservers(va, key, metricLabel)
+	if err != nil {
+		return err
 	}
+	observeLatency(flow)
 
 	if lastAccessedMetric, ok = va.lastAccessed[key]; !ok {
 		lastAccessedMetric, err = fc.metrics.lastAccessed.GetMetricWith(metricLabel)
@@ -2326,9 +2586,11 @@ func (fc *FlowCollector) reconcileFlowRecords() error {
 	if err == nil {
 		m.Set(float64(len(fc.flowsToPairReconcile)))
 	}
+	unpaired := 0
 	for reverseId, ftpr := range fc.flowsToPairReconcile {
 		if age > ftpr.created {
 			delete(fc.flowsToPairReconcile, reverseId)
+			unpaired++
 		} else if reverseFlow, ok := fc.Flows[reverseId]; ok {
 			if forwardFlow, ok := fc.Flows[ftpr.forwardId]; ok {
 				forwardFlow.CounterFlow = &reverseFlow.Identity
@@ -2337,11 +2599,14 @@ func (fc *FlowCollector) reconcileFlowRecords() error {
 					flowPair.Protocol = forwardFlow.Protocol
 					fc.FlowPairs[flowPair.Identity] = flowPair
 					fc.aggregatesToReconcile[flowPair.Identity] = flowPair
+					fc.tagFlowPair(flowPair)
+					fc.addFanoutTarget(forwardFlow)
 					delete(fc.flowsToPairReconcile, reverseId)
 				}
 			}
 		}
 	}
+	fc.countIgnored(IgnoreTtlPurge, Flow, unpaired)
 	m, err = fc.metrics.activeReconcile.GetMetricWith(prometheus.Labels{"reconcileTask": "pairToAggregate"})
 	if err == nil {
 		m.Set(float64(len(fc.aggregatesToReconcile)))
@@ -2406,6 +2671,7 @@ func (fc *FlowCollector) reconcileFlowRecords() error {
 					fc.FlowAggregates[processAggregateId] = pfa
 				}
 				flowPair.ProcessAggregateId = &processAggregateId
+				fc.countHttpResponse(flowPair)
 				// next process group pairs
 				processGroupAggregateId := *ffp.GroupIdentity + "-to-" + *cfp.GroupIdentity
 				if _, ok := fc.FlowAggregates[processGroupAggregateId]; !ok {
@@ -2429,6 +2695,10 @@ func (fc *FlowCollector) reconcileFlowRecords() error {
 				}
 				flowPair.ProcessGroupAggregateId = &processGroupAggregateId
 				delete(fc.aggregatesToReconcile, flowPairId)
+				fc.emitFlowEvent(flowPair, FlowEventOpen)
+				if flowPair.EndTime != 0 {
+					fc.emitFlowEvent(flowPair, FlowEventClose)
+				}
 			}
 		}
 	}
@@ -2470,7 +2740,7 @@ func (fc *FlowCollector) reconcileConnectorRecords() error {
 							process.connector = &connector.Identity
 							process.ProcessBinding = &Bound
 							fc.updateNetworkStatus()
-							log.Printf("COLLECTOR: Connector %s/%s associated to process %s\n", connector.Identity, *connector.Address, *process.Name)
+							logger.Infof("Connector %s/%s associated to process %s", connector.Identity, *connector.Address, *process.Name)
 							delete(fc.connectorsToReconcile, connId)
 							break
 						}
@@ -2489,7 +2759,7 @@ func (fc *FlowCollector) reconcileConnectorRecords() error {
 					if diff > wait {
 						for _, process := range fc.Processes {
 							if process.Name != nil && *process.Name == processName {
-								log.Printf("COLLECTOR: Associating connector %s to external process %s\n", connector.Identity, processName)
+								logger.Infof("Associating connector %s to external process %s", connector.Identity, processName)
 								connector.ProcessId = &process.Identity
 								delete(fc.connectorsToReconcile, connId)
 								break
@@ -2508,25 +2778,39 @@ func (fc *FlowCollector) reconcileConnectorRecords() error {
 func (fc *FlowCollector) ageAndPurgeRecords() error {
 	age := uint64(time.Now().UnixNano())/uint64(time.Microsecond) - uint64(fc.recordTtl.Microseconds())
 
+	flows, flowPairs, unknownParent := 0, 0, 0
 	for flowId, flow := range fc.Flows {
 		router := fc.getRouterForFlow(flow)
-		if flow.EndTime != 0 && age > flow.EndTime || router == nil {
+		aged := flow.EndTime != 0 && age > flow.EndTime
+		if aged || router == nil {
+			if !aged {
+				unknownParent++
+			}
 			fc.deleteRecord(flow)
+			flows++
 			if flowPair, ok := fc.FlowPairs["fp-"+flowId]; ok {
 				fc.deleteRecord(flowPair)
+				flowPairs++
 			}
 		}
 	}
+	fc.countPurged(Flow, flows)
+	fc.countPurged(FlowPair, flowPairs)
+	fc.countIgnored(IgnoreUnknownParent, Flow, unknownParent)
 
 	t := time.Now()
+	fc.markStaleSources(uint64(t.UnixNano()) / uint64(time.Microsecond))
+	purgeAfter := uint64((fc.staleSourceAfter + fc.staleSourceGrace).Microseconds())
 	for _, source := range fc.eventSources {
 		diff := uint64(t.UnixNano())/uint64(time.Microsecond) - source.EventSourceRecord.LastHeard
-		if diff > 60*oneSecond {
-			log.Printf("COLLECTOR: Purging event source %s of type %s \n", source.Beacon.Identity, source.Beacon.SourceType)
+		if diff > purgeAfter {
+			logger.Infof("Purging event source %s of type %s", source.Beacon.Identity, source.Beacon.SourceType)
 			fc.purgeEventSource(source.EventSourceRecord)
 		}
 	}
 
+	fc.ageSiteLatencies(age)
+
 	// recentConnectors for flows after the fact
 	for _, connector := range fc.recentConnectors {
 		diff := uint64(t.UnixNano())/uint64(time.Microsecond) - connector.EndTime
@@ -2574,6 +2858,7 @@ func (fc *FlowCollector) purgeEventSource(eventSource EventSourceRecord) error {
 		for _, connector := range fc.Connectors {
 			if connector.Parent == eventSource.Identity {
 				connector.EndTime = now
+				connector.Purged = true
 				fc.updateRecord(*connector)
 			}
 		}
@@ -2599,6 +2884,11 @@ func (fc *FlowCollector) purgeEventSource(eventSource EventSourceRecord) error {
 					site.Purged = true
 					fc.updateRecord(*site)
 				}
+			} else if site, ok := fc.Sites[router.Parent]; ok && fc.siteOrphaned(site.Identity, router.Identity) {
+				// nothing is left to report the end of the site
+				site.EndTime = now
+				site.Purged = true
+				fc.updateRecord(*site)
 			}
 			router.EndTime = now
 			router.Purged = true
@@ -2637,8 +2927,14 @@ func (fc *FlowCollector) purgeEventSource(eventSource EventSourceRecord) error {
 	}
 	eventSource.Purged = true
 	eventSource.EndTime = now
-	log.Printf("COLLECTOR: %s \n", prettyPrint(eventSource))
+	logger.Infof("%s", prettyPrint(eventSource))
 	delete(fc.eventSources, eventSource.Identity)
+	fc.countPurged(EventSource, 1)
+	if fc.metrics != nil {
+		fc.metrics.beaconAge.Delete(beaconAgeLabels(eventSource))
+		fc.metrics.clockSkew.DeletePartialMatch(prometheus.Labels{"eventSource": eventSource.Identity})
+		fc.metrics.ingestionLag.Delete(beaconAgeLabels(eventSource))
+	}
 
 	return nil
 }
@@ -2679,7 +2975,7 @@ func (fc *FlowCollector) needForSiteProcess(flow *FlowRecord, siteId string, sta
 	diff := uint64(time.Now().UnixNano())/uint64(time.Microsecond) - diffTime
 	found := false
 	if diff > wait && flow.Process == nil {
-		log.Printf("COLLECTOR: Associating flow %s to external process %s\n", flow.Identity, processName)
+		logger.Infof("Associating flow %s to external process %s", flow.Identity, processName)
 		for _, process := range fc.Processes {
 			if process.Name != nil && *process.Name == processName {
 				flow.Process = &process.Identity
@@ -1,7 +1,9 @@
 package flow
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"testing"
 	"time"
@@ -949,7 +951,7 @@ func TestRecordGraphWithMetrics(t *testing.T) {
 		}
 		req = mux.SetURLVars(req, test.vars)
 		req.URL.RawQuery = q.Encode()
-		resp, err := fc.retrieve(ApiRequest{RecordType: test.recordType, HandlerName: test.name, Request: req})
+		resp, err := fc.retrieve(req.Context(), ApiRequest{RecordType: test.recordType, HandlerName: test.name, Request: req})
 		assert.Assert(t, err)
 		err = json.Unmarshal([]byte(*resp), &payload)
 		assert.Assert(t, err)
@@ -1271,3 +1273,40 @@ func xTestRecordGraphNetworkStatus(t *testing.T) {
 	err := fc.updateNetworkStatus()
 	assert.Assert(t, err)
 }
+
+func TestRetrievePartialOnDeadline(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	fc := NewFlowCollector(FlowCollectorSpec{
+		Mode:          RecordMetrics,
+		Origin:        "origin",
+		PromReg:       reg,
+		FlowRecordTtl: time.Minute * 5,
+	})
+	fc.metrics = fc.NewMetrics(fc.prometheusReg)
+	for i := 0; i < 4*queryDeadlineInterval; i++ {
+		id := fmt.Sprintf("flow-%04d", i)
+		fc.Flows[id] = &FlowRecord{Base: Base{RecType: recordNames[Flow], Identity: id, StartTime: 1}}
+	}
+	query := func(ctx context.Context) Payload {
+		req, _ := http.NewRequestWithContext(ctx, "GET", "/?limit=10", nil)
+		resp, err := fc.retrieve(ctx, ApiRequest{RecordType: Flow, HandlerName: "list", Request: req})
+		assert.Assert(t, err)
+		var payload Payload
+		assert.Assert(t, json.Unmarshal([]byte(*resp), &payload))
+		return payload
+	}
+
+	payload := query(context.Background())
+	assert.Equal(t, payload.Partial, false)
+	assert.Equal(t, payload.TimeRangeCount, 4*queryDeadlineInterval)
+	assert.Assert(t, payload.NextCursor != "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	payload = query(ctx)
+	assert.Equal(t, payload.Partial, true)
+	assert.Equal(t, payload.Status, queryDeadlineStatus)
+	assert.Equal(t, payload.TimeRangeCount, queryDeadlineInterval-1)
+	assert.Equal(t, payload.Count, 10)
+	assert.Equal(t, payload.NextCursor, "")
+}
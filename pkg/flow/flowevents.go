@@ -0,0 +1,118 @@
+package flow
+
+const (
+	FlowEventOpen  = "open"
+	FlowEventClose = "close"
+)
+
+// FlowEvent is the open or close of a connection between two processes of
+// the network, the bytes, duration and end reason are only set on close
+type FlowEvent struct {
+	Type               string   `json:"type"`
+	Time               uint64   `json:"time"`
+	Identity           string   `json:"identity"`
+	Protocol           string   `json:"protocol,omitempty"`
+	Address            string   `json:"address,omitempty"`
+	SourceSite         string   `json:"sourceSite,omitempty"`
+	SourceProcess      string   `json:"sourceProcess,omitempty"`
+	SourceHost         string   `json:"sourceHost,omitempty"`
+	SourcePort         string   `json:"sourcePort,omitempty"`
+	DestinationSite    string   `json:"destinationSite,omitempty"`
+	DestinationProcess string   `json:"destinationProcess,omitempty"`
+	DestinationHost    string   `json:"destinationHost,omitempty"`
+	DestinationPort    string   `json:"destinationPort,omitempty"`
+	Octets             *uint64  `json:"octets,omitempty"`
+	OctetsReverse      *uint64  `json:"octetsReverse,omitempty"`
+	Duration           *uint64  `json:"duration,omitempty"`
+	EndReason          string   `json:"endReason,omitempty"`
+	Method             string   `json:"method,omitempty"`
+	Result             string   `json:"result,omitempty"`
+	Tags               []string `json:"tags,omitempty"`
+	TraceParent        string   `json:"traceParent,omitempty"`
+	TraceState         string   `json:"traceState,omitempty"`
+}
+
+// FlowEventSink receives the flow events from the update loop, so it must
+// not block
+type FlowEventSink interface {
+	FlowEvent(event FlowEvent)
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// flowAddress returns the name of the address a flow was sent to, the
+// http flows being found through their transport flow
+func (fc *FlowCollector) flowAddress(flow *FlowRecord) string {
+	addressId := flow.addressId
+	if addressId == "" {
+		if l4Flow, ok := fc.Flows[flow.Parent]; ok {
+			addressId = l4Flow.addressId
+		}
+	}
+	if va, ok := fc.VanAddresses[addressId]; ok {
+		return va.Name
+	}
+	return ""
+}
+
+// emitFlowEvent sends the open or close event of a flow pair, the open
+// event is sent once the processes of both ends are known and the close
+// event only follows an open event
+func (fc *FlowCollector) emitFlowEvent(flowPair *FlowPairRecord, eventType string) {
+	if fc.flowEvents == nil || flowPair.ForwardFlow == nil || flowPair.CounterFlow == nil {
+		return
+	}
+	forward, counter := flowPair.ForwardFlow, flowPair.CounterFlow
+	// the http flows have their source in their transport flow
+	source := forward
+	if l4Flow, ok := fc.Flows[forward.Parent]; ok && forward.SourceHost == nil {
+		source = l4Flow
+	}
+	event := FlowEvent{
+		Type:               eventType,
+		Time:               flowPair.StartTime,
+		Identity:           flowPair.Identity,
+		Protocol:           derefString(flowPair.Protocol),
+		Address:            fc.flowAddress(forward),
+		SourceSite:         derefString(flowPair.SourceSiteName),
+		SourceProcess:      derefString(forward.ProcessName),
+		SourceHost:         derefString(source.SourceHost),
+		SourcePort:         derefString(source.SourcePort),
+		DestinationSite:    derefString(flowPair.DestinationSiteName),
+		DestinationProcess: derefString(counter.ProcessName),
+		Method:             derefString(forward.Method),
+		Tags:               flowPair.Tags,
+	}
+	for _, flow := range []*FlowRecord{forward, counter} {
+		if flow.TraceParent != nil {
+			event.TraceParent, event.TraceState = *flow.TraceParent, derefString(flow.TraceState)
+			break
+		}
+	}
+	connectorId := counter.Parent
+	if l4Flow, ok := fc.Flows[counter.Parent]; ok {
+		connectorId = l4Flow.Parent
+	}
+	if connector, ok := fc.Connectors[connectorId]; ok {
+		event.DestinationHost = derefString(connector.DestHost)
+		event.DestinationPort = derefString(connector.DestPort)
+	}
+	if eventType == FlowEventClose {
+		duration := flowPair.Duration
+		event.Time = flowPair.EndTime
+		event.Octets = forward.Octets
+		event.OctetsReverse = counter.Octets
+		event.Duration = &duration
+		event.EndReason = derefString(forward.Reason)
+		if event.EndReason == "" {
+			event.EndReason = derefString(counter.Reason)
+		}
+		event.Result = derefString(counter.Result)
+	}
+	fc.flowEvents.FlowEvent(event)
+}
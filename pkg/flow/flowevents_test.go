@@ -0,0 +1,78 @@
+package flow
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+type recordedFlowEvents []FlowEvent
+
+func (r *recordedFlowEvents) FlowEvent(event FlowEvent) {
+	*r = append(*r, event)
+}
+
+func TestEmitFlowEvent(t *testing.T) {
+	events := &recordedFlowEvents{}
+	fc := NewFlowCollector(FlowCollectorSpec{FlowEvents: events})
+	fc.VanAddresses["address:0"] = &VanAddressRecord{Base: Base{Identity: "address:0"}, Name: "backend"}
+	fc.Connectors["connector:0"] = &ConnectorRecord{Base: Base{Identity: "connector:0"}, DestHost: &[]string{"10.0.0.2"}[0], DestPort: &[]string{"8080"}[0]}
+
+	octets, octetsReverse := uint64(100), uint64(2000)
+	forward := &FlowRecord{
+		Base:        Base{Identity: "flow:0", StartTime: 1000},
+		SourceHost:  &[]string{"10.0.0.1"}[0],
+		SourcePort:  &[]string{"40000"}[0],
+		ProcessName: &[]string{"frontend"}[0],
+		Octets:      &octets,
+		Reason:      &[]string{"connection reset"}[0],
+		addressId:   "address:0",
+	}
+	counter := &FlowRecord{
+		Base:        Base{Identity: "flow:1", Parent: "connector:0", StartTime: 1100},
+		ProcessName: &[]string{"backend"}[0],
+		Octets:      &octetsReverse,
+	}
+	flowPair := &FlowPairRecord{
+		Base:                Base{Identity: "fp-flow:0", StartTime: 1000},
+		Protocol:            &[]string{"tcp"}[0],
+		SourceSiteName:      &[]string{"west"}[0],
+		DestinationSiteName: &[]string{"east"}[0],
+		ForwardFlow:         forward,
+		CounterFlow:         counter,
+	}
+
+	fc.emitFlowEvent(flowPair, FlowEventOpen)
+	flowPair.EndTime = 6000
+	flowPair.Duration = 5000
+	fc.emitFlowEvent(flowPair, FlowEventClose)
+
+	open := FlowEvent{
+		Type:               FlowEventOpen,
+		Time:               1000,
+		Identity:           "fp-flow:0",
+		Protocol:           "tcp",
+		Address:            "backend",
+		SourceSite:         "west",
+		SourceProcess:      "frontend",
+		SourceHost:         "10.0.0.1",
+		SourcePort:         "40000",
+		DestinationSite:    "east",
+		DestinationProcess: "backend",
+		DestinationHost:    "10.0.0.2",
+		DestinationPort:    "8080",
+	}
+	duration := uint64(5000)
+	closed := open
+	closed.Type = FlowEventClose
+	closed.Time = 6000
+	closed.Octets = &octets
+	closed.OctetsReverse = &octetsReverse
+	closed.Duration = &duration
+	closed.EndReason = "connection reset"
+	assert.DeepEqual(t, []FlowEvent(*events), []FlowEvent{open, closed})
+
+	// pairs missing a flow are not reported
+	fc.emitFlowEvent(&FlowPairRecord{ForwardFlow: forward}, FlowEventOpen)
+	assert.Equal(t, len(*events), 2)
+}
@@ -0,0 +1,215 @@
+package flow
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	forecastInterval     = time.Hour
+	forecastRetention    = 7 * 24
+	forecastSeason       = 24
+	defaultForecastHours = 24
+	maxForecastHours     = 7 * 24
+
+	ForecastNone        = "none"
+	ForecastConstant    = "constant"
+	ForecastLinearTrend = "linear-trend"
+	ForecastHoltWinters = "holt-winters"
+)
+
+// smoothing factors for level, trend and season
+const (
+	forecastAlpha = 0.5
+	forecastBeta  = 0.2
+	forecastGamma = 0.3
+)
+
+type ForecastPoint struct {
+	Time   uint64  `json:"time"`
+	Octets float64 `json:"octets"`
+	Flows  float64 `json:"flows"`
+}
+
+type AddressForecast struct {
+	Identity string          `json:"identity"`
+	Address  string          `json:"address"`
+	Method   string          `json:"method"`
+	Interval uint64          `json:"interval"`
+	History  []ForecastPoint `json:"history"`
+	Forecast []ForecastPoint `json:"forecast"`
+}
+
+type historyBucket struct {
+	start  int64
+	octets uint64
+	flows  uint64
+}
+
+// addressHistory keeps the hourly octet and flow totals of an address for
+// the retention period, oldest first
+type addressHistory struct {
+	buckets []historyBucket
+}
+
+func (h *addressHistory) add(now time.Time, octets uint64, flows uint64) {
+	start := now.Truncate(forecastInterval).Unix()
+	last := len(h.buckets) - 1
+	// an update for an earlier hour is counted in the latest one
+	if last < 0 || h.buckets[last].start < start {
+		h.buckets = append(h.buckets, historyBucket{start: start})
+		last++
+	}
+	h.buckets[last].octets += octets
+	h.buckets[last].flows += flows
+
+	oldest := start - int64(forecastRetention*forecastInterval/time.Second)
+	expired := 0
+	for expired < last && h.buckets[expired].start <= oldest {
+		expired++
+	}
+	h.buckets = h.buckets[expired:]
+}
+
+// series returns the totals of the complete hours before now, starting
+// with the first hour that saw traffic; hours without traffic are zero
+func (h *addressHistory) series(now time.Time) (int64, []float64, []float64) {
+	current := now.Truncate(forecastInterval).Unix()
+	step := int64(forecastInterval / time.Second)
+	if len(h.buckets) == 0 || h.buckets[0].start >= current {
+		return current, nil, nil
+	}
+	first := h.buckets[0].start
+	n := int((current - first) / step)
+	octets := make([]float64, n)
+	flows := make([]float64, n)
+	for _, bucket := range h.buckets {
+		if i := int((bucket.start - first) / step); i < n {
+			octets[i] = float64(bucket.octets)
+			flows[i] = float64(bucket.flows)
+		}
+	}
+	return first, octets, flows
+}
+
+func (h *addressHistory) forecast(now time.Time, hours int) AddressForecast {
+	step := int64(forecastInterval / time.Second)
+	first, octets, flows := h.series(now)
+	method, projectedOctets := forecastSeries(octets, hours)
+	_, projectedFlows := forecastSeries(flows, hours)
+	result := AddressForecast{
+		Method:   method,
+		Interval: uint64(step),
+		History:  []ForecastPoint{},
+		Forecast: []ForecastPoint{},
+	}
+	for i := range octets {
+		result.History = append(result.History, ForecastPoint{
+			Time:   uint64(first+int64(i)*step) * 1000000,
+			Octets: octets[i],
+			Flows:  flows[i],
+		})
+	}
+	next := first + int64(len(octets))*step
+	for i := range projectedOctets {
+		result.Forecast = append(result.Forecast, ForecastPoint{
+			Time:   uint64(next+int64(i)*step) * 1000000,
+			Octets: projectedOctets[i],
+			Flows:  projectedFlows[i],
+		})
+	}
+	return result
+}
+
+// forecastSeries projects the series for the horizon, using seasonal
+// smoothing once two full days are known and a linear trend before that
+func forecastSeries(series []float64, horizon int) (string, []float64) {
+	var method string
+	var projected []float64
+	switch {
+	case len(series) == 0:
+		return ForecastNone, []float64{}
+	case len(series) == 1:
+		method = ForecastConstant
+		projected = make([]float64, horizon)
+		for i := range projected {
+			projected[i] = series[0]
+		}
+	case len(series) < 2*forecastSeason:
+		method = ForecastLinearTrend
+		projected = holtLinear(series, horizon)
+	default:
+		method = ForecastHoltWinters
+		projected = holtWinters(series, forecastSeason, horizon)
+	}
+	for i := range projected {
+		if projected[i] < 0 {
+			projected[i] = 0
+		}
+	}
+	return method, projected
+}
+
+func holtLinear(series []float64, horizon int) []float64 {
+	level := series[0]
+	trend := series[1] - series[0]
+	for _, x := range series[1:] {
+		previous := level
+		level = forecastAlpha*x + (1-forecastAlpha)*(level+trend)
+		trend = forecastBeta*(level-previous) + (1-forecastBeta)*trend
+	}
+	projected := make([]float64, horizon)
+	for i := range projected {
+		projected[i] = level + float64(i+1)*trend
+	}
+	return projected
+}
+
+func holtWinters(series []float64, season int, horizon int) []float64 {
+	level := mean(series[:season])
+	trend := (mean(series[season:2*season]) - level) / float64(season)
+	seasonal := make([]float64, season)
+	for i := range seasonal {
+		seasonal[i] = series[i] - level
+	}
+	for t := season; t < len(series); t++ {
+		x, s := series[t], seasonal[t%season]
+		previous := level
+		level = forecastAlpha*(x-s) + (1-forecastAlpha)*(level+trend)
+		trend = forecastBeta*(level-previous) + (1-forecastBeta)*trend
+		seasonal[t%season] = forecastGamma*(x-level) + (1-forecastGamma)*s
+	}
+	projected := make([]float64, horizon)
+	for i := range projected {
+		projected[i] = level + float64(i+1)*trend + seasonal[(len(series)+i)%season]
+	}
+	return projected
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func forecastHours(value string) int {
+	hours, err := strconv.Atoi(value)
+	if err != nil || hours <= 0 {
+		return defaultForecastHours
+	}
+	if hours > maxForecastHours {
+		return maxForecastHours
+	}
+	return hours
+}
+
+func (fc *FlowCollector) addAddressHistory(addressId string, octets uint64, flows uint64) {
+	history, ok := fc.addressHistory[addressId]
+	if !ok {
+		history = &addressHistory{}
+		fc.addressHistory[addressId] = history
+	}
+	history.add(time.Now(), octets, flows)
+}
@@ -0,0 +1,102 @@
+package flow
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestForecastSeries(t *testing.T) {
+	daily := make([]float64, 3*forecastSeason)
+	for i := range daily {
+		daily[i] = 100 + 50*math.Sin(2*math.Pi*float64(i%forecastSeason)/forecastSeason)
+	}
+	testTable := []struct {
+		name     string
+		series   []float64
+		horizon  int
+		method   string
+		expected []float64
+	}{
+		{
+			name:     "empty",
+			horizon:  3,
+			method:   ForecastNone,
+			expected: []float64{},
+		},
+		{
+			name:     "single",
+			series:   []float64{7},
+			horizon:  2,
+			method:   ForecastConstant,
+			expected: []float64{7, 7},
+		},
+		{
+			name:     "linear",
+			series:   []float64{10, 20, 30, 40},
+			horizon:  3,
+			method:   ForecastLinearTrend,
+			expected: []float64{50, 60, 70},
+		},
+		{
+			name:     "falling-to-zero",
+			series:   []float64{30, 20, 10},
+			horizon:  3,
+			method:   ForecastLinearTrend,
+			expected: []float64{0, 0, 0},
+		},
+		{
+			name:     "seasonal",
+			series:   daily,
+			horizon:  forecastSeason,
+			method:   ForecastHoltWinters,
+			expected: daily[:forecastSeason],
+		},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			method, projected := forecastSeries(test.series, test.horizon)
+			assert.Equal(t, method, test.method)
+			assert.Equal(t, len(projected), len(test.expected))
+			for i := range projected {
+				assert.Assert(t, math.Abs(projected[i]-test.expected[i]) < 0.001, "%d: %f != %f", i, projected[i], test.expected[i])
+			}
+		})
+	}
+}
+
+func TestAddressHistory(t *testing.T) {
+	start := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	history := &addressHistory{}
+	history.add(start.Add(5*time.Minute), 100, 1)
+	history.add(start.Add(50*time.Minute), 50, 0)
+	history.add(start.Add(2*time.Hour+time.Minute), 300, 3)
+
+	// the current hour is not complete and not part of the series
+	first, octets, flows := history.series(start.Add(2*time.Hour + 30*time.Minute))
+	assert.Equal(t, first, start.Unix())
+	assert.DeepEqual(t, octets, []float64{150, 0})
+	assert.DeepEqual(t, flows, []float64{1, 0})
+
+	forecast := history.forecast(start.Add(3*time.Hour), 2)
+	assert.Equal(t, forecast.Method, ForecastLinearTrend)
+	assert.Equal(t, forecast.Interval, uint64(3600))
+	assert.Equal(t, len(forecast.History), 3)
+	assert.Equal(t, forecast.History[2].Octets, float64(300))
+	assert.Equal(t, len(forecast.Forecast), 2)
+	assert.Equal(t, forecast.Forecast[0].Time, uint64(start.Add(3*time.Hour).UnixNano()/int64(time.Microsecond)))
+
+	// buckets older than the retention period are dropped
+	history.add(start.Add(forecastRetention*time.Hour+time.Minute), 1, 1)
+	assert.Equal(t, history.buckets[0].start, start.Add(2*time.Hour).Unix())
+}
+
+func TestForecastHours(t *testing.T) {
+	assert.Equal(t, forecastHours(""), defaultForecastHours)
+	assert.Equal(t, forecastHours("abc"), defaultForecastHours)
+	assert.Equal(t, forecastHours("-3"), defaultForecastHours)
+	assert.Equal(t, forecastHours("6"), 6)
+	assert.Equal(t, forecastHours("1000"), maxForecastHours)
+}
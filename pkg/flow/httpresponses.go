@@ -0,0 +1,78 @@
+package flow
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// httpResponseClass returns the class of an http status code, as in 2xx
+func httpResponseClass(code string) string {
+	if len(code) == 3 && code[0] >= '1' && code[0] <= '5' {
+		return code[:1] + "xx"
+	}
+	return "other"
+}
+
+func isHttpProtocol(protocol *string) bool {
+	return protocol != nil && (*protocol == "http" || *protocol == "http2")
+}
+
+// flowPairOf returns the flow pair of a client or server flow
+func (fc *FlowCollector) flowPairOf(flow *FlowRecord) (*FlowPairRecord, bool) {
+	if flowPair, ok := fc.FlowPairs["fp-"+flow.Identity]; ok {
+		return flowPair, true
+	}
+	if flow.CounterFlow != nil {
+		flowPair, ok := fc.FlowPairs["fp-"+*flow.CounterFlow]
+		return flowPair, ok
+	}
+	return nil, false
+}
+
+// countHttpResponse counts the response of an http flow pair by address
+// and process pair once both its status code and its processes are known,
+// whichever comes last
+func (fc *FlowCollector) countHttpResponse(flowPair *FlowPairRecord) {
+	if flowPair.httpResponseCounted || flowPair.ProcessAggregateId == nil || !isHttpProtocol(flowPair.Protocol) {
+		return
+	}
+	var result *string
+	for _, flow := range []*FlowRecord{flowPair.CounterFlow, flowPair.ForwardFlow} {
+		if flow != nil && flow.Result != nil {
+			result = flow.Result
+			break
+		}
+	}
+	if result == nil {
+		return
+	}
+	flowPair.httpResponseCounted = true
+	class := httpResponseClass(*result)
+	address := ""
+	if flowPair.ForwardFlow != nil {
+		address = fc.flowAddress(flowPair.ForwardFlow)
+	}
+	for _, va := range fc.VanAddresses {
+		if va.Name == address {
+			if va.HttpResponses == nil {
+				va.HttpResponses = map[string]uint64{}
+			}
+			va.HttpResponses[class]++
+			break
+		}
+	}
+	labels := prometheus.Labels{"address": address, "sourceProcess": "", "destProcess": "", "class": class}
+	if aggregate, ok := fc.FlowAggregates[*flowPair.ProcessAggregateId]; ok {
+		if aggregate.HttpResponses == nil {
+			aggregate.HttpResponses = map[string]uint64{}
+		}
+		aggregate.HttpResponses[class]++
+		labels["sourceProcess"] = derefString(aggregate.SourceName)
+		labels["destProcess"] = derefString(aggregate.DestinationName)
+	}
+	if fc.metrics == nil {
+		return
+	}
+	if m, err := fc.metrics.httpResponses.GetMetricWith(labels); err == nil {
+		m.Inc()
+	}
+}
@@ -0,0 +1,84 @@
+package flow
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestHttpResponseClass(t *testing.T) {
+	for code, class := range map[string]string{
+		"200": "2xx",
+		"204": "2xx",
+		"302": "3xx",
+		"404": "4xx",
+		"503": "5xx",
+		"":    "other",
+		"600": "other",
+		"2":   "other",
+	} {
+		assert.Equal(t, httpResponseClass(code), class, code)
+	}
+}
+
+func TestCountHttpResponse(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{
+		Mode:    RecordMetrics,
+		PromReg: prometheus.NewRegistry(),
+	})
+	fc.metrics = fc.NewMetrics(fc.prometheusReg)
+	http, tcp := "http", "tcp"
+	frontend, backend := "frontend", "backend"
+	fc.VanAddresses["va-1"] = &VanAddressRecord{Base: Base{Identity: "va-1"}, Name: "backend:8080"}
+	fc.FlowAggregates["p1-to-p2"] = &FlowAggregateRecord{Base: Base{Identity: "p1-to-p2"}, SourceName: &frontend, DestinationName: &backend}
+
+	pair := func(id string, protocol *string, code string) *FlowPairRecord {
+		client := &FlowRecord{Base: Base{Identity: id}, addressId: "va-1"}
+		server := &FlowRecord{Base: Base{Identity: id + "-server"}, CounterFlow: &client.Identity}
+		if code != "" {
+			server.Result = &code
+		}
+		fc.Flows[client.Identity], fc.Flows[server.Identity] = client, server
+		flowPair := &FlowPairRecord{Base: Base{Identity: "fp-" + id}, Protocol: protocol, ForwardFlow: client, CounterFlow: server}
+		fc.FlowPairs[flowPair.Identity] = flowPair
+		return flowPair
+	}
+	aggregateId := "p1-to-p2"
+
+	// the status code known before the processes
+	succeeded := pair("flow-1", &http, "200")
+	fc.countHttpResponse(succeeded)
+	assert.Equal(t, len(fc.FlowAggregates[aggregateId].HttpResponses), 0)
+	succeeded.ProcessAggregateId = &aggregateId
+	fc.countHttpResponse(succeeded)
+	fc.countHttpResponse(succeeded)
+
+	// the processes known before the status code
+	failed := pair("flow-2", &http, "")
+	failed.ProcessAggregateId = &aggregateId
+	fc.countHttpResponse(failed)
+	code := "503"
+	flowPair, found := fc.flowPairOf(fc.Flows["flow-2-server"])
+	assert.Assert(t, found)
+	flowPair.CounterFlow.Result = &code
+	fc.countHttpResponse(flowPair)
+
+	// not an http address
+	other := pair("flow-3", &tcp, "200")
+	other.ProcessAggregateId = &aggregateId
+	fc.countHttpResponse(other)
+
+	assert.DeepEqual(t, fc.FlowAggregates[aggregateId].HttpResponses, map[string]uint64{"2xx": 1, "5xx": 1})
+	assert.DeepEqual(t, fc.VanAddresses["va-1"].HttpResponses, map[string]uint64{"2xx": 1, "5xx": 1})
+	counter, err := fc.metrics.httpResponses.GetMetricWith(prometheus.Labels{
+		"address":       "backend:8080",
+		"sourceProcess": "frontend",
+		"destProcess":   "backend",
+		"class":         "5xx",
+	})
+	assert.Assert(t, err)
+	assert.Equal(t, testutil.ToFloat64(counter), 1.0)
+	assert.Equal(t, testutil.CollectAndCount(fc.metrics.httpResponses), 2)
+}
@@ -0,0 +1,207 @@
+package flow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	maxIngestBatchSize = 1000
+	MaxIngestBodySize  = 8 << 20
+	ingestSourcePrefix = "ingest:"
+)
+
+// IngestBatch is a set of host and process records submitted by an
+// external agent, e.g. a VM inventory script or a CMDB sync job
+type IngestBatch struct {
+	Source    string          `json:"source"`
+	Hosts     []HostRecord    `json:"hosts,omitempty"`
+	Processes []ProcessRecord `json:"processes,omitempty"`
+}
+
+type IngestError struct {
+	RecType  string `json:"recType"`
+	Identity string `json:"identity,omitempty"`
+	Index    int    `json:"index"`
+	Error    string `json:"error"`
+}
+
+type IngestResult struct {
+	Accepted int           `json:"accepted"`
+	Rejected []IngestError `json:"rejected"`
+}
+
+func isIngested(base Base) bool {
+	return strings.HasPrefix(base.Source, ingestSourcePrefix)
+}
+
+func (fc *FlowCollector) validateIngestBase(base *Base, recType int) error {
+	if base.RecType == "" {
+		base.RecType = recordNames[recType]
+	} else if base.RecType != recordNames[recType] {
+		return fmt.Errorf("record type %s does not match %s", base.RecType, recordNames[recType])
+	}
+	if base.Identity == "" {
+		return fmt.Errorf("identity is required")
+	}
+	if base.Parent == "" {
+		return fmt.Errorf("parent site is required")
+	}
+	if _, ok := fc.Sites[base.Parent]; !ok {
+		return fmt.Errorf("parent site %s not found", base.Parent)
+	}
+	if base.EndTime != 0 && base.EndTime < base.StartTime {
+		return fmt.Errorf("endTime must not be before startTime")
+	}
+	return nil
+}
+
+// mergeMissing copies the pointer and slice attributes of src that are not
+// set in dst, leaving everything reported by the routers untouched
+func mergeMissing(dst interface{}, src interface{}) {
+	d := reflect.ValueOf(dst).Elem()
+	s := reflect.ValueOf(src).Elem()
+	for i := 0; i < d.NumField(); i++ {
+		field := d.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+		switch field.Kind() {
+		case reflect.Pointer, reflect.Slice:
+			if field.IsNil() && !s.Field(i).IsNil() {
+				field.Set(s.Field(i))
+			}
+		}
+	}
+}
+
+func (fc *FlowCollector) ingestHost(host HostRecord, source string) error {
+	if err := fc.validateIngestBase(&host.Base, Host); err != nil {
+		return err
+	}
+	host.Source = source
+	if current, ok := fc.Hosts[host.Identity]; ok && !isIngested(current.Base) {
+		mergeMissing(current, &host)
+		return nil
+	}
+	return fc.updateRecord(host)
+}
+
+func (fc *FlowCollector) ingestProcess(process ProcessRecord, source string) error {
+	if err := fc.validateIngestBase(&process.Base, Process); err != nil {
+		return err
+	}
+	if process.Name == nil {
+		return fmt.Errorf("name is required")
+	}
+	if process.GroupName == nil {
+		process.GroupName = process.Name
+	}
+	process.Source = source
+	if current, ok := fc.Processes[process.Identity]; ok {
+		if !isIngested(current.Base) {
+			mergeMissing(current, &process)
+			return nil
+		}
+		if process.EndTime == 0 {
+			// keep the bindings computed when the process was first added
+			process.ParentName = current.ParentName
+			process.GroupIdentity = current.GroupIdentity
+			process.ProcessBinding = current.ProcessBinding
+			process.connector = current.connector
+			*current = process
+			fc.siteIndex.add(current)
+			return nil
+		}
+	}
+	return fc.updateRecord(process)
+}
+
+func (fc *FlowCollector) ingestBatch(batch IngestBatch) (IngestResult, error) {
+	result := IngestResult{Rejected: []IngestError{}}
+	if batch.Source == "" {
+		return result, fmt.Errorf("source is required")
+	}
+	if len(batch.Hosts)+len(batch.Processes) > maxIngestBatchSize {
+		return result, fmt.Errorf("batch exceeds the maximum of %d records", maxIngestBatchSize)
+	}
+	source := ingestSourcePrefix + batch.Source
+	now := uint64(time.Now().UnixNano()) / uint64(time.Microsecond)
+	for i, host := range batch.Hosts {
+		if host.StartTime == 0 {
+			host.StartTime = now
+		}
+		if err := fc.ingestHost(host, source); err != nil {
+			result.Rejected = append(result.Rejected, IngestError{RecType: recordNames[Host], Identity: host.Identity, Index: i, Error: err.Error()})
+		} else {
+			result.Accepted++
+		}
+	}
+	// hosts first so processes in the same batch can refer to them
+	for i, process := range batch.Processes {
+		if process.StartTime == 0 {
+			process.StartTime = now
+		}
+		if err := fc.ingestProcess(process, source); err != nil {
+			result.Rejected = append(result.Rejected, IngestError{RecType: recordNames[Process], Identity: process.Identity, Index: i, Error: err.Error()})
+		} else {
+			result.Accepted++
+		}
+	}
+	return result, nil
+}
+
+func decodeIngestBatch(r io.Reader) (IngestBatch, error) {
+	batch := IngestBatch{}
+	data, err := io.ReadAll(io.LimitReader(r, MaxIngestBodySize+1))
+	if err != nil {
+		return batch, err
+	}
+	if len(data) > MaxIngestBodySize {
+		return batch, fmt.Errorf("request body exceeds %d bytes", MaxIngestBodySize)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&batch); err != nil {
+		return batch, fmt.Errorf("invalid ingest batch: %s", err)
+	}
+	return batch, nil
+}
+
+func (fc *FlowCollector) ingest(request ApiRequest) ApiResponse {
+	response := ApiResponse{
+		Body:   nil,
+		Status: http.StatusOK,
+	}
+	writeError := func(status int, err error) ApiResponse {
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		s := string(body)
+		response.Body = &s
+		response.Status = status
+		return response
+	}
+	batch, err := decodeIngestBatch(request.Request.Body)
+	if err != nil {
+		return writeError(http.StatusBadRequest, err)
+	}
+	result, err := fc.ingestBatch(batch)
+	if err != nil {
+		return writeError(http.StatusBadRequest, err)
+	}
+	if fc.mode == RecordStatus {
+		fc.updateNetworkStatus()
+	}
+	body, err := json.Marshal(result)
+	if err != nil {
+		return writeError(http.StatusInternalServerError, err)
+	}
+	s := string(body)
+	response.Body = &s
+	return response
+}
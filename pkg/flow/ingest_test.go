@@ -0,0 +1,88 @@
+package flow
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gotest.tools/assert"
+)
+
+func TestIngestBatch(t *testing.T) {
+	siteName := "site-a"
+	routerHostName := "node-1"
+	arch := "amd64"
+	vmName := "vm-inventory-1"
+	processName := "legacy-db"
+
+	fc := NewFlowCollector(FlowCollectorSpec{
+		Mode:          RecordMetrics,
+		Origin:        "origin",
+		PromReg:       prometheus.NewRegistry(),
+		FlowRecordTtl: time.Minute,
+	})
+	fc.metrics = fc.NewMetrics(fc.prometheusReg)
+	now := uint64(time.Now().UnixNano()) / uint64(time.Microsecond)
+	assert.Assert(t, fc.updateRecord(SiteRecord{
+		Base: Base{RecType: recordNames[Site], Identity: "site:0", StartTime: now},
+		Name: &siteName,
+	}))
+	assert.Assert(t, fc.updateRecord(HostRecord{
+		Base: Base{RecType: recordNames[Host], Identity: "host:0", Parent: "site:0", StartTime: now},
+		Name: &routerHostName,
+	}))
+
+	batch, err := decodeIngestBatch(strings.NewReader(`{"source":"cmdb","unknown":true}`))
+	assert.ErrorContains(t, err, "invalid ingest batch")
+
+	batch = IngestBatch{
+		Source: "cmdb",
+		Hosts: []HostRecord{
+			{Base: Base{Identity: "host:0", Parent: "site:0"}, Name: &vmName, Arch: &arch},
+			{Base: Base{Identity: "vm:1", Parent: "site:0"}, Name: &vmName},
+			{Base: Base{Identity: "vm:2", Parent: "site:missing"}, Name: &vmName},
+			{Base: Base{RecType: recordNames[Process], Identity: "vm:3", Parent: "site:0"}},
+		},
+		Processes: []ProcessRecord{
+			{Base: Base{Identity: "process:ext", Parent: "site:0"}, Name: &processName},
+			{Base: Base{Identity: "process:noname", Parent: "site:0"}},
+		},
+	}
+	result, err := fc.ingestBatch(batch)
+	assert.Assert(t, err)
+	assert.Equal(t, result.Accepted, 3)
+	assert.Equal(t, len(result.Rejected), 3)
+	assert.Equal(t, result.Rejected[0].Identity, "vm:2")
+	assert.Equal(t, result.Rejected[1].Identity, "vm:3")
+	assert.Equal(t, result.Rejected[2].Identity, "process:noname")
+
+	// router-derived attributes win, missing ones are filled in
+	assert.Equal(t, *fc.Hosts["host:0"].Name, routerHostName)
+	assert.Equal(t, *fc.Hosts["host:0"].Arch, arch)
+	assert.Equal(t, fc.Hosts["host:0"].Source, "")
+	assert.Equal(t, fc.Hosts["vm:1"].Source, "ingest:cmdb")
+
+	process, ok := fc.Processes["process:ext"]
+	assert.Assert(t, ok)
+	assert.Equal(t, *process.ParentName, siteName)
+	assert.Equal(t, *process.GroupName, processName)
+	assert.Assert(t, process.GroupIdentity != nil)
+
+	batch = IngestBatch{
+		Source: "cmdb",
+		Processes: []ProcessRecord{
+			{Base: Base{Identity: "process:ext", Parent: "site:0", StartTime: now, EndTime: now + 1}, Name: &processName},
+		},
+	}
+	result, err = fc.ingestBatch(batch)
+	assert.Assert(t, err)
+	assert.Equal(t, result.Accepted, 1)
+	_, ok = fc.Processes["process:ext"]
+	assert.Assert(t, !ok)
+
+	_, err = fc.ingestBatch(IngestBatch{})
+	assert.ErrorContains(t, err, "source is required")
+	_, err = fc.ingestBatch(IngestBatch{Source: "cmdb", Hosts: make([]HostRecord, maxIngestBatchSize+1)})
+	assert.ErrorContains(t, err, "maximum")
+}
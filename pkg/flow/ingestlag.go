@@ -0,0 +1,50 @@
+package flow
+
+import (
+	"time"
+)
+
+// receivedBatch follows the records decoded from a message of an event
+// source, telling the update loop when they were received
+type receivedBatch struct {
+	source   string
+	received time.Time
+}
+
+func (fc *FlowCollector) setIngestionLag(source *eventSource, lag time.Duration) {
+	source.IngestionLag = uint64(lag.Microseconds())
+	if fc.metrics != nil {
+		fc.metrics.ingestionLag.With(beaconAgeLabels(source.EventSourceRecord)).Set(lag.Seconds())
+	}
+}
+
+// observeIngestionLag measures the time the records of an event source
+// waited to be applied since they were received
+func (fc *FlowCollector) observeIngestionLag(batch receivedBatch, now time.Time) {
+	source, ok := fc.eventSources[batch.source]
+	if !ok {
+		return
+	}
+	lag := now.Sub(batch.received)
+	if lag < 0 {
+		lag = 0
+	}
+	fc.setIngestionLag(source, lag)
+}
+
+// updateIngestionLag accounts for the records still waiting to be read,
+// the lag of an event source otherwise only being known once its records
+// are applied, which never happens while the update loop is stuck
+func (fc *FlowCollector) updateIngestionLag(now time.Time) {
+	for _, source := range fc.eventSources {
+		for _, r := range source.receivers {
+			waiting := r.waiting.Load()
+			if waiting == 0 {
+				continue
+			}
+			if lag := now.Sub(time.Unix(0, waiting)); lag > time.Duration(source.IngestionLag)*time.Microsecond {
+				fc.setIngestionLag(source, lag)
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package flow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestIngestionLag(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{
+		Mode:    RecordMetrics,
+		PromReg: prometheus.NewRegistry(),
+	})
+	fc.metrics = fc.NewMetrics(fc.prometheusReg)
+	r := newReceiver(nil, "mc/sfe.router:a.flows", nil)
+	source := &eventSource{
+		EventSourceRecord: EventSourceRecord{
+			Base:   Base{Identity: "router:a"},
+			Beacon: &BeaconRecord{SourceType: recordNames[Router]},
+		},
+		receivers: []*receiver{r},
+	}
+	fc.eventSources["router:a"] = source
+	gauge, err := fc.metrics.ingestionLag.GetMetricWith(prometheus.Labels{"eventSource": "router:a", "sourceType": recordNames[Router]})
+	assert.Assert(t, err)
+	now := time.Unix(1700000000, 0)
+
+	// the batches of the unknown sources are left out
+	fc.observeIngestionLag(receivedBatch{source: "router:b", received: now.Add(-time.Second)}, now)
+	assert.Equal(t, source.IngestionLag, uint64(0))
+
+	fc.observeIngestionLag(receivedBatch{source: "router:a", received: now.Add(-250 * time.Millisecond)}, now)
+	assert.Equal(t, source.IngestionLag, uint64(250000))
+	assert.Equal(t, testutil.ToFloat64(gauge), 0.25)
+
+	// records waiting to be read raise the lag
+	r.waiting.Store(now.Add(-3 * time.Second).UnixNano())
+	fc.updateIngestionLag(now)
+	assert.Equal(t, source.IngestionLag, uint64(3000000))
+	assert.Equal(t, testutil.ToFloat64(gauge), 3.0)
+
+	// the lag falls back once the records are applied
+	r.waiting.Store(0)
+	fc.updateIngestionLag(now)
+	assert.Equal(t, source.IngestionLag, uint64(3000000))
+	fc.observeIngestionLag(receivedBatch{source: "router:a", received: now}, now)
+	assert.Equal(t, source.IngestionLag, uint64(0))
+	assert.Equal(t, testutil.ToFloat64(gauge), 0.0)
+}
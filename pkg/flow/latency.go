@@ -0,0 +1,91 @@
+package flow
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in microseconds, of the flow
+// latency histograms: 1ms, 2ms, 5ms, 10ms, 100ms, 1s and 10s
+var DefaultLatencyBuckets = []float64{1000, 2000, 5000, 10000, 100000, 1000000, 10000000}
+
+// ParseLatencyBuckets parses a comma separated list of durations, as in
+// 1ms,5ms,25ms,100ms,1s, into the upper bounds in microseconds of the flow
+// latency histograms
+func ParseLatencyBuckets(value string) ([]float64, error) {
+	buckets := []float64{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		bound, err := time.ParseDuration(entry)
+		if err != nil || bound < time.Microsecond {
+			return nil, fmt.Errorf("invalid latency bucket %q, expected a duration of at least 1us", entry)
+		}
+		buckets = append(buckets, float64(bound/time.Microsecond))
+	}
+	if len(buckets) == 0 {
+		return nil, fmt.Errorf("no latency buckets in %q", value)
+	}
+	sort.Float64s(buckets)
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] == buckets[i-1] {
+			return nil, fmt.Errorf("duplicate latency bucket %s", time.Duration(buckets[i])*time.Microsecond)
+		}
+	}
+	return buckets, nil
+}
+
+func getLatencyBuckets(buckets []float64) []float64 {
+	if len(buckets) == 0 {
+		return DefaultLatencyBuckets
+	}
+	return buckets
+}
+
+// latencyObservers returns the histograms the latency of a flow is
+// observed in: by flow labels, by address and by pair of sites
+func (fc *FlowCollector) latencyObservers(va *VanAddressRecord, key metricKey, metricLabel prometheus.Labels) ([]prometheus.Observer, error) {
+	flowLatencyMetric, ok := va.flowLatency[key]
+	if !ok {
+		var err error
+		flowLatencyMetric, err = fc.metrics.flowLatency.GetMetricWith(metricLabel)
+		if err != nil {
+			return nil, err
+		}
+		va.flowLatency[key] = flowLatencyMetric
+	}
+	addressLatencyMetric, err := fc.metrics.addressLatency.GetMetricWith(prometheus.Labels{
+		"address":   metricLabel["address"],
+		"protocol":  metricLabel["protocol"],
+		"direction": metricLabel["direction"],
+	})
+	if err != nil {
+		return nil, err
+	}
+	sitePairLatencyMetric, err := fc.metrics.sitePairLatency.GetMetricWith(prometheus.Labels{
+		"sourceSite": metricLabel["sourceSite"],
+		"destSite":   metricLabel["destSite"],
+		"direction":  metricLabel["direction"],
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []prometheus.Observer{flowLatencyMetric, addressLatencyMetric, sitePairLatencyMetric}, nil
+}
+
+// observeLatency records the latency of a flow once it is known, either
+// when the flow is paired or when a later update carries it
+func observeLatency(flow *FlowRecord) {
+	if flow.Latency == nil {
+		return
+	}
+	for _, m := range flow.latencyMetrics {
+		observeWithExemplar(m, float64(*flow.Latency), flow.exemplar)
+	}
+}
@@ -0,0 +1,82 @@
+package flow
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"gotest.tools/assert"
+)
+
+func TestParseLatencyBuckets(t *testing.T) {
+	var tests = []struct {
+		value   string
+		buckets []float64
+		err     string
+	}{
+		{value: "1ms, 500us,1s", buckets: []float64{500, 1000, 1000000}},
+		{value: "250ms", buckets: []float64{250000}},
+		{value: "", err: "no latency buckets"},
+		{value: "1ms,fast", err: "invalid latency bucket \"fast\""},
+		{value: "100ns", err: "invalid latency bucket \"100ns\""},
+		{value: "1ms,1000us", err: "duplicate latency bucket 1ms"},
+	}
+	for _, test := range tests {
+		buckets, err := ParseLatencyBuckets(test.value)
+		if test.err != "" {
+			assert.ErrorContains(t, err, test.err)
+			continue
+		}
+		assert.Assert(t, err)
+		assert.DeepEqual(t, buckets, test.buckets)
+	}
+}
+
+func histogramCount(t *testing.T, histogram prometheus.Metric) uint64 {
+	m := &dto.Metric{}
+	assert.Assert(t, histogram.Write(m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestObserveLatency(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{
+		Mode:           RecordMetrics,
+		PromReg:        prometheus.NewRegistry(),
+		LatencyBuckets: []float64{1000, 50000},
+	})
+	fc.metrics = fc.NewMetrics(fc.prometheusReg)
+	va := &VanAddressRecord{Name: "backend:8080", flowLatency: make(map[metricKey]prometheus.Observer)}
+	labels := prometheus.Labels{
+		"sourceSite":    "east@_@site:0",
+		"destSite":      "west@_@site:1",
+		"address":       "backend:8080",
+		"protocol":      "tcp",
+		"direction":     "incoming",
+		"sourceProcess": "frontend",
+		"destProcess":   "backend",
+	}
+	key := metricKey{sourceSite: labels["sourceSite"], destSite: labels["destSite"], sourceProcess: labels["sourceProcess"], destProcess: labels["destProcess"]}
+
+	latency := uint64(20000)
+	flows := []*FlowRecord{{Latency: &latency}, {}}
+	for _, flow := range flows {
+		var err error
+		flow.latencyMetrics, err = fc.latencyObservers(va, key, labels)
+		assert.Assert(t, err)
+		observeLatency(flow)
+	}
+	assert.Equal(t, len(va.flowLatency), 1)
+
+	// the flow without a latency is not observed
+	addressLatency := fc.metrics.addressLatency.WithLabelValues("backend:8080", "tcp", "incoming").(prometheus.Metric)
+	assert.Equal(t, testutil.CollectAndCount(fc.metrics.addressLatency), 1)
+	assert.Equal(t, testutil.CollectAndCount(fc.metrics.sitePairLatency), 1)
+	assert.Equal(t, histogramCount(t, addressLatency), uint64(1))
+
+	flows[1].Latency = &latency
+	observeLatency(flows[1])
+	assert.Equal(t, histogramCount(t, addressLatency), uint64(2))
+	sitePairLatency := fc.metrics.sitePairLatency.WithLabelValues("east@_@site:0", "west@_@site:1", "incoming").(prometheus.Metric)
+	assert.Equal(t, histogramCount(t, sitePairLatency), uint64(2))
+}
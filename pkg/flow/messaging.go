@@ -1,10 +1,12 @@
 package flow
 
 import (
-	"log"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	amqp "github.com/interconnectedcloud/go-amqp"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/skupperproject/skupper/pkg/event"
 	"github.com/skupperproject/skupper/pkg/messaging"
 )
@@ -22,12 +24,22 @@ type base struct {
 	incoming          chan []interface{}
 	outgoing          chan interface{}
 	address           string
+	connections       int
+	reconnects        prometheus.Counter
 }
 
 func (c *base) stop() {
 	c.stopOnce.Do(func() { close(c.done) })
 }
 
+// connected counts the connections established after the first one
+func (c *base) connected() {
+	if c.connections > 0 && c.reconnects != nil {
+		c.reconnects.Inc()
+	}
+	c.connections++
+}
+
 type sender struct {
 	base
 	sendSettled bool
@@ -53,11 +65,11 @@ func (c *sender) send() {
 	for {
 		select {
 		case <-c.done:
-			log.Println("COLLECTOR: Flow process stopped sending")
+			logger.Infof("Flow process stopped sending")
 			return
 		default:
 			if err := c._send(); err != nil {
-				log.Printf("COLLECTOR: Error sending out updates %s", err.Error())
+				logger.Errorf("Error sending out updates %s", err.Error())
 			}
 		}
 
@@ -69,7 +81,8 @@ func (c *sender) _send() error {
 	if err != nil {
 		return err
 	}
-	log.Printf("COLLECTOR: Connection for sender %s to %s established\n", c.address, c.connectionFactory.Url())
+	logger.Infof("Connection for sender %s to %s established", c.address, c.connectionFactory.Url())
+	c.connected()
 	defer client.Close()
 
 	sender, err := client.Sender(c.address)
@@ -133,6 +146,30 @@ func (c *sender) _send() error {
 					request = msg
 				}
 			}
+			if logEvent, ok := update.(*LogEventRecord); ok {
+				msg, err := encodeLogEvent(logEvent)
+				if err != nil {
+					event.Recordf(FlowControllerEvent, "Failed to encode message for flow controller: %s", err.Error())
+				} else {
+					request = msg
+				}
+			}
+			if probe, ok := update.(*ProbeRecord); ok {
+				msg, err := encodeProbe(probe)
+				if err != nil {
+					event.Recordf(FlowControllerEvent, "Failed to encode message for flow controller: %s", err.Error())
+				} else {
+					request = msg
+				}
+			}
+			if latency, ok := update.(*SiteLatencyRecord); ok {
+				msg, err := encodeSiteLatency(latency)
+				if err != nil {
+					event.Recordf(FlowControllerEvent, "Failed to encode message for flow controller: %s", err.Error())
+				} else {
+					request = msg
+				}
+			}
 		}
 		if request != nil {
 			request.SendSettled = c.sendSettled
@@ -147,6 +184,11 @@ func (c *sender) _send() error {
 
 type receiver struct {
 	base
+	// source is the identity of the event source the records are from
+	source string
+	// waiting is the time in nanoseconds the records of the receiver wait
+	// since to be read by the update loop, zero when none do
+	waiting atomic.Int64
 }
 
 func newReceiver(connectionFactory messaging.ConnectionFactory, address string, updates chan []interface{}) *receiver {
@@ -171,7 +213,7 @@ func (r *receiver) receive() {
 			return
 		default:
 			if err := r._receive(); err != nil {
-				log.Printf("COLLECTOR: Receiver %s %s\n", r.address, err.Error())
+				logger.Warnf("Receiver %s %s", r.address, err.Error())
 			}
 		}
 	}
@@ -182,7 +224,8 @@ func (r *receiver) _receive() error {
 	if err != nil {
 		return err
 	}
-	log.Printf("COLLECTOR: Connection for receiver %s to %s established\n", r.address, r.connectionFactory.Url())
+	logger.Infof("Connection for receiver %s to %s established", r.address, r.connectionFactory.Url())
+	r.connected()
 	defer client.Close()
 
 	receiver, err := client.Receiver(r.address, 250)
@@ -203,6 +246,11 @@ func (r *receiver) _receive() error {
 		}
 		receiver.Accept(msg)
 		results := decode(msg)
+		if r.source != "" {
+			results = append(results, receivedBatch{source: r.source, received: time.Now()})
+		}
+		r.waiting.Store(time.Now().UnixNano())
 		r.incoming <- results
+		r.waiting.Store(0)
 	}
 }
@@ -0,0 +1,266 @@
+package flow
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skupperproject/skupper/pkg/messaging"
+)
+
+const (
+	// ProbeAddress is the address the controllers of all the sites receive
+	// the latency probes on
+	ProbeAddress string = RecordPrefix + "probes"
+	// LatencyProbeIntervalEnv enables the latency probes of a site when set
+	// to the interval between them, as in 30s
+	LatencyProbeIntervalEnv string = "SKUPPER_LATENCY_PROBE_INTERVAL"
+	minProbeInterval               = time.Second
+	// probeForgetAfter is the time after which a site no longer replying to
+	// the probes is forgotten
+	probeForgetAfter = 5 * time.Minute
+)
+
+func probeReplyAddress(siteId string) string {
+	return DirectPrefix + siteId + ".probes"
+}
+
+func siteLatencyId(sourceId string, destinationId string) string {
+	return sourceId + "-" + destinationId
+}
+
+// getProbeInterval returns the interval between the latency probes, zero
+// leaving them disabled
+func getProbeInterval(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		controllerLogger.Warnf("Latency probes disabled, invalid %s %q", LatencyProbeIntervalEnv, value)
+		return 0
+	}
+	if interval < minProbeInterval {
+		return minProbeInterval
+	}
+	return interval
+}
+
+type siteLatency struct {
+	destination string
+	startTime   uint64
+	endTime     uint64
+	latency     *uint64
+	sent        uint64
+	lost        uint64
+	lastReply   time.Time
+}
+
+func (l *siteLatency) record(origin string) *SiteLatencyRecord {
+	destination, sent, lost := l.destination, l.sent, l.lost
+	record := &SiteLatencyRecord{
+		Base: Base{
+			RecType:   recordNames[SiteLatency],
+			Identity:  siteLatencyId(origin, destination),
+			Parent:    origin,
+			StartTime: l.startTime,
+			EndTime:   l.endTime,
+		},
+		DestinationId: &destination,
+		ProbesSent:    &sent,
+		ProbesLost:    &lost,
+	}
+	if l.latency != nil {
+		latency := *l.latency
+		record.Latency = &latency
+	}
+	return record
+}
+
+// latencyProber measures the round trip time to the other sites with probes
+// multicast to their controllers. A probe not replied to by a site before
+// the next one is sent is counted as lost.
+type latencyProber struct {
+	mu        sync.Mutex
+	origin    string
+	probe     *ProbeRecord
+	sent      time.Time
+	replies   map[string]time.Duration
+	latencies map[string]*siteLatency
+}
+
+func newLatencyProber(origin string) *latencyProber {
+	return &latencyProber{
+		origin:    origin,
+		replies:   map[string]time.Duration{},
+		latencies: map[string]*siteLatency{},
+	}
+}
+
+// reply records the round trip time of a reply to the probe in flight
+func (p *latencyProber) reply(probe ProbeRecord, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.probe == nil || probe.Identity != p.probe.Identity || probe.Responder == "" || probe.Responder == p.origin {
+		return
+	}
+	if _, ok := p.replies[probe.Responder]; !ok {
+		p.replies[probe.Responder] = now.Sub(p.sent)
+	}
+}
+
+// next completes the probe in flight, returning the latencies it updated,
+// and returns the probe to send in its place
+func (p *latencyProber) next(now time.Time) ([]*SiteLatencyRecord, *ProbeRecord) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	micros := uint64(now.UnixNano()) / uint64(time.Microsecond)
+	var updates []*SiteLatencyRecord
+	if p.probe != nil {
+		for siteId := range p.replies {
+			if _, ok := p.latencies[siteId]; !ok {
+				p.latencies[siteId] = &siteLatency{destination: siteId, startTime: micros}
+			}
+		}
+		for siteId, latency := range p.latencies {
+			latency.sent++
+			if rtt, ok := p.replies[siteId]; ok {
+				value := uint64(rtt.Microseconds())
+				latency.latency = &value
+				latency.lastReply = now
+			} else {
+				latency.lost++
+			}
+			if now.Sub(latency.lastReply) > probeForgetAfter {
+				latency.endTime = micros
+				delete(p.latencies, siteId)
+			}
+			updates = append(updates, latency.record(p.origin))
+		}
+	}
+	p.probe = &ProbeRecord{
+		Identity: uuid.New().String(),
+		Source:   p.origin,
+		Sent:     micros,
+	}
+	p.sent = now
+	p.replies = map[string]time.Duration{}
+	probe := *p.probe
+	return updates, &probe
+}
+
+// records returns the latencies measured so far
+func (p *latencyProber) records() []*SiteLatencyRecord {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	records := make([]*SiteLatencyRecord, 0, len(p.latencies))
+	for _, latency := range p.latencies {
+		records = append(records, latency.record(p.origin))
+	}
+	return records
+}
+
+type probeReplySender struct {
+	sender   *sender
+	outgoing chan interface{}
+	lastUsed time.Time
+}
+
+// probeResponder replies to the probes of the other sites, keeping a sender
+// to each site probing until it stops
+type probeResponder struct {
+	origin            string
+	connectionFactory messaging.ConnectionFactory
+	senders           map[string]*probeReplySender
+}
+
+func newProbeResponder(origin string, connectionFactory messaging.ConnectionFactory) *probeResponder {
+	return &probeResponder{
+		origin:            origin,
+		connectionFactory: connectionFactory,
+		senders:           map[string]*probeReplySender{},
+	}
+}
+
+func (r *probeResponder) respond(probe ProbeRecord, now time.Time) {
+	if probe.Responder != "" || probe.Source == "" || probe.Source == r.origin {
+		return
+	}
+	s, ok := r.senders[probe.Source]
+	if !ok {
+		outgoing := make(chan interface{}, 10)
+		s = &probeReplySender{
+			sender:   newSender(r.connectionFactory, probeReplyAddress(probe.Source), true, outgoing),
+			outgoing: outgoing,
+		}
+		s.sender.start()
+		r.senders[probe.Source] = s
+	}
+	s.lastUsed = now
+	probe.Responder = r.origin
+	select {
+	case s.outgoing <- &probe:
+	default:
+		// the reply would be too late to be counted anyway
+	}
+}
+
+func (r *probeResponder) expire(now time.Time) {
+	for siteId, s := range r.senders {
+		if now.Sub(s.lastUsed) > probeForgetAfter {
+			s.sender.stop()
+			delete(r.senders, siteId)
+		}
+	}
+}
+
+func (r *probeResponder) stop() {
+	for siteId, s := range r.senders {
+		s.sender.stop()
+		delete(r.senders, siteId)
+	}
+}
+
+func (c *FlowController) respondProbes(stopCh <-chan struct{}) {
+	responder := newProbeResponder(c.origin, c.connectionFactory)
+	defer responder.stop()
+	tickerExpire := time.NewTicker(probeForgetAfter)
+	defer tickerExpire.Stop()
+	for {
+		select {
+		case probes := <-c.probesIncoming:
+			for _, update := range probes {
+				if probe, ok := update.(ProbeRecord); ok {
+					responder.respond(probe, time.Now())
+				}
+			}
+		case <-tickerExpire.C:
+			responder.expire(time.Now())
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+func (c *FlowController) updateProbes(stopCh <-chan struct{}) {
+	probeTimer := time.NewTicker(c.probeInterval)
+	defer probeTimer.Stop()
+	for {
+		select {
+		case <-probeTimer.C:
+			latencies, probe := c.prober.next(time.Now())
+			for _, latency := range latencies {
+				c.latencyOutgoing <- latency
+			}
+			c.probeOutgoing <- probe
+		case replies := <-c.probeRepliesIncoming:
+			for _, update := range replies {
+				if reply, ok := update.(ProbeRecord); ok {
+					c.prober.reply(reply, time.Now())
+				}
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package flow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/skupperproject/skupper/pkg/messaging"
+	"gotest.tools/assert"
+)
+
+func TestGetProbeInterval(t *testing.T) {
+	assert.Equal(t, getProbeInterval(""), time.Duration(0))
+	assert.Equal(t, getProbeInterval("30s"), 30*time.Second)
+	assert.Equal(t, getProbeInterval("10ms"), minProbeInterval)
+	assert.Equal(t, getProbeInterval("-1s"), time.Duration(0))
+	assert.Equal(t, getProbeInterval("often"), time.Duration(0))
+}
+
+func TestLatencyProber(t *testing.T) {
+	p := newLatencyProber("west")
+	now := time.Unix(1700000000, 0)
+
+	latencies, probe := p.next(now)
+	assert.Equal(t, len(latencies), 0)
+	assert.Equal(t, probe.Source, "west")
+
+	// the replies to other probes and the own replies are left out
+	p.reply(ProbeRecord{Identity: "other", Source: "west", Responder: "east"}, now.Add(time.Millisecond))
+	p.reply(ProbeRecord{Identity: probe.Identity, Source: "west", Responder: "west"}, now.Add(time.Millisecond))
+	p.reply(ProbeRecord{Identity: probe.Identity, Source: "west", Responder: "east"}, now.Add(20*time.Millisecond))
+	p.reply(ProbeRecord{Identity: probe.Identity, Source: "west", Responder: "east"}, now.Add(30*time.Millisecond))
+	latencies, probe = p.next(now.Add(time.Second))
+	assert.Equal(t, len(latencies), 1)
+	assert.Equal(t, latencies[0].Identity, "west-east")
+	assert.Equal(t, latencies[0].Parent, "west")
+	assert.Equal(t, *latencies[0].DestinationId, "east")
+	assert.Equal(t, *latencies[0].Latency, uint64(20000))
+	assert.Equal(t, *latencies[0].ProbesSent, uint64(1))
+	assert.Equal(t, *latencies[0].ProbesLost, uint64(0))
+
+	// a probe not replied to is lost
+	latencies, _ = p.next(now.Add(2 * time.Second))
+	assert.Equal(t, *latencies[0].Latency, uint64(20000))
+	assert.Equal(t, *latencies[0].ProbesSent, uint64(2))
+	assert.Equal(t, *latencies[0].ProbesLost, uint64(1))
+	assert.Equal(t, len(p.records()), 1)
+
+	// and the site forgotten once it stops replying
+	latencies, _ = p.next(now.Add(time.Second + probeForgetAfter + time.Second))
+	assert.Assert(t, latencies[0].EndTime > 0)
+	assert.Equal(t, len(p.records()), 0)
+}
+
+func TestProbeResponder(t *testing.T) {
+	factory := messaging.NewMockConnectionFactory(t, "mockamqp://local")
+	replies := make(chan []interface{}, 1)
+	r := newReceiver(factory, probeReplyAddress("west"), replies)
+	r.start()
+	defer r.stop()
+	factory.Broker.AwaitReceivers(probeReplyAddress("west"), 1)
+
+	responder := newProbeResponder("east", factory)
+	defer responder.stop()
+	now := time.Now()
+	responder.respond(ProbeRecord{Identity: "probe:0", Source: "east"}, now)
+	assert.Equal(t, len(responder.senders), 0)
+	responder.respond(ProbeRecord{Identity: "probe:1", Source: "west", Sent: 42}, now)
+	reply := (<-replies)[0].(ProbeRecord)
+	assert.DeepEqual(t, reply, ProbeRecord{Identity: "probe:1", Source: "west", Responder: "east", Sent: 42})
+
+	responder.expire(now.Add(probeForgetAfter + time.Second))
+	assert.Equal(t, len(responder.senders), 0)
+}
+
+func TestSiteLatency(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{
+		Mode:    RecordMetrics,
+		PromReg: prometheus.NewRegistry(),
+	})
+	fc.metrics = fc.NewMetrics(fc.prometheusReg)
+	west, east := "west", "east"
+	fc.Sites["site:0"] = &SiteRecord{Base: Base{Identity: "site:0"}, Name: &west}
+	fc.Sites["site:1"] = &SiteRecord{Base: Base{Identity: "site:1"}, Name: &east}
+	update := func(sent uint64, lost uint64, latency uint64) {
+		destination := "site:1"
+		err := fc.updateRecord(SiteLatencyRecord{
+			Base:          Base{RecType: recordNames[SiteLatency], Identity: siteLatencyId("site:0", "site:1"), Parent: "site:0", StartTime: 1},
+			DestinationId: &destination,
+			Latency:       &latency,
+			ProbesSent:    &sent,
+			ProbesLost:    &lost,
+		})
+		assert.Assert(t, err)
+	}
+
+	update(1, 0, 2000)
+	update(3, 1, 3000)
+	// a lost probe leaves the latency of the previous one
+	update(4, 2, 3000)
+	latency, ok := fc.SiteLatencies["site:0-site:1"]
+	assert.Assert(t, ok)
+	assert.Equal(t, *latency.SourceName, "west")
+	assert.Equal(t, *latency.DestinationName, "east")
+	assert.Equal(t, *latency.Latency, uint64(3000))
+	labels := prometheus.Labels{"sourceSite": "west@_@site:0", "destSite": "east@_@site:1"}
+	observer, err := fc.metrics.siteProbeLatency.GetMetricWith(labels)
+	assert.Assert(t, err)
+	assert.Equal(t, histogramCount(t, observer.(prometheus.Metric)), uint64(2))
+	assert.Equal(t, testutil.ToFloat64(fc.metrics.siteProbesLost.With(labels)), 2.0)
+
+	// the latencies of a site go with it
+	err = fc.updateRecord(SiteRecord{Base: Base{Identity: "site:1", EndTime: 2}})
+	assert.Assert(t, err)
+	assert.Equal(t, len(fc.SiteLatencies), 0)
+	assert.Equal(t, testutil.CollectAndCount(fc.metrics.siteProbesLost), 0)
+}
@@ -0,0 +1,76 @@
+package flow
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// processTrafficMetrics are the per process metrics a transport flow
+// updates: the octets it carries are sent by the process of the flow and
+// received by the process of its counter flow
+type processTrafficMetrics struct {
+	octetsSent     prometheus.Counter
+	octetsReceived prometheus.Counter
+	active         prometheus.Gauge
+	closed         prometheus.Counter
+}
+
+func (fc *FlowCollector) getProcessLabels(flow *FlowRecord) prometheus.Labels {
+	labels := prometheus.Labels{"process": "", "processGroup": ""}
+	if flow.ProcessName != nil {
+		labels["process"] = *flow.ProcessName
+	}
+	if process, ok := fc.getFlowProcess(flow.Identity); ok && process.GroupName != nil {
+		labels["processGroup"] = *process.GroupName
+	}
+	return labels
+}
+
+// setupProcessMetrics counts the connection of a transport flow, the
+// application flows carried by a connection are not counted twice
+func (fc *FlowCollector) setupProcessMetrics(flow *FlowRecord, counterFlow *FlowRecord) error {
+	_, isListener := fc.Listeners[flow.Parent]
+	_, isConnector := fc.Connectors[flow.Parent]
+	if !isListener && !isConnector {
+		return nil
+	}
+	labels := fc.getProcessLabels(flow)
+	m := &processTrafficMetrics{}
+	var err error
+	if m.octetsSent, err = fc.metrics.processOctetsSent.GetMetricWith(labels); err != nil {
+		return err
+	}
+	if m.octetsReceived, err = fc.metrics.processOctetsReceived.GetMetricWith(fc.getProcessLabels(counterFlow)); err != nil {
+		return err
+	}
+	if m.active, err = fc.metrics.processActiveConnections.GetMetricWith(labels); err != nil {
+		return err
+	}
+	if m.closed, err = fc.metrics.processClosedConnections.GetMetricWith(labels); err != nil {
+		return err
+	}
+	opened, err := fc.metrics.processOpenedConnections.GetMetricWith(labels)
+	if err != nil {
+		return err
+	}
+	opened.Inc()
+	if flow.EndTime == 0 {
+		m.active.Inc()
+	} else {
+		m.closed.Inc()
+	}
+	if flow.Octets != nil {
+		m.addOctets(*flow.Octets)
+	}
+	flow.processMetrics = m
+	return nil
+}
+
+func (m *processTrafficMetrics) addOctets(octets uint64) {
+	m.octetsSent.Add(float64(octets))
+	m.octetsReceived.Add(float64(octets))
+}
+
+func (m *processTrafficMetrics) close() {
+	m.active.Dec()
+	m.closed.Inc()
+}
@@ -0,0 +1,49 @@
+package flow
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestProcessMetrics(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{
+		Mode:    RecordMetrics,
+		PromReg: prometheus.NewRegistry(),
+	})
+	fc.metrics = fc.NewMetrics(fc.prometheusReg)
+	frontend, backend, group := "frontend", "backend", "shop"
+	fc.Processes["process:0"] = &ProcessRecord{Base: Base{Identity: "process:0"}, Name: &frontend, GroupName: &group}
+	fc.Processes["process:1"] = &ProcessRecord{Base: Base{Identity: "process:1"}, Name: &backend}
+	fc.Listeners["listener:0"] = &ListenerRecord{Base: Base{Identity: "listener:0"}}
+	fc.Connectors["connector:0"] = &ConnectorRecord{Base: Base{Identity: "connector:0"}}
+
+	processes := []string{"process:0", "process:1"}
+	octets := uint64(100)
+	source := &FlowRecord{Base: Base{Identity: "flow:0", Parent: "listener:0"}, Process: &processes[0], ProcessName: &frontend, Octets: &octets}
+	dest := &FlowRecord{Base: Base{Identity: "flow:1", Parent: "connector:0"}, Process: &processes[1], ProcessName: &backend}
+	request := &FlowRecord{Base: Base{Identity: "flow:2", Parent: "flow:0"}, Process: &processes[0], ProcessName: &frontend}
+	for _, flow := range []*FlowRecord{source, dest, request} {
+		fc.Flows[flow.Identity] = flow
+	}
+	assert.Assert(t, fc.setupProcessMetrics(source, dest))
+	assert.Assert(t, fc.setupProcessMetrics(dest, source))
+	// the requests carried by the connection are not counted
+	assert.Assert(t, fc.setupProcessMetrics(request, dest))
+	assert.Assert(t, request.processMetrics == nil)
+
+	assert.Equal(t, testutil.ToFloat64(fc.metrics.processOctetsSent.WithLabelValues("frontend", "shop")), 100.0)
+	assert.Equal(t, testutil.ToFloat64(fc.metrics.processOctetsReceived.WithLabelValues("backend", "")), 100.0)
+	assert.Equal(t, testutil.ToFloat64(fc.metrics.processActiveConnections.WithLabelValues("frontend", "shop")), 1.0)
+	assert.Equal(t, testutil.ToFloat64(fc.metrics.processOpenedConnections.WithLabelValues("backend", "")), 1.0)
+
+	dest.processMetrics.addOctets(50)
+	assert.Equal(t, testutil.ToFloat64(fc.metrics.processOctetsSent.WithLabelValues("backend", "")), 50.0)
+	assert.Equal(t, testutil.ToFloat64(fc.metrics.processOctetsReceived.WithLabelValues("frontend", "shop")), 50.0)
+
+	source.processMetrics.close()
+	assert.Equal(t, testutil.ToFloat64(fc.metrics.processActiveConnections.WithLabelValues("frontend", "shop")), 0.0)
+	assert.Equal(t, testutil.ToFloat64(fc.metrics.processClosedConnections.WithLabelValues("frontend", "shop")), 1.0)
+}
@@ -1,6 +1,8 @@
 package flow
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"reflect"
@@ -38,6 +40,7 @@ const (
 	ProcessGroupPair        // 19
 	ProcessPair             // 20
 	Address                 // 21
+	SiteLatency             // 22
 )
 
 var recordNames = []string{
@@ -63,6 +66,7 @@ var recordNames = []string{
 	"PROCESSGROUPPAIR",
 	"PROCESSPAIR",
 	"ADDRESS",
+	"SITELATENCY",
 }
 
 // Attribute Types
@@ -122,6 +126,10 @@ const (
 	Version                // 52
 	Policy                 // 53
 	Target                 // 54
+	TraceParent            // 55
+	TraceState             // 56
+	ProbesSent             // 57
+	ProbesLost             // 58
 )
 
 var attributeNames = []string{
@@ -180,6 +188,10 @@ var attributeNames = []string{
 	"Version",         // 52
 	"Policy",          // 53
 	"Target",          // 54
+	"TraceParent",     // 55
+	"TraceState",      // 56
+	"ProbesSent",      // 57
+	"ProbesLost",      // 58
 }
 
 var Internal string = "internal"
@@ -222,6 +234,15 @@ type FlushRecord struct {
 	Source  string `json:"source,omitempty"`
 }
 
+// ProbeRecord is a latency probe multicast by the controller of a site to
+// the controllers of the other sites, or the reply of one of them
+type ProbeRecord struct {
+	Identity  string `json:"identity,omitempty"`
+	Source    string `json:"source,omitempty"`
+	Responder string `json:"responder,omitempty"`
+	Sent      uint64 `json:"sent,omitempty"`
+}
+
 type EventSourceRecord struct {
 	Base
 	Beacon     *BeaconRecord `json:"beacon,omitempty"`
@@ -229,6 +250,14 @@ type EventSourceRecord struct {
 	Heartbeats int           `json:"heartbeats,omitempty"`
 	Beacons    int           `json:"beacons,omitempty"`
 	Messages   int           `json:"messages,omitempty"`
+	// Stale is set while the source is not heard from, until it is purged
+	Stale bool `json:"stale,omitempty"`
+	// ClockSkew is how far in microseconds the clock of the source is ahead
+	// of the clock of the collector, measured with its heartbeats
+	ClockSkew *int64 `json:"clockSkew,omitempty"`
+	// IngestionLag is the time in microseconds the latest records of the
+	// source waited to be applied by the collector
+	IngestionLag uint64 `json:"ingestionLag,omitempty"`
 }
 
 type SiteRecord struct {
@@ -240,6 +269,12 @@ type SiteRecord struct {
 	NameSpace *string `json:"nameSpace,omitempty"`
 	Version   *string `json:"siteVersion,omitempty"`
 	Policy    *string `json:"policy,omitempty"`
+	// Stale is set while the controller or all the routers of the site are
+	// not heard from
+	Stale bool `json:"stale,omitempty"`
+	// ClockSkew is the largest clock skew in microseconds of the controller
+	// and routers of the site
+	ClockSkew *int64 `json:"clockSkew,omitempty"`
 }
 
 type HostRecord struct {
@@ -268,6 +303,8 @@ type RouterRecord struct {
 	ImageVersion *string `json:"imageVersion,omitempty"`
 	Hostname     *string `json:"hostname,omitempty"`
 	BuildVersion *string `json:"buildVersion,omitempty"`
+	// Stale is set while the router is not heard from
+	Stale bool `json:"stale,omitempty"`
 }
 
 type LinkRecord struct {
@@ -334,6 +371,10 @@ type VanAddressRecord struct {
 	lastAccessed    map[metricKey]prometheus.Gauge
 	flowLatency     map[metricKey]prometheus.Observer
 	activeFlowCount map[metricKey]prometheus.Gauge
+
+	// HttpResponses counts the responses to the http requests sent to the
+	// address by class of status code, as in 2xx
+	HttpResponses map[string]uint64 `json:"httpResponses,omitempty"`
 }
 
 type ProcessRecord struct {
@@ -387,10 +428,18 @@ type FlowRecord struct {
 	ProcessName      *string   `json:"processName,omitempty"`
 	Protocol         *string   `json:"protocol,omitempty"`
 	Place            FlowPlace `json:"place"`
+	Tags             []string  `json:"tags,omitempty"`
+	FailureClass     *string   `json:"failureClass,omitempty"`
+	TraceParent      *string   `json:"traceParent,omitempty"`
+	TraceState       *string   `json:"traceState,omitempty"`
 	lastOctets       uint64
+	addressId        string
 	octetMetric      prometheus.Counter
 	activeFlowMetric prometheus.Gauge
 	httpReqsMetric   prometheus.Counter
+	latencyMetrics   []prometheus.Observer
+	processMetrics   *processTrafficMetrics
+	exemplar         prometheus.Labels
 }
 
 // Note a flowpair does not have a defined parent relationship through Base
@@ -408,6 +457,11 @@ type FlowPairRecord struct {
 	SiteAggregateId         *string     `json:"siteAggregateId,omitempty"`
 	ProcessGroupAggregateId *string     `json:"processGroupAggregateId,omitempty"`
 	ProcessAggregateId      *string     `json:"processAggregateId,omitempty"`
+	Tags                    []string    `json:"tags,omitempty"`
+	// TraceId is the id of the distributed trace of the request of the
+	// flow pair, from the traceparent header of its http flows
+	TraceId             string `json:"traceId,omitempty"`
+	httpResponseCounted bool
 }
 
 type FlowAggregateRecord struct {
@@ -423,6 +477,26 @@ type FlowAggregateRecord struct {
 	DestinationSiteId   *string `json:"destinationSiteId,omitempty"`
 	DestinationSiteName *string `json:"destinationSiteName,omitempty"`
 	Protocol            *string `json:"protocol,omitempty"`
+	// HttpResponses counts the responses to the http requests between the
+	// processes of a process pair by class of status code, as in 2xx
+	HttpResponses map[string]uint64 `json:"httpResponses,omitempty"`
+}
+
+// SiteLatencyRecord is the round trip time between two sites measured by
+// the latency probes of the source site, its parent
+type SiteLatencyRecord struct {
+	Base
+	SourceName      *string `json:"sourceName,omitempty"`
+	DestinationId   *string `json:"destinationId,omitempty"`
+	DestinationName *string `json:"destinationName,omitempty"`
+	// Latency is the round trip time in microseconds of the latest probe
+	// replied to
+	Latency    *uint64 `json:"latency,omitempty"`
+	ProbesSent *uint64 `json:"probesSent,omitempty"`
+	ProbesLost *uint64 `json:"probesLost,omitempty"`
+
+	lastUpdate   uint64
+	metricLabels prometheus.Labels
 }
 
 type ControllerRecord struct {
@@ -469,6 +543,8 @@ type Payload struct {
 	Count          int         `json:"count"`
 	TimeRangeCount int         `json:"timeRangeCount"`
 	TotalCount     int         `json:"totalCount"`
+	NextCursor     string      `json:"nextCursor,omitempty"`
+	Partial        bool        `json:"partial,omitempty"`
 	timestamp      uint64
 	elapsed        uint64
 }
@@ -476,6 +552,7 @@ type Payload struct {
 type QueryParams struct {
 	Offset             int                 `json:"offset"`
 	Limit              int                 `json:"limit"`
+	Cursor             string              `json:"cursor"`
 	SortBy             string              `json:"sortBy"`
 	Filter             string              `json:"filter"`
 	FilterFields       map[string][]string `json:"filterFields"`
@@ -511,6 +588,8 @@ func getQueryParams(url *url.URL) QueryParams {
 			if err == nil {
 				qp.Limit = limit
 			}
+		case "cursor":
+			qp.Cursor = v[0]
 		case "sortBy":
 			if v[0] != "" {
 				qp.SortBy = v[0]
@@ -691,6 +770,11 @@ func getField(field string, record interface{}) interface{} {
 			return x.FieldByName(field).Int()
 		case reflect.Uint64:
 			return x.FieldByName(field).Uint()
+		case reflect.Slice:
+			if values, ok := x.FieldByName(field).Interface().([]string); ok && len(values) > 0 {
+				return values
+			}
+			return nil
 		default:
 			return nil
 		}
@@ -714,6 +798,14 @@ func matchFieldValues(x interface{}, values []string) bool {
 				return true
 			}
 		}
+	case []string:
+		for _, y := range values {
+			for _, v := range x {
+				if v == y {
+					return true
+				}
+			}
+		}
 	case uint64:
 		return numInStringSlice(x, values)
 	case int32:
@@ -855,6 +947,96 @@ func filterSubRecord(item interface{}, filter string) bool {
 	return matchFieldValues(value, []string{match})
 }
 
+// pageCursor is the position after the last record of a page: the value of
+// the sort field and the identity of that record. Unlike an offset it still
+// points to the same place when records are added or removed in between
+type pageCursor struct {
+	SortBy   string `json:"s"`
+	Value    string `json:"v,omitempty"`
+	Nil      bool   `json:"n,omitempty"`
+	Identity string `json:"i"`
+}
+
+func encodeCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string, sortBy string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("Malformed cursor query parameter")
+	}
+	c := &pageCursor{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("Malformed cursor query parameter")
+	}
+	if c.SortBy != sortBy {
+		return nil, fmt.Errorf("The cursor query parameter does not match sortBy %s", sortBy)
+	}
+	return c, nil
+}
+
+// cursorValue converts the cursor value to the type of the sort field
+func cursorValue(c *pageCursor, sample interface{}) (interface{}, error) {
+	if c.Nil {
+		return nil, nil
+	}
+	var err error
+	var value interface{}
+	switch sample.(type) {
+	case string:
+		value = c.Value
+	case uint64:
+		value, err = strconv.ParseUint(c.Value, 10, 64)
+	case int64:
+		value, err = strconv.ParseInt(c.Value, 10, 64)
+	case int32:
+		var v int64
+		v, err = strconv.ParseInt(c.Value, 10, 32)
+		value = int32(v)
+	case int:
+		value, err = strconv.Atoi(c.Value)
+	default:
+		// not sortable, the identity alone orders the records
+		value = sample
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Malformed cursor query parameter")
+	}
+	return value, nil
+}
+
+func sortField(item interface{}, field string, subField string) interface{} {
+	value := getField(field, item)
+	if reflect.ValueOf(value).Kind() == reflect.Struct {
+		value = getField(subField, value)
+	}
+	return value
+}
+
+// compareSortValues orders missing values first and breaks no ties
+func compareSortValues(x, y interface{}, order string) int {
+	switch {
+	case x == nil && y == nil:
+		return 0
+	case x == nil:
+		return -1
+	case y == nil:
+		return 1
+	case compareFields(x, y, order):
+		return -1
+	case compareFields(y, x, order):
+		return 1
+	}
+	return 0
+}
+
+func recordIdentity(item interface{}) string {
+	identity, _ := getField("Identity", item).(string)
+	return identity
+}
+
 func sortAndSlice[T any](list []T, payload *Payload, queryParams QueryParams) error {
 	offset := queryParams.Offset
 	limit := queryParams.Limit
@@ -864,20 +1046,49 @@ func sortAndSlice[T any](list []T, payload *Payload, queryParams QueryParams) er
 	if err != nil {
 		return err
 	}
+	var cursor *pageCursor
+	if queryParams.Cursor != "" {
+		if cursor, err = decodeCursor(queryParams.Cursor, queryParams.SortBy); err != nil {
+			return err
+		}
+	}
 	payload.TimeRangeCount = len(list)
+	// the identity breaks ties so that every request sees the same order
 	sort.Slice(list, func(i, j int) bool {
-		v1 := getField(field, list[i])
-		v2 := getField(field, list[j])
-		x := reflect.ValueOf(v1)
-		// todo: embedded all the way down
-		if x.Kind() == reflect.Struct {
-			v1 = getField(subField, v1)
-			v2 = getField(subField, v2)
+		if c := compareSortValues(sortField(list[i], field, subField), sortField(list[j], field, subField), order); c != 0 {
+			return c < 0
 		}
-		return compareFields(v1, v2, order)
+		return recordIdentity(list[i]) < recordIdentity(list[j])
 	})
+	if cursor != nil {
+		var value interface{}
+		for _, item := range list {
+			if sample := sortField(item, field, subField); sample != nil {
+				if value, err = cursorValue(cursor, sample); err != nil {
+					return err
+				}
+				break
+			}
+		}
+		offset = sort.Search(len(list), func(i int) bool {
+			if c := compareSortValues(sortField(list[i], field, subField), value, order); c != 0 {
+				return c > 0
+			}
+			return recordIdentity(list[i]) > cursor.Identity
+		})
+	}
 	start, end = paginate(offset, limit, len(list))
 	payload.Count = end - start
 	payload.Results = (list[start:end])
+	if end > start && end < len(list) {
+		last := list[end-1]
+		next := pageCursor{SortBy: queryParams.SortBy, Identity: recordIdentity(last)}
+		if value := sortField(last, field, subField); value == nil {
+			next.Nil = true
+		} else {
+			next.Value = fmt.Sprint(value)
+		}
+		payload.NextCursor = encodeCursor(next)
+	}
 	return nil
 }
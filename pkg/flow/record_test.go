@@ -487,6 +487,85 @@ func TestPagination(t *testing.T) {
 	}
 }
 
+func TestCursorPagination(t *testing.T) {
+	newSite := func(id string, start uint64) SiteRecord {
+		return SiteRecord{Base: Base{Identity: id, StartTime: start}}
+	}
+	pageIds := func(p Payload) []string {
+		ids := []string{}
+		for _, site := range p.Results.([]SiteRecord) {
+			ids = append(ids, site.Identity)
+		}
+		return ids
+	}
+
+	testTable := []struct {
+		doc    string
+		sortBy string
+		first  []SiteRecord
+		second []SiteRecord
+		page1  []string
+		page2  []string
+	}{
+		{
+			doc:    "removed-before-cursor",
+			sortBy: "identity.asc",
+			first:  []SiteRecord{newSite("a", 1), newSite("b", 2), newSite("c", 3), newSite("d", 4), newSite("e", 5)},
+			second: []SiteRecord{newSite("b", 2), newSite("c", 3), newSite("d", 4), newSite("e", 5)},
+			page1:  []string{"a", "b"},
+			page2:  []string{"c", "d"},
+		},
+		{
+			doc:    "cursor-record-removed",
+			sortBy: "identity.asc",
+			first:  []SiteRecord{newSite("a", 1), newSite("b", 2), newSite("c", 3), newSite("d", 4)},
+			second: []SiteRecord{newSite("a", 1), newSite("c", 3), newSite("d", 4)},
+			page1:  []string{"a", "b"},
+			page2:  []string{"c", "d"},
+		},
+		{
+			doc:    "added-before-cursor",
+			sortBy: "startTime.desc",
+			first:  []SiteRecord{newSite("a", 1), newSite("b", 2), newSite("c", 3), newSite("d", 4)},
+			second: []SiteRecord{newSite("a", 1), newSite("b", 2), newSite("c", 3), newSite("d", 4), newSite("e", 5)},
+			page1:  []string{"d", "c"},
+			page2:  []string{"b", "a"},
+		},
+		{
+			doc:    "ties-broken-by-identity",
+			sortBy: "startTime.asc",
+			first:  []SiteRecord{newSite("d", 1), newSite("c", 1), newSite("b", 1), newSite("a", 1)},
+			second: []SiteRecord{newSite("c", 1), newSite("a", 1), newSite("d", 1), newSite("b", 1)},
+			page1:  []string{"a", "b"},
+			page2:  []string{"c", "d"},
+		},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			qp := QueryParams{Offset: -1, Limit: 2, SortBy: test.sortBy}
+			p1 := Payload{}
+			assert.Assert(t, sortAndSlice(test.first, &p1, qp))
+			assert.DeepEqual(t, pageIds(p1), test.page1)
+			assert.Assert(t, p1.NextCursor != "")
+
+			qp.Cursor = p1.NextCursor
+			p2 := Payload{}
+			assert.Assert(t, sortAndSlice(test.second, &p2, qp))
+			assert.DeepEqual(t, pageIds(p2), test.page2)
+		})
+	}
+
+	qp := QueryParams{Offset: -1, Limit: 2, SortBy: "identity.asc"}
+	p := Payload{}
+	assert.Assert(t, sortAndSlice([]SiteRecord{newSite("a", 1), newSite("b", 2)}, &p, qp))
+	assert.Equal(t, p.NextCursor, "")
+
+	qp.Cursor = encodeCursor(pageCursor{SortBy: "startTime.asc", Identity: "a"})
+	assert.ErrorContains(t, sortAndSlice([]SiteRecord{newSite("a", 1)}, &p, qp), "does not match sortBy")
+	qp.Cursor = "not a cursor"
+	assert.ErrorContains(t, sortAndSlice([]SiteRecord{newSite("a", 1)}, &p, qp), "Malformed cursor")
+}
+
 func TestMatchField(t *testing.T) {
 	field1 := "foo"
 	field1Value := []string{"foo"}
@@ -0,0 +1,91 @@
+package flow
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// countReceived accounts for a record read from the routers and controllers
+func (fc *FlowCollector) countReceived(record interface{}) {
+	if fc.metrics == nil {
+		return
+	}
+	recType := "UNKNOWN"
+	switch r := record.(type) {
+	case HeartbeatRecord:
+		recType = "HEARTBEAT"
+	default:
+		if base, ok := recordBase(r); ok && base.RecType != "" {
+			recType = base.RecType
+		}
+	}
+	fc.metrics.recordsReceived.WithLabelValues(recType).Inc()
+}
+
+// countPurged accounts for the records removed once aged out or once their
+// event source went away
+func (fc *FlowCollector) countPurged(recordType int, count int) {
+	if fc.metrics == nil || count == 0 {
+		return
+	}
+	fc.metrics.recordsPurged.WithLabelValues(recordNames[recordType]).Add(float64(count))
+}
+
+// countReconnects has the links of the collector count the connections
+// established after their first one
+func (fc *FlowCollector) countReconnects(role string, links ...*base) {
+	if fc.metrics == nil {
+		return
+	}
+	for _, link := range links {
+		link.reconnects = fc.metrics.amqpReconnects.WithLabelValues(role)
+	}
+}
+
+// heldRecords returns the number of records held by type
+func (fc *FlowCollector) heldRecords() map[int]int {
+	return map[int]int{
+		Site:          len(fc.Sites),
+		Host:          len(fc.Hosts),
+		Router:        len(fc.Routers),
+		Link:          len(fc.Links),
+		Listener:      len(fc.Listeners),
+		Connector:     len(fc.Connectors),
+		Flow:          len(fc.Flows),
+		FlowPair:      len(fc.FlowPairs),
+		FlowAggregate: len(fc.FlowAggregates),
+		Process:       len(fc.Processes),
+		ProcessGroup:  len(fc.ProcessGroups),
+		Address:       len(fc.VanAddresses),
+		EventSource:   len(fc.eventSources),
+		SiteLatency:   len(fc.SiteLatencies),
+	}
+}
+
+// updateSelfMetrics sets the number of records held by type and the time
+// since the last beacon of each event source
+func (fc *FlowCollector) updateSelfMetrics(now time.Time) {
+	if fc.metrics == nil {
+		return
+	}
+	for recordType, count := range fc.heldRecords() {
+		fc.metrics.recordsHeld.WithLabelValues(recordNames[recordType]).Set(float64(count))
+	}
+	micros := uint64(now.UnixNano()) / uint64(time.Microsecond)
+	for _, source := range fc.eventSources {
+		age := 0.0
+		if micros > source.LastHeard {
+			age = float64(micros-source.LastHeard) / float64(oneSecond)
+		}
+		fc.metrics.beaconAge.With(beaconAgeLabels(source.EventSourceRecord)).Set(age)
+	}
+}
+
+func beaconAgeLabels(source EventSourceRecord) prometheus.Labels {
+	labels := prometheus.Labels{"eventSource": source.Identity, "sourceType": ""}
+	if source.Beacon != nil {
+		labels["sourceType"] = source.Beacon.SourceType
+	}
+	return labels
+}
@@ -0,0 +1,54 @@
+package flow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestSelfMetrics(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{
+		Mode:    RecordMetrics,
+		PromReg: prometheus.NewRegistry(),
+	})
+	fc.metrics = fc.NewMetrics(fc.prometheusReg)
+
+	fc.countReceived(SiteRecord{Base: Base{RecType: recordNames[Site], Identity: "site:0"}})
+	fc.countReceived(FlowRecord{Base: Base{RecType: recordNames[Flow], Identity: "flow:0"}})
+	fc.countReceived(FlowRecord{Base: Base{RecType: recordNames[Flow], Identity: "flow:1"}})
+	fc.countReceived(HeartbeatRecord{Identity: "controller:0"})
+	assert.Equal(t, testutil.ToFloat64(fc.metrics.recordsReceived.WithLabelValues("SITE")), 1.0)
+	assert.Equal(t, testutil.ToFloat64(fc.metrics.recordsReceived.WithLabelValues("FLOW")), 2.0)
+	assert.Equal(t, testutil.ToFloat64(fc.metrics.recordsReceived.WithLabelValues("HEARTBEAT")), 1.0)
+
+	fc.countPurged(Flow, 3)
+	fc.countPurged(FlowPair, 0)
+	assert.Equal(t, testutil.ToFloat64(fc.metrics.recordsPurged.WithLabelValues("FLOW")), 3.0)
+	assert.Equal(t, testutil.CollectAndCount(fc.metrics.recordsPurged), 1)
+
+	now := time.Now()
+	lastHeard := uint64(now.Add(-10*time.Second).UnixNano()) / uint64(time.Microsecond)
+	fc.Sites["site:0"] = &SiteRecord{Base: Base{Identity: "site:0"}}
+	fc.eventSources["router:0"] = &eventSource{EventSourceRecord: EventSourceRecord{
+		Base:      Base{Identity: "router:0"},
+		Beacon:    &BeaconRecord{SourceType: recordNames[Router]},
+		LastHeard: lastHeard,
+	}}
+	fc.updateSelfMetrics(now)
+	assert.Equal(t, testutil.ToFloat64(fc.metrics.recordsHeld.WithLabelValues("SITE")), 1.0)
+	assert.Equal(t, testutil.ToFloat64(fc.metrics.recordsHeld.WithLabelValues("EVENTSOURCE")), 1.0)
+	assert.Equal(t, testutil.ToFloat64(fc.metrics.recordsHeld.WithLabelValues("FLOW")), 0.0)
+	age := testutil.ToFloat64(fc.metrics.beaconAge.WithLabelValues("router:0", "ROUTER"))
+	assert.Assert(t, age >= 9.9 && age <= 10.1, "unexpected beacon age %f", age)
+
+	r := newReceiver(nil, BeaconAddress, nil)
+	fc.countReconnects("receiver", &r.base)
+	r.connected()
+	assert.Equal(t, testutil.ToFloat64(fc.metrics.amqpReconnects.WithLabelValues("receiver")), 0.0)
+	r.connected()
+	r.connected()
+	assert.Equal(t, testutil.ToFloat64(fc.metrics.amqpReconnects.WithLabelValues("receiver")), 2.0)
+}
@@ -0,0 +1,134 @@
+package flow
+
+// siteRecords are the site scoped records of a single site
+type siteRecords struct {
+	routers   map[string]*RouterRecord
+	hosts     map[string]*HostRecord
+	processes map[string]*ProcessRecord
+}
+
+func newSiteRecords() *siteRecords {
+	return &siteRecords{
+		routers:   make(map[string]*RouterRecord),
+		hosts:     make(map[string]*HostRecord),
+		processes: make(map[string]*ProcessRecord),
+	}
+}
+
+func (r *siteRecords) empty() bool {
+	return len(r.routers) == 0 && len(r.hosts) == 0 && len(r.processes) == 0
+}
+
+// siteIndex indexes the site scoped records by their parent site, so the
+// per site queries do not need to scan every record. The record maps of
+// the collector remain the store. Like them, the index is only accessed
+// from the collector goroutine, so it has no lock of its own.
+type siteIndex struct {
+	sites  map[string]*siteRecords
+	owners map[string]string
+}
+
+func newSiteIndex() *siteIndex {
+	return &siteIndex{
+		sites:  make(map[string]*siteRecords),
+		owners: make(map[string]string),
+	}
+}
+
+func siteIndexKey(record interface{}) (string, string, bool) {
+	switch r := record.(type) {
+	case *RouterRecord:
+		return r.Identity, r.Parent, true
+	case *HostRecord:
+		return r.Identity, r.Parent, true
+	case *ProcessRecord:
+		return r.Identity, r.Parent, true
+	}
+	return "", "", false
+}
+
+// add indexes a record under its parent site, moving it if the parent
+// has changed since it was last indexed
+func (s *siteIndex) add(record interface{}) {
+	identity, siteId, ok := siteIndexKey(record)
+	if !ok {
+		return
+	}
+	if previous, indexed := s.owners[identity]; indexed && previous != siteId {
+		s.remove(record)
+	}
+	if siteId == "" {
+		return
+	}
+	site, ok := s.sites[siteId]
+	if !ok {
+		site = newSiteRecords()
+		s.sites[siteId] = site
+	}
+	switch r := record.(type) {
+	case *RouterRecord:
+		site.routers[identity] = r
+	case *HostRecord:
+		site.hosts[identity] = r
+	case *ProcessRecord:
+		site.processes[identity] = r
+	}
+	s.owners[identity] = siteId
+}
+
+func (s *siteIndex) remove(record interface{}) {
+	identity, _, ok := siteIndexKey(record)
+	if !ok {
+		return
+	}
+	siteId, indexed := s.owners[identity]
+	if !indexed {
+		return
+	}
+	delete(s.owners, identity)
+	site, ok := s.sites[siteId]
+	if !ok {
+		return
+	}
+	switch record.(type) {
+	case *RouterRecord:
+		delete(site.routers, identity)
+	case *HostRecord:
+		delete(site.hosts, identity)
+	case *ProcessRecord:
+		delete(site.processes, identity)
+	}
+	if site.empty() {
+		delete(s.sites, siteId)
+	}
+}
+
+func (s *siteIndex) routers(siteId string) []RouterRecord {
+	routers := []RouterRecord{}
+	if site, ok := s.sites[siteId]; ok {
+		for _, router := range site.routers {
+			routers = append(routers, *router)
+		}
+	}
+	return routers
+}
+
+func (s *siteIndex) hosts(siteId string) []HostRecord {
+	hosts := []HostRecord{}
+	if site, ok := s.sites[siteId]; ok {
+		for _, host := range site.hosts {
+			hosts = append(hosts, *host)
+		}
+	}
+	return hosts
+}
+
+func (s *siteIndex) processes(siteId string) []ProcessRecord {
+	processes := []ProcessRecord{}
+	if site, ok := s.sites[siteId]; ok {
+		for _, process := range site.processes {
+			processes = append(processes, *process)
+		}
+	}
+	return processes
+}
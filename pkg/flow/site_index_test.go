@@ -0,0 +1,46 @@
+package flow
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSiteIndex(t *testing.T) {
+	index := newSiteIndex()
+	router := &RouterRecord{Base: Base{Identity: "router:0"}}
+	host := &HostRecord{Base: Base{Identity: "host:0", Parent: "site:0"}}
+	process := &ProcessRecord{Base: Base{Identity: "process:0", Parent: "site:0"}}
+	other := &ProcessRecord{Base: Base{Identity: "process:1", Parent: "site:1"}}
+
+	index.add(router)
+	index.add(host)
+	index.add(process)
+	index.add(other)
+	index.add(&LinkRecord{Base: Base{Identity: "link:0", Parent: "router:0"}})
+	assert.Equal(t, len(index.routers("site:0")), 0)
+	assert.Equal(t, len(index.hosts("site:0")), 1)
+	assert.Equal(t, len(index.processes("site:0")), 1)
+	assert.Equal(t, len(index.processes("site:1")), 1)
+
+	// routers are indexed once their parent site is known
+	router.Parent = "site:0"
+	index.add(router)
+	assert.Equal(t, len(index.routers("site:0")), 1)
+
+	// a record moving to another site is removed from the previous one
+	process.Parent = "site:1"
+	index.add(process)
+	assert.Equal(t, len(index.processes("site:0")), 0)
+	assert.Equal(t, len(index.processes("site:1")), 2)
+
+	index.remove(process)
+	index.remove(other)
+	assert.Equal(t, len(index.processes("site:1")), 0)
+	assert.Assert(t, index.sites["site:1"] == nil)
+
+	index.remove(host)
+	index.remove(router)
+	assert.Equal(t, len(index.sites), 0)
+	assert.Equal(t, len(index.owners), 0)
+}
@@ -0,0 +1,327 @@
+package flow
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SiteEventLinkEstablished  = "link-established"
+	SiteEventLinkLost         = "link-lost"
+	SiteEventRouterRestarted  = "router-restarted"
+	SiteEventServiceExposed   = "service-exposed"
+	SiteEventServiceUnexposed = "service-unexposed"
+	SiteEventTokenRedeemed    = "token-redeemed"
+	SiteEventSourceStale      = "source-stale"
+	SiteEventSourceRecovered  = "source-recovered"
+	SiteEventClockSkew        = "clock-skew"
+	SiteEventClockSynced      = "clock-synced"
+	// SiteEventLog is a log event of a router or controller that is not
+	// one of the other types
+	SiteEventLog = "log"
+
+	maxSiteEvents      = 1000
+	siteEventRetention = 7 * 24 * time.Hour
+)
+
+// SiteEvent is a significant change of the network, kept after the records
+// it is about are gone so that the changes of the past can be looked up
+type SiteEvent struct {
+	Time     uint64 `json:"time"`
+	Type     string `json:"type"`
+	SiteId   string `json:"siteId,omitempty"`
+	SiteName string `json:"siteName,omitempty"`
+	Subject  string `json:"subject,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	// Key identifies what the event is about, the records received again
+	// after a restart of the collector not being recorded twice
+	Key string `json:"key"`
+}
+
+// SiteEventStore keeps the site events across restarts of the collector
+type SiteEventStore interface {
+	Load() ([]SiteEvent, error)
+	Save(events []SiteEvent) error
+}
+
+// siteEventLog holds the site events, oldest first
+type siteEventLog struct {
+	events []SiteEvent
+	// last is the type of the latest event of each key
+	last map[string]string
+	// routers are the identities of the routers by name and lostRouters
+	// the names of the routers gone by site, a router coming back under
+	// either being a restart
+	routers     map[string]string
+	lostRouters map[string]string
+	store       SiteEventStore
+	dirty       bool
+	saves       chan []SiteEvent
+}
+
+func newSiteEventLog(store SiteEventStore) *siteEventLog {
+	l := &siteEventLog{
+		last:        map[string]string{},
+		routers:     map[string]string{},
+		lostRouters: map[string]string{},
+		store:       store,
+	}
+	if store == nil {
+		return l
+	}
+	events, err := store.Load()
+	if err != nil {
+		logger.Errorf("Unable to load the site events: %s", err)
+	}
+	for _, event := range events {
+		l.add(event)
+	}
+	l.dirty = false
+	l.saves = make(chan []SiteEvent, 1)
+	go l.saveLoop()
+	return l
+}
+
+// add records the event unless the latest event of its key has the same
+// type, returning whether it was recorded
+func (l *siteEventLog) add(event SiteEvent) bool {
+	if last, ok := l.last[event.Key]; ok && last == event.Type {
+		return false
+	}
+	l.last[event.Key] = event.Type
+	l.events = append(l.events, event)
+	// the events of the records received out of order are kept sorted
+	for i := len(l.events) - 1; i > 0 && l.events[i].Time < l.events[i-1].Time; i-- {
+		l.events[i], l.events[i-1] = l.events[i-1], l.events[i]
+	}
+	if len(l.events) > maxSiteEvents {
+		l.events = l.events[len(l.events)-maxSiteEvents:]
+	}
+	l.dirty = true
+	return true
+}
+
+// age removes the events past their retention
+func (l *siteEventLog) age(now time.Time) {
+	oldest := uint64(now.Add(-siteEventRetention).UnixNano()) / uint64(time.Microsecond)
+	i := sort.Search(len(l.events), func(i int) bool { return l.events[i].Time >= oldest })
+	if i == 0 {
+		return
+	}
+	l.events = append([]SiteEvent{}, l.events[i:]...)
+	l.last = map[string]string{}
+	for _, event := range l.events {
+		l.last[event.Key] = event.Type
+	}
+	l.dirty = true
+}
+
+// flush hands the events over to be saved when they changed, the saving
+// being done away from the update loop
+func (l *siteEventLog) flush() {
+	if !l.dirty || l.store == nil {
+		return
+	}
+	events := append([]SiteEvent{}, l.events...)
+	select {
+	case <-l.saves:
+	default:
+	}
+	l.saves <- events
+	l.dirty = false
+}
+
+func (l *siteEventLog) saveLoop() {
+	for events := range l.saves {
+		if err := l.store.Save(events); err != nil {
+			logger.Errorf("Unable to save the site events: %s", err)
+		}
+	}
+}
+
+type siteEventFilter struct {
+	types   map[string]bool
+	site    string
+	subject string
+	start   uint64
+	end     uint64
+	limit   int
+}
+
+func getSiteEventFilter(u *url.URL) siteEventFilter {
+	filter := siteEventFilter{limit: -1}
+	query := u.Query()
+	for _, value := range query["type"] {
+		for _, eventType := range strings.Split(value, ",") {
+			if filter.types == nil {
+				filter.types = map[string]bool{}
+			}
+			filter.types[strings.TrimSpace(eventType)] = true
+		}
+	}
+	filter.site = query.Get("site")
+	filter.subject = query.Get("subject")
+	if start, err := strconv.ParseUint(query.Get("timeRangeStart"), 10, 64); err == nil {
+		filter.start = start
+	}
+	if end, err := strconv.ParseUint(query.Get("timeRangeEnd"), 10, 64); err == nil {
+		filter.end = end
+	}
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit >= 0 {
+		filter.limit = limit
+	}
+	return filter
+}
+
+func (f siteEventFilter) matches(event SiteEvent) bool {
+	if f.types != nil && !f.types[event.Type] {
+		return false
+	}
+	if f.site != "" && f.site != event.SiteId && f.site != event.SiteName {
+		return false
+	}
+	if f.subject != "" && f.subject != event.Subject {
+		return false
+	}
+	if event.Time < f.start || (f.end != 0 && event.Time > f.end) {
+		return false
+	}
+	return true
+}
+
+// query returns the events matching the filter, newest first, along with
+// the number of matching events before the limit
+func (l *siteEventLog) query(filter siteEventFilter) ([]SiteEvent, int) {
+	events := []SiteEvent{}
+	total := 0
+	for i := len(l.events) - 1; i >= 0; i-- {
+		if !filter.matches(l.events[i]) {
+			continue
+		}
+		total++
+		if filter.limit < 0 || len(events) < filter.limit {
+			events = append(events, l.events[i])
+		}
+	}
+	return events, total
+}
+
+func microsOrNow(t uint64) uint64 {
+	if t == 0 {
+		return uint64(time.Now().UnixNano()) / uint64(time.Microsecond)
+	}
+	return t
+}
+
+func (fc *FlowCollector) siteEventSite(siteId string) (string, string) {
+	if router, ok := fc.Routers[siteId]; ok {
+		siteId = router.Parent
+	}
+	if site, ok := fc.Sites[siteId]; ok && site.Name != nil {
+		return siteId, *site.Name
+	}
+	return siteId, ""
+}
+
+// recordSiteEvent records the links, routers and addresses coming and going
+func (fc *FlowCollector) recordSiteEvent(record interface{}, added bool) {
+	switch r := record.(type) {
+	case *LinkRecord:
+		event := SiteEvent{
+			Type:    SiteEventLinkLost,
+			Time:    microsOrNow(r.EndTime),
+			Subject: derefString(r.Name),
+			Detail:  derefString(r.Direction),
+			Key:     r.Identity,
+		}
+		if added {
+			event.Type, event.Time = SiteEventLinkEstablished, microsOrNow(r.StartTime)
+		}
+		event.SiteId, event.SiteName = fc.siteEventSite(r.Parent)
+		fc.siteEvents.add(event)
+	case *RouterRecord:
+		name := derefString(r.Name)
+		if !added {
+			fc.siteEvents.lostRouters[r.Parent] = name
+			return
+		}
+		previous, known := fc.siteEvents.routers[name]
+		fc.siteEvents.routers[name] = r.Identity
+		lost, replaced := fc.siteEvents.lostRouters[r.Parent]
+		delete(fc.siteEvents.lostRouters, r.Parent)
+		detail := ""
+		switch {
+		case known && previous == r.Identity:
+			// the same router heard from again
+			return
+		case known:
+			detail = "previous identity " + previous
+		case replaced:
+			detail = "replaced router " + lost
+		default:
+			return
+		}
+		event := SiteEvent{
+			Type:    SiteEventRouterRestarted,
+			Time:    microsOrNow(r.StartTime),
+			Subject: name,
+			Detail:  detail,
+			Key:     r.Identity,
+		}
+		event.SiteId, event.SiteName = fc.siteEventSite(r.Parent)
+		fc.siteEvents.add(event)
+	case *VanAddressRecord:
+		event := SiteEvent{
+			Type:    SiteEventServiceUnexposed,
+			Time:    microsOrNow(r.EndTime),
+			Subject: r.Name,
+			Detail:  r.Protocol,
+			// the addresses get a new identity when created again
+			Key: "address/" + r.Name,
+		}
+		if added {
+			event.Type, event.Time = SiteEventServiceExposed, microsOrNow(r.StartTime)
+		}
+		fc.siteEvents.add(event)
+	}
+}
+
+// recordLogEvent records the log events, their text being the type of the
+// site event followed by its detail for the known types
+func (fc *FlowCollector) recordLogEvent(logEvent LogEventRecord) {
+	event := SiteEvent{
+		Type:   SiteEventLog,
+		Time:   microsOrNow(logEvent.StartTime),
+		Detail: derefString(logEvent.LogText),
+		Key:    logEvent.Identity,
+	}
+	if eventType, detail, ok := strings.Cut(event.Detail, ": "); ok && eventType == SiteEventTokenRedeemed {
+		event.Type, event.Detail = eventType, detail
+	}
+	event.SiteId, event.SiteName = fc.siteEventSite(logEvent.Parent)
+	fc.siteEvents.add(event)
+}
+
+func (fc *FlowCollector) serveSiteEvents(request ApiRequest) ApiResponse {
+	response := ApiResponse{Status: http.StatusOK}
+	events, total := fc.siteEvents.query(getSiteEventFilter(request.Request.URL))
+	body, err := json.Marshal(Payload{
+		Results:        events,
+		Status:         "",
+		Count:          len(events),
+		TimeRangeCount: total,
+		TotalCount:     len(fc.siteEvents.events),
+	})
+	if err != nil {
+		response.Status = http.StatusInternalServerError
+		return response
+	}
+	s := string(body)
+	response.Body = &s
+	return response
+}
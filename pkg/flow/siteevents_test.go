@@ -0,0 +1,155 @@
+package flow
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+type memSiteEventStore struct {
+	events []SiteEvent
+	saved  chan []SiteEvent
+}
+
+func (s *memSiteEventStore) Load() ([]SiteEvent, error) {
+	return s.events, nil
+}
+
+func (s *memSiteEventStore) Save(events []SiteEvent) error {
+	s.saved <- events
+	return nil
+}
+
+func TestRecordSiteEvents(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{})
+	fc.Sites["site-1"] = &SiteRecord{Base: Base{Identity: "site-1"}, Name: &[]string{"west"}[0]}
+	fc.Routers["router-1"] = &RouterRecord{Base: Base{Identity: "router-1", Parent: "site-1"}}
+	name, direction := "east-link", "outgoing"
+
+	link := &LinkRecord{Base: Base{Identity: "link-1", Parent: "router-1", StartTime: 100}, Name: &name, Direction: &direction}
+	fc.recordSiteEvent(link, true)
+	// the same record heard again after a restart of the collector
+	fc.recordSiteEvent(link, true)
+	link.EndTime = 200
+	fc.recordSiteEvent(link, false)
+	fc.recordSiteEvent(&VanAddressRecord{Base: Base{Identity: "address-1", StartTime: 150}, Name: "backend", Protocol: "tcp"}, true)
+
+	routerName := "0/west-skupper-router-1"
+	fc.recordSiteEvent(&RouterRecord{Base: Base{Identity: "router-2", Parent: "site-1", StartTime: 300}, Name: &routerName}, true)
+	fc.recordSiteEvent(&RouterRecord{Base: Base{Identity: "router-2", Parent: "site-1", StartTime: 300}, Name: &routerName}, true)
+	fc.recordSiteEvent(&RouterRecord{Base: Base{Identity: "router-3", Parent: "site-1", StartTime: 400}, Name: &routerName}, true)
+
+	text := SiteEventTokenRedeemed + ": Token west-token redeemed at https://claims"
+	fc.recordLogEvent(LogEventRecord{Base: Base{Identity: "log-1", Parent: "site-1", StartTime: 500}, LogText: &text})
+
+	events, total := fc.siteEvents.query(siteEventFilter{limit: -1})
+	assert.Equal(t, total, 5)
+	types := []string{}
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	assert.DeepEqual(t, types, []string{
+		SiteEventTokenRedeemed,
+		SiteEventRouterRestarted,
+		SiteEventLinkLost,
+		SiteEventServiceExposed,
+		SiteEventLinkEstablished,
+	})
+	assert.Equal(t, events[0].Detail, "Token west-token redeemed at https://claims")
+	assert.Equal(t, events[0].SiteName, "west")
+	assert.Equal(t, events[1].Detail, "previous identity router-2")
+	assert.Equal(t, events[2].SiteId, "site-1")
+	assert.Equal(t, events[2].SiteName, "west")
+	assert.Equal(t, events[2].Subject, "east-link")
+}
+
+func TestQuerySiteEvents(t *testing.T) {
+	l := newSiteEventLog(nil)
+	l.add(SiteEvent{Time: 100, Type: SiteEventLinkEstablished, SiteId: "site-1", SiteName: "west", Subject: "east", Key: "link-1"})
+	l.add(SiteEvent{Time: 200, Type: SiteEventServiceExposed, Subject: "backend", Key: "address/backend"})
+	l.add(SiteEvent{Time: 300, Type: SiteEventLinkLost, SiteId: "site-1", SiteName: "west", Subject: "east", Key: "link-1"})
+	l.add(SiteEvent{Time: 400, Type: SiteEventServiceUnexposed, Subject: "backend", Key: "address/backend"})
+
+	query := func(target string) []uint64 {
+		events, _ := l.query(getSiteEventFilter(httptest.NewRequest("GET", target, nil).URL))
+		times := []uint64{}
+		for _, event := range events {
+			times = append(times, event.Time)
+		}
+		return times
+	}
+	assert.DeepEqual(t, query("/api/v1alpha1/events/"), []uint64{400, 300, 200, 100})
+	assert.DeepEqual(t, query("/api/v1alpha1/events/?type=link-lost,link-established"), []uint64{300, 100})
+	assert.DeepEqual(t, query("/api/v1alpha1/events/?site=west"), []uint64{300, 100})
+	assert.DeepEqual(t, query("/api/v1alpha1/events/?subject=backend"), []uint64{400, 200})
+	assert.DeepEqual(t, query("/api/v1alpha1/events/?timeRangeStart=150&timeRangeEnd=350"), []uint64{300, 200})
+	assert.DeepEqual(t, query("/api/v1alpha1/events/?limit=1"), []uint64{400})
+
+	events, total := l.query(siteEventFilter{limit: 1})
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, total, 4)
+}
+
+func TestAgeSiteEvents(t *testing.T) {
+	now := time.Now()
+	micros := func(t time.Time) uint64 {
+		return uint64(t.UnixNano()) / uint64(time.Microsecond)
+	}
+	store := &memSiteEventStore{
+		events: []SiteEvent{
+			{Time: micros(now.Add(-8 * 24 * time.Hour)), Type: SiteEventLinkEstablished, Key: "link-1"},
+			{Time: micros(now.Add(-time.Hour)), Type: SiteEventLinkLost, Key: "link-1"},
+		},
+		saved: make(chan []SiteEvent, 1),
+	}
+	l := newSiteEventLog(store)
+	assert.Equal(t, len(l.events), 2)
+	l.flush()
+	assert.Equal(t, len(store.saved), 0)
+
+	l.age(now)
+	assert.Equal(t, len(l.events), 1)
+	assert.Equal(t, l.events[0].Type, SiteEventLinkLost)
+	// the lost link is still known after the establishment aged out
+	assert.Assert(t, !l.add(SiteEvent{Time: micros(now), Type: SiteEventLinkLost, Key: "link-1"}))
+
+	l.flush()
+	select {
+	case saved := <-store.saved:
+		assert.Equal(t, len(saved), 1)
+	case <-time.After(5 * time.Second):
+		t.Fatal("site events not saved")
+	}
+
+	for i := 0; i < maxSiteEvents+10; i++ {
+		l.add(SiteEvent{Time: micros(now), Type: SiteEventLog, Key: "log-" + strconv.Itoa(i)})
+	}
+	assert.Equal(t, len(l.events), maxSiteEvents)
+}
+
+func TestLogEventRoundtrip(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{})
+	controller := &FlowController{origin: "site-1", logEventOutgoing: make(chan *LogEventRecord, 1)}
+	RecordLogEvent(controller, SiteEventTokenRedeemed, "Token west redeemed at https://claims")
+	msg, err := encodeLogEvent(<-controller.logEventOutgoing)
+	assert.Assert(t, err)
+	records := decode(msg)
+	assert.Equal(t, len(records), 1)
+	logEvent, ok := records[0].(LogEventRecord)
+	assert.Assert(t, ok)
+	fc.recordLogEvent(logEvent)
+
+	response := fc.serveSiteEvents(ApiRequest{Request: httptest.NewRequest("GET", "/api/v1alpha1/events/?type=token-redeemed", nil)})
+	assert.Equal(t, response.Status, 200)
+	payload := struct {
+		Results []SiteEvent `json:"results"`
+	}{}
+	assert.Assert(t, json.Unmarshal([]byte(*response.Body), &payload))
+	assert.Equal(t, len(payload.Results), 1)
+	assert.Equal(t, payload.Results[0].SiteId, "site-1")
+	assert.Equal(t, payload.Results[0].Detail, "Token west redeemed at https://claims")
+}
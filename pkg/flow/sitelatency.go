@@ -0,0 +1,117 @@
+package flow
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func (fc *FlowCollector) siteName(siteId string) *string {
+	if site, ok := fc.Sites[siteId]; ok && site.Name != nil {
+		return site.Name
+	}
+	return nil
+}
+
+// siteLatencyMetricLabels returns the labels of the metrics of a latency,
+// nil until both of its sites are known
+func (fc *FlowCollector) siteLatencyMetricLabels(latency *SiteLatencyRecord) prometheus.Labels {
+	if latency.SourceName == nil || latency.DestinationName == nil {
+		return nil
+	}
+	return prometheus.Labels{
+		"sourceSite": *latency.SourceName + "@_@" + latency.Parent,
+		"destSite":   *latency.DestinationName + "@_@" + *latency.DestinationId,
+	}
+}
+
+// updateSiteLatency keeps the latency between two sites measured by the
+// probes of the source site. The round trip times of the probes replied to
+// and the probes lost since the previous update are added to the metrics.
+func (fc *FlowCollector) updateSiteLatency(latency SiteLatencyRecord) {
+	current, ok := fc.SiteLatencies[latency.Identity]
+	if latency.EndTime > 0 {
+		if ok {
+			current.EndTime = latency.EndTime
+			fc.removeSiteLatency(current)
+		}
+		return
+	}
+	if !ok {
+		if latency.StartTime == 0 || latency.DestinationId == nil {
+			return
+		}
+		current = &SiteLatencyRecord{
+			Base:          latency.Base,
+			DestinationId: latency.DestinationId,
+		}
+		fc.addRecord(current)
+	}
+	current.Source = latency.Source
+	current.lastUpdate = uint64(time.Now().UnixNano()) / uint64(time.Microsecond)
+	if current.SourceName == nil {
+		current.SourceName = fc.siteName(current.Parent)
+	}
+	if current.DestinationName == nil {
+		current.DestinationName = fc.siteName(*current.DestinationId)
+	}
+	var sent, lost uint64
+	if current.ProbesSent != nil {
+		sent = *current.ProbesSent
+	}
+	if current.ProbesLost != nil {
+		lost = *current.ProbesLost
+	}
+	if latency.ProbesSent != nil && latency.ProbesLost != nil && *latency.ProbesSent >= sent {
+		if fc.metrics != nil && current.metricLabels == nil {
+			current.metricLabels = fc.siteLatencyMetricLabels(current)
+		}
+		if current.metricLabels != nil {
+			replied := *latency.ProbesSent - *latency.ProbesLost
+			if latency.Latency != nil && replied > sent-lost {
+				fc.metrics.siteProbeLatency.With(current.metricLabels).Observe(float64(*latency.Latency))
+			}
+			if *latency.ProbesLost > lost {
+				fc.metrics.siteProbesLost.With(current.metricLabels).Add(float64(*latency.ProbesLost - lost))
+			}
+		}
+		current.ProbesSent = latency.ProbesSent
+		current.ProbesLost = latency.ProbesLost
+	}
+	if latency.Latency != nil {
+		current.Latency = latency.Latency
+	}
+}
+
+func (fc *FlowCollector) removeSiteLatency(latency *SiteLatencyRecord) {
+	fc.deleteRecord(latency)
+	if fc.metrics != nil && latency.metricLabels != nil {
+		fc.metrics.siteProbeLatency.Delete(latency.metricLabels)
+		fc.metrics.siteProbesLost.Delete(latency.metricLabels)
+	}
+}
+
+// endSiteLatencies removes the latencies from and to a site that ended
+func (fc *FlowCollector) endSiteLatencies(siteId string, endTime uint64) {
+	for _, latency := range fc.SiteLatencies {
+		if latency.Parent == siteId || *latency.DestinationId == siteId {
+			latency.EndTime = endTime
+			fc.removeSiteLatency(latency)
+		}
+	}
+}
+
+// ageSiteLatencies removes the latencies no longer updated since the time
+// given, as when the probes of their source site are disabled
+func (fc *FlowCollector) ageSiteLatencies(age uint64) {
+	now := uint64(time.Now().UnixNano()) / uint64(time.Microsecond)
+	aged := 0
+	for _, latency := range fc.SiteLatencies {
+		if latency.lastUpdate < age {
+			latency.EndTime = now
+			fc.removeSiteLatency(latency)
+			aged++
+		}
+	}
+	fc.countPurged(SiteLatency, aged)
+}
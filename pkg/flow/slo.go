@@ -0,0 +1,504 @@
+package flow
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/skupperproject/skupper/api/types"
+)
+
+const (
+	SloObjectiveLatency = "latency"
+	SloObjectiveErrors  = "errors"
+
+	SloSourceAnnotation = "annotation"
+	SloSourceApi        = "api"
+
+	// the compliance is computed over sloWindow in buckets of sloInterval,
+	// the burn rate also being computed over sloFastWindow so that a fast
+	// burn is noticed before it shows in the compliance
+	sloInterval   = time.Minute
+	sloWindow     = time.Hour
+	sloFastWindow = 5 * time.Minute
+	// the windows as labelled in the burn rate metric
+	sloWindowLabel     = "1h"
+	sloFastWindowLabel = "5m"
+
+	maxSloBodySize = 4096
+)
+
+// AddressSlo declares the objectives of the flows sent to an address, an
+// objective being the fraction of the flows to satisfy it, as in 0.99
+type AddressSlo struct {
+	Address string `json:"address"`
+	// Latency is the threshold, in microseconds, of the latency of the
+	// flows counted as good for the latency objective
+	Latency          uint64  `json:"latency,omitempty"`
+	LatencyObjective float64 `json:"latencyObjective,omitempty"`
+	// ErrorObjective is the fraction of the flows to end without an error
+	ErrorObjective float64 `json:"errorObjective,omitempty"`
+	Source         string  `json:"source,omitempty"`
+}
+
+func (s AddressSlo) Validate() error {
+	if s.Address == "" {
+		return fmt.Errorf("address is required")
+	}
+	if s.LatencyObjective == 0 && s.ErrorObjective == 0 {
+		return fmt.Errorf("at least one of latencyObjective and errorObjective is required")
+	}
+	for name, objective := range map[string]float64{"latencyObjective": s.LatencyObjective, "errorObjective": s.ErrorObjective} {
+		if objective < 0 || objective >= 1 {
+			return fmt.Errorf("%s must be between 0 and 1, excluded", name)
+		}
+	}
+	if s.LatencyObjective != 0 && s.Latency == 0 {
+		return fmt.Errorf("latency is required with latencyObjective")
+	}
+	return nil
+}
+
+// SloFromAnnotations returns the objectives declared by the annotations of
+// a service for its address, nil when it declares none
+func SloFromAnnotations(address string, annotations map[string]string) (*AddressSlo, error) {
+	slo := AddressSlo{Address: address, Source: SloSourceAnnotation}
+	found := false
+	if value, ok := annotations[types.SloLatencyAnnotation]; ok {
+		latency, err := time.ParseDuration(value)
+		if err != nil || latency < time.Microsecond {
+			return nil, fmt.Errorf("invalid %s %q, expected a duration as in 250ms", types.SloLatencyAnnotation, value)
+		}
+		slo.Latency = uint64(latency / time.Microsecond)
+		found = true
+	}
+	for annotation, objective := range map[string]*float64{
+		types.SloLatencyObjectiveAnnotation: &slo.LatencyObjective,
+		types.SloErrorObjectiveAnnotation:   &slo.ErrorObjective,
+	} {
+		if value, ok := annotations[annotation]; ok {
+			v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q, expected a fraction as in 0.99", annotation, value)
+			}
+			*objective = v
+			found = true
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	if err := slo.Validate(); err != nil {
+		return nil, err
+	}
+	return &slo, nil
+}
+
+// SloStore keeps the objectives declared through the api across restarts
+// of the collector
+type SloStore interface {
+	Load() ([]AddressSlo, error)
+	Save(slos []AddressSlo) error
+}
+
+// SloObjectiveStatus is the compliance of the flows of an address with one
+// of its objectives, the burn rate being the pace at which the error
+// budget is spent, 1 spending it exactly over the window
+type SloObjectiveStatus struct {
+	Objective    string  `json:"objective"`
+	Target       float64 `json:"target"`
+	Flows        uint64  `json:"flows"`
+	BadFlows     uint64  `json:"badFlows"`
+	Compliance   float64 `json:"compliance"`
+	BurnRate     float64 `json:"burnRate"`
+	FastBurnRate float64 `json:"fastBurnRate"`
+	Met          bool    `json:"met"`
+}
+
+type SloStatus struct {
+	AddressSlo
+	// Window is the duration, in microseconds, the compliance is computed
+	// over
+	Window     uint64               `json:"window"`
+	Objectives []SloObjectiveStatus `json:"objectives"`
+	Met        bool                 `json:"met"`
+}
+
+type sloBucket struct {
+	start        int64
+	latencyFlows uint64
+	slowFlows    uint64
+	flows        uint64
+	errorFlows   uint64
+}
+
+// sloCounts holds the flows of an address by interval, oldest first
+type sloCounts struct {
+	buckets []sloBucket
+}
+
+func (c *sloCounts) add(now time.Time, latency *uint64, threshold uint64, failed bool) {
+	start := now.Truncate(sloInterval).Unix()
+	last := len(c.buckets) - 1
+	if last < 0 || c.buckets[last].start < start {
+		c.buckets = append(c.buckets, sloBucket{start: start})
+		last++
+	}
+	b := &c.buckets[last]
+	b.flows++
+	if failed {
+		b.errorFlows++
+	}
+	if latency != nil {
+		b.latencyFlows++
+		if threshold != 0 && *latency > threshold {
+			b.slowFlows++
+		}
+	}
+	c.age(now)
+}
+
+func (c *sloCounts) age(now time.Time) {
+	oldest := now.Add(-sloWindow).Truncate(sloInterval).Unix()
+	expired := 0
+	for expired < len(c.buckets) && c.buckets[expired].start < oldest {
+		expired++
+	}
+	c.buckets = c.buckets[expired:]
+}
+
+// totals returns the counts of the buckets within the window before now
+func (c *sloCounts) totals(now time.Time, window time.Duration) sloBucket {
+	oldest := now.Add(-window).Truncate(sloInterval).Unix()
+	total := sloBucket{}
+	for _, b := range c.buckets {
+		if b.start < oldest {
+			continue
+		}
+		total.latencyFlows += b.latencyFlows
+		total.slowFlows += b.slowFlows
+		total.flows += b.flows
+		total.errorFlows += b.errorFlows
+	}
+	return total
+}
+
+func burnRate(flows uint64, bad uint64, target float64) float64 {
+	if flows == 0 {
+		return 0
+	}
+	return (float64(bad) / float64(flows)) / (1 - target)
+}
+
+func objectiveStatus(objective string, target float64, flows uint64, bad uint64, fastFlows uint64, fastBad uint64) SloObjectiveStatus {
+	status := SloObjectiveStatus{
+		Objective:    objective,
+		Target:       target,
+		Flows:        flows,
+		BadFlows:     bad,
+		Compliance:   1,
+		BurnRate:     burnRate(flows, bad, target),
+		FastBurnRate: burnRate(fastFlows, fastBad, target),
+	}
+	if flows > 0 {
+		status.Compliance = float64(flows-bad) / float64(flows)
+	}
+	status.Met = status.Compliance >= target
+	return status
+}
+
+func (c *sloCounts) status(slo AddressSlo, now time.Time) SloStatus {
+	status := SloStatus{
+		AddressSlo: slo,
+		Window:     uint64(sloWindow / time.Microsecond),
+		Objectives: []SloObjectiveStatus{},
+		Met:        true,
+	}
+	total, fast := c.totals(now, sloWindow), c.totals(now, sloFastWindow)
+	if slo.LatencyObjective != 0 {
+		status.Objectives = append(status.Objectives, objectiveStatus(SloObjectiveLatency, slo.LatencyObjective, total.latencyFlows, total.slowFlows, fast.latencyFlows, fast.slowFlows))
+	}
+	if slo.ErrorObjective != 0 {
+		status.Objectives = append(status.Objectives, objectiveStatus(SloObjectiveErrors, slo.ErrorObjective, total.flows, total.errorFlows, fast.flows, fast.errorFlows))
+	}
+	for _, objective := range status.Objectives {
+		status.Met = status.Met && objective.Met
+	}
+	return status
+}
+
+// sloTracker counts the flows of the addresses with objectives, those
+// declared through the api taking precedence over the annotated ones
+type sloTracker struct {
+	annotated map[string]AddressSlo
+	declared  map[string]AddressSlo
+	counts    map[string]*sloCounts
+	store     SloStore
+	saves     chan []AddressSlo
+}
+
+func newSloTracker(store SloStore) *sloTracker {
+	t := &sloTracker{
+		annotated: map[string]AddressSlo{},
+		declared:  map[string]AddressSlo{},
+		counts:    map[string]*sloCounts{},
+		store:     store,
+	}
+	if store == nil {
+		return t
+	}
+	slos, err := store.Load()
+	if err != nil {
+		logger.Errorf("Unable to load the address objectives: %s", err)
+	}
+	for _, slo := range slos {
+		if slo.Validate() == nil {
+			slo.Source = SloSourceApi
+			t.declared[slo.Address] = slo
+		}
+	}
+	t.saves = make(chan []AddressSlo, 1)
+	go t.saveLoop()
+	return t
+}
+
+func (t *sloTracker) get(address string) (AddressSlo, bool) {
+	if slo, ok := t.declared[address]; ok {
+		return slo, true
+	}
+	slo, ok := t.annotated[address]
+	return slo, ok
+}
+
+func (t *sloTracker) addresses() []string {
+	addresses := []string{}
+	for address := range t.declared {
+		addresses = append(addresses, address)
+	}
+	for address := range t.annotated {
+		if _, ok := t.declared[address]; !ok {
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// prune drops the counts of the addresses left without objectives
+func (t *sloTracker) prune() []string {
+	pruned := []string{}
+	for address := range t.counts {
+		if _, ok := t.get(address); !ok {
+			delete(t.counts, address)
+			pruned = append(pruned, address)
+		}
+	}
+	return pruned
+}
+
+func (t *sloTracker) save() {
+	if t.store == nil {
+		return
+	}
+	slos := []AddressSlo{}
+	for _, slo := range t.declared {
+		slos = append(slos, slo)
+	}
+	sort.Slice(slos, func(i, j int) bool { return slos[i].Address < slos[j].Address })
+	select {
+	case <-t.saves:
+	default:
+	}
+	t.saves <- slos
+}
+
+func (t *sloTracker) saveLoop() {
+	for slos := range t.saves {
+		if err := t.store.Save(slos); err != nil {
+			logger.Errorf("Unable to save the address objectives: %s", err)
+		}
+	}
+}
+
+// SetAnnotatedSlos replaces the objectives declared by annotations
+func (fc *FlowCollector) SetAnnotatedSlos(slos []AddressSlo) {
+	fc.sloUpdates <- slos
+}
+
+func (fc *FlowCollector) setAnnotatedSlos(slos []AddressSlo) {
+	fc.slos.annotated = map[string]AddressSlo{}
+	for _, slo := range slos {
+		slo.Source = SloSourceAnnotation
+		fc.slos.annotated[slo.Address] = slo
+	}
+	fc.deleteSloMetrics(fc.slos.prune())
+}
+
+// flowFailed reports whether a client flow ended with an error, either
+// its own or a 5xx response of its server
+func (fc *FlowCollector) flowFailed(flow *FlowRecord) bool {
+	if flow.Reason != nil && *flow.Reason != "" {
+		return true
+	}
+	results := []*string{flow.Result}
+	if flow.CounterFlow != nil {
+		if counterFlow, ok := fc.Flows[*flow.CounterFlow]; ok {
+			results = append(results, counterFlow.Result)
+		}
+	}
+	for _, result := range results {
+		if result != nil && strings.HasPrefix(*result, "5") {
+			return true
+		}
+	}
+	return false
+}
+
+// observeSlo counts a client flow that has ended against the objectives
+// of its address
+func (fc *FlowCollector) observeSlo(flow *FlowRecord) {
+	address := fc.flowAddress(flow)
+	slo, ok := fc.slos.get(address)
+	if !ok {
+		return
+	}
+	counts, ok := fc.slos.counts[address]
+	if !ok {
+		counts = &sloCounts{}
+		fc.slos.counts[address] = counts
+	}
+	counts.add(time.Now(), flow.Latency, slo.Latency, fc.flowFailed(flow))
+}
+
+func (fc *FlowCollector) sloStatus(address string, now time.Time) (SloStatus, bool) {
+	slo, ok := fc.slos.get(address)
+	if !ok {
+		return SloStatus{}, false
+	}
+	counts, ok := fc.slos.counts[address]
+	if !ok {
+		counts = &sloCounts{}
+	}
+	counts.age(now)
+	return counts.status(slo, now), true
+}
+
+// updateSloMetrics sets the compliance and burn rate gauges of the
+// addresses with objectives
+func (fc *FlowCollector) updateSloMetrics(now time.Time) {
+	if fc.metrics == nil {
+		return
+	}
+	for _, address := range fc.slos.addresses() {
+		status, _ := fc.sloStatus(address, now)
+		for _, objective := range status.Objectives {
+			labels := prometheus.Labels{"address": address, "objective": objective.Objective}
+			fc.metrics.sloTarget.With(labels).Set(objective.Target)
+			fc.metrics.sloCompliance.With(labels).Set(objective.Compliance)
+			labels["window"] = sloWindowLabel
+			fc.metrics.sloBurnRate.With(labels).Set(objective.BurnRate)
+			labels["window"] = sloFastWindowLabel
+			fc.metrics.sloBurnRate.With(labels).Set(objective.FastBurnRate)
+		}
+	}
+}
+
+func (fc *FlowCollector) deleteSloMetrics(addresses []string) {
+	if fc.metrics == nil {
+		return
+	}
+	for _, address := range addresses {
+		labels := prometheus.Labels{"address": address}
+		fc.metrics.sloTarget.DeletePartialMatch(labels)
+		fc.metrics.sloCompliance.DeletePartialMatch(labels)
+		fc.metrics.sloBurnRate.DeletePartialMatch(labels)
+	}
+}
+
+func decodeAddressSlo(r io.Reader) (AddressSlo, error) {
+	slo := AddressSlo{}
+	data, err := io.ReadAll(io.LimitReader(r, maxSloBodySize+1))
+	if err != nil {
+		return slo, err
+	}
+	if len(data) > maxSloBodySize {
+		return slo, fmt.Errorf("request body exceeds %d bytes", maxSloBodySize)
+	}
+	if err := json.Unmarshal(data, &slo); err != nil {
+		return slo, fmt.Errorf("invalid objectives: %s", err)
+	}
+	return slo, nil
+}
+
+// serveSlos lists the status of the objectives of all the addresses, or
+// gets, declares or removes those of one address
+func (fc *FlowCollector) serveSlos(request ApiRequest) ApiResponse {
+	response := ApiResponse{Status: http.StatusOK}
+	writeJson := func(status int, value interface{}) ApiResponse {
+		body, err := json.Marshal(value)
+		if err != nil {
+			response.Status = http.StatusInternalServerError
+			return response
+		}
+		s := string(body)
+		response.Body = &s
+		response.Status = status
+		return response
+	}
+	writeError := func(status int, err error) ApiResponse {
+		return writeJson(status, map[string]string{"error": err.Error()})
+	}
+	now := time.Now()
+	address, ok := mux.Vars(request.Request)["address"]
+	if !ok {
+		results := []SloStatus{}
+		for _, address := range fc.slos.addresses() {
+			status, _ := fc.sloStatus(address, now)
+			results = append(results, status)
+		}
+		return writeJson(http.StatusOK, Payload{Results: results, Count: len(results), TotalCount: len(results)})
+	}
+	switch request.Request.Method {
+	case http.MethodPut:
+		slo, err := decodeAddressSlo(request.Request.Body)
+		if err != nil {
+			return writeError(http.StatusBadRequest, err)
+		}
+		if slo.Address != "" && slo.Address != address {
+			return writeError(http.StatusBadRequest, fmt.Errorf("address %q does not match the path", slo.Address))
+		}
+		slo.Address, slo.Source = address, SloSourceApi
+		if err := slo.Validate(); err != nil {
+			return writeError(http.StatusBadRequest, err)
+		}
+		fc.slos.declared[address] = slo
+		fc.slos.save()
+		// the counts are kept as the flows are the same, the new
+		// thresholds only applying to the flows to come
+		fc.deleteSloMetrics([]string{address})
+	case http.MethodDelete:
+		if _, ok := fc.slos.declared[address]; !ok {
+			return writeError(http.StatusNotFound, fmt.Errorf("no objectives declared for %s through the api", address))
+		}
+		delete(fc.slos.declared, address)
+		fc.slos.save()
+		fc.deleteSloMetrics([]string{address})
+		fc.slos.prune()
+		if _, ok := fc.slos.get(address); !ok {
+			response.Status = http.StatusNoContent
+			return response
+		}
+	}
+	status, ok := fc.sloStatus(address, now)
+	if !ok {
+		return writeError(http.StatusNotFound, fmt.Errorf("no objectives for %s", address))
+	}
+	return writeJson(http.StatusOK, Payload{Results: status, Count: 1, TotalCount: 1})
+}
@@ -0,0 +1,167 @@
+package flow
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/skupperproject/skupper/api/types"
+	"gotest.tools/assert"
+)
+
+func TestSloFromAnnotations(t *testing.T) {
+	slo, err := SloFromAnnotations("backend", map[string]string{
+		types.SloLatencyAnnotation:          "250ms",
+		types.SloLatencyObjectiveAnnotation: "0.99",
+		types.SloErrorObjectiveAnnotation:   " 0.999 ",
+	})
+	assert.Assert(t, err)
+	assert.DeepEqual(t, *slo, AddressSlo{Address: "backend", Latency: 250000, LatencyObjective: 0.99, ErrorObjective: 0.999, Source: SloSourceAnnotation})
+
+	slo, err = SloFromAnnotations("backend", map[string]string{types.ProxyQualifier: "tcp"})
+	assert.Assert(t, err)
+	assert.Assert(t, slo == nil)
+
+	for expected, annotations := range map[string]map[string]string{
+		"invalid " + types.SloLatencyAnnotation:        {types.SloLatencyAnnotation: "fast"},
+		"invalid " + types.SloErrorObjectiveAnnotation: {types.SloErrorObjectiveAnnotation: "most"},
+		"latency is required":                          {types.SloLatencyObjectiveAnnotation: "0.99"},
+		"errorObjective must be between 0 and 1":       {types.SloErrorObjectiveAnnotation: "99"},
+		"at least one of latencyObjective":             {types.SloLatencyAnnotation: "1s"},
+	} {
+		_, err := SloFromAnnotations("backend", annotations)
+		assert.ErrorContains(t, err, expected)
+	}
+}
+
+func TestSloStatus(t *testing.T) {
+	now := time.Date(2023, 5, 1, 10, 30, 0, 0, time.UTC)
+	slo := AddressSlo{Address: "backend", Latency: 1000, LatencyObjective: 0.9, ErrorObjective: 0.99}
+	counts := &sloCounts{}
+	fast, slow := uint64(500), uint64(5000)
+	// 20 minutes ago: 10 good flows
+	for i := 0; i < 10; i++ {
+		counts.add(now.Add(-20*time.Minute), &fast, slo.Latency, false)
+	}
+	// the last minute: 1 slow flow and 1 failed flow without latency
+	counts.add(now, &slow, slo.Latency, false)
+	counts.add(now, nil, slo.Latency, true)
+
+	status := counts.status(slo, now)
+	assert.Equal(t, len(status.Objectives), 2)
+	latency, errors := status.Objectives[0], status.Objectives[1]
+	assert.Equal(t, latency.Objective, SloObjectiveLatency)
+	assert.Equal(t, latency.Flows, uint64(11))
+	assert.Equal(t, latency.BadFlows, uint64(1))
+	assert.Assert(t, latency.Met)
+	// 1 bad flow in 11 against a budget of 10%
+	assert.Assert(t, latency.BurnRate > 0.9 && latency.BurnRate < 0.91, latency.BurnRate)
+	// 1 bad flow in 1 over the last 5 minutes
+	assert.Assert(t, latency.FastBurnRate > 9.99 && latency.FastBurnRate < 10.01, latency.FastBurnRate)
+
+	assert.Equal(t, errors.Objective, SloObjectiveErrors)
+	assert.Equal(t, errors.Flows, uint64(12))
+	assert.Equal(t, errors.BadFlows, uint64(1))
+	assert.Assert(t, !errors.Met)
+	assert.Assert(t, !status.Met)
+
+	// the flows age out of the window
+	counts.age(now.Add(2 * time.Hour))
+	status = counts.status(slo, now.Add(2*time.Hour))
+	assert.Equal(t, status.Objectives[0].Flows, uint64(0))
+	assert.Equal(t, status.Objectives[0].Compliance, 1.0)
+	assert.Assert(t, status.Met)
+}
+
+func TestObserveSlo(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{})
+	fc.VanAddresses["va-1"] = &VanAddressRecord{Base: Base{Identity: "va-1"}, Name: "backend"}
+	fc.setAnnotatedSlos([]AddressSlo{{Address: "backend", ErrorObjective: 0.99}})
+
+	refused, code := "connection refused", "503"
+	fc.Flows["server-1"] = &FlowRecord{Base: Base{Identity: "server-1"}, Result: &code}
+	counterFlow := "server-1"
+	fc.observeSlo(&FlowRecord{Base: Base{Identity: "client-1"}, addressId: "va-1"})
+	fc.observeSlo(&FlowRecord{Base: Base{Identity: "client-2"}, addressId: "va-1", Reason: &refused})
+	fc.observeSlo(&FlowRecord{Base: Base{Identity: "client-3"}, addressId: "va-1", CounterFlow: &counterFlow})
+	// no objectives for other addresses
+	fc.observeSlo(&FlowRecord{Base: Base{Identity: "client-4"}})
+
+	status, ok := fc.sloStatus("backend", time.Now())
+	assert.Assert(t, ok)
+	assert.Equal(t, status.Objectives[0].Flows, uint64(3))
+	assert.Equal(t, status.Objectives[0].BadFlows, uint64(2))
+	assert.Equal(t, len(fc.slos.counts), 1)
+
+	fc.setAnnotatedSlos(nil)
+	assert.Equal(t, len(fc.slos.counts), 0)
+}
+
+type memSloStore struct {
+	saved chan []AddressSlo
+}
+
+func (s *memSloStore) Load() ([]AddressSlo, error) {
+	return []AddressSlo{{Address: "db", ErrorObjective: 0.999}}, nil
+}
+
+func (s *memSloStore) Save(slos []AddressSlo) error {
+	s.saved <- slos
+	return nil
+}
+
+func TestServeSlos(t *testing.T) {
+	store := &memSloStore{saved: make(chan []AddressSlo, 1)}
+	fc := NewFlowCollector(FlowCollectorSpec{Slos: store})
+	fc.setAnnotatedSlos([]AddressSlo{{Address: "backend", ErrorObjective: 0.99}})
+
+	serve := func(method string, address string, body string) ApiResponse {
+		r := httptest.NewRequest(method, "/api/v1alpha1/slos/"+address, strings.NewReader(body))
+		if address != "" {
+			r = mux.SetURLVars(r, map[string]string{"address": address})
+		}
+		return fc.serveSlos(ApiRequest{Request: r})
+	}
+	results := func(response ApiResponse, value interface{}) {
+		assert.Equal(t, response.Status, http.StatusOK)
+		payload := struct {
+			Results interface{} `json:"results"`
+		}{Results: value}
+		assert.Assert(t, json.Unmarshal([]byte(*response.Body), &payload))
+	}
+
+	list := []SloStatus{}
+	results(serve(http.MethodGet, "", ""), &list)
+	assert.Equal(t, len(list), 2)
+	assert.Equal(t, list[0].Address, "backend")
+	assert.Equal(t, list[0].Source, SloSourceAnnotation)
+	assert.Equal(t, list[1].Address, "db")
+	assert.Equal(t, list[1].Source, SloSourceApi)
+
+	// the api takes precedence over the annotations
+	status := SloStatus{}
+	results(serve(http.MethodPut, "backend", `{"latency":250000,"latencyObjective":0.95}`), &status)
+	assert.Equal(t, status.Source, SloSourceApi)
+	assert.Equal(t, status.Objectives[0].Objective, SloObjectiveLatency)
+	select {
+	case saved := <-store.saved:
+		assert.Equal(t, len(saved), 2)
+	case <-time.After(5 * time.Second):
+		t.Fatal("objectives not saved")
+	}
+
+	assert.Equal(t, serve(http.MethodPut, "backend", `{"latencyObjective":0.95}`).Status, http.StatusBadRequest)
+	assert.Equal(t, serve(http.MethodPut, "backend", `{"address":"other","errorObjective":0.95}`).Status, http.StatusBadRequest)
+	assert.Equal(t, serve(http.MethodPut, "backend", `not json`).Status, http.StatusBadRequest)
+
+	// back to the annotated objectives once removed
+	results(serve(http.MethodDelete, "backend", ""), &status)
+	assert.Equal(t, status.Source, SloSourceAnnotation)
+	assert.Equal(t, serve(http.MethodDelete, "db", "").Status, http.StatusNoContent)
+	assert.Equal(t, serve(http.MethodDelete, "db", "").Status, http.StatusNotFound)
+	assert.Equal(t, serve(http.MethodGet, "db", "").Status, http.StatusNotFound)
+}
@@ -0,0 +1,105 @@
+package flow
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultStaleSourceAfter = 30 * time.Second
+	minStaleSourceAfter     = 5 * time.Second
+	// defaultStaleSourceGrace has the event sources purged after a minute
+	// of silence by default
+	defaultStaleSourceGrace = 30 * time.Second
+)
+
+func getStaleSourceAfter(after time.Duration) time.Duration {
+	if after == 0 {
+		return defaultStaleSourceAfter
+	}
+	if after < minStaleSourceAfter {
+		return minStaleSourceAfter
+	}
+	return after
+}
+
+func getStaleSourceGrace(grace time.Duration) time.Duration {
+	if grace <= 0 {
+		return defaultStaleSourceGrace
+	}
+	return grace
+}
+
+// staleSourceEvent records an event source going stale or being heard
+// from again
+func (fc *FlowCollector) staleSourceEvent(source *EventSourceRecord, silence uint64) {
+	event := SiteEvent{
+		Type:    SiteEventSourceRecovered,
+		Time:    source.LastHeard,
+		Subject: source.Identity,
+		Key:     "source/" + source.Identity,
+	}
+	if source.Beacon != nil {
+		event.Detail = source.Beacon.SourceType
+	}
+	if router, ok := fc.Routers[source.Identity]; ok && router.Name != nil {
+		event.Subject = *router.Name
+	}
+	if source.Stale {
+		event.Type = SiteEventSourceStale
+		event.Time = source.LastHeard + silence
+		event.Detail = fmt.Sprintf("%s not heard from in %s", event.Detail, time.Duration(silence)*time.Microsecond)
+	}
+	event.SiteId, event.SiteName = fc.siteEventSite(source.Identity)
+	fc.siteEvents.add(event)
+}
+
+// markStaleSources marks the event sources whose beacons and heartbeats
+// stopped, along with their routers and sites, until they are heard from
+// again or purged once past the grace period
+func (fc *FlowCollector) markStaleSources(now uint64) {
+	for _, source := range fc.eventSources {
+		silence := uint64(0)
+		if now > source.LastHeard {
+			silence = now - source.LastHeard
+		}
+		stale := silence > uint64(fc.staleSourceAfter.Microseconds())
+		if stale == source.Stale {
+			continue
+		}
+		source.Stale = stale
+		if stale {
+			logger.Warnf("Event source %s not heard from in %s, marked stale", source.Identity, time.Duration(silence)*time.Microsecond)
+		}
+		fc.staleSourceEvent(&source.EventSourceRecord, silence)
+	}
+	staleRouters := map[string]int{}
+	routers := map[string]int{}
+	for _, router := range fc.Routers {
+		source, ok := fc.eventSources[router.Identity]
+		router.Stale = ok && source.Stale
+		routers[router.Parent]++
+		if router.Stale {
+			staleRouters[router.Parent]++
+		}
+	}
+	// a site is stale when its controller or all of its routers are
+	for _, site := range fc.Sites {
+		source, ok := fc.eventSources[site.Identity]
+		site.Stale = (ok && source.Stale) || (routers[site.Identity] > 0 && staleRouters[site.Identity] == routers[site.Identity])
+	}
+}
+
+// siteOrphaned reports whether a site is left without any router or
+// controller once the router is purged
+func (fc *FlowCollector) siteOrphaned(siteId string, routerId string) bool {
+	if _, ok := fc.eventSources[siteId]; ok {
+		return false
+	}
+	for _, router := range fc.Routers {
+		if router.Parent == siteId && router.Identity != routerId && router.EndTime == 0 {
+			return false
+		}
+	}
+	return true
+}
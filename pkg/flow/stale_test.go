@@ -0,0 +1,82 @@
+package flow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gotest.tools/assert"
+)
+
+func TestMarkStaleSources(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{
+		Mode:    RecordMetrics,
+		PromReg: prometheus.NewRegistry(),
+	})
+	assert.Equal(t, fc.staleSourceAfter, defaultStaleSourceAfter)
+	now := uint64(time.Now().UnixNano()) / uint64(time.Microsecond)
+	siteName, routerA, routerB := "west", "router-a", "router-b"
+	fc.Sites["site-1"] = &SiteRecord{Base: Base{Identity: "site-1"}, Name: &siteName}
+	fc.Routers["router:a"] = &RouterRecord{Base: Base{Identity: "router:a", Parent: "site-1"}, Name: &routerA}
+	fc.Routers["router:b"] = &RouterRecord{Base: Base{Identity: "router:b", Parent: "site-1"}, Name: &routerB}
+	source := func(id string, sourceType string) *eventSource {
+		es := &eventSource{EventSourceRecord: EventSourceRecord{
+			Base:      Base{Identity: id},
+			Beacon:    &BeaconRecord{SourceType: sourceType},
+			LastHeard: now,
+		}}
+		fc.eventSources[id] = es
+		return es
+	}
+	a, b := source("router:a", recordNames[Router]), source("router:b", recordNames[Router])
+
+	// one of the routers of the site gone quiet
+	a.LastHeard = now - 40*oneSecond
+	fc.markStaleSources(now)
+	assert.Assert(t, a.Stale)
+	assert.Assert(t, fc.Routers["router:a"].Stale)
+	assert.Assert(t, !fc.Routers["router:b"].Stale)
+	assert.Assert(t, !fc.Sites["site-1"].Stale)
+	events, _ := fc.siteEvents.query(siteEventFilter{limit: -1})
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].Type, SiteEventSourceStale)
+	assert.Equal(t, events[0].Subject, "router-a")
+	assert.Equal(t, events[0].SiteName, "west")
+
+	// both of them
+	b.LastHeard = now - 40*oneSecond
+	fc.markStaleSources(now)
+	assert.Assert(t, fc.Sites["site-1"].Stale)
+
+	// heard from again
+	a.LastHeard, b.LastHeard = now, now
+	fc.markStaleSources(now)
+	assert.Assert(t, !a.Stale)
+	assert.Assert(t, !fc.Routers["router:a"].Stale)
+	assert.Assert(t, !fc.Sites["site-1"].Stale)
+	events, _ = fc.siteEvents.query(siteEventFilter{types: map[string]bool{SiteEventSourceRecovered: true}, limit: -1})
+	assert.Equal(t, len(events), 2)
+
+	// the site of a stale controller
+	controller := source("site-1", recordNames[Controller])
+	controller.LastHeard = now - 40*oneSecond
+	fc.markStaleSources(now)
+	assert.Assert(t, fc.Sites["site-1"].Stale)
+	assert.Assert(t, !fc.Routers["router:a"].Stale)
+}
+
+func TestSiteOrphaned(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{
+		Mode:    RecordMetrics,
+		PromReg: prometheus.NewRegistry(),
+	})
+	fc.Sites["site-1"] = &SiteRecord{Base: Base{Identity: "site-1"}}
+	fc.Routers["router:a"] = &RouterRecord{Base: Base{Identity: "router:a", Parent: "site-1"}}
+	fc.Routers["router:b"] = &RouterRecord{Base: Base{Identity: "router:b", Parent: "site-1"}}
+	assert.Assert(t, !fc.siteOrphaned("site-1", "router:a"))
+	fc.Routers["router:b"].EndTime = 100
+	assert.Assert(t, fc.siteOrphaned("site-1", "router:a"))
+	// the controller still reports the site
+	fc.eventSources["site-1"] = &eventSource{EventSourceRecord: EventSourceRecord{Base: Base{Identity: "site-1"}}}
+	assert.Assert(t, !fc.siteOrphaned("site-1", "router:a"))
+}
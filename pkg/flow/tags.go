@@ -0,0 +1,249 @@
+package flow
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v3"
+)
+
+// TagRule applies a tag to the flow pairs that satisfy all the conditions
+// of its match, empty conditions match everything
+type TagRule struct {
+	Tag   string   `yaml:"tag" json:"tag"`
+	Match TagMatch `yaml:"match" json:"match"`
+}
+
+// TagMatch string conditions are shell patterns as accepted by path.Match
+type TagMatch struct {
+	Address     string       `yaml:"address,omitempty" json:"address,omitempty"`
+	Protocol    string       `yaml:"protocol,omitempty" json:"protocol,omitempty"`
+	Ports       []string     `yaml:"ports,omitempty" json:"ports,omitempty"`
+	Source      ProcessMatch `yaml:"source,omitempty" json:"source,omitempty"`
+	Destination ProcessMatch `yaml:"destination,omitempty" json:"destination,omitempty"`
+	MinOctets   uint64       `yaml:"minOctets,omitempty" json:"minOctets,omitempty"`
+	MaxOctets   uint64       `yaml:"maxOctets,omitempty" json:"maxOctets,omitempty"`
+}
+
+type ProcessMatch struct {
+	Name  string `yaml:"name,omitempty" json:"name,omitempty"`
+	Group string `yaml:"group,omitempty" json:"group,omitempty"`
+	Role  string `yaml:"role,omitempty" json:"role,omitempty"`
+	Image string `yaml:"image,omitempty" json:"image,omitempty"`
+}
+
+type tagRulesFile struct {
+	Rules []TagRule `yaml:"rules"`
+}
+
+// LoadTagRules reads the tagging rules from a yaml file of the form
+//
+//	rules:
+//	- tag: batch
+//	  match:
+//	    address: "backup-*"
+//	    minOctets: 10485760
+func LoadTagRules(filename string) ([]TagRule, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	file := tagRulesFile{}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("Invalid tag rules in %s: %s", filename, err)
+	}
+	for i, rule := range file.Rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("Invalid tag rule %d in %s: %s", i, filename, err)
+		}
+	}
+	return file.Rules, nil
+}
+
+func (r TagRule) validate() error {
+	if r.Tag == "" {
+		return fmt.Errorf("tag is required")
+	}
+	patterns := []string{r.Match.Address, r.Match.Protocol,
+		r.Match.Source.Name, r.Match.Source.Group, r.Match.Source.Role, r.Match.Source.Image,
+		r.Match.Destination.Name, r.Match.Destination.Group, r.Match.Destination.Role, r.Match.Destination.Image}
+	patterns = append(patterns, r.Match.Ports...)
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad pattern %q", pattern)
+		}
+	}
+	if r.Match.MaxOctets != 0 && r.Match.MaxOctets < r.Match.MinOctets {
+		return fmt.Errorf("maxOctets is lower than minOctets")
+	}
+	return nil
+}
+
+// tagSubject holds what the rules are matched against for a flow pair
+type tagSubject struct {
+	address     string
+	protocol    string
+	ports       []string
+	source      *ProcessRecord
+	destination *ProcessRecord
+	octets      uint64
+}
+
+func matchPattern(pattern string, value *string) bool {
+	if pattern == "" {
+		return true
+	}
+	if value == nil {
+		return false
+	}
+	ok, _ := path.Match(pattern, *value)
+	return ok
+}
+
+func (m ProcessMatch) matches(process *ProcessRecord) bool {
+	if m == (ProcessMatch{}) {
+		return true
+	}
+	if process == nil {
+		return false
+	}
+	return matchPattern(m.Name, process.Name) && matchPattern(m.Group, process.GroupName) &&
+		matchPattern(m.Role, process.ProcessRole) && matchPattern(m.Image, process.ImageName)
+}
+
+func (m TagMatch) matches(subject tagSubject) bool {
+	if !matchPattern(m.Address, &subject.address) || !matchPattern(m.Protocol, &subject.protocol) {
+		return false
+	}
+	if len(m.Ports) > 0 {
+		found := false
+		for _, pattern := range m.Ports {
+			for i := range subject.ports {
+				if matchPattern(pattern, &subject.ports[i]) {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if subject.octets < m.MinOctets || (m.MaxOctets != 0 && subject.octets > m.MaxOctets) {
+		return false
+	}
+	return m.Source.matches(subject.source) && m.Destination.matches(subject.destination)
+}
+
+func evaluateTagRules(rules []TagRule, subject tagSubject) []string {
+	matched := map[string]bool{}
+	for _, rule := range rules {
+		if rule.Match.matches(subject) {
+			matched[rule.Tag] = true
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+	tags := make([]string, 0, len(matched))
+	for tag := range matched {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func (fc *FlowCollector) flowPairForFlow(flow *FlowRecord) *FlowPairRecord {
+	if flowPair, ok := fc.FlowPairs["fp-"+flow.Identity]; ok {
+		return flowPair
+	}
+	if flow.CounterFlow != nil {
+		if flowPair, ok := fc.FlowPairs["fp-"+*flow.CounterFlow]; ok {
+			return flowPair
+		}
+	}
+	return nil
+}
+
+func (fc *FlowCollector) tagSubject(flowPair *FlowPairRecord) tagSubject {
+	subject := tagSubject{}
+	if flowPair.Protocol != nil {
+		subject.protocol = *flowPair.Protocol
+	}
+	for _, flow := range []*FlowRecord{flowPair.ForwardFlow, flowPair.CounterFlow} {
+		if flow == nil {
+			continue
+		}
+		if flow.Octets != nil {
+			subject.octets += *flow.Octets
+		}
+		var address, port *string
+		if listener, ok := fc.Listeners[flow.Parent]; ok {
+			address, port = listener.Address, listener.DestPort
+		} else if connector, ok := fc.Connectors[flow.Parent]; ok {
+			address, port = connector.Address, connector.DestPort
+		}
+		if address != nil {
+			subject.address = *address
+		}
+		if port != nil {
+			subject.ports = append(subject.ports, *port)
+		}
+	}
+	if flowPair.ForwardFlow != nil && flowPair.ForwardFlow.Process != nil {
+		subject.source = fc.Processes[*flowPair.ForwardFlow.Process]
+	}
+	if flowPair.CounterFlow != nil && flowPair.CounterFlow.Process != nil {
+		subject.destination = fc.Processes[*flowPair.CounterFlow.Process]
+	}
+	return subject
+}
+
+// tagFlowPair evaluates the rules against the flow pair, setting the
+// resulting tags on the pair and both of its flows
+func (fc *FlowCollector) tagFlowPair(flowPair *FlowPairRecord) {
+	if len(fc.tagRules) == 0 || flowPair == nil {
+		return
+	}
+	subject := fc.tagSubject(flowPair)
+	tags := evaluateTagRules(fc.tagRules, subject)
+	if fc.metrics != nil {
+		previous := map[string]bool{}
+		for _, tag := range flowPair.Tags {
+			previous[tag] = true
+		}
+		for _, tag := range tags {
+			if !previous[tag] {
+				labels := prometheus.Labels{"tag": tag, "address": subject.address}
+				fc.metrics.taggedFlows.With(labels).Inc()
+				fc.metrics.taggedOctets.With(labels).Add(float64(subject.octets))
+			}
+		}
+	}
+	flowPair.Tags = tags
+	if flowPair.ForwardFlow != nil {
+		flowPair.ForwardFlow.Tags = tags
+	}
+	if flowPair.CounterFlow != nil {
+		flowPair.CounterFlow.Tags = tags
+	}
+}
+
+// addTaggedOctets accounts the octets received for a flow against the tags
+// it already carries
+func (fc *FlowCollector) addTaggedOctets(flow *FlowRecord, octets uint64) {
+	if fc.metrics == nil || len(flow.Tags) == 0 || octets == 0 {
+		return
+	}
+	address := ""
+	if listener, ok := fc.Listeners[flow.Parent]; ok && listener.Address != nil {
+		address = *listener.Address
+	} else if connector, ok := fc.Connectors[flow.Parent]; ok && connector.Address != nil {
+		address = *connector.Address
+	}
+	for _, tag := range flow.Tags {
+		fc.metrics.taggedOctets.With(prometheus.Labels{"tag": tag, "address": address}).Add(float64(octets))
+	}
+}
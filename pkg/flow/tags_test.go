@@ -0,0 +1,114 @@
+package flow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestLoadTagRules(t *testing.T) {
+	dir := t.TempDir()
+	testTable := []struct {
+		name    string
+		content string
+		rules   int
+		err     string
+	}{
+		{
+			name: "valid",
+			content: `rules:
+- tag: batch
+  match:
+    address: "backup-*"
+    minOctets: 1000
+- tag: interactive
+  match:
+    protocol: http
+    source:
+      group: "frontend*"
+`,
+			rules: 2,
+		},
+		{
+			name:    "missing-tag",
+			content: "rules:\n- match:\n    address: db\n",
+			err:     "tag is required",
+		},
+		{
+			name:    "bad-pattern",
+			content: "rules:\n- tag: x\n  match:\n    address: \"[\"\n",
+			err:     "bad pattern",
+		},
+		{
+			name:    "bad-octets",
+			content: "rules:\n- tag: x\n  match:\n    minOctets: 10\n    maxOctets: 5\n",
+			err:     "maxOctets is lower than minOctets",
+		},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			filename := filepath.Join(dir, test.name+".yaml")
+			assert.Assert(t, os.WriteFile(filename, []byte(test.content), 0644))
+			rules, err := LoadTagRules(filename)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+			} else {
+				assert.Assert(t, err)
+				assert.Equal(t, len(rules), test.rules)
+			}
+		})
+	}
+}
+
+func TestEvaluateTagRules(t *testing.T) {
+	frontend := "frontend"
+	rules := []TagRule{
+		{Tag: "batch", Match: TagMatch{Address: "backup-*", MinOctets: 1000}},
+		{Tag: "interactive", Match: TagMatch{Protocol: "http", Source: ProcessMatch{Group: "front*"}}},
+		{Tag: "small", Match: TagMatch{MaxOctets: 100}},
+		{Tag: "db", Match: TagMatch{Ports: []string{"5432"}}},
+	}
+	testTable := []struct {
+		name    string
+		subject tagSubject
+		tags    []string
+	}{
+		{
+			name:    "batch",
+			subject: tagSubject{address: "backup-west", protocol: "tcp", ports: []string{"8080"}, octets: 5000},
+			tags:    []string{"batch"},
+		},
+		{
+			name:    "below-threshold",
+			subject: tagSubject{address: "backup-west", protocol: "tcp", octets: 500},
+		},
+		{
+			name:    "interactive-and-small",
+			subject: tagSubject{address: "web", protocol: "http", source: &ProcessRecord{GroupName: &frontend}, octets: 10},
+			tags:    []string{"interactive", "small"},
+		},
+		{
+			name:    "unknown-process",
+			subject: tagSubject{address: "web", protocol: "http", octets: 1000},
+		},
+		{
+			name:    "port",
+			subject: tagSubject{address: "postgres", protocol: "tcp", ports: []string{"5432", "5432"}, octets: 1000},
+			tags:    []string{"db"},
+		},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			assert.DeepEqual(t, evaluateTagRules(rules, test.subject), test.tags)
+		})
+	}
+}
+
+func TestFilterTags(t *testing.T) {
+	flowPair := FlowPairRecord{Tags: []string{"batch", "db"}}
+	assert.Assert(t, filterRecord(flowPair, QueryParams{FilterFields: map[string][]string{"Tags": {"db"}}}))
+	assert.Assert(t, !filterRecord(flowPair, QueryParams{FilterFields: map[string][]string{"Tags": {"interactive"}}}))
+	assert.Assert(t, !filterRecord(FlowPairRecord{}, QueryParams{FilterFields: map[string][]string{"Tags": {"db"}}}))
+}
@@ -0,0 +1,80 @@
+package flow
+
+import (
+	"strings"
+)
+
+// traceParentLength is the length of a traceparent header of version 00
+const traceParentLength = 55
+
+func isLowerHex(value string) bool {
+	for _, c := range value {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseTraceParent returns the trace and parent span ids of a W3C
+// traceparent header, as in 00-<trace id>-<span id>-<flags>. The values of
+// the future versions are read up to the flags as the specification asks.
+func ParseTraceParent(value string) (traceId string, spanId string, ok bool) {
+	value = strings.TrimSpace(value)
+	if len(value) < traceParentLength {
+		return "", "", false
+	}
+	parts := strings.Split(value[:traceParentLength], "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return "", "", false
+	}
+	for _, part := range parts {
+		if !isLowerHex(part) {
+			return "", "", false
+		}
+	}
+	version := parts[0]
+	if version == "ff" || (version == "00" && len(value) != traceParentLength) {
+		return "", "", false
+	}
+	if len(value) > traceParentLength && value[traceParentLength] != '-' {
+		return "", "", false
+	}
+	traceId, spanId = parts[1], parts[2]
+	if strings.Trim(traceId, "0") == "" || strings.Trim(spanId, "0") == "" {
+		return "", "", false
+	}
+	return traceId, spanId, true
+}
+
+// captureTraceContext keeps the trace context of an http request reported
+// in an update of its flow, and links its flow pair to the trace
+func (fc *FlowCollector) captureTraceContext(current *FlowRecord, update *FlowRecord) {
+	if update.TraceParent != nil {
+		current.TraceParent = update.TraceParent
+	}
+	if update.TraceState != nil {
+		current.TraceState = update.TraceState
+	}
+	if update.TraceParent == nil {
+		return
+	}
+	if flowPair, ok := fc.flowPairOf(current); ok {
+		flowPair.TraceId = flowPairTraceId(flowPair)
+	}
+}
+
+// flowPairTraceId returns the id of the trace of the request of a flow
+// pair, the traceparent seen by the listener taking precedence over the
+// one forwarded to the connector
+func flowPairTraceId(flowPair *FlowPairRecord) string {
+	for _, flow := range []*FlowRecord{flowPair.ForwardFlow, flowPair.CounterFlow} {
+		if flow == nil || flow.TraceParent == nil {
+			continue
+		}
+		if traceId, _, ok := ParseTraceParent(*flow.TraceParent); ok {
+			return traceId
+		}
+	}
+	return ""
+}
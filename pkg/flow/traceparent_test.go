@@ -0,0 +1,63 @@
+package flow
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gotest.tools/assert"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	testTable := []struct {
+		value   string
+		traceId string
+		spanId  string
+	}{
+		{value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", traceId: "4bf92f3577b34da6a3ce929d0e0e4736", spanId: "00f067aa0ba902b7"},
+		{value: " 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00 ", traceId: "4bf92f3577b34da6a3ce929d0e0e4736", spanId: "00f067aa0ba902b7"},
+		{value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", traceId: "4bf92f3577b34da6a3ce929d0e0e4736", spanId: "00f067aa0ba902b7"},
+		{value: ""},
+		{value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+		{value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7_01"},
+	}
+	for _, test := range testTable {
+		traceId, spanId, ok := ParseTraceParent(test.value)
+		assert.Equal(t, ok, test.traceId != "", test.value)
+		assert.Equal(t, traceId, test.traceId, test.value)
+		assert.Equal(t, spanId, test.spanId, test.value)
+	}
+}
+
+func TestCaptureTraceContext(t *testing.T) {
+	fc := NewFlowCollector(FlowCollectorSpec{
+		Mode:    RecordMetrics,
+		PromReg: prometheus.NewRegistry(),
+	})
+	client := &FlowRecord{Base: Base{Identity: "flow:0"}}
+	server := &FlowRecord{Base: Base{Identity: "flow:1"}, CounterFlow: &client.Identity}
+	fc.Flows[client.Identity], fc.Flows[server.Identity] = client, server
+	flowPair := &FlowPairRecord{Base: Base{Identity: "fp-flow:0"}, ForwardFlow: client, CounterFlow: server}
+	fc.FlowPairs[flowPair.Identity] = flowPair
+
+	// the connector side only
+	forwarded, state := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", "vendor=value"
+	fc.captureTraceContext(server, &FlowRecord{TraceParent: &forwarded, TraceState: &state})
+	assert.Equal(t, *server.TraceParent, forwarded)
+	assert.Equal(t, *server.TraceState, state)
+	assert.Equal(t, flowPair.TraceId, "0af7651916cd43dd8448eb211c80319c")
+
+	// the listener side takes precedence
+	received := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	fc.captureTraceContext(client, &FlowRecord{TraceParent: &received})
+	assert.Equal(t, flowPair.TraceId, "4bf92f3577b34da6a3ce929d0e0e4736")
+	assert.Assert(t, client.TraceState == nil)
+
+	// an update without trace context leaves it as is
+	fc.captureTraceContext(client, &FlowRecord{})
+	assert.Equal(t, *client.TraceParent, received)
+	assert.Equal(t, flowPair.TraceId, "4bf92f3577b34da6a3ce929d0e0e4736")
+}
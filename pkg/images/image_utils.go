@@ -18,6 +18,7 @@ const (
 	FlowCollectorImageEnvKey          string = "SKUPPER_FLOW_COLLECTOR_IMAGE"
 	PrometheusServerImageEnvKey       string = "PROMETHEUS_SERVER_IMAGE"
 	OauthProxyImageEnvKey             string = "OAUTH_PROXY_IMAGE"
+	SocatImageEnvKey                  string = "SKUPPER_SOCAT_IMAGE"
 	RouterPullPolicyEnvKey            string = "QDROUTERD_IMAGE_PULL_POLICY"
 	ServiceControllerPullPolicyEnvKey string = "SKUPPER_SERVICE_CONTROLLER_IMAGE_PULL_POLICY"
 	ControllerPodmanPullPolicyEnvKey  string = "SKUPPER_CONTROLLER_PODMAN_IMAGE_PULL_POLICY"
@@ -178,6 +179,14 @@ func GetPrometheusServerImageDetails() types.ImageDetails {
 	}
 }
 
+func GetSocatImageName() string {
+	image := os.Getenv(SocatImageEnvKey)
+	if image == "" {
+		return strings.Join([]string{SocatImageRegistry, SocatImageName}, "/")
+	}
+	return image
+}
+
 func GetImageRegistry() string {
 	imageRegistry := os.Getenv(SkupperImageRegistryEnvKey)
 	if imageRegistry == "" {
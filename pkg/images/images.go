@@ -12,4 +12,6 @@ const (
 	PrometheusServerImageName  string = "prometheus:v2.42.0"
 	OauthProxyImageRegistry    string = "quay.io/openshift"
 	OauthProxyImageName        string = "origin-oauth-proxy:4.14.0"
+	SocatImageRegistry         string = "docker.io/alpine"
+	SocatImageName             string = "socat:1.7.4.4"
 )
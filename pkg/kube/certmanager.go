@@ -0,0 +1,197 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/fips"
+	"github.com/skupperproject/skupper/pkg/utils"
+)
+
+const (
+	// the annotation set by cert-manager on the secrets it issues
+	CertManagerCertificateNameAnnotation = "cert-manager.io/certificate-name"
+
+	certManagerSecretTimeout  = 2 * time.Minute
+	certManagerSecretInterval = time.Second
+)
+
+var certificateResource = schema.GroupVersionResource{
+	Group:    "cert-manager.io",
+	Version:  "v1",
+	Resource: "certificates",
+}
+var certificateGVK = schema.GroupVersionKind{
+	Group:   certificateResource.Group,
+	Version: certificateResource.Version,
+	Kind:    "Certificate",
+}
+var issuerResource = schema.GroupVersionResource{
+	Group:    "cert-manager.io",
+	Version:  "v1",
+	Resource: "issuers",
+}
+var issuerGVK = schema.GroupVersionKind{
+	Group:   issuerResource.Group,
+	Version: issuerResource.Version,
+	Kind:    types.CertManagerIssuerKind,
+}
+
+// IsCertManagerSecret returns true when the secret is issued by cert-manager
+func IsCertManagerSecret(secret *corev1.Secret) bool {
+	return secret != nil && secret.Annotations[CertManagerCertificateNameAnnotation] != ""
+}
+
+func certManagerCertificate(name string, subject string, hosts []string, isCA bool, issuer string, issuerKind string, labels map[string]string, owner *metav1.OwnerReference) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(certificateGVK)
+	obj.SetName(name)
+	obj.SetLabels(labels)
+	if owner != nil {
+		obj.SetOwnerReferences([]metav1.OwnerReference{*owner})
+	}
+	if issuerKind == "" {
+		issuerKind = types.CertManagerIssuerKind
+	}
+	spec := map[string]interface{}{
+		"secretName": name,
+		"commonName": subject,
+		"isCA":       isCA,
+		// the CA keys sign the tokens and client certificates generated
+		// by skupper, which expects pkcs1 rsa keys
+		"privateKey": map[string]interface{}{
+			"algorithm":      "RSA",
+			"encoding":       "PKCS1",
+			"size":           int64(fips.RSAKeyBits()),
+			"rotationPolicy": "Always",
+		},
+		"issuerRef": map[string]interface{}{
+			"name":  issuer,
+			"kind":  issuerKind,
+			"group": certificateResource.Group,
+		},
+	}
+	if isCA {
+		spec["usages"] = []interface{}{"cert sign", "crl sign", "digital signature"}
+	} else {
+		spec["usages"] = []interface{}{"server auth", "client auth", "digital signature", "key encipherment"}
+		dnsNames, ips := []interface{}{}, []interface{}{}
+		for _, host := range append([]string{subject}, hosts...) {
+			if net.ParseIP(host) != nil {
+				ips = append(ips, host)
+			} else if !containsValue(dnsNames, host) {
+				dnsNames = append(dnsNames, host)
+			}
+		}
+		if len(dnsNames) > 0 {
+			spec["dnsNames"] = dnsNames
+		}
+		if len(ips) > 0 {
+			spec["ipAddresses"] = ips
+		}
+	}
+	if err := unstructured.SetNestedMap(obj.UnstructuredContent(), spec, "spec"); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func containsValue(values []interface{}, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func createCertManagerCertificate(obj *unstructured.Unstructured, namespace string, dc dynamic.Interface) error {
+	_, err := dc.Resource(certificateResource).Namespace(namespace).Create(context.TODO(), obj, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("Failed to create cert-manager certificate %s: %w", obj.GetName(), err)
+	}
+	return nil
+}
+
+// waitCertManagerSecret waits for cert-manager to issue the secret of a
+// certificate
+func waitCertManagerSecret(name string, namespace string, cli kubernetes.Interface, timeout time.Duration) (*corev1.Secret, error) {
+	var secret *corev1.Secret
+	ctx, cancel := context.WithTimeout(context.TODO(), timeout)
+	defer cancel()
+	err := utils.RetryWithContext(ctx, certManagerSecretInterval, func() (bool, error) {
+		current, err := cli.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			// not issued yet
+			return false, nil
+		}
+		secret = current
+		return len(current.Data["tls.crt"]) > 0 && len(current.Data["tls.key"]) > 0, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Timed out waiting for cert-manager to issue the secret %s, check the status of the certificate %s: %w", name, name, err)
+	}
+	return secret, nil
+}
+
+// NewCertManagerCertAuthority has the issuer of the options issue the
+// certificate of the CA, along with an issuer of the same name signing the
+// certificates of the CA
+func NewCertManagerCertAuthority(ca types.CertAuthority, owner *metav1.OwnerReference, namespace string, cli kubernetes.Interface, dc dynamic.Interface) (*corev1.Secret, error) {
+	existing, err := cli.CoreV1().Secrets(namespace).Get(context.TODO(), ca.Name, metav1.GetOptions{})
+	if err == nil {
+		return existing, nil
+	} else if !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("Failed to check CA %s : %w", ca.Name, err)
+	}
+	certificate, err := certManagerCertificate(ca.Name, ca.Name, nil, true, ca.CertManager.Issuer, ca.CertManager.IssuerKind, ca.Labels, owner)
+	if err != nil {
+		return nil, err
+	}
+	if err = createCertManagerCertificate(certificate, namespace, dc); err != nil {
+		return nil, err
+	}
+	issuer := &unstructured.Unstructured{}
+	issuer.SetGroupVersionKind(issuerGVK)
+	issuer.SetName(ca.Name)
+	issuer.SetLabels(ca.Labels)
+	if owner != nil {
+		issuer.SetOwnerReferences([]metav1.OwnerReference{*owner})
+	}
+	if err = unstructured.SetNestedField(issuer.UnstructuredContent(), ca.Name, "spec", "ca", "secretName"); err != nil {
+		return nil, err
+	}
+	_, err = dc.Resource(issuerResource).Namespace(namespace).Create(context.TODO(), issuer, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("Failed to create cert-manager issuer %s: %w", ca.Name, err)
+	}
+	return waitCertManagerSecret(ca.Name, namespace, cli, certManagerSecretTimeout)
+}
+
+// NewCertManagerSecret has the issuer of the CA of the credential issue its
+// certificate, the credentials without a CA, the simple ones and those
+// holding a connect.json being generated by NewSecret
+func NewCertManagerSecret(cred types.Credential, owner *metav1.OwnerReference, namespace string, cli kubernetes.Interface, dc dynamic.Interface) (*corev1.Secret, error) {
+	if cred.CA == "" || cred.Simple || cred.ConnectJson {
+		return NewSecret(cred, owner, namespace, cli)
+	}
+	certificate, err := certManagerCertificate(cred.Name, cred.Subject, cred.Hosts, false, cred.CA, types.CertManagerIssuerKind, cred.Labels, owner)
+	if err != nil {
+		return nil, err
+	}
+	if err = createCertManagerCertificate(certificate, namespace, dc); err != nil {
+		return nil, err
+	}
+	return waitCertManagerSecret(cred.Name, namespace, cli, certManagerSecretTimeout)
+}
@@ -73,10 +73,29 @@ func NewClaimFactory(clients kube.Clients, namespace string, siteContext SiteCon
 }
 
 func (m *ClaimFactory) CreateTokenClaim(name string, password []byte, expiry time.Duration, uses int) (*corev1.Secret, error) {
+	return m.createTokenClaim(name, password, expiry, uses, false, types.ClaimPolicy{})
+}
+
+// CreateMutualTokenClaim creates a claim that also allows the redeeming
+// site to have a link created back to it from this site
+func (m *ClaimFactory) CreateMutualTokenClaim(name string, password []byte, expiry time.Duration, uses int) (*corev1.Secret, error) {
+	return m.createTokenClaim(name, password, expiry, uses, true, types.ClaimPolicy{})
+}
+
+// CreateTokenClaimWithPolicy creates a claim that can only be redeemed by
+// the sites satisfying the policy
+func (m *ClaimFactory) CreateTokenClaimWithPolicy(name string, password []byte, expiry time.Duration, uses int, mutual bool, policy types.ClaimPolicy) (*corev1.Secret, error) {
+	return m.createTokenClaim(name, password, expiry, uses, mutual, policy)
+}
+
+func (m *ClaimFactory) createTokenClaim(name string, password []byte, expiry time.Duration, uses int, mutual bool, policy types.ClaimPolicy) (*corev1.Secret, error) {
 	options, err := checkOptions(name, password, expiry, uses)
 	if err != nil {
 		return nil, err
 	}
+	if err = ValidateClaimPolicy(policy, expiry); err != nil {
+		return nil, err
+	}
 
 	if m.siteContext.IsEdge() {
 		return nil, fmt.Errorf("Edge configuration cannot accept connections")
@@ -86,10 +105,13 @@ func (m *ClaimFactory) CreateTokenClaim(name string, password []byte, expiry tim
 	if err != nil {
 		return nil, err
 	}
-	err = m.createClaimRecord(options.Name, options.Password, options.Expiry, options.Uses)
+	err = m.createClaimRecord(options.Name, options.Password, options.Expiry, options.Uses, mutual, policy)
 	if err != nil {
 		return nil, err
 	}
+	if mutual {
+		claim.ObjectMeta.Annotations[types.ClaimMutual] = "true"
+	}
 
 	return claim, nil
 }
@@ -108,7 +130,7 @@ func (m *ClaimFactory) RecreateTokenClaim(name string) (*corev1.Secret, error) {
 	return token, err
 }
 
-func (m *ClaimFactory) createClaimRecord(name string, password []byte, expiry time.Duration, uses int) error {
+func (m *ClaimFactory) createClaimRecord(name string, password []byte, expiry time.Duration, uses int, mutual bool, policy types.ClaimPolicy) error {
 	record := corev1.Secret{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
@@ -135,6 +157,10 @@ func (m *ClaimFactory) createClaimRecord(name string, password []byte, expiry ti
 	if uses > 0 {
 		record.ObjectMeta.Annotations[types.ClaimsRemaining] = strconv.Itoa(uses)
 	}
+	if mutual {
+		record.ObjectMeta.Annotations[types.ClaimMutual] = "true"
+	}
+	annotateClaimPolicy(&record, policy)
 	_, err := m.clients.GetKubeClient().CoreV1().Secrets(m.namespace).Create(m.ctx, &record, metav1.CreateOptions{})
 	return err
 }
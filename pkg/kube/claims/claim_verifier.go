@@ -3,6 +3,7 @@ package claims
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"log"
@@ -21,6 +22,7 @@ import (
 	"k8s.io/client-go/util/retry"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/utils"
 )
 
 const (
@@ -35,48 +37,68 @@ type TokenGenerator interface {
 	ConnectorTokenCreate(ctx context.Context, subject string, namespace string) (*corev1.Secret, bool, error)
 }
 
+type LinkCreator interface {
+	ConnectorCreateSecretFromData(ctx context.Context, options types.ConnectorCreateOptions) (*corev1.Secret, error)
+}
+
 type ClaimVerifier struct {
 	client      kubernetes.Interface
 	namespace   string
 	generator   TokenGenerator
 	siteChecker SiteChecker
+	linkCreator LinkCreator
+	limiter     *utils.AttemptLimiter
 }
 
-func newClaimVerifier(client kubernetes.Interface, namespace string, generator TokenGenerator, siteChecker SiteChecker) *ClaimVerifier {
+func newClaimVerifier(client kubernetes.Interface, namespace string, generator TokenGenerator, siteChecker SiteChecker, linkCreator LinkCreator) *ClaimVerifier {
 	return &ClaimVerifier{
 		client:      client,
 		namespace:   namespace,
 		generator:   generator,
 		siteChecker: siteChecker,
+		linkCreator: linkCreator,
+		limiter:     newClaimLimiter(),
 	}
 }
 
-func (server *ClaimVerifier) checkAndUpdateClaim(name string, data []byte) (string, int) {
+func (server *ClaimVerifier) checkAndUpdateClaim(name string, data []byte, requester claimRequester) (types.ClaimPolicy, string, int) {
 	log.Printf("Checking claim %s", name)
 	claim, err := server.client.CoreV1().Secrets(server.namespace).Get(context.TODO(), name, metav1.GetOptions{})
 	if errors.IsNotFound(err) {
-		return "No such claim", http.StatusNotFound
+		return types.ClaimPolicy{}, "No such claim", http.StatusNotFound
 	} else if err != nil {
 		log.Printf("Error retrieving claim: %s", err)
-		return err.Error(), http.StatusInternalServerError
+		return types.ClaimPolicy{}, err.Error(), http.StatusInternalServerError
 	}
 	if claim.ObjectMeta.Labels == nil || claim.ObjectMeta.Labels[types.SkupperTypeQualifier] != types.TypeClaimRecord {
-		return "No such claim", http.StatusNotFound
+		return types.ClaimPolicy{}, "No such claim", http.StatusNotFound
 	}
 	if claim.ObjectMeta.Annotations != nil {
 		if expirationString, ok := claim.ObjectMeta.Annotations[types.ClaimExpiration]; ok {
 			expiration, err := time.Parse(time.RFC3339, expirationString)
 			if err != nil {
 				log.Printf("Cannot determine expiration: %s", err)
-				return "Corrupted claim", http.StatusInternalServerError
+				return types.ClaimPolicy{}, "Corrupted claim", http.StatusInternalServerError
 			} else if expiration.Before(time.Now()) {
 				log.Printf("Claim %s expired", name)
-				return "No such claim", http.StatusNotFound
+				return types.ClaimPolicy{}, "No such claim", http.StatusNotFound
 			}
 		}
 	}
 	if !bytes.Equal(claim.Data["password"], data) {
-		return "Claim refused", http.StatusForbidden
+		return types.ClaimPolicy{}, "Claim refused", http.StatusForbidden
+	}
+	policy, err := readClaimPolicy(claim)
+	if err != nil {
+		log.Printf("Cannot determine the policy of claim %s: %s", name, err)
+		return types.ClaimPolicy{}, "Corrupted claim", http.StatusInternalServerError
+	}
+	if err := checkClaimPolicy(policy, requester, time.Now()); err != nil {
+		log.Printf("Claim %s refused to site %s: %s", name, requester.siteId, err)
+		if _, ok := err.(errClaimNotYetValid); ok {
+			return types.ClaimPolicy{}, err.Error(), http.StatusTooEarly
+		}
+		return types.ClaimPolicy{}, "Claim refused: " + err.Error(), http.StatusPreconditionFailed
 	}
 	if claim.ObjectMeta.Annotations == nil {
 		claim.ObjectMeta.Annotations = map[string]string{}
@@ -85,11 +107,11 @@ func (server *ClaimVerifier) checkAndUpdateClaim(name string, data []byte) (stri
 		remainingUses, err := strconv.Atoi(uses)
 		if err != nil {
 			log.Printf("Cannot determine remaining uses: %s", err)
-			return "Corrupted claim", http.StatusInternalServerError
+			return types.ClaimPolicy{}, "Corrupted claim", http.StatusInternalServerError
 		}
 		if remainingUses == 0 {
 			log.Printf("Claim %s already used", name)
-			return "No such claim", http.StatusNotFound
+			return types.ClaimPolicy{}, "No such claim", http.StatusNotFound
 		}
 		remainingUses -= 1
 		claim.ObjectMeta.Annotations[types.ClaimsRemaining] = strconv.Itoa(remainingUses)
@@ -98,7 +120,7 @@ func (server *ClaimVerifier) checkAndUpdateClaim(name string, data []byte) (stri
 		made, err := strconv.Atoi(value)
 		if err != nil {
 			log.Printf("Cannot determine claims made: %s", err)
-			return "Corrupted claim", http.StatusInternalServerError
+			return types.ClaimPolicy{}, "Corrupted claim", http.StatusInternalServerError
 		}
 		made += 1
 		claim.ObjectMeta.Annotations[types.ClaimsMade] = strconv.Itoa(made)
@@ -108,12 +130,13 @@ func (server *ClaimVerifier) checkAndUpdateClaim(name string, data []byte) (stri
 	_, err = server.client.CoreV1().Secrets(server.namespace).Update(context.TODO(), claim, metav1.UpdateOptions{})
 	if err != nil {
 		log.Printf("Error updating remaining uses: %s", err)
-		return "Internal error", http.StatusServiceUnavailable
+		return types.ClaimPolicy{}, "Internal error", http.StatusServiceUnavailable
 	}
-	return "ok", http.StatusOK
+	return policy, "ok", http.StatusOK
 }
 
-func (server *ClaimVerifier) redeemClaim(name string, subject string, data []byte, generator TokenGenerator) (*corev1.Secret, string, int) {
+func (server *ClaimVerifier) redeemClaim(name string, requester claimRequester, data []byte, generator TokenGenerator) (*corev1.Secret, string, int) {
+	policy := types.ClaimPolicy{}
 	text := ""
 	code := http.StatusServiceUnavailable
 	backoff := retry.DefaultRetry
@@ -121,21 +144,62 @@ func (server *ClaimVerifier) redeemClaim(name string, subject string, data []byt
 		if i > 0 {
 			time.Sleep(backoff.Step())
 		}
-		text, code = server.checkAndUpdateClaim(name, data)
+		policy, text, code = server.checkAndUpdateClaim(name, data, requester)
 	}
 	if code != http.StatusOK {
 		log.Printf("failed to check and update claim record: %s", text)
 		return nil, text, code
 	}
-	token, _, err := generator.ConnectorTokenCreate(context.TODO(), subject, "")
+	token, _, err := generator.ConnectorTokenCreate(context.TODO(), requester.siteId, "")
 	if err != nil {
 		log.Printf("Failed to create token: %s", err.Error())
 		return nil, err.Error(), http.StatusInternalServerError
 	}
+	restrictToken(token, policy, requester)
 	return token, "ok", http.StatusOK
 
 }
 
+// createReverseLink links this site back to the redeeming site, using the
+// claim it supplied, when the redeemed claim was created as mutual
+func (server *ClaimVerifier) createReverseLink(name string, encoded string) error {
+	if encoded == "" || server.linkCreator == nil {
+		return nil
+	}
+	record, err := server.client.CoreV1().Secrets(server.namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if record.ObjectMeta.Annotations[types.ClaimMutual] != "true" {
+		return fmt.Errorf("claim %s does not allow a reverse link", name)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("invalid reverse claim: %w", err)
+	}
+	s := json.NewYAMLSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+	reverse := &corev1.Secret{}
+	if _, _, err = s.Decode(data, nil, reverse); err != nil {
+		return fmt.Errorf("invalid reverse claim: %w", err)
+	}
+	if reverse.ObjectMeta.Labels[types.SkupperTypeQualifier] != types.TypeClaimRequest {
+		return fmt.Errorf("reverse claim is not a token claim")
+	}
+	options := types.ConnectorCreateOptions{
+		SkupperNamespace: server.namespace,
+		Secret:           reverse,
+	}
+	if cost, err := strconv.Atoi(reverse.ObjectMeta.Annotations[types.TokenCost]); err == nil {
+		options.Cost = int32(cost)
+	}
+	link, err := server.linkCreator.ConnectorCreateSecretFromData(context.TODO(), options)
+	if err != nil {
+		return err
+	}
+	log.Printf("Created link %s back to the site that redeemed claim %s", link.ObjectMeta.Name, name)
+	return nil
+}
+
 func (server *ClaimVerifier) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		log.Printf("Bad method %s", r.Method)
@@ -143,6 +207,23 @@ func (server *ClaimVerifier) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	name := strings.Join(strings.Split(r.URL.Path, "/"), "")
+	event := ClaimAuditEvent{
+		Time:     time.Now().UTC(),
+		Claim:    name,
+		SourceIP: sourceIP(r),
+	}
+	if wait := server.limiter.Locked(event.SourceIP); wait > 0 {
+		event.Outcome, event.Status = "locked-out", http.StatusTooManyRequests
+		auditClaim(event)
+		tooManyRequests(w, wait)
+		return
+	}
+	if ok, wait := server.limiter.Allow(event.SourceIP); !ok {
+		event.Outcome, event.Status = "rate-limited", http.StatusTooManyRequests
+		auditClaim(event)
+		tooManyRequests(w, wait)
+		return
+	}
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.Printf("Error reading body: %s", err.Error())
@@ -154,21 +235,46 @@ func (server *ClaimVerifier) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		log.Printf("No site name specified, using claim name")
 		subject = name
 	}
+	event.Subject = subject
 	remoteSiteVersion := r.URL.Query().Get("site-version")
 	if err = server.siteChecker.VerifySiteCompatibility(remoteSiteVersion); err != nil {
 		if remoteSiteVersion == "" {
 			remoteSiteVersion = "undefined"
 		}
 		log.Printf("%s - remote site version is %s", err.Error(), remoteSiteVersion)
+		event.Outcome, event.Status = "incompatible", http.StatusBadRequest
+		auditClaim(event)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	token, text, code := server.redeemClaim(name, subject, body, server.generator)
+	requester := claimRequester{
+		siteId:   subject,
+		siteName: r.Header.Get(types.ClaimSiteNameHeader),
+		mode:     r.Header.Get(types.ClaimSiteModeHeader),
+		cost:     r.Header.Get(types.ClaimLinkCostHeader),
+	}
+	token, text, code := server.redeemClaim(name, requester, body, server.generator)
+	event.Status = code
 	if token == nil {
 		log.Printf("Claim request for %s failed: %s", name, text)
+		switch code {
+		case http.StatusForbidden, http.StatusNotFound:
+			// wrong passwords and guessed claim names count towards a lockout
+			event.Outcome = "refused"
+			event.Lockout = int64(server.limiter.Failed(event.SourceIP).Seconds())
+		case http.StatusPreconditionFailed, http.StatusTooEarly:
+			// the password was right, but the policy of the claim refuses the site
+			event.Outcome = "rejected"
+		default:
+			event.Outcome = "error"
+		}
+		auditClaim(event)
 		http.Error(w, text, code)
 		return
 	}
+	server.limiter.Succeeded(event.SourceIP)
+	event.Outcome = "redeemed"
+	auditClaim(event)
 	s := json.NewYAMLSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
 	err = s.Encode(token, w)
 	if err != nil {
@@ -177,6 +283,9 @@ func (server *ClaimVerifier) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	log.Printf("Claim for %s succeeded", name)
+	if err := server.createReverseLink(name, r.Header.Get(types.ClaimReverseHeader)); err != nil {
+		log.Printf("Could not create reverse link for claim %s: %s", name, err)
+	}
 }
 
 const (
@@ -202,9 +311,14 @@ func (server *ClaimVerifier) listen() {
 	log.Fatal(http.ListenAndServeTLS(addr, cert, key, server))
 }
 
-func StartClaimVerifier(client kubernetes.Interface, namespace string, generator TokenGenerator, siteChecker SiteChecker) bool {
+func StartClaimVerifier(client kubernetes.Interface, namespace string, generator TokenGenerator, siteChecker SiteChecker, linkCreator LinkCreator) bool {
 	if enableClaimVerifier() {
-		verifier := newClaimVerifier(client, namespace, generator, siteChecker)
+		verifier := newClaimVerifier(client, namespace, generator, siteChecker, linkCreator)
+		if limiter, err := newClaimLimiterFromEnv(); err != nil {
+			log.Printf("Using the default claim request limits: %s", err)
+		} else {
+			verifier.limiter = limiter
+		}
 		go verifier.listen()
 		return true
 	}
@@ -3,6 +3,7 @@ package claims
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,13 +15,16 @@ import (
 	routev1client "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
 	"github.com/skupperproject/skupper/api/types"
 	"github.com/skupperproject/skupper/pkg/event"
+	"github.com/skupperproject/skupper/pkg/utils"
 	"gotest.tools/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
 )
 
 type TestClientContext struct {
@@ -112,7 +116,7 @@ func TestClaimVerifier(t *testing.T) {
 	}
 
 	generator := newMockTokenGenerator(nil)
-	verifier := newClaimVerifier(cli.KubeClient, cli.Namespace, generator, cli)
+	verifier := newClaimVerifier(cli.KubeClient, cli.Namespace, generator, cli, nil)
 
 	//create some claim records
 	err := createClaimRecord(cli, "a", []byte("abcdefg"), nil, 2)
@@ -122,7 +126,7 @@ func TestClaimVerifier(t *testing.T) {
 	assert.Check(t, err, "claim-verifier-test: creating b")
 
 	//simple test of valid claim
-	secret, _, code := verifier.redeemClaim("a", "foo", []byte("abcdefg"), generator)
+	secret, _, code := verifier.redeemClaim("a", claimRequester{siteId: "foo"}, []byte("abcdefg"), generator)
 	assert.Equal(t, code, http.StatusOK, "claim-verifier-test: a")
 	assert.Equal(t, secret, generator.Secret, "claim-verifier-test: a")
 	assert.Equal(t, secret.ObjectMeta.Name, "foo", "claim-verifier-test: a")
@@ -132,11 +136,11 @@ func TestClaimVerifier(t *testing.T) {
 	assert.Equal(t, record.ObjectMeta.Annotations[types.ClaimsMade], "1", "claim-verifier-test: a")
 
 	//test password checking
-	secret, _, code = verifier.redeemClaim("a", "foo", []byte("blahblah"), generator)
+	secret, _, code = verifier.redeemClaim("a", claimRequester{siteId: "foo"}, []byte("blahblah"), generator)
 	assert.Equal(t, code, http.StatusForbidden, "claim-verifier-test: a, bad password")
 	assert.Assert(t, secret == nil, "claim-verifier-test: a, bad password")
 
-	secret, _, code = verifier.redeemClaim("a", "foo", []byte("abcdefg"), generator)
+	secret, _, code = verifier.redeemClaim("a", claimRequester{siteId: "foo"}, []byte("abcdefg"), generator)
 	assert.Equal(t, code, http.StatusOK, "claim-verifier-test: a 2nd attempt")
 	assert.Equal(t, secret, generator.Secret, "claim-verifier-test: a 2nd attempt")
 	assert.Equal(t, secret.ObjectMeta.Name, "foo", "claim-verifier-test: a 2nd attempt")
@@ -145,13 +149,13 @@ func TestClaimVerifier(t *testing.T) {
 	assert.Equal(t, record.ObjectMeta.Annotations[types.ClaimsMade], "2", "claim-verifier-test: a")
 
 	//test claim that does not exist
-	secret, _, code = verifier.redeemClaim("not-there", "foo", []byte("abcdefg"), generator)
+	secret, _, code = verifier.redeemClaim("not-there", claimRequester{siteId: "foo"}, []byte("abcdefg"), generator)
 	//  - check the result is as expected
 	assert.Equal(t, code, http.StatusNotFound, "claim-verifier-test: not-there")
 	assert.Assert(t, secret == nil, "claim-verifier-test: not-there")
 
 	//test expired claim
-	secret, _, code = verifier.redeemClaim("b", "foo", []byte("abcdefg"), generator)
+	secret, _, code = verifier.redeemClaim("b", claimRequester{siteId: "foo"}, []byte("abcdefg"), generator)
 	assert.Equal(t, code, http.StatusNotFound, "claim-verifier-test: b")
 	assert.Assert(t, secret == nil, "claim-verifier-test: b")
 }
@@ -200,7 +204,7 @@ func TestServeClaims(t *testing.T) {
 		KubeClient: fake.NewSimpleClientset(),
 	}
 	generator := newMockTokenGenerator(nil)
-	verifier := newClaimVerifier(cli.KubeClient, cli.Namespace, generator, cli)
+	verifier := newClaimVerifier(cli.KubeClient, cli.Namespace, generator, cli, nil)
 	err := createClaimRecord(cli, "myclaim", []byte("abcdefg"), nil, 1)
 	assert.Check(t, err, "serve-claims-test: creating mytoken")
 	err = createClaimRecord(cli, "anotherclaim", []byte("password"), nil, 1)
@@ -217,3 +221,249 @@ func TestServeClaims(t *testing.T) {
 		assert.Equal(t, res.Code, test.expectedCode, name)
 	}
 }
+
+func TestClaimRequestLimits(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	cli := &TestClientContext{
+		Namespace:  "claim-limits-test",
+		KubeClient: fake.NewSimpleClientset(),
+	}
+	verifier := newClaimVerifier(cli.KubeClient, cli.Namespace, newMockTokenGenerator(nil), cli, nil)
+	verifier.limiter = utils.NewAttemptLimiter(0, 0, 2, time.Minute, time.Hour)
+	err := createClaimRecord(cli, "myclaim", []byte("abcdefg"), nil, 2)
+	assert.Check(t, err, "claim-limits-test: creating myclaim")
+
+	var tests = []struct {
+		name         string
+		source       string
+		password     string
+		expectedCode int
+	}{
+		{name: "wrong password", source: "192.0.2.1:1234", password: "guess", expectedCode: http.StatusForbidden},
+		{name: "second wrong password", source: "192.0.2.1:1234", password: "guess", expectedCode: http.StatusForbidden},
+		{name: "locked out", source: "192.0.2.1:1235", password: "abcdefg", expectedCode: http.StatusTooManyRequests},
+		{name: "other source", source: "192.0.2.2:1234", password: "abcdefg", expectedCode: http.StatusOK},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/myclaim", bytes.NewBufferString(test.password))
+		req.RemoteAddr = test.source
+		res := httptest.NewRecorder()
+		verifier.ServeHTTP(res, req)
+		assert.Equal(t, res.Code, test.expectedCode, test.name)
+		if test.expectedCode == http.StatusTooManyRequests {
+			assert.Equal(t, res.Header().Get("Retry-After"), "60", test.name)
+		}
+	}
+
+	verifier.limiter = utils.NewAttemptLimiter(0.1, 1, 0, 0, 0)
+	for _, expectedCode := range []int{http.StatusNotFound, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodPost, "/doesntexist", bytes.NewBufferString("abcdefg"))
+		res := httptest.NewRecorder()
+		verifier.ServeHTTP(res, req)
+		assert.Equal(t, res.Code, expectedCode, "rate limited")
+	}
+}
+
+func TestClaimLimiterFromEnv(t *testing.T) {
+	var tests = []struct {
+		name  string
+		env   string
+		value string
+		err   string
+	}{
+		{name: "defaults"},
+		{name: "rate", env: "SKUPPER_CLAIM_RATE_LIMIT", value: "0.5"},
+		{name: "bad rate", env: "SKUPPER_CLAIM_RATE_LIMIT", value: "fast", err: "invalid SKUPPER_CLAIM_RATE_LIMIT"},
+		{name: "bad burst", env: "SKUPPER_CLAIM_RATE_BURST", value: "0", err: "invalid SKUPPER_CLAIM_RATE_BURST"},
+		{name: "bad failures", env: "SKUPPER_CLAIM_MAX_FAILURES", value: "-1", err: "invalid SKUPPER_CLAIM_MAX_FAILURES"},
+		{name: "lockout", env: "SKUPPER_CLAIM_LOCKOUT", value: "5m"},
+		{name: "bad lockout", env: "SKUPPER_CLAIM_LOCKOUT", value: "5", err: "invalid SKUPPER_CLAIM_LOCKOUT"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.env != "" {
+				t.Setenv(test.env, test.value)
+			}
+			limiter, err := newClaimLimiterFromEnv()
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+			} else {
+				assert.Assert(t, err)
+				assert.Assert(t, limiter != nil)
+			}
+		})
+	}
+}
+
+type MockLinkCreator struct {
+	Options []types.ConnectorCreateOptions
+}
+
+func (o *MockLinkCreator) ConnectorCreateSecretFromData(ctx context.Context, options types.ConnectorCreateOptions) (*corev1.Secret, error) {
+	o.Options = append(o.Options, options)
+	return options.Secret, nil
+}
+
+func encodeReverseClaim(t *testing.T, label string, cost string) string {
+	claim := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "reverse",
+			Labels:      map[string]string{types.SkupperTypeQualifier: label},
+			Annotations: map[string]string{types.TokenCost: cost},
+		},
+	}
+	var buf bytes.Buffer
+	s := json.NewYAMLSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+	assert.Assert(t, s.Encode(claim, &buf))
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestCreateReverseLink(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	cli := &TestClientContext{
+		Namespace:  "reverse-link-test",
+		KubeClient: fake.NewSimpleClientset(),
+	}
+	assert.Assert(t, createClaimRecord(cli, "mutual", []byte("abcdefg"), nil, 1))
+	assert.Assert(t, createClaimRecord(cli, "oneway", []byte("abcdefg"), nil, 1))
+	record, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(context.TODO(), "mutual", metav1.GetOptions{})
+	assert.Assert(t, err)
+	record.ObjectMeta.Annotations[types.ClaimMutual] = "true"
+	_, err = cli.KubeClient.CoreV1().Secrets(cli.Namespace).Update(context.TODO(), record, metav1.UpdateOptions{})
+	assert.Assert(t, err)
+
+	var tests = []struct {
+		name    string
+		claim   string
+		reverse string
+		err     string
+		cost    int32
+	}{
+		{
+			name:  "no-reverse-claim",
+			claim: "mutual",
+		},
+		{
+			name:    "mutual",
+			claim:   "mutual",
+			reverse: encodeReverseClaim(t, types.TypeClaimRequest, "5"),
+			cost:    5,
+		},
+		{
+			name:    "not-mutual",
+			claim:   "oneway",
+			reverse: encodeReverseClaim(t, types.TypeClaimRequest, "5"),
+			err:     "does not allow a reverse link",
+		},
+		{
+			name:    "not-a-claim",
+			claim:   "mutual",
+			reverse: encodeReverseClaim(t, types.TypeToken, "5"),
+			err:     "not a token claim",
+		},
+		{
+			name:    "invalid",
+			claim:   "mutual",
+			reverse: "%%%",
+			err:     "invalid reverse claim",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			linkCreator := &MockLinkCreator{}
+			verifier := newClaimVerifier(cli.KubeClient, cli.Namespace, newMockTokenGenerator(nil), cli, linkCreator)
+			err := verifier.createReverseLink(test.claim, test.reverse)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				assert.Equal(t, len(linkCreator.Options), 0)
+			} else if test.reverse == "" {
+				assert.Assert(t, err)
+				assert.Equal(t, len(linkCreator.Options), 0)
+			} else {
+				assert.Assert(t, err)
+				assert.Equal(t, len(linkCreator.Options), 1)
+				assert.Equal(t, linkCreator.Options[0].Cost, test.cost)
+				assert.Equal(t, linkCreator.Options[0].SkupperNamespace, cli.Namespace)
+				assert.Equal(t, linkCreator.Options[0].Secret.ObjectMeta.Name, "reverse")
+			}
+		})
+	}
+}
+
+func TestServeClaimPolicy(t *testing.T) {
+	event.StartDefaultEventStore(nil)
+	cli := &TestClientContext{
+		Namespace:  "claim-policy-test",
+		KubeClient: fake.NewSimpleClientset(),
+	}
+	generator := newMockTokenGenerator(nil)
+	generator.Secret.ObjectMeta.Annotations = map[string]string{
+		"inter-router-host": "inter-router.example.com",
+		"inter-router-port": "55671",
+		"edge-host":         "edge.example.com",
+		"edge-port":         "45671",
+	}
+	verifier := newClaimVerifier(cli.KubeClient, cli.Namespace, generator, cli, nil)
+	assert.Assert(t, createClaimRecord(cli, "restricted", []byte("abcdefg"), nil, 1))
+	record, err := cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(context.TODO(), "restricted", metav1.GetOptions{})
+	assert.Assert(t, err)
+	annotateClaimPolicy(record, types.ClaimPolicy{AllowedSites: []string{"edge-*"}, EdgeOnly: true, MaxCost: 2})
+	_, err = cli.KubeClient.CoreV1().Secrets(cli.Namespace).Update(context.TODO(), record, metav1.UpdateOptions{})
+	assert.Assert(t, err)
+
+	var tests = []struct {
+		name         string
+		headers      map[string]string
+		expectedCode int
+	}{
+		{
+			name:         "old-site",
+			expectedCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:         "interior-site",
+			headers:      map[string]string{types.ClaimSiteNameHeader: "edge-1", types.ClaimSiteModeHeader: "interior", types.ClaimLinkCostHeader: "1"},
+			expectedCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:         "expensive-link",
+			headers:      map[string]string{types.ClaimSiteNameHeader: "edge-1", types.ClaimSiteModeHeader: "edge", types.ClaimLinkCostHeader: "3"},
+			expectedCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:         "allowed",
+			headers:      map[string]string{types.ClaimSiteNameHeader: "edge-1", types.ClaimSiteModeHeader: "edge", types.ClaimLinkCostHeader: "1"},
+			expectedCode: http.StatusOK,
+		},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/restricted", bytes.NewBufferString("abcdefg"))
+		for key, value := range test.headers {
+			req.Header.Set(key, value)
+		}
+		res := httptest.NewRecorder()
+		verifier.ServeHTTP(res, req)
+		assert.Equal(t, res.Code, test.expectedCode, test.name)
+		if res.Code != http.StatusOK {
+			continue
+		}
+		// the issued token only allows an edge link at the reported cost
+		s := json.NewYAMLSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+		token := &corev1.Secret{}
+		_, _, err = s.Decode(res.Body.Bytes(), nil, token)
+		assert.Assert(t, err)
+		_, ok := token.ObjectMeta.Annotations["inter-router-host"]
+		assert.Assert(t, !ok, test.name)
+		assert.Equal(t, token.ObjectMeta.Annotations["edge-host"], "edge.example.com", test.name)
+		assert.Equal(t, token.ObjectMeta.Annotations[types.TokenCost], "1", test.name)
+	}
+	// the refused requests did not use the claim up
+	record, err = cli.KubeClient.CoreV1().Secrets(cli.Namespace).Get(context.TODO(), "restricted", metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, record.ObjectMeta.Annotations[types.ClaimsRemaining], "0")
+	assert.Equal(t, record.ObjectMeta.Annotations[types.ClaimsMade], "1")
+}
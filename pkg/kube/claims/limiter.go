@@ -0,0 +1,99 @@
+package claims
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/skupperproject/skupper/pkg/utils"
+)
+
+const (
+	defaultClaimRateLimit   = 0.2
+	defaultClaimRateBurst   = 10
+	defaultClaimMaxFailures = 5
+	defaultClaimLockout     = time.Minute
+	claimMaxLockout         = time.Hour
+)
+
+// ClaimAuditEvent is a single redemption attempt, written as a line of json
+type ClaimAuditEvent struct {
+	Time     time.Time `json:"time"`
+	Claim    string    `json:"claim"`
+	Subject  string    `json:"subject,omitempty"`
+	SourceIP string    `json:"sourceIP"`
+	Outcome  string    `json:"outcome"`
+	Status   int       `json:"status"`
+	Lockout  int64     `json:"lockoutSeconds,omitempty"`
+}
+
+func auditClaim(event ClaimAuditEvent) {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	log.Printf("CLAIM AUDIT %s", line)
+}
+
+func newClaimLimiter() *utils.AttemptLimiter {
+	return utils.NewAttemptLimiter(defaultClaimRateLimit, defaultClaimRateBurst, defaultClaimMaxFailures, defaultClaimLockout, claimMaxLockout)
+}
+
+// newClaimLimiterFromEnv reads SKUPPER_CLAIM_RATE_LIMIT, the redemptions
+// allowed per second from a source ip, SKUPPER_CLAIM_RATE_BURST,
+// SKUPPER_CLAIM_MAX_FAILURES, the consecutive failed redemptions that lock
+// the source out, and SKUPPER_CLAIM_LOCKOUT, the first of those lockouts
+func newClaimLimiterFromEnv() (*utils.AttemptLimiter, error) {
+	rate := defaultClaimRateLimit
+	burst := defaultClaimRateBurst
+	maxFailures := defaultClaimMaxFailures
+	lockout := defaultClaimLockout
+	if value := os.Getenv("SKUPPER_CLAIM_RATE_LIMIT"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid SKUPPER_CLAIM_RATE_LIMIT %q", value)
+		}
+		rate = parsed
+	}
+	if value := os.Getenv("SKUPPER_CLAIM_RATE_BURST"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return nil, fmt.Errorf("invalid SKUPPER_CLAIM_RATE_BURST %q", value)
+		}
+		burst = parsed
+	}
+	if value := os.Getenv("SKUPPER_CLAIM_MAX_FAILURES"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid SKUPPER_CLAIM_MAX_FAILURES %q", value)
+		}
+		maxFailures = parsed
+	}
+	if value := os.Getenv("SKUPPER_CLAIM_LOCKOUT"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid SKUPPER_CLAIM_LOCKOUT %q", value)
+		}
+		lockout = parsed
+	}
+	return utils.NewAttemptLimiter(rate, burst, maxFailures, lockout, claimMaxLockout), nil
+}
+
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func tooManyRequests(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	http.Error(w, "Too many claim requests", http.StatusTooManyRequests)
+}
@@ -0,0 +1,155 @@
+package claims
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+// claimRequester is the site redeeming a claim, as reported by it. Nothing
+// but the reported values identifies the site, so the allowed site names
+// are advisory, while the mode and the cost are enforced by the token
+// issued, through restrictToken.
+type claimRequester struct {
+	siteId   string
+	siteName string
+	mode     string
+	cost     string
+}
+
+// errClaimNotYetValid is returned for claims redeemed before their
+// validity window
+type errClaimNotYetValid struct {
+	notBefore time.Time
+}
+
+func (e errClaimNotYetValid) Error() string {
+	return fmt.Sprintf("claim not valid before %s", e.notBefore.Format(time.RFC3339))
+}
+
+// ValidateClaimPolicy checks the policy can be satisfied by some site
+func ValidateClaimPolicy(policy types.ClaimPolicy, expiry time.Duration) error {
+	for _, pattern := range policy.AllowedSites {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid allowed site pattern %q: %w", pattern, err)
+		}
+	}
+	if policy.MaxCost < 0 {
+		return fmt.Errorf("invalid max cost %d, it must be positive", policy.MaxCost)
+	}
+	if !policy.NotBefore.IsZero() && expiry > 0 && !policy.NotBefore.Before(time.Now().Add(expiry)) {
+		return fmt.Errorf("the claim expires before it becomes valid at %s", policy.NotBefore.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func annotateClaimPolicy(record *corev1.Secret, policy types.ClaimPolicy) {
+	if len(policy.AllowedSites) > 0 {
+		record.ObjectMeta.Annotations[types.ClaimAllowedSites] = strings.Join(policy.AllowedSites, ",")
+	}
+	if policy.EdgeOnly {
+		record.ObjectMeta.Annotations[types.ClaimEdgeOnly] = "true"
+	}
+	if policy.MaxCost > 0 {
+		record.ObjectMeta.Annotations[types.ClaimMaxCost] = strconv.Itoa(policy.MaxCost)
+	}
+	if !policy.NotBefore.IsZero() {
+		record.ObjectMeta.Annotations[types.ClaimNotBefore] = policy.NotBefore.UTC().Format(time.RFC3339)
+	}
+}
+
+func readClaimPolicy(record *corev1.Secret) (types.ClaimPolicy, error) {
+	policy := types.ClaimPolicy{}
+	annotations := record.ObjectMeta.Annotations
+	if value := annotations[types.ClaimAllowedSites]; value != "" {
+		policy.AllowedSites = strings.Split(value, ",")
+	}
+	policy.EdgeOnly = annotations[types.ClaimEdgeOnly] == "true"
+	if value := annotations[types.ClaimMaxCost]; value != "" {
+		cost, err := strconv.Atoi(value)
+		if err != nil {
+			return policy, fmt.Errorf("invalid max cost: %w", err)
+		}
+		policy.MaxCost = cost
+	}
+	if value := annotations[types.ClaimNotBefore]; value != "" {
+		notBefore, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return policy, fmt.Errorf("invalid start of validity: %w", err)
+		}
+		policy.NotBefore = notBefore
+	}
+	return policy, nil
+}
+
+// checkClaimPolicy returns why the requester is not allowed to redeem a
+// claim with the policy, nil when it is allowed. It only refuses the
+// sites honestly reporting what the policy does not allow, a site naming
+// itself after an allowed one being let through.
+func checkClaimPolicy(policy types.ClaimPolicy, requester claimRequester, now time.Time) error {
+	if !policy.NotBefore.IsZero() && now.Before(policy.NotBefore) {
+		return errClaimNotYetValid{notBefore: policy.NotBefore}
+	}
+	if len(policy.AllowedSites) > 0 {
+		if requester.siteName == "" {
+			return fmt.Errorf("the claim is restricted to sites %s and the redeeming site did not report its name", strings.Join(policy.AllowedSites, ", "))
+		}
+		allowed := false
+		for _, pattern := range policy.AllowedSites {
+			if matched, _ := path.Match(pattern, requester.siteName); matched {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("site %s is not allowed to redeem the claim", requester.siteName)
+		}
+	}
+	if policy.EdgeOnly && requester.mode != string(types.TransportModeEdge) {
+		if requester.mode == "" {
+			return fmt.Errorf("the claim is restricted to edge sites and the redeeming site did not report its mode")
+		}
+		return fmt.Errorf("the claim is restricted to edge sites, the redeeming site is %s", requester.mode)
+	}
+	if policy.MaxCost > 0 {
+		if requester.cost == "" {
+			return fmt.Errorf("the claim limits the link cost to %d and the redeeming site did not report it", policy.MaxCost)
+		}
+		cost, err := strconv.Atoi(requester.cost)
+		if err != nil {
+			return fmt.Errorf("invalid link cost %q", requester.cost)
+		}
+		if cost > policy.MaxCost {
+			return fmt.Errorf("link cost %d exceeds the maximum of %d allowed by the claim", cost, policy.MaxCost)
+		}
+	}
+	return nil
+}
+
+// restrictToken limits the token issued for a claim to what its policy
+// allows, whatever the redeeming site reported. The token of an edge only
+// claim only holds the edge endpoint, an interior router being unable to
+// link through it, and the cost of the link is set by the issuing site
+// when the policy limits it.
+func restrictToken(token *corev1.Secret, policy types.ClaimPolicy, requester claimRequester) {
+	if token.ObjectMeta.Annotations == nil {
+		token.ObjectMeta.Annotations = map[string]string{}
+	}
+	if policy.EdgeOnly {
+		delete(token.ObjectMeta.Annotations, "inter-router-host")
+		delete(token.ObjectMeta.Annotations, "inter-router-port")
+	}
+	if policy.MaxCost > 0 {
+		cost := policy.MaxCost
+		if requested, err := strconv.Atoi(requester.cost); err == nil && requested > 0 && requested < cost {
+			cost = requested
+		}
+		token.ObjectMeta.Annotations[types.TokenCost] = strconv.Itoa(cost)
+	}
+}
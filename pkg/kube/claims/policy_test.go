@@ -0,0 +1,170 @@
+package claims
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func TestClaimPolicy(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		name      string
+		policy    types.ClaimPolicy
+		requester claimRequester
+		err       string
+		early     bool
+	}{
+		{
+			name:      "no policy",
+			requester: claimRequester{siteId: "id"},
+		},
+		{
+			name:      "allowed site",
+			policy:    types.ClaimPolicy{AllowedSites: []string{"west", "edge-*"}},
+			requester: claimRequester{siteId: "id", siteName: "edge-1"},
+		},
+		{
+			name:      "site not allowed",
+			policy:    types.ClaimPolicy{AllowedSites: []string{"west", "edge-*"}},
+			requester: claimRequester{siteId: "id", siteName: "east"},
+			err:       "site east is not allowed",
+		},
+		{
+			name:      "site name not reported",
+			policy:    types.ClaimPolicy{AllowedSites: []string{"west"}},
+			requester: claimRequester{siteId: "id"},
+			err:       "did not report its name",
+		},
+		{
+			name:      "edge site",
+			policy:    types.ClaimPolicy{EdgeOnly: true},
+			requester: claimRequester{siteId: "id", mode: "edge"},
+		},
+		{
+			name:      "interior site",
+			policy:    types.ClaimPolicy{EdgeOnly: true},
+			requester: claimRequester{siteId: "id", mode: "interior"},
+			err:       "restricted to edge sites, the redeeming site is interior",
+		},
+		{
+			name:      "cost within limit",
+			policy:    types.ClaimPolicy{MaxCost: 5},
+			requester: claimRequester{siteId: "id", cost: "5"},
+		},
+		{
+			name:      "cost above limit",
+			policy:    types.ClaimPolicy{MaxCost: 5},
+			requester: claimRequester{siteId: "id", cost: "10"},
+			err:       "link cost 10 exceeds the maximum of 5",
+		},
+		{
+			name:      "cost not reported",
+			policy:    types.ClaimPolicy{MaxCost: 5},
+			requester: claimRequester{siteId: "id"},
+			err:       "did not report it",
+		},
+		{
+			name:      "within validity window",
+			policy:    types.ClaimPolicy{NotBefore: now.Add(-time.Minute)},
+			requester: claimRequester{siteId: "id"},
+		},
+		{
+			name:      "before validity window",
+			policy:    types.ClaimPolicy{NotBefore: now.Add(time.Hour)},
+			requester: claimRequester{siteId: "id"},
+			err:       "claim not valid before 2023-06-01T13:00:00Z",
+			early:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			record := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+			annotateClaimPolicy(record, test.policy)
+			policy, err := readClaimPolicy(record)
+			assert.Assert(t, err)
+			assert.DeepEqual(t, policy, test.policy)
+
+			err = checkClaimPolicy(policy, test.requester, now)
+			if test.err == "" {
+				assert.Assert(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, test.err)
+			_, early := err.(errClaimNotYetValid)
+			assert.Equal(t, early, test.early)
+		})
+	}
+}
+
+func TestRestrictToken(t *testing.T) {
+	var tests = []struct {
+		name                string
+		policy              types.ClaimPolicy
+		requester           claimRequester
+		interRouterEndpoint bool
+		cost                string
+	}{
+		{
+			name:                "no policy",
+			requester:           claimRequester{siteId: "id", mode: "interior", cost: "10"},
+			interRouterEndpoint: true,
+		},
+		{
+			name:      "edge only",
+			policy:    types.ClaimPolicy{EdgeOnly: true},
+			requester: claimRequester{siteId: "id", mode: "edge"},
+		},
+		{
+			name:                "requested cost",
+			policy:              types.ClaimPolicy{MaxCost: 5},
+			requester:           claimRequester{siteId: "id", cost: "2"},
+			interRouterEndpoint: true,
+			cost:                "2",
+		},
+		{
+			name:                "cost not reported",
+			policy:              types.ClaimPolicy{MaxCost: 5},
+			requester:           claimRequester{siteId: "id"},
+			interRouterEndpoint: true,
+			cost:                "5",
+		},
+		{
+			name:                "cost above limit",
+			policy:              types.ClaimPolicy{MaxCost: 5},
+			requester:           claimRequester{siteId: "id", cost: "10"},
+			interRouterEndpoint: true,
+			cost:                "5",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+				"inter-router-host": "inter-router.example.com",
+				"inter-router-port": "55671",
+				"edge-host":         "edge.example.com",
+				"edge-port":         "45671",
+			}}}
+			restrictToken(token, test.policy, test.requester)
+			_, ok := token.ObjectMeta.Annotations["inter-router-host"]
+			assert.Equal(t, ok, test.interRouterEndpoint)
+			_, ok = token.ObjectMeta.Annotations["inter-router-port"]
+			assert.Equal(t, ok, test.interRouterEndpoint)
+			assert.Equal(t, token.ObjectMeta.Annotations["edge-host"], "edge.example.com")
+			assert.Equal(t, token.ObjectMeta.Annotations[types.TokenCost], test.cost)
+		})
+	}
+}
+
+func TestValidateClaimPolicy(t *testing.T) {
+	assert.Assert(t, ValidateClaimPolicy(types.ClaimPolicy{AllowedSites: []string{"edge-*"}, MaxCost: 3}, time.Hour))
+	assert.ErrorContains(t, ValidateClaimPolicy(types.ClaimPolicy{AllowedSites: []string{"edge-["}}, 0), "invalid allowed site pattern")
+	assert.ErrorContains(t, ValidateClaimPolicy(types.ClaimPolicy{MaxCost: -1}, 0), "invalid max cost")
+	assert.ErrorContains(t, ValidateClaimPolicy(types.ClaimPolicy{NotBefore: time.Now().Add(2 * time.Hour)}, time.Hour), "expires before it becomes valid")
+	assert.Assert(t, ValidateClaimPolicy(types.ClaimPolicy{NotBefore: time.Now().Add(2 * time.Hour)}, 0))
+}
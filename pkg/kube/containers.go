@@ -200,7 +200,7 @@ func ContainerForPrometheusServer(ds types.DeploymentSpec) corev1.Container {
 	container := corev1.Container{
 		Name:            types.PrometheusContainerName,
 		Image:           ds.Image.Name,
-		Args:            []string{"--config.file=/etc/prometheus/prometheus.yml", "--storage.tsdb.path=/prometheus/", "--web.config.file=/etc/prometheus/web-config.yml"},
+		Args:            []string{"--config.file=/etc/prometheus/prometheus.yml", "--storage.tsdb.path=/prometheus/", "--web.config.file=/etc/prometheus/web-config.yml", "--enable-feature=exemplar-storage"},
 		Env:             ds.EnvVar,
 		VolumeMounts:    []corev1.VolumeMount{},
 		ImagePullPolicy: GetPullPolicy(ds.Image.PullPolicy),
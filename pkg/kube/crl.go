@@ -0,0 +1,208 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
+)
+
+const (
+	// the certificates issued by the site CA to linked sites
+	CrlCertificatesKey string = "certificates.json"
+	// the revocation list, signed by the site CA
+	CrlKey string = "crl.pem"
+)
+
+// ReadIssuedCertificates returns the certificates recorded in the CRL secret
+func ReadIssuedCertificates(secret *corev1.Secret) ([]certs.IssuedCertificate, error) {
+	issued := []certs.IssuedCertificate{}
+	if secret == nil || len(secret.Data[CrlCertificatesKey]) == 0 {
+		return issued, nil
+	}
+	if err := json.Unmarshal(secret.Data[CrlCertificatesKey], &issued); err != nil {
+		return nil, fmt.Errorf("Invalid issued certificates in %s: %w", secret.Name, err)
+	}
+	return issued, nil
+}
+
+// RevokedFingerprints returns the fingerprints of the revoked certificates
+// recorded in the CRL secret. When the secret holds a revocation list, only
+// the certificates it lists are revoked, once its signature is verified with
+// the site CA. The CAs of older sites cannot sign one, the certificates
+// marked as revoked are returned then.
+func RevokedFingerprints(secret *corev1.Secret, ca *corev1.Secret) ([]string, error) {
+	issued, err := ReadIssuedCertificates(secret)
+	if err != nil {
+		return nil, err
+	}
+	revoked := []string{}
+	if secret != nil && len(secret.Data[CrlKey]) > 0 {
+		serials, err := certs.RevokedSerials(secret.Data[CrlKey], ca)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid revocation list in %s: %w", secret.Name, err)
+		}
+		for _, cert := range issued {
+			for _, serial := range serials {
+				if cert.Serial == serial {
+					revoked = append(revoked, cert.Fingerprint)
+					break
+				}
+			}
+		}
+		return revoked, nil
+	}
+	for _, cert := range issued {
+		if cert.Revoked() {
+			revoked = append(revoked, cert.Fingerprint)
+		}
+	}
+	return revoked, nil
+}
+
+// updateIssuedCertificates applies the update to the certificates recorded
+// in the CRL secret, dropping those that have expired, and signs the
+// revocation list again with the site CA
+func updateIssuedCertificates(namespace string, cli kubernetes.Interface, update func([]certs.IssuedCertificate) ([]certs.IssuedCertificate, error)) ([]certs.IssuedCertificate, error) {
+	var result []certs.IssuedCertificate
+	isRetriable := func(err error) bool {
+		return errors.IsConflict(err) || errors.IsAlreadyExists(err)
+	}
+	err := retry.OnError(retry.DefaultRetry, isRetriable, func() error {
+		ca, err := cli.CoreV1().Secrets(namespace).Get(context.TODO(), types.SiteCaSecret, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		secret, err := cli.CoreV1().Secrets(namespace).Get(context.TODO(), types.SiteCrlSecret, metav1.GetOptions{})
+		create := errors.IsNotFound(err)
+		if create {
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: types.SiteCrlSecret,
+					Labels: map[string]string{
+						types.SkupperTypeQualifier: types.TypeSiteCrl,
+					},
+					OwnerReferences: ca.ObjectMeta.OwnerReferences,
+				},
+			}
+		} else if err != nil {
+			return err
+		}
+		current, err := ReadIssuedCertificates(secret)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		valid := []certs.IssuedCertificate{}
+		for _, cert := range current {
+			if cert.NotAfter.After(now) {
+				valid = append(valid, cert)
+			}
+		}
+		result, err = update(valid)
+		if err != nil {
+			return err
+		}
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[CrlCertificatesKey] = encoded
+		crl, err := certs.GenerateCRL(ca, result, now.Unix(), now)
+		if err == certs.ErrCannotSignCRL {
+			// the revoked certificates are still refused by the controller
+			delete(secret.Data, CrlKey)
+		} else if err != nil {
+			return fmt.Errorf("Failed to sign the revocation list: %w", err)
+		} else {
+			secret.Data[CrlKey] = crl
+		}
+		if create {
+			_, err = cli.CoreV1().Secrets(namespace).Create(context.TODO(), secret, metav1.CreateOptions{})
+		} else {
+			_, err = cli.CoreV1().Secrets(namespace).Update(context.TODO(), secret, metav1.UpdateOptions{})
+		}
+		return err
+	})
+	return result, err
+}
+
+// RecordIssuedCertificate records the certificate of a token issued by the
+// site CA, so that it can be revoked later on
+func RecordIssuedCertificate(token *corev1.Secret, namespace string, cli kubernetes.Interface) error {
+	issued, err := certs.NewIssuedCertificate(token.Data["tls.crt"])
+	if err != nil {
+		return fmt.Errorf("Invalid certificate in token %s: %w", token.Name, err)
+	}
+	_, err = updateIssuedCertificates(namespace, cli, func(current []certs.IssuedCertificate) ([]certs.IssuedCertificate, error) {
+		return append(current, issued), nil
+	})
+	return err
+}
+
+// RevokeIssuedCertificates revokes the certificates issued by the site CA
+// whose subject, serial number or fingerprint matches, returning them
+func RevokeIssuedCertificates(match string, namespace string, cli kubernetes.Interface) ([]certs.IssuedCertificate, error) {
+	var revoked []certs.IssuedCertificate
+	_, err := updateIssuedCertificates(namespace, cli, func(current []certs.IssuedCertificate) ([]certs.IssuedCertificate, error) {
+		revoked = nil
+		now := time.Now().UTC()
+		for i, cert := range current {
+			if cert.Revoked() {
+				continue
+			}
+			if cert.Subject == match || cert.Serial == match || certs.SameFingerprint(cert.Fingerprint, match) {
+				current[i].RevokedAt = &now
+				revoked = append(revoked, current[i])
+			}
+		}
+		if len(revoked) == 0 {
+			return nil, fmt.Errorf("No valid link credentials issued to %s", match)
+		}
+		return current, nil
+	})
+	return revoked, err
+}
+
+// PruneIssuedCertificates drops the expired certificates from the CRL
+// secret, which otherwise only happens when a certificate is issued or
+// revoked
+func PruneIssuedCertificates(namespace string, cli kubernetes.Interface) error {
+	secret, err := cli.CoreV1().Secrets(namespace).Get(context.TODO(), types.SiteCrlSecret, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	current, err := ReadIssuedCertificates(secret)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	expired := false
+	for _, cert := range current {
+		if !cert.NotAfter.After(now) {
+			expired = true
+			break
+		}
+	}
+	if !expired {
+		return nil
+	}
+	_, err = updateIssuedCertificates(namespace, cli, func(valid []certs.IssuedCertificate) ([]certs.IssuedCertificate, error) {
+		return valid, nil
+	})
+	return err
+}
@@ -0,0 +1,171 @@
+package kube
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/certs"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRevokeIssuedCertificates(t *testing.T) {
+	const NS = "test"
+	kubeClient := fake.NewSimpleClientset()
+
+	ca := certs.GenerateCASecret(types.SiteCaSecret, types.SiteCaSecret)
+	_, err := kubeClient.CoreV1().Secrets(NS).Create(context.TODO(), &ca, metav1.CreateOptions{})
+	assert.Assert(t, err)
+
+	issued := map[string]certs.IssuedCertificate{}
+	for _, subject := range []string{"east", "west", "west"} {
+		token := certs.GenerateSecret(subject, subject, "", &ca)
+		assert.Assert(t, RecordIssuedCertificate(&token, NS, kubeClient))
+		cert, err := certs.NewIssuedCertificate(token.Data["tls.crt"])
+		assert.Assert(t, err)
+		issued[cert.Fingerprint] = cert
+	}
+
+	secret, err := kubeClient.CoreV1().Secrets(NS).Get(context.TODO(), types.SiteCrlSecret, metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, secret.Labels[types.SkupperTypeQualifier], types.TypeSiteCrl)
+	recorded, err := ReadIssuedCertificates(secret)
+	assert.Assert(t, err)
+	assert.Equal(t, len(recorded), 3)
+	revoked, err := RevokedFingerprints(secret, &ca)
+	assert.Assert(t, err)
+	assert.Equal(t, len(revoked), 0)
+
+	_, err = RevokeIssuedCertificates("north", NS, kubeClient)
+	assert.ErrorContains(t, err, "No valid link credentials issued to north")
+
+	certificates, err := RevokeIssuedCertificates("west", NS, kubeClient)
+	assert.Assert(t, err)
+	assert.Equal(t, len(certificates), 2)
+	for _, cert := range certificates {
+		assert.Equal(t, cert.Subject, "west")
+		assert.Assert(t, cert.Revoked())
+	}
+
+	// a certificate can also be revoked by its serial number
+	var east certs.IssuedCertificate
+	for _, cert := range issued {
+		if cert.Subject == "east" {
+			east = cert
+		}
+	}
+	_, err = RevokeIssuedCertificates("west", NS, kubeClient)
+	assert.ErrorContains(t, err, "No valid link credentials issued to west")
+	certificates, err = RevokeIssuedCertificates(east.Serial, NS, kubeClient)
+	assert.Assert(t, err)
+	assert.Equal(t, len(certificates), 1)
+	assert.Equal(t, certificates[0].Fingerprint, east.Fingerprint)
+
+	secret, err = kubeClient.CoreV1().Secrets(NS).Get(context.TODO(), types.SiteCrlSecret, metav1.GetOptions{})
+	assert.Assert(t, err)
+	revoked, err = RevokedFingerprints(secret, &ca)
+	assert.Assert(t, err)
+	assert.Equal(t, len(revoked), 3)
+	for _, fingerprint := range revoked {
+		_, ok := issued[fingerprint]
+		assert.Assert(t, ok, fingerprint)
+	}
+
+	block, _ := pem.Decode(secret.Data[CrlKey])
+	assert.Assert(t, block != nil)
+	crl, err := x509.ParseRevocationList(block.Bytes)
+	assert.Assert(t, err)
+	caCert, err := certs.DecodeCertificate(ca.Data["tls.crt"])
+	assert.Assert(t, err)
+	assert.Assert(t, crl.CheckSignatureFrom(caCert))
+	assert.Equal(t, len(crl.RevokedCertificates), 3)
+	for _, entry := range crl.RevokedCertificates {
+		found := false
+		for _, cert := range issued {
+			if cert.Serial == entry.SerialNumber.Text(16) {
+				found = true
+			}
+		}
+		assert.Assert(t, found, entry.SerialNumber.Text(16))
+	}
+}
+
+func TestRevokedFingerprintsFromCrl(t *testing.T) {
+	ca := certs.GenerateCASecret(types.SiteCaSecret, types.SiteCaSecret)
+	other := certs.GenerateCASecret(types.SiteCaSecret, types.SiteCaSecret)
+	token := certs.GenerateSecret("west", "west", "", &ca)
+	cert, err := certs.NewIssuedCertificate(token.Data["tls.crt"])
+	assert.Assert(t, err)
+	now := time.Now()
+	cert.RevokedAt = &now
+
+	encoded, err := json.Marshal([]certs.IssuedCertificate{cert})
+	assert.Assert(t, err)
+	crl, err := certs.GenerateCRL(&ca, []certs.IssuedCertificate{cert}, 1, now)
+	assert.Assert(t, err)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: types.SiteCrlSecret},
+		Data: map[string][]byte{
+			CrlCertificatesKey: encoded,
+			CrlKey:             crl,
+		},
+	}
+	revoked, err := RevokedFingerprints(secret, &ca)
+	assert.Assert(t, err)
+	assert.DeepEqual(t, revoked, []string{cert.Fingerprint})
+
+	// the list must be signed by the site CA
+	_, err = RevokedFingerprints(secret, &other)
+	assert.ErrorContains(t, err, "not signed by the CA")
+
+	// only the certificates in the list are revoked
+	empty, err := certs.GenerateCRL(&ca, nil, 2, now)
+	assert.Assert(t, err)
+	secret.Data[CrlKey] = empty
+	revoked, err = RevokedFingerprints(secret, &ca)
+	assert.Assert(t, err)
+	assert.Equal(t, len(revoked), 0)
+}
+
+func TestPruneIssuedCertificates(t *testing.T) {
+	const NS = "test"
+	kubeClient := fake.NewSimpleClientset()
+	assert.Assert(t, PruneIssuedCertificates(NS, kubeClient))
+
+	ca := certs.GenerateCASecret(types.SiteCaSecret, types.SiteCaSecret)
+	_, err := kubeClient.CoreV1().Secrets(NS).Create(context.TODO(), &ca, metav1.CreateOptions{})
+	assert.Assert(t, err)
+	token := certs.GenerateSecret("east", "east", "", &ca)
+	valid, err := certs.NewIssuedCertificate(token.Data["tls.crt"])
+	assert.Assert(t, err)
+	expired := certs.IssuedCertificate{
+		Serial:      "1",
+		Fingerprint: "aa01",
+		Subject:     "west",
+		NotAfter:    time.Now().Add(-time.Hour),
+	}
+	encoded, err := json.Marshal([]certs.IssuedCertificate{valid, expired})
+	assert.Assert(t, err)
+	_, err = kubeClient.CoreV1().Secrets(NS).Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: types.SiteCrlSecret},
+		Data: map[string][]byte{
+			CrlCertificatesKey: encoded,
+		},
+	}, metav1.CreateOptions{})
+	assert.Assert(t, err)
+
+	assert.Assert(t, PruneIssuedCertificates(NS, kubeClient))
+	secret, err := kubeClient.CoreV1().Secrets(NS).Get(context.TODO(), types.SiteCrlSecret, metav1.GetOptions{})
+	assert.Assert(t, err)
+	issued, err := ReadIssuedCertificates(secret)
+	assert.Assert(t, err)
+	assert.Equal(t, len(issued), 1)
+	assert.Equal(t, issued[0].Fingerprint, valid.Fingerprint)
+}
@@ -603,6 +603,32 @@ func WaitDeploymentReady(name string, namespace string, cli kubernetes.Interface
 	return dep, err
 }
 
+// WaitDeploymentRolledOut waits till all the replicas of the given deployment
+// are updated to its latest template and ready, or until it times out
+func WaitDeploymentRolledOut(name string, namespace string, cli kubernetes.Interface, timeout, interval time.Duration) (*appsv1.Deployment, error) {
+	var dep *appsv1.Deployment
+	var err error
+
+	ctx, cancel := context.WithTimeout(context.TODO(), timeout)
+	defer cancel()
+	err = utils.RetryWithContext(ctx, interval, func() (bool, error) {
+		dep, err = cli.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		replicas := int32(1)
+		if dep.Spec.Replicas != nil {
+			replicas = *dep.Spec.Replicas
+		}
+		return dep.Status.ObservedGeneration >= dep.Generation &&
+			dep.Status.UpdatedReplicas == replicas &&
+			dep.Status.ReadyReplicas == replicas &&
+			dep.Status.Replicas == replicas, nil
+	})
+
+	return dep, err
+}
+
 func WaitDaemonSetReady(name string, namespace string, cli kubernetes.Interface, timeout, interval time.Duration) (*appsv1.DaemonSet, error) {
 	var dep *appsv1.DaemonSet
 	var err error
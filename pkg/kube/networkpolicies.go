@@ -16,8 +16,10 @@ package kube
 
 import (
 	"context"
+	"reflect"
 
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
@@ -77,3 +79,104 @@ func CreateNetworkPolicy(ownerrefs []metav1.OwnerReference, namespace string, cl
 	}
 	return nil
 }
+
+// ComponentIngress is the traffic a skupper component needs to receive in
+// namespaces denying ingress by default: any peer may reach its Ports and the
+// Peers may reach any of its ports
+type ComponentIngress struct {
+	Name     string
+	Selector map[string]string
+	Ports    []intstr.IntOrString
+	Peers    []networkingv1.NetworkPolicyPeer
+}
+
+// ComponentNetworkPolicyName is the name of the network policy of a component,
+// distinct from the names of its deployment and services
+func ComponentNetworkPolicyName(component string) string {
+	return component + "-netpol"
+}
+
+func (c ComponentIngress) networkPolicy(ownerrefs []metav1.OwnerReference) *networkingv1.NetworkPolicy {
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            ComponentNetworkPolicyName(c.Name),
+			OwnerReferences: ownerrefs,
+			Labels: map[string]string{
+				types.SkupperTypeQualifier: types.TypeComponentNetworkPolicy,
+			},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: c.Selector,
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     []networkingv1.NetworkPolicyIngressRule{},
+		},
+	}
+	if len(c.Peers) > 0 {
+		policy.Spec.Ingress = append(policy.Spec.Ingress, networkingv1.NetworkPolicyIngressRule{
+			From: c.Peers,
+		})
+	}
+	if len(c.Ports) > 0 {
+		rule := networkingv1.NetworkPolicyIngressRule{}
+		for i := range c.Ports {
+			rule.Ports = append(rule.Ports, networkingv1.NetworkPolicyPort{
+				Port: &c.Ports[i],
+			})
+		}
+		policy.Spec.Ingress = append(policy.Spec.Ingress, rule)
+	}
+	return policy
+}
+
+// ReconcileComponentNetworkPolicies creates or updates a network policy per
+// component, restricting the ingress of its pods to the required peers and
+// ports, and deletes the policies of the components no longer deployed
+func ReconcileComponentNetworkPolicies(components []ComponentIngress, ownerrefs []metav1.OwnerReference, namespace string, cli kubernetes.Interface) error {
+	desired := map[string]*networkingv1.NetworkPolicy{}
+	for _, component := range components {
+		policy := component.networkPolicy(ownerrefs)
+		desired[policy.Name] = policy
+	}
+	existing, err := cli.NetworkingV1().NetworkPolicies(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: types.ComponentPolicySelector})
+	if err != nil {
+		return err
+	}
+	for _, current := range existing.Items {
+		policy, ok := desired[current.Name]
+		if !ok {
+			err = cli.NetworkingV1().NetworkPolicies(namespace).Delete(context.TODO(), current.Name, metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			continue
+		}
+		delete(desired, current.Name)
+		if reflect.DeepEqual(current.Spec, policy.Spec) {
+			continue
+		}
+		current.Spec = policy.Spec
+		_, err = cli.NetworkingV1().NetworkPolicies(namespace).Update(context.TODO(), &current, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	for _, component := range components {
+		policy, ok := desired[ComponentNetworkPolicyName(component.Name)]
+		if !ok {
+			continue
+		}
+		_, err = cli.NetworkingV1().NetworkPolicies(namespace).Create(context.TODO(), policy, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteComponentNetworkPolicies deletes the network policies of the
+// components, once the site no longer generates them
+func DeleteComponentNetworkPolicies(namespace string, cli kubernetes.Interface) error {
+	return ReconcileComponentNetworkPolicies(nil, nil, namespace, cli)
+}
@@ -0,0 +1,94 @@
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/skupperproject/skupper/api/types"
+)
+
+func TestReconcileComponentNetworkPolicies(t *testing.T) {
+	const NS = "test"
+	namespacePods := []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}
+	components := []ComponentIngress{
+		{
+			Name:     "skupper-router",
+			Selector: map[string]string{"skupper.io/component": "router"},
+			Ports:    []intstr.IntOrString{intstr.FromInt(55671), intstr.FromInt(45671)},
+			Peers:    namespacePods,
+		},
+		{
+			Name:     "skupper-service-controller",
+			Selector: map[string]string{"skupper.io/component": "service-controller"},
+			Ports:    []intstr.IntOrString{intstr.FromString("metrics")},
+		},
+		{
+			Name:     "skupper-prometheus",
+			Selector: map[string]string{"skupper.io/component": "prometheus"},
+			Peers: []networkingv1.NetworkPolicyPeer{{
+				PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"skupper.io/component": "service-controller"}},
+			}},
+		},
+	}
+	ownerrefs := []metav1.OwnerReference{{Name: "skupper-site"}}
+	kubeClient := fake.NewSimpleClientset()
+	// a policy of the user is never touched
+	_, err := kubeClient.NetworkingV1().NetworkPolicies(NS).Create(context.TODO(), &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "skupper-router"},
+	}, metav1.CreateOptions{})
+	assert.Assert(t, err)
+
+	assert.Assert(t, ReconcileComponentNetworkPolicies(components, ownerrefs, NS, kubeClient))
+	assert.Assert(t, ReconcileComponentNetworkPolicies(components, ownerrefs, NS, kubeClient))
+
+	policies, err := kubeClient.NetworkingV1().NetworkPolicies(NS).List(context.TODO(), metav1.ListOptions{LabelSelector: types.ComponentPolicySelector})
+	assert.Assert(t, err)
+	assert.Equal(t, len(policies.Items), 3)
+
+	router, err := kubeClient.NetworkingV1().NetworkPolicies(NS).Get(context.TODO(), "skupper-router-netpol", metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.DeepEqual(t, router.OwnerReferences, ownerrefs)
+	assert.DeepEqual(t, router.Spec.PodSelector.MatchLabels, components[0].Selector)
+	assert.DeepEqual(t, router.Spec.PolicyTypes, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress})
+	assert.Equal(t, len(router.Spec.Ingress), 2)
+	assert.DeepEqual(t, router.Spec.Ingress[0].From, namespacePods)
+	assert.Equal(t, len(router.Spec.Ingress[0].Ports), 0)
+	assert.Equal(t, len(router.Spec.Ingress[1].From), 0)
+	assert.Equal(t, len(router.Spec.Ingress[1].Ports), 2)
+	assert.Equal(t, router.Spec.Ingress[1].Ports[0].Port.IntValue(), 55671)
+	assert.Equal(t, router.Spec.Ingress[1].Ports[1].Port.IntValue(), 45671)
+
+	controller, err := kubeClient.NetworkingV1().NetworkPolicies(NS).Get(context.TODO(), "skupper-service-controller-netpol", metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, len(controller.Spec.Ingress), 1)
+	assert.Equal(t, len(controller.Spec.Ingress[0].From), 0)
+	assert.Equal(t, controller.Spec.Ingress[0].Ports[0].Port.String(), "metrics")
+
+	prometheus, err := kubeClient.NetworkingV1().NetworkPolicies(NS).Get(context.TODO(), "skupper-prometheus-netpol", metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, len(prometheus.Spec.Ingress), 1)
+	assert.DeepEqual(t, prometheus.Spec.Ingress[0].From, components[2].Peers)
+	assert.Equal(t, len(prometheus.Spec.Ingress[0].Ports), 0)
+
+	// changed ports are updated and the policies of removed components deleted
+	components[0].Ports = []intstr.IntOrString{intstr.FromInt(55671)}
+	assert.Assert(t, ReconcileComponentNetworkPolicies(components[:2], ownerrefs, NS, kubeClient))
+	router, err = kubeClient.NetworkingV1().NetworkPolicies(NS).Get(context.TODO(), "skupper-router-netpol", metav1.GetOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, len(router.Spec.Ingress[1].Ports), 1)
+	policies, err = kubeClient.NetworkingV1().NetworkPolicies(NS).List(context.TODO(), metav1.ListOptions{LabelSelector: types.ComponentPolicySelector})
+	assert.Assert(t, err)
+	assert.Equal(t, len(policies.Items), 2)
+
+	assert.Assert(t, DeleteComponentNetworkPolicies(NS, kubeClient))
+	policies, err = kubeClient.NetworkingV1().NetworkPolicies(NS).List(context.TODO(), metav1.ListOptions{})
+	assert.Assert(t, err)
+	assert.Equal(t, len(policies.Items), 1)
+	assert.Equal(t, policies.Items[0].Name, "skupper-router")
+}
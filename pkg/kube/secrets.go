@@ -21,7 +21,20 @@ func NewCertAuthority(ca types.CertAuthority, owner *metav1.OwnerReference, name
 	if err == nil {
 		return existing, nil
 	} else if errors.IsNotFound(err) {
-		newCA := certs.GenerateCASecret(ca.Name, ca.Name)
+		var newCA corev1.Secret
+		if ca.Vault == nil {
+			newCA = certs.GenerateCASecret(ca.Name, ca.Name)
+		} else {
+			newCA, err = certs.GenerateVaultCASecret(ca.Name, certs.VaultPki{
+				Address:  ca.Vault.Address,
+				PkiPath:  ca.Vault.PkiPath,
+				Role:     ca.Vault.Role,
+				AuthRole: ca.Vault.AuthRole,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("Failed to retrieve CA %s from vault: %w", ca.Name, err)
+			}
+		}
 		newCA.Labels = ca.Labels
 		if owner != nil {
 			newCA.ObjectMeta.OwnerReferences = []metav1.OwnerReference{
@@ -147,6 +160,12 @@ func RegenerateCertAuthority(name string, namespace string, cli kubernetes.Inter
 	if err != nil {
 		return nil, err
 	}
+	if vault := certs.VaultPkiFromSecret(current); vault != nil {
+		return nil, fmt.Errorf("The CA %s is issued by vault at %s, rotate the issuer of the %s pki secrets engine or revoke the certificates in vault instead", name, vault.Address, vault.PkiPath)
+	}
+	if IsCertManagerSecret(current) {
+		return nil, fmt.Errorf("The CA %s is issued by cert-manager, renew its certificate %s with cmctl renew instead", name, current.Annotations[CertManagerCertificateNameAnnotation])
+	}
 	regenerated := certs.GenerateCASecret(name, name)
 	current.Data = regenerated.Data
 	return cli.CoreV1().Secrets(namespace).Update(context.TODO(), current, metav1.UpdateOptions{})
@@ -157,6 +176,9 @@ func RegenerateCredentials(credential types.Credential, namespace string, ca *co
 	if err != nil {
 		return nil, err
 	}
+	if IsCertManagerSecret(current) {
+		return nil, fmt.Errorf("The secret %s is issued by cert-manager, renew its certificate %s with cmctl renew instead", credential.Name, current.Annotations[CertManagerCertificateNameAnnotation])
+	}
 	regenerated := certs.GenerateSecret(credential.Name, credential.Subject, strings.Join(credential.Hosts, ","), ca)
 	current.Data = regenerated.Data
 	return cli.CoreV1().Secrets(namespace).Update(context.TODO(), current, metav1.UpdateOptions{})
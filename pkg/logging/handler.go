@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+// LevelPath is where the components serve their log level
+const LevelPath = "/loglevel"
+
+type levelResponse struct {
+	Level string `json:"level"`
+}
+
+// LevelHandler returns the log level of the process on GET and changes it
+// on PUT or POST, the new level being either the level query parameter or
+// the level of a json body, so that debug logging can be turned on without
+// a restart
+func LevelHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		value := r.URL.Query().Get("level")
+		if value == "" {
+			var request levelResponse
+			body, err := io.ReadAll(io.LimitReader(r.Body, 1024))
+			if err != nil || json.Unmarshal(body, &request) != nil {
+				http.Error(w, "The body must be a json object with a level", http.StatusBadRequest)
+				return
+			}
+			value = request.Level
+		}
+		level, err := ParseLevel(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		previous := GetLevel()
+		SetLevel(level)
+		// logged whatever the new level, as it explains the change of
+		// verbosity to whoever reads the logs
+		std.write(time.Now(), InfoLevel, "logging", "Log level changed from "+previous.String()+" to "+level.String(), nil)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(levelResponse{Level: GetLevel().String()})
+}
@@ -0,0 +1,286 @@
+// Package logging provides the leveled, structured logs of the skupper
+// components, written either as text lines or as json objects that log
+// aggregators such as Loki or Elasticsearch parse without regular
+// expressions.
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// LevelEnvVar sets the lowest level logged: debug, info, warn or error
+	LevelEnvVar = "SKUPPER_LOG_LEVEL"
+	// FormatEnvVar sets the format of the logs: text or json
+	FormatEnvVar = "SKUPPER_LOG_FORMAT"
+
+	textTimeFormat = "2006/01/02 15:04:05"
+)
+
+type Level int32
+
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < DebugLevel || l > ErrorLevel {
+		return fmt.Sprintf("level(%d)", int32(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel parses the name of a level, case insensitive
+func ParseLevel(value string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "debug":
+		return DebugLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	}
+	return InfoLevel, fmt.Errorf("invalid log level %q, expected one of %s", value, strings.Join(levelNames, ", "))
+}
+
+// output is shared by the loggers of a process, so that the level and the
+// format are set once for all of them
+type output struct {
+	lock   sync.Mutex
+	writer io.Writer
+	json   bool
+	level  int32
+	syslog *SyslogWriter
+	// syslogFailed reports a failure to reach the syslog endpoint once
+	// rather than for every message
+	syslogFailed bool
+}
+
+var std = &output{writer: os.Stderr, level: int32(InfoLevel)}
+
+// SetLevel sets the lowest level logged by every logger
+func SetLevel(level Level) {
+	atomic.StoreInt32(&std.level, int32(level))
+}
+
+// GetLevel returns the lowest level logged
+func GetLevel() Level {
+	return Level(atomic.LoadInt32(&std.level))
+}
+
+// SetOutput sets where the logs are written, standard error by default
+func SetOutput(writer io.Writer) {
+	std.lock.Lock()
+	defer std.lock.Unlock()
+	std.writer = writer
+}
+
+// SetSyslog also sends the logs to a syslog endpoint, or stops sending
+// them when nil
+func SetSyslog(writer *SyslogWriter) {
+	std.lock.Lock()
+	defer std.lock.Unlock()
+	if std.syslog != nil {
+		std.syslog.Close()
+	}
+	std.syslog = writer
+	std.syslogFailed = false
+}
+
+// SetJSON writes the logs as json objects rather than text lines
+func SetJSON(enabled bool) {
+	std.lock.Lock()
+	defer std.lock.Unlock()
+	std.json = enabled
+}
+
+type field struct {
+	key   string
+	value interface{}
+}
+
+// Logger logs the messages of a component, along with its fields
+type Logger struct {
+	component string
+	fields    []field
+}
+
+func New(component string) *Logger {
+	return &Logger{component: component}
+}
+
+// With returns a logger adding the field to each message
+func (l *Logger) With(key string, value interface{}) *Logger {
+	fields := make([]field, len(l.fields), len(l.fields)+1)
+	copy(fields, l.fields)
+	return &Logger{component: l.component, fields: append(fields, field{key, value})}
+}
+
+// Enabled reports whether the messages of the level are logged
+func (l *Logger) Enabled(level Level) bool {
+	return level >= GetLevel()
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.log(DebugLevel, format, args...)
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.log(InfoLevel, format, args...)
+}
+
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.log(WarnLevel, format, args...)
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.log(ErrorLevel, format, args...)
+}
+
+// Fatalf logs the message as an error and exits
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	l.log(ErrorLevel, format, args...)
+	os.Exit(1)
+}
+
+func (l *Logger) log(level Level, format string, args ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	std.write(time.Now(), level, l.component, fmt.Sprintf(format, args...), l.fields)
+}
+
+func (o *output) write(now time.Time, level Level, component string, msg string, fields []field) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	line := o.format(now, level, component, msg, fields)
+	o.writer.Write(line)
+	if o.syslog == nil {
+		return
+	}
+	if err := o.syslog.WriteLevel(level, line); err == nil {
+		o.syslogFailed = false
+	} else if !o.syslogFailed {
+		o.syslogFailed = true
+		o.writer.Write(o.format(now, WarnLevel, "logging", "Failed to send the logs to syslog, dropping them: "+err.Error(), nil))
+	}
+}
+
+func (o *output) format(now time.Time, level Level, component string, msg string, fields []field) []byte {
+	if o.json {
+		return formatJSON(now, level, component, msg, fields)
+	}
+	return formatText(now, level, component, msg, fields)
+}
+
+// formatJSON writes the fields in a stable order: time, level, component,
+// msg and then the fields of the logger
+func formatJSON(now time.Time, level Level, component string, msg string, fields []field) []byte {
+	var b bytes.Buffer
+	b.WriteString(`{"time":`)
+	writeJSON(&b, now.UTC().Format(time.RFC3339Nano))
+	b.WriteString(`,"level":`)
+	writeJSON(&b, level.String())
+	if component != "" {
+		b.WriteString(`,"component":`)
+		writeJSON(&b, component)
+	}
+	b.WriteString(`,"msg":`)
+	writeJSON(&b, msg)
+	for _, f := range fields {
+		b.WriteByte(',')
+		writeJSON(&b, f.key)
+		b.WriteByte(':')
+		writeJSON(&b, f.value)
+	}
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+func writeJSON(b *bytes.Buffer, value interface{}) {
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		encoded, _ = json.Marshal(fmt.Sprint(value))
+	}
+	b.Write(encoded)
+}
+
+func formatText(now time.Time, level Level, component string, msg string, fields []field) []byte {
+	var b bytes.Buffer
+	b.WriteString(now.Format(textTimeFormat))
+	b.WriteByte(' ')
+	b.WriteString(strings.ToUpper(level.String()))
+	b.WriteByte(' ')
+	if component != "" {
+		b.WriteString(component)
+		b.WriteString(": ")
+	}
+	b.WriteString(strings.TrimRight(msg, " \n"))
+	for _, f := range fields {
+		fmt.Fprintf(&b, " %s=%v", f.key, f.value)
+	}
+	b.WriteByte('\n')
+	return b.Bytes()
+}
+
+// stdWriter turns the lines of the standard log package, written by the
+// shared packages not using a logger, into info messages of the component,
+// so that they share the format of the leveled messages
+type stdWriter struct {
+	logger *Logger
+}
+
+func (w *stdWriter) Write(data []byte) (int, error) {
+	w.logger.log(InfoLevel, "%s", strings.TrimRight(string(data), " \n"))
+	return len(data), nil
+}
+
+// Configure sets the level, the format and the syslog endpoint of the logs
+// from the environment and has the standard log package write through the
+// logger of the component
+func Configure(component string) error {
+	if value := os.Getenv(LevelEnvVar); value != "" {
+		level, err := ParseLevel(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", LevelEnvVar, err)
+		}
+		SetLevel(level)
+	}
+	switch format := strings.ToLower(os.Getenv(FormatEnvVar)); format {
+	case "", "text":
+		SetJSON(false)
+	case "json":
+		SetJSON(true)
+	default:
+		return fmt.Errorf("invalid %s %q, expected text or json", FormatEnvVar, format)
+	}
+	if address := os.Getenv(SyslogEnvVar); address != "" {
+		writer, err := NewSyslogWriter(address, []byte(os.Getenv(SyslogCAEnvVar)), component)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", SyslogEnvVar, err)
+		}
+		SetSyslog(writer)
+	}
+	log.SetFlags(0)
+	log.SetOutput(&stdWriter{logger: New(component)})
+	return nil
+}
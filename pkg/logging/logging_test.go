@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestParseLevel(t *testing.T) {
+	for value, expected := range map[string]Level{
+		"debug":   DebugLevel,
+		"INFO":    InfoLevel,
+		"warning": WarnLevel,
+		" error ": ErrorLevel,
+	} {
+		level, err := ParseLevel(value)
+		assert.Assert(t, err)
+		assert.Equal(t, level, expected)
+	}
+	_, err := ParseLevel("verbose")
+	assert.ErrorContains(t, err, "invalid log level")
+}
+
+func TestLogger(t *testing.T) {
+	var out bytes.Buffer
+	SetOutput(&out)
+	defer SetOutput(os.Stderr)
+	defer SetLevel(InfoLevel)
+	defer SetJSON(false)
+
+	logger := New("collector")
+	SetLevel(WarnLevel)
+	logger.Infof("dropped")
+	logger.Warnf("kept %d", 1)
+	assert.Assert(t, !strings.Contains(out.String(), "dropped"))
+	assert.Assert(t, strings.Contains(out.String(), " WARN collector: kept 1\n"), out.String())
+
+	out.Reset()
+	SetLevel(DebugLevel)
+	SetJSON(true)
+	logger.With("site", "west").With("error", errors.New("refused")).Debugf("connecting")
+	entry := map[string]interface{}{}
+	assert.Assert(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, entry["level"], "debug")
+	assert.Equal(t, entry["component"], "collector")
+	assert.Equal(t, entry["msg"], "connecting")
+	assert.Equal(t, entry["site"], "west")
+	assert.Equal(t, entry["error"], "refused")
+	assert.Assert(t, strings.HasPrefix(out.String(), `{"time":`))
+}
+
+func TestStdWriter(t *testing.T) {
+	var out bytes.Buffer
+	SetOutput(&out)
+	defer SetOutput(os.Stderr)
+
+	// the wording of the lines does not change their level
+	writer := &stdWriter{logger: New("collector")}
+	writer.Write([]byte("Error getting site id\n"))
+	assert.Assert(t, strings.Contains(out.String(), " INFO collector: Error getting site id\n"), out.String())
+}
+
+func TestLevelHandler(t *testing.T) {
+	SetOutput(io.Discard)
+	defer SetOutput(os.Stderr)
+	defer SetLevel(InfoLevel)
+
+	serve := func(method string, target string, body string) (int, string) {
+		w := httptest.NewRecorder()
+		LevelHandler(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return w.Code, w.Body.String()
+	}
+	code, body := serve(http.MethodGet, LevelPath, "")
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, body, "{\"level\":\"info\"}\n")
+
+	code, body = serve(http.MethodPut, LevelPath+"?level=debug", "")
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, body, "{\"level\":\"debug\"}\n")
+	assert.Equal(t, GetLevel(), DebugLevel)
+
+	code, _ = serve(http.MethodPost, LevelPath, `{"level":"warn"}`)
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, GetLevel(), WarnLevel)
+
+	code, _ = serve(http.MethodPut, LevelPath, `{"level":"loud"}`)
+	assert.Equal(t, code, http.StatusBadRequest)
+	code, _ = serve(http.MethodPut, LevelPath, "debug")
+	assert.Equal(t, code, http.StatusBadRequest)
+	code, _ = serve(http.MethodDelete, LevelPath, "")
+	assert.Equal(t, code, http.StatusMethodNotAllowed)
+	assert.Equal(t, GetLevel(), WarnLevel)
+}
@@ -0,0 +1,194 @@
+package logging
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// SyslogEnvVar sets the syslog endpoint the logs are also sent to, as
+	// udp://host:port, tcp://host:port or tls://host:port
+	SyslogEnvVar = "SKUPPER_LOG_SYSLOG"
+	// SyslogCAEnvVar holds the pem encoded CAs verifying the certificate of
+	// a tls syslog endpoint, the system CAs being used when unset
+	SyslogCAEnvVar = "SKUPPER_LOG_SYSLOG_CA"
+
+	defaultSyslogPort    = "514"
+	defaultSyslogTlsPort = "6514"
+	// syslogFacility is the local0 facility
+	syslogFacility = 16
+	syslogTimeout  = 5 * time.Second
+	// syslogRedialDelay keeps an unreachable endpoint from slowing down
+	// every message logged
+	syslogRedialDelay = 10 * time.Second
+)
+
+var syslogSeverities = map[Level]int{
+	DebugLevel: 7,
+	InfoLevel:  6,
+	WarnLevel:  4,
+	ErrorLevel: 3,
+}
+
+// SyslogWriter sends the logs to a syslog endpoint as RFC 5424 messages,
+// one datagram per message over udp and octet counted over tcp and tls.
+// The messages logged while the endpoint is unreachable are dropped.
+type SyslogWriter struct {
+	lock      sync.Mutex
+	network   string
+	address   string
+	tlsConfig *tls.Config
+	appName   string
+	hostname  string
+	conn      net.Conn
+	failedAt  time.Time
+	now       func() time.Time
+}
+
+// ParseSyslogAddress returns the network and the address of a syslog
+// endpoint, along with whether it is reached over tls
+func ParseSyslogAddress(value string) (string, string, bool, error) {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return "", "", false, fmt.Errorf("invalid syslog address %q, expected udp://host:port, tcp://host:port or tls://host:port", value)
+	}
+	port := u.Port()
+	switch u.Scheme {
+	case "udp", "tcp":
+		if port == "" {
+			port = defaultSyslogPort
+		}
+		return u.Scheme, net.JoinHostPort(u.Hostname(), port), false, nil
+	case "tls":
+		if port == "" {
+			port = defaultSyslogTlsPort
+		}
+		return "tcp", net.JoinHostPort(u.Hostname(), port), true, nil
+	}
+	return "", "", false, fmt.Errorf("invalid syslog protocol %q, expected udp, tcp or tls", u.Scheme)
+}
+
+// NewSyslogWriter returns a writer to the syslog endpoint, the messages
+// being tagged with the name of the component. The endpoint is only
+// connected to when the first message is sent.
+func NewSyslogWriter(address string, caPEM []byte, component string) (*SyslogWriter, error) {
+	network, hostPort, useTls, err := ParseSyslogAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	w := &SyslogWriter{
+		network:  network,
+		address:  hostPort,
+		appName:  syslogName(component),
+		hostname: "-",
+		now:      time.Now,
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		w.hostname = syslogName(hostname)
+	}
+	if useTls {
+		host, _, _ := net.SplitHostPort(hostPort)
+		w.tlsConfig = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+		if len(caPEM) > 0 {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(caPEM) {
+				return nil, fmt.Errorf("invalid %s, no pem encoded certificate found", SyslogCAEnvVar)
+			}
+			w.tlsConfig.RootCAs = pool
+		}
+	}
+	return w, nil
+}
+
+// syslogName replaces the characters not allowed in the header fields of
+// a message
+func syslogName(value string) string {
+	if value == "" {
+		return "-"
+	}
+	name := []byte(value)
+	for i, c := range name {
+		if c < 33 || c > 126 {
+			name[i] = '_'
+		}
+	}
+	if len(name) > 48 {
+		name = name[:48]
+	}
+	return string(name)
+}
+
+func (w *SyslogWriter) format(level Level, line []byte) []byte {
+	severity, ok := syslogSeverities[level]
+	if !ok {
+		severity = syslogSeverities[InfoLevel]
+	}
+	for len(line) > 0 && line[len(line)-1] == '\n' {
+		line = line[:len(line)-1]
+	}
+	msg := fmt.Sprintf("<%d>1 %s %s %s %d - - %s",
+		syslogFacility*8+severity,
+		w.now().UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		w.hostname, w.appName, os.Getpid(), line)
+	if w.network == "udp" {
+		return []byte(msg)
+	}
+	return []byte(strconv.Itoa(len(msg)) + " " + msg)
+}
+
+func (w *SyslogWriter) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: syslogTimeout}
+	if w.tlsConfig != nil {
+		return tls.DialWithDialer(dialer, w.network, w.address, w.tlsConfig)
+	}
+	return dialer.Dial(w.network, w.address)
+}
+
+// WriteLevel sends the line with the severity of the level, reconnecting
+// once if the connection was closed by the endpoint
+func (w *SyslogWriter) WriteLevel(level Level, line []byte) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	msg := w.format(level, line)
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.conn == nil {
+			if !w.failedAt.IsZero() && w.now().Sub(w.failedAt) < syslogRedialDelay {
+				return fmt.Errorf("syslog endpoint %s unreachable", w.address)
+			}
+			w.conn, err = w.dial()
+			if err != nil {
+				w.conn = nil
+				w.failedAt = w.now()
+				return err
+			}
+			w.failedAt = time.Time{}
+		}
+		w.conn.SetWriteDeadline(time.Now().Add(syslogTimeout))
+		if _, err = w.conn.Write(msg); err == nil {
+			return nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	return err
+}
+
+// Close closes the connection to the endpoint
+func (w *SyslogWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestParseSyslogAddress(t *testing.T) {
+	tests := []struct {
+		value   string
+		network string
+		address string
+		useTls  bool
+		err     string
+	}{
+		{value: "udp://syslog.example.com", network: "udp", address: "syslog.example.com:514"},
+		{value: "tcp://10.0.0.1:1514", network: "tcp", address: "10.0.0.1:1514"},
+		{value: "tls://syslog.example.com", network: "tcp", address: "syslog.example.com:6514", useTls: true},
+		{value: "syslog.example.com:514", err: "invalid syslog"},
+		{value: "http://syslog.example.com", err: "invalid syslog protocol"},
+	}
+	for _, test := range tests {
+		network, address, useTls, err := ParseSyslogAddress(test.value)
+		if test.err != "" {
+			assert.ErrorContains(t, err, test.err, test.value)
+			continue
+		}
+		assert.Assert(t, err, test.value)
+		assert.Equal(t, network, test.network)
+		assert.Equal(t, address, test.address)
+		assert.Equal(t, useTls, test.useTls)
+	}
+	_, err := NewSyslogWriter("tls://syslog.example.com", []byte("not a certificate"), "collector")
+	assert.ErrorContains(t, err, SyslogCAEnvVar)
+}
+
+func TestSyslogWriterUdp(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Assert(t, err)
+	defer conn.Close()
+
+	w, err := NewSyslogWriter("udp://"+conn.LocalAddr().String(), nil, "flow collector")
+	assert.Assert(t, err)
+	defer w.Close()
+	w.hostname = "pod-0"
+	w.now = func() time.Time { return time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC) }
+	assert.Assert(t, w.WriteLevel(WarnLevel, []byte("link down\n")))
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	assert.Assert(t, err)
+	// local0 and warning
+	expected := "<132>1 2023-05-01T10:00:00.000000Z pod-0 flow_collector " + strconv.Itoa(os.Getpid()) + " - - link down"
+	assert.Equal(t, string(buf[:n]), expected)
+}
+
+func TestSyslogOutputTcp(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Assert(t, err)
+	defer listener.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// octet counting framing
+		reader := bufio.NewReader(conn)
+		length, _ := reader.ReadString(' ')
+		size, _ := strconv.Atoi(strings.TrimSpace(length))
+		msg := make([]byte, size)
+		io.ReadFull(reader, msg)
+		received <- string(msg)
+	}()
+
+	w, err := NewSyslogWriter("tcp://"+listener.Addr().String(), nil, "service-controller")
+	assert.Assert(t, err)
+	SetOutput(io.Discard)
+	SetSyslog(w)
+	defer SetOutput(os.Stderr)
+	defer SetSyslog(nil)
+
+	New("service-controller").Errorf("Error getting van client")
+	select {
+	case msg := <-received:
+		assert.Assert(t, strings.HasPrefix(msg, "<131>1 "), msg)
+		assert.Assert(t, strings.HasSuffix(msg, " ERROR service-controller: Error getting van client"), msg)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
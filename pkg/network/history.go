@@ -0,0 +1,212 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// NetworkStatusHistoryKey holds the snapshots next to the network status
+	NetworkStatusHistoryKey = "NetworkStatusHistory"
+	// NetworkStatusHistoryRetention is how long the snapshots are kept
+	NetworkStatusHistoryRetention = 7 * 24 * time.Hour
+	// NetworkStatusHistoryMaxSnapshots bounds the size of the history
+	NetworkStatusHistoryMaxSnapshots = 100
+)
+
+// NetworkSnapshot is the state of the network from its time on, until the
+// time of the next snapshot, the snapshots are only taken when the network
+// changes
+type NetworkSnapshot struct {
+	Time     time.Time         `json:"time"`
+	Sites    []SnapshotSite    `json:"sites"`
+	Links    []string          `json:"links"`
+	Services []SnapshotService `json:"services"`
+}
+
+type SnapshotSite struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Version   string `json:"version,omitempty"`
+}
+
+type SnapshotService struct {
+	Address    string `json:"address"`
+	Protocol   string `json:"protocol,omitempty"`
+	Listeners  int    `json:"listeners"`
+	Connectors int    `json:"connectors"`
+}
+
+// NetworkChange is an entity added, removed or changed between snapshots
+type NetworkChange struct {
+	Kind   string
+	Name   string
+	Change string
+	Detail string
+}
+
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// NewNetworkSnapshot summarizes the sites, links between sites and
+// services of the network status
+func NewNetworkSnapshot(status *NetworkStatusInfo, t time.Time) NetworkSnapshot {
+	snapshot := NetworkSnapshot{Time: t.UTC(), Sites: []SnapshotSite{}, Links: []string{}, Services: []SnapshotService{}}
+	if status == nil {
+		return snapshot
+	}
+	skupperStatus := SkupperStatus{NetworkStatus: status}
+	routerSiteMap := skupperStatus.GetRouterSiteMap()
+	links := map[string]bool{}
+	for _, siteStatus := range status.SiteStatus {
+		snapshot.Sites = append(snapshot.Sites, SnapshotSite{
+			Name:      siteStatus.Site.Name,
+			Namespace: siteStatus.Site.Namespace,
+			Version:   siteStatus.Site.Version,
+		})
+		for _, router := range siteStatus.RouterStatus {
+			for _, link := range skupperStatus.RemoveLinksFromSameSite(router, siteStatus.Site) {
+				remote, ok := routerSiteMap[link.Name]
+				if !ok {
+					continue
+				}
+				if link.Direction == "incoming" {
+					links[remote.Site.Name+" -> "+siteStatus.Site.Name] = true
+				} else {
+					links[siteStatus.Site.Name+" -> "+remote.Site.Name] = true
+				}
+			}
+		}
+	}
+	for link := range links {
+		snapshot.Links = append(snapshot.Links, link)
+	}
+	for _, address := range status.Addresses {
+		snapshot.Services = append(snapshot.Services, SnapshotService{
+			Address:    address.Name,
+			Protocol:   address.Protocol,
+			Listeners:  address.ListenerCount,
+			Connectors: address.ConnectorCount,
+		})
+	}
+	sort.Slice(snapshot.Sites, func(i, j int) bool { return snapshot.Sites[i].Name < snapshot.Sites[j].Name })
+	sort.Strings(snapshot.Links)
+	sort.Slice(snapshot.Services, func(i, j int) bool { return snapshot.Services[i].Address < snapshot.Services[j].Address })
+	return snapshot
+}
+
+// SameState tells if both snapshots describe the same network
+func (s NetworkSnapshot) SameState(other NetworkSnapshot) bool {
+	return len(DiffNetworkSnapshots(s, other)) == 0
+}
+
+// AddNetworkSnapshot appends the snapshot when the network changed since
+// the last one, dropping the snapshots past the retention but the one
+// describing the network at that time
+func AddNetworkSnapshot(history []NetworkSnapshot, snapshot NetworkSnapshot) []NetworkSnapshot {
+	if len(history) > 0 && history[len(history)-1].SameState(snapshot) {
+		return history
+	}
+	history = append(history, snapshot)
+	oldest := snapshot.Time.Add(-NetworkStatusHistoryRetention)
+	for len(history) > 1 && (len(history) > NetworkStatusHistoryMaxSnapshots || !history[1].Time.After(oldest)) {
+		history = history[1:]
+	}
+	return history
+}
+
+// NetworkSnapshotAt returns the snapshot describing the network at the
+// given time, false when the history starts later, in which case the oldest
+// snapshot is returned
+func NetworkSnapshotAt(history []NetworkSnapshot, t time.Time) (NetworkSnapshot, bool) {
+	if len(history) == 0 {
+		return NetworkSnapshot{}, false
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if !history[i].Time.After(t) {
+			return history[i], true
+		}
+	}
+	return history[0], false
+}
+
+// DiffNetworkSnapshots lists the sites, links and services added, removed
+// or changed from the earlier snapshot to the later one
+func DiffNetworkSnapshots(earlier NetworkSnapshot, later NetworkSnapshot) []NetworkChange {
+	changes := []NetworkChange{}
+	diff := func(kind string, before map[string]string, after map[string]string) {
+		names := []string{}
+		for name := range before {
+			names = append(names, name)
+		}
+		for name := range after {
+			if _, ok := before[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			previous, wasPresent := before[name]
+			current, isPresent := after[name]
+			switch {
+			case !wasPresent:
+				changes = append(changes, NetworkChange{Kind: kind, Name: name, Change: ChangeAdded, Detail: current})
+			case !isPresent:
+				changes = append(changes, NetworkChange{Kind: kind, Name: name, Change: ChangeRemoved, Detail: previous})
+			case previous != current:
+				changes = append(changes, NetworkChange{Kind: kind, Name: name, Change: ChangeChanged, Detail: previous + " => " + current})
+			}
+		}
+	}
+	sites := func(s NetworkSnapshot) map[string]string {
+		m := map[string]string{}
+		for _, site := range s.Sites {
+			detail := []string{}
+			if site.Namespace != "" {
+				detail = append(detail, "namespace "+site.Namespace)
+			}
+			if site.Version != "" {
+				detail = append(detail, "version "+site.Version)
+			}
+			m[site.Name] = strings.Join(detail, ", ")
+		}
+		return m
+	}
+	links := func(s NetworkSnapshot) map[string]string {
+		m := map[string]string{}
+		for _, link := range s.Links {
+			m[link] = ""
+		}
+		return m
+	}
+	services := func(s NetworkSnapshot) map[string]string {
+		m := map[string]string{}
+		for _, service := range s.Services {
+			m[service.Address] = fmt.Sprintf("%s, %d listeners, %d connectors", service.Protocol, service.Listeners, service.Connectors)
+		}
+		return m
+	}
+	diff("site", sites(earlier), sites(later))
+	diff("link", links(earlier), links(later))
+	diff("service", services(earlier), services(later))
+	return changes
+}
+
+// UnmarshalNetworkStatusHistory reads the snapshots kept with the network
+// status, an empty history being returned when there is none
+func UnmarshalNetworkStatusHistory(data string) ([]NetworkSnapshot, error) {
+	history := []NetworkSnapshot{}
+	if data == "" {
+		return history, nil
+	}
+	if err := json.Unmarshal([]byte(data), &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
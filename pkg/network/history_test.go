@@ -0,0 +1,93 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestNewNetworkSnapshot(t *testing.T) {
+	skupperStatus := createTestSkupperStatus()
+	now := time.Now()
+
+	snapshot := NewNetworkSnapshot(skupperStatus.NetworkStatus, now)
+
+	assert.Assert(t, snapshot.Time.Equal(now))
+	assert.DeepEqual(t, snapshot.Sites, []SnapshotSite{
+		{Name: "public1", Namespace: "public1", Version: "5a16a97"},
+		{Name: "public2", Namespace: "public2", Version: "5a16a97"},
+	})
+	// the incoming link of public1 is the outgoing link of public2
+	assert.DeepEqual(t, snapshot.Links, []string{"public2 -> public1"})
+	assert.DeepEqual(t, snapshot.Services, []SnapshotService{
+		{Address: "backend:8080", Protocol: "tcp", Listeners: 2, Connectors: 1},
+	})
+}
+
+func TestDiffNetworkSnapshots(t *testing.T) {
+	earlier := NetworkSnapshot{
+		Sites:    []SnapshotSite{{Name: "east", Version: "1.5.0"}, {Name: "west", Version: "1.5.0"}},
+		Links:    []string{"east -> west"},
+		Services: []SnapshotService{{Address: "backend", Protocol: "tcp", Listeners: 2, Connectors: 1}},
+	}
+	later := NetworkSnapshot{
+		Sites:    []SnapshotSite{{Name: "east", Version: "1.5.1"}, {Name: "north", Version: "1.5.1"}},
+		Links:    []string{"north -> east"},
+		Services: []SnapshotService{{Address: "backend", Protocol: "tcp", Listeners: 2, Connectors: 1}, {Address: "db", Protocol: "tcp", Listeners: 1}},
+	}
+
+	changes := DiffNetworkSnapshots(earlier, later)
+
+	assert.DeepEqual(t, changes, []NetworkChange{
+		{Kind: "site", Name: "east", Change: ChangeChanged, Detail: "version 1.5.0 => version 1.5.1"},
+		{Kind: "site", Name: "north", Change: ChangeAdded, Detail: "version 1.5.1"},
+		{Kind: "site", Name: "west", Change: ChangeRemoved, Detail: "version 1.5.0"},
+		{Kind: "link", Name: "east -> west", Change: ChangeRemoved},
+		{Kind: "link", Name: "north -> east", Change: ChangeAdded},
+		{Kind: "service", Name: "db", Change: ChangeAdded, Detail: "tcp, 1 listeners, 0 connectors"},
+	})
+	assert.Equal(t, len(DiffNetworkSnapshots(later, later)), 0)
+}
+
+func TestNetworkHistory(t *testing.T) {
+	start := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	snapshot := func(offset time.Duration, sites ...string) NetworkSnapshot {
+		s := NetworkSnapshot{Time: start.Add(offset)}
+		for _, site := range sites {
+			s.Sites = append(s.Sites, SnapshotSite{Name: site})
+		}
+		return s
+	}
+
+	history := AddNetworkSnapshot(nil, snapshot(0, "east"))
+	history = AddNetworkSnapshot(history, snapshot(time.Minute, "east"))
+	assert.Equal(t, len(history), 1, "unchanged network must not be recorded")
+	history = AddNetworkSnapshot(history, snapshot(time.Hour, "east", "west"))
+	history = AddNetworkSnapshot(history, snapshot(2*time.Hour, "west"))
+	assert.Equal(t, len(history), 3)
+
+	testTable := []struct {
+		name     string
+		at       time.Time
+		expected time.Time
+		found    bool
+	}{
+		{"before-history", start.Add(-time.Hour), start, false},
+		{"first", start.Add(30 * time.Minute), start, true},
+		{"exact", start.Add(time.Hour), start.Add(time.Hour), true},
+		{"latest", start.Add(3 * time.Hour), start.Add(2 * time.Hour), true},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			s, found := NetworkSnapshotAt(history, test.at)
+			assert.Equal(t, found, test.found)
+			assert.Assert(t, s.Time.Equal(test.expected))
+		})
+	}
+
+	// the snapshot describing the network at the start of the retention is kept
+	history = AddNetworkSnapshot(history, snapshot(NetworkStatusHistoryRetention+150*time.Minute, "north"))
+	assert.Equal(t, len(history), 2)
+	assert.Assert(t, history[0].Time.Equal(start.Add(2*time.Hour)))
+}
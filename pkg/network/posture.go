@@ -0,0 +1,196 @@
+package network
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	PostureOk       = "ok"
+	PostureExpiring = "expiring"
+	PostureExpired  = "expired"
+	PostureWeak     = "weak"
+	PostureInsecure = "insecure"
+
+	// certificates expiring within this period are reported
+	PostureExpiryWarning = 30 * 24 * time.Hour
+	postureMinRSABits    = 2048
+)
+
+// CertificatePosture summarizes a certificate used by a site
+type CertificatePosture struct {
+	Name         string    `json:"name"`
+	Source       string    `json:"source"`
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	NotAfter     time.Time `json:"notAfter"`
+	KeyAlgorithm string    `json:"keyAlgorithm"`
+	KeySize      int       `json:"keySize,omitempty"`
+	Signature    string    `json:"signature"`
+	Status       string    `json:"status"`
+}
+
+// TlsPosture describes the tls settings of a router listener, a link or the
+// console listener
+type TlsPosture struct {
+	Kind              string `json:"kind"`
+	Name              string `json:"name"`
+	Role              string `json:"role,omitempty"`
+	Direction         string `json:"direction,omitempty"`
+	Host              string `json:"host,omitempty"`
+	Encrypted         bool   `json:"encrypted"`
+	TlsVersion        string `json:"tlsVersion,omitempty"`
+	Cipher            string `json:"cipher,omitempty"`
+	PeerAuthenticated bool   `json:"peerAuthenticated"`
+	Status            string `json:"status"`
+}
+
+// AuthPosture describes how a component authenticates its clients
+type AuthPosture struct {
+	Component string `json:"component"`
+	Mode      string `json:"mode"`
+	Status    string `json:"status"`
+}
+
+// PostureReport is the security posture of the site of a collector
+type PostureReport struct {
+	Collector    string               `json:"collector"`
+	Generated    time.Time            `json:"generated"`
+	Certificates []CertificatePosture `json:"certificates"`
+	Tls          []TlsPosture         `json:"tls"`
+	Auth         []AuthPosture        `json:"auth"`
+	Warnings     []string             `json:"warnings"`
+	Errors       []string             `json:"errors,omitempty"`
+}
+
+// CertificatePostures describes the certificates of the pem data
+func CertificatePostures(name string, source string, data []byte, now time.Time) ([]CertificatePosture, error) {
+	postures := []CertificatePosture{}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		postures = append(postures, certificatePosture(name, source, cert, now))
+	}
+	if len(postures) == 0 {
+		return nil, fmt.Errorf("%s: no certificate found", source)
+	}
+	return postures, nil
+}
+
+func certificatePosture(name string, source string, cert *x509.Certificate, now time.Time) CertificatePosture {
+	posture := CertificatePosture{
+		Name:         name,
+		Source:       source,
+		Subject:      cert.Subject.CommonName,
+		Issuer:       cert.Issuer.CommonName,
+		NotAfter:     cert.NotAfter,
+		KeyAlgorithm: cert.PublicKeyAlgorithm.String(),
+		Signature:    cert.SignatureAlgorithm.String(),
+		Status:       PostureOk,
+	}
+	weak := false
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		posture.KeySize = key.N.BitLen()
+		weak = posture.KeySize < postureMinRSABits
+	case *ecdsa.PublicKey:
+		posture.KeySize = key.Curve.Params().BitSize
+	}
+	switch cert.SignatureAlgorithm {
+	case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+		weak = true
+	}
+	switch {
+	case now.After(cert.NotAfter):
+		posture.Status = PostureExpired
+	case cert.NotAfter.Sub(now) < PostureExpiryWarning:
+		posture.Status = PostureExpiring
+	case weak:
+		posture.Status = PostureWeak
+	}
+	return posture
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Evaluate sets the status of the tls settings: site links must be encrypted
+// and authenticate their peer, other listeners must be encrypted unless
+// bound to the loopback interface
+func (p *TlsPosture) Evaluate() {
+	p.Status = PostureOk
+	link := p.Role == "inter-router" || p.Role == "edge"
+	switch {
+	case !p.Encrypted && (link || !isLoopback(p.Host)):
+		p.Status = PostureInsecure
+	case !p.Encrypted:
+	case isWeakTlsVersion(p.TlsVersion):
+		p.Status = PostureWeak
+	case link && !p.PeerAuthenticated:
+		p.Status = PostureWeak
+	}
+}
+
+func isWeakTlsVersion(version string) bool {
+	for _, weak := range []string{"SSLv2", "SSLv3", "TLSv1", "TLSv1.0", "TLSv1.1", "1.0", "1.1"} {
+		for _, v := range strings.Fields(version) {
+			if strings.EqualFold(v, weak) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Summarize lists a warning for each item of the report that is not ok
+func (r *PostureReport) Summarize() {
+	r.Warnings = []string{}
+	for _, cert := range r.Certificates {
+		switch cert.Status {
+		case PostureExpired:
+			r.Warnings = append(r.Warnings, fmt.Sprintf("certificate %s (%s) expired on %s", cert.Subject, cert.Name, cert.NotAfter.Format(time.RFC3339)))
+		case PostureExpiring:
+			r.Warnings = append(r.Warnings, fmt.Sprintf("certificate %s (%s) expires on %s", cert.Subject, cert.Name, cert.NotAfter.Format(time.RFC3339)))
+		case PostureWeak:
+			r.Warnings = append(r.Warnings, fmt.Sprintf("certificate %s (%s) uses a weak %s %d key or %s signature", cert.Subject, cert.Name, cert.KeyAlgorithm, cert.KeySize, cert.Signature))
+		}
+	}
+	for _, t := range r.Tls {
+		switch t.Status {
+		case PostureInsecure:
+			r.Warnings = append(r.Warnings, fmt.Sprintf("%s %s is not encrypted", t.Kind, t.Name))
+		case PostureWeak:
+			if isWeakTlsVersion(t.TlsVersion) {
+				r.Warnings = append(r.Warnings, fmt.Sprintf("%s %s uses %s", t.Kind, t.Name, t.TlsVersion))
+			} else {
+				r.Warnings = append(r.Warnings, fmt.Sprintf("%s %s does not authenticate its peer", t.Kind, t.Name))
+			}
+		}
+	}
+	for _, a := range r.Auth {
+		if a.Status != PostureOk {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("%s authentication is %s (%s)", a.Component, a.Status, a.Mode))
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package network
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func postureCertificate(t *testing.T, bits int, notAfter time.Time) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	assert.Assert(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "skupper-site-ca"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Assert(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestCertificatePostures(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		name     string
+		bits     int
+		notAfter time.Time
+		status   string
+	}{
+		{name: "valid", bits: 2048, notAfter: now.Add(365 * 24 * time.Hour), status: PostureOk},
+		{name: "expiring", bits: 2048, notAfter: now.Add(7 * 24 * time.Hour), status: PostureExpiring},
+		{name: "expired", bits: 2048, notAfter: now.Add(-time.Hour), status: PostureExpired},
+		{name: "weak", bits: 1024, notAfter: now.Add(365 * 24 * time.Hour), status: PostureWeak},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			postures, err := CertificatePostures(test.name, "secret/"+test.name, postureCertificate(t, test.bits, test.notAfter), now)
+			assert.Assert(t, err)
+			assert.Equal(t, len(postures), 1)
+			assert.Equal(t, postures[0].Subject, "skupper-site-ca")
+			assert.Equal(t, postures[0].KeySize, test.bits)
+			assert.Equal(t, postures[0].Status, test.status)
+		})
+	}
+	_, err := CertificatePostures("empty", "secret/empty", []byte("none"), now)
+	assert.ErrorContains(t, err, "no certificate found")
+}
+
+func TestTlsPostureEvaluate(t *testing.T) {
+	var tests = []struct {
+		name    string
+		posture TlsPosture
+		status  string
+	}{
+		{
+			name:    "mutual tls link",
+			posture: TlsPosture{Role: "inter-router", Encrypted: true, TlsVersion: "TLSv1.3", PeerAuthenticated: true},
+			status:  PostureOk,
+		},
+		{
+			name:    "plain link",
+			posture: TlsPosture{Role: "edge"},
+			status:  PostureInsecure,
+		},
+		{
+			name:    "old tls version",
+			posture: TlsPosture{Role: "inter-router", Encrypted: true, TlsVersion: "TLSv1.1", PeerAuthenticated: true},
+			status:  PostureWeak,
+		},
+		{
+			name:    "unauthenticated link",
+			posture: TlsPosture{Role: "inter-router", Encrypted: true, TlsVersion: "TLSv1.2"},
+			status:  PostureWeak,
+		},
+		{
+			name:    "local listener",
+			posture: TlsPosture{Role: "normal", Host: "localhost"},
+			status:  PostureOk,
+		},
+		{
+			name:    "exposed listener",
+			posture: TlsPosture{Role: "normal", Host: "0.0.0.0"},
+			status:  PostureInsecure,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.posture.Evaluate()
+			assert.Equal(t, test.posture.Status, test.status)
+		})
+	}
+}
+
+func TestPostureReportSummarize(t *testing.T) {
+	report := PostureReport{
+		Certificates: []CertificatePosture{
+			{Name: "skupper-site-ca", Subject: "skupper-site-ca", Status: PostureOk},
+			{Name: "skupper-site-server", Subject: "skupper-site-server", Status: PostureExpiring},
+		},
+		Tls: []TlsPosture{
+			{Kind: "link", Name: "west", TlsVersion: "TLSv1.1", Status: PostureWeak},
+			{Kind: "console", Name: "flow-collector", Status: PostureInsecure},
+		},
+		Auth: []AuthPosture{
+			{Component: "console", Mode: "unsecured", Status: PostureInsecure},
+		},
+	}
+	report.Summarize()
+	assert.Equal(t, len(report.Warnings), 4)
+	assert.Equal(t, report.Warnings[0], "certificate skupper-site-server (skupper-site-server) expires on 0001-01-01T00:00:00Z")
+	assert.Equal(t, report.Warnings[1], "link west uses TLSv1.1")
+	assert.Equal(t, report.Warnings[2], "console flow-collector is not encrypted")
+	assert.Equal(t, report.Warnings[3], "console authentication is insecure (unsecured)")
+}
@@ -87,3 +87,19 @@ type RemoteLinkInfo struct {
 	SiteId    string `json:"siteId,omitempty"`
 	LinkName  string `json:"linkName,omitempty"`
 }
+
+// CollectorSummary is the view of the network reported by a flow collector
+type CollectorSummary struct {
+	Sites     []SiteInfo        `json:"sites"`
+	Addresses []AddressInfo     `json:"addresses"`
+	Traffic   []SiteTrafficInfo `json:"traffic"`
+}
+
+// SiteTrafficInfo is the number of flows observed from one site to another
+type SiteTrafficInfo struct {
+	SourceSiteId        string `json:"sourceId,omitempty"`
+	SourceSiteName      string `json:"sourceName,omitempty"`
+	DestinationSiteId   string `json:"destinationId,omitempty"`
+	DestinationSiteName string `json:"destinationName,omitempty"`
+	Flows               uint64 `json:"recordCount,omitempty"`
+}
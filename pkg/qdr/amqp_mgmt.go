@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,12 +28,18 @@ func (r *RouterNode) IsSelf() bool {
 }
 
 type Connection struct {
+	Identity   string `json:"identity"`
 	Container  string `json:"container"`
 	OperStatus string `json:"operStatus"`
 	Host       string `json:"host"`
 	Role       string `json:"role"`
 	Active     bool   `json:"active"`
 	Dir        string `json:"dir"`
+	User       string `json:"user"`
+	Encrypted  bool   `json:"isEncrypted"`
+	SslProto   string `json:"sslProto,omitempty"`
+	SslCipher  string `json:"sslCipher,omitempty"`
+	Sasl       string `json:"sasl,omitempty"`
 }
 
 type Agent struct {
@@ -130,27 +137,52 @@ func asTcpEndpoint(record Record) TcpEndpoint {
 
 func asHttpEndpoint(record Record) HttpEndpoint {
 	return HttpEndpoint{
-		Name:            record.AsString("name"),
-		Host:            record.AsString("host"),
-		Port:            record.AsString("port"),
-		Address:         record.AsString("address"),
-		SiteId:          record.AsString("siteId"),
-		ProtocolVersion: record.AsString("protocolVersion"),
-		Aggregation:     record.AsString("aggregation"),
-		EventChannel:    record.AsBool("eventChannel"),
-		HostOverride:    record.AsString("hostOverride"),
-		SslProfile:      record.AsString("sslProfile"),
+		Name:                   record.AsString("name"),
+		Host:                   record.AsString("host"),
+		Port:                   record.AsString("port"),
+		Address:                record.AsString("address"),
+		SiteId:                 record.AsString("siteId"),
+		ProtocolVersion:        record.AsString("protocolVersion"),
+		Aggregation:            record.AsString("aggregation"),
+		AggregationTimeout:     record.AsInt("aggregationTimeout"),
+		AggregationPartial:     record.AsString("aggregationPartial"),
+		AggregationMaxParallel: record.AsInt("aggregationMaxParallel"),
+		EventChannel:           record.AsBool("eventChannel"),
+		HostOverride:           record.AsString("hostOverride"),
+		SslProfile:             record.AsString("sslProfile"),
 	}
 }
 
 func asConnection(record Record) Connection {
 	return Connection{
+		Identity:   record.AsString("identity"),
 		Role:       record.AsString("role"),
 		Container:  record.AsString("container"),
 		Host:       record.AsString("host"),
 		OperStatus: record.AsString("operStatus"),
 		Dir:        record.AsString("dir"),
 		Active:     record.AsBool("active"),
+		User:       record.AsString("user"),
+		Encrypted:  record.AsBool("isEncrypted"),
+		SslProto:   record.AsString("sslProto"),
+		SslCipher:  record.AsString("sslCipher"),
+		Sasl:       record.AsString("sasl"),
+	}
+}
+
+func asListener(record Record) Listener {
+	port, err := strconv.Atoi(record.AsString("port"))
+	if err != nil {
+		port = record.AsInt("port")
+	}
+	return Listener{
+		Name:             record.AsString("name"),
+		Role:             Role(record.AsString("role")),
+		Host:             record.AsString("host"),
+		Port:             int32(port),
+		SslProfile:       record.AsString("sslProfile"),
+		SaslMechanisms:   record.AsString("saslMechanisms"),
+		AuthenticatePeer: record.AsBool("authenticatePeer"),
 	}
 }
 
@@ -230,6 +262,11 @@ func Connect(url string, config TlsConfigRetriever) (*Agent, error) {
 	return newAgent(&factory)
 }
 
+// ConnectWithFactory connects with the sasl configuration of the factory
+func ConnectWithFactory(factory *ConnectionFactory) (*Agent, error) {
+	return newAgent(factory)
+}
+
 func newAgent(factory *ConnectionFactory) (*Agent, error) {
 	client, err := factory.Connect()
 	if err != nil {
@@ -336,6 +373,10 @@ func GetRouterAddress(id string, edge bool) string {
 }
 
 func (a *Agent) request(operation string, typename string, name string, attributes *map[string]interface{}) error {
+	return a.requestEntity(operation, typename, "name", name, attributes)
+}
+
+func (a *Agent) requestEntity(operation string, typename string, key string, value string, attributes *map[string]interface{}) error {
 	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
 	defer cancel()
 
@@ -347,7 +388,7 @@ func (a *Agent) request(operation string, typename string, name string, attribut
 	request.ApplicationProperties = make(map[string]interface{})
 	request.ApplicationProperties["operation"] = operation
 	request.ApplicationProperties["type"] = typename
-	request.ApplicationProperties["name"] = name
+	request.ApplicationProperties[key] = value
 	if attributes != nil {
 		request.Value = attributes
 	}
@@ -382,6 +423,18 @@ func (a *Agent) Delete(typename string, name string) error {
 	return a.request("DELETE", typename, name, nil)
 }
 
+// CloseConnection closes the connection of the router with the identity
+func (a *Agent) CloseConnection(identity string) error {
+	if identity == "" {
+		return fmt.Errorf("Cannot close connection with no identity")
+	}
+	log.Println("CLOSE connection", identity)
+	attributes := map[string]interface{}{
+		"adminStatus": "deleted",
+	}
+	return a.requestEntity("UPDATE", "io.skupper.router.connection", "identity", identity, &attributes)
+}
+
 func (a *Agent) Query(typename string, attributes []string) ([]Record, error) {
 	return a.QueryRouterNode(typename, attributes, nil)
 }
@@ -641,6 +694,20 @@ func (a *Agent) GetConnectionsFor(agent string) ([]Connection, error) {
 	return connections, nil
 }
 
+// GetLocalListeners returns the listeners of the router the agent is
+// connected to
+func (a *Agent) GetLocalListeners() ([]Listener, error) {
+	records, err := a.Query("io.skupper.router.listener", []string{})
+	if err != nil {
+		return nil, err
+	}
+	listeners := make([]Listener, len(records))
+	for i, r := range records {
+		listeners[i] = asListener(r)
+	}
+	return listeners, nil
+}
+
 func getAddressesFor(routers []Router) []string {
 	agents := make([]string, len(routers))
 	for i, r := range routers {
@@ -1234,6 +1301,9 @@ func asSslProfile(record Record) SslProfile {
 		CertFile:       record.AsString("certFile"),
 		PrivateKeyFile: record.AsString("privateKeyFile"),
 		CaCertFile:     record.AsString("caCertFile"),
+		UidFormat:      record.AsString("uidFormat"),
+		Ciphers:        record.AsString("ciphers"),
+		Protocols:      record.AsString("protocols"),
 	}
 }
 
@@ -1416,7 +1486,7 @@ func (a *Agent) CreateSslProfile(profile SslProfile) error {
 	}
 
 	record := map[string]interface{}{}
-	if err := convert(profile, &record); err != nil {
+	if err := convert(restrictSslProfile(profile), &record); err != nil {
 		return fmt.Errorf("Failed to convert record: %s", err)
 	}
 	if err := a.Create("io.skupper.router.sslProfile", profile.Name, record); err != nil {
@@ -3,6 +3,8 @@ package qdr
 import (
 	"context"
 	"crypto/tls"
+	"fmt"
+	"sync"
 
 	amqp "github.com/interconnectedcloud/go-amqp"
 
@@ -16,18 +18,41 @@ type TlsConfigRetriever interface {
 type ConnectionFactory struct {
 	url    string
 	config TlsConfigRetriever
+	sasl   *SaslConfig
+}
+
+// SaslConfig selects the sasl mechanism (EXTERNAL, PLAIN or ANONYMOUS) of
+// a connection, EXTERNAL is used with tls and none without when unset
+type SaslConfig struct {
+	Mechanism string
+	Username  string
+	Password  string
 }
 
 func (f *ConnectionFactory) Connect() (messaging.Connection, error) {
-	if f.config == nil {
-		return dial(f.url, amqp.ConnMaxFrameSize(4294967295))
-	} else {
+	opts := []amqp.ConnOption{amqp.ConnMaxFrameSize(4294967295)}
+	if f.config != nil {
 		tlsConfig, err := f.config.GetTlsConfig()
 		if err != nil {
 			return nil, err
 		}
-		return dial(f.url, amqp.ConnSASLExternal(), amqp.ConnMaxFrameSize(4294967295), amqp.ConnTLSConfig(tlsConfig))
+		opts = append(opts, amqp.ConnTLSConfig(tlsConfig))
+	}
+	if f.sasl != nil {
+		switch f.sasl.Mechanism {
+		case "EXTERNAL":
+			opts = append(opts, amqp.ConnSASLExternal())
+		case "PLAIN":
+			opts = append(opts, amqp.ConnSASLPlain(f.sasl.Username, f.sasl.Password))
+		case "ANONYMOUS":
+			opts = append(opts, amqp.ConnSASLAnonymous())
+		default:
+			return nil, fmt.Errorf("unsupported sasl mechanism %q", f.sasl.Mechanism)
+		}
+	} else if f.config != nil {
+		opts = append(opts, amqp.ConnSASLExternal())
 	}
+	return dial(f.url, opts...)
 }
 
 func dial(addr string, opts ...amqp.ConnOption) (*AmqpConnection, error) {
@@ -54,6 +79,59 @@ func NewConnectionFactory(url string, config TlsConfigRetriever) *ConnectionFact
 	}
 }
 
+func NewConnectionFactoryWithSasl(url string, config TlsConfigRetriever, sasl SaslConfig) *ConnectionFactory {
+	return &ConnectionFactory{
+		url:    url,
+		config: config,
+		sasl:   &sasl,
+	}
+}
+
+// FailoverConnectionFactory connects to the first reachable of several
+// routers, starting with the last one it connected to
+type FailoverConnectionFactory struct {
+	factories []*ConnectionFactory
+	lock      sync.Mutex
+	current   int
+}
+
+func NewFailoverConnectionFactory(factories ...*ConnectionFactory) *FailoverConnectionFactory {
+	return &FailoverConnectionFactory{
+		factories: factories,
+	}
+}
+
+func (f *FailoverConnectionFactory) Connect() (messaging.Connection, error) {
+	f.lock.Lock()
+	start := f.current
+	f.lock.Unlock()
+	var lastErr error
+	for i := 0; i < len(f.factories); i++ {
+		index := (start + i) % len(f.factories)
+		conn, err := f.factories[index].Connect()
+		if err == nil {
+			f.lock.Lock()
+			f.current = index
+			f.lock.Unlock()
+			return conn, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no router endpoints")
+	}
+	return nil, lastErr
+}
+
+func (f *FailoverConnectionFactory) Url() string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if len(f.factories) == 0 {
+		return ""
+	}
+	return f.factories[f.current].Url()
+}
+
 type AmqpConnection struct {
 	client  *amqp.Client
 	session *amqp.Session
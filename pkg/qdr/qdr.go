@@ -12,6 +12,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/fips"
 )
 
 type RouterConfig struct {
@@ -109,8 +110,11 @@ func InitialConfigSkupperRouter(id string, siteId string, version string, edge b
 		})
 
 	if !edge {
+		// the links are identified by the fingerprint of the certificate of
+		// the linked site, so that the revoked ones can be closed
 		routerConfig.AddSslProfile(SslProfile{
-			Name: types.InterRouterProfile,
+			Name:      types.InterRouterProfile,
+			UidFormat: UidFormatFingerprint,
 		})
 		listeners := []Listener{InteriorListener(options), EdgeListener(options)}
 		for _, listener := range listeners {
@@ -178,14 +182,14 @@ func (r *RouterConfig) AddSslProfileWithPath(path string, s SslProfile) {
 		s.PrivateKeyFile = fmt.Sprintf(path+"/%s/tls.key", s.Name)
 		s.CaCertFile = fmt.Sprintf(path+"/%s/ca.crt", s.Name)
 	}
-	r.SslProfiles[s.Name] = s
+	r.SslProfiles[s.Name] = restrictSslProfile(s)
 }
 
 func (r *RouterConfig) AddSimpleSslProfileWithPath(path string, s SslProfile) {
 	if s.CaCertFile == "" {
 		s.CaCertFile = fmt.Sprintf(path+"/%s/ca.crt", s.Name)
 	}
-	r.SslProfiles[s.Name] = s
+	r.SslProfiles[s.Name] = restrictSslProfile(s)
 }
 
 func (r *RouterConfig) AddSslProfile(s SslProfile) {
@@ -419,11 +423,28 @@ type RouterMetadata struct {
 	Metadata            string `json:"metadata,omitempty"`
 }
 
+// UidFormatFingerprint identifies the peers authenticated through an ssl
+// profile by the SHA-256 fingerprint of their certificate
+const UidFormatFingerprint string = "2"
+
 type SslProfile struct {
 	Name           string `json:"name,omitempty"`
 	CertFile       string `json:"certFile,omitempty"`
 	PrivateKeyFile string `json:"privateKeyFile,omitempty"`
 	CaCertFile     string `json:"caCertFile,omitempty"`
+	UidFormat      string `json:"uidFormat,omitempty"`
+	Ciphers        string `json:"ciphers,omitempty"`
+	Protocols      string `json:"protocols,omitempty"`
+}
+
+// restrictSslProfile limits the profile to the FIPS approved protocols and
+// ciphers when the FIPS mode is on
+func restrictSslProfile(s SslProfile) SslProfile {
+	if fips.Enabled() {
+		s.Ciphers = fips.RouterCiphers
+		s.Protocols = fips.RouterProtocols
+	}
+	return s
 }
 
 type LogConfig struct {
@@ -505,17 +526,31 @@ type TcpEndpoint struct {
 }
 
 type HttpEndpoint struct {
-	Name            string `json:"name,omitempty"`
-	Host            string `json:"host,omitempty"`
-	Port            string `json:"port,omitempty"`
-	Address         string `json:"address,omitempty"`
-	SiteId          string `json:"siteId,omitempty"`
-	ProtocolVersion string `json:"protocolVersion,omitempty"`
-	Aggregation     string `json:"aggregation,omitempty"`
-	EventChannel    bool   `json:"eventChannel,omitempty"`
-	HostOverride    string `json:"hostOverride,omitempty"`
-	SslProfile      string `json:"sslProfile,omitempty"`
-	VerifyHostname  *bool  `json:"verifyHostname,omitempty"`
+	Name                   string `json:"name,omitempty"`
+	Host                   string `json:"host,omitempty"`
+	Port                   string `json:"port,omitempty"`
+	Address                string `json:"address,omitempty"`
+	SiteId                 string `json:"siteId,omitempty"`
+	ProtocolVersion        string `json:"protocolVersion,omitempty"`
+	Aggregation            string `json:"aggregation,omitempty"`
+	AggregationTimeout     int    `json:"aggregationTimeout,omitempty"`
+	AggregationPartial     string `json:"aggregationPartial,omitempty"`
+	AggregationMaxParallel int    `json:"aggregationMaxParallel,omitempty"`
+	EventChannel           bool   `json:"eventChannel,omitempty"`
+	HostOverride           string `json:"hostOverride,omitempty"`
+	SslProfile             string `json:"sslProfile,omitempty"`
+	VerifyHostname         *bool  `json:"verifyHostname,omitempty"`
+}
+
+// SetAggregateOptions configures the fan-out of an aggregating endpoint,
+// the timeout is given to the router in milliseconds
+func (e *HttpEndpoint) SetAggregateOptions(options *types.AggregateOptions) {
+	if e.Aggregation == "" || options == nil {
+		return
+	}
+	e.AggregationTimeout, _ = options.TimeoutMillis()
+	e.AggregationPartial = options.Partial
+	e.AggregationMaxParallel = options.MaxParallel
 }
 
 func convert(from interface{}, to interface{}) error {
@@ -882,6 +917,8 @@ func (a TcpEndpointMap) Difference(b TcpEndpointMap) TcpEndpointDifference {
 func (a HttpEndpoint) Equivalent(b HttpEndpoint) bool {
 	if !equivalentHost(a.Host, b.Host) || a.Port != b.Port || a.Address != b.Address ||
 		a.SiteId != b.SiteId || a.Aggregation != b.Aggregation ||
+		a.AggregationTimeout != b.AggregationTimeout || a.AggregationPartial != b.AggregationPartial ||
+		a.AggregationMaxParallel != b.AggregationMaxParallel ||
 		a.EventChannel != b.EventChannel || a.HostOverride != b.HostOverride {
 		return false
 	}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/skupperproject/skupper/api/types"
+	"github.com/skupperproject/skupper/pkg/fips"
 	"github.com/skupperproject/skupper/pkg/utils"
 	"gotest.tools/assert"
 )
@@ -88,6 +89,22 @@ func TestAddSslProfile(t *testing.T) {
 	}
 }
 
+func TestAddSslProfileFips(t *testing.T) {
+	t.Setenv(fips.EnvVar, "true")
+	config := InitialConfig("foo", "bar", "undefined", true, 3)
+	config.AddSslProfile(SslProfile{Name: "myprofile"})
+	config.AddSimpleSslProfile(SslProfile{Name: "simple"})
+	for _, name := range []string{"myprofile", "simple"} {
+		assert.Equal(t, config.SslProfiles[name].Ciphers, fips.RouterCiphers)
+		assert.Equal(t, config.SslProfiles[name].Protocols, fips.RouterProtocols)
+	}
+	t.Setenv(fips.EnvVar, "false")
+	config.AddSslProfile(SslProfile{Name: "plain"})
+	if !fips.Enabled() {
+		assert.Equal(t, config.SslProfiles["plain"].Ciphers, "")
+	}
+}
+
 func TestAddAddress(t *testing.T) {
 	config := InitialConfig("foo", "bar", "undefined", true, 3)
 	config.AddAddress(Address{
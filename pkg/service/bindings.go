@@ -69,6 +69,7 @@ type ServiceBindings struct {
 	ingressPorts             []int
 	ingressBinding           ServiceIngress
 	aggregation              string
+	aggregateOptions         *types.AggregateOptions
 	eventChannel             bool
 	headless                 *types.Headless
 	Labels                   map[string]string
@@ -124,6 +125,7 @@ func (bindings *ServiceBindings) AsServiceInterface() types.ServiceInterface {
 		Ports:                    bindings.publicPorts,
 		ExposeIngress:            mode,
 		Aggregate:                bindings.aggregation,
+		AggregateOptions:         bindings.aggregateOptions,
 		EventChannel:             bindings.eventChannel,
 		Headless:                 bindings.headless,
 		Labels:                   bindings.Labels,
@@ -173,6 +175,7 @@ func NewServiceBindings(required types.ServiceInterface, ports []int, bindingCon
 		ingressPorts:             ports,
 		ingressBinding:           bindingContext.NewServiceIngress(&required),
 		aggregation:              required.Aggregate,
+		aggregateOptions:         required.AggregateOptions,
 		eventChannel:             required.EventChannel,
 		headless:                 required.Headless,
 		Labels:                   required.Labels,
@@ -223,6 +226,9 @@ func (bindings *ServiceBindings) Update(required types.ServiceInterface, binding
 	if bindings.aggregation != required.Aggregate {
 		bindings.aggregation = required.Aggregate
 	}
+	if !reflect.DeepEqual(bindings.aggregateOptions, required.AggregateOptions) {
+		bindings.aggregateOptions = required.AggregateOptions
+	}
 	if bindings.eventChannel != required.EventChannel {
 		bindings.eventChannel = required.EventChannel
 	}
@@ -572,6 +578,7 @@ func addIngressBridge(sb *ServiceBindings, siteId string, bridges *qdr.BridgeCon
 				Aggregation:  sb.aggregation,
 				EventChannel: sb.eventChannel,
 			}
+			httpListener.SetAggregateOptions(sb.aggregateOptions)
 
 			if len(sb.TlsCredentials) > 0 {
 				httpListener.SslProfile = sb.TlsCredentials
@@ -589,6 +596,7 @@ func addIngressBridge(sb *ServiceBindings, siteId string, bridges *qdr.BridgeCon
 				EventChannel:    sb.eventChannel,
 				ProtocolVersion: qdr.HttpVersion2,
 			}
+			httpListener.SetAggregateOptions(sb.aggregateOptions)
 
 			if len(sb.TlsCredentials) > 0 {
 				httpListener.SslProfile = sb.TlsCredentials
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/skupperproject/skupper/pkg/qdr"
+	"github.com/skupperproject/skupper/pkg/utils"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -22,7 +23,9 @@ const (
 	SiteConfigIngressKey             string = "ingress"
 	SiteConfigIngressAnnotationsKey  string = "ingress-annotations"
 	SiteConfigIngressHostKey         string = "ingress-host"
+	SiteConfigCertificateHostsKey    string = "certificate-hosts"
 	SiteConfigCreateNetworkPolicyKey string = "create-network-policy"
+	SiteConfigComponentPoliciesKey   string = "create-component-network-policies"
 	SiteConfigRoutersKey             string = "routers"
 	SiteConfigRunAsUserKey           string = "run-as-user"
 	SiteConfigRunAsGroupKey          string = "run-as-group"
@@ -33,16 +36,26 @@ const (
 	SiteConfigConsoleAuthenticationKey string = "console-authentication"
 	SiteConfigConsoleUserKey           string = "console-user"
 	SiteConfigConsolePasswordKey       string = "console-password"
+	SiteConfigConsoleOpenIDIssuerKey   string = "console-openid-issuer"
+	SiteConfigConsoleOpenIDClientKey   string = "console-openid-client-id"
+	SiteConfigConsoleSAMLMetadataKey   string = "console-saml-idp-metadata"
+	SiteConfigConsoleSAMLURLKey        string = "console-saml-url"
 	SiteConfigConsoleIngressKey        string = "console-ingress"
 	SiteConfigRestAPIKey               string = "rest-api"
 
 	// flow collector options
-	SiteConfigFlowCollectorKey            string = "flow-collector"
-	SiteConfigFlowCollectorRecordTtlKey   string = "flow-collector-record-ttl"
-	SiteConfigFlowCollectorCpuKey         string = "flow-collector-cpu"
-	SiteConfigFlowCollectorMemoryKey      string = "flow-collector-memory"
-	SiteConfigFlowCollectorCpuLimitKey    string = "flow-collector-cpu-limit"
-	SiteConfigFlowCollectorMemoryLimitKey string = "flow-collector-memory-limit"
+	SiteConfigFlowCollectorKey                    string = "flow-collector"
+	SiteConfigFlowCollectorRecordTtlKey           string = "flow-collector-record-ttl"
+	SiteConfigFlowCollectorCpuKey                 string = "flow-collector-cpu"
+	SiteConfigFlowCollectorMemoryKey              string = "flow-collector-memory"
+	SiteConfigFlowCollectorCpuLimitKey            string = "flow-collector-cpu-limit"
+	SiteConfigFlowCollectorMemoryLimitKey         string = "flow-collector-memory-limit"
+	SiteConfigFlowCollectorTlsMinVersionKey       string = "flow-collector-tls-min-version"
+	SiteConfigFlowCollectorTlsCipherSuitesKey     string = "flow-collector-tls-cipher-suites"
+	SiteConfigFlowCollectorTlsCurvePreferencesKey string = "flow-collector-tls-curve-preferences"
+	SiteConfigFlowCollectorAcmeDomainsKey         string = "flow-collector-acme-domains"
+	SiteConfigFlowCollectorAcmeEmailKey           string = "flow-collector-acme-email"
+	SiteConfigFlowCollectorAcmeDirectoryKey       string = "flow-collector-acme-directory"
 
 	// prometheus server options
 	SiteConfigPrometheusExternalServerKey       string = "prometheus-external-server"
@@ -98,6 +111,19 @@ const (
 
 	SiteConfigEnableSkupperEventsKey string = "enable-skupper-events"
 
+	// logging options
+	SiteConfigSyslogAddressKey string = "syslog-address"
+
+	// vault options
+	SiteConfigVaultAddressKey  string = "vault-address"
+	SiteConfigVaultPkiPathKey  string = "vault-pki-path"
+	SiteConfigVaultRoleKey     string = "vault-role"
+	SiteConfigVaultAuthRoleKey string = "vault-auth-role"
+
+	// cert-manager options
+	SiteConfigCertManagerIssuerKey     string = "cert-manager-issuer"
+	SiteConfigCertManagerIssuerKindKey string = "cert-manager-issuer-kind"
+
 	//labels:
 	ValidRfc1123Label                = `^(` + ValidRfc1123LabelKey + `)+=(` + ValidRfc1123LabelValue + `)+(,(` + ValidRfc1123LabelKey + `)+=(` + ValidRfc1123LabelValue + `)+)*$`
 	ValidRfc1123LabelKey             = "[a-z0-9]([-._a-z0-9]*[a-z0-9])*"
@@ -169,6 +195,18 @@ func WriteSiteConfig(spec types.SiteConfigSpec, namespace string) (*corev1.Confi
 	if spec.Password != "" {
 		siteConfig.Data[SiteConfigConsolePasswordKey] = spec.Password
 	}
+	if spec.OpenIDIssuer != "" {
+		siteConfig.Data[SiteConfigConsoleOpenIDIssuerKey] = spec.OpenIDIssuer
+	}
+	if spec.OpenIDClientId != "" {
+		siteConfig.Data[SiteConfigConsoleOpenIDClientKey] = spec.OpenIDClientId
+	}
+	if spec.SAMLIdPMetadata != "" {
+		siteConfig.Data[SiteConfigConsoleSAMLMetadataKey] = spec.SAMLIdPMetadata
+	}
+	if spec.SAMLConsoleURL != "" {
+		siteConfig.Data[SiteConfigConsoleSAMLURLKey] = spec.SAMLConsoleURL
+	}
 	if spec.Ingress != "" {
 		siteConfig.Data[SiteConfigIngressKey] = spec.Ingress
 	}
@@ -185,9 +223,15 @@ func WriteSiteConfig(spec types.SiteConfigSpec, namespace string) (*corev1.Confi
 	if spec.IngressHost != "" {
 		siteConfig.Data[SiteConfigIngressHostKey] = spec.IngressHost
 	}
+	if len(spec.CertificateHosts) > 0 {
+		siteConfig.Data[SiteConfigCertificateHostsKey] = strings.Join(spec.CertificateHosts, ",")
+	}
 	if spec.CreateNetworkPolicy {
 		siteConfig.Data[SiteConfigCreateNetworkPolicyKey] = "true"
 	}
+	if spec.ComponentNetworkPolicies {
+		siteConfig.Data[SiteConfigComponentPoliciesKey] = "true"
+	}
 	if spec.RunAsUser != 0 {
 		siteConfig.Data[SiteConfigRunAsUserKey] = strconv.FormatInt(spec.RunAsUser, 10)
 	}
@@ -387,12 +431,51 @@ func WriteSiteConfig(spec types.SiteConfigSpec, namespace string) (*corev1.Confi
 			siteConfig.Data[SiteConfigFlowCollectorMemoryLimitKey] = spec.FlowCollector.MemoryLimit
 		}
 	}
+	if _, err := utils.TlsServerConfig(spec.FlowCollector.TlsMinVersion, spec.FlowCollector.TlsCipherSuites, spec.FlowCollector.TlsCurvePreferences); err != nil {
+		errs = append(errs, fmt.Sprintf("Invalid flow collector tls settings: %s", err))
+	} else {
+		if spec.FlowCollector.TlsMinVersion != "" {
+			siteConfig.Data[SiteConfigFlowCollectorTlsMinVersionKey] = spec.FlowCollector.TlsMinVersion
+		}
+		if spec.FlowCollector.TlsCipherSuites != "" {
+			siteConfig.Data[SiteConfigFlowCollectorTlsCipherSuitesKey] = spec.FlowCollector.TlsCipherSuites
+		}
+		if spec.FlowCollector.TlsCurvePreferences != "" {
+			siteConfig.Data[SiteConfigFlowCollectorTlsCurvePreferencesKey] = spec.FlowCollector.TlsCurvePreferences
+		}
+	}
+	if spec.FlowCollector.AcmeDomains != "" {
+		siteConfig.Data[SiteConfigFlowCollectorAcmeDomainsKey] = spec.FlowCollector.AcmeDomains
+	}
+	if spec.FlowCollector.AcmeEmail != "" {
+		siteConfig.Data[SiteConfigFlowCollectorAcmeEmailKey] = spec.FlowCollector.AcmeEmail
+	}
+	if spec.FlowCollector.AcmeDirectory != "" {
+		siteConfig.Data[SiteConfigFlowCollectorAcmeDirectoryKey] = spec.FlowCollector.AcmeDirectory
+	}
 
 	if spec.EnableSkupperEvents {
 		siteConfig.Data[SiteConfigEnableSkupperEventsKey] = "true"
 	} else {
 		siteConfig.Data[SiteConfigEnableSkupperEventsKey] = "false"
 	}
+	if spec.SyslogAddress != "" {
+		siteConfig.Data[SiteConfigSyslogAddressKey] = spec.SyslogAddress
+	}
+
+	if spec.Vault.Address != "" {
+		siteConfig.Data[SiteConfigVaultAddressKey] = spec.Vault.Address
+		siteConfig.Data[SiteConfigVaultPkiPathKey] = spec.Vault.PkiPath
+		siteConfig.Data[SiteConfigVaultRoleKey] = spec.Vault.Role
+		if spec.Vault.AuthRole != "" {
+			siteConfig.Data[SiteConfigVaultAuthRoleKey] = spec.Vault.AuthRole
+		}
+	}
+
+	if spec.CertManager.Issuer != "" {
+		siteConfig.Data[SiteConfigCertManagerIssuerKey] = spec.CertManager.Issuer
+		siteConfig.Data[SiteConfigCertManagerIssuerKindKey] = spec.CertManager.IssuerKind
+	}
 
 	if spec.PrometheusServer.ExternalServer != "" {
 		siteConfig.Data[SiteConfigPrometheusExternalServerKey] = spec.PrometheusServer.ExternalServer
@@ -543,6 +626,9 @@ func ReadSiteConfig(siteConfig *corev1.ConfigMap, namespace string, defaultIngre
 	} else {
 		result.Spec.CreateNetworkPolicy = false
 	}
+	if componentPolicies, ok := siteConfig.Data[SiteConfigComponentPoliciesKey]; ok {
+		result.Spec.ComponentNetworkPolicies, _ = strconv.ParseBool(componentPolicies)
+	}
 	if authMode, ok := siteConfig.Data[SiteConfigConsoleAuthenticationKey]; ok {
 		result.Spec.AuthMode = authMode
 	} else {
@@ -558,6 +644,10 @@ func ReadSiteConfig(siteConfig *corev1.ConfigMap, namespace string, defaultIngre
 	} else {
 		result.Spec.Password = ""
 	}
+	result.Spec.OpenIDIssuer = siteConfig.Data[SiteConfigConsoleOpenIDIssuerKey]
+	result.Spec.OpenIDClientId = siteConfig.Data[SiteConfigConsoleOpenIDClientKey]
+	result.Spec.SAMLIdPMetadata = siteConfig.Data[SiteConfigConsoleSAMLMetadataKey]
+	result.Spec.SAMLConsoleURL = siteConfig.Data[SiteConfigConsoleSAMLURLKey]
 	if ingress, ok := siteConfig.Data[SiteConfigIngressKey]; ok {
 		result.Spec.Ingress = ingress
 	} else {
@@ -577,6 +667,9 @@ func ReadSiteConfig(siteConfig *corev1.ConfigMap, namespace string, defaultIngre
 	if ingressHost, ok := siteConfig.Data[SiteConfigIngressHostKey]; ok {
 		result.Spec.IngressHost = ingressHost
 	}
+	if certificateHosts, ok := siteConfig.Data[SiteConfigCertificateHostsKey]; ok && certificateHosts != "" {
+		result.Spec.CertificateHosts = strings.Split(certificateHosts, ",")
+	}
 	if runAsUser, ok := siteConfig.Data[SiteConfigRunAsUserKey]; ok {
 		result.Spec.RunAsUser, _ = strconv.ParseInt(runAsUser, 10, 64)
 	}
@@ -723,6 +816,14 @@ func ReadSiteConfig(siteConfig *corev1.ConfigMap, namespace string, defaultIngre
 	if value, ok := siteConfig.Data[SiteConfigEnableSkupperEventsKey]; ok {
 		result.Spec.EnableSkupperEvents, _ = strconv.ParseBool(value)
 	}
+	result.Spec.SyslogAddress = siteConfig.Data[SiteConfigSyslogAddressKey]
+
+	result.Spec.Vault.Address = siteConfig.Data[SiteConfigVaultAddressKey]
+	result.Spec.Vault.PkiPath = siteConfig.Data[SiteConfigVaultPkiPathKey]
+	result.Spec.Vault.Role = siteConfig.Data[SiteConfigVaultRoleKey]
+	result.Spec.Vault.AuthRole = siteConfig.Data[SiteConfigVaultAuthRoleKey]
+	result.Spec.CertManager.Issuer = siteConfig.Data[SiteConfigCertManagerIssuerKey]
+	result.Spec.CertManager.IssuerKind = siteConfig.Data[SiteConfigCertManagerIssuerKindKey]
 
 	if flowCollectorCpu, ok := siteConfig.Data[SiteConfigFlowCollectorCpuKey]; ok && flowCollectorCpu != "" {
 		result.Spec.FlowCollector.Cpu = flowCollectorCpu
@@ -736,6 +837,12 @@ func ReadSiteConfig(siteConfig *corev1.ConfigMap, namespace string, defaultIngre
 	if flowCollectorMemoryLimit, ok := siteConfig.Data[SiteConfigFlowCollectorMemoryLimitKey]; ok && flowCollectorMemoryLimit != "" {
 		result.Spec.FlowCollector.MemoryLimit = flowCollectorMemoryLimit
 	}
+	result.Spec.FlowCollector.TlsMinVersion = siteConfig.Data[SiteConfigFlowCollectorTlsMinVersionKey]
+	result.Spec.FlowCollector.TlsCipherSuites = siteConfig.Data[SiteConfigFlowCollectorTlsCipherSuitesKey]
+	result.Spec.FlowCollector.TlsCurvePreferences = siteConfig.Data[SiteConfigFlowCollectorTlsCurvePreferencesKey]
+	result.Spec.FlowCollector.AcmeDomains = siteConfig.Data[SiteConfigFlowCollectorAcmeDomainsKey]
+	result.Spec.FlowCollector.AcmeEmail = siteConfig.Data[SiteConfigFlowCollectorAcmeEmailKey]
+	result.Spec.FlowCollector.AcmeDirectory = siteConfig.Data[SiteConfigFlowCollectorAcmeDirectoryKey]
 
 	if externalServer, ok := siteConfig.Data[SiteConfigPrometheusExternalServerKey]; ok {
 		result.Spec.PrometheusServer.ExternalServer = externalServer
@@ -819,6 +926,32 @@ func UpdateLogging(config types.SiteConfigSpec, configmap *corev1.ConfigMap) boo
 	return false
 }
 
+func UpdateCertificateHosts(config types.SiteConfigSpec, configmap *corev1.ConfigMap) bool {
+	latestHosts := strings.Join(config.CertificateHosts, ",")
+	if configmap.Data[SiteConfigCertificateHostsKey] == latestHosts {
+		return false
+	}
+	if latestHosts == "" {
+		delete(configmap.Data, SiteConfigCertificateHostsKey)
+	} else {
+		configmap.Data[SiteConfigCertificateHostsKey] = latestHosts
+	}
+	return true
+}
+
+func UpdateComponentNetworkPolicies(config types.SiteConfigSpec, configmap *corev1.ConfigMap) bool {
+	current, _ := strconv.ParseBool(configmap.Data[SiteConfigComponentPoliciesKey])
+	if current == config.ComponentNetworkPolicies {
+		return false
+	}
+	if config.ComponentNetworkPolicies {
+		configmap.Data[SiteConfigComponentPoliciesKey] = "true"
+	} else {
+		delete(configmap.Data, SiteConfigComponentPoliciesKey)
+	}
+	return true
+}
+
 func UpdateForCollectorEnabled(configmap *corev1.ConfigMap) {
 	configmap.Data[SiteConfigConsoleKey] = "true"
 	configmap.Data[SiteConfigFlowCollectorKey] = "true"
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+const attemptLimiterEntries = 10000
+
+type attemptBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+type attemptFailures struct {
+	count       int
+	lockedUntil time.Time
+	last        time.Time
+}
+
+// AttemptLimiter protects an authentication path from brute force: it
+// limits the rate of the attempts made from a source and locks a key out
+// after too many consecutive failures, doubling the lockout every further
+// failure
+type AttemptLimiter struct {
+	rate        float64
+	burst       int
+	maxFailures int
+	lockout     time.Duration
+	maxLockout  time.Duration
+	now         func() time.Time
+
+	lock     sync.Mutex
+	buckets  map[string]*attemptBucket
+	failures map[string]*attemptFailures
+}
+
+// NewAttemptLimiter allows rate attempts per second from a source, up to
+// burst at once, a rate of zero disables the rate limit and maxFailures of
+// zero disables the lockouts
+func NewAttemptLimiter(rate float64, burst int, maxFailures int, lockout time.Duration, maxLockout time.Duration) *AttemptLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	if maxLockout < lockout {
+		maxLockout = lockout
+	}
+	return &AttemptLimiter{
+		rate:        rate,
+		burst:       burst,
+		maxFailures: maxFailures,
+		lockout:     lockout,
+		maxLockout:  maxLockout,
+		now:         time.Now,
+		buckets:     map[string]*attemptBucket{},
+		failures:    map[string]*attemptFailures{},
+	}
+}
+
+// Allow takes an attempt from the source, when none is left it returns
+// false and how long until the next one
+func (l *AttemptLimiter) Allow(source string) (bool, time.Duration) {
+	if l.rate <= 0 {
+		return true, 0
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := l.now()
+	bucket, ok := l.buckets[source]
+	if !ok {
+		l.prune(now)
+		bucket = &attemptBucket{tokens: float64(l.burst), updated: now}
+		l.buckets[source] = bucket
+	}
+	bucket.tokens += now.Sub(bucket.updated).Seconds() * l.rate
+	if bucket.tokens > float64(l.burst) {
+		bucket.tokens = float64(l.burst)
+	}
+	bucket.updated = now
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// Locked returns how long the key remains locked out
+func (l *AttemptLimiter) Locked(key string) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if failures, ok := l.failures[key]; ok {
+		if remaining := failures.lockedUntil.Sub(l.now()); remaining > 0 {
+			return remaining
+		}
+	}
+	return 0
+}
+
+// Failed records a failed attempt for the key and returns the lockout it
+// caused, if any
+func (l *AttemptLimiter) Failed(key string) time.Duration {
+	if l.maxFailures <= 0 {
+		return 0
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := l.now()
+	failures, ok := l.failures[key]
+	if !ok {
+		l.prune(now)
+		failures = &attemptFailures{}
+		l.failures[key] = failures
+	} else if now.After(failures.lockedUntil) && now.Sub(failures.last) > l.maxLockout {
+		// the earlier failures are old enough to be forgiven
+		failures.count = 0
+	}
+	failures.count++
+	failures.last = now
+	if failures.count < l.maxFailures {
+		return 0
+	}
+	lockout := l.lockout
+	for i := l.maxFailures; i < failures.count && lockout < l.maxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > l.maxLockout {
+		lockout = l.maxLockout
+	}
+	failures.lockedUntil = now.Add(lockout)
+	return lockout
+}
+
+// Succeeded forgets the failures of the key
+func (l *AttemptLimiter) Succeeded(key string) {
+	l.lock.Lock()
+	delete(l.failures, key)
+	l.lock.Unlock()
+}
+
+// prune drops the entries that no longer limit anything once there are too
+// many of them, so that many sources cannot grow the limiter without bound
+func (l *AttemptLimiter) prune(now time.Time) {
+	if len(l.buckets) >= attemptLimiterEntries {
+		for source, bucket := range l.buckets {
+			if bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate >= float64(l.burst) {
+				delete(l.buckets, source)
+			}
+		}
+	}
+	if len(l.failures) >= attemptLimiterEntries {
+		for key, failures := range l.failures {
+			if now.After(failures.lockedUntil) && now.Sub(failures.last) > l.maxLockout {
+				delete(l.failures, key)
+			}
+		}
+	}
+}
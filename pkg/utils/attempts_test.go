@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestAttemptLimiterRate(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := NewAttemptLimiter(0.5, 2, 0, 0, 0)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		ok, _ := l.Allow("10.0.0.1")
+		assert.Assert(t, ok)
+	}
+	ok, wait := l.Allow("10.0.0.1")
+	assert.Assert(t, !ok)
+	assert.Equal(t, wait, 2*time.Second)
+
+	// other sources have their own allowance
+	ok, _ = l.Allow("10.0.0.2")
+	assert.Assert(t, ok)
+
+	now = now.Add(2 * time.Second)
+	ok, _ = l.Allow("10.0.0.1")
+	assert.Assert(t, ok)
+	ok, _ = l.Allow("10.0.0.1")
+	assert.Assert(t, !ok)
+
+	// a zero rate disables the limit
+	l = NewAttemptLimiter(0, 0, 0, 0, 0)
+	for i := 0; i < 100; i++ {
+		ok, _ = l.Allow("10.0.0.1")
+		assert.Assert(t, ok)
+	}
+}
+
+func TestAttemptLimiterLockout(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := NewAttemptLimiter(0, 0, 3, time.Minute, 5*time.Minute)
+	l.now = func() time.Time { return now }
+
+	testTable := []struct {
+		doc     string
+		advance time.Duration
+		lockout time.Duration
+	}{
+		{doc: "first", lockout: 0},
+		{doc: "second", lockout: 0},
+		{doc: "third", lockout: time.Minute},
+		{doc: "fourth", advance: time.Minute, lockout: 2 * time.Minute},
+		{doc: "fifth", advance: 2 * time.Minute, lockout: 4 * time.Minute},
+		{doc: "capped", advance: 4 * time.Minute, lockout: 5 * time.Minute},
+		{doc: "forgiven", advance: 11 * time.Minute, lockout: 0},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			now = now.Add(test.advance)
+			assert.Equal(t, l.Locked("admin"), time.Duration(0))
+			assert.Equal(t, l.Failed("admin"), test.lockout)
+			assert.Equal(t, l.Locked("admin"), test.lockout)
+		})
+	}
+
+	l.Failed("viewer")
+	l.Failed("viewer")
+	l.Succeeded("viewer")
+	assert.Equal(t, l.Failed("viewer"), time.Duration(0))
+
+	// zero failures disables the lockouts
+	l = NewAttemptLimiter(0, 0, 0, time.Minute, time.Minute)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, l.Failed("admin"), time.Duration(0))
+	}
+	assert.Equal(t, l.Locked("admin"), time.Duration(0))
+}
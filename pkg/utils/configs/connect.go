@@ -0,0 +1,288 @@
+package configs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/skupperproject/skupper/pkg/encryption"
+)
+
+// ConnectInfoVersion is the latest layout of connect.json, files without a
+// version use the original single endpoint layout
+const ConnectInfoVersion = 2
+
+const (
+	SaslMechanismExternal  = "EXTERNAL"
+	SaslMechanismPlain     = "PLAIN"
+	SaslMechanismAnonymous = "ANONYMOUS"
+)
+
+type ConnectTls struct {
+	Ca     string `json:"ca,omitempty"`
+	Cert   string `json:"cert,omitempty"`
+	Key    string `json:"key,omitempty"`
+	Verify *bool  `json:"verify,omitempty"`
+}
+
+// VerifyHost is true unless verification was explicitly disabled
+func (t *ConnectTls) VerifyHost() bool {
+	return t.Verify == nil || *t.Verify
+}
+
+type ConnectSasl struct {
+	Mechanism    string `json:"mechanism,omitempty"`
+	Username     string `json:"username,omitempty"`
+	Password     string `json:"password,omitempty"`
+	PasswordFile string `json:"passwordFile,omitempty"`
+}
+
+// ConnectPort accepts the port as a json string or number
+type ConnectPort string
+
+func (p *ConnectPort) UnmarshalJSON(data []byte) error {
+	var port interface{}
+	if err := json.Unmarshal(data, &port); err != nil {
+		return err
+	}
+	switch port := port.(type) {
+	case string:
+		*p = ConnectPort(port)
+	case float64:
+		*p = ConnectPort(strconv.FormatFloat(port, 'f', -1, 64))
+	default:
+		return &json.UnmarshalTypeError{Value: string(data), Type: reflect.TypeOf("")}
+	}
+	return nil
+}
+
+type ConnectEndpoint struct {
+	Scheme string       `json:"scheme,omitempty"`
+	Host   string       `json:"host,omitempty"`
+	Port   ConnectPort  `json:"port,omitempty"`
+	Tls    *ConnectTls  `json:"tls,omitempty"`
+	Sasl   *ConnectSasl `json:"sasl,omitempty"`
+}
+
+func (e *ConnectEndpoint) Url() string {
+	return e.Scheme + "://" + e.Host + ":" + string(e.Port)
+}
+
+type ConnectInfo struct {
+	Version   int               `json:"version,omitempty"`
+	Endpoints []ConnectEndpoint `json:"endpoints,omitempty"`
+}
+
+// connectFile holds both layouts, the endpoint fields at the top level
+// are the original layout
+type connectFile struct {
+	Version int `json:"version"`
+	ConnectEndpoint
+	Endpoints []ConnectEndpoint `json:"endpoints"`
+}
+
+// LoadConnectInfo reads and validates a connect.json file of any version,
+// the values of the environment variables starting with envPrefix
+// override those of the file
+func LoadConnectInfo(file string, envPrefix string) (*ConnectInfo, error) {
+	data, err := encryption.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	info, err := ParseConnectInfo(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if err := info.applyEnv(envPrefix); err != nil {
+		return nil, err
+	}
+	if err := info.validate(filepath.Dir(file)); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return info, nil
+}
+
+// ParseConnectInfo decodes either layout into the latest one
+func ParseConnectInfo(data []byte) (*ConnectInfo, error) {
+	cf := connectFile{}
+	if err := json.Unmarshal(data, &cf); err != nil {
+		return nil, describeJsonError(err)
+	}
+	info := &ConnectInfo{Version: cf.Version}
+	switch {
+	case cf.Version > ConnectInfoVersion:
+		return nil, fmt.Errorf("unsupported version %d, the latest supported is %d", cf.Version, ConnectInfoVersion)
+	case cf.Version < 0:
+		return nil, fmt.Errorf("invalid version %d", cf.Version)
+	case cf.Version < ConnectInfoVersion:
+		if len(cf.Endpoints) > 0 {
+			return nil, fmt.Errorf("endpoints require version %d", ConnectInfoVersion)
+		}
+		info.Endpoints = []ConnectEndpoint{cf.ConnectEndpoint}
+	default:
+		if cf.Host != "" || cf.Scheme != "" || cf.Port != "" {
+			return nil, fmt.Errorf("scheme, host and port belong to the endpoints in version %d", ConnectInfoVersion)
+		}
+		info.Endpoints = cf.Endpoints
+	}
+	return info, nil
+}
+
+func describeJsonError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := typeErr.Field
+		if field == "" {
+			return fmt.Errorf("invalid value %s", typeErr.Value)
+		}
+		return fmt.Errorf("field %q has the wrong type, %s is not a %s", field, typeErr.Value, typeErr.Type)
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Errorf("invalid json at offset %d: %w", syntaxErr.Offset, err)
+	}
+	return err
+}
+
+// applyEnv applies the <prefix>ENDPOINTS (comma separated urls),
+// <prefix>SASL_MECHANISM, <prefix>SASL_USERNAME and <prefix>SASL_PASSWORD
+// overrides, the tls and sasl settings of the first endpoint are kept for
+// the endpoints replaced by <prefix>ENDPOINTS
+func (c *ConnectInfo) applyEnv(prefix string) error {
+	if prefix == "" {
+		return nil
+	}
+	if value := os.Getenv(prefix + "ENDPOINTS"); value != "" {
+		var template ConnectEndpoint
+		if len(c.Endpoints) > 0 {
+			template = c.Endpoints[0]
+		}
+		endpoints := []ConnectEndpoint{}
+		for _, url := range strings.Split(value, ",") {
+			endpoint := template
+			scheme, address, ok := strings.Cut(strings.TrimSpace(url), "://")
+			if !ok {
+				return fmt.Errorf("invalid %sENDPOINTS url %q, expected scheme://host:port", prefix, url)
+			}
+			host, port, ok := strings.Cut(address, ":")
+			if !ok {
+				return fmt.Errorf("invalid %sENDPOINTS url %q, expected scheme://host:port", prefix, url)
+			}
+			endpoint.Scheme, endpoint.Host, endpoint.Port = scheme, host, ConnectPort(port)
+			endpoints = append(endpoints, endpoint)
+		}
+		c.Endpoints = endpoints
+	}
+	mechanism := os.Getenv(prefix + "SASL_MECHANISM")
+	username := os.Getenv(prefix + "SASL_USERNAME")
+	password := os.Getenv(prefix + "SASL_PASSWORD")
+	if mechanism == "" && username == "" && password == "" {
+		return nil
+	}
+	for i := range c.Endpoints {
+		sasl := ConnectSasl{}
+		if c.Endpoints[i].Sasl != nil {
+			sasl = *c.Endpoints[i].Sasl
+		}
+		if mechanism != "" {
+			sasl.Mechanism = mechanism
+		}
+		if username != "" {
+			sasl.Username = username
+		}
+		if password != "" {
+			sasl.Password, sasl.PasswordFile = password, ""
+		}
+		c.Endpoints[i].Sasl = &sasl
+	}
+	return nil
+}
+
+// validate checks every endpoint and fills in the defaults, the tls files
+// default to those next to connect.json
+func (c *ConnectInfo) validate(dir string) error {
+	if len(c.Endpoints) == 0 {
+		return fmt.Errorf("no endpoints")
+	}
+	for i := range c.Endpoints {
+		if err := c.Endpoints[i].validate(dir); err != nil {
+			if len(c.Endpoints) == 1 && c.Version < ConnectInfoVersion {
+				return err
+			}
+			return fmt.Errorf("endpoints[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (e *ConnectEndpoint) validate(dir string) error {
+	if e.Host == "" {
+		return fmt.Errorf("host is required")
+	}
+	switch e.Scheme {
+	case "":
+		e.Scheme = "amqps"
+	case "amqp", "amqps":
+	default:
+		return fmt.Errorf("unsupported scheme %q, expected amqp or amqps", e.Scheme)
+	}
+	if e.Port == "" {
+		e.Port = "5672"
+		if e.Scheme == "amqps" {
+			e.Port = "5671"
+		}
+	}
+	if port, err := strconv.Atoi(string(e.Port)); err != nil || port <= 0 || port > 65535 {
+		return fmt.Errorf("invalid port %q", e.Port)
+	}
+	if e.Scheme == "amqps" {
+		if e.Tls == nil {
+			e.Tls = &ConnectTls{}
+		}
+		e.Tls.Ca = defaultPath(e.Tls.Ca, dir, "ca.crt")
+		e.Tls.Cert = defaultPath(e.Tls.Cert, dir, "tls.crt")
+		e.Tls.Key = defaultPath(e.Tls.Key, dir, "tls.key")
+	} else {
+		// tls settings were ignored for amqp before they were validated
+		e.Tls = nil
+	}
+	if e.Sasl == nil {
+		return nil
+	}
+	e.Sasl.Mechanism = strings.ToUpper(e.Sasl.Mechanism)
+	switch e.Sasl.Mechanism {
+	case "":
+		return fmt.Errorf("sasl.mechanism is required")
+	case SaslMechanismExternal:
+		if e.Tls == nil {
+			return fmt.Errorf("sasl mechanism %s requires the amqps scheme", SaslMechanismExternal)
+		}
+	case SaslMechanismPlain:
+		if e.Sasl.Username == "" {
+			return fmt.Errorf("sasl.username is required for the %s mechanism", SaslMechanismPlain)
+		}
+		if e.Sasl.Password == "" && e.Sasl.PasswordFile != "" {
+			password, err := os.ReadFile(e.Sasl.PasswordFile)
+			if err != nil {
+				return fmt.Errorf("reading sasl.passwordFile: %w", err)
+			}
+			e.Sasl.Password = strings.TrimSpace(string(password))
+		}
+	case SaslMechanismAnonymous:
+	default:
+		return fmt.Errorf("unsupported sasl mechanism %q", e.Sasl.Mechanism)
+	}
+	return nil
+}
+
+func defaultPath(value string, dir string, name string) string {
+	if value != "" {
+		return value
+	}
+	return filepath.Join(dir, name)
+}
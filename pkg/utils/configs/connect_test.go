@@ -0,0 +1,179 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestLoadConnectInfo(t *testing.T) {
+	verify := false
+	testcases := []struct {
+		title    string
+		content  string
+		env      map[string]string
+		expected []ConnectEndpoint
+		err      string
+	}{
+		{
+			title:   "generated",
+			content: ConnectJson("skupper-router-local.test.svc.cluster.local"),
+			expected: []ConnectEndpoint{{
+				Scheme: "amqps",
+				Host:   "skupper-router-local.test.svc.cluster.local",
+				Port:   "5671",
+				Tls:    &ConnectTls{Ca: "/etc/messaging/ca.crt", Cert: "/etc/messaging/tls.crt", Key: "/etc/messaging/tls.key", Verify: boolPtr(true)},
+			}},
+		},
+		{
+			title:   "legacy-defaults",
+			content: `{"host": "router", "port": 5671}`,
+			expected: []ConnectEndpoint{{
+				Scheme: "amqps",
+				Host:   "router",
+				Port:   "5671",
+				Tls:    &ConnectTls{Ca: "DIR/ca.crt", Cert: "DIR/tls.crt", Key: "DIR/tls.key"},
+			}},
+		},
+		{
+			title:   "legacy-amqp-ignores-tls",
+			content: `{"scheme": "amqp", "host": "router", "tls": {"ca": "/ca.crt"}}`,
+			expected: []ConnectEndpoint{{
+				Scheme: "amqp",
+				Host:   "router",
+				Port:   "5672",
+			}},
+		},
+		{
+			title: "endpoints",
+			content: `{"version": 2, "endpoints": [
+				{"host": "router-a", "tls": {"ca": "/ca.crt", "cert": "/tls.crt", "key": "/tls.key", "verify": false}, "sasl": {"mechanism": "external"}},
+				{"scheme": "amqp", "host": "router-b", "port": "15672", "sasl": {"mechanism": "PLAIN", "username": "collector", "password": "secret"}}
+			]}`,
+			expected: []ConnectEndpoint{
+				{
+					Scheme: "amqps",
+					Host:   "router-a",
+					Port:   "5671",
+					Tls:    &ConnectTls{Ca: "/ca.crt", Cert: "/tls.crt", Key: "/tls.key", Verify: &verify},
+					Sasl:   &ConnectSasl{Mechanism: SaslMechanismExternal},
+				},
+				{
+					Scheme: "amqp",
+					Host:   "router-b",
+					Port:   "15672",
+					Sasl:   &ConnectSasl{Mechanism: SaslMechanismPlain, Username: "collector", Password: "secret"},
+				},
+			},
+		},
+		{
+			title:   "env-overrides",
+			content: `{"scheme": "amqp", "host": "router"}`,
+			env: map[string]string{
+				"TEST_CONNECT_ENDPOINTS":      "amqp://router-a:5672, amqp://router-b:5673",
+				"TEST_CONNECT_SASL_MECHANISM": "plain",
+				"TEST_CONNECT_SASL_USERNAME":  "collector",
+				"TEST_CONNECT_SASL_PASSWORD":  "secret",
+			},
+			expected: []ConnectEndpoint{
+				{Scheme: "amqp", Host: "router-a", Port: "5672", Sasl: &ConnectSasl{Mechanism: SaslMechanismPlain, Username: "collector", Password: "secret"}},
+				{Scheme: "amqp", Host: "router-b", Port: "5673", Sasl: &ConnectSasl{Mechanism: SaslMechanismPlain, Username: "collector", Password: "secret"}},
+			},
+		},
+		{
+			title:   "missing-host",
+			content: `{"scheme": "amqps", "port": "5671"}`,
+			err:     "host is required",
+		},
+		{
+			title:   "missing-endpoint-host",
+			content: `{"version": 2, "endpoints": [{"host": "router"}, {"port": "5671"}]}`,
+			err:     "endpoints[1]: host is required",
+		},
+		{
+			title:   "wrong-type",
+			content: `{"host": "router", "tls": {"verify": "yes"}}`,
+			err:     `field "tls.verify" has the wrong type, string is not a bool`,
+		},
+		{
+			title:   "invalid-port",
+			content: `{"host": "router", "port": "amqps"}`,
+			err:     `invalid port "amqps"`,
+		},
+		{
+			title:   "future-version",
+			content: `{"version": 3, "endpoints": []}`,
+			err:     "unsupported version 3",
+		},
+		{
+			title:   "endpoints-without-version",
+			content: `{"endpoints": [{"host": "router"}]}`,
+			err:     "endpoints require version 2",
+		},
+		{
+			title:   "plain-without-username",
+			content: `{"version": 2, "endpoints": [{"host": "router", "sasl": {"mechanism": "PLAIN"}}]}`,
+			err:     "endpoints[0]: sasl.username is required",
+		},
+		{
+			title:   "external-without-tls",
+			content: `{"scheme": "amqp", "host": "router", "sasl": {"mechanism": "EXTERNAL"}}`,
+			err:     "requires the amqps scheme",
+		},
+		{
+			title:   "invalid-env-endpoint",
+			content: `{"host": "router"}`,
+			env:     map[string]string{"TEST_CONNECT_ENDPOINTS": "router:5671"},
+			err:     "invalid TEST_CONNECT_ENDPOINTS url",
+		},
+	}
+	for _, c := range testcases {
+		t.Run(c.title, func(t *testing.T) {
+			for _, name := range []string{"ENDPOINTS", "SASL_MECHANISM", "SASL_USERNAME", "SASL_PASSWORD"} {
+				t.Setenv("TEST_CONNECT_"+name, c.env["TEST_CONNECT_"+name])
+			}
+			dir := t.TempDir()
+			file := filepath.Join(dir, "connect.json")
+			assert.Assert(t, os.WriteFile(file, []byte(c.content), 0600))
+			info, err := LoadConnectInfo(file, "TEST_CONNECT_")
+			if c.err != "" {
+				assert.ErrorContains(t, err, c.err)
+				return
+			}
+			assert.Assert(t, err)
+			for _, endpoint := range c.expected {
+				if endpoint.Tls != nil {
+					endpoint.Tls.Ca = replaceDir(endpoint.Tls.Ca, dir)
+					endpoint.Tls.Cert = replaceDir(endpoint.Tls.Cert, dir)
+					endpoint.Tls.Key = replaceDir(endpoint.Tls.Key, dir)
+				}
+			}
+			assert.DeepEqual(t, info.Endpoints, c.expected)
+		})
+	}
+}
+
+func TestConnectSaslPasswordFile(t *testing.T) {
+	dir := t.TempDir()
+	assert.Assert(t, os.WriteFile(filepath.Join(dir, "password"), []byte("secret\n"), 0600))
+	file := filepath.Join(dir, "connect.json")
+	content := `{"version": 2, "endpoints": [{"scheme": "amqp", "host": "router", "sasl": {"mechanism": "PLAIN", "username": "collector", "passwordFile": "` + filepath.Join(dir, "password") + `"}}]}`
+	assert.Assert(t, os.WriteFile(file, []byte(content), 0600))
+	info, err := LoadConnectInfo(file, "")
+	assert.Assert(t, err)
+	assert.Equal(t, info.Endpoints[0].Sasl.Password, "secret")
+	assert.Equal(t, info.Endpoints[0].Url(), "amqp://router:5672")
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func replaceDir(path string, dir string) string {
+	if filepath.Dir(path) == "DIR" {
+		return filepath.Join(dir, filepath.Base(path))
+	}
+	return path
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestVerifyHtpasswd(t *testing.T) {
+	testTable := []struct {
+		doc      string
+		hash     string
+		password string
+		valid    bool
+	}{
+		{doc: "apr1", hash: "$apr1$r31.....$HqJZimcKQFAMYayBlzkrA/", password: "myPassword", valid: true},
+		{doc: "apr1-wrong", hash: "$apr1$r31.....$HqJZimcKQFAMYayBlzkrA/", password: "myPasswore", valid: false},
+		{doc: "apr1-long", hash: "$apr1$abcdefgh$CWmSdRXg6.q2WlUC6/oKv1", password: "a much longer password than sixteen", valid: true},
+		{doc: "apr1-malformed", hash: "$apr1$abcdefgh", password: "", valid: false},
+		{doc: "sha1", hash: "{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=", password: "password", valid: true},
+		{doc: "sha1-wrong", hash: "{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=", password: "Password", valid: false},
+		{doc: "plain", hash: "secret", password: "secret", valid: true},
+		{doc: "plain-wrong", hash: "secret", password: "secrets", valid: false},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			assert.Equal(t, VerifyHtpasswd(test.hash, test.password), test.valid)
+		})
+	}
+}
+
+func TestParseHtpasswd(t *testing.T) {
+	users, err := ParseHtpasswd(strings.NewReader("# console users\nadmin:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n\n  viewer:$apr1$r31.....$HqJZimcKQFAMYayBlzkrA/\n"))
+	assert.Assert(t, err)
+	assert.DeepEqual(t, users, map[string]string{
+		"admin":  "{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=",
+		"viewer": "$apr1$r31.....$HqJZimcKQFAMYayBlzkrA/",
+	})
+
+	_, err = ParseHtpasswd(strings.NewReader("admin:secret\nviewer\n"))
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestHtpasswdReload(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "htpasswd")
+	assert.Assert(t, os.WriteFile(filename, []byte("admin:secret\n"), 0600))
+	h, err := NewHtpasswd(filename)
+	assert.Assert(t, err)
+
+	ok, err := h.Authenticate("admin", "secret")
+	assert.Assert(t, err)
+	assert.Assert(t, ok)
+	ok, _ = h.Authenticate("viewer", "secret")
+	assert.Assert(t, !ok)
+
+	assert.Assert(t, os.WriteFile(filename, []byte("admin:changed\nviewer:secret\n"), 0600))
+	later := time.Now().Add(time.Minute)
+	assert.Assert(t, os.Chtimes(filename, later, later))
+	ok, _ = h.Authenticate("admin", "secret")
+	assert.Assert(t, !ok)
+	ok, _ = h.Authenticate("viewer", "secret")
+	assert.Assert(t, ok)
+
+	// the last users read are kept when the file goes away
+	assert.Assert(t, os.Remove(filename))
+	ok, err = h.Authenticate("viewer", "secret")
+	assert.Assert(t, ok)
+	assert.Assert(t, err != nil)
+
+	_, err = NewHtpasswd(filename)
+	assert.Assert(t, err != nil)
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestHashPassword(t *testing.T) {
+	for _, scheme := range ValidPasswordHashes() {
+		t.Run(scheme, func(t *testing.T) {
+			hash, err := HashPassword("s3cret", scheme)
+			assert.Assert(t, err)
+			assert.Assert(t, !strings.Contains(hash, "s3cret"))
+			assert.Assert(t, IsPasswordHash(hash))
+			assert.Assert(t, VerifyPassword(hash, "s3cret"))
+			assert.Assert(t, VerifyPassword(hash+"\n", "s3cret"))
+			assert.Assert(t, !VerifyPassword(hash, "s3cret "))
+			assert.Assert(t, !VerifyPassword(hash, ""))
+		})
+	}
+	_, err := HashPassword("s3cret", "md5")
+	assert.ErrorContains(t, err, "invalid password hash")
+}
+
+func TestVerifyPassword(t *testing.T) {
+	testTable := []struct {
+		name     string
+		stored   string
+		password string
+		expected bool
+	}{
+		{name: "plain", stored: "s3cret", password: "s3cret", expected: true},
+		{name: "plain-mismatch", stored: "s3cret", password: "S3cret", expected: false},
+		{name: "bcrypt-malformed", stored: "$2a$10$short", password: "s3cret", expected: false},
+		{name: "argon2id-missing-fields", stored: "$argon2id$v=19$m=65536,t=3,p=4$c2FsdA", password: "s3cret", expected: false},
+		{name: "argon2id-bad-version", stored: "$argon2id$v=16$m=65536,t=3,p=4$c2FsdHNhbHRzYWx0$a2V5", password: "s3cret", expected: false},
+		{name: "argon2id-bad-params", stored: "$argon2id$v=19$m=65536,t=0,p=4$c2FsdHNhbHRzYWx0$a2V5", password: "s3cret", expected: false},
+		{name: "argon2id-bad-salt", stored: "$argon2id$v=19$m=65536,t=3,p=4$!!$a2V5", password: "s3cret", expected: false},
+	}
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, VerifyPassword(test.stored, test.password), test.expected)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+
+	"github.com/skupperproject/skupper/pkg/fips"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var tlsCurves = map[string]tls.CurveID{
+	"x25519": tls.X25519,
+	"p256":   tls.CurveP256,
+	"p384":   tls.CurveP384,
+	"p521":   tls.CurveP521,
+}
+
+func splitTlsList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ParseTlsVersion accepts 1.2 or 1.3, with an optional TLS prefix, older
+// versions are not supported
+func ParseTlsVersion(value string) (uint16, error) {
+	version := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(value)), "tls"), "v")
+	if id, ok := tlsVersions[strings.TrimSpace(version)]; ok {
+		return id, nil
+	}
+	return 0, fmt.Errorf("unsupported tls version %q, it must be 1.2 or 1.3", value)
+}
+
+// ParseTlsCipherSuites reads a comma separated list of cipher suite names,
+// as in TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, the insecure suites are
+// refused. The list only applies to tls 1.2, the tls 1.3 suites are not
+// configurable.
+func ParseTlsCipherSuites(value string) ([]uint16, error) {
+	suites := []uint16{}
+	for _, name := range splitTlsList(value) {
+		found := false
+		for _, suite := range tls.CipherSuites() {
+			if strings.EqualFold(suite.Name, name) {
+				suites = append(suites, suite.ID)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unsupported or insecure cipher suite %q", name)
+		}
+	}
+	return suites, nil
+}
+
+// ParseTlsCurvePreferences reads a comma separated list of X25519, P256,
+// P384 and P521, in order of preference
+func ParseTlsCurvePreferences(value string) ([]tls.CurveID, error) {
+	curves := []tls.CurveID{}
+	for _, name := range splitTlsList(value) {
+		curve, ok := tlsCurves[strings.ReplaceAll(strings.ToLower(name), "-", "")]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %q", name)
+		}
+		curves = append(curves, curve)
+	}
+	return curves, nil
+}
+
+// TlsServerConfig returns a server configuration with the minimum version,
+// cipher suites and curve preferences given, tls 1.2 being the default
+// minimum version. In FIPS mode only the approved versions, suites and
+// curves are accepted.
+func TlsServerConfig(minVersion string, cipherSuites string, curvePreferences string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if minVersion != "" {
+		version, err := ParseTlsVersion(minVersion)
+		if err != nil {
+			return nil, err
+		}
+		if err := fips.ValidateTlsVersion(version); err != nil {
+			return nil, err
+		}
+		config.MinVersion = version
+	}
+	if cipherSuites != "" {
+		suites, err := ParseTlsCipherSuites(cipherSuites)
+		if err != nil {
+			return nil, err
+		}
+		for _, suite := range suites {
+			if err := fips.ValidateCipherSuite(suite); err != nil {
+				return nil, err
+			}
+		}
+		config.CipherSuites = suites
+	}
+	if curvePreferences != "" {
+		curves, err := ParseTlsCurvePreferences(curvePreferences)
+		if err != nil {
+			return nil, err
+		}
+		for _, curve := range curves {
+			if err := fips.ValidateCurve(curve); err != nil {
+				return nil, err
+			}
+		}
+		config.CurvePreferences = curves
+	}
+	fips.RestrictTls(config)
+	return config, nil
+}
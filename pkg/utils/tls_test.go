@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/skupperproject/skupper/pkg/fips"
+)
+
+func TestTlsServerConfig(t *testing.T) {
+	testTable := []struct {
+		doc          string
+		minVersion   string
+		cipherSuites string
+		curves       string
+		expected     *tls.Config
+		err          string
+	}{
+		{doc: "defaults", expected: &tls.Config{MinVersion: tls.VersionTLS12}},
+		{doc: "tls13", minVersion: "TLS1.3", expected: &tls.Config{MinVersion: tls.VersionTLS13}},
+		{doc: "tls12", minVersion: "1.2", expected: &tls.Config{MinVersion: tls.VersionTLS12}},
+		{doc: "tls10", minVersion: "1.0", err: "unsupported tls version"},
+		{
+			doc:          "ciphers",
+			cipherSuites: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls_ecdhe_ecdsa_with_chacha20_poly1305_sha256",
+			expected: &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256},
+			},
+		},
+		{doc: "insecure cipher", cipherSuites: "TLS_RSA_WITH_RC4_128_SHA", err: "unsupported or insecure cipher suite"},
+		{
+			doc:    "curves",
+			curves: "X25519,P-384",
+			expected: &tls.Config{
+				MinVersion:       tls.VersionTLS12,
+				CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP384},
+			},
+		},
+		{doc: "bad curve", curves: "P224", err: "unsupported curve"},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			config, err := TlsServerConfig(test.minVersion, test.cipherSuites, test.curves)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			assert.Equal(t, config.MinVersion, test.expected.MinVersion)
+			assert.DeepEqual(t, config.CipherSuites, test.expected.CipherSuites)
+			assert.DeepEqual(t, config.CurvePreferences, test.expected.CurvePreferences)
+		})
+	}
+}
+
+func TestTlsServerConfigFips(t *testing.T) {
+	t.Setenv(fips.EnvVar, "true")
+	testTable := []struct {
+		doc          string
+		minVersion   string
+		cipherSuites string
+		curves       string
+		expected     *tls.Config
+		err          string
+	}{
+		{
+			doc: "defaults",
+			expected: &tls.Config{
+				MinVersion:       tls.VersionTLS12,
+				CipherSuites:     fips.CipherSuites,
+				CurvePreferences: fips.CurvePreferences,
+			},
+		},
+		{doc: "tls13", minVersion: "1.3", err: "only tls 1.2 is allowed in FIPS mode"},
+		{
+			doc:          "approved cipher",
+			cipherSuites: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+			curves:       "P-256",
+			expected: &tls.Config{
+				MinVersion:       tls.VersionTLS12,
+				CipherSuites:     []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+				CurvePreferences: []tls.CurveID{tls.CurveP256},
+			},
+		},
+		{doc: "chacha20", cipherSuites: "TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256", err: "is not allowed in FIPS mode"},
+		{doc: "x25519", curves: "X25519", err: "curve X25519 is not allowed in FIPS mode"},
+	}
+	for _, test := range testTable {
+		t.Run(test.doc, func(t *testing.T) {
+			config, err := TlsServerConfig(test.minVersion, test.cipherSuites, test.curves)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+			assert.Assert(t, err)
+			assert.Equal(t, config.MinVersion, test.expected.MinVersion)
+			assert.Equal(t, config.MaxVersion, uint16(tls.VersionTLS12))
+			assert.DeepEqual(t, config.CipherSuites, test.expected.CipherSuites)
+			assert.DeepEqual(t, config.CurvePreferences, test.expected.CurvePreferences)
+		})
+	}
+}